@@ -2,27 +2,25 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/debug"
+	"github.com/aykay76/ai-idp/internal/healthcheck"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/metrics"
 	"github.com/aykay76/ai-idp/internal/middleware"
 	"github.com/aykay76/ai-idp/internal/proxy"
+	"github.com/aykay76/ai-idp/internal/ratelimit"
 )
 
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Service   string    `json:"service"`
-	Version   string    `json:"version,omitempty"`
-}
-
 // getEnvWithDefault gets an environment variable or returns a default value
 func getEnvWithDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -36,7 +34,14 @@ func main() {
 	cfg := config.Load()
 
 	// Initialize logger
-	appLogger := logger.New(cfg.Logging.Level, cfg.Logging.Format)
+	appLogger := logger.NewWithOptions(cfg.Logging.Level, cfg.Logging.Format, logger.Options{
+		Async:       cfg.Logging.Async,
+		BufferSize:  int(cfg.Logging.BufferSize),
+		Destination: cfg.Logging.Output,
+		FilePath:    cfg.Logging.FilePath,
+		MaxSizeMB:   int(cfg.Logging.MaxSizeMB),
+		MaxBackups:  int(cfg.Logging.MaxBackups),
+	})
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "api-gateway",
 		"port":                cfg.Server.Port,
@@ -45,90 +50,88 @@ func main() {
 	// Create HTTP server mux
 	mux := http.NewServeMux()
 
-	// Health check endpoint
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "healthy",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-api-gateway",
-			Version:   os.Getenv("VERSION"),
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode health response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
-
+	// Shadow traffic targets, e.g. "/api/v1/applications|http://application-canary:8082|10"
+	shadowTargets, err := proxy.ParseShadowTargets(os.Getenv("PROXY_SHADOW_TARGETS"))
+	if err != nil {
 		appLogger.WithFields(logger.LogFields{
-			logger.FieldHTTPMethod: r.Method,
-			logger.FieldHTTPPath:   r.URL.Path,
-			logger.FieldHTTPStatus: http.StatusOK,
-		}).Debug("Health check requested")
-	})
-
-	// Readiness check endpoint (for Kubernetes)
-	mux.HandleFunc("GET /readiness", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "ready",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-api-gateway",
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode readiness response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
+			logger.FieldComponent: "api-gateway",
+			logger.FieldError:     err.Error(),
+		}).Error("Invalid shadow traffic configuration")
+		_ = appLogger.Shutdown(context.Background())
+		os.Exit(1)
+	}
 
+	// Additional backends, e.g. "/api/v1/users|http://user-service:8084|user-service"
+	extraRoutes, err := proxy.ParseRoutes(os.Getenv("PROXY_ROUTES"))
+	if err != nil {
 		appLogger.WithFields(logger.LogFields{
-			logger.FieldHTTPMethod: r.Method,
-			logger.FieldHTTPPath:   r.URL.Path,
-			logger.FieldHTTPStatus: http.StatusOK,
-		}).Debug("Readiness check requested")
-	})
+			logger.FieldComponent: "api-gateway",
+			logger.FieldError:     err.Error(),
+		}).Error("Invalid proxy route configuration")
+		_ = appLogger.Shutdown(context.Background())
+		os.Exit(1)
+	}
 
-	// Liveness check endpoint (for Kubernetes)
-	mux.HandleFunc("GET /liveness", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "alive",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-api-gateway",
+	// Circuit breaker, connection pool and retry tuning; unset values use the proxy
+	// defaults, except that idempotent requests are retried twice
+	var envErrs []error
+	intEnv := func(key, defaultValue string) int {
+		value, err := strconv.Atoi(getEnvWithDefault(key, defaultValue))
+		if err != nil {
+			envErrs = append(envErrs, fmt.Errorf("%s: %w", key, err))
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode liveness response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+		return value
+	}
+	durationEnv := func(key, defaultValue string) time.Duration {
+		value, err := time.ParseDuration(getEnvWithDefault(key, defaultValue))
+		if err != nil {
+			envErrs = append(envErrs, fmt.Errorf("%s: %w", key, err))
 		}
-
+		return value
+	}
+	breakerFailures := intEnv("PROXY_BREAKER_FAILURES", "0")
+	breakerCooldown := durationEnv("PROXY_BREAKER_COOLDOWN", "0s")
+	proxyTimeout := durationEnv("PROXY_TIMEOUT", "0s")
+	maxIdleConns := intEnv("PROXY_MAX_IDLE_CONNS", "0")
+	maxIdleConnsPerHost := intEnv("PROXY_MAX_IDLE_CONNS_PER_HOST", "0")
+	idleConnTimeout := durationEnv("PROXY_IDLE_CONN_TIMEOUT", "0s")
+	maxRetries := intEnv("PROXY_MAX_RETRIES", "2")
+	retryBaseDelay := durationEnv("PROXY_RETRY_BASE_DELAY", "0s")
+	readinessTimeout := durationEnv("READINESS_PROBE_TIMEOUT", "0s")
+	readinessCacheTTL := durationEnv("READINESS_CACHE_TTL", "0s")
+	if err := errors.Join(envErrs...); err != nil {
 		appLogger.WithFields(logger.LogFields{
-			logger.FieldHTTPMethod: r.Method,
-			logger.FieldHTTPPath:   r.URL.Path,
-			logger.FieldHTTPStatus: http.StatusOK,
-		}).Debug("Liveness check requested")
-	})
+			logger.FieldComponent: "api-gateway",
+			logger.FieldError:     err.Error(),
+		}).Error("Invalid proxy configuration")
+		_ = appLogger.Shutdown(context.Background())
+		os.Exit(1)
+	}
 
 	// Setup proxy configuration
 	proxyConfig := &proxy.ProxyConfig{
-		ApplicationServiceURL: getEnvWithDefault("APPLICATION_SERVICE_URL", "http://localhost:8082"),
-		TeamServiceURL:        getEnvWithDefault("TEAM_SERVICE_URL", "http://localhost:8083"),
-		Logger:                appLogger,
+		ApplicationServiceURL:   getEnvWithDefault("APPLICATION_SERVICE_URL", "http://localhost:8082"),
+		TeamServiceURL:          getEnvWithDefault("TEAM_SERVICE_URL", "http://localhost:8083"),
+		TenantServiceURL:        getEnvWithDefault("TENANT_SERVICE_URL", "http://localhost:8085"),
+		UserServiceURL:          getEnvWithDefault("USER_SERVICE_URL", "http://localhost:8084"),
+		Logger:                  appLogger,
+		DisableFramingChecks:    getEnvWithDefault("PROXY_FRAMING_CHECKS", "true") == "false",
+		ShadowTargets:           shadowTargets,
+		BreakerFailureThreshold: breakerFailures,
+		BreakerCooldown:         breakerCooldown,
+		Timeout:                 proxyTimeout,
+		MaxIdleConns:            maxIdleConns,
+		MaxIdleConnsPerHost:     maxIdleConnsPerHost,
+		IdleConnTimeout:         idleConnTimeout,
+		MaxRetries:              maxRetries,
+		RetryBaseDelay:          retryBaseDelay,
+		ReadinessTimeout:        readinessTimeout,
+		ReadinessCacheTTL:       readinessCacheTTL,
+	}
+
+	// Configured routes take precedence over the built-in services for the same prefix
+	if len(extraRoutes) > 0 {
+		proxyConfig.Routes = append(extraRoutes, proxy.DefaultRoutes(proxyConfig)...)
 	}
 
 	// Create proxy handler
@@ -137,9 +140,69 @@ func main() {
 	// Add proxy routes for API endpoints
 	mux.Handle("/api/", proxyHandler)
 
-	// Apply middleware chain
-	handler := middleware.RequestID(mux)
-	handler = middleware.Logging(appLogger)(handler)
+	// Health and probe endpoints (for Kubernetes); ready only while every backend is healthy
+	healthcheck.RegisterWithReadiness(mux, "ai-idp-api-gateway", proxy.NewReadiness(proxyConfig))
+
+	// Aggregation endpoints fan out to several backends and tolerate partial failure
+	aggregator := proxy.NewAggregator(proxyConfig)
+	mux.HandleFunc("GET /health/services", aggregator.ServicesHealth)
+	mux.HandleFunc("GET /openapi.json", aggregator.OpenAPI)
+	mux.Handle("GET /api/v1/admin/applications",
+		middleware.RequireAdmin(cfg.Security.AdminToken)(http.HandlerFunc(aggregator.FleetApplications)))
+
+	// Profiling endpoints (admin only, disabled unless ENABLE_PROFILING is set)
+	if debug.RegisterProfiling(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "api-gateway",
+		}).Warn("Profiling endpoints enabled under /debug/pprof/")
+	}
+
+	// Effective configuration, secrets redacted (admin only, disabled unless ENABLE_DEBUG_CONFIG is set)
+	if debug.RegisterConfig(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "api-gateway",
+		}).Warn("Config endpoint enabled at /debug/config")
+	}
+
+	// Prometheus metrics, labelled by route pattern
+	metricsRegistry := metrics.NewRegistry()
+	mux.Handle("GET /metrics", metricsRegistry.Handler())
+
+	// Count requests so shutdown can wait for them
+	inFlight := middleware.NewInFlight()
+	loggingOptions := []middleware.LoggingOption{middleware.WithSampleRate(int(cfg.Logging.SampleRate))}
+	if cfg.Logging.SkipProbes {
+		loggingOptions = append(loggingOptions, middleware.WithoutPaths(middleware.ProbePaths...))
+	}
+
+	// Apply middleware chain, outermost first
+	stack := []middleware.Middleware{
+		middleware.Logging(appLogger, loggingOptions...),
+		middleware.RequestID,
+		// Browsers reach the services through the gateway, so only it answers CORS
+		middleware.CORS(cfg.CORS),
+		middleware.Metrics(metrics.NewHTTP(metricsRegistry), mux),
+		middleware.Deadline(cfg.Server.RequestDeadline),
+		middleware.MaxBodyBytes(cfg.Server.MaxBodyBytes),
+		middleware.Recover(appLogger),
+		inFlight.Middleware,
+	}
+	if cfg.Security.RequireAuth {
+		stack = append(stack, middleware.JWTAuth(cfg.Security.JWTSecret, middleware.WithAdminToken(cfg.Security.AdminToken)))
+	}
+	// Limit callers after authentication so tenants are limited as a whole
+	if cfg.RateLimit.Enabled {
+		stack = append(stack, middleware.RateLimit(ratelimit.NewKeyed(float64(cfg.RateLimit.RequestsPerSecond), cfg.RateLimit.Burst),
+			middleware.WithTrustedProxies(cfg.RateLimit.TrustedProxies...)))
+	}
+	if cfg.QoS.Enabled {
+		stack = append(stack, middleware.ConcurrencyLimit(middleware.ConcurrencyLimitConfig{
+			MaxConcurrent:       cfg.QoS.MaxConcurrent,
+			NormalPriorityLimit: cfg.QoS.NormalPriorityLimit,
+			LowPriorityLimit:    cfg.QoS.LowPriorityLimit,
+		}))
+	}
+	handler := middleware.Chain(stack...)(mux)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -178,6 +241,7 @@ func main() {
 
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "api-gateway",
+		"in_flight":           inFlight.Count(),
 	}).Info("API Gateway server shutting down")
 
 	// Create a context with timeout for shutdown
@@ -190,10 +254,28 @@ func main() {
 			logger.FieldComponent: "api-gateway",
 			logger.FieldError:     err.Error(),
 		}).Error("Server forced to shutdown")
+		_ = appLogger.Shutdown(ctx)
 		os.Exit(1)
 	}
 
+	// Wait for the requests still being served before exiting
+	if err := inFlight.Wait(ctx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "api-gateway",
+			"in_flight":           inFlight.Count(),
+		}).Warn("Requests still in flight at the shutdown timeout")
+	}
+
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "api-gateway",
 	}).Info("API Gateway server stopped")
+
+	// Flush buffered log records
+	if dropped := appLogger.DroppedRecords(); dropped > 0 {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "api-gateway",
+			"dropped_records":     dropped,
+		}).Warn("Log records were dropped by the async logger")
+	}
+	_ = appLogger.Shutdown(ctx)
 }
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,26 +11,39 @@ import (
 	"time"
 
 	"github.com/aykay76/ai-idp/internal/applications"
+	"github.com/aykay76/ai-idp/internal/audit"
 	"github.com/aykay76/ai-idp/internal/config"
 	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/debug"
+	"github.com/aykay76/ai-idp/internal/events"
+	"github.com/aykay76/ai-idp/internal/healthcheck"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/metrics"
 	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/notify"
+	"github.com/aykay76/ai-idp/internal/openapi"
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/ratelimit"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/templates"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/aykay76/ai-idp/internal/workers"
 )
 
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Service   string    `json:"service"`
-	Version   string    `json:"version,omitempty"`
-}
-
 func main() {
 	// Load configuration
 	cfg := config.LoadWithDefaults("application-service", "8082")
 
 	// Initialize logger
-	appLogger := logger.New(cfg.Logging.Level, cfg.Logging.Format)
+	appLogger := logger.NewWithOptions(cfg.Logging.Level, cfg.Logging.Format, logger.Options{
+		Async:       cfg.Logging.Async,
+		BufferSize:  int(cfg.Logging.BufferSize),
+		Destination: cfg.Logging.Output,
+		FilePath:    cfg.Logging.FilePath,
+		MaxSizeMB:   int(cfg.Logging.MaxSizeMB),
+		MaxBackups:  int(cfg.Logging.MaxBackups),
+	})
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "application-service",
 		"port":                cfg.Server.Port,
@@ -39,7 +52,30 @@ func main() {
 	// Setup database connection
 	ctx := context.Background()
 	dbConfig := database.DefaultConfig(cfg.Database.URL)
-	dbPool, err := database.NewPool(ctx, dbConfig)
+	// Log slow queries and count every query for the metrics endpoint
+	queryTracer := database.NewQueryTracer(cfg.Database.SlowQueryThreshold, func(ctx context.Context, q database.SlowQuery) {
+		fields := logger.LogFields{
+			logger.FieldComponent: "application-service",
+			logger.FieldDuration:  q.Duration.String(),
+			"sql":                 q.SQL,
+		}
+		if q.Err != nil {
+			fields[logger.FieldError] = q.Err.Error()
+		}
+		appLogger.WithFields(fields).Warn("Slow database query")
+	})
+	dbConfig.Tracer = queryTracer
+	// Wait for the database when the service starts first, as in docker-compose
+	dbPool, err := database.NewPoolWithRetry(ctx, dbConfig,
+		int(cfg.Database.StartupRetryAttempts), cfg.Database.StartupRetryBackoff,
+		database.WithConnectAttemptHook(func(attempt int, err error, wait time.Duration) {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-service",
+				logger.FieldError:     err.Error(),
+				"attempt":             attempt,
+				"retry_in":            wait.String(),
+			}).Warn("Database connection attempt failed")
+		}))
 	if err != nil {
 		appLogger.WithFields(logger.LogFields{
 			logger.FieldComponent: "application-service",
@@ -48,47 +84,355 @@ func main() {
 	}
 	defer dbPool.Close()
 
+	// Degrade to read-only mode on the replica if the primary becomes unreachable
+	var failover *database.Failover
+	if cfg.Database.ReplicaURL != "" {
+		replicaPool, err := database.NewPool(ctx, database.DefaultConfig(cfg.Database.ReplicaURL))
+		if err != nil {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Failed to connect to read replica")
+		}
+		defer replicaPool.Close()
+
+		failover = database.NewFailover(dbPool, replicaPool, database.WithReadOnlyChangeHook(func(readOnly bool, cause error) {
+			if readOnly {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "application-service",
+					logger.FieldError:     cause.Error(),
+				}).Warn("Primary database unavailable, entering read-only mode")
+				return
+			}
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-service",
+			}).Info("Primary database recovered, leaving read-only mode")
+		}))
+
+		failoverCtx, stopFailover := context.WithCancel(ctx)
+		defer stopFailover()
+		go failover.Run(failoverCtx, cfg.Database.FailoverCheckInterval)
+	}
+
+	// Spread list queries across the read replicas
+	var replicas *database.ReplicaPool
+	if len(cfg.Database.ReplicaURLs) > 0 {
+		var replicaPools []*database.Pool
+		for _, url := range cfg.Database.ReplicaURLs {
+			pool, err := database.NewPool(ctx, database.DefaultConfig(url))
+			if err != nil {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "application-service",
+					logger.FieldError:     err.Error(),
+				}).Fatal("Failed to connect to read replica")
+			}
+			replicaPools = append(replicaPools, pool)
+		}
+		replicas = database.NewReplicaPool(dbPool, replicaPools...)
+		defer replicas.Close()
+
+		replicasCtx, stopReplicas := context.WithCancel(ctx)
+		defer stopReplicas()
+		go replicas.Run(replicasCtx, cfg.Database.FailoverCheckInterval)
+	}
+
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "application-service",
 	}).Info("Database connection established")
 
+	// Publish domain events for downstream consumers when enabled
+	var publisher events.Publisher
+	if cfg.Redis.EventsEnabled {
+		redisPublisher, err := events.NewRedisPublisher(events.RedisOptions{
+			URL:      cfg.Redis.URL,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			Channel:  cfg.Redis.EventsChannel,
+			OnError: func(event events.Event, err error) {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "application-service",
+					logger.FieldError:     err.Error(),
+					"event_type":          event.Type,
+					"resource_uid":        event.Resource.UID,
+				}).Warn("Failed to publish domain event")
+			},
+		})
+		if err != nil {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Invalid Redis configuration for domain events")
+		}
+		defer redisPublisher.Close()
+		publisher = redisPublisher
+	}
+
+	// Send webhook notifications to tenants that configure them when enabled
+	var notifier notify.Notifier
+	if cfg.Webhooks.Enabled {
+		dispatcher := notify.NewWebhookDispatcher(notify.NewTenantTargets(dbPool), notify.WebhookOptions{
+			Timeout:     cfg.Webhooks.Timeout,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Backoff:     cfg.Webhooks.Backoff,
+			OnError: func(n notify.Notification, url string, err error) {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "application-service",
+					logger.FieldError:     err.Error(),
+					"notification_id":     n.ID,
+					"notification_type":   n.Type,
+					logger.FieldTenantID:  n.TenantID,
+					"webhook_url":         url,
+				}).Warn("Failed to deliver webhook notification")
+			},
+		})
+		defer dispatcher.Close()
+		notifier = dispatcher
+	}
+
 	// Initialize application service
-	appService := applications.NewService(dbPool)
-	appHandlers := applications.NewHandlers(appService, appLogger)
+	namePolicy, err := naming.ParsePolicy(cfg.Applications.NamePolicy)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Invalid application name policy")
+	}
 
-	// Create HTTP server mux
-	mux := http.NewServeMux()
+	appOptions := []applications.Option{
+		applications.WithFailover(failover),
+		applications.WithReplicas(replicas),
+		applications.WithReadRetry(database.NewRetryPolicy(int(cfg.Database.ReadRetryAttempts), cfg.Database.ReadRetryBackoff)),
+		applications.WithNamePolicy(namePolicy),
+		applications.WithRetirementGracePeriod(cfg.Applications.RetirementGracePeriod),
+		applications.WithAudit(audit.New(dbPool, appLogger)),
+		applications.WithEvents(publisher),
+		applications.WithNotifier(notifier),
+	}
+	if cfg.Applications.ApprovalRequired {
+		appOptions = append(appOptions, applications.WithApprovalWorkflow(applications.ApprovalNotifierFunc(
+			func(ctx context.Context, app *applications.Application, approvers []string) {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "application-approvals",
+					"application_id":      app.ID.String(),
+					"team_name":           app.TeamName,
+					"approvers":           approvers,
+				}).Info("Application awaiting approval")
+			},
+		)))
+	}
+	appService := applications.NewService(dbPool, appOptions...)
 
-	// Health check endpoints
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "healthy",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-application-service",
-			Version:   os.Getenv("VERSION"),
+	handlerOptions := []applications.HandlerOption{
+		applications.WithResponseEnvelope(cfg.Server.ResponseEnvelope),
+		applications.WithPageLimits(server.PageLimits{DefaultLimit: cfg.Pagination.DefaultLimit, MaxLimit: cfg.Pagination.MaxLimit}),
+	}
+	// Governance policies checked before resources are created
+	if cfg.Policies.File != "" {
+		policies, err := policy.LoadFile(cfg.Policies.File)
+		if err != nil {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Failed to load policies")
 		}
+		handlerOptions = append(handlerOptions, applications.WithPolicies(policy.NewEvaluator(policies)))
+	}
+	appHandlers := applications.NewHandlers(appService, appLogger, handlerOptions...)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+	// Application templates rendered for scaffolding
+	var templateList []types.Template
+	if cfg.Templates.File != "" {
+		templateList, err = templates.LoadFile(cfg.Templates.File)
+		if err != nil {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Failed to load templates")
+		}
+	}
+	templateService, err := templates.NewService(templateList)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Invalid templates")
+	}
+	templateHandlers := templates.NewHandlers(templateService, appLogger, templates.WithResponseEnvelope(cfg.Server.ResponseEnvelope))
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+	// Background workers heartbeat so a stalled worker shows up in /admin/workers
+	workerRegistry := workers.NewRegistry()
+	var gcOptions []workers.Option
+	if cfg.Applications.GCCritical {
+		gcOptions = append(gcOptions, workers.Critical())
+	}
+	gcInterval := cfg.Applications.GCInterval
+	if gcInterval <= 0 {
+		gcInterval = applications.DefaultRetirementGCInterval
+	}
+	gcHeartbeat := workerRegistry.Register("application-gc", gcInterval, gcOptions...)
+
+	// Purge retired applications once their grace period has elapsed
+	gcCtx, stopGC := context.WithCancel(ctx)
+	defer stopGC()
+	go appService.RunRetirementGC(gcCtx, gcInterval, func(purged int64, err error) {
+		gcHeartbeat.Beat()
+		if err != nil {
 			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode health response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				logger.FieldComponent: "application-gc",
+				logger.FieldError:     err.Error(),
+			}).Error("Failed to purge retired applications")
+			return
+		}
+		if purged > 0 {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "application-gc",
+				"purged":              purged,
+			}).Info("Purged retired applications")
 		}
 	})
 
-	// Application endpoints
-	mux.HandleFunc("GET /api/v1/applications", appHandlers.ListApplications)
-	mux.HandleFunc("POST /api/v1/applications", appHandlers.CreateApplication)
-	mux.HandleFunc("GET /api/v1/applications/{id}", appHandlers.GetApplication)
-	mux.HandleFunc("PUT /api/v1/applications/{id}", appHandlers.UpdateApplication)
-	mux.HandleFunc("DELETE /api/v1/applications/{id}", appHandlers.DeleteApplication)
+	// Create HTTP server mux
+	mux := http.NewServeMux()
+
+	// Health and probe endpoints (for Kubernetes); readiness pings the database and
+	// requires the critical workers to be running
+	healthcheck.RegisterHandlers(mux, "ai-idp-application-service", dbPool,
+		healthcheck.WithFailover(failover),
+		healthcheck.WithCheck(func() error {
+			if !workerRegistry.Ready() {
+				return errors.New("a critical background worker is not running")
+			}
+			return nil
+		}),
+	)
+
+	// Worker liveness (admin only)
+	mux.Handle("GET /admin/workers", middleware.RequireAdmin(cfg.Security.AdminToken)(workerRegistry.Handler()))
+
+	// Application endpoints are scoped to the tenant named by the sources in
+	// TENANT_RESOLUTION: the X-Tenant-ID header, the token's tenant claim or the subdomain
+	tenantSources, err := server.ParseTenantSources(cfg.Tenants.Resolution, cfg.Tenants.BaseDomain,
+		database.NewTenantNameCache(dbPool, cfg.Tenants.NameCacheTTL).ID)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Invalid tenant resolution")
+	}
+	tenantResolver := server.NewTenantResolver(tenantSources...)
+	withTenant := func(handler http.HandlerFunc) http.HandlerFunc {
+		return server.WithTenantResolution(tenantResolver, handler)
+	}
+	mux.HandleFunc("GET /api/v1/applications", withTenant(appHandlers.ListApplications))
+	mux.HandleFunc("POST /api/v1/applications", withTenant(appHandlers.CreateApplication))
+	mux.HandleFunc("GET /api/v1/applications/by-team", withTenant(appHandlers.GetApplicationsByTeam))
+	mux.HandleFunc("GET /api/v1/applications/stats", withTenant(appHandlers.GetApplicationStats))
+	mux.HandleFunc("GET /api/v1/applications/{id}", withTenant(appHandlers.GetApplication))
+	mux.HandleFunc("PUT /api/v1/applications/{id}", withTenant(appHandlers.UpdateApplication))
+	mux.HandleFunc("POST /api/v1/applications/{id}", withTenant(appHandlers.ApplicationAction))
+	mux.HandleFunc("DELETE /api/v1/applications/{id}", withTenant(appHandlers.DeleteApplication))
+	mux.HandleFunc("GET /api/v1/applications/{id}/resources", withTenant(appHandlers.GetResources))
+	mux.HandleFunc("PUT /api/v1/applications/{id}/resources", withTenant(appHandlers.SetResources))
+	mux.HandleFunc("PUT /api/v1/applications/{id}/resources/{name}/status", withTenant(appHandlers.UpdateResourceStatus))
+	mux.HandleFunc("GET /api/v1/applications/{id}/env", withTenant(appHandlers.GetEnvironment))
+	mux.HandleFunc("PUT /api/v1/applications/{id}/env", withTenant(appHandlers.SetEnvironment))
+	mux.HandleFunc("PUT /api/v1/applications/{id}/dependencies", withTenant(appHandlers.SetDependencies))
+	mux.HandleFunc("GET /api/v1/applications/{id}/dependencies", withTenant(appHandlers.GetDependencies))
+	mux.HandleFunc("GET /api/v1/applications/{id}/status", withTenant(appHandlers.GetStatus))
+	mux.HandleFunc("GET /api/v1/applications/{id}/history", withTenant(appHandlers.GetHistory))
+	mux.HandleFunc("GET /api/v1/applications/{id}/history/diff", withTenant(appHandlers.DiffHistory))
+	mux.HandleFunc("POST /api/v1/templates/{name}/render", withTenant(templateHandlers.Render))
+
+	// Machine-readable API description, for generated clients and Swagger UI
+	version := os.Getenv("VERSION")
+	mux.Handle("GET /openapi.json", openapi.Merge("Application Service", version, applications.OpenAPI(version), templates.OpenAPI(version)).Handler())
+
+	// Profiling endpoints (admin only, disabled unless ENABLE_PROFILING is set)
+	if debug.RegisterProfiling(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+		}).Warn("Profiling endpoints enabled under /debug/pprof/")
+	}
+
+	// Effective configuration, secrets redacted (admin only, disabled unless ENABLE_DEBUG_CONFIG is set)
+	if debug.RegisterConfig(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+		}).Warn("Config endpoint enabled at /debug/config")
+	}
 
-	// Apply middleware chain
-	handler := middleware.RequestID(mux)
-	handler = middleware.Logging(appLogger)(handler)
+	// Schema migration status (admin only), left out when the migration files are missing
+	migrations, err := database.NewMigrationManager(dbPool, cfg.Database.MigrationsPath)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+			logger.FieldError:     err.Error(),
+		}).Warn("Migration status endpoint disabled")
+	} else {
+		defer migrations.Close()
+		server.RegisterMigrationStatus(mux, migrations, cfg.Security.AdminToken)
+	}
+
+	// Prometheus metrics, labelled by route pattern
+	metricsRegistry := metrics.NewRegistry()
+	metrics.RegisterPool(metricsRegistry, dbPool.Stats)
+	metrics.RegisterQueries(metricsRegistry, queryTracer.Stats)
+	mux.Handle("GET /metrics", metricsRegistry.Handler())
+
+	// Reads may be cached privately; mutations and sensitive reads are never stored
+	cachePolicy := server.NewCachePolicy(cfg.Server.CacheMaxAge).
+		Route("GET /health", server.NoStore).
+		Route("GET /readiness", server.NoStore).
+		Route("GET /metrics", server.NoStore).
+		Route("GET /api/v1/applications/{id}/env", server.NoStore)
+	maintenance := database.NewMaintenanceSchedule(dbPool, database.DefaultMaintenanceCacheTTL)
+	// Count requests inside Timeout, whose abandoned handlers keep running, so shutdown
+	// can wait for them before the database is closed
+	inFlight := middleware.NewInFlight()
+	loggingOptions := []middleware.LoggingOption{middleware.WithSampleRate(int(cfg.Logging.SampleRate))}
+	if cfg.Logging.SkipProbes {
+		loggingOptions = append(loggingOptions, middleware.WithoutPaths(middleware.ProbePaths...))
+	}
+
+	// Apply middleware chain, outermost first
+	stack := []middleware.Middleware{
+		middleware.Logging(appLogger, loggingOptions...),
+		middleware.RequestID,
+		middleware.Metrics(metrics.NewHTTP(metricsRegistry), mux),
+		middleware.Deadline(cfg.Server.RequestDeadline),
+		middleware.MaxBodyBytes(cfg.Server.MaxBodyBytes),
+		middleware.Timeout(cfg.Server.RequestTimeout),
+		middleware.Recover(appLogger),
+		inFlight.Middleware,
+	}
+	if cfg.Security.RequireAuth {
+		stack = append(stack, middleware.JWTAuth(cfg.Security.JWTSecret, middleware.WithAdminToken(cfg.Security.AdminToken)))
+	}
+	// Limit callers after authentication so tenants are limited as a whole
+	if cfg.RateLimit.Enabled {
+		stack = append(stack, middleware.RateLimit(ratelimit.NewKeyed(float64(cfg.RateLimit.RequestsPerSecond), cfg.RateLimit.Burst),
+			middleware.WithTrustedProxies(cfg.RateLimit.TrustedProxies...)))
+	}
+	stack = append(stack, cachePolicy.Middleware)
+	// Resolve the tenant once, so the suspension and maintenance checks act on the same
+	// tenant as the handlers whether it came from the header, token or subdomain
+	stack = append(stack, tenantResolver.Middleware)
+	if cfg.Tenants.BlockSuspended {
+		tenantStatus := database.NewTenantStatusCache(dbPool, cfg.Tenants.StatusCacheTTL)
+		stack = append(stack, middleware.TenantSuspension(tenantStatus.Status, cfg.Security.AdminToken))
+	}
+	stack = append(stack, middleware.TenantMaintenance(maintenance.ActiveUntil, nil))
+	if failover != nil {
+		stack = append(stack, middleware.ReadOnly(failover.ReadOnly))
+	}
+	if cfg.QoS.Enabled {
+		stack = append(stack, middleware.ConcurrencyLimit(middleware.ConcurrencyLimitConfig{
+			MaxConcurrent:       cfg.QoS.MaxConcurrent,
+			NormalPriorityLimit: cfg.QoS.NormalPriorityLimit,
+			LowPriorityLimit:    cfg.QoS.LowPriorityLimit,
+		}))
+	}
+	handler := middleware.Chain(stack...)(mux)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -126,6 +470,7 @@ func main() {
 
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "application-service",
+		"in_flight":           inFlight.Count(),
 	}).Info("Application service shutting down")
 
 	// Create a context with timeout for shutdown
@@ -138,12 +483,31 @@ func main() {
 			logger.FieldComponent: "application-service",
 			logger.FieldError:     err.Error(),
 		}).Error("Server forced to shutdown")
+		_ = appLogger.Shutdown(shutdownCtx)
 		os.Exit(1)
 	}
 
+	// Wait for handlers still running, such as those abandoned by Timeout, before the
+	// deferred close of the database pool
+	if err := inFlight.Wait(shutdownCtx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+			"in_flight":           inFlight.Count(),
+		}).Warn("Requests still in flight at the shutdown timeout")
+	}
+
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "application-service",
 	}).Info("Application service stopped")
+
+	// Flush buffered log records
+	if dropped := appLogger.DroppedRecords(); dropped > 0 {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "application-service",
+			"dropped_records":     dropped,
+		}).Warn("Log records were dropped by the async logger")
+	}
+	_ = appLogger.Shutdown(shutdownCtx)
 }
 
 // parseQuery helper function for parsing query parameters
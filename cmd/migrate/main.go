@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+)
+
+const usage = `Usage: migrate [flags] <command>
+
+Commands:
+  up         apply all pending migrations
+  down       roll back the last migration
+  steps N    apply N migrations, or roll back -N
+  force N    set the version to N and clear the dirty flag, without migrating
+  version    print the current version
+  status     print the migration status
+  drop       drop everything in the database (requires -confirm)
+
+Flags:
+`
+
+// options are the parsed command line
+type options struct {
+	command string
+	steps   int
+	version int
+	path    string
+	confirm bool
+}
+
+// migrator is the part of database.MigrationManager the commands use
+type migrator interface {
+	Up(ctx context.Context) error
+	Down(ctx context.Context) error
+	Steps(ctx context.Context, steps int) error
+	ForceVersion(version int) error
+	Version() (uint, bool, error)
+	Status() (*database.MigrationStatus, error)
+	Drop(ctx context.Context) error
+}
+
+func main() {
+	opts, err := parseArgs(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	cfg := config.LoadWithDefaults("migrate", "")
+	appLogger := logger.New(cfg.Logging.Level, cfg.Logging.Format)
+
+	ctx := context.Background()
+	dbPool, err := database.NewPool(ctx, database.DefaultConfig(cfg.Database.URL))
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "migrate",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Failed to connect to database")
+	}
+	defer dbPool.Close()
+
+	mm, err := database.NewMigrationManager(dbPool, opts.path)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "migrate",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Failed to create migration manager")
+	}
+	defer mm.Close()
+
+	if err := run(ctx, mm, opts, os.Stdout); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "migrate",
+			logger.FieldError:     err.Error(),
+			"command":             opts.command,
+		}).Error("Migration command failed")
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses the flags and command, writing usage to output on -h or an error
+func parseArgs(args []string, output io.Writer) (options, error) {
+	var opts options
+
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.path, "path", "migrations", "directory containing the migration files")
+	fs.BoolVar(&opts.confirm, "confirm", false, "confirm that drop may delete all data")
+	fs.Usage = func() {
+		fmt.Fprint(output, usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fs.Usage()
+		return options{}, errors.New("missing command")
+	}
+	opts.command = rest[0]
+
+	wantArgs := 1
+	switch opts.command {
+	case "up", "down", "version", "status":
+	case "steps":
+		wantArgs = 2
+		if len(rest) < 2 {
+			return options{}, errors.New("steps needs a number of migrations")
+		}
+		n, err := strconv.Atoi(rest[1])
+		if err != nil || n == 0 {
+			return options{}, fmt.Errorf("invalid number of steps %q", rest[1])
+		}
+		opts.steps = n
+	case "force":
+		wantArgs = 2
+		if len(rest) < 2 {
+			return options{}, errors.New("force needs a version")
+		}
+		v, err := strconv.Atoi(rest[1])
+		if err != nil || v < -1 {
+			return options{}, fmt.Errorf("invalid version %q", rest[1])
+		}
+		opts.version = v
+	case "drop":
+		if !opts.confirm {
+			return options{}, errors.New("drop deletes all data; pass -confirm to proceed")
+		}
+	default:
+		fs.Usage()
+		return options{}, fmt.Errorf("unknown command %q", opts.command)
+	}
+
+	if len(rest) > wantArgs {
+		return options{}, fmt.Errorf("unexpected arguments after %s: %v", opts.command, rest[wantArgs:])
+	}
+
+	return opts, nil
+}
+
+// run executes the parsed command, printing the result to out
+func run(ctx context.Context, mm migrator, opts options, out io.Writer) error {
+	switch opts.command {
+	case "up":
+		if err := mm.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		if err := mm.Down(ctx); err != nil {
+			return err
+		}
+	case "steps":
+		if err := mm.Steps(ctx, opts.steps); err != nil {
+			return err
+		}
+	case "force":
+		if err := mm.ForceVersion(opts.version); err != nil {
+			return err
+		}
+	case "drop":
+		if err := mm.Drop(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Database dropped")
+		return nil
+	case "version":
+		version, dirty, err := mm.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%d", version)
+		if dirty {
+			fmt.Fprint(out, " (dirty)")
+		}
+		fmt.Fprintln(out)
+		return nil
+	}
+
+	// Every other command reports where the schema ended up
+	status, err := mm.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, status.Status)
+	return nil
+}
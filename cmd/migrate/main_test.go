@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    options
+		wantErr bool
+	}{
+		{"up", []string{"up"}, options{command: "up", path: "migrations"}, false},
+		{"down", []string{"down"}, options{command: "down", path: "migrations"}, false},
+		{"version", []string{"version"}, options{command: "version", path: "migrations"}, false},
+		{"status with path", []string{"-path", "/migrations", "status"}, options{command: "status", path: "/migrations"}, false},
+		{"steps up", []string{"steps", "2"}, options{command: "steps", steps: 2, path: "migrations"}, false},
+		{"steps down", []string{"steps", "-1"}, options{command: "steps", steps: -1, path: "migrations"}, false},
+		{"force", []string{"force", "13"}, options{command: "force", version: 13, path: "migrations"}, false},
+		{"force to no migrations", []string{"force", "-1"}, options{command: "force", version: -1, path: "migrations"}, false},
+		{"force without a version", []string{"force"}, options{}, true},
+		{"force with an invalid version", []string{"force", "-2"}, options{}, true},
+		{"confirmed drop", []string{"-confirm", "drop"}, options{command: "drop", path: "migrations", confirm: true}, false},
+		{"drop without confirmation", []string{"drop"}, options{}, true},
+		{"steps without a number", []string{"steps"}, options{}, true},
+		{"steps with an invalid number", []string{"steps", "two"}, options{}, true},
+		{"zero steps", []string{"steps", "0"}, options{}, true},
+		{"no command", nil, options{}, true},
+		{"unknown command", []string{"sideways"}, options{}, true},
+		{"extra arguments", []string{"up", "now"}, options{}, true},
+		{"unknown flag", []string{"-force", "up"}, options{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArgs(tt.args, io.Discard)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := parseArgs([]string{"-h"}, &out)
+		assert.ErrorIs(t, err, flag.ErrHelp)
+		assert.Contains(t, out.String(), "steps N")
+	})
+}
+
+// fakeMigrator records the calls made to it
+type fakeMigrator struct {
+	calls   []string
+	steps   int
+	forced  int
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (f *fakeMigrator) Up(ctx context.Context) error {
+	f.calls = append(f.calls, "up")
+	return f.err
+}
+
+func (f *fakeMigrator) Down(ctx context.Context) error {
+	f.calls = append(f.calls, "down")
+	return f.err
+}
+
+func (f *fakeMigrator) Steps(ctx context.Context, steps int) error {
+	f.calls = append(f.calls, "steps")
+	f.steps = steps
+	return f.err
+}
+
+func (f *fakeMigrator) ForceVersion(version int) error {
+	f.calls = append(f.calls, "force")
+	f.forced = version
+	return f.err
+}
+
+func (f *fakeMigrator) Version() (uint, bool, error) {
+	f.calls = append(f.calls, "version")
+	return f.version, f.dirty, nil
+}
+
+func (f *fakeMigrator) Status() (*database.MigrationStatus, error) {
+	f.calls = append(f.calls, "status")
+	return &database.MigrationStatus{Version: f.version, Status: "Version 14 (clean)"}, nil
+}
+
+func (f *fakeMigrator) Drop(ctx context.Context) error {
+	f.calls = append(f.calls, "drop")
+	return f.err
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("up reports the status", func(t *testing.T) {
+		mm := &fakeMigrator{version: 14}
+		var out bytes.Buffer
+
+		require.NoError(t, run(ctx, mm, options{command: "up"}, &out))
+		assert.Equal(t, []string{"up", "status"}, mm.calls)
+		assert.Equal(t, "Version 14 (clean)\n", out.String())
+	})
+
+	t.Run("steps", func(t *testing.T) {
+		mm := &fakeMigrator{}
+		require.NoError(t, run(ctx, mm, options{command: "steps", steps: -2}, io.Discard))
+		assert.Equal(t, -2, mm.steps)
+	})
+
+	t.Run("force", func(t *testing.T) {
+		mm := &fakeMigrator{}
+		require.NoError(t, run(ctx, mm, options{command: "force", version: 13}, io.Discard))
+		assert.Equal(t, []string{"force", "status"}, mm.calls)
+		assert.Equal(t, 13, mm.forced)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		mm := &fakeMigrator{version: 7, dirty: true}
+		var out bytes.Buffer
+
+		require.NoError(t, run(ctx, mm, options{command: "version"}, &out))
+		assert.Equal(t, "7 (dirty)\n", out.String())
+	})
+
+	t.Run("failure skips the status", func(t *testing.T) {
+		mm := &fakeMigrator{err: errors.New("dirty database")}
+
+		assert.Error(t, run(ctx, mm, options{command: "down"}, io.Discard))
+		assert.Equal(t, []string{"down"}, mm.calls)
+	})
+}
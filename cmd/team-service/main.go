@@ -2,34 +2,43 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/audit"
 	"github.com/aykay76/ai-idp/internal/config"
 	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/debug"
+	"github.com/aykay76/ai-idp/internal/events"
+	"github.com/aykay76/ai-idp/internal/healthcheck"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/metrics"
 	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/notify"
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/ratelimit"
+	"github.com/aykay76/ai-idp/internal/server"
 	"github.com/aykay76/ai-idp/internal/teams"
+	"github.com/aykay76/ai-idp/internal/types"
 )
 
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Service   string    `json:"service"`
-	Version   string    `json:"version,omitempty"`
-}
-
 func main() {
 	// Load configuration
 	cfg := config.LoadWithDefaults("team-service", "8083")
 
 	// Initialize logger
-	appLogger := logger.New(cfg.Logging.Level, cfg.Logging.Format)
+	appLogger := logger.NewWithOptions(cfg.Logging.Level, cfg.Logging.Format, logger.Options{
+		Async:       cfg.Logging.Async,
+		BufferSize:  int(cfg.Logging.BufferSize),
+		Destination: cfg.Logging.Output,
+		FilePath:    cfg.Logging.FilePath,
+		MaxSizeMB:   int(cfg.Logging.MaxSizeMB),
+		MaxBackups:  int(cfg.Logging.MaxBackups),
+	})
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "team-service",
 		"port":                cfg.Server.Port,
@@ -38,7 +47,30 @@ func main() {
 	// Setup database connection
 	ctx := context.Background()
 	dbConfig := database.DefaultConfig(cfg.Database.URL)
-	dbPool, err := database.NewPool(ctx, dbConfig)
+	// Log slow queries and count every query for the metrics endpoint
+	queryTracer := database.NewQueryTracer(cfg.Database.SlowQueryThreshold, func(ctx context.Context, q database.SlowQuery) {
+		fields := logger.LogFields{
+			logger.FieldComponent: "team-service",
+			logger.FieldDuration:  q.Duration.String(),
+			"sql":                 q.SQL,
+		}
+		if q.Err != nil {
+			fields[logger.FieldError] = q.Err.Error()
+		}
+		appLogger.WithFields(fields).Warn("Slow database query")
+	})
+	dbConfig.Tracer = queryTracer
+	// Wait for the database when the service starts first, as in docker-compose
+	dbPool, err := database.NewPoolWithRetry(ctx, dbConfig,
+		int(cfg.Database.StartupRetryAttempts), cfg.Database.StartupRetryBackoff,
+		database.WithConnectAttemptHook(func(attempt int, err error, wait time.Duration) {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "team-service",
+				logger.FieldError:     err.Error(),
+				"attempt":             attempt,
+				"retry_in":            wait.String(),
+			}).Warn("Database connection attempt failed")
+		}))
 	if err != nil {
 		appLogger.WithFields(logger.LogFields{
 			logger.FieldComponent: "team-service",
@@ -47,102 +79,269 @@ func main() {
 	}
 	defer dbPool.Close()
 
-	// Initialize team service
-	teamService := teams.NewService(dbPool)
-	teamHandlers := teams.NewHandlers(teamService, appLogger)
-
-	// Create HTTP server mux
-	mux := http.NewServeMux()
-
-	// Health check endpoint
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "healthy",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-team-service",
-			Version:   os.Getenv("VERSION"),
+	// Degrade to read-only mode on the replica if the primary becomes unreachable
+	var failover *database.Failover
+	if cfg.Database.ReplicaURL != "" {
+		replicaPool, err := database.NewPool(ctx, database.DefaultConfig(cfg.Database.ReplicaURL))
+		if err != nil {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "team-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Failed to connect to read replica")
 		}
+		defer replicaPool.Close()
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+		failover = database.NewFailover(dbPool, replicaPool, database.WithReadOnlyChangeHook(func(readOnly bool, cause error) {
+			if readOnly {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "team-service",
+					logger.FieldError:     cause.Error(),
+				}).Warn("Primary database unavailable, entering read-only mode")
+				return
+			}
 			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode health response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
+				logger.FieldComponent: "team-service",
+			}).Info("Primary database recovered, leaving read-only mode")
+		}))
 
-		appLogger.WithFields(logger.LogFields{
-			logger.FieldHTTPMethod: r.Method,
-			logger.FieldHTTPPath:   r.URL.Path,
-			logger.FieldHTTPStatus: http.StatusOK,
-		}).Debug("Health check requested")
-	})
+		failoverCtx, stopFailover := context.WithCancel(ctx)
+		defer stopFailover()
+		go failover.Run(failoverCtx, cfg.Database.FailoverCheckInterval)
+	}
 
-	// Readiness check endpoint (for Kubernetes)
-	mux.HandleFunc("GET /readiness", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "ready",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-team-service",
+	// Spread list queries across the read replicas
+	var replicas *database.ReplicaPool
+	if len(cfg.Database.ReplicaURLs) > 0 {
+		var replicaPools []*database.Pool
+		for _, url := range cfg.Database.ReplicaURLs {
+			pool, err := database.NewPool(ctx, database.DefaultConfig(url))
+			if err != nil {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "team-service",
+					logger.FieldError:     err.Error(),
+				}).Fatal("Failed to connect to read replica")
+			}
+			replicaPools = append(replicaPools, pool)
 		}
+		replicas = database.NewReplicaPool(dbPool, replicaPools...)
+		defer replicas.Close()
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		replicasCtx, stopReplicas := context.WithCancel(ctx)
+		defer stopReplicas()
+		go replicas.Run(replicasCtx, cfg.Database.FailoverCheckInterval)
+	}
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+	// Publish domain events for downstream consumers when enabled
+	var publisher events.Publisher
+	if cfg.Redis.EventsEnabled {
+		redisPublisher, err := events.NewRedisPublisher(events.RedisOptions{
+			URL:      cfg.Redis.URL,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			Channel:  cfg.Redis.EventsChannel,
+			OnError: func(event events.Event, err error) {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "team-service",
+					logger.FieldError:     err.Error(),
+					"event_type":          event.Type,
+					"resource_uid":        event.Resource.UID,
+				}).Warn("Failed to publish domain event")
+			},
+		})
+		if err != nil {
 			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode readiness response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+				logger.FieldComponent: "team-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Invalid Redis configuration for domain events")
 		}
+		defer redisPublisher.Close()
+		publisher = redisPublisher
+	}
 
-		appLogger.WithFields(logger.LogFields{
-			logger.FieldHTTPMethod: r.Method,
-			logger.FieldHTTPPath:   r.URL.Path,
-			logger.FieldHTTPStatus: http.StatusOK,
-		}).Debug("Readiness check requested")
-	})
+	// Send webhook notifications to tenants that configure them when enabled
+	var notifier notify.Notifier
+	if cfg.Webhooks.Enabled {
+		dispatcher := notify.NewWebhookDispatcher(notify.NewTenantTargets(dbPool), notify.WebhookOptions{
+			Timeout:     cfg.Webhooks.Timeout,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Backoff:     cfg.Webhooks.Backoff,
+			OnError: func(n notify.Notification, url string, err error) {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "team-service",
+					logger.FieldError:     err.Error(),
+					"notification_id":     n.ID,
+					"notification_type":   n.Type,
+					logger.FieldTenantID:  n.TenantID,
+					"webhook_url":         url,
+				}).Warn("Failed to deliver webhook notification")
+			},
+		})
+		defer dispatcher.Close()
+		notifier = dispatcher
+	}
 
-	// Liveness check endpoint (for Kubernetes)
-	mux.HandleFunc("GET /liveness", func(w http.ResponseWriter, r *http.Request) {
-		response := HealthResponse{
-			Status:    "alive",
-			Timestamp: time.Now().UTC(),
-			Service:   "ai-idp-team-service",
-		}
+	namePolicy, err := naming.ParsePolicy(cfg.Teams.NamePolicy)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Invalid team name policy")
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+	// Initialize team service
+	teamService := teams.NewService(dbPool,
+		teams.WithFailover(failover),
+		teams.WithReplicas(replicas),
+		teams.WithReadRetry(database.NewRetryPolicy(int(cfg.Database.ReadRetryAttempts), cfg.Database.ReadRetryBackoff)),
+		teams.WithNamePolicy(namePolicy),
+		teams.WithAutoOwnerMembership(cfg.Teams.AutoOwnerMembership),
+		teams.WithAudit(audit.New(dbPool, appLogger)),
+		teams.WithEvents(publisher),
+		teams.WithNotifier(notifier),
+	)
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+	handlerOptions := []teams.HandlerOption{
+		teams.WithResponseEnvelope(cfg.Server.ResponseEnvelope),
+		teams.WithPageLimits(server.PageLimits{DefaultLimit: cfg.Pagination.DefaultLimit, MaxLimit: cfg.Pagination.MaxLimit}),
+	}
+	// Governance policies checked before resources are created
+	if cfg.Policies.File != "" {
+		policies, err := policy.LoadFile(cfg.Policies.File)
+		if err != nil {
 			appLogger.WithFields(logger.LogFields{
-				logger.FieldError: err.Error(),
-			}).Error("Failed to encode liveness response")
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+				logger.FieldComponent: "team-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Failed to load policies")
 		}
+		handlerOptions = append(handlerOptions, teams.WithPolicies(policy.NewEvaluator(policies)))
+	}
+	teamHandlers := teams.NewHandlers(teamService, appLogger, handlerOptions...)
 
-		appLogger.WithFields(logger.LogFields{
-			logger.FieldHTTPMethod: r.Method,
-			logger.FieldHTTPPath:   r.URL.Path,
-			logger.FieldHTTPStatus: http.StatusOK,
-		}).Debug("Liveness check requested")
-	})
+	// Create HTTP server mux
+	mux := http.NewServeMux()
+
+	// Health and probe endpoints (for Kubernetes); readiness pings the database
+	healthcheck.RegisterHandlers(mux, "ai-idp-team-service", dbPool, healthcheck.WithFailover(failover))
+
+	// With AUTH_REQUIRED, changing a team or its members takes a maintainer or owner of
+	// it, and restoring a deleted team one of the team as it was deleted
+	requireMaintainer := middleware.Chain()
+	requireRestorer := middleware.Chain()
+	if cfg.Security.RequireAuth {
+		requireMaintainer = middleware.RequireRole(types.TeamRoleMaintainer, teamService.MemberRole, cfg.Security.AdminToken)
+		requireRestorer = middleware.RequireRole(types.TeamRoleMaintainer, teamService.DeletedMemberRole, cfg.Security.AdminToken)
+	}
 
 	// Team API endpoints
 	mux.HandleFunc("POST /api/v1/teams", teamHandlers.CreateTeam)
 	mux.HandleFunc("GET /api/v1/teams/{id}", teamHandlers.GetTeam)
-	mux.HandleFunc("PUT /api/v1/teams/{id}", teamHandlers.UpdateTeam)
-	mux.HandleFunc("DELETE /api/v1/teams/{id}", teamHandlers.DeleteTeam)
+	mux.Handle("PUT /api/v1/teams/{id}", requireMaintainer(http.HandlerFunc(teamHandlers.UpdateTeam)))
+	mux.Handle("PATCH /api/v1/teams/{id}", requireMaintainer(http.HandlerFunc(teamHandlers.PatchTeam)))
+	mux.Handle("DELETE /api/v1/teams/{id}", requireMaintainer(http.HandlerFunc(teamHandlers.DeleteTeam)))
+	mux.Handle("POST /api/v1/teams/{id}/restore", requireRestorer(http.HandlerFunc(teamHandlers.RestoreTeam)))
 	mux.HandleFunc("GET /api/v1/teams", teamHandlers.ListTeams)
+	mux.Handle("POST /api/v1/teams/{id}/members:bulk", requireMaintainer(http.HandlerFunc(teamHandlers.BulkAddMembers)))
+	mux.Handle("PUT /api/v1/teams/{id}/members/{email}", requireMaintainer(http.HandlerFunc(teamHandlers.UpdateMemberRole)))
+	mux.Handle("DELETE /api/v1/teams/{id}/members/{email}", requireMaintainer(http.HandlerFunc(teamHandlers.RemoveMember)))
+
+	// Machine-readable API description, for generated clients and Swagger UI
+	mux.Handle("GET /openapi.json", teams.OpenAPI(os.Getenv("VERSION")).Handler())
+
+	// Profiling endpoints (admin only, disabled unless ENABLE_PROFILING is set)
+	if debug.RegisterProfiling(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+		}).Warn("Profiling endpoints enabled under /debug/pprof/")
+	}
 
-	// Apply middleware chain
-	handler := middleware.RequestID(mux)
-	handler = middleware.Logging(appLogger)(handler)
+	// Effective configuration, secrets redacted (admin only, disabled unless ENABLE_DEBUG_CONFIG is set)
+	if debug.RegisterConfig(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+		}).Warn("Config endpoint enabled at /debug/config")
+	}
+
+	// Schema migration status (admin only), left out when the migration files are missing
+	migrations, err := database.NewMigrationManager(dbPool, cfg.Database.MigrationsPath)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+			logger.FieldError:     err.Error(),
+		}).Warn("Migration status endpoint disabled")
+	} else {
+		defer migrations.Close()
+		server.RegisterMigrationStatus(mux, migrations, cfg.Security.AdminToken)
+	}
+
+	// Prometheus metrics, labelled by route pattern
+	metricsRegistry := metrics.NewRegistry()
+	metrics.RegisterPool(metricsRegistry, dbPool.Stats)
+	metrics.RegisterQueries(metricsRegistry, queryTracer.Stats)
+	mux.Handle("GET /metrics", metricsRegistry.Handler())
+
+	// Reads may be cached privately; mutations and sensitive reads are never stored
+	cachePolicy := server.NewCachePolicy(cfg.Server.CacheMaxAge).
+		Route("GET /health", server.NoStore).
+		Route("GET /readiness", server.NoStore).
+		Route("GET /metrics", server.NoStore)
+	maintenance := database.NewMaintenanceSchedule(dbPool, database.DefaultMaintenanceCacheTTL)
+	// Tenant checks apply to the tenant named by the sources in TENANT_RESOLUTION
+	tenantSources, err := server.ParseTenantSources(cfg.Tenants.Resolution, cfg.Tenants.BaseDomain,
+		database.NewTenantNameCache(dbPool, cfg.Tenants.NameCacheTTL).ID)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Invalid tenant resolution")
+	}
+	tenantResolver := server.NewTenantResolver(tenantSources...)
+	// Count requests inside Timeout, whose abandoned handlers keep running, so shutdown
+	// can wait for them before the database is closed
+	inFlight := middleware.NewInFlight()
+	loggingOptions := []middleware.LoggingOption{middleware.WithSampleRate(int(cfg.Logging.SampleRate))}
+	if cfg.Logging.SkipProbes {
+		loggingOptions = append(loggingOptions, middleware.WithoutPaths(middleware.ProbePaths...))
+	}
+
+	// Apply middleware chain, outermost first
+	stack := []middleware.Middleware{
+		middleware.Logging(appLogger, loggingOptions...),
+		middleware.RequestID,
+		middleware.Metrics(metrics.NewHTTP(metricsRegistry), mux),
+		middleware.Deadline(cfg.Server.RequestDeadline),
+		middleware.MaxBodyBytes(cfg.Server.MaxBodyBytes),
+		middleware.Timeout(cfg.Server.RequestTimeout),
+		middleware.Recover(appLogger),
+		inFlight.Middleware,
+	}
+	if cfg.Security.RequireAuth {
+		stack = append(stack, middleware.JWTAuth(cfg.Security.JWTSecret, middleware.WithAdminToken(cfg.Security.AdminToken)))
+	}
+	// Limit callers after authentication so tenants are limited as a whole
+	if cfg.RateLimit.Enabled {
+		stack = append(stack, middleware.RateLimit(ratelimit.NewKeyed(float64(cfg.RateLimit.RequestsPerSecond), cfg.RateLimit.Burst),
+			middleware.WithTrustedProxies(cfg.RateLimit.TrustedProxies...)))
+	}
+	stack = append(stack, cachePolicy.Middleware)
+	// Resolve the tenant once, so the suspension and maintenance checks act on the same
+	// tenant as the handlers whether it came from the header, token or subdomain
+	stack = append(stack, tenantResolver.Middleware)
+	if cfg.Tenants.BlockSuspended {
+		tenantStatus := database.NewTenantStatusCache(dbPool, cfg.Tenants.StatusCacheTTL)
+		stack = append(stack, middleware.TenantSuspension(tenantStatus.Status, cfg.Security.AdminToken))
+	}
+	stack = append(stack, middleware.TenantMaintenance(maintenance.ActiveUntil, nil))
+	if failover != nil {
+		stack = append(stack, middleware.ReadOnly(failover.ReadOnly))
+	}
+	if cfg.QoS.Enabled {
+		stack = append(stack, middleware.ConcurrencyLimit(middleware.ConcurrencyLimitConfig{
+			MaxConcurrent:       cfg.QoS.MaxConcurrent,
+			NormalPriorityLimit: cfg.QoS.NormalPriorityLimit,
+			LowPriorityLimit:    cfg.QoS.LowPriorityLimit,
+		}))
+	}
+	handler := middleware.Chain(stack...)(mux)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -181,6 +380,7 @@ func main() {
 
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "team-service",
+		"in_flight":           inFlight.Count(),
 	}).Info("Team Service server shutting down")
 
 	// Create a context with timeout for shutdown
@@ -193,10 +393,29 @@ func main() {
 			logger.FieldComponent: "team-service",
 			logger.FieldError:     err.Error(),
 		}).Error("Server forced to shutdown")
+		_ = appLogger.Shutdown(ctx)
 		os.Exit(1)
 	}
 
+	// Wait for handlers still running, such as those abandoned by Timeout, before the
+	// deferred close of the database pool
+	if err := inFlight.Wait(ctx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+			"in_flight":           inFlight.Count(),
+		}).Warn("Requests still in flight at the shutdown timeout")
+	}
+
 	appLogger.WithFields(logger.LogFields{
 		logger.FieldComponent: "team-service",
 	}).Info("Team Service server stopped")
+
+	// Flush buffered log records
+	if dropped := appLogger.DroppedRecords(); dropped > 0 {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "team-service",
+			"dropped_records":     dropped,
+		}).Warn("Log records were dropped by the async logger")
+	}
+	_ = appLogger.Shutdown(ctx)
 }
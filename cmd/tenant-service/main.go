@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/debug"
+	"github.com/aykay76/ai-idp/internal/healthcheck"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/metrics"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/ratelimit"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/tenants"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadWithDefaults("tenant-service", "8085")
+
+	// Initialize logger
+	appLogger := logger.NewWithOptions(cfg.Logging.Level, cfg.Logging.Format, logger.Options{
+		Async:       cfg.Logging.Async,
+		BufferSize:  int(cfg.Logging.BufferSize),
+		Destination: cfg.Logging.Output,
+		FilePath:    cfg.Logging.FilePath,
+		MaxSizeMB:   int(cfg.Logging.MaxSizeMB),
+		MaxBackups:  int(cfg.Logging.MaxBackups),
+	})
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "tenant-service",
+		"port":                cfg.Server.Port,
+	}).Info("Starting Tenant Service")
+
+	// Setup database connection
+	ctx := context.Background()
+	dbConfig := database.DefaultConfig(cfg.Database.URL)
+	// Log slow queries and count every query for the metrics endpoint
+	queryTracer := database.NewQueryTracer(cfg.Database.SlowQueryThreshold, func(ctx context.Context, q database.SlowQuery) {
+		fields := logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			logger.FieldDuration:  q.Duration.String(),
+			"sql":                 q.SQL,
+		}
+		if q.Err != nil {
+			fields[logger.FieldError] = q.Err.Error()
+		}
+		appLogger.WithFields(fields).Warn("Slow database query")
+	})
+	dbConfig.Tracer = queryTracer
+	// Wait for the database when the service starts first, as in docker-compose
+	dbPool, err := database.NewPoolWithRetry(ctx, dbConfig,
+		int(cfg.Database.StartupRetryAttempts), cfg.Database.StartupRetryBackoff,
+		database.WithConnectAttemptHook(func(attempt int, err error, wait time.Duration) {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "tenant-service",
+				logger.FieldError:     err.Error(),
+				"attempt":             attempt,
+				"retry_in":            wait.String(),
+			}).Warn("Database connection attempt failed")
+		}))
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Failed to connect to database")
+	}
+	defer dbPool.Close()
+
+	// Initialize tenant manager; tenant creation provisions a database, so it is
+	// rate limited and capped independently of the general API
+	var globalLimiter *ratelimit.Limiter
+	if cfg.Tenants.CreateRatePerMinute > 0 {
+		globalLimiter = ratelimit.New(ratelimit.PerMinute(cfg.Tenants.CreateRatePerMinute), cfg.Tenants.CreateBurst)
+	}
+	var callerLimiter *ratelimit.KeyedLimiter
+	if cfg.Tenants.CallerCreateRatePerMinute > 0 {
+		callerLimiter = ratelimit.NewKeyed(ratelimit.PerMinute(cfg.Tenants.CallerCreateRatePerMinute), cfg.Tenants.CallerCreateBurst)
+	}
+
+	tenantManager := database.NewTenantManager(dbPool,
+		database.WithCreationRateLimit(globalLimiter, callerLimiter),
+		database.WithMaxTenants(cfg.Tenants.MaxTenants),
+		database.WithUsageCacheTTL(cfg.Tenants.UsageCacheTTL),
+	)
+	tenantHandlers := tenants.NewHandlers(tenantManager, appLogger,
+		tenants.WithResponseEnvelope(cfg.Server.ResponseEnvelope),
+		tenants.WithPageLimits(server.PageLimits{DefaultLimit: cfg.Pagination.DefaultLimit, MaxLimit: cfg.Pagination.MaxLimit}),
+		tenants.WithAdminToken(cfg.Security.AdminToken),
+	)
+
+	// Create HTTP server mux
+	mux := http.NewServeMux()
+
+	// Health and probe endpoints (for Kubernetes); readiness pings the database
+	healthcheck.RegisterHandlers(mux, "ai-idp-tenant-service", dbPool)
+
+	// Tenant API endpoints; creating tenants provisions databases and listing them
+	// shows every tenant, so both are admin only. The reads below are open to admins
+	// and the tenant itself.
+	requireAdmin := middleware.RequireAdmin(cfg.Security.AdminToken)
+	mux.Handle("POST /api/v1/tenants", requireAdmin(http.HandlerFunc(tenantHandlers.CreateTenant)))
+	mux.Handle("GET /api/v1/tenants", requireAdmin(http.HandlerFunc(tenantHandlers.ListTenants)))
+	mux.HandleFunc("GET /api/v1/tenants/{id}", tenantHandlers.GetTenant)
+	mux.HandleFunc("GET /api/v1/tenants/by-slug/{slug}", tenantHandlers.GetTenantBySlug)
+	mux.HandleFunc("GET /api/v1/tenants/{id}/usage", tenantHandlers.GetTenantUsage)
+
+	// Profiling endpoints (admin only, disabled unless ENABLE_PROFILING is set)
+	if debug.RegisterProfiling(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+		}).Warn("Profiling endpoints enabled under /debug/pprof/")
+	}
+
+	// Effective configuration, secrets redacted (admin only, disabled unless ENABLE_DEBUG_CONFIG is set)
+	if debug.RegisterConfig(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+		}).Warn("Config endpoint enabled at /debug/config")
+	}
+
+	// Schema migration status (admin only), left out when the migration files are missing
+	migrations, err := database.NewMigrationManager(dbPool, cfg.Database.MigrationsPath)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			logger.FieldError:     err.Error(),
+		}).Warn("Migration status endpoint disabled")
+	} else {
+		defer migrations.Close()
+		server.RegisterMigrationStatus(mux, migrations, cfg.Security.AdminToken)
+	}
+
+	// Prometheus metrics, labelled by route pattern
+	metricsRegistry := metrics.NewRegistry()
+	metrics.RegisterPool(metricsRegistry, dbPool.Stats)
+	metrics.RegisterQueries(metricsRegistry, queryTracer.Stats)
+	mux.Handle("GET /metrics", metricsRegistry.Handler())
+
+	// Reads may be cached privately; mutations and sensitive reads are never stored
+	cachePolicy := server.NewCachePolicy(cfg.Server.CacheMaxAge).
+		Route("GET /health", server.NoStore).
+		Route("GET /readiness", server.NoStore).
+		Route("GET /metrics", server.NoStore)
+	// Count requests inside Timeout, whose abandoned handlers keep running, so shutdown
+	// can wait for them before the database is closed
+	inFlight := middleware.NewInFlight()
+	loggingOptions := []middleware.LoggingOption{middleware.WithSampleRate(int(cfg.Logging.SampleRate))}
+	if cfg.Logging.SkipProbes {
+		loggingOptions = append(loggingOptions, middleware.WithoutPaths(middleware.ProbePaths...))
+	}
+
+	// Apply middleware chain, outermost first
+	stack := []middleware.Middleware{
+		middleware.Logging(appLogger, loggingOptions...),
+		middleware.RequestID,
+		middleware.Metrics(metrics.NewHTTP(metricsRegistry), mux),
+		middleware.Deadline(cfg.Server.RequestDeadline),
+		middleware.MaxBodyBytes(cfg.Server.MaxBodyBytes),
+		middleware.Timeout(cfg.Server.RequestTimeout),
+		middleware.Recover(appLogger),
+		inFlight.Middleware,
+	}
+	if cfg.Security.RequireAuth {
+		stack = append(stack, middleware.JWTAuth(cfg.Security.JWTSecret, middleware.WithAdminToken(cfg.Security.AdminToken)))
+	}
+	// Limit callers after authentication so tenants are limited as a whole
+	if cfg.RateLimit.Enabled {
+		stack = append(stack, middleware.RateLimit(ratelimit.NewKeyed(float64(cfg.RateLimit.RequestsPerSecond), cfg.RateLimit.Burst),
+			middleware.WithTrustedProxies(cfg.RateLimit.TrustedProxies...)))
+	}
+	stack = append(stack, cachePolicy.Middleware)
+	if cfg.QoS.Enabled {
+		stack = append(stack, middleware.ConcurrencyLimit(middleware.ConcurrencyLimitConfig{
+			MaxConcurrent:       cfg.QoS.MaxConcurrent,
+			NormalPriorityLimit: cfg.QoS.NormalPriorityLimit,
+			LowPriorityLimit:    cfg.QoS.LowPriorityLimit,
+		}))
+	}
+	handler := middleware.Chain(stack...)(mux)
+
+	// Create HTTP server
+	server := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      handler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			"address":             server.Addr,
+		}).Info("Tenant Service server starting")
+
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "tenant-service",
+				logger.FieldError:     err.Error(),
+			}).Error("Server failed to start")
+			os.Exit(1)
+		}
+	}()
+
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "tenant-service",
+		"port":                cfg.Server.Port,
+	}).Info("Tenant Service server started successfully")
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "tenant-service",
+		"in_flight":           inFlight.Count(),
+	}).Info("Tenant Service server shutting down")
+
+	// Create a context with timeout for shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Attempt graceful shutdown
+	if err := server.Shutdown(ctx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			logger.FieldError:     err.Error(),
+		}).Error("Server forced to shutdown")
+		_ = appLogger.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	// Wait for handlers still running, such as those abandoned by Timeout, before the
+	// deferred close of the database pool
+	if err := inFlight.Wait(ctx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			"in_flight":           inFlight.Count(),
+		}).Warn("Requests still in flight at the shutdown timeout")
+	}
+
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "tenant-service",
+	}).Info("Tenant Service server stopped")
+
+	// Flush buffered log records
+	if dropped := appLogger.DroppedRecords(); dropped > 0 {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "tenant-service",
+			"dropped_records":     dropped,
+		}).Warn("Log records were dropped by the async logger")
+	}
+	_ = appLogger.Shutdown(ctx)
+}
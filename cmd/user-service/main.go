@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/auth"
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/debug"
+	"github.com/aykay76/ai-idp/internal/healthcheck"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/metrics"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/ratelimit"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/users"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadWithDefaults("user-service", "8084")
+
+	// Initialize logger
+	appLogger := logger.NewWithOptions(cfg.Logging.Level, cfg.Logging.Format, logger.Options{
+		Async:       cfg.Logging.Async,
+		BufferSize:  int(cfg.Logging.BufferSize),
+		Destination: cfg.Logging.Output,
+		FilePath:    cfg.Logging.FilePath,
+		MaxSizeMB:   int(cfg.Logging.MaxSizeMB),
+		MaxBackups:  int(cfg.Logging.MaxBackups),
+	})
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "user-service",
+		"port":                cfg.Server.Port,
+	}).Info("Starting User Service")
+
+	// Setup database connection
+	ctx := context.Background()
+	dbConfig := database.DefaultConfig(cfg.Database.URL)
+	// Log slow queries and count every query for the metrics endpoint
+	queryTracer := database.NewQueryTracer(cfg.Database.SlowQueryThreshold, func(ctx context.Context, q database.SlowQuery) {
+		fields := logger.LogFields{
+			logger.FieldComponent: "user-service",
+			logger.FieldDuration:  q.Duration.String(),
+			"sql":                 q.SQL,
+		}
+		if q.Err != nil {
+			fields[logger.FieldError] = q.Err.Error()
+		}
+		appLogger.WithFields(fields).Warn("Slow database query")
+	})
+	dbConfig.Tracer = queryTracer
+	// Wait for the database when the service starts first, as in docker-compose
+	dbPool, err := database.NewPoolWithRetry(ctx, dbConfig,
+		int(cfg.Database.StartupRetryAttempts), cfg.Database.StartupRetryBackoff,
+		database.WithConnectAttemptHook(func(attempt int, err error, wait time.Duration) {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "user-service",
+				logger.FieldError:     err.Error(),
+				"attempt":             attempt,
+				"retry_in":            wait.String(),
+			}).Warn("Database connection attempt failed")
+		}))
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Failed to connect to database")
+	}
+	defer dbPool.Close()
+
+	// Degrade to read-only mode on the replica if the primary becomes unreachable
+	var failover *database.Failover
+	if cfg.Database.ReplicaURL != "" {
+		replicaPool, err := database.NewPool(ctx, database.DefaultConfig(cfg.Database.ReplicaURL))
+		if err != nil {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "user-service",
+				logger.FieldError:     err.Error(),
+			}).Fatal("Failed to connect to read replica")
+		}
+		defer replicaPool.Close()
+
+		failover = database.NewFailover(dbPool, replicaPool, database.WithReadOnlyChangeHook(func(readOnly bool, cause error) {
+			if readOnly {
+				appLogger.WithFields(logger.LogFields{
+					logger.FieldComponent: "user-service",
+					logger.FieldError:     cause.Error(),
+				}).Warn("Primary database unavailable, entering read-only mode")
+				return
+			}
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "user-service",
+			}).Info("Primary database recovered, leaving read-only mode")
+		}))
+
+		failoverCtx, stopFailover := context.WithCancel(ctx)
+		defer stopFailover()
+		go failover.Run(failoverCtx, cfg.Database.FailoverCheckInterval)
+	}
+
+	// Initialize user service
+	userService := users.NewService(dbPool,
+		users.WithFailover(failover),
+		users.WithReadRetry(database.NewRetryPolicy(int(cfg.Database.ReadRetryAttempts), cfg.Database.ReadRetryBackoff)),
+	)
+	userHandlers := users.NewHandlers(userService, appLogger,
+		users.WithResponseEnvelope(cfg.Server.ResponseEnvelope),
+		users.WithPageLimits(server.PageLimits{DefaultLimit: cfg.Pagination.DefaultLimit, MaxLimit: cfg.Pagination.MaxLimit}),
+	)
+
+	// Create HTTP server mux
+	mux := http.NewServeMux()
+
+	// Health and probe endpoints (for Kubernetes); readiness pings the database
+	healthcheck.RegisterHandlers(mux, "ai-idp-user-service", dbPool, healthcheck.WithFailover(failover))
+
+	// User API endpoints
+	mux.HandleFunc("POST /api/v1/users", userHandlers.CreateUser)
+	mux.HandleFunc("GET /api/v1/users/{id}", userHandlers.GetUser)
+	mux.HandleFunc("PUT /api/v1/users/{id}", userHandlers.UpdateUser)
+	mux.HandleFunc("DELETE /api/v1/users/{id}", userHandlers.DeleteUser)
+	mux.HandleFunc("GET /api/v1/users", userHandlers.ListUsers)
+
+	// Login endpoints, issuing the tokens AUTH_REQUIRED accepts; public, as callers
+	// have no token yet
+	var providers []auth.HandlerOption
+	if cfg.GitHub.ClientID != "" && cfg.GitHub.ClientSecret != "" {
+		providers = append(providers, auth.WithProvider(auth.NewGitHub(cfg.GitHub.ClientID, cfg.GitHub.ClientSecret, auth.GitHubOptions{})))
+	}
+	if cfg.Auth.LocalLogin {
+		providers = append(providers, auth.WithProvider(auth.Local{}))
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+		}).Warn("Local login enabled; any email address can sign in")
+	}
+	if len(providers) > 0 && cfg.Security.JWTSecret != "" {
+		authHandlers := auth.NewHandlers(auth.NewIssuer(cfg.Security.JWTSecret, cfg.Auth.TokenTTL), auth.NewMembershipService(dbPool), appLogger,
+			append(providers,
+				auth.WithResponseEnvelope(cfg.Server.ResponseEnvelope),
+				auth.WithRefreshTokens(auth.NewRefreshService(dbPool, cfg.Auth.RefreshTokenTTL)),
+			)...,
+		)
+		mux.HandleFunc("POST /api/v1/auth/login", authHandlers.Login)
+		mux.HandleFunc("GET /api/v1/auth/callback", authHandlers.Callback)
+		mux.HandleFunc("POST /api/v1/auth/refresh", authHandlers.Refresh)
+		mux.HandleFunc("POST /api/v1/auth/logout", authHandlers.Logout)
+	}
+
+	// Profiling endpoints (admin only, disabled unless ENABLE_PROFILING is set)
+	if debug.RegisterProfiling(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+		}).Warn("Profiling endpoints enabled under /debug/pprof/")
+	}
+
+	// Effective configuration, secrets redacted (admin only, disabled unless ENABLE_DEBUG_CONFIG is set)
+	if debug.RegisterConfig(mux, cfg) {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+		}).Warn("Config endpoint enabled at /debug/config")
+	}
+
+	// Schema migration status (admin only), left out when the migration files are missing
+	migrations, err := database.NewMigrationManager(dbPool, cfg.Database.MigrationsPath)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			logger.FieldError:     err.Error(),
+		}).Warn("Migration status endpoint disabled")
+	} else {
+		defer migrations.Close()
+		server.RegisterMigrationStatus(mux, migrations, cfg.Security.AdminToken)
+	}
+
+	// Prometheus metrics, labelled by route pattern
+	metricsRegistry := metrics.NewRegistry()
+	metrics.RegisterPool(metricsRegistry, dbPool.Stats)
+	metrics.RegisterQueries(metricsRegistry, queryTracer.Stats)
+	mux.Handle("GET /metrics", metricsRegistry.Handler())
+
+	// Reads may be cached privately; mutations and sensitive reads are never stored
+	cachePolicy := server.NewCachePolicy(cfg.Server.CacheMaxAge).
+		Route("GET /health", server.NoStore).
+		Route("GET /readiness", server.NoStore).
+		Route("GET /metrics", server.NoStore).
+		Route("POST /api/v1/auth/login", server.NoStore).
+		Route("GET /api/v1/auth/callback", server.NoStore).
+		Route("POST /api/v1/auth/refresh", server.NoStore)
+	maintenance := database.NewMaintenanceSchedule(dbPool, database.DefaultMaintenanceCacheTTL)
+	// Tenant checks apply to the tenant named by the sources in TENANT_RESOLUTION
+	tenantSources, err := server.ParseTenantSources(cfg.Tenants.Resolution, cfg.Tenants.BaseDomain,
+		database.NewTenantNameCache(dbPool, cfg.Tenants.NameCacheTTL).ID)
+	if err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			logger.FieldError:     err.Error(),
+		}).Fatal("Invalid tenant resolution")
+	}
+	tenantResolver := server.NewTenantResolver(tenantSources...)
+	// Count requests inside Timeout, whose abandoned handlers keep running, so shutdown
+	// can wait for them before the database is closed
+	inFlight := middleware.NewInFlight()
+	loggingOptions := []middleware.LoggingOption{middleware.WithSampleRate(int(cfg.Logging.SampleRate))}
+	if cfg.Logging.SkipProbes {
+		loggingOptions = append(loggingOptions, middleware.WithoutPaths(middleware.ProbePaths...))
+	}
+
+	// Apply middleware chain, outermost first
+	stack := []middleware.Middleware{
+		middleware.Logging(appLogger, loggingOptions...),
+		middleware.RequestID,
+		middleware.Metrics(metrics.NewHTTP(metricsRegistry), mux),
+		middleware.Deadline(cfg.Server.RequestDeadline),
+		middleware.MaxBodyBytes(cfg.Server.MaxBodyBytes),
+		middleware.Timeout(cfg.Server.RequestTimeout),
+		middleware.Recover(appLogger),
+		inFlight.Middleware,
+	}
+	if cfg.Security.RequireAuth {
+		stack = append(stack, middleware.JWTAuth(cfg.Security.JWTSecret, middleware.WithAdminToken(cfg.Security.AdminToken)))
+	}
+	// Limit callers after authentication so tenants are limited as a whole
+	if cfg.RateLimit.Enabled {
+		stack = append(stack, middleware.RateLimit(ratelimit.NewKeyed(float64(cfg.RateLimit.RequestsPerSecond), cfg.RateLimit.Burst),
+			middleware.WithTrustedProxies(cfg.RateLimit.TrustedProxies...)))
+	}
+	stack = append(stack, cachePolicy.Middleware)
+	// Resolve the tenant once, so the suspension and maintenance checks act on the same
+	// tenant as the handlers whether it came from the header, token or subdomain
+	stack = append(stack, tenantResolver.Middleware)
+	if cfg.Tenants.BlockSuspended {
+		tenantStatus := database.NewTenantStatusCache(dbPool, cfg.Tenants.StatusCacheTTL)
+		stack = append(stack, middleware.TenantSuspension(tenantStatus.Status, cfg.Security.AdminToken))
+	}
+	stack = append(stack, middleware.TenantMaintenance(maintenance.ActiveUntil, nil))
+	if failover != nil {
+		stack = append(stack, middleware.ReadOnly(failover.ReadOnly))
+	}
+	if cfg.QoS.Enabled {
+		stack = append(stack, middleware.ConcurrencyLimit(middleware.ConcurrencyLimitConfig{
+			MaxConcurrent:       cfg.QoS.MaxConcurrent,
+			NormalPriorityLimit: cfg.QoS.NormalPriorityLimit,
+			LowPriorityLimit:    cfg.QoS.LowPriorityLimit,
+		}))
+	}
+	handler := middleware.Chain(stack...)(mux)
+
+	// Create HTTP server
+	server := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      handler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			"address":             server.Addr,
+		}).Info("User Service server starting")
+
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			appLogger.WithFields(logger.LogFields{
+				logger.FieldComponent: "user-service",
+				logger.FieldError:     err.Error(),
+			}).Error("Server failed to start")
+			os.Exit(1)
+		}
+	}()
+
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "user-service",
+		"port":                cfg.Server.Port,
+	}).Info("User Service server started successfully")
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "user-service",
+		"in_flight":           inFlight.Count(),
+	}).Info("User Service server shutting down")
+
+	// Create a context with timeout for shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Attempt graceful shutdown
+	if err := server.Shutdown(ctx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			logger.FieldError:     err.Error(),
+		}).Error("Server forced to shutdown")
+		_ = appLogger.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	// Wait for handlers still running, such as those abandoned by Timeout, before the
+	// deferred close of the database pool
+	if err := inFlight.Wait(ctx); err != nil {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			"in_flight":           inFlight.Count(),
+		}).Warn("Requests still in flight at the shutdown timeout")
+	}
+
+	appLogger.WithFields(logger.LogFields{
+		logger.FieldComponent: "user-service",
+	}).Info("User Service server stopped")
+
+	// Flush buffered log records
+	if dropped := appLogger.DroppedRecords(); dropped > 0 {
+		appLogger.WithFields(logger.LogFields{
+			logger.FieldComponent: "user-service",
+			"dropped_records":     dropped,
+		}).Warn("Log records were dropped by the async logger")
+	}
+	_ = appLogger.Shutdown(ctx)
+}
@@ -0,0 +1,77 @@
+package applications
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidAnnotations is returned when annotations fail validation
+var ErrInvalidAnnotations = errors.New("invalid annotations")
+
+// ReservedAnnotationPrefix is the annotation namespace owned by the platform. Only
+// keys registered in KnownAnnotations may use it; any other key is a user annotation.
+const ReservedAnnotationPrefix = "aiidp.io/"
+
+// Recognized annotation keys
+const (
+	// AnnotationSuppressRetirementWarning omits the Warning header on responses for
+	// an application scheduled for retirement; the Sunset header is still sent
+	AnnotationSuppressRetirementWarning = ReservedAnnotationPrefix + "suppress-retirement-warning"
+)
+
+// AnnotationSpec describes a recognized annotation and the values it accepts
+type AnnotationSpec struct {
+	Key           string
+	Description   string
+	AllowedValues []string
+}
+
+var booleanValues = []string{"true", "false"}
+
+// KnownAnnotations is the registry of annotations in the reserved namespace
+var KnownAnnotations = map[string]AnnotationSpec{
+	AnnotationSuppressRetirementWarning: {
+		Key:           AnnotationSuppressRetirementWarning,
+		Description:   "Omit the Warning header for applications scheduled for retirement",
+		AllowedValues: booleanValues,
+	},
+}
+
+// ValidateAnnotations checks annotation keys and, for reserved keys, their values
+func ValidateAnnotations(annotations map[string]string) error {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // deterministic error messages
+
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%w: annotation key must not be empty", ErrInvalidAnnotations)
+		}
+
+		if !strings.HasPrefix(key, ReservedAnnotationPrefix) {
+			continue
+		}
+
+		spec, ok := KnownAnnotations[key]
+		if !ok {
+			return fmt.Errorf("%w: unknown annotation %q in reserved namespace %s", ErrInvalidAnnotations, key, ReservedAnnotationPrefix)
+		}
+
+		if value := annotations[key]; len(spec.AllowedValues) > 0 && !slices.Contains(spec.AllowedValues, value) {
+			return fmt.Errorf("%w: annotation %q must be one of %s, got %q",
+				ErrInvalidAnnotations, key, strings.Join(spec.AllowedValues, ", "), value)
+		}
+	}
+
+	return nil
+}
+
+// AnnotationEnabled reports whether a boolean toggle annotation is set to "true"
+func (a *Application) AnnotationEnabled(key string) bool {
+	return a.Annotations[key] == "true"
+}
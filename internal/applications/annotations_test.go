@@ -0,0 +1,47 @@
+package applications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{"nil annotations", nil, false},
+		{"user annotations are allowed", map[string]string{"example.com/owner": "payments", "tier": "gold"}, false},
+		{"recognized toggle", map[string]string{AnnotationSuppressRetirementWarning: "true"}, false},
+		{"recognized toggle disabled", map[string]string{AnnotationSuppressRetirementWarning: "false"}, false},
+		{"unknown reserved annotation", map[string]string{"aiidp.io/turbo-mode": "true"}, true},
+		{"unenforced toggles are not recognized", map[string]string{"aiidp.io/skip-policy": "true"}, true},
+		{"invalid value for recognized toggle", map[string]string{AnnotationSuppressRetirementWarning: "yes"}, true},
+		{"empty key", map[string]string{"": "value"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAnnotations(tt.annotations)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAnnotations)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApplication_AnnotationEnabled(t *testing.T) {
+	app := &Application{Annotations: map[string]string{
+		AnnotationSuppressRetirementWarning: "true",
+		"example.com/disabled":              "false",
+	}}
+
+	assert.True(t, app.AnnotationEnabled(AnnotationSuppressRetirementWarning))
+	assert.False(t, app.AnnotationEnabled("example.com/disabled"))
+	assert.False(t, app.AnnotationEnabled("example.com/missing"))
+	assert.False(t, (&Application{}).AnnotationEnabled(AnnotationSuppressRetirementWarning))
+}
@@ -0,0 +1,227 @@
+package applications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/audit"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Approval workflow states. Approved applications continue as pending provisioning.
+const (
+	StatusPendingApproval = "pending-approval"
+	StatusRejected        = "rejected"
+	StatusPending         = "pending"
+)
+
+// Approval errors
+var (
+	ErrNotPendingApproval = errors.New("application is not pending approval")
+	ErrNotApprover        = errors.New("only an owner or maintainer of the application's team can review it")
+)
+
+// AutoApprovalSetting is the tenant settings and team policies key that skips the
+// approval workflow
+const AutoApprovalSetting = "auto_approval"
+
+// approverRoles are the team roles allowed to review applications
+var approverRoles = map[string]bool{"owner": true, "maintainer": true}
+
+// ApprovalNotifier is told when an application is waiting for review so that its
+// approvers can be notified
+type ApprovalNotifier interface {
+	ApprovalRequested(ctx context.Context, app *Application, approvers []string)
+}
+
+// ApprovalNotifierFunc adapts a function to ApprovalNotifier
+type ApprovalNotifierFunc func(ctx context.Context, app *Application, approvers []string)
+
+// ApprovalRequested calls f
+func (f ApprovalNotifierFunc) ApprovalRequested(ctx context.Context, app *Application, approvers []string) {
+	f(ctx, app, approvers)
+}
+
+// WithApprovalWorkflow requires production applications to be approved by an owner
+// or maintainer of their team before they proceed, unless the tenant or team has
+// auto-approval enabled. The notifier may be nil.
+func WithApprovalWorkflow(notifier ApprovalNotifier) Option {
+	return func(s *Service) {
+		s.approvals = true
+		s.approvalNotifier = notifier
+	}
+}
+
+// ReviewApplicationRequest carries an optional comment recorded with a review decision
+type ReviewApplicationRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// teamReviewers is the review configuration of a team
+type teamReviewers struct {
+	autoApproval bool
+	approvers    []string
+}
+
+// requiresApproval reports whether a new application must wait for review and who
+// may review it
+func (s *Service) requiresApproval(ctx context.Context, tenantID uuid.UUID, teamName, lifecycle string) (bool, []string, error) {
+	if !s.approvals || lifecycle != "production" {
+		return false, nil, nil
+	}
+
+	reviewers, err := loadTeamReviewers(ctx, s.db, tenantID, teamName)
+	if err != nil {
+		return false, nil, err
+	}
+	if reviewers.autoApproval {
+		return false, nil, nil
+	}
+
+	return true, reviewers.approvers, nil
+}
+
+// ApproveApplication approves an application pending approval; it then continues to
+// provisioning
+func (s *Service) ApproveApplication(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest) (*Application, error) {
+	return s.review(ctx, tenantID, id, reviewer, req, StatusPending, audit.ActionApprove)
+}
+
+// RejectApplication rejects an application pending approval
+func (s *Service) RejectApplication(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest) (*Application, error) {
+	return s.review(ctx, tenantID, id, reviewer, req, StatusRejected, audit.ActionReject)
+}
+
+// review records a review decision, moving the application to status. The decision,
+// or the reason it was refused, is audited as action.
+func (s *Service) review(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest, status, action string) (*Application, error) {
+	var comment *string
+	if req != nil && req.Comment != "" {
+		comment = &req.Comment
+	}
+
+	var app *Application
+	var name, current string
+	err := s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		query := `
+			SELECT name, team_name, status FROM resource_management.applications
+			WHERE tenant_id = $1 AND id = $2
+			FOR UPDATE
+		`
+
+		var teamName string
+		if err := tx.QueryRow(ctx, query, tenantID, id).Scan(&name, &teamName, &current); err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrApplicationNotFound
+			}
+			return fmt.Errorf("failed to lock application: %w", err)
+		}
+
+		if current != StatusPendingApproval {
+			return ErrNotPendingApproval
+		}
+
+		reviewers, err := loadTeamReviewers(ctx, tx, tenantID, teamName)
+		if err != nil {
+			return err
+		}
+		if !containsFold(reviewers.approvers, reviewer) {
+			return ErrNotApprover
+		}
+
+		update := `
+			UPDATE resource_management.applications
+			SET status = $3, reviewed_by = $4, reviewed_at = $5, review_comment = $6,
+			    updated_by = $4, updated_at = $5, version = version + 1
+			WHERE tenant_id = $1 AND id = $2
+			RETURNING ` + applicationColumns
+
+		app, err = scanApplication(tx.QueryRow(ctx, update, tenantID, id, status, reviewer, s.now().UTC(), comment))
+		if err != nil {
+			return fmt.Errorf("failed to record review: %w", err)
+		}
+
+		return recordRevision(ctx, tx, app, reviewer)
+	})
+
+	details := map[string]string{"previous_status": current, "status": status}
+	if comment != nil {
+		details["comment"] = *comment
+	}
+	s.recordAuditDetails(ctx, action, tenantID, id, name, reviewer, details, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// queryRower and execer are satisfied by both pools and transactions
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// loadTeamReviewers reads whether a team, or its tenant, has auto-approval and who
+// may review its applications: the team lead and its active owners and maintainers.
+// Unknown teams have no approvers.
+func loadTeamReviewers(ctx context.Context, q queryRower, tenantID uuid.UUID, teamName string) (*teamReviewers, error) {
+	query := `
+		SELECT COALESCE((t.settings->>$3)::boolean, false),
+		       COALESCE((tm.policies->>$3)::boolean, false),
+		       tm.lead_email, COALESCE(tm.members, '[]')
+		FROM control_plane.tenants t
+		LEFT JOIN resource_management.teams tm ON tm.tenant_id = t.id AND tm.name = $2 AND tm.deleted_at IS NULL
+		WHERE t.id = $1
+	`
+
+	var tenantAuto, teamAuto bool
+	var leadEmail *string
+	var membersJSON []byte
+	if err := q.QueryRow(ctx, query, tenantID, teamName, AutoApprovalSetting).Scan(&tenantAuto, &teamAuto, &leadEmail, &membersJSON); err != nil {
+		if err == pgx.ErrNoRows {
+			return &teamReviewers{}, nil
+		}
+		return nil, fmt.Errorf("failed to load team reviewers: %w", err)
+	}
+
+	reviewers := &teamReviewers{autoApproval: tenantAuto || teamAuto}
+	if leadEmail != nil {
+		reviewers.approvers = append(reviewers.approvers, *leadEmail)
+	}
+
+	var members []struct {
+		Email  string `json:"email"`
+		Role   string `json:"role"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(membersJSON, &members); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal team members: %w", err)
+	}
+	for _, m := range members {
+		if approverRoles[m.Role] && (m.Status == "" || m.Status == "active") && !containsFold(reviewers.approvers, m.Email) {
+			reviewers.approvers = append(reviewers.approvers, m.Email)
+		}
+	}
+
+	return reviewers, nil
+}
+
+// containsFold reports whether values contains s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
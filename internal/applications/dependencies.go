@@ -0,0 +1,297 @@
+package applications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidDependencies is returned when declared dependencies fail validation
+var ErrInvalidDependencies = errors.New("invalid dependencies")
+
+// DependencyTypeService marks a dependency on another application in the same tenant,
+// referenced by name. Other dependency types refer to the application's own declared
+// resources.
+const DependencyTypeService = "service"
+
+// Rolled-up application health
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+)
+
+// Dependency health states
+const (
+	DependencyHealthy   = "healthy"
+	DependencyUnhealthy = "unhealthy"
+	// DependencyUnknown is reported for dependencies the platform cannot observe,
+	// such as external APIs; they never degrade the rollup
+	DependencyUnknown = "unknown"
+)
+
+// DependencyStatus is the observed health of a single declared dependency
+type DependencyStatus struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Health   string `json:"health"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ApplicationStatus is an application's own status rolled up with the health of its
+// dependencies. Health is degraded if the application's resources are not ready or
+// any required dependency is unhealthy; optional dependencies are reported but do
+// not affect it.
+type ApplicationStatus struct {
+	ApplicationID         uuid.UUID          `json:"application_id"`
+	Status                string             `json:"status"`
+	ResourcesReady        bool               `json:"resources_ready"`
+	Health                string             `json:"health"`
+	Dependencies          []DependencyStatus `json:"dependencies"`
+	UnhealthyDependencies []string           `json:"unhealthy_dependencies"`
+}
+
+// dependencyTarget is the state of an application another one depends on
+type dependencyTarget struct {
+	Status    string
+	Resources []ApplicationResource
+}
+
+// SetDependencies replaces the dependencies an application declares
+func (s *Service) SetDependencies(ctx context.Context, tenantID, id uuid.UUID, deps []types.DependencySpec) ([]types.DependencySpec, error) {
+	if err := validateDependencySpecs(deps); err != nil {
+		return nil, err
+	}
+	if deps == nil {
+		deps = []types.DependencySpec{}
+	}
+
+	err := s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		query := `
+			SELECT name, retirement_date FROM resource_management.applications
+			WHERE tenant_id = $1 AND id = $2
+			FOR UPDATE
+		`
+
+		var name string
+		var retirementDate *time.Time
+		if err := tx.QueryRow(ctx, query, tenantID, id).Scan(&name, &retirementDate); err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrApplicationNotFound
+			}
+			return fmt.Errorf("failed to lock application: %w", err)
+		}
+
+		app := Application{RetirementDate: retirementDate}
+		if app.IsRetired(s.now()) {
+			return ErrApplicationRetired
+		}
+
+		for _, dep := range deps {
+			if dep.Type == DependencyTypeService && dep.Name == name {
+				return fmt.Errorf("%w: application cannot depend on itself", ErrInvalidDependencies)
+			}
+		}
+
+		depsJSON, err := json.Marshal(deps)
+		if err != nil {
+			return fmt.Errorf("failed to marshal dependencies: %w", err)
+		}
+
+		update := `
+			UPDATE resource_management.applications
+			SET dependencies = $3, updated_at = $4, version = version + 1
+			WHERE tenant_id = $1 AND id = $2
+		`
+		if _, err := tx.Exec(ctx, update, tenantID, id, depsJSON, s.now().UTC()); err != nil {
+			return fmt.Errorf("failed to update dependencies: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deps, nil
+}
+
+// GetStatus returns an application's status rolled up with the health of its
+// dependencies
+func (s *Service) GetStatus(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationStatus, error) {
+	query := `
+		SELECT status, resources, dependencies FROM resource_management.applications
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var status string
+	var resourcesJSON, depsJSON []byte
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.reader().QueryRow(ctx, query, tenantID, id).Scan(&status, &resourcesJSON, &depsJSON)
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, fmt.Errorf("failed to get application status: %w", err)
+	}
+
+	var resources []ApplicationResource
+	if err := unmarshalJSONB(resourcesJSON, &resources); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal resources: %w", err)
+	}
+	var deps []types.DependencySpec
+	if err := unmarshalJSONB(depsJSON, &deps); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dependencies: %w", err)
+	}
+
+	targets, err := s.dependencyTargets(ctx, tenantID, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	return rollupStatus(id, status, resources, deps, targets), nil
+}
+
+// dependencyTargets loads the applications named by service dependencies. A name may
+// belong to a live application and to terminated ones it replaced; the live one wins.
+func (s *Service) dependencyTargets(ctx context.Context, tenantID uuid.UUID, deps []types.DependencySpec) (map[string]dependencyTarget, error) {
+	var names []string
+	for _, dep := range deps {
+		if dep.Type == DependencyTypeService {
+			names = append(names, dep.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT DISTINCT ON (name) name, status, resources FROM resource_management.applications
+		WHERE tenant_id = $1 AND name = ANY($2)
+		ORDER BY name, status = $3, updated_at DESC
+	`
+
+	var targets map[string]dependencyTarget
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		rows, err := s.reader().Query(ctx, query, tenantID, names, StatusTerminated)
+		if err != nil {
+			return fmt.Errorf("failed to get dependencies: %w", err)
+		}
+		defer rows.Close()
+
+		targets = make(map[string]dependencyTarget, len(names))
+		for rows.Next() {
+			var name string
+			var target dependencyTarget
+			var resourcesJSON []byte
+			if err := rows.Scan(&name, &target.Status, &resourcesJSON); err != nil {
+				return fmt.Errorf("failed to scan dependency: %w", err)
+			}
+			if err := unmarshalJSONB(resourcesJSON, &target.Resources); err != nil {
+				return fmt.Errorf("failed to unmarshal dependency resources: %w", err)
+			}
+			targets[name] = target
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate dependencies: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// rollupStatus derives an application's health from its resources and the health of
+// each declared dependency
+func rollupStatus(id uuid.UUID, status string, resources []ApplicationResource, deps []types.DependencySpec, targets map[string]dependencyTarget) *ApplicationStatus {
+	result := &ApplicationStatus{
+		ApplicationID:         id,
+		Status:                status,
+		ResourcesReady:        resourcesReady(resources),
+		Health:                HealthOK,
+		Dependencies:          make([]DependencyStatus, 0, len(deps)),
+		UnhealthyDependencies: []string{},
+	}
+	if !result.ResourcesReady || !applicationRunning(status) {
+		result.Health = HealthDegraded
+	}
+
+	for _, dep := range deps {
+		depStatus := dependencyStatus(dep, resources, targets)
+		result.Dependencies = append(result.Dependencies, depStatus)
+
+		if dep.Required && depStatus.Health == DependencyUnhealthy {
+			result.Health = HealthDegraded
+			result.UnhealthyDependencies = append(result.UnhealthyDependencies, dep.Name)
+		}
+	}
+
+	return result
+}
+
+// dependencyStatus reports the health of one dependency. Service dependencies are
+// resolved to applications; other types to the declaring application's resources.
+func dependencyStatus(dep types.DependencySpec, resources []ApplicationResource, targets map[string]dependencyTarget) DependencyStatus {
+	result := DependencyStatus{Name: dep.Name, Type: dep.Type, Required: dep.Required, Health: DependencyHealthy}
+
+	if dep.Type == DependencyTypeService {
+		target, ok := targets[dep.Name]
+		switch {
+		case !ok:
+			result.Health, result.Reason = DependencyUnhealthy, "application not found"
+		case !applicationRunning(target.Status):
+			result.Health, result.Reason = DependencyUnhealthy, "application is "+target.Status
+		case !resourcesReady(target.Resources):
+			result.Health, result.Reason = DependencyUnhealthy, "application resources are not ready"
+		}
+		return result
+	}
+
+	for _, r := range resources {
+		if r.Spec.Name != dep.Name {
+			continue
+		}
+		if !r.Status.Ready {
+			result.Health, result.Reason = DependencyUnhealthy, "resource is "+r.Status.Status
+		}
+		return result
+	}
+
+	result.Health, result.Reason = DependencyUnknown, "not tracked by the platform"
+	return result
+}
+
+// applicationRunning reports whether an application status is neither failed nor
+// being torn down
+func applicationRunning(status string) bool {
+	return status != "failed" && status != "terminating"
+}
+
+// validateDependencySpecs checks that every dependency has a type and a unique name
+func validateDependencySpecs(deps []types.DependencySpec) error {
+	seen := make(map[string]bool, len(deps))
+	for i, dep := range deps {
+		if dep.Name == "" {
+			return fmt.Errorf("%w: dependency %d: name is required", ErrInvalidDependencies, i)
+		}
+		if dep.Type == "" {
+			return fmt.Errorf("%w: dependency %q: type is required", ErrInvalidDependencies, dep.Name)
+		}
+		if seen[dep.Name] {
+			return fmt.Errorf("%w: duplicate dependency name %q", ErrInvalidDependencies, dep.Name)
+		}
+		seen[dep.Name] = true
+	}
+	return nil
+}
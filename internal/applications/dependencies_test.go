@@ -0,0 +1,99 @@
+package applications
+
+import (
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDependencySpecs(t *testing.T) {
+	assert.NoError(t, validateDependencySpecs(nil))
+	assert.NoError(t, validateDependencySpecs([]types.DependencySpec{{Type: "service", Name: "billing"}}))
+	assert.ErrorIs(t, validateDependencySpecs([]types.DependencySpec{{Type: "service"}}), ErrInvalidDependencies)
+	assert.ErrorIs(t, validateDependencySpecs([]types.DependencySpec{{Name: "billing"}}), ErrInvalidDependencies)
+	assert.ErrorIs(t, validateDependencySpecs([]types.DependencySpec{
+		{Type: "service", Name: "billing"},
+		{Type: "database", Name: "billing"},
+	}), ErrInvalidDependencies)
+}
+
+func TestRollupStatus(t *testing.T) {
+	id := uuid.New()
+	ready := []ApplicationResource{{
+		Spec:   types.ResourceSpec{Type: "database", Name: "orders-db"},
+		Status: types.ResourceStatus{Status: ResourceStatusReady, Ready: true},
+	}}
+	pending := []ApplicationResource{{
+		Spec:   types.ResourceSpec{Type: "database", Name: "billing-db"},
+		Status: types.ResourceStatus{Status: ResourceStatusPending},
+	}}
+
+	t.Run("healthy required dependency is ok", func(t *testing.T) {
+		status := rollupStatus(id, "running", ready,
+			[]types.DependencySpec{{Type: DependencyTypeService, Name: "billing", Required: true}},
+			map[string]dependencyTarget{"billing": {Status: "running", Resources: ready}},
+		)
+
+		assert.Equal(t, HealthOK, status.Health)
+		assert.True(t, status.ResourcesReady)
+		assert.Empty(t, status.UnhealthyDependencies)
+		assert.Equal(t, DependencyHealthy, status.Dependencies[0].Health)
+	})
+
+	t.Run("failing required dependency degrades the application", func(t *testing.T) {
+		status := rollupStatus(id, "running", ready,
+			[]types.DependencySpec{
+				{Type: DependencyTypeService, Name: "billing", Required: true},
+				{Type: DependencyTypeService, Name: "ledger", Required: true},
+			},
+			map[string]dependencyTarget{"billing": {Status: "failed"}},
+		)
+
+		assert.Equal(t, HealthDegraded, status.Health)
+		assert.Equal(t, []string{"billing", "ledger"}, status.UnhealthyDependencies)
+		assert.Equal(t, "application is failed", status.Dependencies[0].Reason)
+		assert.Equal(t, "application not found", status.Dependencies[1].Reason)
+	})
+
+	t.Run("unready dependency resources degrade the application", func(t *testing.T) {
+		status := rollupStatus(id, "pending", ready,
+			[]types.DependencySpec{{Type: DependencyTypeService, Name: "billing", Required: true}},
+			map[string]dependencyTarget{"billing": {Status: "pending", Resources: pending}},
+		)
+
+		assert.Equal(t, HealthDegraded, status.Health)
+		assert.Equal(t, []string{"billing"}, status.UnhealthyDependencies)
+	})
+
+	t.Run("optional dependencies do not affect the rollup", func(t *testing.T) {
+		status := rollupStatus(id, "running", nil,
+			[]types.DependencySpec{{Type: DependencyTypeService, Name: "recommendations"}},
+			nil,
+		)
+
+		assert.Equal(t, HealthOK, status.Health)
+		assert.Empty(t, status.UnhealthyDependencies)
+		assert.Equal(t, DependencyUnhealthy, status.Dependencies[0].Health)
+	})
+
+	t.Run("resource dependencies use the declared resource status", func(t *testing.T) {
+		status := rollupStatus(id, "running", pending,
+			[]types.DependencySpec{
+				{Type: "database", Name: "billing-db", Required: true},
+				{Type: "external-api", Name: "stripe", Required: true},
+			},
+			nil,
+		)
+
+		assert.Equal(t, HealthDegraded, status.Health)
+		assert.False(t, status.ResourcesReady)
+		assert.Equal(t, []string{"billing-db"}, status.UnhealthyDependencies)
+		assert.Equal(t, DependencyUnknown, status.Dependencies[1].Health)
+	})
+
+	t.Run("failed application is degraded", func(t *testing.T) {
+		assert.Equal(t, HealthDegraded, rollupStatus(id, "failed", nil, nil, nil).Health)
+	})
+}
@@ -0,0 +1,201 @@
+package applications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidEnvironment is returned when environment variables fail validation
+var ErrInvalidEnvironment = errors.New("invalid environment variables")
+
+// Environment variable limits
+const (
+	// MaskedValue replaces the value of secret-backed variables on read
+	MaskedValue = "********"
+	// ReservedEnvPrefix is reserved for variables injected by the platform
+	ReservedEnvPrefix = "AIIDP_"
+
+	maxEnvVars        = 256
+	maxEnvValueLength = 32 * 1024
+)
+
+// envNamePattern follows the POSIX rules for portable environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvNames are set by the runtime or alter process loading and cannot be overridden
+var reservedEnvNames = map[string]bool{
+	"PATH":            true,
+	"HOME":            true,
+	"HOSTNAME":        true,
+	"USER":            true,
+	"SHELL":           true,
+	"PWD":             true,
+	"IFS":             true,
+	"LD_PRELOAD":      true,
+	"LD_LIBRARY_PATH": true,
+	"LD_AUDIT":        true,
+}
+
+// EnvVar is an application environment variable. Exactly one of Value and SecretRef
+// is set; secret-backed values are resolved at deploy time and never stored.
+type EnvVar struct {
+	Name      string           `json:"name"`
+	Value     string           `json:"value,omitempty"`
+	SecretRef *types.SecretRef `json:"secret_ref,omitempty"`
+}
+
+// IsSecret reports whether the variable is sourced from a secret
+func (v EnvVar) IsSecret() bool {
+	return v.SecretRef != nil
+}
+
+// ApplicationEnvironment is the set of environment variables managed for an application
+type ApplicationEnvironment struct {
+	ApplicationID uuid.UUID `json:"application_id"`
+	Variables     []EnvVar  `json:"variables"`
+}
+
+// SetEnvironment replaces an application's environment variables
+func (s *Service) SetEnvironment(ctx context.Context, tenantID, id uuid.UUID, vars []EnvVar) (*ApplicationEnvironment, error) {
+	if err := ValidateEnvironment(vars); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		query := `
+			SELECT retirement_date FROM resource_management.applications
+			WHERE tenant_id = $1 AND id = $2
+			FOR UPDATE
+		`
+
+		var retirementDate *time.Time
+		if err := tx.QueryRow(ctx, query, tenantID, id).Scan(&retirementDate); err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrApplicationNotFound
+			}
+			return fmt.Errorf("failed to lock application: %w", err)
+		}
+
+		app := Application{RetirementDate: retirementDate}
+		if app.IsRetired(s.now()) {
+			return ErrApplicationRetired
+		}
+
+		envJSON, err := json.Marshal(vars)
+		if err != nil {
+			return fmt.Errorf("failed to marshal environment: %w", err)
+		}
+
+		update := `
+			UPDATE resource_management.applications
+			SET environment = $3, updated_at = $4, version = version + 1
+			WHERE tenant_id = $1 AND id = $2
+		`
+		if _, err := tx.Exec(ctx, update, tenantID, id, envJSON, s.now().UTC()); err != nil {
+			return fmt.Errorf("failed to update environment: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newApplicationEnvironment(id, vars), nil
+}
+
+// GetEnvironment returns an application's environment variables with secret-backed
+// values masked
+func (s *Service) GetEnvironment(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationEnvironment, error) {
+	query := `
+		SELECT environment FROM resource_management.applications
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var envJSON []byte
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.reader().QueryRow(ctx, query, tenantID, id).Scan(&envJSON)
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, fmt.Errorf("failed to get environment: %w", err)
+	}
+
+	var vars []EnvVar
+	if err := json.Unmarshal(envJSON, &vars); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal environment: %w", err)
+	}
+
+	return newApplicationEnvironment(id, vars), nil
+}
+
+// ValidateEnvironment checks variable names against the POSIX naming rules, rejects
+// reserved and duplicate names, and requires exactly one value source per variable
+func ValidateEnvironment(vars []EnvVar) error {
+	if len(vars) > maxEnvVars {
+		return fmt.Errorf("%w: at most %d variables are allowed", ErrInvalidEnvironment, maxEnvVars)
+	}
+
+	seen := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		if !envNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("%w: %q is not a valid variable name", ErrInvalidEnvironment, v.Name)
+		}
+
+		upper := strings.ToUpper(v.Name)
+		if reservedEnvNames[upper] || strings.HasPrefix(upper, ReservedEnvPrefix) {
+			return fmt.Errorf("%w: %q is reserved", ErrInvalidEnvironment, v.Name)
+		}
+
+		if seen[v.Name] {
+			return fmt.Errorf("%w: duplicate variable %q", ErrInvalidEnvironment, v.Name)
+		}
+		seen[v.Name] = true
+
+		if v.IsSecret() {
+			if v.Value != "" {
+				return fmt.Errorf("%w: %q cannot set both value and secret_ref", ErrInvalidEnvironment, v.Name)
+			}
+			if v.SecretRef.Name == "" {
+				return fmt.Errorf("%w: %q: secret_ref name is required", ErrInvalidEnvironment, v.Name)
+			}
+			continue
+		}
+
+		if len(v.Value) > maxEnvValueLength {
+			return fmt.Errorf("%w: %q exceeds %d bytes", ErrInvalidEnvironment, v.Name, maxEnvValueLength)
+		}
+	}
+
+	return nil
+}
+
+// newApplicationEnvironment wraps variables for a response, masking secret-backed values
+func newApplicationEnvironment(id uuid.UUID, vars []EnvVar) *ApplicationEnvironment {
+	masked := make([]EnvVar, 0, len(vars))
+	for _, v := range vars {
+		if v.IsSecret() {
+			ref := *v.SecretRef
+			v.SecretRef = &ref
+			v.Value = MaskedValue
+		}
+		masked = append(masked, v)
+	}
+
+	return &ApplicationEnvironment{
+		ApplicationID: id,
+		Variables:     masked,
+	}
+}
@@ -0,0 +1,51 @@
+package applications
+
+import (
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEnvironment(t *testing.T) {
+	secret := &types.SecretRef{Name: "orders-db", Key: "password"}
+
+	assert.NoError(t, ValidateEnvironment(nil))
+	assert.NoError(t, ValidateEnvironment([]EnvVar{
+		{Name: "LOG_LEVEL", Value: "debug"},
+		{Name: "_private", Value: ""},
+		{Name: "DATABASE_PASSWORD", SecretRef: secret},
+	}))
+
+	for name, vars := range map[string][]EnvVar{
+		"leading digit":      {{Name: "1VAR", Value: "x"}},
+		"dash":               {{Name: "MY-VAR", Value: "x"}},
+		"empty name":         {{Name: "", Value: "x"}},
+		"reserved":           {{Name: "LD_PRELOAD", Value: "/tmp/evil.so"}},
+		"reserved lowercase": {{Name: "path", Value: "/bin"}},
+		"platform prefix":    {{Name: "AIIDP_TENANT", Value: "x"}},
+		"duplicate":          {{Name: "A", Value: "1"}, {Name: "A", Value: "2"}},
+		"value and secret":   {{Name: "A", Value: "1", SecretRef: secret}},
+		"secret without ref": {{Name: "A", SecretRef: &types.SecretRef{}}},
+	} {
+		assert.ErrorIs(t, ValidateEnvironment(vars), ErrInvalidEnvironment, name)
+	}
+}
+
+func TestNewApplicationEnvironment_MasksSecrets(t *testing.T) {
+	vars := []EnvVar{
+		{Name: "LOG_LEVEL", Value: "debug"},
+		{Name: "DATABASE_PASSWORD", SecretRef: &types.SecretRef{Name: "orders-db", Key: "password"}},
+	}
+
+	env := newApplicationEnvironment(uuid.New(), vars)
+	require.Len(t, env.Variables, 2)
+	assert.Equal(t, "debug", env.Variables[0].Value)
+	assert.Equal(t, MaskedValue, env.Variables[1].Value)
+	assert.Equal(t, "orders-db", env.Variables[1].SecretRef.Name)
+
+	// The input is left untouched so it can still be stored without the mask
+	assert.Empty(t, vars[1].Value)
+}
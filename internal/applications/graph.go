@@ -0,0 +1,237 @@
+package applications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidDirection is returned for a dependency graph direction other than
+// upstream or downstream
+var ErrInvalidDirection = errors.New("direction must be upstream or downstream")
+
+// Dependency graph directions. Upstream follows the service dependencies an
+// application declares; downstream follows them in reverse to the applications that
+// would be affected by a change to it.
+const (
+	DirectionUpstream   = "upstream"
+	DirectionDownstream = "downstream"
+)
+
+// DependencyGraph is the set of applications connected to one through service
+// dependencies, in one direction
+type DependencyGraph struct {
+	ApplicationID uuid.UUID        `json:"application_id"`
+	Direction     string           `json:"direction"`
+	Applications  []DependencyNode `json:"applications"`
+	Edges         []DependencyEdge `json:"edges"`
+	// HasCycle is set when applications in the graph depend on each other in a loop.
+	// Cycle names one such loop, starting and ending with the same application.
+	HasCycle bool     `json:"has_cycle"`
+	Cycle    []string `json:"cycle,omitempty"`
+}
+
+// DependencyNode is an application reached in a dependency graph
+type DependencyNode struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+	// Depth is the number of dependencies between the application and the one the
+	// graph was requested for
+	Depth int `json:"depth"`
+}
+
+// DependencyEdge records that application From depends on application To
+type DependencyEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Required bool   `json:"required"`
+}
+
+// graphApplication is an application's service dependencies as stored
+type graphApplication struct {
+	ID           uuid.UUID
+	Name         string
+	Status       string
+	Dependencies []types.DependencySpec
+}
+
+// GetDependencyGraph returns the applications connected to an application through
+// service dependencies: those it depends on, directly or not, for upstream, and those
+// depending on it for downstream. An empty direction is downstream. Terminated
+// applications are left out of the graph.
+func (s *Service) GetDependencyGraph(ctx context.Context, tenantID, id uuid.UUID, direction string) (*DependencyGraph, error) {
+	if direction == "" {
+		direction = DirectionDownstream
+	}
+	if direction != DirectionUpstream && direction != DirectionDownstream {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDirection, direction)
+	}
+
+	query := `
+		SELECT id, name, status, dependencies FROM resource_management.applications
+		WHERE tenant_id = $1 AND status <> $2
+		ORDER BY name
+	`
+
+	var apps []graphApplication
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		rows, err := s.reader().Query(ctx, query, tenantID, StatusTerminated)
+		if err != nil {
+			return fmt.Errorf("failed to get dependency graph: %w", err)
+		}
+		defer rows.Close()
+
+		apps = apps[:0]
+		for rows.Next() {
+			var app graphApplication
+			var depsJSON []byte
+			if err := rows.Scan(&app.ID, &app.Name, &app.Status, &depsJSON); err != nil {
+				return fmt.Errorf("failed to scan application: %w", err)
+			}
+			if err := unmarshalJSONB(depsJSON, &app.Dependencies); err != nil {
+				return fmt.Errorf("failed to unmarshal dependencies: %w", err)
+			}
+			apps = append(apps, app)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate applications: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graph, ok := resolveDependencyGraph(apps, id, direction)
+	if !ok {
+		return nil, ErrApplicationNotFound
+	}
+	return graph, nil
+}
+
+// resolveDependencyGraph walks the service dependencies between apps, breadth first
+// from root, and reports whether root is among them. Dependencies on applications
+// that are not in apps are ignored.
+func resolveDependencyGraph(apps []graphApplication, root uuid.UUID, direction string) (*DependencyGraph, bool) {
+	byName := make(map[string]*graphApplication, len(apps))
+	var start *graphApplication
+	for i := range apps {
+		byName[apps[i].Name] = &apps[i]
+		if apps[i].ID == root {
+			start = &apps[i]
+		}
+	}
+	if start == nil {
+		return nil, false
+	}
+
+	var edges []DependencyEdge
+	dependsOn := make(map[string][]string)
+	dependedOnBy := make(map[string][]string)
+	for _, app := range apps {
+		for _, dep := range app.Dependencies {
+			if dep.Type != DependencyTypeService || byName[dep.Name] == nil {
+				continue
+			}
+			edges = append(edges, DependencyEdge{From: app.Name, To: dep.Name, Required: dep.Required})
+			dependsOn[app.Name] = append(dependsOn[app.Name], dep.Name)
+			dependedOnBy[dep.Name] = append(dependedOnBy[dep.Name], app.Name)
+		}
+	}
+	next := dependsOn
+	if direction == DirectionDownstream {
+		next = dependedOnBy
+	}
+
+	depth := map[string]int{start.Name: 0}
+	order := []string{start.Name}
+	for i := 0; i < len(order); i++ {
+		for _, name := range next[order[i]] {
+			if _, seen := depth[name]; !seen {
+				depth[name] = depth[order[i]] + 1
+				order = append(order, name)
+			}
+		}
+	}
+
+	graph := &DependencyGraph{
+		ApplicationID: root,
+		Direction:     direction,
+		Applications:  make([]DependencyNode, 0, len(order)-1),
+		Edges:         []DependencyEdge{},
+	}
+	for _, name := range order[1:] {
+		app := byName[name]
+		graph.Applications = append(graph.Applications, DependencyNode{ID: app.ID, Name: app.Name, Status: app.Status, Depth: depth[name]})
+	}
+	sort.SliceStable(graph.Applications, func(i, j int) bool {
+		if graph.Applications[i].Depth != graph.Applications[j].Depth {
+			return graph.Applications[i].Depth < graph.Applications[j].Depth
+		}
+		return graph.Applications[i].Name < graph.Applications[j].Name
+	})
+
+	for _, edge := range edges {
+		_, fromReached := depth[edge.From]
+		_, toReached := depth[edge.To]
+		if fromReached && toReached {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	graph.Cycle = findCycle(order, dependsOn, depth)
+	graph.HasCycle = graph.Cycle != nil
+	return graph, true
+}
+
+// findCycle returns a loop of dependencies among the reached applications, or nil if
+// there is none
+func findCycle(order []string, dependsOn map[string][]string, reached map[string]int) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(order))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, n := range dependsOn[name] {
+			if _, ok := reached[n]; !ok {
+				continue
+			}
+			switch state[n] {
+			case visiting:
+				for i := range path {
+					if path[i] == n {
+						return append(append([]string{}, path[i:]...), n)
+					}
+				}
+			case unvisited:
+				if cycle := visit(n); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range order {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
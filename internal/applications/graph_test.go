@@ -0,0 +1,83 @@
+package applications
+
+import (
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDependencyGraph(t *testing.T) {
+	app := func(name string, deps ...string) graphApplication {
+		specs := make([]types.DependencySpec, 0, len(deps))
+		for _, dep := range deps {
+			specs = append(specs, types.DependencySpec{Type: DependencyTypeService, Name: dep, Required: true})
+		}
+		return graphApplication{ID: uuid.New(), Name: name, Status: "running", Dependencies: specs}
+	}
+	names := func(nodes []DependencyNode) []string {
+		var result []string
+		for _, node := range nodes {
+			result = append(result, node.Name)
+		}
+		return result
+	}
+
+	// web -> orders -> billing -> ledger, with web also calling billing directly
+	ledger := app("ledger")
+	ledger.Dependencies = append(ledger.Dependencies, types.DependencySpec{Type: "database", Name: "ledger-db"})
+	apps := []graphApplication{
+		app("billing", "ledger"),
+		ledger,
+		app("orders", "billing", "missing"),
+		app("web", "orders", "billing"),
+	}
+
+	t.Run("upstream follows declared dependencies", func(t *testing.T) {
+		graph, ok := resolveDependencyGraph(apps, apps[3].ID, DirectionUpstream)
+		require.True(t, ok)
+
+		assert.Equal(t, []string{"billing", "orders", "ledger"}, names(graph.Applications))
+		assert.Equal(t, 1, graph.Applications[0].Depth)
+		assert.Equal(t, 2, graph.Applications[2].Depth)
+		assert.Len(t, graph.Edges, 4)
+		assert.False(t, graph.HasCycle)
+		assert.Nil(t, graph.Cycle)
+	})
+
+	t.Run("downstream follows dependents", func(t *testing.T) {
+		graph, ok := resolveDependencyGraph(apps, apps[1].ID, DirectionDownstream)
+		require.True(t, ok)
+
+		assert.Equal(t, []string{"billing", "orders", "web"}, names(graph.Applications))
+		assert.Equal(t, []int{1, 2, 2}, []int{graph.Applications[0].Depth, graph.Applications[1].Depth, graph.Applications[2].Depth})
+		assert.False(t, graph.HasCycle)
+	})
+
+	t.Run("applications without connections have an empty graph", func(t *testing.T) {
+		graph, ok := resolveDependencyGraph(apps, apps[3].ID, DirectionDownstream)
+		require.True(t, ok)
+
+		assert.Empty(t, graph.Applications)
+		assert.Empty(t, graph.Edges)
+	})
+
+	t.Run("cycles are detected in either direction", func(t *testing.T) {
+		cyclic := []graphApplication{app("a", "b"), app("b", "c"), app("c", "a"), app("d", "a")}
+
+		for _, direction := range []string{DirectionUpstream, DirectionDownstream} {
+			graph, ok := resolveDependencyGraph(cyclic, cyclic[0].ID, direction)
+			require.True(t, ok)
+
+			assert.True(t, graph.HasCycle, direction)
+			assert.Equal(t, []string{"a", "b", "c", "a"}, graph.Cycle, direction)
+		}
+	})
+
+	t.Run("unknown application", func(t *testing.T) {
+		_, ok := resolveDependencyGraph(apps, uuid.New(), DirectionUpstream)
+		assert.False(t, ok)
+	})
+}
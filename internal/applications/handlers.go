@@ -2,26 +2,72 @@ package applications
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/database"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/aykay76/ai-idp/internal/validation"
 	"github.com/google/uuid"
 )
 
 // Handlers provides HTTP handlers for application resources using native Go HTTP
 type Handlers struct {
-	service *Service
-	logger  *logger.Logger
+	service   ApplicationService
+	logger    *logger.Logger
+	responder *server.Responder
+	policies  *policy.Evaluator
+
+	pageLimits server.PageLimits
+}
+
+// HandlerOption configures Handlers
+type HandlerOption func(*Handlers)
+
+// WithResponseEnvelope wraps every response in the types.APIResponse envelope
+func WithResponseEnvelope(enabled bool) HandlerOption {
+	return func(h *Handlers) {
+		h.responder = server.NewResponder(enabled)
+	}
+}
+
+// WithPolicies evaluates policies before applications are created. Blocking
+// violations reject the request with a 403; others are logged.
+func WithPolicies(e *policy.Evaluator) HandlerOption {
+	return func(h *Handlers) {
+		h.policies = e
+	}
+}
+
+// WithPageLimits sets the default and maximum page size for listing applications
+func WithPageLimits(limits server.PageLimits) HandlerOption {
+	return func(h *Handlers) {
+		h.pageLimits = limits
+	}
 }
 
 // NewHandlers creates new application handlers
-func NewHandlers(service *Service, appLogger *logger.Logger) *Handlers {
-	return &Handlers{
-		service: service,
-		logger:  appLogger,
+func NewHandlers(service ApplicationService, appLogger *logger.Logger, opts ...HandlerOption) *Handlers {
+	h := &Handlers{
+		service:   service,
+		logger:    appLogger,
+		responder: server.NewResponder(false),
+
+		pageLimits: server.DefaultPageLimits,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // ListApplicationsResponse represents the response for listing applications
@@ -30,53 +76,81 @@ type ListApplicationsResponse struct {
 	Pagination   PaginationMeta `json:"pagination"`
 }
 
+// ApplicationsByTeamResponse represents the response for listing a team's applications
+type ApplicationsByTeamResponse struct {
+	TeamName     string        `json:"team_name"`
+	Applications []Application `json:"applications"`
+	Total        int           `json:"total"`
+}
+
 // PaginationMeta contains pagination metadata
 type PaginationMeta struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
-	Total  int `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string    `json:"error"`
-	Message string    `json:"message"`
-	Code    string    `json:"code,omitempty"`
-	Time    time.Time `json:"timestamp"`
+// ErrorResponse represents an error response, in the shape shared by every service
+type ErrorResponse = server.ErrorResponse
+
+// NameCollisionResponse is returned when a requested name derives the same identifier
+// as an existing application
+type NameCollisionResponse struct {
+	ErrorResponse
+	RequestedName string `json:"requested_name"`
+	DerivedName   string `json:"derived_name"`
+	ConflictsWith string `json:"conflicts_with,omitempty"`
 }
 
 // CreateApplication handles POST /api/v1/applications
 func (h *Handlers) CreateApplication(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// For now, we'll use a hardcoded tenant ID until we implement proper tenant middleware
-	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001") // Default tenant
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
 
 	// Parse request body
 	var req CreateApplicationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid JSON", err)
+	if err := server.DecodeJSON(r.Body, &req); err != nil {
+		h.respondWithDecodeError(w, r, err)
 		return
 	}
 
-	// Basic validation
-	if req.Name == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Name is required", nil)
+	if !h.validate(w, r, &req) {
 		return
 	}
-	if req.DisplayName == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Display name is required", nil)
+	if !h.checkPolicies(w, r, tenantID, &req) {
 		return
 	}
 
 	// Create application
-	app, err := h.service.CreateApplication(ctx, tenantID, &req)
+	app, err := h.service.CreateApplication(ctx, tenantID, &req, requestActor(r))
 	if err != nil {
+		if errors.Is(err, ErrInvalidAnnotations) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid annotations", err)
+			return
+		}
+		if errors.Is(err, ErrInvalidName) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid name", err)
+			return
+		}
+		var collision *NameCollisionError
+		if errors.As(err, &collision) {
+			h.respondWithNameCollision(w, r, collision)
+			return
+		}
+		if errors.Is(err, database.ErrQuotaExceeded) {
+			h.respondWithError(w, r, http.StatusForbidden, "Quota exceeded", err)
+			return
+		}
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 			"name":            req.Name,
 		}).Error("Failed to create application")
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to create application", err)
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to create application", err)
 		return
 	}
 
@@ -85,29 +159,49 @@ func (h *Handlers) CreateApplication(w http.ResponseWriter, r *http.Request) {
 		"name":           app.Name,
 	}).Info("Application created successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(app)
+	h.respondJSON(w, r, http.StatusCreated, app)
 }
 
 // ListApplications handles GET /api/v1/applications
 func (h *Handlers) ListApplications(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// For now, we'll use a hardcoded tenant ID until we implement proper tenant middleware
-	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001") // Default tenant
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
 
 	// Parse pagination parameters
-	limit := h.parseQueryInt(r, "limit", 20)
-	if limit > 100 {
-		limit = 100 // Max limit
+	params, err := server.ParsePagination(r, h.pageLimits)
+	if err != nil {
+		h.respondWithPaginationError(w, r, err)
+		return
 	}
-	offset := h.parseQueryInt(r, "offset", 0)
+	limit, offset := params.Limit, params.Offset
 
 	// Parse filter parameters
 	teamName := r.URL.Query().Get("team_name")
 	lifecycle := r.URL.Query().Get("lifecycle")
 	status := r.URL.Query().Get("status")
+	includeTerminated, _ := strconv.ParseBool(r.URL.Query().Get("include_terminated"))
+
+	filters := server.ParseFilterParams(r)
+	if err := filters.Validate(); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	sort, err := server.ParseSortParam(r, SortFields)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, "Invalid sort", err)
+		return
+	}
+
+	configFilters, err := parseConfigFilters(r)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, "Invalid config filter", err)
+		return
+	}
 
 	// Create list request
 	listReq := &ListApplicationsRequest{
@@ -115,8 +209,32 @@ func (h *Handlers) ListApplications(w http.ResponseWriter, r *http.Request) {
 		TeamName:  teamName,
 		Lifecycle: lifecycle,
 		Status:    status,
+		CreatedBy: filters.CreatedBy,
+		UpdatedBy: filters.UpdatedBy,
+		Search:    filters.Search,
+		Sort:      sort,
 		Limit:     limit,
 		Offset:    offset,
+
+		IncludeTerminated: includeTerminated,
+		ConfigFilters:     configFilters,
+	}
+
+	// A cursor switches to keyset pagination; one extra row shows whether more exist
+	cursor, err := server.ParseCursorParam(r)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, "Invalid cursor", err)
+		return
+	}
+	if cursor != nil {
+		if sort != database.DefaultSort {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid sort", errors.New("a cursor can only continue a listing in the default order"))
+			return
+		}
+		offset = 0
+		listReq.After = cursor
+		listReq.Limit = limit + 1
+		listReq.Offset = 0
 	}
 
 	// Get applications
@@ -125,22 +243,103 @@ func (h *Handlers) ListApplications(w http.ResponseWriter, r *http.Request) {
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 		}).Error("Failed to list applications")
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to list applications", err)
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to list applications", err)
 		return
 	}
 
+	if apps == nil {
+		apps = []Application{}
+	}
+
+	more := offset+len(apps) < total
+	if cursor != nil {
+		more = len(apps) > limit
+		if more {
+			apps = apps[:limit]
+		}
+	}
+
+	// Cursors follow the default order, so other sorts page by offset only
+	var nextCursor string
+	if more && len(apps) > 0 && sort == database.DefaultSort {
+		last := apps[len(apps)-1]
+		nextCursor = database.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
 	response := ListApplicationsResponse{
 		Applications: apps,
 		Pagination: PaginationMeta{
-			Limit:  limit,
-			Offset: offset,
-			Total:  total,
+			Limit:      limit,
+			Offset:     offset,
+			Total:      total,
+			NextCursor: nextCursor,
 		},
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	page := server.NewPagination(limit, offset, total)
+	page.NextCursor = nextCursor
+
+	if err := h.responder.ConditionalList(w, r, http.StatusOK, response, apps, page); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode applications response")
+	}
+}
+
+// GetApplicationsByTeam handles GET /api/v1/applications/by-team?team_name=...
+func (h *Handlers) GetApplicationsByTeam(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, "team_name is required", nil)
+		return
+	}
+
+	applications, err := h.service.GetApplicationsByTeam(ctx, tenantID, teamName)
+	if err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"team_name":       teamName,
+		}).Error("Failed to get team applications")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get team applications", err)
+		return
+	}
+
+	if applications == nil {
+		applications = []Application{}
+	}
+	h.respondJSON(w, r, http.StatusOK, ApplicationsByTeamResponse{
+		TeamName:     teamName,
+		Applications: applications,
+		Total:        len(applications),
+	})
+}
+
+// GetApplicationStats handles GET /api/v1/applications/stats
+func (h *Handlers) GetApplicationStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetApplicationStats(ctx, tenantID)
+	if err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to get application stats")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get application stats", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, stats)
 }
 
 // GetApplication handles GET /api/v1/applications/{id}
@@ -148,39 +347,123 @@ func (h *Handlers) GetApplication(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Extract ID from path
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Application ID is required", nil)
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
 		return
 	}
 
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid application ID format", err)
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
 		return
 	}
 
-	// For now, we'll use a hardcoded tenant ID until we implement proper tenant middleware
-	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001") // Default tenant
-
 	// Get application
 	app, err := h.service.GetApplication(ctx, tenantID, id)
 	if err != nil {
-		if err.Error() == "application not found" {
-			h.respondWithError(w, http.StatusNotFound, "Application not found", err)
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
 			return
 		}
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 			"application_id":  id.String(),
 		}).Error("Failed to get application")
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to get application", err)
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get application", err)
+		return
+	}
+
+	h.setRetirementHeaders(w, app)
+	if server.NotModified(w, r, server.ETag(app.Version)) {
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, app)
+}
+
+// ApplicationAction handles POST /api/v1/applications/{id}, where the final segment
+// is a custom method such as "{id}:approve" or "{id}:reject"
+func (h *Handlers) ApplicationAction(w http.ResponseWriter, r *http.Request) {
+	value, action, ok := strings.Cut(r.PathValue("id"), ":")
+	if !ok {
+		h.respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		h.responder.PathError(w, r, &server.PathParamError{Param: "id", Value: value, Err: server.ErrInvalidPathUUID})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(app)
+	switch action {
+	case "approve":
+		h.reviewApplication(w, r, id, true)
+	case "reject":
+		h.reviewApplication(w, r, id, false)
+	default:
+		h.respondWithError(w, r, http.StatusNotFound, "Unknown application action", fmt.Errorf("unknown action %q", action))
+	}
+}
+
+// reviewApplication records an approval decision made by the authenticated caller.
+// The reviewer is checked against the team's owners and maintainers, so it is only
+// taken from the token JWTAuth verified, never from a request header.
+func (h *Handlers) reviewApplication(w http.ResponseWriter, r *http.Request, id uuid.UUID, approve bool) {
+	ctx := r.Context()
+
+	decide, outcome := h.service.RejectApplication, "rejected"
+	if approve {
+		decide, outcome = h.service.ApproveApplication, "approved"
+	}
+
+	reviewer, _ := ctx.Value(types.UserIDKey).(string)
+	if reviewer == "" {
+		h.respondWithError(w, r, http.StatusUnauthorized, "Reviewer identity is required", errors.New("an authenticated user is required"))
+		return
+	}
+
+	// The comment is optional, so an empty body is accepted
+	var req ReviewApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondWithDecodeError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	app, err := decide(ctx, tenantID, id, reviewer, &req)
+	if err != nil {
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		if errors.Is(err, ErrNotPendingApproval) {
+			h.respondWithError(w, r, http.StatusConflict, "Application is not pending approval", err)
+			return
+		}
+		if errors.Is(err, ErrNotApprover) {
+			h.respondWithError(w, r, http.StatusForbidden, "Not allowed to review this application", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to review application")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to review application", err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"application_id": id.String(),
+		"reviewer":       reviewer,
+		"outcome":        outcome,
+	}).Info("Application reviewed")
+
+	h.respondJSON(w, r, http.StatusOK, app)
 }
 
 // UpdateApplication handles PUT /api/v1/applications/{id}
@@ -188,40 +471,63 @@ func (h *Handlers) UpdateApplication(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Extract ID from path
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Application ID is required", nil)
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
 		return
 	}
 
-	id, err := uuid.Parse(idStr)
+	// Updates must name the version they were made against
+	version, err := server.ParseIfMatch(r)
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid application ID format", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, server.ErrMissingIfMatch) {
+			status = http.StatusPreconditionRequired
+		}
+		h.respondWithError(w, r, status, "Invalid If-Match header", err)
 		return
 	}
 
 	// Parse request body
 	var req UpdateApplicationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid JSON", err)
+	if err := server.DecodeJSON(r.Body, &req); err != nil {
+		h.respondWithDecodeError(w, r, err)
+		return
+	}
+	if !h.validate(w, r, &req) {
 		return
 	}
+	req.IfMatch = version
 
-	// For now, we'll use a hardcoded tenant ID until we implement proper tenant middleware
-	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001") // Default tenant
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
 
 	// Update application
-	app, err := h.service.UpdateApplication(ctx, tenantID, id, &req)
+	app, err := h.service.UpdateApplication(ctx, tenantID, id, &req, requestActor(r))
 	if err != nil {
-		if err.Error() == "application not found" {
-			h.respondWithError(w, http.StatusNotFound, "Application not found", err)
+		if errors.Is(err, ErrInvalidAnnotations) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid annotations", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationRetired) {
+			h.respondWithError(w, r, http.StatusConflict, "Application is retired and only accepts retirement date changes", nil)
+			return
+		}
+		if errors.Is(err, ErrVersionMismatch) {
+			h.respondWithError(w, r, http.StatusPreconditionFailed, "Application has been modified since it was read; fetch it again and retry", nil)
 			return
 		}
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 			"application_id":  id.String(),
 		}).Error("Failed to update application")
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to update application", err)
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to update application", err)
 		return
 	}
 
@@ -230,9 +536,10 @@ func (h *Handlers) UpdateApplication(w http.ResponseWriter, r *http.Request) {
 		"name":           app.Name,
 	}).Info("Application updated successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(app)
+	h.setRetirementHeaders(w, app)
+	server.SetETag(w, app.Version)
+
+	h.respondJSON(w, r, http.StatusOK, app)
 }
 
 // DeleteApplication handles DELETE /api/v1/applications/{id}
@@ -240,33 +547,29 @@ func (h *Handlers) DeleteApplication(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Extract ID from path
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		h.respondWithError(w, http.StatusBadRequest, "Application ID is required", nil)
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
 		return
 	}
 
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid application ID format", err)
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
 		return
 	}
 
-	// For now, we'll use a hardcoded tenant ID until we implement proper tenant middleware
-	tenantID := uuid.MustParse("00000000-0000-0000-0000-000000000001") // Default tenant
-
 	// Delete application
-	err = h.service.DeleteApplication(ctx, tenantID, id)
+	err = h.service.DeleteApplication(ctx, tenantID, id, requestActor(r))
 	if err != nil {
-		if err.Error() == "application not found" {
-			h.respondWithError(w, http.StatusNotFound, "Application not found", err)
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
 			return
 		}
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 			"application_id":  id.String(),
 		}).Error("Failed to delete application")
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to delete application", err)
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to delete application", err)
 		return
 	}
 
@@ -277,21 +580,620 @@ func (h *Handlers) DeleteApplication(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// SetResources handles PUT /api/v1/applications/{id}/resources
+func (h *Handlers) SetResources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	// Parse request body
+	var specs []types.ResourceSpec
+	if err := json.NewDecoder(r.Body).Decode(&specs); err != nil {
+		h.respondWithDecodeError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	resources, err := h.service.SetResources(ctx, tenantID, id, specs)
+	if err != nil {
+		if errors.Is(err, ErrInvalidResources) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid resources", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationRetired) {
+			h.respondWithError(w, r, http.StatusConflict, "Application is retired and only accepts retirement date changes", nil)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to set application resources")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to set application resources", err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"application_id": id.String(),
+		"resources":      len(resources.Resources),
+	}).Info("Application resources declared successfully")
+
+	h.respondJSON(w, r, http.StatusOK, resources)
+}
+
+// SetDependencies handles PUT /api/v1/applications/{id}/dependencies
+func (h *Handlers) SetDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	// Parse request body
+	var deps []types.DependencySpec
+	if err := json.NewDecoder(r.Body).Decode(&deps); err != nil {
+		h.respondWithDecodeError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	deps, err = h.service.SetDependencies(ctx, tenantID, id, deps)
+	if err != nil {
+		if errors.Is(err, ErrInvalidDependencies) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid dependencies", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationRetired) {
+			h.respondWithError(w, r, http.StatusConflict, "Application is retired and only accepts retirement date changes", nil)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to set application dependencies")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to set application dependencies", err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"application_id": id.String(),
+		"dependencies":   len(deps),
+	}).Info("Application dependencies declared successfully")
+
+	h.respondJSON(w, r, http.StatusOK, deps)
+}
+
+// GetStatus handles GET /api/v1/applications/{id}/status
+func (h *Handlers) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetStatus(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to get application status")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get application status", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, status)
+}
+
+// GetDependencies handles GET /api/v1/applications/{id}/dependencies
+func (h *Handlers) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	graph, err := h.service.GetDependencyGraph(ctx, tenantID, id, r.URL.Query().Get("direction"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidDirection) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid direction", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to get application dependency graph")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get application dependency graph", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, graph)
+}
+
+// GetResources handles GET /api/v1/applications/{id}/resources
+func (h *Handlers) GetResources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	resources, err := h.service.GetResources(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to get application resources")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get application resources", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resources)
+}
+
+// UpdateResourceStatus handles PUT /api/v1/applications/{id}/resources/{name}/status
+func (h *Handlers) UpdateResourceStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID and resource name from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	name := r.PathValue("name")
+	if name == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, "Resource name is required", nil)
+		return
+	}
+
+	// Parse request body
+	var req UpdateResourceStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithDecodeError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	resources, err := h.service.UpdateResourceStatus(ctx, tenantID, id, name, &req)
+	if err != nil {
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		if errors.Is(err, ErrResourceNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Resource not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+			"resource":        name,
+		}).Error("Failed to update resource status")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to update resource status", err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"application_id": id.String(),
+		"resource":       name,
+		"ready":          req.Ready,
+	}).Info("Resource status updated successfully")
+
+	h.respondJSON(w, r, http.StatusOK, resources)
+}
+
+// SetEnvironment handles PUT /api/v1/applications/{id}/env
+func (h *Handlers) SetEnvironment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	// Parse request body
+	var vars []EnvVar
+	if err := json.NewDecoder(r.Body).Decode(&vars); err != nil {
+		h.respondWithDecodeError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	env, err := h.service.SetEnvironment(ctx, tenantID, id, vars)
+	if err != nil {
+		if errors.Is(err, ErrInvalidEnvironment) {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid environment variables", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		if errors.Is(err, ErrApplicationRetired) {
+			h.respondWithError(w, r, http.StatusConflict, "Application is retired and only accepts retirement date changes", nil)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to set application environment")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to set application environment", err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"application_id": id.String(),
+		"variables":      len(env.Variables),
+	}).Info("Application environment updated successfully")
+
+	h.respondJSON(w, r, http.StatusOK, env)
+}
+
+// GetEnvironment handles GET /api/v1/applications/{id}/env
+func (h *Handlers) GetEnvironment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	env, err := h.service.GetEnvironment(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to get application environment")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to get application environment", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, env)
+}
+
+// ListRevisionsResponse lists the stored revisions of an application
+type ListRevisionsResponse struct {
+	ApplicationID uuid.UUID             `json:"application_id"`
+	Revisions     []ApplicationRevision `json:"revisions"`
+}
+
+// GetHistory handles GET /api/v1/applications/{id}/history
+func (h *Handlers) GetHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrApplicationNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Application not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to list application revisions")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to list application revisions", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, ListRevisionsResponse{ApplicationID: id, Revisions: revisions})
+}
+
+// DiffHistory handles GET /api/v1/applications/{id}/history/diff?from=N&to=M
+func (h *Handlers) DiffHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	from, fromErr := strconv.Atoi(r.URL.Query().Get("from"))
+	to, toErr := strconv.Atoi(r.URL.Query().Get("to"))
+	if fromErr != nil || toErr != nil || from < 1 || to < 1 {
+		h.respondWithError(w, r, http.StatusBadRequest, "from and to must be positive revision numbers", nil)
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	diff, err := h.service.DiffRevisions(ctx, tenantID, id, from, to)
+	if err != nil {
+		if errors.Is(err, ErrRevisionNotFound) {
+			h.respondWithError(w, r, http.StatusNotFound, "Revision not found", err)
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"application_id":  id.String(),
+		}).Error("Failed to diff application revisions")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to diff application revisions", err)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, diff)
+}
+
 // Helper methods
 
-func (h *Handlers) parseQueryInt(r *http.Request, key string, defaultValue int) int {
-	if value := r.URL.Query().Get(key); value != "" {
-		if parsed, err := strconv.Atoi(value); err == nil {
-			return parsed
+// checkPolicies evaluates the policies for creating the requested application. It
+// writes a 403 and returns false when a blocking policy denies it.
+func (h *Handlers) checkPolicies(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID, req *CreateApplicationRequest) bool {
+	if h.policies == nil {
+		return true
+	}
+
+	resource, err := policy.NewResource(policy.ResourceApplication, tenantID.String(), req.TeamName, req)
+	var violations []policy.Violation
+	if err == nil {
+		violations, err = h.policies.Evaluate(r.Context(), resource, policy.ActionCreate)
+	}
+	if err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"name":            req.Name,
+		}).Error("Failed to evaluate application policies")
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to evaluate policies", nil)
+		return false
+	}
+
+	for _, v := range violations {
+		if v.Blocking() {
+			continue
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldHTTPPath: r.URL.Path,
+			"policy":             v.Policy,
+			"rule":               v.Rule,
+			"enforcement":        v.Enforcement,
+			"violation":          v.Message,
+		}).Warn("Policy violation allowed")
+	}
+
+	blocking := policy.Blocking(violations)
+	if len(blocking) == 0 {
+		return true
+	}
+	if err := h.responder.PolicyViolations(w, r, blocking); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode policy violation response")
+	}
+	return false
+}
+
+// tenantID returns the tenant of the request, as stored in the context by
+// server.WithTenantResolution or read from the X-Tenant-ID header. It writes a 400 and
+// returns false when the tenant is missing or malformed.
+func (h *Handlers) tenantID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tenantCtx, err := server.GetTenantFromContext(r)
+	if err != nil {
+		if tenantCtx, err = server.ExtractTenantContext(r); err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, "Invalid tenant context", err)
+			return uuid.Nil, false
+		}
+	}
+	return tenantCtx.TenantID, true
+}
+
+// configFilterPrefix starts the query parameters that filter applications by config,
+// as in ?config.runtime=go or ?config.feature=*
+const configFilterPrefix = "config."
+
+// parseConfigFilters returns the config filters in the query string, keyed by config
+// key. Parameters with an empty value are ignored, like the other list filters.
+func parseConfigFilters(r *http.Request) (map[string]string, error) {
+	var filters map[string]string
+	for param, values := range r.URL.Query() {
+		key, ok := strings.CutPrefix(param, configFilterPrefix)
+		if !ok || values[0] == "" {
+			continue
+		}
+		if key == "" {
+			return nil, errors.New("config filters need a key, as in config.runtime=go")
 		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[key] = values[0]
+	}
+	return filters, nil
+}
+
+// requestActor identifies the caller making a change: the authenticated user, then the
+// X-User-Email header, and otherwise empty so that the service records the system
+func requestActor(r *http.Request) string {
+	if userID, ok := r.Context().Value(types.UserIDKey).(string); ok && userID != "" {
+		return userID
+	}
+	return r.Header.Get("X-User-Email")
+}
+
+// setRetirementHeaders warns clients about applications scheduled for retirement using
+// the Warning and Sunset headers
+func (h *Handlers) setRetirementHeaders(w http.ResponseWriter, app *Application) {
+	warning := app.RetirementWarning(time.Now())
+	if warning == "" {
+		return
+	}
+
+	if !app.AnnotationEnabled(AnnotationSuppressRetirementWarning) {
+		w.Header().Set("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+	w.Header().Set("Sunset", app.RetirementDate.UTC().Format(http.TimeFormat))
+}
+
+// respondWithNameCollision writes a 409 naming both the requested and derived names
+func (h *Handlers) respondWithNameCollision(w http.ResponseWriter, r *http.Request, collision *NameCollisionError) {
+	response := NameCollisionResponse{
+		ErrorResponse: ErrorResponse{
+			Error:   "Application name conflict",
+			Message: collision.Error(),
+			Code:    "NAME_COLLISION",
+			Time:    time.Now().UTC(),
+		},
+		RequestedName: collision.RequestedName,
+		DerivedName:   collision.DerivedName,
+		ConflictsWith: collision.ExistingName,
+	}
+
+	h.responder.Error(w, r, http.StatusConflict, &types.APIError{
+		Code:    response.Code,
+		Message: response.Message,
+		Details: fmt.Sprintf("requested_name=%s derived_name=%s conflicts_with=%s",
+			collision.RequestedName, collision.DerivedName, collision.ExistingName),
+	}, response)
+}
+
+// respondWithPaginationError writes a 400 naming the limit or offset parameter that
+// was rejected and the value that was sent
+func (h *Handlers) respondWithPaginationError(w http.ResponseWriter, r *http.Request, err error) {
+	response := ErrorResponse{
+		Error:   "Invalid pagination",
+		Message: err.Error(),
+		Code:    server.CodeInvalidPagination,
+		Time:    time.Now().UTC(),
+	}
+
+	apiErr := &types.APIError{Code: response.Code, Message: response.Message}
+	var paginationErr *server.PaginationError
+	if errors.As(err, &paginationErr) {
+		apiErr.Details = fmt.Sprintf("parameter=%s value=%s", paginationErr.Param, paginationErr.Value)
 	}
-	return defaultValue
+	h.responder.Error(w, r, http.StatusBadRequest, apiErr, response)
 }
 
-func (h *Handlers) respondWithError(w http.ResponseWriter, status int, message string, err error) {
+// validate checks req against its validate tags, writing a 400 with the failing
+// fields and returning false if it is invalid
+func (h *Handlers) validate(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	err := validation.Struct(req)
+	if err == nil {
+		return true
+	}
+
+	var errs server.ValidationErrors
+	if !errors.As(err, &errs) {
+		h.respondWithError(w, r, http.StatusInternalServerError, "Failed to validate request", err)
+		return false
+	}
+	h.responder.ValidationErrors(w, r, errs)
+	return false
+}
+
+// respondJSON writes payload with the status code. The status has been sent by the
+// time encoding fails, so the failure can only be logged.
+func (h *Handlers) respondJSON(w http.ResponseWriter, r *http.Request, status int, payload interface{}) {
+	if err := h.responder.JSON(w, r, status, payload); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError:      err.Error(),
+			logger.FieldHTTPPath:   r.URL.Path,
+			logger.FieldHTTPStatus: status,
+		}).Error("Failed to encode response")
+	}
+}
+
+func (h *Handlers) respondWithError(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
 	response := ErrorResponse{
 		Error:   message,
 		Message: message,
+		Code:    server.CodeForStatus(status),
 		Time:    time.Now().UTC(),
 	}
 
@@ -299,7 +1201,23 @@ func (h *Handlers) respondWithError(w http.ResponseWriter, status int, message s
 		response.Message = err.Error()
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(response)
+	h.responder.Error(w, r, status, &types.APIError{
+		Code:    response.Code,
+		Message: response.Message,
+	}, response)
+}
+
+// respondWithDecodeError reports a request body that could not be decoded: 413 when it
+// is over the MaxBodyBytes limit, otherwise 400 naming any unknown field
+func (h *Handlers) respondWithDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if limit, ok := middleware.BodyTooLarge(err); ok {
+		h.respondWithError(w, r, http.StatusRequestEntityTooLarge, "Request body too large", errors.New(middleware.BodyTooLargeError(limit).Message))
+		return
+	}
+	var unknownField *server.UnknownFieldError
+	if errors.As(err, &unknownField) {
+		h.respondWithError(w, r, http.StatusBadRequest, "Unknown field", err)
+		return
+	}
+	h.respondWithError(w, r, http.StatusBadRequest, "Invalid JSON", err)
 }
@@ -0,0 +1,1615 @@
+package applications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockApplicationService is a mock implementation of the application service for testing
+type MockApplicationService struct {
+	mock.Mock
+}
+
+func (m *MockApplicationService) CreateApplication(ctx context.Context, tenantID uuid.UUID, req *CreateApplicationRequest, createdBy string) (*Application, error) {
+	args := m.Called(ctx, tenantID, req, createdBy)
+	if app := args.Get(0); app != nil {
+		return app.(*Application), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) ListApplications(ctx context.Context, req *ListApplicationsRequest) ([]Application, int, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]Application), args.Int(1), args.Error(2)
+}
+
+func (m *MockApplicationService) GetApplication(ctx context.Context, tenantID, id uuid.UUID) (*Application, error) {
+	args := m.Called(ctx, tenantID, id)
+	if app := args.Get(0); app != nil {
+		return app.(*Application), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) GetApplicationsByTeam(ctx context.Context, tenantID uuid.UUID, teamName string) ([]Application, error) {
+	args := m.Called(ctx, tenantID, teamName)
+	return args.Get(0).([]Application), args.Error(1)
+}
+
+func (m *MockApplicationService) GetApplicationStats(ctx context.Context, tenantID uuid.UUID) (*ApplicationStats, error) {
+	args := m.Called(ctx, tenantID)
+	if stats := args.Get(0); stats != nil {
+		return stats.(*ApplicationStats), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) UpdateApplication(ctx context.Context, tenantID, id uuid.UUID, req *UpdateApplicationRequest, updatedBy string) (*Application, error) {
+	args := m.Called(ctx, tenantID, id, req, updatedBy)
+	if app := args.Get(0); app != nil {
+		return app.(*Application), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) DeleteApplication(ctx context.Context, tenantID, id uuid.UUID, deletedBy string) error {
+	args := m.Called(ctx, tenantID, id, deletedBy)
+	return args.Error(0)
+}
+
+func (m *MockApplicationService) SetResources(ctx context.Context, tenantID, id uuid.UUID, specs []types.ResourceSpec) (*ApplicationResources, error) {
+	args := m.Called(ctx, tenantID, id, specs)
+	if resources := args.Get(0); resources != nil {
+		return resources.(*ApplicationResources), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) GetResources(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationResources, error) {
+	args := m.Called(ctx, tenantID, id)
+	if resources := args.Get(0); resources != nil {
+		return resources.(*ApplicationResources), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) UpdateResourceStatus(ctx context.Context, tenantID, id uuid.UUID, name string, req *UpdateResourceStatusRequest) (*ApplicationResources, error) {
+	args := m.Called(ctx, tenantID, id, name, req)
+	if resources := args.Get(0); resources != nil {
+		return resources.(*ApplicationResources), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) SetEnvironment(ctx context.Context, tenantID, id uuid.UUID, vars []EnvVar) (*ApplicationEnvironment, error) {
+	args := m.Called(ctx, tenantID, id, vars)
+	if env := args.Get(0); env != nil {
+		return env.(*ApplicationEnvironment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) GetEnvironment(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationEnvironment, error) {
+	args := m.Called(ctx, tenantID, id)
+	if env := args.Get(0); env != nil {
+		return env.(*ApplicationEnvironment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) SetDependencies(ctx context.Context, tenantID, id uuid.UUID, deps []types.DependencySpec) ([]types.DependencySpec, error) {
+	args := m.Called(ctx, tenantID, id, deps)
+	if specs := args.Get(0); specs != nil {
+		return specs.([]types.DependencySpec), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) GetStatus(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationStatus, error) {
+	args := m.Called(ctx, tenantID, id)
+	if status := args.Get(0); status != nil {
+		return status.(*ApplicationStatus), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) GetDependencyGraph(ctx context.Context, tenantID, id uuid.UUID, direction string) (*DependencyGraph, error) {
+	args := m.Called(ctx, tenantID, id, direction)
+	if graph := args.Get(0); graph != nil {
+		return graph.(*DependencyGraph), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) ApproveApplication(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest) (*Application, error) {
+	args := m.Called(ctx, tenantID, id, reviewer, req)
+	if app := args.Get(0); app != nil {
+		return app.(*Application), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) RejectApplication(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest) (*Application, error) {
+	args := m.Called(ctx, tenantID, id, reviewer, req)
+	if app := args.Get(0); app != nil {
+		return app.(*Application), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) ListRevisions(ctx context.Context, tenantID, id uuid.UUID) ([]ApplicationRevision, error) {
+	args := m.Called(ctx, tenantID, id)
+	if revisions := args.Get(0); revisions != nil {
+		return revisions.([]ApplicationRevision), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockApplicationService) DiffRevisions(ctx context.Context, tenantID, id uuid.UUID, from, to int) (*RevisionDiff, error) {
+	args := m.Called(ctx, tenantID, id, from, to)
+	if diff := args.Get(0); diff != nil {
+		return diff.(*RevisionDiff), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// testTenantID is the tenant sent with handler test requests
+const testTenantID = "00000000-0000-0000-0000-000000000001"
+
+// newTenantRequest creates a test request scoped to testTenantID
+func newTenantRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("X-Tenant-ID", testTenantID)
+	return req
+}
+
+func setupTestHandlers() (*Handlers, *MockApplicationService) {
+	mockService := &MockApplicationService{}
+	testLogger := logger.New("debug", "text")
+	handlers := NewHandlers(mockService, testLogger)
+	return handlers, mockService
+}
+
+func TestHandlers_TenantIsolation(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	tenantA := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	tenantB := uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+	app := &Application{ID: uuid.New(), TenantID: tenantB, Name: "orders-api"}
+
+	mockService.On("CreateApplication", mock.Anything, tenantB, mock.Anything, mock.Anything).Return(app, nil).Once()
+	mockService.On("GetApplication", mock.Anything, tenantB, app.ID).Return(app, nil)
+	mockService.On("GetApplication", mock.Anything, tenantA, app.ID).Return(nil, ErrApplicationNotFound)
+
+	serve := func(method, target, tenant string, body io.Reader, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, body)
+		req.SetPathValue("id", app.ID.String())
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	created := serve(http.MethodPost, "/api/v1/applications", tenantB.String(),
+		bytes.NewBufferString(`{"name":"orders-api","display_name":"Orders API","team_name":"payments","owner_email":"owner@company.com","lifecycle":"development"}`),
+		handlers.CreateApplication)
+	require.Equal(t, http.StatusCreated, created.Code)
+
+	t.Run("other tenant cannot read the application", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/api/v1/applications/"+app.ID.String(), tenantA.String(), nil, handlers.GetApplication)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("owning tenant can read the application", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/api/v1/applications/"+app.ID.String(), tenantB.String(), nil, handlers.GetApplication)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("missing or malformed tenant is rejected", func(t *testing.T) {
+		for _, tenant := range []string{"", "not-a-uuid"} {
+			rr := serve(http.MethodGet, "/api/v1/applications/"+app.ID.String(), tenant, nil, handlers.GetApplication)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, tenant)
+		}
+	})
+
+	t.Run("tenant from request context is used", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/applications/"+app.ID.String(), nil)
+		req.SetPathValue("id", app.ID.String())
+		req.Header.Set("X-Tenant-ID", tenantA.String())
+
+		rr := httptest.NewRecorder()
+		server.WithTenantValidation(handlers.GetApplication)(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_GetApplication_Retirement(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("warns about scheduled retirement", func(t *testing.T) {
+		retirement := time.Now().Add(7 * 24 * time.Hour).UTC()
+		app := &Application{
+			ID:             uuid.New(),
+			Name:           "legacy-api",
+			Lifecycle:      "deprecated",
+			RetirementDate: &retirement,
+		}
+		mockService.On("GetApplication", mock.Anything, mock.Anything, app.ID).Return(app, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+app.ID.String(), nil)
+		req.SetPathValue("id", app.ID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetApplication(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Warning"), "will be retired on")
+		assert.Equal(t, retirement.Format(http.TimeFormat), rr.Header().Get("Sunset"))
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("retired application is still readable during grace period", func(t *testing.T) {
+		retirement := time.Now().Add(-24 * time.Hour).UTC()
+		app := &Application{
+			ID:             uuid.New(),
+			Name:           "legacy-api",
+			Lifecycle:      "deprecated",
+			RetirementDate: &retirement,
+		}
+		mockService.On("GetApplication", mock.Anything, mock.Anything, app.ID).Return(app, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+app.ID.String(), nil)
+		req.SetPathValue("id", app.ID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetApplication(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Warning"), "was retired on")
+
+		var response Application
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, app.ID, response.ID)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("no warning without retirement date", func(t *testing.T) {
+		app := &Application{ID: uuid.New(), Name: "active-api", Lifecycle: "production", Version: 2}
+		mockService.On("GetApplication", mock.Anything, mock.Anything, app.ID).Return(app, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+app.ID.String(), nil)
+		req.SetPathValue("id", app.ID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetApplication(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Warning"))
+		assert.Empty(t, rr.Header().Get("Sunset"))
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_UpdateApplication_Retired(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	id := uuid.New()
+	mockService.On("UpdateApplication", mock.Anything, mock.Anything, id, mock.Anything, mock.Anything).Return(nil, ErrApplicationRetired).Once()
+
+	body, err := json.Marshal(map[string]interface{}{"config": map[string]interface{}{"replicas": 3}})
+	require.NoError(t, err)
+
+	req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String(), bytes.NewReader(body))
+	req.SetPathValue("id", id.String())
+	req.Header.Set("If-Match", "*")
+
+	rr := httptest.NewRecorder()
+	handlers.UpdateApplication(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_UpdateApplication_IfMatch(t *testing.T) {
+	t.Run("matching version", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		id := uuid.New()
+		mockService.On("UpdateApplication", mock.Anything, mock.Anything, id, mock.MatchedBy(func(req *UpdateApplicationRequest) bool {
+			return req.IfMatch == 4
+		}), mock.Anything).Return(&Application{ID: id, Name: "orders-api", Version: 5}, nil).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String(), strings.NewReader(`{"display_name":"Orders"}`))
+		req.SetPathValue("id", id.String())
+		req.Header.Set("If-Match", `"4"`)
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateApplication(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		id := uuid.New()
+		mockService.On("UpdateApplication", mock.Anything, mock.Anything, id, mock.Anything, mock.Anything).Return(nil, ErrVersionMismatch).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String(), strings.NewReader(`{"display_name":"Orders"}`))
+		req.SetPathValue("id", id.String())
+		req.Header.Set("If-Match", `"3"`)
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateApplication(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing or malformed If-Match", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		for ifMatch, status := range map[string]int{
+			"":       http.StatusPreconditionRequired,
+			"4":      http.StatusBadRequest,
+			`W/"4"`:  http.StatusBadRequest,
+			`"four"`: http.StatusBadRequest,
+		} {
+			id := uuid.New()
+			req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String(), strings.NewReader(`{"display_name":"Orders"}`))
+			req.SetPathValue("id", id.String())
+			if ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+
+			rr := httptest.NewRecorder()
+			handlers.UpdateApplication(rr, req)
+
+			assert.Equal(t, status, rr.Code, ifMatch)
+		}
+
+		mockService.AssertNotCalled(t, "UpdateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_UnknownField(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	id := uuid.New()
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		body    string
+		field   string
+	}{
+		{"create", handlers.CreateApplication, http.MethodPost, `{"name": "orders-api", "owner_emial": "owner@company.com"}`, "owner_emial"},
+		{"update", handlers.UpdateApplication, http.MethodPut, `{"display_name": "Orders", "lifecylce": "production"}`, "lifecylce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTenantRequest(tt.method, "/api/v1/applications/"+id.String(), strings.NewReader(tt.body))
+			req.SetPathValue("id", id.String())
+			req.Header.Set("If-Match", "*")
+
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var errorResp ErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+			assert.Equal(t, "Unknown field", errorResp.Error)
+			assert.Contains(t, errorResp.Message, tt.field)
+		})
+	}
+
+	mockService.AssertNotCalled(t, "CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "UpdateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandlers_OversizedBody(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+	limit := middleware.MaxBodyBytes(1024)
+	body := `{"name": "orders-api", "description": "` + strings.Repeat("x", 2048) + `"}`
+
+	id := uuid.New()
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+	}{
+		{"create", handlers.CreateApplication, http.MethodPost},
+		{"update", handlers.UpdateApplication, http.MethodPut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTenantRequest(tt.method, "/api/v1/applications/"+id.String(), strings.NewReader(body))
+			req.SetPathValue("id", id.String())
+			req.Header.Set("If-Match", "*")
+			req.ContentLength = -1
+
+			rr := httptest.NewRecorder()
+			limit(tt.handler).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+			var errorResp ErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+			assert.Equal(t, "Request body too large", errorResp.Error)
+			assert.Equal(t, "Request body must not exceed 1024 bytes", errorResp.Message)
+		})
+	}
+
+	t.Run("malformed JSON is still a bad request", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		limit(http.HandlerFunc(handlers.CreateApplication)).ServeHTTP(rr, newTenantRequest(http.MethodPost, "/api/v1/applications", strings.NewReader("{")))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	mockService.AssertNotCalled(t, "CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandlers_Actor(t *testing.T) {
+	t.Run("authenticated user", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		id := uuid.New()
+		mockService.On("UpdateApplication", mock.Anything, mock.Anything, id, mock.Anything, "alice@company.com").
+			Return(&Application{ID: id, Name: "orders-api"}, nil).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String(), strings.NewReader(`{"display_name":"Orders"}`))
+		req.SetPathValue("id", id.String())
+		req.Header.Set("If-Match", "*")
+		req.Header.Set("X-User-Email", "ignored@company.com")
+		req = req.WithContext(context.WithValue(req.Context(), types.UserIDKey, "alice@company.com"))
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateApplication(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("user header", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		id := uuid.New()
+		mockService.On("DeleteApplication", mock.Anything, mock.Anything, id, "bob@company.com").Return(nil).Once()
+
+		req := newTenantRequest(http.MethodDelete, "/api/v1/applications/"+id.String(), nil)
+		req.SetPathValue("id", id.String())
+		req.Header.Set("X-User-Email", "bob@company.com")
+
+		rr := httptest.NewRecorder()
+		handlers.DeleteApplication(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown caller", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		mockService.On("CreateApplication", mock.Anything, mock.Anything, mock.Anything, "").
+			Return(&Application{ID: uuid.New(), Name: "orders-api"}, nil).Once()
+
+		body := `{"name":"orders-api","display_name":"Orders","team_name":"platform-team","owner_email":"owner@company.com","lifecycle":"development"}`
+		req := newTenantRequest(http.MethodPost, "/api/v1/applications", strings.NewReader(body))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateApplication(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_SetResources(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("declares resources", func(t *testing.T) {
+		id := uuid.New()
+		specs := []types.ResourceSpec{
+			{Type: "database", Name: "orders-db", Provider: "postgres"},
+			{Type: "cache", Name: "orders-cache", Provider: "redis"},
+		}
+		resources := newApplicationResources(id, mergeResources(nil, specs, time.Now()))
+		mockService.On("SetResources", mock.Anything, mock.Anything, id, specs).Return(resources, nil).Once()
+
+		body, err := json.Marshal(specs)
+		require.NoError(t, err)
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String()+"/resources", bytes.NewReader(body))
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.SetResources(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ApplicationResources
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Resources, 2)
+		assert.Equal(t, ResourceStatusPending, response.Resources[0].Status.Status)
+		assert.False(t, response.Ready)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid resources", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("SetResources", mock.Anything, mock.Anything, id, mock.Anything).
+			Return(nil, validateResourceSpecs([]types.ResourceSpec{{Name: "orders-db"}})).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String()+"/resources", bytes.NewBufferString(`[{"name":"orders-db"}]`))
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.SetResources(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_ApplicationAction(t *testing.T) {
+	newRequest := func(path, reviewer string) *http.Request {
+		req := newTenantRequest(http.MethodPost, "/api/v1/applications/"+path, bytes.NewReader([]byte(`{"comment": "ok"}`)))
+		req.SetPathValue("id", path)
+		if reviewer != "" {
+			req = req.WithContext(context.WithValue(req.Context(), types.UserIDKey, reviewer))
+		}
+		return req
+	}
+
+	t.Run("approves", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		id := uuid.New()
+		approved := &Application{ID: id, Status: StatusPending}
+		mockService.On("ApproveApplication", mock.Anything, mock.Anything, id, "lead@company.com", &ReviewApplicationRequest{Comment: "ok"}).
+			Return(approved, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.ApplicationAction(rr, newRequest(id.String()+":approve", "lead@company.com"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		id := uuid.New()
+		mockService.On("RejectApplication", mock.Anything, mock.Anything, id, "lead@company.com", mock.Anything).
+			Return(&Application{ID: id, Status: StatusRejected}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.ApplicationAction(rr, newRequest(id.String()+":reject", "lead@company.com"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response Application
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, StatusRejected, response.Status)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("maps review errors", func(t *testing.T) {
+		for err, status := range map[error]int{
+			ErrNotApprover:         http.StatusForbidden,
+			ErrNotPendingApproval:  http.StatusConflict,
+			ErrApplicationNotFound: http.StatusNotFound,
+		} {
+			handlers, mockService := setupTestHandlers()
+			id := uuid.New()
+			mockService.On("ApproveApplication", mock.Anything, mock.Anything, id, mock.Anything, mock.Anything).Return(nil, err).Once()
+
+			rr := httptest.NewRecorder()
+			handlers.ApplicationAction(rr, newRequest(id.String()+":approve", "dev@company.com"))
+
+			assert.Equal(t, status, rr.Code, err.Error())
+		}
+	})
+
+	t.Run("requires a reviewer", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		rr := httptest.NewRecorder()
+		handlers.ApplicationAction(rr, newRequest(uuid.New().String()+":approve", ""))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockService.AssertNotCalled(t, "ApproveApplication")
+	})
+
+	t.Run("ignores the X-User-Email header", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := newRequest(uuid.New().String()+":approve", "")
+		req.Header.Set("X-User-Email", "owner@company.com")
+		rr := httptest.NewRecorder()
+		handlers.ApplicationAction(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		mockService.AssertNotCalled(t, "ApproveApplication")
+	})
+
+	t.Run("unknown action and invalid ID", func(t *testing.T) {
+		handlers, _ := setupTestHandlers()
+
+		rr := httptest.NewRecorder()
+		handlers.ApplicationAction(rr, newRequest(uuid.New().String()+":archive", "lead@company.com"))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = httptest.NewRecorder()
+		handlers.ApplicationAction(rr, newRequest("not-a-uuid:approve", "lead@company.com"))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestHandlers_DiffHistory(t *testing.T) {
+	newRequest := func(id, query string) *http.Request {
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+id+"/history/diff?"+query, nil)
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	t.Run("returns the diff", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		id := uuid.New()
+		diff := &RevisionDiff{ApplicationID: id, From: 3, To: 5, Changes: []FieldChange{
+			{Path: "config.replicas", Change: ChangeChanged, From: float64(2), To: float64(3)},
+		}}
+		mockService.On("DiffRevisions", mock.Anything, mock.Anything, id, 3, 5).Return(diff, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.DiffHistory(rr, newRequest(id.String(), "from=3&to=5"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response RevisionDiff
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, diff.Changes, response.Changes)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid revisions", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		for _, query := range []string{"", "from=3", "from=a&to=5", "from=0&to=5"} {
+			rr := httptest.NewRecorder()
+			handlers.DiffHistory(rr, newRequest(uuid.New().String(), query))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+		mockService.AssertNotCalled(t, "DiffRevisions")
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		id := uuid.New()
+		mockService.On("DiffRevisions", mock.Anything, mock.Anything, id, 1, 9).Return(nil, ErrRevisionNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.DiffHistory(rr, newRequest(id.String(), "from=1&to=9"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandlers_GetStatus(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("returns the rolled up status", func(t *testing.T) {
+		id := uuid.New()
+		status := rollupStatus(id, "running", nil,
+			[]types.DependencySpec{{Type: DependencyTypeService, Name: "billing", Required: true}},
+			map[string]dependencyTarget{"billing": {Status: "failed"}},
+		)
+		mockService.On("GetStatus", mock.Anything, mock.Anything, id).Return(status, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+id.String()+"/status", nil)
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetStatus(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ApplicationStatus
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, HealthDegraded, response.Health)
+		assert.Equal(t, []string{"billing"}, response.UnhealthyDependencies)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown application", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("GetStatus", mock.Anything, mock.Anything, id).Return(nil, ErrApplicationNotFound).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+id.String()+"/status", nil)
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetStatus(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_GetDependencies(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	newRequest := func(id, query string) *http.Request {
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+id+"/dependencies?"+query, nil)
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	t.Run("returns the graph in the requested direction", func(t *testing.T) {
+		id := uuid.New()
+		graph := &DependencyGraph{
+			ApplicationID: id,
+			Direction:     DirectionUpstream,
+			Applications:  []DependencyNode{{ID: uuid.New(), Name: "billing-api", Status: "running", Depth: 1}},
+			Edges:         []DependencyEdge{{From: "orders-api", To: "billing-api", Required: true}},
+		}
+		mockService.On("GetDependencyGraph", mock.Anything, mock.Anything, id, DirectionUpstream).Return(graph, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetDependencies(rr, newRequest(id.String(), "direction=upstream"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response DependencyGraph
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, DirectionUpstream, response.Direction)
+		require.Len(t, response.Applications, 1)
+		assert.Equal(t, "billing-api", response.Applications[0].Name)
+		assert.False(t, response.HasCycle)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid direction", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("GetDependencyGraph", mock.Anything, mock.Anything, id, "sideways").
+			Return(nil, fmt.Errorf("%w: %q", ErrInvalidDirection, "sideways")).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetDependencies(rr, newRequest(id.String(), "direction=sideways"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown application", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("GetDependencyGraph", mock.Anything, mock.Anything, id, "").Return(nil, ErrApplicationNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetDependencies(rr, newRequest(id.String(), ""))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_GetApplicationsByTeam(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("lists the team's applications", func(t *testing.T) {
+		mockService.On("GetApplicationsByTeam", mock.Anything, mock.Anything, "platform-team").
+			Return([]Application{{Name: "billing"}, {Name: "orders-api"}}, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/by-team?team_name=platform-team", nil)
+		rr := httptest.NewRecorder()
+		handlers.GetApplicationsByTeam(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			TeamName     string        `json:"team_name"`
+			Applications []Application `json:"applications"`
+			Total        int           `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "platform-team", response.TeamName)
+		assert.Len(t, response.Applications, 2)
+		assert.Equal(t, 2, response.Total)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("requires a team", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/by-team", nil)
+		rr := httptest.NewRecorder()
+		handlers.GetApplicationsByTeam(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "GetApplicationsByTeam", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_GetApplicationStats(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	stats := &ApplicationStats{
+		Total:       3,
+		ByLifecycle: map[string]int{"development": 2, "production": 1},
+		ByStatus:    map[string]int{"pending": 3},
+	}
+	mockService.On("GetApplicationStats", mock.Anything, mock.Anything).Return(stats, nil).Once()
+
+	req := newTenantRequest(http.MethodGet, "/api/v1/applications/stats", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetApplicationStats(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response ApplicationStats
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, *stats, response)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_UpdateResourceStatus(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("marks resource ready", func(t *testing.T) {
+		id := uuid.New()
+		resources := newApplicationResources(id, []ApplicationResource{{
+			Spec:   types.ResourceSpec{Type: "database", Name: "orders-db"},
+			Status: types.ResourceStatus{Name: "orders-db", Type: "database", Status: ResourceStatusReady, Ready: true},
+		}})
+		statusReq := &UpdateResourceStatusRequest{Status: ResourceStatusReady, Ready: true}
+		mockService.On("UpdateResourceStatus", mock.Anything, mock.Anything, id, "orders-db", statusReq).Return(resources, nil).Once()
+
+		body, err := json.Marshal(statusReq)
+		require.NoError(t, err)
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String()+"/resources/orders-db/status", bytes.NewReader(body))
+		req.SetPathValue("id", id.String())
+		req.SetPathValue("name", "orders-db")
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateResourceStatus(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ApplicationResources
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Ready)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown resource", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("UpdateResourceStatus", mock.Anything, mock.Anything, id, "missing", mock.Anything).Return(nil, ErrResourceNotFound).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String()+"/resources/missing/status", bytes.NewBufferString(`{"ready":true}`))
+		req.SetPathValue("id", id.String())
+		req.SetPathValue("name", "missing")
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateResourceStatus(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_Annotations(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("suppress-retirement-warning toggle omits Warning header", func(t *testing.T) {
+		retirement := time.Now().Add(7 * 24 * time.Hour).UTC()
+		app := &Application{
+			ID:             uuid.New(),
+			Name:           "legacy-api",
+			Lifecycle:      "deprecated",
+			RetirementDate: &retirement,
+			Annotations:    map[string]string{AnnotationSuppressRetirementWarning: "true"},
+		}
+		mockService.On("GetApplication", mock.Anything, mock.Anything, app.ID).Return(app, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+app.ID.String(), nil)
+		req.SetPathValue("id", app.ID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetApplication(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Warning"))
+		assert.Equal(t, retirement.Format(http.TimeFormat), rr.Header().Get("Sunset"))
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("reserved annotation is rejected", func(t *testing.T) {
+		annotations := map[string]string{"aiidp.io/turbo-mode": "true"}
+		mockService.On("CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, ValidateAnnotations(annotations)).Once()
+
+		body, err := json.Marshal(CreateApplicationRequest{
+			Name:        "orders-api",
+			DisplayName: "Orders API",
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+			Annotations: annotations,
+		})
+		require.NoError(t, err)
+
+		req := newTenantRequest(http.MethodPost, "/api/v1/applications", bytes.NewReader(body))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateApplication(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "aiidp.io/turbo-mode")
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_CreateApplication_Policies(t *testing.T) {
+	productionPolicy := func(enforcement types.PolicyEnforcement) policy.Static {
+		return policy.Static{{
+			Metadata: types.ObjectMeta{Name: "production-freeze"},
+			Spec: types.PolicySpec{
+				Type:        types.PolicyTypeCompliance,
+				Scope:       types.PolicyScopeTenant,
+				Enforcement: enforcement,
+				Rules: []types.PolicyRule{{
+					Name:        "no-new-production-apps",
+					Description: "new applications cannot start in production",
+					Resource:    policy.ResourceApplication,
+					Action:      policy.ActionCreate,
+					Conditions:  []types.PolicyCondition{{Field: "lifecycle", Operator: policy.OperatorEq, Value: "production"}},
+					Effect:      types.PolicyEffectDeny,
+				}},
+			},
+		}}
+	}
+
+	createRequest := func(t *testing.T, lifecycle string) *http.Request {
+		body, err := json.Marshal(CreateApplicationRequest{
+			Name:        "orders-api",
+			DisplayName: "Orders API",
+			TeamName:    "payments",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   lifecycle,
+		})
+		require.NoError(t, err)
+		return newTenantRequest(http.MethodPost, "/api/v1/applications", bytes.NewReader(body))
+	}
+
+	t.Run("blocking policy rejects a production app", func(t *testing.T) {
+		mockService := &MockApplicationService{}
+		handlers := NewHandlers(mockService, logger.New("debug", "text"),
+			WithPolicies(policy.NewEvaluator(productionPolicy(types.PolicyEnforcementBlock))))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateApplication(rr, createRequest(t, "production"))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		var response struct {
+			Details struct {
+				PolicyViolations []policy.Violation `json:"policy_violations"`
+			} `json:"details"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Details.PolicyViolations, 1)
+		assert.Equal(t, "production-freeze", response.Details.PolicyViolations[0].Policy)
+		assert.Equal(t, "new applications cannot start in production", response.Details.PolicyViolations[0].Message)
+
+		mockService.AssertNotCalled(t, "CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("blocking policy allows other lifecycles", func(t *testing.T) {
+		mockService := &MockApplicationService{}
+		handlers := NewHandlers(mockService, logger.New("debug", "text"),
+			WithPolicies(policy.NewEvaluator(productionPolicy(types.PolicyEnforcementBlock))))
+		mockService.On("CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&Application{ID: uuid.New(), Name: "orders-api"}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.CreateApplication(rr, createRequest(t, "development"))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("warning policy allows a production app", func(t *testing.T) {
+		mockService := &MockApplicationService{}
+		handlers := NewHandlers(mockService, logger.New("debug", "text"),
+			WithPolicies(policy.NewEvaluator(productionPolicy(types.PolicyEnforcementWarn))))
+		mockService.On("CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&Application{ID: uuid.New(), Name: "orders-api"}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.CreateApplication(rr, createRequest(t, "production"))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// Compile-time check that MockApplicationService implements ApplicationService
+var _ ApplicationService = (*MockApplicationService)(nil)
+
+func TestHandlers_Environment(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("sets a plain variable", func(t *testing.T) {
+		id := uuid.New()
+		vars := []EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+		mockService.On("SetEnvironment", mock.Anything, mock.Anything, id, vars).
+			Return(newApplicationEnvironment(id, vars), nil).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String()+"/env", bytes.NewBufferString(`[{"name":"LOG_LEVEL","value":"debug"}]`))
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.SetEnvironment(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ApplicationEnvironment
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Variables, 1)
+		assert.Equal(t, "debug", response.Variables[0].Value)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("masks secret-backed variables on read", func(t *testing.T) {
+		id := uuid.New()
+		vars := []EnvVar{
+			{Name: "LOG_LEVEL", Value: "debug"},
+			{Name: "DATABASE_PASSWORD", SecretRef: &types.SecretRef{Name: "orders-db", Key: "password"}},
+		}
+		mockService.On("GetEnvironment", mock.Anything, mock.Anything, id).
+			Return(newApplicationEnvironment(id, vars), nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+id.String()+"/env", nil)
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetEnvironment(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ApplicationEnvironment
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Variables, 2)
+		assert.Equal(t, "debug", response.Variables[0].Value)
+		assert.Equal(t, MaskedValue, response.Variables[1].Value)
+		require.NotNil(t, response.Variables[1].SecretRef)
+		assert.Equal(t, "orders-db", response.Variables[1].SecretRef.Name)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid variable name", func(t *testing.T) {
+		id := uuid.New()
+		vars := []EnvVar{{Name: "1-BAD", Value: "x"}}
+		mockService.On("SetEnvironment", mock.Anything, mock.Anything, id, vars).
+			Return(nil, ValidateEnvironment(vars)).Once()
+
+		req := newTenantRequest(http.MethodPut, "/api/v1/applications/"+id.String()+"/env", bytes.NewBufferString(`[{"name":"1-BAD","value":"x"}]`))
+		req.SetPathValue("id", id.String())
+
+		rr := httptest.NewRecorder()
+		handlers.SetEnvironment(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_ListApplications_ActorFilters(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("passes created_by and updated_by to the service", func(t *testing.T) {
+		mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+			return req.CreatedBy == "owner@company.com" && req.UpdatedBy == "system"
+		})).Return([]Application{{Name: "orders-api", CreatedBy: "owner@company.com"}}, 1, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?created_by=owner@company.com&updated_by=system", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid creator", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?created_by=not%20a%20user", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "ListApplications", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_ListApplications_Pagination(t *testing.T) {
+	t.Run("defaults the limit when unspecified", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+			return req.Limit == 50 && req.Offset == 0
+		})).Return([]Application{}, 0, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects a limit over the configured max", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		WithPageLimits(server.PageLimits{DefaultLimit: 20, MaxLimit: 40})(handlers)
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?limit=41", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "between 1 and 40")
+		mockService.AssertNotCalled(t, "ListApplications", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects invalid parameters instead of using defaults", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"limit=abc": `invalid limit "abc": must be an integer between 1 and 100`,
+			"offset=-5": `invalid offset "-5": must be a non-negative integer`,
+			"limit=0":   `invalid limit "0": must be an integer between 1 and 100`,
+		} {
+			mockService := &MockApplicationService{}
+			handlers := NewHandlers(mockService, logger.New("debug", "text"), WithResponseEnvelope(true))
+
+			req := newTenantRequest(http.MethodGet, "/api/v1/applications?"+query, nil)
+			rr := httptest.NewRecorder()
+			handlers.ListApplications(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+
+			var response types.APIResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.NotNil(t, response.Error, query)
+			assert.Equal(t, server.CodeInvalidPagination, response.Error.Code, query)
+			assert.Equal(t, message, response.Error.Message, query)
+			assert.Contains(t, response.Error.Details, "parameter=", query)
+			mockService.AssertNotCalled(t, "ListApplications", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestHandlers_ConditionalGet(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("list", func(t *testing.T) {
+		apps := []Application{{ID: uuid.New(), Name: "orders-api", Version: 2}}
+		mockService.On("ListApplications", mock.Anything, mock.Anything).Return(apps, 1, nil).Twice()
+
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, newTenantRequest(http.MethodGet, "/api/v1/applications", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		etag := rr.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications", nil)
+		req.Header.Set("If-None-Match", etag)
+		rr = httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("detail", func(t *testing.T) {
+		appID := uuid.New()
+		mockService.On("GetApplication", mock.Anything, mock.Anything, appID).Return(&Application{ID: appID, Name: "orders-api", Version: 5}, nil).Twice()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications/"+appID.String(), nil)
+		req.SetPathValue("id", appID.String())
+		rr := httptest.NewRecorder()
+		handlers.GetApplication(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+
+		req = newTenantRequest(http.MethodGet, "/api/v1/applications/"+appID.String(), nil)
+		req.SetPathValue("id", appID.String())
+		req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+		rr = httptest.NewRecorder()
+		handlers.GetApplication(rr, req)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestHandlers_ListApplications_Sort(t *testing.T) {
+	t.Run("passes the sort to the service", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+			return req.Sort == database.Sort{Column: "updated_at"}
+		})).Return([]Application{}, 0, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?sort=updated_at", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an unknown sort field", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?sort=-owner_email", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "sort by one of name, display_name, created_at, updated_at")
+		mockService.AssertNotCalled(t, "ListApplications", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_ListApplications_Search(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+		return req.Search == "orders"
+	})).Return([]Application{{Name: "orders-api"}}, 1, nil).Once()
+
+	req := newTenantRequest(http.MethodGet, "/api/v1/applications?search=orders", nil)
+	rr := httptest.NewRecorder()
+	handlers.ListApplications(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_ListApplications_ConfigFilters(t *testing.T) {
+	t.Run("passes config filters to the service", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+			return assert.ObjectsAreEqual(map[string]string{"runtime": "go", "feature": ConfigFilterAny}, req.ConfigFilters)
+		})).Return([]Application{}, 0, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?config.runtime=go&config.feature=*&config.tier=", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects a filter without a key", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?config.=go", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "ListApplications", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_ListApplications_IncludeTerminated(t *testing.T) {
+	for query, want := range map[string]bool{"": false, "?include_terminated=true": true} {
+		handlers, mockService := setupTestHandlers()
+
+		mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+			return req.IncludeTerminated == want
+		})).Return([]Application{}, 0, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications"+query, nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestHandlers_ListApplications_Cursor(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := database.Cursor{CreatedAt: created, ID: uuid.New()}
+
+	page := make([]Application, 3)
+	for i := range page {
+		page[i] = Application{ID: uuid.New(), Name: fmt.Sprintf("app-%d", i), CreatedAt: created}
+	}
+
+	t.Run("fetches one extra row and returns the next cursor", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListApplications", mock.Anything, mock.MatchedBy(func(req *ListApplicationsRequest) bool {
+			return req.After != nil && *req.After == cursor && req.Limit == 3 && req.Offset == 0
+		})).Return(page, 40, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?limit=2&offset=5&cursor="+cursor.Encode(), nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListApplicationsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Applications, 2)
+		assert.Equal(t, database.Cursor{CreatedAt: created, ID: page[1].ID}.Encode(), response.Pagination.NextCursor)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("last page has no next cursor", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListApplications", mock.Anything, mock.Anything).Return(page[:2], 40, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?limit=2&cursor="+cursor.Encode(), nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListApplicationsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Applications, 2)
+		assert.Empty(t, response.Pagination.NextCursor)
+	})
+
+	t.Run("rejects an invalid cursor", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/applications?cursor=***", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListApplications(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "ListApplications", mock.Anything, mock.Anything)
+	})
+}
+
+// validationFields returns the fields named in a validation error response
+func validationFields(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var response struct {
+		Details struct {
+			ValidationErrors server.ValidationErrors `json:"validation_errors"`
+		} `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(body, &response))
+
+	fields := make([]string, 0, len(response.Details.ValidationErrors))
+	for _, e := range response.Details.ValidationErrors {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestHandlers_RequestValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		fields []string
+	}{
+		{
+			name:   "create missing required fields",
+			method: http.MethodPost,
+			body:   `{"name":"orders-api"}`,
+			fields: []string{"display_name", "team_name", "owner_email", "lifecycle"},
+		},
+		{
+			name:   "create with bad email",
+			method: http.MethodPost,
+			body:   `{"name":"orders-api","display_name":"Orders API","team_name":"platform-team","owner_email":"not-an-email","lifecycle":"production"}`,
+			fields: []string{"owner_email"},
+		},
+		{
+			name:   "create with invalid lifecycle",
+			method: http.MethodPost,
+			body:   `{"name":"orders-api","display_name":"Orders API","team_name":"platform-team","owner_email":"owner@company.com","lifecycle":"prod"}`,
+			fields: []string{"lifecycle"},
+		},
+		{
+			name:   "update with invalid lifecycle",
+			method: http.MethodPut,
+			body:   `{"lifecycle":"retired"}`,
+			fields: []string{"lifecycle"},
+		},
+		{
+			name:   "update with bad email",
+			method: http.MethodPut,
+			body:   `{"owner_email":"owner"}`,
+			fields: []string{"owner_email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers, mockService := setupTestHandlers()
+			id := uuid.New()
+
+			rr := httptest.NewRecorder()
+			if tt.method == http.MethodPost {
+				handlers.CreateApplication(rr, newTenantRequest(tt.method, "/api/v1/applications", bytes.NewBufferString(tt.body)))
+			} else {
+				req := newTenantRequest(tt.method, "/api/v1/applications/"+id.String(), bytes.NewBufferString(tt.body))
+				req.SetPathValue("id", id.String())
+				req.Header.Set("If-Match", "*")
+				handlers.UpdateApplication(rr, req)
+			}
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, tt.fields, validationFields(t, rr.Body.Bytes()))
+			mockService.AssertNotCalled(t, "CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockService.AssertNotCalled(t, "UpdateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandlers_CreateApplication_NameCollision(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	mockService.On("CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &NameCollisionError{RequestedName: "Orders_API", DerivedName: "orders-api", ExistingName: "orders.api"}).Once()
+
+	body := `{"name":"Orders_API","display_name":"Orders API","team_name":"platform-team","owner_email":"owner@company.com","lifecycle":"production"}`
+	req := newTenantRequest(http.MethodPost, "/api/v1/applications", bytes.NewBufferString(body))
+
+	rr := httptest.NewRecorder()
+	handlers.CreateApplication(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	var response NameCollisionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "NAME_COLLISION", response.Code)
+	assert.Equal(t, "Orders_API", response.RequestedName)
+	assert.Equal(t, "orders-api", response.DerivedName)
+	assert.Equal(t, "orders.api", response.ConflictsWith)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_CreateApplication_QuotaExceeded(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	mockService.On("CreateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &database.QuotaExceededError{Resource: database.LimitApplications, Limit: 2}).Once()
+
+	body := `{"name":"orders-api","display_name":"Orders API","team_name":"platform-team","owner_email":"owner@company.com","lifecycle":"production"}`
+	req := newTenantRequest(http.MethodPost, "/api/v1/applications", bytes.NewBufferString(body))
+
+	rr := httptest.NewRecorder()
+	handlers.CreateApplication(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "tenant quota exceeded: the tenant may have at most 2 applications", response.Message)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_ResponseEnvelope(t *testing.T) {
+	mockService := &MockApplicationService{}
+	handlers := NewHandlers(mockService, logger.New("debug", "text"), WithResponseEnvelope(true))
+
+	app := Application{ID: uuid.New(), Name: "orders-api", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	mockService.On("ListApplications", mock.Anything, mock.Anything).
+		Return([]Application{app}, 41, nil).Once()
+
+	req := newTenantRequest(http.MethodGet, "/api/v1/applications?limit=20&offset=20", nil)
+	req = req.WithContext(context.WithValue(req.Context(), types.RequestIDKey, "req-456"))
+	rr := httptest.NewRecorder()
+	handlers.ListApplications(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    []Application `json:"data"`
+		Meta    types.Meta    `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "orders-api", response.Data[0].Name)
+	assert.Equal(t, "req-456", response.Meta.RequestID)
+	require.NotNil(t, response.Meta.Pagination)
+	nextCursor := database.Cursor{CreatedAt: app.CreatedAt, ID: app.ID}.Encode()
+	assert.Equal(t, types.Pagination{Page: 2, PerPage: 20, Total: 41, TotalPages: 3, NextCursor: nextCursor}, *response.Meta.Pagination)
+
+	mockService.AssertExpectations(t)
+}
+
+// failingWriter is a response writer whose body writes fail, as when the client has
+// gone away
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestHandlers_RespondJSON(t *testing.T) {
+	var logs bytes.Buffer
+	handlers := NewHandlers(&MockApplicationService{}, logger.NewWithOptions("info", "json", logger.Options{Output: &logs}))
+	req := newTenantRequest(http.MethodGet, "/api/v1/applications/stats", nil)
+
+	t.Run("writes the status and body", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handlers.respondJSON(rr, req, http.StatusOK, ApplicationStats{Total: 3})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"total":3,"by_lifecycle":null,"by_status":null}`, rr.Body.String())
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("logs an encode failure", func(t *testing.T) {
+		rr := failingWriter{httptest.NewRecorder()}
+		handlers.respondJSON(rr, req, http.StatusOK, ApplicationStats{Total: 3})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, logs.String(), "Failed to encode response")
+		assert.Contains(t, logs.String(), "connection reset by peer")
+		assert.Contains(t, logs.String(), `"http_status":200`)
+	})
+}
@@ -0,0 +1,264 @@
+package applications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRevisionNotFound is returned when a requested application revision does not exist
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Field change kinds reported by DiffRevisions
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// sensitiveKeyPattern matches snapshot keys whose values are redacted in diffs
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|api[_-]?key|private[_-]?key)`)
+
+// ApplicationRevision describes one stored revision of an application
+type ApplicationRevision struct {
+	Revision  int       `json:"revision"`
+	ChangedBy string    `json:"changed_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FieldChange is a single field-level difference between two revisions. Path uses
+// dots for nested keys, e.g. "config.database.host". Values of sensitive keys are
+// replaced with MaskedValue and Redacted is set.
+type FieldChange struct {
+	Path     string      `json:"path"`
+	Change   string      `json:"change"`
+	From     interface{} `json:"from,omitempty"`
+	To       interface{} `json:"to,omitempty"`
+	Redacted bool        `json:"redacted,omitempty"`
+}
+
+// RevisionDiff lists what changed between two revisions of an application
+type RevisionDiff struct {
+	ApplicationID uuid.UUID     `json:"application_id"`
+	From          int           `json:"from"`
+	To            int           `json:"to"`
+	Changes       []FieldChange `json:"changes"`
+}
+
+// applicationSnapshot is the state of an application stored with each revision.
+// Update timestamps are left out as the revision itself records them.
+type applicationSnapshot struct {
+	Name           string                 `json:"name"`
+	DisplayName    string                 `json:"display_name"`
+	Description    *string                `json:"description,omitempty"`
+	TeamName       string                 `json:"team_name"`
+	OwnerEmail     string                 `json:"owner_email"`
+	Lifecycle      string                 `json:"lifecycle"`
+	Status         string                 `json:"status"`
+	Config         map[string]interface{} `json:"config"`
+	Annotations    map[string]string      `json:"annotations,omitempty"`
+	RetirementDate *time.Time             `json:"retirement_date,omitempty"`
+}
+
+// recordRevision stores the current state of app as its next revision. Callers hold
+// the application's row lock, or have just inserted it, so revision numbers are
+// assigned in order.
+func recordRevision(ctx context.Context, db execer, app *Application, changedBy string) error {
+	snapshot, err := json.Marshal(applicationSnapshot{
+		Name:           app.Name,
+		DisplayName:    app.DisplayName,
+		Description:    app.Description,
+		TeamName:       app.TeamName,
+		OwnerEmail:     app.OwnerEmail,
+		Lifecycle:      app.Lifecycle,
+		Status:         app.Status,
+		Config:         app.Config,
+		Annotations:    app.Annotations,
+		RetirementDate: app.RetirementDate,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision: %w", err)
+	}
+
+	query := `
+		INSERT INTO resource_management.application_revisions (
+			tenant_id, application_id, revision, snapshot, changed_by, created_at
+		)
+		SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, $4, $5
+		FROM resource_management.application_revisions
+		WHERE application_id = $2
+	`
+	if _, err := db.Exec(ctx, query, app.TenantID, app.ID, snapshot, changedBy, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	return nil
+}
+
+// ListRevisions returns an application's revisions, oldest first. Applications
+// created before change history was recorded may have none.
+func (s *Service) ListRevisions(ctx context.Context, tenantID, id uuid.UUID) ([]ApplicationRevision, error) {
+	query := `
+		SELECT r.revision, r.changed_by, r.created_at
+		FROM resource_management.applications a
+		LEFT JOIN resource_management.application_revisions r ON r.application_id = a.id
+		WHERE a.tenant_id = $1 AND a.id = $2
+		ORDER BY r.revision
+	`
+
+	var revisions []ApplicationRevision
+	found := false
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		revisions, found = []ApplicationRevision{}, false
+
+		rows, err := s.reader().Query(ctx, query, tenantID, id)
+		if err != nil {
+			return fmt.Errorf("failed to query revisions: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			found = true
+
+			var revision *int
+			var changedBy *string
+			var createdAt *time.Time
+			if err := rows.Scan(&revision, &changedBy, &createdAt); err != nil {
+				return fmt.Errorf("failed to scan revision: %w", err)
+			}
+			if revision == nil {
+				continue
+			}
+			revisions = append(revisions, ApplicationRevision{Revision: *revision, ChangedBy: *changedBy, CreatedAt: *createdAt})
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating revisions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrApplicationNotFound
+	}
+
+	return revisions, nil
+}
+
+// DiffRevisions returns the field-level changes between two revisions of an
+// application. Config and annotations are compared key by key.
+func (s *Service) DiffRevisions(ctx context.Context, tenantID, id uuid.UUID, from, to int) (*RevisionDiff, error) {
+	query := `
+		SELECT revision, snapshot FROM resource_management.application_revisions
+		WHERE tenant_id = $1 AND application_id = $2 AND revision = ANY($3)
+	`
+
+	var snapshots map[int]map[string]interface{}
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		snapshots = make(map[int]map[string]interface{}, 2)
+
+		rows, err := s.reader().Query(ctx, query, tenantID, id, []int{from, to})
+		if err != nil {
+			return fmt.Errorf("failed to query revisions: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var revision int
+			var data []byte
+			if err := rows.Scan(&revision, &data); err != nil {
+				return fmt.Errorf("failed to scan revision: %w", err)
+			}
+
+			var snapshot map[string]interface{}
+			if err := unmarshalJSONB(data, &snapshot); err != nil {
+				return fmt.Errorf("failed to unmarshal revision %d: %w", revision, err)
+			}
+			snapshots[revision] = snapshot
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating revisions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, revision := range []int{from, to} {
+		if _, ok := snapshots[revision]; !ok {
+			return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, revision)
+		}
+	}
+
+	return &RevisionDiff{
+		ApplicationID: id,
+		From:          from,
+		To:            to,
+		Changes:       diffSnapshots(snapshots[from], snapshots[to]),
+	}, nil
+}
+
+// diffSnapshots compares two decoded snapshots, recursing into nested objects so that
+// each changed key is reported separately. Changes are sorted by path.
+func diffSnapshots(from, to map[string]interface{}) []FieldChange {
+	changes := []FieldChange{}
+	diffObjects("", from, to, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// diffObjects appends the differences between two JSON objects under prefix
+func diffObjects(prefix string, from, to map[string]interface{}, changes *[]FieldChange) {
+	for key, before := range from {
+		path := prefix + key
+		after, ok := to[key]
+		if !ok {
+			*changes = append(*changes, newFieldChange(path, key, ChangeRemoved, before, nil))
+			continue
+		}
+
+		beforeObject, beforeIsObject := before.(map[string]interface{})
+		afterObject, afterIsObject := after.(map[string]interface{})
+		if beforeIsObject && afterIsObject && !sensitiveKeyPattern.MatchString(key) {
+			diffObjects(path+".", beforeObject, afterObject, changes)
+			continue
+		}
+
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, newFieldChange(path, key, ChangeChanged, before, after))
+		}
+	}
+
+	for key, after := range to {
+		if _, ok := from[key]; !ok {
+			*changes = append(*changes, newFieldChange(prefix+key, key, ChangeAdded, nil, after))
+		}
+	}
+}
+
+// newFieldChange builds a change, redacting the values of sensitive keys
+func newFieldChange(path, key, change string, from, to interface{}) FieldChange {
+	if !sensitiveKeyPattern.MatchString(key) {
+		return FieldChange{Path: path, Change: change, From: from, To: to}
+	}
+
+	redacted := FieldChange{Path: path, Change: change, Redacted: true}
+	if from != nil {
+		redacted.From = MaskedValue
+	}
+	if to != nil {
+		redacted.To = MaskedValue
+	}
+	return redacted
+}
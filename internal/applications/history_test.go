@@ -0,0 +1,54 @@
+package applications
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeSnapshot decodes a snapshot the way it is read back from the database
+func decodeSnapshot(t *testing.T, snapshot string) map[string]interface{} {
+	t.Helper()
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(snapshot), &decoded))
+	return decoded
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	from := decodeSnapshot(t, `{
+		"name": "orders", "display_name": "Orders", "status": "pending",
+		"config": {"replicas": 2, "region": "eu-west-1", "database": {"host": "db-1", "password": "hunter2"}}
+	}`)
+	to := decodeSnapshot(t, `{
+		"name": "orders", "display_name": "Order Service", "status": "pending",
+		"config": {"replicas": 3, "database": {"host": "db-1", "password": "hunter3"}, "api_token": "abc"}
+	}`)
+
+	changes := diffSnapshots(from, to)
+
+	assert.Equal(t, []FieldChange{
+		{Path: "config.api_token", Change: ChangeAdded, To: MaskedValue, Redacted: true},
+		{Path: "config.database.password", Change: ChangeChanged, From: MaskedValue, To: MaskedValue, Redacted: true},
+		{Path: "config.region", Change: ChangeRemoved, From: "eu-west-1"},
+		{Path: "config.replicas", Change: ChangeChanged, From: float64(2), To: float64(3)},
+		{Path: "display_name", Change: ChangeChanged, From: "Orders", To: "Order Service"},
+	}, changes)
+}
+
+func TestDiffSnapshots_Identical(t *testing.T) {
+	snapshot := decodeSnapshot(t, `{"name": "orders", "config": {"tags": ["a", "b"]}}`)
+
+	assert.Empty(t, diffSnapshots(snapshot, snapshot))
+}
+
+func TestDiffSnapshots_ObjectReplacedByScalar(t *testing.T) {
+	from := decodeSnapshot(t, `{"config": {"scaling": {"min": 1}, "credentials": {"user": "a"}}}`)
+	to := decodeSnapshot(t, `{"config": {"scaling": "auto", "credentials": {"user": "b"}}}`)
+
+	assert.Equal(t, []FieldChange{
+		{Path: "config.credentials", Change: ChangeChanged, From: MaskedValue, To: MaskedValue, Redacted: true},
+		{Path: "config.scaling", Change: ChangeChanged, From: map[string]interface{}{"min": float64(1)}, To: "auto"},
+	}, diffSnapshots(from, to))
+}
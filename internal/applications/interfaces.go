@@ -0,0 +1,31 @@
+package applications
+
+import (
+	"context"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// ApplicationService defines the interface for application operations
+type ApplicationService interface {
+	CreateApplication(ctx context.Context, tenantID uuid.UUID, req *CreateApplicationRequest, createdBy string) (*Application, error)
+	ListApplications(ctx context.Context, req *ListApplicationsRequest) ([]Application, int, error)
+	GetApplicationsByTeam(ctx context.Context, tenantID uuid.UUID, teamName string) ([]Application, error)
+	GetApplicationStats(ctx context.Context, tenantID uuid.UUID) (*ApplicationStats, error)
+	GetApplication(ctx context.Context, tenantID, id uuid.UUID) (*Application, error)
+	UpdateApplication(ctx context.Context, tenantID, id uuid.UUID, req *UpdateApplicationRequest, updatedBy string) (*Application, error)
+	DeleteApplication(ctx context.Context, tenantID, id uuid.UUID, deletedBy string) error
+	SetResources(ctx context.Context, tenantID, id uuid.UUID, specs []types.ResourceSpec) (*ApplicationResources, error)
+	GetResources(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationResources, error)
+	UpdateResourceStatus(ctx context.Context, tenantID, id uuid.UUID, name string, req *UpdateResourceStatusRequest) (*ApplicationResources, error)
+	SetEnvironment(ctx context.Context, tenantID, id uuid.UUID, vars []EnvVar) (*ApplicationEnvironment, error)
+	GetEnvironment(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationEnvironment, error)
+	SetDependencies(ctx context.Context, tenantID, id uuid.UUID, deps []types.DependencySpec) ([]types.DependencySpec, error)
+	GetStatus(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationStatus, error)
+	GetDependencyGraph(ctx context.Context, tenantID, id uuid.UUID, direction string) (*DependencyGraph, error)
+	ApproveApplication(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest) (*Application, error)
+	RejectApplication(ctx context.Context, tenantID, id uuid.UUID, reviewer string, req *ReviewApplicationRequest) (*Application, error)
+	ListRevisions(ctx context.Context, tenantID, id uuid.UUID) ([]ApplicationRevision, error)
+	DiffRevisions(ctx context.Context, tenantID, id uuid.UUID, from, to int) (*RevisionDiff, error)
+}
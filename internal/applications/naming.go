@@ -0,0 +1,78 @@
+package applications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Naming errors
+var (
+	ErrInvalidName   = errors.New("invalid application name")
+	ErrNameCollision = errors.New("application name collides with an existing application")
+)
+
+// maxDerivedNameLength is the DNS label limit shared by Kubernetes namespaces and
+// Postgres identifiers
+const maxDerivedNameLength = 63
+
+// derivedNameSeparators matches runs of characters that are not valid in a derived name
+var derivedNameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NameCollisionError reports a requested name whose derived identifier is already
+// used by another application in the tenant
+type NameCollisionError struct {
+	RequestedName string
+	DerivedName   string
+	ExistingName  string
+}
+
+// Error implements the error interface
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("application name %q derives identifier %q, already used by %q",
+		e.RequestedName, e.DerivedName, e.ExistingName)
+}
+
+// Unwrap returns ErrNameCollision
+func (e *NameCollisionError) Unwrap() error {
+	return ErrNameCollision
+}
+
+// DeriveName returns the identifier downstream resources are named after, such as
+// database identifiers and Kubernetes namespaces. It lowercases the name, collapses
+// every run of other characters into a single dash and truncates to 63 characters.
+func DeriveName(name string) (string, error) {
+	derived := derivedNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	derived = strings.Trim(derived, "-")
+	if len(derived) > maxDerivedNameLength {
+		derived = strings.TrimRight(derived[:maxDerivedNameLength], "-")
+	}
+
+	if derived == "" {
+		return "", fmt.Errorf("%w: %q contains no letters or digits", ErrInvalidName, name)
+	}
+	return derived, nil
+}
+
+// findDerivedNameOwner returns the name of the live application using a derived name,
+// or an empty string when it is free. Terminated applications release their names.
+func (s *Service) findDerivedNameOwner(ctx context.Context, tenantID uuid.UUID, derived string) (string, error) {
+	query := `
+		SELECT name FROM resource_management.applications
+		WHERE tenant_id = $1 AND derived_name = $2 AND status <> $3
+	`
+
+	var name string
+	if err := s.db.QueryRow(ctx, query, tenantID, derived, StatusTerminated).Scan(&name); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to check derived name: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,50 @@
+package applications
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"orders-api", "orders-api"},
+		{"Orders_API", "orders-api"},
+		{"orders.api", "orders-api"},
+		{"orders--api", "orders-api"},
+		{"  orders api  ", "orders-api"},
+		{"_orders_", "orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			derived, err := DeriveName(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, derived)
+		})
+	}
+
+	t.Run("truncates to a DNS label", func(t *testing.T) {
+		derived, err := DeriveName(strings.Repeat("a", 62) + "_b")
+		require.NoError(t, err)
+		assert.Len(t, derived, 62)
+		assert.False(t, strings.HasSuffix(derived, "-"))
+	})
+
+	t.Run("rejects names without letters or digits", func(t *testing.T) {
+		_, err := DeriveName("___")
+		assert.ErrorIs(t, err, ErrInvalidName)
+	})
+}
+
+func TestNameCollisionError(t *testing.T) {
+	err := &NameCollisionError{RequestedName: "Orders_API", DerivedName: "orders-api", ExistingName: "orders-api"}
+	assert.ErrorIs(t, err, ErrNameCollision)
+	assert.Contains(t, err.Error(), "Orders_API")
+	assert.Contains(t, err.Error(), "orders-api")
+}
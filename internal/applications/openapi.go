@@ -0,0 +1,157 @@
+package applications
+
+import (
+	"net/http"
+
+	"github.com/aykay76/ai-idp/internal/openapi"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// OpenAPI describes the application API registered by the application service
+func OpenAPI(version string) *openapi.Document {
+	doc := openapi.New("Application Service", version, ErrorResponse{})
+	tags := []string{"applications"}
+	notFound := []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}
+	conflict := []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError}
+
+	doc.Add("GET /api/v1/applications", openapi.Endpoint{
+		Summary: "List applications",
+		Tags:    tags,
+		Query: []openapi.Parameter{
+			openapi.Query("limit", "integer", "Page size"),
+			openapi.Query("offset", "integer", "Number of applications to skip"),
+			openapi.Query("cursor", "string", "Continue a listing after the next_cursor of the previous page"),
+			openapi.Query("team_name", "string", "Only applications owned by the team"),
+			openapi.Query("lifecycle", "string", "Only applications in the lifecycle"),
+			openapi.Query("status", "string", "Only applications with the status"),
+			openapi.Query("search", "string", "Case-insensitive match on name, display name and description"),
+			openapi.Query("include_terminated", "boolean", "Include deleted applications"),
+			openapi.Query("config.{key}", "string", "Only applications whose config has the top-level key with the value; * matches any value"),
+			openapi.Query("sort", "string", "Sort by name, display_name, created_at or updated_at; a leading - sorts descending (default -created_at). A cursor only continues the default order"),
+		},
+		Response: ListApplicationsResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	})
+	doc.Add("POST /api/v1/applications", openapi.Endpoint{
+		Summary:  "Create an application",
+		Tags:     tags,
+		Request:  CreateApplicationRequest{},
+		Response: Application{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusInternalServerError},
+	})
+	doc.Add("GET /api/v1/applications/by-team", openapi.Endpoint{
+		Summary:  "List a team's applications",
+		Tags:     tags,
+		Query:    []openapi.Parameter{openapi.Query("team_name", "string", "The owning team")},
+		Response: ApplicationsByTeamResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	})
+	doc.Add("GET /api/v1/applications/stats", openapi.Endpoint{
+		Summary:  "Count applications by lifecycle and status",
+		Tags:     tags,
+		Response: ApplicationStats{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	})
+	doc.Add("GET /api/v1/applications/{id}", openapi.Endpoint{
+		Summary:  "Get an application",
+		Tags:     tags,
+		Response: Application{},
+		Errors:   notFound,
+	})
+	doc.Add("PUT /api/v1/applications/{id}", openapi.Endpoint{
+		Summary:  "Update an application",
+		Tags:     tags,
+		Headers:  []openapi.Parameter{openapi.Header("If-Match", "ETag of the version being updated, or * for any version")},
+		Request:  UpdateApplicationRequest{},
+		Response: Application{},
+		Errors:   append(conflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired),
+	})
+	doc.Add("DELETE /api/v1/applications/{id}", openapi.Endpoint{
+		Summary: "Terminate an application",
+		Tags:    tags,
+		Errors:  notFound,
+	})
+	for _, action := range []string{"approve", "reject"} {
+		doc.Add("POST /api/v1/applications/{id}:"+action, openapi.Endpoint{
+			Summary:  "Record the caller's decision to " + action + " an application",
+			Tags:     tags,
+			Request:  ReviewApplicationRequest{},
+			Response: Application{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		})
+	}
+	doc.Add("GET /api/v1/applications/{id}/resources", openapi.Endpoint{
+		Summary:  "Get an application's resources",
+		Tags:     tags,
+		Response: ApplicationResources{},
+		Errors:   notFound,
+	})
+	doc.Add("PUT /api/v1/applications/{id}/resources", openapi.Endpoint{
+		Summary:  "Replace an application's resources",
+		Tags:     tags,
+		Request:  []types.ResourceSpec{},
+		Response: ApplicationResources{},
+		Errors:   conflict,
+	})
+	doc.Add("PUT /api/v1/applications/{id}/resources/{name}/status", openapi.Endpoint{
+		Summary:  "Report the status of a resource",
+		Tags:     tags,
+		Request:  UpdateResourceStatusRequest{},
+		Response: ApplicationResources{},
+		Errors:   notFound,
+	})
+	doc.Add("GET /api/v1/applications/{id}/env", openapi.Endpoint{
+		Summary:  "Get an application's environment",
+		Tags:     tags,
+		Response: ApplicationEnvironment{},
+		Errors:   notFound,
+	})
+	doc.Add("PUT /api/v1/applications/{id}/env", openapi.Endpoint{
+		Summary:  "Replace an application's environment",
+		Tags:     tags,
+		Request:  []EnvVar{},
+		Response: ApplicationEnvironment{},
+		Errors:   conflict,
+	})
+	doc.Add("PUT /api/v1/applications/{id}/dependencies", openapi.Endpoint{
+		Summary:  "Replace an application's dependencies",
+		Tags:     tags,
+		Request:  []types.DependencySpec{},
+		Response: []types.DependencySpec{},
+		Errors:   conflict,
+	})
+	doc.Add("GET /api/v1/applications/{id}/dependencies", openapi.Endpoint{
+		Summary: "Get the applications connected to an application through its dependencies",
+		Tags:    tags,
+		Query: []openapi.Parameter{
+			openapi.Query("direction", "string", "upstream for the applications it depends on, downstream (the default) for those depending on it"),
+		},
+		Response: DependencyGraph{},
+		Errors:   notFound,
+	})
+	doc.Add("GET /api/v1/applications/{id}/status", openapi.Endpoint{
+		Summary:  "Get an application's status and that of its dependencies",
+		Tags:     tags,
+		Response: ApplicationStatus{},
+		Errors:   notFound,
+	})
+	doc.Add("GET /api/v1/applications/{id}/history", openapi.Endpoint{
+		Summary:  "List an application's revisions",
+		Tags:     tags,
+		Response: ListRevisionsResponse{},
+		Errors:   notFound,
+	})
+	doc.Add("GET /api/v1/applications/{id}/history/diff", openapi.Endpoint{
+		Summary: "Compare two revisions of an application",
+		Tags:    tags,
+		Query: []openapi.Parameter{
+			openapi.Query("from", "integer", "The older revision"),
+			openapi.Query("to", "integer", "The newer revision"),
+		},
+		Response: RevisionDiff{},
+		Errors:   notFound,
+	})
+
+	return doc
+}
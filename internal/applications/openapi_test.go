@@ -0,0 +1,30 @@
+package applications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	doc := OpenAPI("")
+
+	for _, op := range []string{
+		"GET /api/v1/applications",
+		"POST /api/v1/applications",
+		"GET /api/v1/applications/{id}",
+		"PUT /api/v1/applications/{id}",
+		"DELETE /api/v1/applications/{id}",
+		"POST /api/v1/applications/{id}:approve",
+		"GET /api/v1/applications/{id}/history/diff",
+	} {
+		assert.Contains(t, doc.Operations(), op)
+	}
+
+	list := doc.Paths["/api/v1/applications"]["get"]
+	require.NotNil(t, list)
+	assert.Equal(t, "#/components/schemas/ListApplicationsResponse", list.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/PaginationMeta", doc.Components.Schemas["ListApplicationsResponse"].Properties["pagination"].Ref)
+	assert.Equal(t, "#/components/schemas/ErrorResponse", list.Responses["500"].Content["application/json"].Schema.Ref)
+}
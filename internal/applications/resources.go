@@ -0,0 +1,263 @@
+package applications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Resource errors
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrInvalidResources = errors.New("invalid resources")
+)
+
+// Resource provisioning states
+const (
+	ResourceStatusPending = "pending"
+	ResourceStatusReady   = "ready"
+)
+
+// ApplicationResource pairs a declared resource with its provisioning status
+type ApplicationResource struct {
+	Spec   types.ResourceSpec   `json:"spec"`
+	Status types.ResourceStatus `json:"status"`
+}
+
+// ApplicationResources is the set of resources declared by an application
+type ApplicationResources struct {
+	ApplicationID uuid.UUID             `json:"application_id"`
+	Resources     []ApplicationResource `json:"resources"`
+	Ready         bool                  `json:"ready"`
+}
+
+// UpdateResourceStatusRequest represents a provisioner's status report for a resource
+type UpdateResourceStatusRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Ready   bool   `json:"ready"`
+}
+
+// SetResources replaces the resources an application requires. Statuses of resources
+// that remain declared are preserved; newly declared resources start out pending.
+func (s *Service) SetResources(ctx context.Context, tenantID, id uuid.UUID, specs []types.ResourceSpec) (*ApplicationResources, error) {
+	if err := validateResourceSpecs(specs); err != nil {
+		return nil, err
+	}
+
+	var result *ApplicationResources
+	err := s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		current, retirementDate, err := lockResources(ctx, tx, tenantID, id)
+		if err != nil {
+			return err
+		}
+
+		app := Application{RetirementDate: retirementDate}
+		if app.IsRetired(s.now()) {
+			return ErrApplicationRetired
+		}
+
+		resources := mergeResources(current, specs, s.now().UTC())
+		if err := saveResources(ctx, tx, tenantID, id, resources); err != nil {
+			return err
+		}
+
+		result = newApplicationResources(id, resources)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetResources returns the resources declared by an application with their status
+func (s *Service) GetResources(ctx context.Context, tenantID, id uuid.UUID) (*ApplicationResources, error) {
+	query := `
+		SELECT resources FROM resource_management.applications
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var resourcesJSON []byte
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.reader().QueryRow(ctx, query, tenantID, id).Scan(&resourcesJSON)
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, fmt.Errorf("failed to get resources: %w", err)
+	}
+
+	var resources []ApplicationResource
+	if err := json.Unmarshal(resourcesJSON, &resources); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal resources: %w", err)
+	}
+
+	return newApplicationResources(id, resources), nil
+}
+
+// UpdateResourceStatus records the provisioning status of a single declared resource
+func (s *Service) UpdateResourceStatus(ctx context.Context, tenantID, id uuid.UUID, name string, req *UpdateResourceStatusRequest) (*ApplicationResources, error) {
+	var result *ApplicationResources
+	err := s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		resources, _, err := lockResources(ctx, tx, tenantID, id)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for i := range resources {
+			if resources[i].Spec.Name != name {
+				continue
+			}
+			found = true
+
+			status := req.Status
+			if status == "" {
+				status = ResourceStatusPending
+				if req.Ready {
+					status = ResourceStatusReady
+				}
+			}
+
+			resources[i].Status.Status = status
+			resources[i].Status.Message = req.Message
+			resources[i].Status.Ready = req.Ready
+			resources[i].Status.LastUpdate = s.now().UTC()
+		}
+		if !found {
+			return ErrResourceNotFound
+		}
+
+		if err := saveResources(ctx, tx, tenantID, id, resources); err != nil {
+			return err
+		}
+
+		result = newApplicationResources(id, resources)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// lockResources reads an application's resources, locking the row for update
+func lockResources(ctx context.Context, tx *database.Transaction, tenantID, id uuid.UUID) ([]ApplicationResource, *time.Time, error) {
+	query := `
+		SELECT resources, retirement_date FROM resource_management.applications
+		WHERE tenant_id = $1 AND id = $2
+		FOR UPDATE
+	`
+
+	var resourcesJSON []byte
+	var retirementDate *time.Time
+	if err := tx.QueryRow(ctx, query, tenantID, id).Scan(&resourcesJSON, &retirementDate); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrApplicationNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to lock application: %w", err)
+	}
+
+	var resources []ApplicationResource
+	if err := json.Unmarshal(resourcesJSON, &resources); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal resources: %w", err)
+	}
+
+	return resources, retirementDate, nil
+}
+
+// saveResources writes an application's resources
+func saveResources(ctx context.Context, tx *database.Transaction, tenantID, id uuid.UUID, resources []ApplicationResource) error {
+	resourcesJSON, err := json.Marshal(resources)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resources: %w", err)
+	}
+
+	query := `
+		UPDATE resource_management.applications
+		SET resources = $3, updated_at = $4, version = version + 1
+		WHERE tenant_id = $1 AND id = $2
+	`
+	if _, err := tx.Exec(ctx, query, tenantID, id, resourcesJSON, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to update resources: %w", err)
+	}
+
+	return nil
+}
+
+// validateResourceSpecs checks that every resource has a type and a unique name
+func validateResourceSpecs(specs []types.ResourceSpec) error {
+	seen := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		if spec.Name == "" {
+			return fmt.Errorf("%w: resource %d: name is required", ErrInvalidResources, i)
+		}
+		if spec.Type == "" {
+			return fmt.Errorf("%w: resource %q: type is required", ErrInvalidResources, spec.Name)
+		}
+		if seen[spec.Name] {
+			return fmt.Errorf("%w: duplicate resource name %q", ErrInvalidResources, spec.Name)
+		}
+		seen[spec.Name] = true
+	}
+	return nil
+}
+
+// mergeResources builds the new resource list from the declared specs, carrying over
+// the status of resources whose name and type are unchanged
+func mergeResources(current []ApplicationResource, specs []types.ResourceSpec, now time.Time) []ApplicationResource {
+	existing := make(map[string]ApplicationResource, len(current))
+	for _, r := range current {
+		existing[r.Spec.Name] = r
+	}
+
+	merged := make([]ApplicationResource, 0, len(specs))
+	for _, spec := range specs {
+		status := types.ResourceStatus{
+			Name:       spec.Name,
+			Type:       spec.Type,
+			Status:     ResourceStatusPending,
+			LastUpdate: now,
+		}
+		if prev, ok := existing[spec.Name]; ok && prev.Spec.Type == spec.Type {
+			status = prev.Status
+		}
+
+		merged = append(merged, ApplicationResource{Spec: spec, Status: status})
+	}
+
+	return merged
+}
+
+// newApplicationResources wraps resources with the derived readiness
+func newApplicationResources(id uuid.UUID, resources []ApplicationResource) *ApplicationResources {
+	if resources == nil {
+		resources = []ApplicationResource{}
+	}
+	return &ApplicationResources{
+		ApplicationID: id,
+		Resources:     resources,
+		Ready:         resourcesReady(resources),
+	}
+}
+
+// resourcesReady reports whether every declared resource is ready
+func resourcesReady(resources []ApplicationResource) bool {
+	for _, r := range resources {
+		if !r.Status.Ready {
+			return false
+		}
+	}
+	return true
+}
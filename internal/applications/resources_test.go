@@ -0,0 +1,52 @@
+package applications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResourceSpecs(t *testing.T) {
+	assert.NoError(t, validateResourceSpecs(nil))
+	assert.NoError(t, validateResourceSpecs([]types.ResourceSpec{{Type: "database", Name: "db"}}))
+	assert.ErrorIs(t, validateResourceSpecs([]types.ResourceSpec{{Type: "database"}}), ErrInvalidResources)
+	assert.ErrorIs(t, validateResourceSpecs([]types.ResourceSpec{{Name: "db"}}), ErrInvalidResources)
+	assert.ErrorIs(t, validateResourceSpecs([]types.ResourceSpec{
+		{Type: "database", Name: "db"},
+		{Type: "cache", Name: "db"},
+	}), ErrInvalidResources)
+}
+
+func TestMergeResources(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	current := []ApplicationResource{
+		{
+			Spec:   types.ResourceSpec{Type: "database", Name: "db"},
+			Status: types.ResourceStatus{Name: "db", Type: "database", Status: ResourceStatusReady, Ready: true},
+		},
+		{
+			Spec:   types.ResourceSpec{Type: "cache", Name: "cache"},
+			Status: types.ResourceStatus{Name: "cache", Type: "cache", Status: ResourceStatusReady, Ready: true},
+		},
+	}
+
+	merged := mergeResources(current, []types.ResourceSpec{
+		{Type: "database", Name: "db", Config: map[string]string{"size": "large"}},
+		{Type: "queue", Name: "cache"},
+		{Type: "bucket", Name: "assets"},
+	}, now)
+
+	require.Len(t, merged, 3)
+	assert.True(t, merged[0].Status.Ready, "unchanged resource keeps its status")
+	assert.Equal(t, "large", merged[0].Spec.Config["size"])
+	assert.False(t, merged[1].Status.Ready, "resource whose type changed starts over")
+	assert.Equal(t, ResourceStatusPending, merged[2].Status.Status)
+	assert.Equal(t, now, merged[2].Status.LastUpdate)
+
+	assert.False(t, resourcesReady(merged))
+	assert.True(t, resourcesReady(merged[:1]))
+	assert.True(t, resourcesReady(nil))
+}
@@ -0,0 +1,84 @@
+package applications
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultRetirementGCInterval is how often RunRetirementGC purges retired applications
+// when given no positive interval
+const DefaultRetirementGCInterval = time.Hour
+
+// IsRetired reports whether the application's retirement date has passed
+func (a *Application) IsRetired(now time.Time) bool {
+	return a.RetirementDate != nil && !now.Before(*a.RetirementDate)
+}
+
+// RetirementWarning returns a human readable warning for applications scheduled for
+// retirement, or an empty string when no retirement is scheduled
+func (a *Application) RetirementWarning(now time.Time) string {
+	if a.RetirementDate == nil {
+		return ""
+	}
+
+	date := a.RetirementDate.UTC().Format(time.RFC3339)
+	if a.IsRetired(now) {
+		return fmt.Sprintf("Application %s was retired on %s and will be removed", a.Name, date)
+	}
+	return fmt.Sprintf("Application %s is deprecated and will be retired on %s", a.Name, date)
+}
+
+// onlyReschedulesRetirement reports whether the update touches nothing but the
+// retirement schedule, which is the only change allowed after retirement
+func (r *UpdateApplicationRequest) onlyReschedulesRetirement() bool {
+	return r.RetirementDate != nil &&
+		r.DisplayName == nil &&
+		r.Description == nil &&
+		r.TeamName == nil &&
+		r.OwnerEmail == nil &&
+		r.Lifecycle == nil &&
+		r.Config == nil &&
+		r.Annotations == nil
+}
+
+// PurgeRetiredApplications deletes applications whose retirement grace period has
+// elapsed and returns the number of applications removed
+func (s *Service) PurgeRetiredApplications(ctx context.Context) (int64, error) {
+	cutoff := s.now().UTC().Add(-s.retirementGracePeriod)
+
+	query := `
+		DELETE FROM resource_management.applications
+		WHERE retirement_date IS NOT NULL AND retirement_date < $1
+	`
+
+	result, err := s.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge retired applications: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// RunRetirementGC purges retired applications on the given interval until the
+// context is cancelled. A non-positive interval uses DefaultRetirementGCInterval.
+func (s *Service) RunRetirementGC(ctx context.Context, interval time.Duration, onResult func(purged int64, err error)) {
+	if interval <= 0 {
+		interval = DefaultRetirementGCInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeRetiredApplications(ctx)
+			if onResult != nil {
+				onResult(purged, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package applications
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplication_IsRetired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.False(t, (&Application{}).IsRetired(now))
+	assert.False(t, (&Application{RetirementDate: &future}).IsRetired(now))
+	assert.True(t, (&Application{RetirementDate: &past}).IsRetired(now))
+	assert.True(t, (&Application{RetirementDate: &now}).IsRetired(now))
+}
+
+func TestUpdateApplicationRequest_OnlyReschedulesRetirement(t *testing.T) {
+	date := time.Now()
+	name := "new-name"
+	config := map[string]interface{}{"replicas": 2}
+
+	assert.True(t, (&UpdateApplicationRequest{RetirementDate: &date}).onlyReschedulesRetirement())
+	assert.False(t, (&UpdateApplicationRequest{}).onlyReschedulesRetirement())
+	assert.False(t, (&UpdateApplicationRequest{RetirementDate: &date, DisplayName: &name}).onlyReschedulesRetirement())
+	assert.False(t, (&UpdateApplicationRequest{Config: &config}).onlyReschedulesRetirement())
+}
+
+func TestService_RunRetirementGC_NonPositiveInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		assert.NotPanics(t, func() {
+			(&Service{}).RunRetirementGC(ctx, interval, nil)
+		}, interval.String())
+	}
+}
@@ -3,22 +3,254 @@ package applications
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/audit"
 	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/events"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/notify"
+	"github.com/aykay76/ai-idp/internal/types"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// Common errors
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrApplicationRetired  = errors.New("application is retired")
+	// ErrVersionMismatch is returned when an update names a version the application
+	// has moved on from, because someone else changed it since the caller read it
+	ErrVersionMismatch = errors.New("application has been modified since it was read")
+)
+
+// StatusTerminated marks a deleted application. Terminated applications are left out
+// of listings but can still be read, so their audit history remains reachable.
+const StatusTerminated = "terminated"
+
+// DefaultRetirementGracePeriod is how long a retired application remains readable
+// before it is purged
+const DefaultRetirementGracePeriod = 30 * 24 * time.Hour
+
 // Service provides clean application management operations using native Go HTTP
 type Service struct {
-	db *database.Pool
+	db                    *database.Pool
+	failover              *database.Failover
+	replicas              *database.ReplicaPool
+	readRetry             database.RetryPolicy
+	namePolicy            naming.Policy
+	retirementGracePeriod time.Duration
+	approvals             bool
+	approvalNotifier      ApprovalNotifier
+	audit                 audit.Recorder
+	events                events.Publisher
+	notifier              notify.Notifier
+	now                   func() time.Time
+}
+
+// Compile-time check that Service implements ApplicationService
+var _ ApplicationService = (*Service)(nil)
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithRetirementGracePeriod sets how long retired applications are kept before purging
+func WithRetirementGracePeriod(d time.Duration) Option {
+	return func(s *Service) {
+		s.retirementGracePeriod = d
+	}
+}
+
+// WithAudit records an audit event for every create, update, delete and approval
+// review
+func WithAudit(r audit.Recorder) Option {
+	return func(s *Service) {
+		s.audit = r
+	}
+}
+
+// WithEvents publishes an event for every successful create, update and delete
+func WithEvents(p events.Publisher) Option {
+	return func(s *Service) {
+		s.events = p
+	}
+}
+
+// WithNotifier sends a notification whenever an application changes lifecycle
+func WithNotifier(n notify.Notifier) Option {
+	return func(s *Service) {
+		s.notifier = n
+	}
+}
+
+// WithNamePolicy sets how mixed-case application names are handled on create
+func WithNamePolicy(p naming.Policy) Option {
+	return func(s *Service) {
+		s.namePolicy = p
+	}
+}
+
+// WithFailover serves reads from the read replica while the primary is unavailable
+func WithFailover(f *database.Failover) Option {
+	return func(s *Service) {
+		s.failover = f
+	}
+}
+
+// WithReplicas serves list queries from read replicas. Other reads stay on the
+// primary so that they observe the service's own writes.
+func WithReplicas(r *database.ReplicaPool) Option {
+	return func(s *Service) {
+		s.replicas = r
+	}
+}
+
+// WithReadRetry retries read-only queries that fail with a transient error. Writes
+// are never retried.
+func WithReadRetry(p database.RetryPolicy) Option {
+	return func(s *Service) {
+		s.readRetry = p
+	}
 }
 
 // NewService creates a new clean application service
-func NewService(db *database.Pool) *Service {
-	return &Service{db: db}
+func NewService(db *database.Pool, opts ...Option) *Service {
+	s := &Service{
+		db:                    db,
+		namePolicy:            naming.PolicyPreserve,
+		retirementGracePeriod: DefaultRetirementGracePeriod,
+		now:                   time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// reader returns the pool queries that only read should use
+func (s *Service) reader() *database.Pool {
+	if s.failover != nil {
+		return s.failover.Reader()
+	}
+	return s.db
+}
+
+// listReader returns the pool list queries should use: a read replica when replicas
+// are configured, unless the service is in read-only mode
+func (s *Service) listReader() *database.Pool {
+	if s.replicas != nil && !s.failover.ReadOnly() {
+		return s.replicas.Reader()
+	}
+	return s.reader()
+}
+
+// recordAudit records the outcome of a write to an application and, when it
+// succeeded, publishes it as an event. The recorder reports events it fails to store
+// and publishing is best effort, so neither ever fails the write itself.
+func (s *Service) recordAudit(ctx context.Context, action string, tenantID uuid.UUID, id uuid.UUID, name, actor string, err error) {
+	s.recordAuditDetails(ctx, action, tenantID, id, name, actor, nil, err)
+}
+
+// recordAuditDetails is recordAudit for actions whose successful events carry details
+func (s *Service) recordAuditDetails(ctx context.Context, action string, tenantID uuid.UUID, id uuid.UUID, name, actor string, details map[string]string, err error) {
+	if s.audit == nil && s.events == nil {
+		return
+	}
+
+	resource := types.ResourceReference{Kind: audit.KindApplication, Name: name, Namespace: tenantID.String()}
+	if id != uuid.Nil {
+		resource.UID = id.String()
+	}
+	event := audit.NewEvent(ctx, action, resource, err)
+	if actor != audit.SystemActor.ID {
+		event.Spec.Actor = types.Actor{Type: types.ActorTypeUser, ID: actor}
+	}
+	if err == nil && details != nil {
+		event.Spec.Details = details
+	}
+	if s.audit != nil {
+		_ = s.audit.Record(ctx, event)
+	}
+	if s.events != nil && err == nil {
+		s.events.Publish(ctx, events.FromAudit(event))
+	}
+}
+
+// notifyLifecycleChange notifies that app moved to its current lifecycle from
+// previous. Sending is best effort and never fails the change.
+func (s *Service) notifyLifecycleChange(ctx context.Context, app *Application, previous string) {
+	if s.notifier == nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, notify.New(notify.TypeApplicationLifecycleChanged, app.TenantID, notify.ApplicationLifecycleChange{
+		ApplicationID: app.ID.String(),
+		Name:          app.Name,
+		TeamName:      app.TeamName,
+		From:          previous,
+		To:            app.Lifecycle,
+	}))
+}
+
+// actorOrSystem returns actor, or the system actor when the caller is unknown
+func actorOrSystem(actor string) string {
+	if actor == "" {
+		return audit.SystemActor.ID
+	}
+	return actor
+}
+
+// applicationColumns is the column list shared by application queries; keep it in
+// sync with scanApplication
+const applicationColumns = `id, tenant_id, name, display_name, description, team_name,
+		       owner_email, lifecycle, status, observability_config, annotations,
+		       retirement_date, created_at, updated_at, created_by, updated_by,
+		       reviewed_by, reviewed_at, review_comment, version`
+
+// scanApplication scans a row selected with applicationColumns
+func scanApplication(row pgx.Row) (*Application, error) {
+	var app Application
+	var configJSON, annotationsJSON []byte
+
+	err := row.Scan(
+		&app.ID, &app.TenantID, &app.Name, &app.DisplayName, &app.Description,
+		&app.TeamName, &app.OwnerEmail, &app.Lifecycle, &app.Status,
+		&configJSON, &annotationsJSON, &app.RetirementDate, &app.CreatedAt, &app.UpdatedAt,
+		&app.CreatedBy, &app.UpdatedBy, &app.ReviewedBy, &app.ReviewedAt, &app.ReviewComment,
+		&app.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalJSONB(configJSON, &app.Config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	if err := unmarshalJSONB(annotationsJSON, &app.Annotations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal annotations: %w", err)
+	}
+
+	// Rows that predate the config column, or where it was nulled, read as no config
+	if app.Config == nil {
+		app.Config = map[string]interface{}{}
+	}
+
+	return &app, nil
+}
+
+// unmarshalJSONB decodes a JSONB column, leaving v untouched when the column is NULL
+// or empty
+func unmarshalJSONB(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
 }
 
 // Application represents an application in the simplified model
@@ -33,268 +265,595 @@ type Application struct {
 	Lifecycle   string                 `json:"lifecycle" db:"lifecycle"` // development, testing, staging, production
 	Status      string                 `json:"status" db:"status"`       // pending, running, failed, stopped
 	Config      map[string]interface{} `json:"config" db:"config"`
-	CreatedAt   time.Time              `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at" db:"updated_at"`
-	CreatedBy   string                 `json:"created_by" db:"created_by"`
-	UpdatedBy   *string                `json:"updated_by,omitempty" db:"updated_by"`
+	Annotations map[string]string      `json:"annotations,omitempty" db:"annotations"`
+	// RetirementDate marks a deprecated application for removal; it stays readable
+	// until the grace period after this date has passed
+	RetirementDate *time.Time `json:"retirement_date,omitempty" db:"retirement_date"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	CreatedBy      string     `json:"created_by" db:"created_by"`
+	UpdatedBy      *string    `json:"updated_by,omitempty" db:"updated_by"`
+	// ReviewedBy, ReviewedAt and ReviewComment record the approval decision for
+	// applications that went through the approval workflow
+	ReviewedBy    *string    `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewComment *string    `json:"review_comment,omitempty" db:"review_comment"`
+	// Version is bumped on every change, for conditional updates
+	Version int `json:"version" db:"version"`
 }
 
+// SortFields are the columns applications can be listed in order of
+var SortFields = []string{"name", "display_name", "created_at", "updated_at"}
+
+// ConfigFilterAny is the ConfigFilters value that matches any value of the key
+const ConfigFilterAny = "*"
+
 // ListApplicationsRequest represents a request to list applications
 type ListApplicationsRequest struct {
 	TenantID  uuid.UUID
 	TeamName  string
 	Lifecycle string
 	Status    string
-	Limit     int
-	Offset    int
+	CreatedBy string
+	UpdatedBy string
+	// Search matches applications whose name, display name or description contains
+	// the term, ignoring case
+	Search string
+	// IncludeTerminated lists deleted applications too
+	IncludeTerminated bool
+	// ConfigFilters matches applications whose config has each top-level key with
+	// the value, compared as text, or has the key at all for ConfigFilterAny
+	ConfigFilters map[string]string
+	// Sort orders the applications by one of SortFields; the zero value is
+	// database.DefaultSort, the only order a cursor can continue
+	Sort database.Sort
+	// After continues a keyset-paginated listing after the cursor; Offset is ignored
+	After  *database.Cursor
+	Limit  int
+	Offset int
+}
+
+// ApplicationStats counts a tenant's applications by lifecycle and by status
+type ApplicationStats struct {
+	Total       int            `json:"total"`
+	ByLifecycle map[string]int `json:"by_lifecycle"`
+	ByStatus    map[string]int `json:"by_status"`
 }
 
 // CreateApplicationRequest represents a request to create a new application
 type CreateApplicationRequest struct {
-	Name        string                 `json:"name" validate:"required,min=1,max=63"`
-	DisplayName string                 `json:"display_name" validate:"required,min=1,max=255"`
-	Description *string                `json:"description,omitempty"`
-	TeamName    string                 `json:"team_name" validate:"required"`
-	OwnerEmail  string                 `json:"owner_email" validate:"required,email"`
-	Lifecycle   string                 `json:"lifecycle" validate:"required,oneof=development staging production deprecated"`
-	Config      map[string]interface{} `json:"config,omitempty"`
+	Name           string                 `json:"name" validate:"required,min=1,max=63"`
+	DisplayName    string                 `json:"display_name" validate:"required,min=1,max=255"`
+	Description    *string                `json:"description,omitempty"`
+	TeamName       string                 `json:"team_name" validate:"required"`
+	OwnerEmail     string                 `json:"owner_email" validate:"required,email"`
+	Lifecycle      string                 `json:"lifecycle" validate:"required,oneof=development staging production deprecated"`
+	Config         map[string]interface{} `json:"config,omitempty"`
+	Annotations    map[string]string      `json:"annotations,omitempty"`
+	RetirementDate *time.Time             `json:"retirement_date,omitempty"`
 }
 
-// UpdateApplicationRequest represents a request to update an application
+// UpdateApplicationRequest represents a request to update an application. Config is
+// merged into the existing config; set a key to null to remove it.
 type UpdateApplicationRequest struct {
-	DisplayName *string                 `json:"display_name,omitempty"`
-	Description *string                 `json:"description,omitempty"`
-	TeamName    *string                 `json:"team_name,omitempty"`
-	OwnerEmail  *string                 `json:"owner_email,omitempty"`
-	Lifecycle   *string                 `json:"lifecycle,omitempty"`
-	Config      *map[string]interface{} `json:"config,omitempty"`
+	DisplayName    *string                 `json:"display_name,omitempty" validate:"omitempty,min=1,max=255"`
+	Description    *string                 `json:"description,omitempty"`
+	TeamName       *string                 `json:"team_name,omitempty" validate:"omitempty,min=1"`
+	OwnerEmail     *string                 `json:"owner_email,omitempty" validate:"omitempty,email"`
+	Lifecycle      *string                 `json:"lifecycle,omitempty" validate:"omitempty,oneof=development staging production deprecated"`
+	Config         *map[string]interface{} `json:"config,omitempty"`
+	Annotations    *map[string]string      `json:"annotations,omitempty"`
+	RetirementDate *time.Time              `json:"retirement_date,omitempty"`
+	// IfMatch applies the update only if the application is still at this version; 0
+	// updates whatever the version. Handlers set it from the If-Match header.
+	IfMatch int `json:"-"`
 }
 
-// CreateApplication creates a new application
-func (s *Service) CreateApplication(ctx context.Context, tenantID uuid.UUID, req *CreateApplicationRequest) (*Application, error) {
+// CreateApplication creates a new application on behalf of createdBy, or the system
+// when it is empty
+func (s *Service) CreateApplication(ctx context.Context, tenantID uuid.UUID, req *CreateApplicationRequest, createdBy string) (created *Application, err error) {
+	createdBy = actorOrSystem(createdBy)
+	defer func() {
+		if created != nil {
+			s.recordAudit(ctx, audit.ActionCreate, tenantID, created.ID, created.Name, createdBy, err)
+			return
+		}
+		s.recordAudit(ctx, audit.ActionCreate, tenantID, uuid.Nil, req.Name, createdBy, err)
+	}()
+
+	if err := ValidateAnnotations(req.Annotations); err != nil {
+		return nil, err
+	}
+
+	name, err := s.namePolicy.Apply(req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidName, err)
+	}
+
+	// Names that sanitize to the same identifier would collide downstream
+	derivedName, err := DeriveName(name)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.findDerivedNameOwner(ctx, tenantID, derivedName)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return nil, &NameCollisionError{RequestedName: name, DerivedName: derivedName, ExistingName: existing}
+	}
+
 	app := &Application{
-		ID:          uuid.New(),
-		TenantID:    tenantID,
-		Name:        req.Name,
-		DisplayName: req.DisplayName,
-		Description: req.Description,
-		TeamName:    req.TeamName,
-		OwnerEmail:  req.OwnerEmail,
-		Lifecycle:   req.Lifecycle,
-		Status:      "pending",
-		Config:      req.Config,
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
-		CreatedBy:   "system", // TODO: Get from context when auth is implemented
+		ID:             uuid.New(),
+		TenantID:       tenantID,
+		Name:           name,
+		DisplayName:    req.DisplayName,
+		Description:    req.Description,
+		TeamName:       req.TeamName,
+		OwnerEmail:     req.OwnerEmail,
+		Lifecycle:      req.Lifecycle,
+		Status:         StatusPending,
+		Config:         req.Config,
+		Annotations:    req.Annotations,
+		RetirementDate: req.RetirementDate,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+		CreatedBy:      createdBy,
+		Version:        1,
+	}
+
+	// Scheduling retirement implies the application is deprecated
+	if app.RetirementDate != nil {
+		app.Lifecycle = "deprecated"
+	}
+
+	needsApproval, approvers, err := s.requiresApproval(ctx, tenantID, app.TeamName, app.Lifecycle)
+	if err != nil {
+		return nil, err
+	}
+	if needsApproval {
+		app.Status = StatusPendingApproval
 	}
 
 	if app.Config == nil {
 		app.Config = make(map[string]interface{})
 	}
+	if app.Annotations == nil {
+		app.Annotations = make(map[string]string)
+	}
 
 	configJSON, err := json.Marshal(app.Config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	annotationsJSON, err := json.Marshal(app.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal annotations: %w", err)
+	}
+
 	query := `
 		INSERT INTO resource_management.applications (
 			id, tenant_id, name, display_name, description, team_name, 
-			owner_email, lifecycle, status, observability_config, created_at, updated_at, created_by
+			owner_email, lifecycle, status, observability_config, annotations,
+			retirement_date, created_at, updated_at, created_by, derived_name
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
 		)
 	`
 
-	_, err = s.db.Exec(ctx, query,
-		app.ID, app.TenantID, app.Name, app.DisplayName, app.Description,
-		app.TeamName, app.OwnerEmail, app.Lifecycle, app.Status,
-		configJSON, app.CreatedAt, app.UpdatedAt, app.CreatedBy,
-	)
+	// The quota check locks the tenant, so concurrent creates cannot all pass it
+	err = s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		if err := database.CheckQuota(ctx, tx, app.TenantID, database.LimitApplications, database.CountApplicationsQuery); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, query,
+			app.ID, app.TenantID, app.Name, app.DisplayName, app.Description,
+			app.TeamName, app.OwnerEmail, app.Lifecycle, app.Status,
+			configJSON, annotationsJSON, app.RetirementDate, app.CreatedAt, app.UpdatedAt, app.CreatedBy,
+			derivedName,
+		)
+		if err != nil {
+			return err
+		}
+
+		// The first revision records the application as created
+		return recordRevision(ctx, tx, app, app.CreatedBy)
+	})
 	if err != nil {
+		if errors.Is(err, database.ErrQuotaExceeded) {
+			return nil, err
+		}
+		// A concurrent create may have claimed the derived name after the check above
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "idx_applications_derived_name" {
+			existing, _ := s.findDerivedNameOwner(ctx, tenantID, derivedName)
+			return nil, &NameCollisionError{RequestedName: name, DerivedName: derivedName, ExistingName: existing}
+		}
 		return nil, fmt.Errorf("failed to create application: %w", err)
 	}
 
+	if needsApproval {
+		s.recordAuditDetails(ctx, audit.ActionApprovalRequested, tenantID, app.ID, app.Name, app.CreatedBy, map[string]string{
+			"status":    app.Status,
+			"approvers": strings.Join(approvers, ","),
+		}, nil)
+		if s.approvalNotifier != nil {
+			s.approvalNotifier.ApprovalRequested(ctx, app, approvers)
+		}
+	}
+
 	return app, nil
 }
 
 // ListApplications lists applications for a tenant
 func (s *Service) ListApplications(ctx context.Context, req *ListApplicationsRequest) ([]Application, int, error) {
-	whereClause := "WHERE tenant_id = $1"
-	args := []interface{}{req.TenantID}
-	argCount := 1
-
-	if req.TeamName != "" {
-		argCount++
-		whereClause += fmt.Sprintf(" AND team_name = $%d", argCount)
-		args = append(args, req.TeamName)
+	sort := req.Sort
+	if sort.Column == "" {
+		sort = database.DefaultSort
 	}
-
-	if req.Lifecycle != "" {
-		argCount++
-		whereClause += fmt.Sprintf(" AND lifecycle = $%d", argCount)
-		args = append(args, req.Lifecycle)
+	if !slices.Contains(SortFields, sort.Column) {
+		return nil, 0, fmt.Errorf("%w %q", database.ErrInvalidSort, sort)
 	}
-
-	if req.Status != "" {
-		argCount++
-		whereClause += fmt.Sprintf(" AND status = $%d", argCount)
-		args = append(args, req.Status)
+	if req.After != nil && sort != database.DefaultSort {
+		return nil, 0, fmt.Errorf("%w: a cursor can only continue a listing in the default order", database.ErrInvalidSort)
 	}
 
 	// Get total count
-	countQuery := "SELECT COUNT(*) FROM resource_management.applications " + whereClause
+	countQuery, countArgs := req.filter(database.NewQueryBuilder("SELECT COUNT(*) FROM resource_management.applications")).Build()
 	var total int
-	err := s.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.listReader().QueryRow(ctx, countQuery, countArgs...).Scan(&total)
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count applications: %w", err)
 	}
 
-	// Get applications with pagination
-	query := `
-		SELECT id, tenant_id, name, display_name, description, team_name, 
-		       owner_email, lifecycle, status, observability_config, created_at, updated_at, 
-		       created_by, updated_by
-		FROM resource_management.applications 
-	` + whereClause + `
-		ORDER BY created_at DESC 
-		LIMIT $` + fmt.Sprintf("%d", argCount+1) + ` OFFSET $` + fmt.Sprintf("%d", argCount+2)
+	// Get applications with pagination, by keyset after a cursor and otherwise by offset
+	qb := req.filter(database.NewQueryBuilder(`
+		SELECT ` + applicationColumns + `
+		FROM resource_management.applications
+	`))
+	qb.AddAfterCursor(req.After)
+	qb.AddSort(sort)
+	qb.AddLimit(req.Limit)
+	if req.After == nil {
+		qb.AddOffset(req.Offset)
+	}
+	query, args := qb.Build()
 
-	args = append(args, req.Limit, req.Offset)
+	var applications []Application
+	err = s.readRetry.Do(ctx, func(ctx context.Context) error {
+		applications = nil
 
-	rows, err := s.db.Query(ctx, query, args...)
+		rows, err := s.listReader().Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query applications: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			app, err := scanApplication(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan application row: %w", err)
+			}
+
+			applications = append(applications, *app)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating application rows: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query applications: %w", err)
+		return nil, 0, err
+	}
+
+	return applications, total, nil
+}
+
+// filter adds the request's filter conditions to qb
+func (req *ListApplicationsRequest) filter(qb *database.QueryBuilder) *database.QueryBuilder {
+	qb.AddEquals("tenant_id", req.TenantID)
+	qb.AddOptionalEquals("team_name", req.TeamName)
+	qb.AddOptionalEquals("lifecycle", req.Lifecycle)
+	qb.AddOptionalEquals("status", req.Status)
+	qb.AddOptionalEquals("created_by", req.CreatedBy)
+	qb.AddOptionalEquals("updated_by", req.UpdatedBy)
+	qb.AddSearch(req.Search, "name", "display_name", "description")
+	// Sorted so the same filters build the same query
+	for _, key := range slices.Sorted(maps.Keys(req.ConfigFilters)) {
+		if value := req.ConfigFilters[key]; value == ConfigFilterAny {
+			qb.AddJSONHasKey("observability_config", key)
+		} else {
+			qb.AddJSONEquals("observability_config", key, value)
+		}
+	}
+	if !req.IncludeTerminated && req.Status != StatusTerminated {
+		qb.AddWhere("status", "<>", StatusTerminated)
 	}
-	defer rows.Close()
+	return qb
+}
+
+// GetApplicationsByTeam lists every live application owned by a team, ordered by name
+func (s *Service) GetApplicationsByTeam(ctx context.Context, tenantID uuid.UUID, teamName string) ([]Application, error) {
+	query := `
+		SELECT ` + applicationColumns + `
+		FROM resource_management.applications 
+		WHERE tenant_id = $1 AND team_name = $2 AND status <> $3
+		ORDER BY name
+	`
 
 	var applications []Application
-	for rows.Next() {
-		var app Application
-		var configJSON []byte
-
-		err := rows.Scan(
-			&app.ID, &app.TenantID, &app.Name, &app.DisplayName, &app.Description,
-			&app.TeamName, &app.OwnerEmail, &app.Lifecycle, &app.Status,
-			&configJSON, &app.CreatedAt, &app.UpdatedAt, &app.CreatedBy, &app.UpdatedBy,
-		)
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		applications = nil
+
+		rows, err := s.reader().Query(ctx, query, tenantID, teamName, StatusTerminated)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan application row: %w", err)
+			return fmt.Errorf("failed to query team applications: %w", err)
 		}
+		defer rows.Close()
+
+		for rows.Next() {
+			app, err := scanApplication(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan application row: %w", err)
+			}
 
-		if err := json.Unmarshal(configJSON, &app.Config); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal config: %w", err)
+			applications = append(applications, *app)
 		}
 
-		applications = append(applications, app)
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating application rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating application rows: %w", err)
+	return applications, nil
+}
+
+// GetApplicationStats counts a tenant's applications by lifecycle and by status
+func (s *Service) GetApplicationStats(ctx context.Context, tenantID uuid.UUID) (*ApplicationStats, error) {
+	query := `
+		SELECT lifecycle, status, COUNT(*)
+		FROM resource_management.applications 
+		WHERE tenant_id = $1
+		GROUP BY lifecycle, status
+	`
+
+	var stats *ApplicationStats
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		stats = &ApplicationStats{ByLifecycle: map[string]int{}, ByStatus: map[string]int{}}
+
+		rows, err := s.reader().Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("failed to query application stats: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var lifecycle, status string
+			var count int
+			if err := rows.Scan(&lifecycle, &status, &count); err != nil {
+				return fmt.Errorf("failed to scan application stats row: %w", err)
+			}
+
+			stats.Total += count
+			stats.ByLifecycle[lifecycle] += count
+			stats.ByStatus[status] += count
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating application stats rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return applications, total, nil
+	return stats, nil
 }
 
 // GetApplication gets an application by ID
 func (s *Service) GetApplication(ctx context.Context, tenantID, id uuid.UUID) (*Application, error) {
 	query := `
-		SELECT id, tenant_id, name, display_name, description, team_name, 
-		       owner_email, lifecycle, status, observability_config, created_at, updated_at, 
-		       created_by, updated_by
+		SELECT ` + applicationColumns + `
 		FROM resource_management.applications 
 		WHERE tenant_id = $1 AND id = $2
 	`
 
-	var app Application
-	var configJSON []byte
-
-	err := s.db.QueryRow(ctx, query, tenantID, id).Scan(
-		&app.ID, &app.TenantID, &app.Name, &app.DisplayName, &app.Description,
-		&app.TeamName, &app.OwnerEmail, &app.Lifecycle, &app.Status,
-		&configJSON, &app.CreatedAt, &app.UpdatedAt, &app.CreatedBy, &app.UpdatedBy,
-	)
+	var app *Application
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		app, err = scanApplication(s.reader().QueryRow(ctx, query, tenantID, id))
+		return err
+	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("application not found")
+			return nil, ErrApplicationNotFound
 		}
 		return nil, fmt.Errorf("failed to get application: %w", err)
 	}
 
-	if err := json.Unmarshal(configJSON, &app.Config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	return app, nil
+}
+
+// UpdateApplication updates an application. The read-modify-write runs in a single
+// transaction with the row locked, so concurrent updates are applied one after the
+// other and config changes merge against the latest committed config. updatedBy, or
+// the system when it is empty, is recorded as the last modifier.
+func (s *Service) UpdateApplication(ctx context.Context, tenantID, id uuid.UUID, req *UpdateApplicationRequest, updatedBy string) (_ *Application, err error) {
+	updatedBy = actorOrSystem(updatedBy)
+	var app *Application
+	var previousLifecycle string
+	defer func() {
+		var name string
+		if app != nil {
+			name = app.Name
+		}
+		s.recordAudit(ctx, audit.ActionUpdate, tenantID, id, name, updatedBy, err)
+	}()
+
+	if req.Annotations != nil {
+		if err := ValidateAnnotations(*req.Annotations); err != nil {
+			return nil, err
+		}
 	}
 
-	return &app, nil
-}
+	err = s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		// Lock the existing application for the duration of the update
+		query := `
+			SELECT ` + applicationColumns + `
+			FROM resource_management.applications 
+			WHERE tenant_id = $1 AND id = $2
+			FOR UPDATE
+		`
 
-// UpdateApplication updates an application
-func (s *Service) UpdateApplication(ctx context.Context, tenantID, id uuid.UUID, req *UpdateApplicationRequest) (*Application, error) {
-	// First get the existing application
-	app, err := s.GetApplication(ctx, tenantID, id)
+		current, err := scanApplication(tx.QueryRow(ctx, query, tenantID, id))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrApplicationNotFound
+			}
+			return fmt.Errorf("failed to get application: %w", err)
+		}
+
+		// Retired applications only accept changes to their retirement schedule
+		if current.IsRetired(s.now()) && !req.onlyReschedulesRetirement() {
+			return ErrApplicationRetired
+		}
+
+		previousLifecycle = current.Lifecycle
+		req.applyTo(current)
+
+		current.UpdatedAt = time.Now().UTC()
+		current.UpdatedBy = &updatedBy
+
+		configJSON, err := json.Marshal(current.Config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+
+		if current.Annotations == nil {
+			current.Annotations = make(map[string]string)
+		}
+		annotationsJSON, err := json.Marshal(current.Annotations)
+		if err != nil {
+			return fmt.Errorf("failed to marshal annotations: %w", err)
+		}
+
+		update := `
+			UPDATE resource_management.applications 
+			SET display_name = $3, description = $4, team_name = $5, owner_email = $6, 
+			    lifecycle = $7, observability_config = $8, retirement_date = $9,
+			    updated_at = $10, updated_by = $11, annotations = $12, version = version + 1
+			WHERE tenant_id = $1 AND id = $2 AND ($13 = 0 OR version = $13)
+			RETURNING version
+		`
+
+		err = tx.QueryRow(ctx, update,
+			tenantID, id, current.DisplayName, current.Description, current.TeamName,
+			current.OwnerEmail, current.Lifecycle, configJSON, current.RetirementDate,
+			current.UpdatedAt, current.UpdatedBy, annotationsJSON, req.IfMatch,
+		).Scan(&current.Version)
+		if err != nil {
+			// The row is locked and exists, so only the version can have missed
+			if err == pgx.ErrNoRows {
+				return ErrVersionMismatch
+			}
+			return fmt.Errorf("failed to update application: %w", err)
+		}
+
+		if err := recordRevision(ctx, tx, current, *current.UpdatedBy); err != nil {
+			return err
+		}
+
+		app = current
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Update fields if provided
-	if req.DisplayName != nil {
-		app.DisplayName = *req.DisplayName
+	if app.Lifecycle != previousLifecycle {
+		s.notifyLifecycleChange(ctx, app, previousLifecycle)
 	}
-	if req.Description != nil {
-		app.Description = req.Description
+	return app, nil
+}
+
+// applyTo applies the requested changes to app. Config is merged key by key: keys in
+// the request overwrite existing values and keys set to null are removed.
+func (r *UpdateApplicationRequest) applyTo(app *Application) {
+	if r.DisplayName != nil {
+		app.DisplayName = *r.DisplayName
 	}
-	if req.TeamName != nil {
-		app.TeamName = *req.TeamName
+	if r.Description != nil {
+		app.Description = r.Description
 	}
-	if req.OwnerEmail != nil {
-		app.OwnerEmail = *req.OwnerEmail
+	if r.TeamName != nil {
+		app.TeamName = *r.TeamName
 	}
-	if req.Lifecycle != nil {
-		app.Lifecycle = *req.Lifecycle
+	if r.OwnerEmail != nil {
+		app.OwnerEmail = *r.OwnerEmail
 	}
-	if req.Config != nil {
-		app.Config = *req.Config
+	if r.Lifecycle != nil {
+		app.Lifecycle = *r.Lifecycle
 	}
-
-	app.UpdatedAt = time.Now().UTC()
-	app.UpdatedBy = &[]string{"system"}[0] // TODO: Get from context when auth is implemented
-
-	configJSON, err := json.Marshal(app.Config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	if r.Config != nil {
+		app.Config = mergeConfig(app.Config, *r.Config)
 	}
-
-	query := `
-		UPDATE resource_management.applications 
-		SET display_name = $3, description = $4, team_name = $5, owner_email = $6, 
-		    lifecycle = $7, observability_config = $8, updated_at = $9, updated_by = $10
-		WHERE tenant_id = $1 AND id = $2
-	`
-
-	_, err = s.db.Exec(ctx, query,
-		tenantID, id, app.DisplayName, app.Description, app.TeamName,
-		app.OwnerEmail, app.Lifecycle, configJSON, app.UpdatedAt, app.UpdatedBy,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update application: %w", err)
+	if r.Annotations != nil {
+		app.Annotations = *r.Annotations
 	}
+	if r.RetirementDate != nil {
+		app.RetirementDate = r.RetirementDate
+		app.Lifecycle = "deprecated"
+	}
+}
 
-	return app, nil
+// mergeConfig merges changes into config, deleting keys whose new value is nil
+func mergeConfig(config, changes map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(config)+len(changes))
+	for k, v := range config {
+		merged[k] = v
+	}
+	for k, v := range changes {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
 }
 
-// DeleteApplication deletes an application
-func (s *Service) DeleteApplication(ctx context.Context, tenantID, id uuid.UUID) error {
-	query := "DELETE FROM resource_management.applications WHERE tenant_id = $1 AND id = $2"
+// DeleteApplication soft-deletes an application on behalf of deletedBy by marking it
+// terminated. ErrApplicationNotFound is returned if there is no live application with
+// the ID.
+func (s *Service) DeleteApplication(ctx context.Context, tenantID, id uuid.UUID, deletedBy string) (err error) {
+	deletedBy = actorOrSystem(deletedBy)
+	var name string
+	defer func() { s.recordAudit(ctx, audit.ActionDelete, tenantID, id, name, deletedBy, err) }()
+
+	query := `
+		UPDATE resource_management.applications
+		SET status = $3, updated_by = $4, updated_at = NOW(), version = version + 1
+		WHERE tenant_id = $1 AND id = $2 AND status <> $3
+		RETURNING name
+	`
 
-	result, err := s.db.Exec(ctx, query, tenantID, id)
+	err = s.db.QueryRow(ctx, query, tenantID, id, StatusTerminated, deletedBy).Scan(&name)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrApplicationNotFound
+		}
 		return fmt.Errorf("failed to delete application: %w", err)
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("application not found")
-	}
-
 	return nil
 }
@@ -0,0 +1,1436 @@
+package applications
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/audit"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/events"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/notify"
+	"github.com/aykay76/ai-idp/internal/testutils"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplicationService_Retirement(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool, WithRetirementGracePeriod(7*24*time.Hour))
+
+	retirement := time.Now().Add(-24 * time.Hour).UTC()
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:           "retired-app",
+		DisplayName:    "Retired App",
+		TeamName:       "platform-team",
+		OwnerEmail:     "owner@company.com",
+		Lifecycle:      "production",
+		RetirementDate: &retirement,
+	}, "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, "deprecated", app.Lifecycle)
+
+	t.Run("writes are blocked after retirement", func(t *testing.T) {
+		config := map[string]interface{}{"replicas": 3}
+		_, err := service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{Config: &config}, "test-user")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrApplicationRetired)
+	})
+
+	t.Run("reads are allowed during the grace period", func(t *testing.T) {
+		got, err := service.GetApplication(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.RetirementDate)
+		assert.True(t, got.IsRetired(time.Now()))
+
+		purged, err := service.PurgeRetiredApplications(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+
+		_, err = service.GetApplication(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("retirement can be rescheduled", func(t *testing.T) {
+		extended := time.Now().Add(24 * time.Hour).UTC()
+		updated, err := service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{RetirementDate: &extended}, "test-user")
+		require.NoError(t, err)
+		assert.False(t, updated.IsRetired(time.Now()))
+
+		_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{RetirementDate: &retirement}, "test-user")
+		require.NoError(t, err)
+	})
+
+	t.Run("GC purges once the grace period has elapsed", func(t *testing.T) {
+		service.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+		defer func() { service.now = time.Now }()
+
+		purged, err := service.PurgeRetiredApplications(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, purged, int64(1))
+
+		_, err = service.GetApplication(ctx, tenant.ID, app.ID)
+		assert.ErrorIs(t, err, ErrApplicationNotFound)
+	})
+}
+
+func TestApplicationService_Resources(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "orders-api",
+		DisplayName: "Orders API",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "production",
+	}, "test-user")
+	require.NoError(t, err)
+
+	t.Run("declare resources", func(t *testing.T) {
+		resources, err := service.SetResources(ctx, tenant.ID, app.ID, []types.ResourceSpec{
+			{Type: "database", Name: "orders-db", Provider: "postgres"},
+			{Type: "cache", Name: "orders-cache", Provider: "redis"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, resources.Resources, 2)
+		assert.False(t, resources.Ready)
+
+		listed, err := service.GetResources(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+		assert.Len(t, listed.Resources, 2)
+		assert.Equal(t, ResourceStatusPending, listed.Resources[0].Status.Status)
+	})
+
+	t.Run("update resource to ready", func(t *testing.T) {
+		resources, err := service.UpdateResourceStatus(ctx, tenant.ID, app.ID, "orders-db", &UpdateResourceStatusRequest{Ready: true})
+		require.NoError(t, err)
+		assert.False(t, resources.Ready)
+
+		resources, err = service.UpdateResourceStatus(ctx, tenant.ID, app.ID, "orders-cache", &UpdateResourceStatusRequest{Ready: true})
+		require.NoError(t, err)
+		assert.True(t, resources.Ready)
+		assert.Equal(t, ResourceStatusReady, resources.Resources[1].Status.Status)
+	})
+
+	t.Run("redeclaring keeps existing status", func(t *testing.T) {
+		resources, err := service.SetResources(ctx, tenant.ID, app.ID, []types.ResourceSpec{
+			{Type: "database", Name: "orders-db", Provider: "postgres"},
+		})
+		require.NoError(t, err)
+		require.Len(t, resources.Resources, 1)
+		assert.True(t, resources.Ready)
+	})
+
+	t.Run("unknown resource", func(t *testing.T) {
+		_, err := service.UpdateResourceStatus(ctx, tenant.ID, app.ID, "missing", &UpdateResourceStatusRequest{Ready: true})
+		assert.ErrorIs(t, err, ErrResourceNotFound)
+	})
+}
+
+func TestApplicationService_DependencyStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	create := func(name string) *Application {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+		}, "test-user")
+		require.NoError(t, err)
+		return app
+	}
+
+	orders := create("orders-api")
+	billing := create("billing-api")
+	create("recommendations-api")
+
+	_, err := service.SetDependencies(ctx, tenant.ID, orders.ID, []types.DependencySpec{
+		{Type: DependencyTypeService, Name: "billing-api", Required: true},
+		{Type: DependencyTypeService, Name: "recommendations-api"},
+	})
+	require.NoError(t, err)
+
+	t.Run("healthy dependency is ok", func(t *testing.T) {
+		status, err := service.GetStatus(ctx, tenant.ID, orders.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, HealthOK, status.Health)
+		assert.Len(t, status.Dependencies, 2)
+		assert.Empty(t, status.UnhealthyDependencies)
+	})
+
+	t.Run("failing required dependency is degraded", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE resource_management.applications SET status = 'failed' WHERE id = $1`, billing.ID)
+		require.NoError(t, err)
+
+		status, err := service.GetStatus(ctx, tenant.ID, orders.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, HealthDegraded, status.Health)
+		assert.Equal(t, []string{"billing-api"}, status.UnhealthyDependencies)
+	})
+
+	t.Run("self dependency is rejected", func(t *testing.T) {
+		_, err := service.SetDependencies(ctx, tenant.ID, orders.ID, []types.DependencySpec{
+			{Type: DependencyTypeService, Name: "orders-api", Required: true},
+		})
+		assert.ErrorIs(t, err, ErrInvalidDependencies)
+	})
+}
+
+func TestApplicationService_DependencyGraph(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	create := func(name string, deps ...string) *Application {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+		}, "test-user")
+		require.NoError(t, err)
+
+		specs := []types.DependencySpec{}
+		for _, dep := range deps {
+			specs = append(specs, types.DependencySpec{Type: DependencyTypeService, Name: dep, Required: true})
+		}
+		_, err = service.SetDependencies(ctx, tenant.ID, app.ID, specs)
+		require.NoError(t, err)
+		return app
+	}
+	names := func(graph *DependencyGraph) []string {
+		var result []string
+		for _, node := range graph.Applications {
+			result = append(result, node.Name)
+		}
+		return result
+	}
+
+	ledger := create("ledger-api")
+	create("billing-api", "ledger-api")
+	orders := create("orders-api", "billing-api")
+	create("storefront", "orders-api")
+
+	t.Run("upstream resolves transitive dependencies", func(t *testing.T) {
+		graph, err := service.GetDependencyGraph(ctx, tenant.ID, orders.ID, DirectionUpstream)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"billing-api", "ledger-api"}, names(graph))
+		assert.Equal(t, []int{1, 2}, []int{graph.Applications[0].Depth, graph.Applications[1].Depth})
+		assert.False(t, graph.HasCycle)
+	})
+
+	t.Run("downstream resolves dependents", func(t *testing.T) {
+		graph, err := service.GetDependencyGraph(ctx, tenant.ID, ledger.ID, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, DirectionDownstream, graph.Direction)
+		assert.Equal(t, []string{"billing-api", "orders-api", "storefront"}, names(graph))
+		assert.False(t, graph.HasCycle)
+	})
+
+	t.Run("cycles are flagged", func(t *testing.T) {
+		_, err := service.SetDependencies(ctx, tenant.ID, ledger.ID, []types.DependencySpec{
+			{Type: DependencyTypeService, Name: "orders-api"},
+		})
+		require.NoError(t, err)
+
+		graph, err := service.GetDependencyGraph(ctx, tenant.ID, orders.ID, DirectionUpstream)
+		require.NoError(t, err)
+
+		assert.True(t, graph.HasCycle)
+		assert.Equal(t, []string{"orders-api", "billing-api", "ledger-api", "orders-api"}, graph.Cycle)
+	})
+
+	t.Run("invalid direction", func(t *testing.T) {
+		_, err := service.GetDependencyGraph(ctx, tenant.ID, orders.ID, "sideways")
+		assert.ErrorIs(t, err, ErrInvalidDirection)
+	})
+
+	t.Run("unknown application", func(t *testing.T) {
+		_, err := service.GetDependencyGraph(ctx, tenant.ID, uuid.New(), DirectionUpstream)
+		assert.ErrorIs(t, err, ErrApplicationNotFound)
+	})
+}
+
+func TestApplicationService_Approvals(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	var notified []string
+	auditLog := audit.New(pool, logger.New("debug", "text"))
+	service := NewService(pool, WithAudit(auditLog), WithApprovalWorkflow(ApprovalNotifierFunc(func(ctx context.Context, app *Application, approvers []string) {
+		notified = approvers
+	})))
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO resource_management.teams (tenant_id, name, display_name, lead_email, members, policies, created_by)
+		VALUES ($1, 'payments', 'Payments', 'lead@company.com', $2, '{}', 'system'),
+		       ($1, 'tooling', 'Tooling', 'tools@company.com', '[]', '{"auto_approval": true}', 'system')
+	`, tenant.ID, `[
+		{"email": "maintainer@company.com", "role": "maintainer", "status": "active"},
+		{"email": "dev@company.com", "role": "developer", "status": "active"}
+	]`)
+	require.NoError(t, err)
+
+	create := func(name, team, lifecycle string) *Application {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: name,
+			TeamName:    team,
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   lifecycle,
+		}, "test-user")
+		require.NoError(t, err)
+		return app
+	}
+
+	auditCount := func(id uuid.UUID, action string) int {
+		events, err := auditLog.ListEvents(ctx, audit.Filter{Kind: audit.KindApplication, Namespace: tenant.ID.String()})
+		require.NoError(t, err)
+
+		count := 0
+		for _, event := range events {
+			if event.Spec.Resource.UID == id.String() && event.Spec.Action == action && event.Spec.Result == types.AuditResultSuccess {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("pending approval to approved", func(t *testing.T) {
+		app := create("payments-api", "payments", "production")
+		assert.Equal(t, StatusPendingApproval, app.Status)
+		assert.Equal(t, []string{"lead@company.com", "maintainer@company.com"}, notified)
+		assert.Equal(t, 1, auditCount(app.ID, "approval_requested"))
+
+		_, err := service.ApproveApplication(ctx, tenant.ID, app.ID, "dev@company.com", nil)
+		assert.ErrorIs(t, err, ErrNotApprover, "developers cannot approve")
+
+		approved, err := service.ApproveApplication(ctx, tenant.ID, app.ID, "Maintainer@company.com", &ReviewApplicationRequest{Comment: "looks good"})
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, approved.Status)
+		require.NotNil(t, approved.ReviewedBy)
+		assert.Equal(t, "Maintainer@company.com", *approved.ReviewedBy)
+		assert.NotNil(t, approved.ReviewedAt)
+		require.NotNil(t, approved.ReviewComment)
+		assert.Equal(t, "looks good", *approved.ReviewComment)
+		assert.Equal(t, 1, auditCount(app.ID, "approve"))
+
+		_, err = service.ApproveApplication(ctx, tenant.ID, app.ID, "lead@company.com", nil)
+		assert.ErrorIs(t, err, ErrNotPendingApproval)
+	})
+
+	t.Run("rejection", func(t *testing.T) {
+		app := create("payments-batch", "payments", "production")
+
+		rejected, err := service.RejectApplication(ctx, tenant.ID, app.ID, "lead@company.com", &ReviewApplicationRequest{Comment: "use the shared batch platform"})
+		require.NoError(t, err)
+		assert.Equal(t, StatusRejected, rejected.Status)
+		assert.Equal(t, 1, auditCount(app.ID, "reject"))
+
+		fetched, err := service.GetApplication(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusRejected, fetched.Status)
+	})
+
+	t.Run("auto-approval and non-production skip review", func(t *testing.T) {
+		assert.Equal(t, StatusPending, create("tooling-api", "tooling", "production").Status)
+		assert.Equal(t, StatusPending, create("payments-sandbox", "payments", "development").Status)
+	})
+}
+
+func TestApplicationService_History(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "history-app",
+		DisplayName: "History App",
+		TeamName:    "platform",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "development",
+		Config:      map[string]interface{}{"replicas": 2, "db_password": "first"},
+	}, "test-user")
+	require.NoError(t, err)
+
+	displayName := "History Service"
+	_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{DisplayName: &displayName}, "test-user")
+	require.NoError(t, err)
+
+	config := map[string]interface{}{"replicas": 3, "db_password": "second"}
+	_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{Config: &config}, "test-user")
+	require.NoError(t, err)
+
+	revisions, err := service.ListRevisions(ctx, tenant.ID, app.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, []int{1, 2, 3}, []int{revisions[0].Revision, revisions[1].Revision, revisions[2].Revision})
+
+	diff, err := service.DiffRevisions(ctx, tenant.ID, app.ID, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []FieldChange{
+		{Path: "config.db_password", Change: ChangeChanged, From: MaskedValue, To: MaskedValue, Redacted: true},
+		{Path: "config.replicas", Change: ChangeChanged, From: float64(2), To: float64(3)},
+		{Path: "display_name", Change: ChangeChanged, From: "History App", To: "History Service"},
+	}, diff.Changes)
+
+	_, err = service.DiffRevisions(ctx, tenant.ID, app.ID, 1, 4)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+	_, err = service.ListRevisions(ctx, tenant.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrApplicationNotFound)
+}
+
+func TestApplicationService_Environment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "billing-api",
+		DisplayName: "Billing API",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "production",
+	}, "test-user")
+	require.NoError(t, err)
+
+	t.Run("plain and secret-backed variables", func(t *testing.T) {
+		_, err := service.SetEnvironment(ctx, tenant.ID, app.ID, []EnvVar{
+			{Name: "LOG_LEVEL", Value: "info"},
+			{Name: "DATABASE_PASSWORD", SecretRef: &types.SecretRef{Name: "billing-db", Key: "password"}},
+		})
+		require.NoError(t, err)
+
+		env, err := service.GetEnvironment(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+		require.Len(t, env.Variables, 2)
+		assert.Equal(t, "info", env.Variables[0].Value)
+		assert.Equal(t, MaskedValue, env.Variables[1].Value)
+		assert.Equal(t, "billing-db", env.Variables[1].SecretRef.Name)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := service.SetEnvironment(ctx, tenant.ID, app.ID, []EnvVar{{Name: "BAD NAME", Value: "x"}})
+		assert.ErrorIs(t, err, ErrInvalidEnvironment)
+	})
+
+	t.Run("unknown application", func(t *testing.T) {
+		_, err := service.GetEnvironment(ctx, tenant.ID, uuid.New())
+		assert.ErrorIs(t, err, ErrApplicationNotFound)
+	})
+}
+
+func TestApplicationService_ListByCreator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	var first *Application
+	for i, creator := range []string{"alice@company.com", "alice@company.com", "bob@company.com"} {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        fmt.Sprintf("creator-app-%d", i),
+			DisplayName: fmt.Sprintf("Creator App %d", i),
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, creator)
+		require.NoError(t, err)
+		assert.Equal(t, creator, app.CreatedBy)
+		if first == nil {
+			first = app
+		}
+	}
+
+	apps, total, err := service.ListApplications(ctx, &ListApplicationsRequest{
+		TenantID:  tenant.ID,
+		CreatedBy: "alice@company.com",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	for _, app := range apps {
+		assert.Equal(t, "alice@company.com", app.CreatedBy)
+	}
+
+	_, total, err = service.ListApplications(ctx, &ListApplicationsRequest{
+		TenantID:  tenant.ID,
+		UpdatedBy: "bob@company.com",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	displayName := "Updated by Bob"
+	updated, err := service.UpdateApplication(ctx, tenant.ID, first.ID, &UpdateApplicationRequest{DisplayName: &displayName}, "bob@company.com")
+	require.NoError(t, err)
+	require.NotNil(t, updated.UpdatedBy)
+	assert.Equal(t, "bob@company.com", *updated.UpdatedBy)
+
+	_, total, err = service.ListApplications(ctx, &ListApplicationsRequest{
+		TenantID:  tenant.ID,
+		UpdatedBy: "bob@company.com",
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	t.Run("unknown caller is recorded as the system", func(t *testing.T) {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        "anonymous-app",
+			DisplayName: "Anonymous App",
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "system", app.CreatedBy)
+	})
+}
+
+func TestApplicationService_TeamApplicationsAndStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	seeds := []struct {
+		name      string
+		team      string
+		lifecycle string
+	}{
+		{"stats-web", "platform-team", "development"},
+		{"stats-api", "platform-team", "production"},
+		{"stats-worker", "platform-team", "development"},
+		{"stats-billing", "payments-team", "staging"},
+	}
+	for _, seed := range seeds {
+		_, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        seed.name,
+			DisplayName: seed.name,
+			TeamName:    seed.team,
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   seed.lifecycle,
+		}, "test-user")
+		require.NoError(t, err)
+	}
+
+	t.Run("by team", func(t *testing.T) {
+		apps, err := service.GetApplicationsByTeam(ctx, tenant.ID, "platform-team")
+		require.NoError(t, err)
+		require.Len(t, apps, 3)
+		assert.Equal(t, []string{"stats-api", "stats-web", "stats-worker"},
+			[]string{apps[0].Name, apps[1].Name, apps[2].Name}, "ordered by name")
+
+		apps, err = service.GetApplicationsByTeam(ctx, tenant.ID, "unknown-team")
+		require.NoError(t, err)
+		assert.Empty(t, apps)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats, err := service.GetApplicationStats(ctx, tenant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 4, stats.Total)
+		assert.Equal(t, map[string]int{"development": 2, "production": 1, "staging": 1}, stats.ByLifecycle)
+		assert.Equal(t, map[string]int{StatusPending: 4}, stats.ByStatus)
+	})
+
+	t.Run("stats are tenant scoped", func(t *testing.T) {
+		stats, err := service.GetApplicationStats(ctx, uuid.New())
+		require.NoError(t, err)
+		assert.Equal(t, 0, stats.Total)
+		assert.Empty(t, stats.ByLifecycle)
+	})
+}
+
+func TestApplicationService_SoftDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	var apps []*Application
+	for _, name := range []string{"soft-delete-kept", "soft-delete-gone"} {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "test-user")
+		require.NoError(t, err)
+		apps = append(apps, app)
+	}
+	deleted := apps[1]
+
+	require.NoError(t, service.DeleteApplication(ctx, tenant.ID, deleted.ID, "alice@company.com"))
+
+	t.Run("delete marks the application terminated", func(t *testing.T) {
+		app, err := service.GetApplication(ctx, tenant.ID, deleted.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusTerminated, app.Status)
+		require.NotNil(t, app.UpdatedBy)
+		assert.Equal(t, "alice@company.com", *app.UpdatedBy)
+		assert.True(t, app.UpdatedAt.After(deleted.UpdatedAt))
+	})
+
+	t.Run("list excludes terminated applications", func(t *testing.T) {
+		listed, total, err := service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, listed, 1)
+		assert.Equal(t, apps[0].ID, listed[0].ID)
+
+		byTeam, err := service.GetApplicationsByTeam(ctx, tenant.ID, "platform-team")
+		require.NoError(t, err)
+		assert.Len(t, byTeam, 1)
+	})
+
+	t.Run("list includes terminated applications on request", func(t *testing.T) {
+		_, total, err := service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, IncludeTerminated: true, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+
+		_, total, err = service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, Status: StatusTerminated, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+	})
+
+	t.Run("deleting again is not found", func(t *testing.T) {
+		err := service.DeleteApplication(ctx, tenant.ID, deleted.ID, "alice@company.com")
+		assert.ErrorIs(t, err, ErrApplicationNotFound)
+	})
+
+	t.Run("a deleted application's name can be used again", func(t *testing.T) {
+		recreated, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        deleted.Name,
+			DisplayName: deleted.Name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "test-user")
+		require.NoError(t, err)
+		assert.NotEqual(t, deleted.ID, recreated.ID)
+
+		old, err := service.GetApplication(ctx, tenant.ID, deleted.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusTerminated, old.Status, "the deleted application stays readable")
+
+		_, err = service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        deleted.Name,
+			DisplayName: deleted.Name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "test-user")
+		assert.ErrorIs(t, err, ErrNameCollision, "live applications still hold their names")
+	})
+}
+
+func TestApplicationService_CreateApplication_Quota(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant, err := database.NewTenantManager(pool).CreateTenant(ctx, &database.CreateTenantRequest{
+		Name:           "quota-tenant",
+		DisplayName:    "Quota Tenant",
+		ResourceLimits: map[string]interface{}{database.LimitApplications: 2},
+	})
+	require.NoError(t, err)
+	service := NewService(pool)
+
+	create := func(name string) (*Application, error) {
+		return service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "test-user")
+	}
+
+	first, err := create("quota-app-1")
+	require.NoError(t, err)
+	_, err = create("quota-app-2")
+	require.NoError(t, err)
+
+	t.Run("creating past the limit is rejected", func(t *testing.T) {
+		_, err := create("quota-app-3")
+		assert.ErrorIs(t, err, database.ErrQuotaExceeded)
+
+		_, total, err := service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("terminated applications do not count", func(t *testing.T) {
+		require.NoError(t, service.DeleteApplication(ctx, tenant.ID, first.ID, "test-user"))
+
+		_, err := create("quota-app-3")
+		assert.NoError(t, err)
+	})
+}
+
+func TestApplicationService_ListApplications_Search(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	description := "Handles 50% of checkout traffic"
+	seeds := []CreateApplicationRequest{
+		{Name: "orders-api", DisplayName: "Orders API"},
+		{Name: "orders-worker", DisplayName: "Order Fulfilment"},
+		{Name: "checkout", DisplayName: "Checkout", Description: &description},
+	}
+	for _, seed := range seeds {
+		seed.TeamName = "platform-team"
+		seed.OwnerEmail = "owner@company.com"
+		seed.Lifecycle = "development"
+		_, err := service.CreateApplication(ctx, tenant.ID, &seed, "test-user")
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"orders", []string{"orders-api", "orders-worker"}},
+		{"ORDERS-A", []string{"orders-api"}},
+		{"fulfil", []string{"orders-worker"}},
+		{"50% of", []string{"checkout"}},
+		{"%", []string{"checkout"}},
+		{"orders_api", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			apps, total, err := service.ListApplications(ctx, &ListApplicationsRequest{
+				TenantID: tenant.ID,
+				Search:   tt.search,
+				Limit:    10,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), total)
+
+			var names []string
+			for _, app := range apps {
+				names = append(names, app.Name)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+func TestApplicationService_ListApplications_ConfigFilters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	seeds := []CreateApplicationRequest{
+		{Name: "orders-api", Config: map[string]interface{}{"runtime": "go", "replicas": 3, "feature": "canary"}},
+		{Name: "orders-worker", Config: map[string]interface{}{"runtime": "go", "replicas": 1}},
+		{Name: "checkout", Config: map[string]interface{}{"runtime": "node", "feature": false}},
+		{Name: "ledger"},
+	}
+	for _, seed := range seeds {
+		seed.DisplayName = seed.Name
+		seed.TeamName = "platform-team"
+		seed.OwnerEmail = "owner@company.com"
+		seed.Lifecycle = "development"
+		_, err := service.CreateApplication(ctx, tenant.ID, &seed, "test-user")
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string]string
+		want    []string
+	}{
+		{"equality", map[string]string{"runtime": "go"}, []string{"orders-api", "orders-worker"}},
+		{"number compared as text", map[string]string{"replicas": "3"}, []string{"orders-api"}},
+		{"existence", map[string]string{"feature": ConfigFilterAny}, []string{"orders-api", "checkout"}},
+		{"combined", map[string]string{"runtime": "go", "feature": ConfigFilterAny}, []string{"orders-api"}},
+		{"no match", map[string]string{"runtime": "rust"}, nil},
+		{"value that looks like SQL", map[string]string{"runtime": "go' OR '1'='1"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps, total, err := service.ListApplications(ctx, &ListApplicationsRequest{
+				TenantID:      tenant.ID,
+				ConfigFilters: tt.filters,
+				Limit:         10,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), total)
+
+			var names []string
+			for _, app := range apps {
+				names = append(names, app.Name)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+func TestApplicationService_ListApplications_Sort(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	// Created out of name order, so name order differs from creation order
+	for _, name := range []string{"bravo", "charlie", "alpha"} {
+		_, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: name,
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "test-user")
+		require.NoError(t, err)
+	}
+
+	list := func(sort database.Sort) []string {
+		apps, _, err := service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, Sort: sort, Limit: 10})
+		require.NoError(t, err)
+		var names []string
+		for _, app := range apps {
+			names = append(names, app.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"alpha", "bravo", "charlie"}, list(database.Sort{Column: "name"}))
+	assert.Equal(t, []string{"charlie", "bravo", "alpha"}, list(database.Sort{Column: "name", Descending: true}))
+	assert.Equal(t, []string{"alpha", "charlie", "bravo"}, list(database.Sort{}), "newest first by default")
+
+	t.Run("rejects a field that is not sortable", func(t *testing.T) {
+		_, _, err := service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, Sort: database.Sort{Column: "owner_email"}, Limit: 10})
+		assert.ErrorIs(t, err, database.ErrInvalidSort)
+	})
+}
+
+func TestApplicationService_ListApplications_Cursor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	want := make(map[uuid.UUID]bool)
+	for i := 0; i < 25; i++ {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        fmt.Sprintf("cursor-app-%02d", i),
+			DisplayName: fmt.Sprintf("Cursor App %d", i),
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "development",
+		}, "test-user")
+		require.NoError(t, err)
+		want[app.ID] = true
+	}
+
+	// Share timestamps between rows so that ordering relies on the id tiebreak
+	_, err := pool.Exec(ctx, `
+		UPDATE resource_management.applications
+		SET created_at = TIMESTAMP WITH TIME ZONE '2024-01-01 00:00:00+00' + (ascii(right(name, 1)) % 3) * INTERVAL '1 second'
+		WHERE tenant_id = $1
+	`, tenant.ID)
+	require.NoError(t, err)
+
+	seen := make(map[uuid.UUID]bool)
+	var after *database.Cursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10, "pagination did not terminate")
+
+		apps, total, err := service.ListApplications(ctx, &ListApplicationsRequest{TenantID: tenant.ID, After: after, Limit: 10})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, total, 25)
+
+		for _, app := range apps {
+			assert.False(t, seen[app.ID], "application %s returned twice", app.Name)
+			seen[app.ID] = true
+		}
+		if len(apps) < 10 {
+			break
+		}
+
+		last := apps[len(apps)-1]
+		after = &database.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+
+		// Rows created between pages sort before the cursor and are not returned
+		if pages == 0 {
+			_, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+				Name:        "cursor-app-late",
+				DisplayName: "Cursor App Late",
+				TeamName:    "platform-team",
+				OwnerEmail:  "owner@company.com",
+				Lifecycle:   "development",
+			}, "test-user")
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, want, seen)
+}
+
+func TestApplicationService_DerivedNameCollision(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	newRequest := func(name string) *CreateApplicationRequest {
+		return &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: "Orders API",
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+		}
+	}
+
+	_, err := service.CreateApplication(ctx, tenant.ID, newRequest("orders.api"), "test-user")
+	require.NoError(t, err)
+
+	_, err = service.CreateApplication(ctx, tenant.ID, newRequest("Orders_API"), "test-user")
+	require.ErrorIs(t, err, ErrNameCollision)
+
+	var collision *NameCollisionError
+	require.ErrorAs(t, err, &collision)
+	assert.Equal(t, "Orders_API", collision.RequestedName)
+	assert.Equal(t, "orders-api", collision.DerivedName)
+	assert.Equal(t, "orders.api", collision.ExistingName)
+
+	// Another tenant may use the same derived name
+	other, err := database.NewTenantManager(pool).CreateTenant(ctx, &database.CreateTenantRequest{
+		Name:        fmt.Sprintf("naming-%d", time.Now().UnixNano()),
+		DisplayName: "Naming Tenant",
+	})
+	require.NoError(t, err)
+	_, err = service.CreateApplication(ctx, other.ID, newRequest("Orders_API"), "test-user")
+	assert.NoError(t, err)
+}
+
+func TestApplicationService_NamePolicy(t *testing.T) {
+	newRequest := func(name string) *CreateApplicationRequest {
+		return &CreateApplicationRequest{
+			Name:        name,
+			DisplayName: "Billing API",
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+		}
+	}
+
+	t.Run("reject refuses mixed-case names", func(t *testing.T) {
+		// Rejected before any query, so no database is needed
+		service := NewService(nil, WithNamePolicy(naming.PolicyReject))
+
+		_, err := service.CreateApplication(context.Background(), uuid.New(), newRequest("Billing-API"), "test-user")
+		assert.ErrorIs(t, err, ErrInvalidName)
+		assert.ErrorIs(t, err, naming.ErrNotLowercase)
+	})
+
+	t.Run("normalize lowercases names", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping integration test in short mode")
+		}
+
+		ctx := context.Background()
+		pool, cleanup := testutils.SetupTestDB(t, ctx)
+		defer cleanup()
+
+		tenant := testutils.SetupTestTenant(t, ctx, pool)
+		service := NewService(pool, WithNamePolicy(naming.PolicyNormalize))
+
+		app, err := service.CreateApplication(ctx, tenant.ID, newRequest("Billing-API"), "test-user")
+		require.NoError(t, err)
+		assert.Equal(t, "billing-api", app.Name)
+
+		stored, err := service.GetApplication(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "billing-api", stored.Name)
+	})
+}
+
+func TestApplicationService_Annotations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	t.Run("annotations are stored", func(t *testing.T) {
+		app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        "annotated-app",
+			DisplayName: "Annotated App",
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+			Annotations: map[string]string{AnnotationSuppressRetirementWarning: "true", "example.com/cost-center": "42"},
+		}, "test-user")
+		require.NoError(t, err)
+
+		got, err := service.GetApplication(ctx, tenant.ID, app.ID)
+		require.NoError(t, err)
+		assert.True(t, got.AnnotationEnabled(AnnotationSuppressRetirementWarning))
+		assert.Equal(t, "42", got.Annotations["example.com/cost-center"])
+	})
+
+	t.Run("reserved annotations are rejected", func(t *testing.T) {
+		_, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+			Name:        "rejected-app",
+			DisplayName: "Rejected App",
+			TeamName:    "platform-team",
+			OwnerEmail:  "owner@company.com",
+			Lifecycle:   "production",
+			Annotations: map[string]string{"aiidp.io/unknown": "true"},
+		}, "test-user")
+		assert.ErrorIs(t, err, ErrInvalidAnnotations)
+	})
+}
+
+func TestMergeConfig(t *testing.T) {
+	config := map[string]interface{}{"replicas": 2, "region": "eu-west-1"}
+
+	merged := mergeConfig(config, map[string]interface{}{"replicas": 3, "region": nil, "tier": "gold"})
+
+	assert.Equal(t, map[string]interface{}{"replicas": 3, "tier": "gold"}, merged)
+	assert.Equal(t, 2, config["replicas"], "original config is not modified")
+	assert.Empty(t, mergeConfig(nil, map[string]interface{}{"gone": nil}))
+}
+
+func TestApplicationService_ConcurrentConfigUpdates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "concurrent-app",
+		DisplayName: "Concurrent App",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "production",
+		Config:      map[string]interface{}{"existing": "value"},
+	}, "test-user")
+	require.NoError(t, err)
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			config := map[string]interface{}{fmt.Sprintf("key-%d", i): i}
+			_, err := service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{Config: &config}, "test-user")
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	got, err := service.GetApplication(ctx, tenant.ID, app.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "value", got.Config["existing"])
+	for i := 0; i < writers; i++ {
+		assert.Contains(t, got.Config, fmt.Sprintf("key-%d", i), "concurrent config update was lost")
+	}
+}
+
+func TestApplicationService_ConditionalUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "versioned-app",
+		DisplayName: "Versioned App",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "development",
+	}, "test-user")
+	require.NoError(t, err)
+	require.Equal(t, 1, app.Version)
+
+	first := "First Writer"
+	updated, err := service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{DisplayName: &first, IfMatch: 1}, "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	// A second writer that read version 1 must not overwrite the first
+	second := "Second Writer"
+	_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{DisplayName: &second, IfMatch: 1}, "test-user")
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+
+	got, err := service.GetApplication(ctx, tenant.ID, app.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First Writer", got.DisplayName)
+	assert.Equal(t, 2, got.Version)
+
+	revisions, err := service.ListRevisions(ctx, tenant.ID, app.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2, "rejected updates are not recorded as revisions")
+}
+
+// fakeRow is a pgx.Row that scans fixed column values, in applicationColumns order
+type fakeRow []interface{}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if len(dest) != len(r) {
+		return fmt.Errorf("scanning %d columns into %d destinations", len(r), len(dest))
+	}
+	for i, value := range r {
+		if value == nil {
+			continue
+		}
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func TestScanApplication_NullJSONColumns(t *testing.T) {
+	row := func(config, annotations []byte) fakeRow {
+		now := time.Now()
+		return fakeRow{
+			uuid.New(), uuid.New(), "orders", "Orders", nil, "payments",
+			"owner@example.com", "development", "pending", config, annotations,
+			nil, now, now, "system", nil, nil, nil, nil, 1,
+		}
+	}
+
+	t.Run("NULL config defaults to an empty map", func(t *testing.T) {
+		app, err := scanApplication(row(nil, nil))
+		require.NoError(t, err)
+
+		assert.NotNil(t, app.Config)
+		assert.Empty(t, app.Config)
+		assert.Empty(t, app.Annotations)
+	})
+
+	t.Run("JSON null config defaults to an empty map", func(t *testing.T) {
+		app, err := scanApplication(row([]byte("null"), []byte("null")))
+		require.NoError(t, err)
+
+		assert.NotNil(t, app.Config)
+		assert.Empty(t, app.Config)
+	})
+
+	t.Run("stored config is decoded", func(t *testing.T) {
+		app, err := scanApplication(row([]byte(`{"replicas": 2}`), []byte(`{"aiidp.io/tier": "gold"}`)))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]interface{}{"replicas": float64(2)}, app.Config)
+		assert.Equal(t, map[string]string{"aiidp.io/tier": "gold"}, app.Annotations)
+	})
+
+	t.Run("malformed config is still an error", func(t *testing.T) {
+		_, err := scanApplication(row([]byte("{"), nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestApplicationService_Audit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	auditLog := audit.New(pool, logger.New("debug", "text"))
+	service := NewService(pool, WithAudit(auditLog))
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "audited-app",
+		DisplayName: "Audited App",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "development",
+	}, "")
+	require.NoError(t, err)
+
+	displayName := "Renamed App"
+	_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{DisplayName: &displayName}, "")
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteApplication(ctx, tenant.ID, app.ID, ""))
+
+	events, err := auditLog.ListEvents(ctx, audit.Filter{
+		Kind:      audit.KindApplication,
+		Namespace: tenant.ID.String(),
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	var actions []string
+	for _, event := range events {
+		actions = append(actions, event.Spec.Action)
+		assert.Equal(t, audit.SystemActor, event.Spec.Actor, "unauthenticated writes are made by the system")
+	}
+	assert.Equal(t, []string{audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate}, actions)
+
+	deleted := events[0].Spec
+	assert.Equal(t, types.AuditResultSuccess, deleted.Result)
+	assert.Equal(t, types.ResourceReference{
+		APIVersion: audit.APIVersion,
+		Kind:       audit.KindApplication,
+		Name:       "audited-app",
+		Namespace:  tenant.ID.String(),
+		UID:        app.ID.String(),
+	}, deleted.Resource)
+}
+
+// recordingPublisher keeps the events published to it
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) {
+	p.events = append(p.events, event)
+}
+
+func TestApplicationService_Events(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	publisher := &recordingPublisher{}
+	service := NewService(pool, WithEvents(publisher))
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "evented-app",
+		DisplayName: "Evented App",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "development",
+	}, "creator@company.com")
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteApplication(ctx, tenant.ID, app.ID, "deleter@company.com"))
+	assert.ErrorIs(t, service.DeleteApplication(ctx, tenant.ID, app.ID, "deleter@company.com"), ErrApplicationNotFound)
+
+	require.Len(t, publisher.events, 2, "failed writes are not announced")
+
+	resource := types.ResourceReference{
+		APIVersion: audit.APIVersion,
+		Kind:       audit.KindApplication,
+		Name:       "evented-app",
+		Namespace:  tenant.ID.String(),
+		UID:        app.ID.String(),
+	}
+	created := publisher.events[0]
+	assert.Equal(t, "application.created", created.Type)
+	assert.Equal(t, tenant.ID.String(), created.TenantID)
+	assert.Equal(t, resource, created.Resource)
+	assert.Equal(t, types.Actor{Type: types.ActorTypeUser, ID: "creator@company.com"}, created.Actor)
+
+	deleted := publisher.events[1]
+	assert.Equal(t, "application.deleted", deleted.Type)
+	assert.Equal(t, resource, deleted.Resource)
+	assert.Equal(t, types.Actor{Type: types.ActorTypeUser, ID: "deleter@company.com"}, deleted.Actor)
+}
+
+// recordingNotifier keeps the notifications sent to it
+type recordingNotifier struct {
+	notifications []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func TestApplicationService_LifecycleNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	notifier := &recordingNotifier{}
+	service := NewService(pool, WithNotifier(notifier))
+
+	app, err := service.CreateApplication(ctx, tenant.ID, &CreateApplicationRequest{
+		Name:        "notified-app",
+		DisplayName: "Notified App",
+		TeamName:    "platform-team",
+		OwnerEmail:  "owner@company.com",
+		Lifecycle:   "staging",
+	}, "")
+	require.NoError(t, err)
+
+	displayName := "Renamed App"
+	_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{DisplayName: &displayName}, "")
+	require.NoError(t, err)
+	assert.Empty(t, notifier.notifications, "only lifecycle changes are notified")
+
+	production := "production"
+	_, err = service.UpdateApplication(ctx, tenant.ID, app.ID, &UpdateApplicationRequest{Lifecycle: &production}, "")
+	require.NoError(t, err)
+
+	require.Len(t, notifier.notifications, 1)
+	n := notifier.notifications[0]
+	assert.Equal(t, notify.TypeApplicationLifecycleChanged, n.Type)
+	assert.Equal(t, tenant.ID.String(), n.TenantID)
+	assert.Equal(t, notify.ApplicationLifecycleChange{
+		ApplicationID: app.ID.String(),
+		Name:          "notified-app",
+		TeamName:      "platform-team",
+		From:          "staging",
+		To:            "production",
+	}, n.Data)
+}
@@ -0,0 +1,297 @@
+// Package audit records who changed which platform resources, and whether the change
+// succeeded, as types.AuditEvent records.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// APIVersion is the API version of audited resources
+const APIVersion = "v1"
+
+// Kinds of audited resources
+const (
+	KindApplication = "Application"
+	KindTeam        = "Team"
+)
+
+// Actions recorded for write operations
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionRestore undoes a soft delete
+	ActionRestore = "restore"
+	// ActionApprovalRequested holds a resource for review, which ActionApprove or
+	// ActionReject concludes
+	ActionApprovalRequested = "approval_requested"
+	ActionApprove           = "approve"
+	ActionReject            = "reject"
+)
+
+// SystemActor performs actions that no authenticated user is attached to
+var SystemActor = types.Actor{Type: types.ActorTypeSystem, ID: "system"}
+
+// Recorder records audit events. Services accept a Recorder so they can be tested
+// without a database.
+type Recorder interface {
+	Record(ctx context.Context, event types.AuditEvent) error
+	// RecordIn stores event through q, such as the transaction making the change it
+	// records, so the event commits or rolls back with the change
+	RecordIn(ctx context.Context, q database.Querier, event types.AuditEvent) error
+}
+
+// Audit stores audit events in the audit_system.audit_events table
+type Audit struct {
+	db     *database.Pool
+	logger *logger.Logger
+}
+
+// Compile-time check that Audit implements Recorder
+var _ Recorder = (*Audit)(nil)
+
+// New creates an Audit backed by db. Events that cannot be stored are logged to
+// appLogger, so callers may ignore the error Record returns.
+func New(db *database.Pool, appLogger *logger.Logger) *Audit {
+	return &Audit{db: db, logger: appLogger}
+}
+
+// ActorFromContext returns the user stored in ctx by the auth middleware, or
+// SystemActor when the request is unauthenticated
+func ActorFromContext(ctx context.Context) types.Actor {
+	if userID, ok := ctx.Value(types.UserIDKey).(string); ok && userID != "" {
+		return types.Actor{Type: types.ActorTypeUser, ID: userID}
+	}
+	return SystemActor
+}
+
+// NewEvent builds the event for action on resource by the actor in ctx. A nil err
+// records success; otherwise the event is a failure carrying the error message.
+// Resources without a namespace are placed in the tenant from ctx.
+func NewEvent(ctx context.Context, action string, resource types.ResourceReference, err error) types.AuditEvent {
+	if resource.APIVersion == "" {
+		resource.APIVersion = APIVersion
+	}
+	if resource.Namespace == "" {
+		resource.Namespace, _ = ctx.Value(types.TenantIDKey).(string)
+	}
+
+	id := uuid.New()
+	event := types.AuditEvent{
+		TypeMeta: types.TypeMeta{APIVersion: APIVersion, Kind: "AuditEvent"},
+		Metadata: types.ObjectMeta{
+			Name:      id.String(),
+			Namespace: resource.Namespace,
+			UID:       id,
+		},
+		Spec: types.AuditEventSpec{
+			Timestamp: time.Now().UTC(),
+			Actor:     ActorFromContext(ctx),
+			Action:    action,
+			Resource:  resource,
+			Result:    types.AuditResultSuccess,
+		},
+	}
+	event.Metadata.CreatedAt = event.Spec.Timestamp
+	event.Metadata.UpdatedAt = event.Spec.Timestamp
+
+	event.Spec.RequestID = types.RequestIDFromContext(ctx)
+	if err != nil {
+		event.Spec.Result = types.AuditResultFailure
+		event.Spec.Details = map[string]string{"error": err.Error()}
+	}
+
+	return event
+}
+
+// Record stores event. The write is not cancelled with ctx, so an action whose client
+// has gone away is still recorded.
+func (a *Audit) Record(ctx context.Context, event types.AuditEvent) error {
+	ctx = context.WithoutCancel(ctx)
+
+	err := insert(ctx, a.db, complete(event))
+	if err != nil {
+		a.logger.WithFields(logger.LogFields{
+			logger.FieldComponent: "audit",
+			logger.FieldError:     err.Error(),
+			"action":              event.Spec.Action,
+			"resource_kind":       event.Spec.Resource.Kind,
+			"resource_uid":        event.Spec.Resource.UID,
+		}).Error("Failed to record audit event")
+	}
+	return err
+}
+
+// RecordIn stores event through q. Unlike Record it does not log a failure, which
+// the caller is expected to return, failing the change along with its event.
+func (a *Audit) RecordIn(ctx context.Context, q database.Querier, event types.AuditEvent) error {
+	return insert(ctx, q, complete(event))
+}
+
+// complete fills in the fields of event that are left to the recorder
+func complete(event types.AuditEvent) types.AuditEvent {
+	if event.Metadata.UID == uuid.Nil {
+		event.Metadata.UID = uuid.New()
+	}
+	if event.Spec.Timestamp.IsZero() {
+		event.Spec.Timestamp = time.Now().UTC()
+	}
+	if event.Spec.Details == nil {
+		event.Spec.Details = map[string]string{}
+	}
+	return event
+}
+
+// insert writes event through q
+func insert(ctx context.Context, q database.Querier, event types.AuditEvent) error {
+	detailsJSON, err := json.Marshal(event.Spec.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	spec := event.Spec
+	query := `
+		INSERT INTO audit_system.audit_events (
+			id, occurred_at, actor_type, actor_id, actor_name, actor_email, action,
+			resource_api_version, resource_kind, resource_name, resource_namespace,
+			resource_uid, result, details, user_agent, ip_address, request_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+		)
+	`
+
+	_, err = q.Exec(ctx, query,
+		event.Metadata.UID, spec.Timestamp, spec.Actor.Type, spec.Actor.ID,
+		nullString(spec.Actor.Name), nullString(spec.Actor.Email), spec.Action,
+		spec.Resource.APIVersion, spec.Resource.Kind, spec.Resource.Name,
+		nullString(spec.Resource.Namespace), nullString(spec.Resource.UID), spec.Result,
+		string(detailsJSON), nullString(spec.UserAgent), nullString(spec.IPAddress),
+		nullString(spec.RequestID),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// Filter selects audit events. Zero fields match every event.
+type Filter struct {
+	ActorID string
+	// Kind is the resource kind, such as KindTeam
+	Kind      string
+	Namespace string
+	// Since and Until bound the event time; Since is inclusive, Until exclusive
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// DefaultListLimit is the number of events ListEvents returns when the filter has no
+// limit
+const DefaultListLimit = 100
+
+// ListEvents returns the events matching filter, newest first
+func (a *Audit) ListEvents(ctx context.Context, filter Filter) ([]types.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ActorID != "" {
+		args = append(args, filter.ActorID)
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+	if filter.Kind != "" {
+		args = append(args, filter.Kind)
+		conditions = append(conditions, fmt.Sprintf("resource_kind = $%d", len(args)))
+	}
+	if filter.Namespace != "" {
+		args = append(args, filter.Namespace)
+		conditions = append(conditions, fmt.Sprintf("resource_namespace = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", len(args)))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		conditions = append(conditions, fmt.Sprintf("occurred_at < $%d", len(args)))
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	args = append(args, limit)
+
+	query := `
+		SELECT id, occurred_at, actor_type, actor_id, COALESCE(actor_name, ''),
+		       COALESCE(actor_email, ''), action, resource_api_version, resource_kind,
+		       resource_name, COALESCE(resource_namespace, ''), COALESCE(resource_uid, ''),
+		       result, details, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       COALESCE(request_id, '')
+		FROM audit_system.audit_events
+		` + whereClause + `
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $` + fmt.Sprintf("%d", len(args))
+
+	rows, err := a.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []types.AuditEvent
+	for rows.Next() {
+		var event types.AuditEvent
+		var detailsJSON []byte
+		spec := &event.Spec
+
+		err := rows.Scan(
+			&event.Metadata.UID, &spec.Timestamp, &spec.Actor.Type, &spec.Actor.ID,
+			&spec.Actor.Name, &spec.Actor.Email, &spec.Action, &spec.Resource.APIVersion,
+			&spec.Resource.Kind, &spec.Resource.Name, &spec.Resource.Namespace,
+			&spec.Resource.UID, &spec.Result, &detailsJSON, &spec.UserAgent,
+			&spec.IPAddress, &spec.RequestID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event row: %w", err)
+		}
+		if err := json.Unmarshal(detailsJSON, &spec.Details); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit details: %w", err)
+		}
+
+		event.TypeMeta = types.TypeMeta{APIVersion: APIVersion, Kind: "AuditEvent"}
+		event.Metadata.Name = event.Metadata.UID.String()
+		event.Metadata.Namespace = spec.Resource.Namespace
+		event.Metadata.CreatedAt = spec.Timestamp
+		event.Metadata.UpdatedAt = spec.Timestamp
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// nullString stores empty optional fields as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/testutils"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, SystemActor, ActorFromContext(context.Background()))
+
+	ctx := context.WithValue(context.Background(), types.UserIDKey, "alice@company.com")
+	assert.Equal(t, types.Actor{Type: types.ActorTypeUser, ID: "alice@company.com"}, ActorFromContext(ctx))
+}
+
+func TestNewEvent(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.UserIDKey, "alice@company.com")
+	ctx = context.WithValue(ctx, types.TenantIDKey, "tenant-1")
+	ctx = context.WithValue(ctx, types.RequestIDKey, "req-1")
+
+	t.Run("success", func(t *testing.T) {
+		event := NewEvent(ctx, ActionDelete, types.ResourceReference{Kind: KindTeam, Name: "platform", UID: "42"}, nil)
+
+		assert.Equal(t, types.AuditResultSuccess, event.Spec.Result)
+		assert.Equal(t, ActionDelete, event.Spec.Action)
+		assert.Equal(t, "alice@company.com", event.Spec.Actor.ID)
+		assert.Equal(t, "req-1", event.Spec.RequestID)
+		assert.Equal(t, types.ResourceReference{
+			APIVersion: APIVersion,
+			Kind:       KindTeam,
+			Name:       "platform",
+			Namespace:  "tenant-1",
+			UID:        "42",
+		}, event.Spec.Resource)
+		assert.Empty(t, event.Spec.Details)
+		assert.WithinDuration(t, time.Now(), event.Spec.Timestamp, time.Second)
+	})
+
+	t.Run("failure carries the error", func(t *testing.T) {
+		event := NewEvent(ctx, ActionUpdate, types.ResourceReference{Kind: KindTeam, Namespace: "tenant-2"}, errors.New("team not found"))
+
+		assert.Equal(t, types.AuditResultFailure, event.Spec.Result)
+		assert.Equal(t, map[string]string{"error": "team not found"}, event.Spec.Details)
+		assert.Equal(t, "tenant-2", event.Spec.Resource.Namespace, "the resource's own namespace wins")
+	})
+}
+
+func TestAudit_ListEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	auditLog := New(pool, logger.New("debug", "text"))
+	namespace := t.Name() + time.Now().Format(time.RFC3339Nano)
+
+	record := func(actor, kind string, at time.Time) {
+		t.Helper()
+		event := NewEvent(ctx, ActionCreate, types.ResourceReference{Kind: kind, Name: "resource", Namespace: namespace}, nil)
+		event.Spec.Actor = types.Actor{Type: types.ActorTypeUser, ID: actor}
+		event.Spec.Timestamp = at
+		require.NoError(t, auditLog.Record(ctx, event))
+	}
+
+	start := time.Now().UTC().Truncate(time.Second)
+	record("alice", KindTeam, start)
+	record("alice", KindApplication, start.Add(time.Minute))
+	record("bob", KindTeam, start.Add(2*time.Minute))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 3},
+		{"by actor", Filter{ActorID: "alice"}, 2},
+		{"by kind", Filter{Kind: KindTeam}, 2},
+		{"by actor and kind", Filter{ActorID: "alice", Kind: KindTeam}, 1},
+		{"since", Filter{Since: start.Add(time.Minute)}, 2},
+		{"until", Filter{Until: start.Add(time.Minute)}, 1},
+		{"limit", Filter{Limit: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Namespace = namespace
+			events, err := auditLog.ListEvents(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Len(t, events, tt.want)
+		})
+	}
+
+	events, err := auditLog.ListEvents(ctx, Filter{Namespace: namespace, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "bob", events[0].Spec.Actor.ID, "newest first")
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// ProviderGitHub names the GitHub provider
+const ProviderGitHub = "github"
+
+// GitHub endpoints and the timeout for calls to them
+const (
+	DefaultGitHubAuthURL  = "https://github.com/login/oauth/authorize"
+	DefaultGitHubTokenURL = "https://github.com/login/oauth/access_token"
+	DefaultGitHubAPIURL   = "https://api.github.com"
+	DefaultGitHubTimeout  = 10 * time.Second
+)
+
+// GitHubOptions configures a GitHub provider
+type GitHubOptions struct {
+	// Client calls GitHub; defaults to a client with DefaultGitHubTimeout
+	Client *http.Client
+	// AuthURL, TokenURL and APIURL default to GitHub's; tests point them at a fake
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// GitHub signs users in with the OAuth web flow of a GitHub App: the user authorizes
+// the app on GitHub, which redirects back with a code that is exchanged for a user
+// token, and the token is used to read the user's profile
+type GitHub struct {
+	clientID     string
+	clientSecret string
+	client       *http.Client
+	authURL      string
+	tokenURL     string
+	apiURL       string
+}
+
+// Compile-time checks that GitHub implements Provider and Redirector
+var (
+	_ Provider   = (*GitHub)(nil)
+	_ Redirector = (*GitHub)(nil)
+)
+
+// NewGitHub creates a GitHub provider for the app with the OAuth client ID and secret
+func NewGitHub(clientID, clientSecret string, opts GitHubOptions) *GitHub {
+	g := &GitHub{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       opts.Client,
+		authURL:      opts.AuthURL,
+		tokenURL:     opts.TokenURL,
+		apiURL:       strings.TrimSuffix(opts.APIURL, "/"),
+	}
+	if g.client == nil {
+		g.client = &http.Client{Timeout: DefaultGitHubTimeout}
+	}
+	if g.authURL == "" {
+		g.authURL = DefaultGitHubAuthURL
+	}
+	if g.tokenURL == "" {
+		g.tokenURL = DefaultGitHubTokenURL
+	}
+	if g.apiURL == "" {
+		g.apiURL = DefaultGitHubAPIURL
+	}
+	return g
+}
+
+// Name implements Provider
+func (g *GitHub) Name() string {
+	return ProviderGitHub
+}
+
+// AuthCodeURL implements Redirector
+func (g *GitHub) AuthCodeURL(state, redirectURI string) string {
+	query := url.Values{
+		"client_id":    {g.clientID},
+		"redirect_uri": {redirectURI},
+		"state":        {state},
+		"scope":        {"read:user user:email"},
+	}
+	return g.authURL + "?" + query.Encode()
+}
+
+// Authenticate implements Provider, exchanging credentials.Code for a user token and
+// returning the GitHub user it belongs to. Users without a public email are given
+// their primary verified one.
+func (g *GitHub) Authenticate(ctx context.Context, credentials Credentials) (*types.User, error) {
+	if credentials.Code == "" {
+		return nil, fmt.Errorf("%w: code is required", ErrInvalidCredentials)
+	}
+
+	token, err := g.exchange(ctx, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := g.get(ctx, token, "/user", &profile); err != nil {
+		return nil, err
+	}
+	if profile.ID == 0 || profile.Login == "" {
+		return nil, errors.New("github returned a user without an id")
+	}
+
+	email := profile.Email
+	if email == "" {
+		if email, err = g.primaryEmail(ctx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	displayName := profile.Name
+	if displayName == "" {
+		displayName = profile.Login
+	}
+	return newUser(ProviderGitHub, strconv.FormatInt(profile.ID, 10), profile.Login, email, displayName, profile.AvatarURL), nil
+}
+
+// exchange trades an authorization code for a user access token
+func (g *GitHub) exchange(ctx context.Context, credentials Credentials) (string, error) {
+	form := url.Values{
+		"client_id":     {g.clientID},
+		"client_secret": {g.clientSecret},
+		"code":          {credentials.Code},
+	}
+	if credentials.RedirectURI != "" {
+		form.Set("redirect_uri", credentials.RedirectURI)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create github token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := g.do(req, &result); err != nil {
+		return "", fmt.Errorf("failed to exchange github code: %w", err)
+	}
+	// GitHub reports a bad or expired code with a 200 and an error field
+	if result.Error != "" {
+		return "", fmt.Errorf("%w: github: %s", ErrInvalidCredentials, result.Error)
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("github returned no access token")
+	}
+	return result.AccessToken, nil
+}
+
+// primaryEmail returns the user's primary verified email address
+func (g *GitHub) primaryEmail(ctx context.Context, token string) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.get(ctx, token, "/user/emails", &emails); err != nil {
+		return "", err
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, nil
+		}
+	}
+	return "", fmt.Errorf("%w: github account has no verified primary email", ErrInvalidCredentials)
+}
+
+// get reads a GitHub API resource with a user token
+func (g *GitHub) get(ctx context.Context, token, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create github request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if err := g.do(req, v); err != nil {
+		return fmt.Errorf("failed to get github %s: %w", path, err)
+	}
+	return nil
+}
+
+// do sends req and decodes a successful JSON response into v
+func (g *GitHub) do(req *http.Request, v interface{}) error {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHub serves the token exchange and user endpoints for code "good-code",
+// returning profile from /user
+func fakeGitHub(t *testing.T, profile map[string]interface{}) *GitHub {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client-id", r.PostForm.Get("client_id"))
+		assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+		if r.PostForm.Get("code") != "good-code" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "user-token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer user-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(profile)
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": true},
+		})
+	})
+	backend := httptest.NewServer(mux)
+	t.Cleanup(backend.Close)
+
+	return NewGitHub("client-id", "client-secret", GitHubOptions{
+		AuthURL:  backend.URL + "/login/oauth/authorize",
+		TokenURL: backend.URL + "/login/oauth/access_token",
+		APIURL:   backend.URL,
+	})
+}
+
+func TestGitHub_Authenticate(t *testing.T) {
+	t.Run("a code signs in the user it was issued to", func(t *testing.T) {
+		github := fakeGitHub(t, map[string]interface{}{"id": 42, "login": "octocat", "name": "Mona", "email": "mona@example.com", "avatar_url": "https://avatars.example.com/42"})
+
+		user, err := github.Authenticate(context.Background(), Credentials{Code: "good-code"})
+		require.NoError(t, err)
+		assert.Equal(t, "mona@example.com", user.Spec.Email)
+		assert.Equal(t, "Mona", user.Spec.DisplayName)
+		assert.Equal(t, "https://avatars.example.com/42", user.Spec.AvatarURL)
+		assert.Equal(t, ProviderGitHub, user.Spec.Provider.Type)
+		assert.Equal(t, "42", user.Spec.Provider.ProviderID)
+		assert.Equal(t, "octocat", user.Spec.Provider.Username)
+	})
+
+	t.Run("users without a public email get their primary verified one", func(t *testing.T) {
+		github := fakeGitHub(t, map[string]interface{}{"id": 42, "login": "octocat"})
+
+		user, err := github.Authenticate(context.Background(), Credentials{Code: "good-code"})
+		require.NoError(t, err)
+		assert.Equal(t, "octo@example.com", user.Spec.Email)
+		assert.Equal(t, "octocat", user.Spec.DisplayName)
+	})
+
+	t.Run("bad and missing codes are invalid credentials", func(t *testing.T) {
+		github := fakeGitHub(t, map[string]interface{}{"id": 42, "login": "octocat"})
+
+		_, err := github.Authenticate(context.Background(), Credentials{Code: "bad-code"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = github.Authenticate(context.Background(), Credentials{})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
+
+func TestGitHub_AuthCodeURL(t *testing.T) {
+	github := NewGitHub("client-id", "client-secret", GitHubOptions{})
+
+	authURL, err := url.Parse(github.AuthCodeURL("the-state", "https://idp.example.com/cb"))
+	require.NoError(t, err)
+	assert.Equal(t, "github.com", authURL.Host)
+	assert.Equal(t, "client-id", authURL.Query().Get("client_id"))
+	assert.Equal(t, "the-state", authURL.Query().Get("state"))
+	assert.Equal(t, "https://idp.example.com/cb", authURL.Query().Get("redirect_uri"))
+	assert.NotContains(t, authURL.String(), "client-secret")
+}
@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Handlers provides the login endpoints, which sign users in through the configured
+// providers and issue access tokens
+type Handlers struct {
+	issuer      *Issuer
+	memberships Memberships
+	logger      *logger.Logger
+	responder   *server.Responder
+
+	providers map[string]Provider
+	stateTTL  time.Duration
+	refresh   RefreshTokens
+}
+
+// HandlerOption configures Handlers
+type HandlerOption func(*Handlers)
+
+// WithResponseEnvelope wraps every response in the types.APIResponse envelope
+func WithResponseEnvelope(enabled bool) HandlerOption {
+	return func(h *Handlers) {
+		h.responder = server.NewResponder(enabled)
+	}
+}
+
+// WithProvider lets users sign in through provider, replacing any provider of the
+// same name
+func WithProvider(provider Provider) HandlerOption {
+	return func(h *Handlers) {
+		h.providers[provider.Name()] = provider
+	}
+}
+
+// WithRefreshTokens issues a refresh token with every access token, enabling Refresh
+// and Logout
+func WithRefreshTokens(tokens RefreshTokens) HandlerOption {
+	return func(h *Handlers) {
+		h.refresh = tokens
+	}
+}
+
+// NewHandlers creates login handlers issuing tokens with issuer, for the tenants
+// memberships finds users in
+func NewHandlers(issuer *Issuer, memberships Memberships, appLogger *logger.Logger, opts ...HandlerOption) *Handlers {
+	h := &Handlers{
+		issuer:      issuer,
+		memberships: memberships,
+		logger:      appLogger,
+		responder:   server.NewResponder(false),
+
+		providers: make(map[string]Provider),
+		stateTTL:  DefaultStateTTL,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// LoginRequest is the body of a login. Credentials are checked by the provider, so
+// which fields are needed depends on it.
+type LoginRequest struct {
+	Provider string `json:"provider"`
+	Credentials
+}
+
+// TokenResponse is a successful login: the access token and the signed-in user. The
+// user is left out of refreshes.
+type TokenResponse struct {
+	Token
+	User *types.User `json:"user,omitempty"`
+}
+
+// RefreshRequest is the body of a refresh or logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthorizationResponse starts a login on a provider's site. The client sends the
+// user to AuthorizationURL, and the provider redirects back to the callback with a
+// code and State.
+type AuthorizationResponse struct {
+	Provider         string `json:"provider"`
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// Login handles POST /api/v1/auth/login. Providers that check credentials directly
+// answer with a token; providers that sign users in on their own site answer with the
+// URL to send the user to, and the token is issued by Callback.
+func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := server.DecodeJSON(r.Body, &req); err != nil {
+		h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		return
+	}
+
+	provider, ok := h.providers[req.Provider]
+	if !ok {
+		h.writeError(w, r, "Unknown auth provider: "+req.Provider, http.StatusBadRequest, "UNKNOWN_PROVIDER")
+		return
+	}
+
+	redirector, ok := provider.(Redirector)
+	if !ok {
+		h.authenticate(w, r, provider, req.Credentials)
+		return
+	}
+
+	if req.RedirectURI == "" {
+		h.writeError(w, r, "redirect_uri is required for "+provider.Name(), http.StatusBadRequest, "VALIDATION_ERROR")
+		return
+	}
+	state, err := signState(loginState{
+		Provider:    provider.Name(),
+		RedirectURI: req.RedirectURI,
+		ExpiresAt:   h.issuer.now().Add(h.stateTTL).Unix(),
+	}, h.issuer.secret)
+	if err != nil {
+		h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to create login state")
+
+		h.writeError(w, r, "Failed to start login", http.StatusInternalServerError, "LOGIN_FAILED")
+		return
+	}
+
+	response := AuthorizationResponse{
+		Provider:         provider.Name(),
+		AuthorizationURL: redirector.AuthCodeURL(state, req.RedirectURI),
+		State:            state,
+	}
+	if err := h.responder.JSON(w, r, http.StatusOK, response); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode authorization response")
+	}
+}
+
+// Callback handles GET /api/v1/auth/callback, where a provider redirects to with the
+// code and state of a login started by Login
+func (h *Handlers) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		h.writeError(w, r, "Login was not completed: "+providerErr, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+		return
+	}
+
+	state, err := parseState(query.Get("state"), h.issuer.secret, h.issuer.now())
+	if err != nil {
+		h.writeError(w, r, "Login state is invalid or has expired", http.StatusBadRequest, "INVALID_STATE")
+		return
+	}
+	provider, ok := h.providers[state.Provider]
+	if !ok {
+		h.writeError(w, r, "Unknown auth provider: "+state.Provider, http.StatusBadRequest, "UNKNOWN_PROVIDER")
+		return
+	}
+
+	h.authenticate(w, r, provider, Credentials{Code: query.Get("code"), RedirectURI: state.RedirectURI})
+}
+
+// authenticate signs the user in with provider and writes their token. The token is
+// issued for the tenant the request names, or else the user's only tenant, and users
+// of no tenant are refused.
+func (h *Handlers) authenticate(w http.ResponseWriter, r *http.Request, provider Provider, credentials Credentials) {
+	user, err := provider.Authenticate(r.Context(), credentials)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			h.writeError(w, r, "Invalid credentials", http.StatusUnauthorized, "INVALID_CREDENTIALS")
+			return
+		}
+
+		h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"provider":        provider.Name(),
+		}).Error("Failed to authenticate with provider")
+
+		h.writeError(w, r, "Failed to authenticate with "+provider.Name(), http.StatusBadGateway, "PROVIDER_ERROR")
+		return
+	}
+
+	tenantIDs, err := h.memberships.TenantIDs(r.Context(), user.Spec.Email)
+	if err != nil {
+		h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"provider":        provider.Name(),
+		}).Error("Failed to get tenant memberships")
+
+		h.writeError(w, r, "Failed to issue token", http.StatusInternalServerError, "LOGIN_FAILED")
+		return
+	}
+	tenantID, err := sessionTenant(types.ResolvedTenantIDFromContext(r.Context()), tenantIDs)
+	if err != nil {
+		if errors.Is(err, ErrTenantRequired) {
+			h.writeError(w, r, "User belongs to several tenants; name the tenant to sign in to", http.StatusBadRequest, "TENANT_REQUIRED")
+			return
+		}
+		h.writeError(w, r, "User is not a member of the tenant", http.StatusForbidden, "NO_TENANT")
+		return
+	}
+
+	token, err := h.issuer.Issue(user, tenantID.String())
+	if err == nil && h.refresh != nil {
+		token.RefreshToken, err = h.refresh.Issue(r.Context(), Session{Subject: user.Spec.Email, TenantID: tenantID.String()})
+	}
+	if err != nil {
+		h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"provider":        provider.Name(),
+		}).Error("Failed to issue token")
+
+		h.writeError(w, r, "Failed to issue token", http.StatusInternalServerError, "LOGIN_FAILED")
+		return
+	}
+
+	h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+		"provider":           provider.Name(),
+		"user":               user.Spec.Email,
+		logger.FieldTenantID: tenantID.String(),
+	}).Info("User signed in")
+
+	if err := h.responder.JSON(w, r, http.StatusOK, TokenResponse{Token: *token, User: user}); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode token response")
+	}
+}
+
+// Refresh handles POST /api/v1/auth/refresh, exchanging a refresh token for a new
+// access token and a new refresh token. The old refresh token stops working; if it is
+// presented again, every token descended from the same login is revoked.
+func (h *Handlers) Refresh(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRefresh(w, r)
+	if !ok {
+		return
+	}
+
+	next, session, err := h.refresh.Rotate(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRefreshTokenReused):
+			h.logger.WithContext(r.Context()).Warn("Rotated refresh token reused; revoked its session")
+			h.writeError(w, r, "Refresh token was already used; the session has been revoked", http.StatusUnauthorized, "REFRESH_TOKEN_REUSED")
+		case errors.Is(err, ErrInvalidRefreshToken):
+			h.writeError(w, r, "Refresh token is invalid or has expired", http.StatusUnauthorized, "INVALID_REFRESH_TOKEN")
+		default:
+			h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+				logger.FieldError: err.Error(),
+			}).Error("Failed to rotate refresh token")
+
+			h.writeError(w, r, "Failed to refresh token", http.StatusInternalServerError, "REFRESH_FAILED")
+		}
+		return
+	}
+
+	token, err := h.issuer.issue(*session)
+	if err != nil {
+		h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to issue token")
+
+		h.writeError(w, r, "Failed to refresh token", http.StatusInternalServerError, "REFRESH_FAILED")
+		return
+	}
+	token.RefreshToken = next
+
+	if err := h.responder.JSON(w, r, http.StatusOK, TokenResponse{Token: *token}); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode token response")
+	}
+}
+
+// Logout handles POST /api/v1/auth/logout, revoking a refresh token and every token
+// descended from the same login. Access tokens already issued stay valid until they
+// expire.
+func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRefresh(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.refresh.Revoke(r.Context(), req.RefreshToken); err != nil {
+		h.logger.WithContext(r.Context()).WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to revoke refresh token")
+
+		h.writeError(w, r, "Failed to log out", http.StatusInternalServerError, "LOGOUT_FAILED")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRefresh reads the refresh token from a refresh or logout body, writing an
+// error and returning false when there is none
+func (h *Handlers) decodeRefresh(w http.ResponseWriter, r *http.Request) (RefreshRequest, bool) {
+	var req RefreshRequest
+	if h.refresh == nil {
+		h.writeError(w, r, "Refresh tokens are not enabled", http.StatusNotFound, "NOT_FOUND")
+		return req, false
+	}
+	if err := server.DecodeJSON(r.Body, &req); err != nil {
+		h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		return req, false
+	}
+	if req.RefreshToken == "" {
+		h.writeError(w, r, "refresh_token is required", http.StatusBadRequest, "VALIDATION_ERROR")
+		return req, false
+	}
+	return req, true
+}
+
+// writeError writes an error response
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, message string, statusCode int, code string) {
+	response := server.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    code,
+		Time:    time.Now().UTC(),
+	}
+
+	apiErr := &types.APIError{Code: code, Message: message}
+	if err := h.responder.Error(w, r, statusCode, apiErr, response); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode error response")
+	}
+}
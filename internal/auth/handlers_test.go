@@ -0,0 +1,341 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider signs in credentials whose Code or Email is "good", and fails with
+// err when it is set
+type fakeProvider struct {
+	err  error
+	seen Credentials
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) Authenticate(ctx context.Context, credentials Credentials) (*types.User, error) {
+	p.seen = credentials
+	if p.err != nil {
+		return nil, p.err
+	}
+	if credentials.Code != "good" && credentials.Email != "good" {
+		return nil, ErrInvalidCredentials
+	}
+	return newUser("fake", "1", "fake-user", "fake@example.com", "Fake", ""), nil
+}
+
+// redirectingProvider is a fakeProvider that signs users in on its own site
+type redirectingProvider struct {
+	fakeProvider
+}
+
+func (p *redirectingProvider) AuthCodeURL(state, redirectURI string) string {
+	return "https://provider.example.com/authorize?" + url.Values{"state": {state}, "redirect_uri": {redirectURI}}.Encode()
+}
+
+// testTenantID is the only tenant of the fake user, unless a test says otherwise
+var testTenantID = uuid.MustParse("7d3c0a52-1f0e-4b8e-9a4c-2b1f6a0e5d11")
+
+// staticMemberships puts every user in the same tenants
+type staticMemberships []uuid.UUID
+
+func (m staticMemberships) TenantIDs(ctx context.Context, email string) ([]uuid.UUID, error) {
+	return m, nil
+}
+
+func newTestHandlers(provider Provider) *Handlers {
+	return NewHandlers(NewIssuer(testSecret, time.Hour), staticMemberships{testTenantID}, logger.New("debug", "text"), WithProvider(provider))
+}
+
+func login(h *Handlers, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.Login(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body)))
+	return rr
+}
+
+// loginTo is login with tenantID resolved for the request, as server.TenantResolver
+// does
+func loginTo(h *Handlers, tenantID uuid.UUID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), types.ResolvedTenantIDKey, tenantID))
+	rr := httptest.NewRecorder()
+	h.Login(rr, req)
+	return rr
+}
+
+func callback(h *Handlers, query url.Values) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.Callback(rr, httptest.NewRequest(http.MethodGet, "/api/v1/auth/callback?"+query.Encode(), nil))
+	return rr
+}
+
+// assertToken checks rr holds a token that JWTAuth accepts for the fake user in
+// testTenantID
+func assertToken(t *testing.T, rr *httptest.ResponseRecorder) {
+	t.Helper()
+	assertTenantToken(t, rr, testTenantID)
+}
+
+// assertTenantToken checks rr holds a token that JWTAuth accepts for the fake user in
+// tenantID
+func assertTenantToken(t *testing.T, rr *httptest.ResponseRecorder, tenantID uuid.UUID) {
+	t.Helper()
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, TokenTypeBearer, response.TokenType)
+	assert.Equal(t, "fake@example.com", response.User.Spec.Email)
+
+	claims, err := middleware.ParseJWT(response.AccessToken, testSecret, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "fake@example.com", claims.Subject)
+	assert.Equal(t, tenantID.String(), claims.TenantID)
+}
+
+func assertErrorCode(t *testing.T, rr *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	require.Equal(t, status, rr.Code, rr.Body.String())
+
+	var response server.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, code, response.Code)
+}
+
+func TestHandlers_Login(t *testing.T) {
+	t.Run("valid credentials get a token", func(t *testing.T) {
+		provider := &fakeProvider{}
+		rr := login(newTestHandlers(provider), `{"provider":"fake","email":"good"}`)
+
+		assertToken(t, rr)
+		assert.Equal(t, "good", provider.seen.Email)
+	})
+
+	t.Run("invalid credentials are unauthorized", func(t *testing.T) {
+		rr := login(newTestHandlers(&fakeProvider{}), `{"provider":"fake","email":"bad"}`)
+		assertErrorCode(t, rr, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+	})
+
+	t.Run("provider failures are a bad gateway", func(t *testing.T) {
+		rr := login(newTestHandlers(&fakeProvider{err: errors.New("provider down")}), `{"provider":"fake","email":"good"}`)
+		assertErrorCode(t, rr, http.StatusBadGateway, "PROVIDER_ERROR")
+	})
+
+	t.Run("unknown providers and bodies are rejected", func(t *testing.T) {
+		h := newTestHandlers(&fakeProvider{})
+		assertErrorCode(t, login(h, `{"provider":"other","email":"good"}`), http.StatusBadRequest, "UNKNOWN_PROVIDER")
+		assertErrorCode(t, login(h, `{"provider":"fake","pasword":"x"}`), http.StatusBadRequest, "INVALID_JSON")
+		assertErrorCode(t, login(h, `{`), http.StatusBadRequest, "INVALID_JSON")
+	})
+
+	t.Run("redirecting providers need a redirect URI", func(t *testing.T) {
+		rr := login(newTestHandlers(&redirectingProvider{}), `{"provider":"fake"}`)
+		assertErrorCode(t, rr, http.StatusBadRequest, "VALIDATION_ERROR")
+	})
+}
+
+func TestHandlers_LoginTenant(t *testing.T) {
+	other := uuid.MustParse("0b6f3e8a-5c2d-4f71-8e9b-3a4d5c6e7f80")
+	handlers := func(tenantIDs ...uuid.UUID) *Handlers {
+		return NewHandlers(NewIssuer(testSecret, time.Hour), staticMemberships(tenantIDs), logger.New("debug", "text"), WithProvider(&fakeProvider{}))
+	}
+	body := `{"provider":"fake","email":"good"}`
+
+	t.Run("users of no tenant are refused", func(t *testing.T) {
+		assertErrorCode(t, login(handlers(), body), http.StatusForbidden, "NO_TENANT")
+	})
+
+	t.Run("users of several tenants name one", func(t *testing.T) {
+		h := handlers(testTenantID, other)
+		assertErrorCode(t, login(h, body), http.StatusBadRequest, "TENANT_REQUIRED")
+		assertTenantToken(t, loginTo(h, other, body), other)
+	})
+
+	t.Run("the named tenant must be one of the user's", func(t *testing.T) {
+		assertErrorCode(t, loginTo(handlers(testTenantID), other, body), http.StatusForbidden, "NO_TENANT")
+	})
+}
+
+func TestHandlers_RedirectLogin(t *testing.T) {
+	provider := &redirectingProvider{}
+	h := newTestHandlers(provider)
+
+	rr := login(h, `{"provider":"fake","redirect_uri":"https://idp.example.com/cb"}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var started AuthorizationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &started))
+	assert.Equal(t, "fake", started.Provider)
+	assert.NotEmpty(t, started.State)
+	authURL, err := url.Parse(started.AuthorizationURL)
+	require.NoError(t, err)
+	assert.Equal(t, started.State, authURL.Query().Get("state"))
+
+	t.Run("the callback exchanges the code for a token", func(t *testing.T) {
+		assertToken(t, callback(h, url.Values{"code": {"good"}, "state": {started.State}}))
+		assert.Equal(t, "https://idp.example.com/cb", provider.seen.RedirectURI)
+	})
+
+	t.Run("bad codes are unauthorized", func(t *testing.T) {
+		rr := callback(h, url.Values{"code": {"bad"}, "state": {started.State}})
+		assertErrorCode(t, rr, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+	})
+
+	t.Run("tampered and missing states are rejected", func(t *testing.T) {
+		assertErrorCode(t, callback(h, url.Values{"code": {"good"}, "state": {started.State + "x"}}), http.StatusBadRequest, "INVALID_STATE")
+		assertErrorCode(t, callback(h, url.Values{"code": {"good"}}), http.StatusBadRequest, "INVALID_STATE")
+	})
+
+	t.Run("expired states are rejected", func(t *testing.T) {
+		h.issuer.now = func() time.Time { return time.Now().Add(DefaultStateTTL + time.Minute) }
+		defer func() { h.issuer.now = time.Now }()
+
+		assertErrorCode(t, callback(h, url.Values{"code": {"good"}, "state": {started.State}}), http.StatusBadRequest, "INVALID_STATE")
+	})
+
+	t.Run("provider errors are unauthorized", func(t *testing.T) {
+		rr := callback(h, url.Values{"error": {"access_denied"}, "state": {started.State}})
+		assertErrorCode(t, rr, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+	})
+}
+
+// memoryRefreshTokens is a RefreshTokens keeping tokens in memory. Each token is its
+// family name and a suffix that grows with every rotation.
+type memoryRefreshTokens struct {
+	sessions map[string]Session
+	rotated  map[string]bool
+	revoked  map[string]bool
+	families int
+}
+
+func newMemoryRefreshTokens() *memoryRefreshTokens {
+	return &memoryRefreshTokens{sessions: map[string]Session{}, rotated: map[string]bool{}, revoked: map[string]bool{}}
+}
+
+func (m *memoryRefreshTokens) Issue(ctx context.Context, session Session) (string, error) {
+	m.families++
+	token := fmt.Sprintf("family%d.0", m.families)
+	m.sessions[token] = session
+	return token, nil
+}
+
+func (m *memoryRefreshTokens) Rotate(ctx context.Context, token string) (string, *Session, error) {
+	session, ok := m.sessions[token]
+	family, generation, _ := strings.Cut(token, ".")
+	switch {
+	case !ok || m.revoked[family]:
+		return "", nil, ErrInvalidRefreshToken
+	case m.rotated[token]:
+		m.revoked[family] = true
+		return "", nil, ErrRefreshTokenReused
+	}
+	m.rotated[token] = true
+	next := family + "." + generation + "1"
+	m.sessions[next] = session
+	return next, &session, nil
+}
+
+func (m *memoryRefreshTokens) Revoke(ctx context.Context, token string) error {
+	if _, ok := m.sessions[token]; ok {
+		family, _, _ := strings.Cut(token, ".")
+		m.revoked[family] = true
+	}
+	return nil
+}
+
+func refresh(h *Handlers, path, token string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"refresh_token":"`+token+`"}`))
+	if path == "/api/v1/auth/logout" {
+		h.Logout(rr, req)
+	} else {
+		h.Refresh(rr, req)
+	}
+	return rr
+}
+
+func TestHandlers_Refresh(t *testing.T) {
+	newHandlers := func() *Handlers {
+		return NewHandlers(NewIssuer(testSecret, time.Hour), staticMemberships{testTenantID}, logger.New("debug", "text"),
+			WithProvider(&fakeProvider{}), WithRefreshTokens(newMemoryRefreshTokens()))
+	}
+	signIn := func(t *testing.T, h *Handlers) string {
+		t.Helper()
+		rr := login(h, `{"provider":"fake","email":"good"}`)
+		assertToken(t, rr)
+
+		var response TokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.NotEmpty(t, response.RefreshToken)
+		return response.RefreshToken
+	}
+
+	t.Run("a refresh returns a new access and refresh token", func(t *testing.T) {
+		h := newHandlers()
+		first := signIn(t, h)
+
+		rr := refresh(h, "/api/v1/auth/refresh", first)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response TokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.NotEqual(t, first, response.RefreshToken)
+		assert.Nil(t, response.User)
+
+		claims, err := middleware.ParseJWT(response.AccessToken, testSecret, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, "fake@example.com", claims.Subject)
+		assert.Equal(t, testTenantID.String(), claims.TenantID, "the token keeps the tenant signed in to")
+	})
+
+	t.Run("reusing a rotated token revokes the session", func(t *testing.T) {
+		h := newHandlers()
+		first := signIn(t, h)
+
+		rr := refresh(h, "/api/v1/auth/refresh", first)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response TokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+		assertErrorCode(t, refresh(h, "/api/v1/auth/refresh", first), http.StatusUnauthorized, "REFRESH_TOKEN_REUSED")
+		assertErrorCode(t, refresh(h, "/api/v1/auth/refresh", response.RefreshToken), http.StatusUnauthorized, "INVALID_REFRESH_TOKEN")
+	})
+
+	t.Run("logout revokes the refresh token", func(t *testing.T) {
+		h := newHandlers()
+		token := signIn(t, h)
+
+		rr := refresh(h, "/api/v1/auth/logout", token)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assertErrorCode(t, refresh(h, "/api/v1/auth/refresh", token), http.StatusUnauthorized, "INVALID_REFRESH_TOKEN")
+	})
+
+	t.Run("a refresh token is required", func(t *testing.T) {
+		assertErrorCode(t, refresh(newHandlers(), "/api/v1/auth/refresh", ""), http.StatusBadRequest, "VALIDATION_ERROR")
+		assertErrorCode(t, refresh(newTestHandlers(&fakeProvider{}), "/api/v1/auth/refresh", "x"), http.StatusNotFound, "NOT_FOUND")
+	})
+
+	t.Run("logins without refresh tokens get none", func(t *testing.T) {
+		rr := login(newTestHandlers(&fakeProvider{}), `{"provider":"fake","email":"good"}`)
+		assertToken(t, rr)
+		assert.NotContains(t, rr.Body.String(), "refresh_token")
+	})
+}
@@ -0,0 +1,64 @@
+// Package auth signs users in through identity providers and issues the JWTs that
+// middleware.JWTAuth accepts.
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Common errors
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnknownProvider    = errors.New("unknown auth provider")
+	ErrInvalidState       = errors.New("invalid or expired login state")
+)
+
+// Credentials are what a provider checks to sign a user in. OAuth providers read Code
+// and RedirectURI; the local provider reads Email and DisplayName.
+type Credentials struct {
+	// Code is the authorization code the provider redirected back with
+	Code string `json:"code,omitempty"`
+	// RedirectURI is the callback the code was issued for
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	Email       string `json:"email,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// Provider signs users in against an identity provider
+type Provider interface {
+	// Name identifies the provider in login requests, e.g. "github"
+	Name() string
+	// Authenticate checks credentials and returns the user they belong to, or an error
+	// wrapping ErrInvalidCredentials when they are rejected
+	Authenticate(ctx context.Context, credentials Credentials) (*types.User, error)
+}
+
+// Redirector is implemented by providers that sign users in on their own site and
+// redirect back to the callback with a code
+type Redirector interface {
+	// AuthCodeURL returns the provider's authorization URL for a login that redirects
+	// back to redirectURI with state
+	AuthCodeURL(state, redirectURI string) string
+}
+
+// newUser returns a user signed in through provider
+func newUser(provider, providerID, username, email, displayName, avatarURL string) *types.User {
+	return &types.User{
+		TypeMeta: types.TypeMeta{APIVersion: "v1", Kind: "User"},
+		Metadata: types.ObjectMeta{Name: username},
+		Spec: types.UserSpec{
+			Email:       email,
+			DisplayName: displayName,
+			AvatarURL:   avatarURL,
+			Provider: types.AuthProvider{
+				Type:       provider,
+				ProviderID: providerID,
+				Username:   username,
+			},
+		},
+		Status: types.UserStatus{Active: true},
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultRefreshTokenTTL is how long a refresh token is valid when the service is
+// given no TTL
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// Refresh token errors
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned for a token that was already rotated. Only a
+	// copy of the token can be presented twice, so its whole family has been revoked.
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+)
+
+// Session is who a refresh token signs in
+type Session struct {
+	Subject  string
+	TenantID string
+}
+
+// RefreshTokens issues, rotates and revokes refresh tokens
+type RefreshTokens interface {
+	// Issue returns a refresh token for session, starting a new family
+	Issue(ctx context.Context, session Session) (string, error)
+	// Rotate exchanges token for its replacement and returns the session it signs in
+	Rotate(ctx context.Context, token string) (string, *Session, error)
+	// Revoke revokes token's whole family; unknown tokens are ignored
+	Revoke(ctx context.Context, token string) error
+}
+
+// RefreshService stores refresh tokens in the database, as SHA-256 hashes so a copy of
+// the table signs no one in. Each rotation adds a token to the family of the one it
+// replaces.
+type RefreshService struct {
+	pool *database.Pool
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// Compile-time check that RefreshService implements RefreshTokens
+var _ RefreshTokens = (*RefreshService)(nil)
+
+// NewRefreshService creates a refresh token service issuing tokens valid for ttl, or
+// DefaultRefreshTokenTTL when ttl is not positive
+func NewRefreshService(pool *database.Pool, ttl time.Duration) *RefreshService {
+	if ttl <= 0 {
+		ttl = DefaultRefreshTokenTTL
+	}
+	return &RefreshService{pool: pool, ttl: ttl, now: time.Now}
+}
+
+// Issue implements RefreshTokens
+func (s *RefreshService) Issue(ctx context.Context, session Session) (string, error) {
+	return s.insert(ctx, s.pool, uuid.New(), session, s.now().UTC())
+}
+
+// Rotate implements RefreshTokens. The token is marked rotated and replaced by a new
+// one in its family. Presenting a rotated token again revokes the family and returns
+// ErrRefreshTokenReused; unknown, revoked and expired tokens are ErrInvalidRefreshToken.
+func (s *RefreshService) Rotate(ctx context.Context, token string) (string, *Session, error) {
+	now := s.now().UTC()
+	var next string
+	var session Session
+	reused := false
+
+	err := s.pool.WithTransaction(ctx, func(tx *database.Transaction) error {
+		var id, familyID uuid.UUID
+		var expiresAt time.Time
+		var rotatedAt, revokedAt *time.Time
+		query := `
+			SELECT id, family_id, subject, COALESCE(tenant_id::text, ''), expires_at, rotated_at, revoked_at
+			FROM user_management.refresh_tokens
+			WHERE token_hash = $1
+			FOR UPDATE
+		`
+		err := tx.QueryRow(ctx, query, hashRefreshToken(token)).Scan(
+			&id, &familyID, &session.Subject, &session.TenantID, &expiresAt, &rotatedAt, &revokedAt,
+		)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidRefreshToken
+			}
+			return fmt.Errorf("failed to get refresh token: %w", err)
+		}
+
+		if revokedAt != nil {
+			return ErrInvalidRefreshToken
+		}
+		if rotatedAt != nil {
+			// Committed, not rolled back: the family must stay revoked
+			reused = true
+			return revokeFamily(ctx, tx, familyID, now)
+		}
+		if !now.Before(expiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		update := `UPDATE user_management.refresh_tokens SET rotated_at = $2 WHERE id = $1`
+		if _, err := tx.Exec(ctx, update, id, now); err != nil {
+			return fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+		next, err = s.insert(ctx, tx, familyID, session, now)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if reused {
+		return "", nil, ErrRefreshTokenReused
+	}
+	return next, &session, nil
+}
+
+// Revoke implements RefreshTokens
+func (s *RefreshService) Revoke(ctx context.Context, token string) error {
+	query := `
+		UPDATE user_management.refresh_tokens SET revoked_at = $2
+		WHERE revoked_at IS NULL AND family_id = (
+			SELECT family_id FROM user_management.refresh_tokens WHERE token_hash = $1
+		)
+	`
+	if _, err := s.pool.Exec(ctx, query, hashRefreshToken(token), s.now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// insert stores a new token in family and returns it
+func (s *RefreshService) insert(ctx context.Context, q database.Querier, familyID uuid.UUID, session Session, now time.Time) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	query := `
+		INSERT INTO user_management.refresh_tokens (token_hash, family_id, subject, tenant_id, created_at, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6)
+	`
+	if _, err := q.Exec(ctx, query, hashRefreshToken(token), familyID, session.Subject, session.TenantID, now, now.Add(s.ttl)); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// revokeFamily revokes every token of a family that is not revoked yet
+func revokeFamily(ctx context.Context, q database.Querier, familyID uuid.UUID, now time.Time) error {
+	query := `UPDATE user_management.refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+	if _, err := q.Exec(ctx, query, familyID, now); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+// hashRefreshToken is the stored form of a refresh token
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewRefreshService(pool, time.Hour)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	session := Session{Subject: "dev@example.com", TenantID: tenant.ID.String()}
+
+	t.Run("a refresh rotates the token", func(t *testing.T) {
+		token, err := service.Issue(ctx, session)
+		require.NoError(t, err)
+
+		next, refreshed, err := service.Rotate(ctx, token)
+		require.NoError(t, err)
+		assert.NotEqual(t, token, next)
+		assert.Equal(t, session, *refreshed)
+		assert.Equal(t, tenant.ID.String(), refreshed.TenantID, "the stored tenant is returned")
+
+		var storedTenant uuid.UUID
+		require.NoError(t, pool.QueryRow(ctx, `SELECT tenant_id FROM user_management.refresh_tokens WHERE token_hash = $1`, hashRefreshToken(next)).Scan(&storedTenant))
+		assert.Equal(t, tenant.ID, storedTenant, "the replacement keeps the tenant")
+
+		var stored int
+		require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM user_management.refresh_tokens WHERE token_hash IN ($1, $2)`, token, next).Scan(&stored))
+		assert.Zero(t, stored, "only hashes are stored")
+
+		_, _, err = service.Rotate(ctx, next)
+		require.NoError(t, err, "the replacement can be refreshed in turn")
+	})
+
+	t.Run("reusing a rotated token revokes the family", func(t *testing.T) {
+		token, err := service.Issue(ctx, session)
+		require.NoError(t, err)
+		next, _, err := service.Rotate(ctx, token)
+		require.NoError(t, err)
+
+		_, _, err = service.Rotate(ctx, token)
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+		_, _, err = service.Rotate(ctx, next)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken, "the legitimate holder is signed out too")
+	})
+
+	t.Run("logout revokes the token and its family", func(t *testing.T) {
+		token, err := service.Issue(ctx, session)
+		require.NoError(t, err)
+		next, _, err := service.Rotate(ctx, token)
+		require.NoError(t, err)
+
+		require.NoError(t, service.Revoke(ctx, next))
+
+		_, _, err = service.Rotate(ctx, next)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		_, _, err = service.Rotate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+		assert.NoError(t, service.Revoke(ctx, "unknown"), "unknown tokens are ignored")
+	})
+
+	t.Run("expired and unknown tokens are invalid", func(t *testing.T) {
+		expiring := NewRefreshService(pool, time.Hour)
+		expiring.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+		token, err := expiring.Issue(ctx, Session{Subject: "dev@example.com"})
+		require.NoError(t, err)
+
+		_, _, err = service.Rotate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+		_, _, err = service.Rotate(ctx, "unknown")
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+}
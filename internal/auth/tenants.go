@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/google/uuid"
+)
+
+// Tenant errors
+var (
+	// ErrNoTenant is returned when a user belongs to no tenant, or not to the tenant
+	// the login request names
+	ErrNoTenant = errors.New("user belongs to no tenant")
+	// ErrTenantRequired is returned when a user belongs to several tenants and the
+	// login request names none of them
+	ErrTenantRequired = errors.New("user belongs to several tenants; name one to sign in to")
+)
+
+// Memberships finds the tenants users belong to, which tokens are issued for
+type Memberships interface {
+	// TenantIDs returns the tenants the user with email belongs to
+	TenantIDs(ctx context.Context, email string) ([]uuid.UUID, error)
+}
+
+// MembershipService finds memberships in the database: the active tenant
+// memberships of the user's login account, and the tenants holding a profile of the
+// user
+type MembershipService struct {
+	pool *database.Pool
+}
+
+// Compile-time check that MembershipService implements Memberships
+var _ Memberships = (*MembershipService)(nil)
+
+// NewMembershipService creates a membership service reading from pool
+func NewMembershipService(pool *database.Pool) *MembershipService {
+	return &MembershipService{pool: pool}
+}
+
+// TenantIDs implements Memberships
+func (s *MembershipService) TenantIDs(ctx context.Context, email string) ([]uuid.UUID, error) {
+	query := `
+		SELECT m.tenant_id
+		FROM user_management.tenant_memberships m
+		JOIN user_management.users u ON u.id = m.user_id
+		WHERE u.email = $1 AND u.is_active AND m.is_active
+		UNION
+		SELECT tenant_id FROM resource_management.users WHERE email = $1
+		ORDER BY 1
+	`
+	rows, err := s.pool.Query(ctx, query, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenant memberships: %w", err)
+	}
+	defer rows.Close()
+
+	var tenantIDs []uuid.UUID
+	for rows.Next() {
+		var tenantID uuid.UUID
+		if err := rows.Scan(&tenantID); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant membership row: %w", err)
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant membership rows: %w", err)
+	}
+
+	return tenantIDs, nil
+}
+
+// sessionTenant picks the tenant of tenantIDs a user signs in to: requested, when the
+// request names one, or else their only tenant
+func sessionTenant(requested uuid.UUID, tenantIDs []uuid.UUID) (uuid.UUID, error) {
+	switch {
+	case requested != uuid.Nil:
+		if !slices.Contains(tenantIDs, requested) {
+			return uuid.Nil, ErrNoTenant
+		}
+		return requested, nil
+	case len(tenantIDs) == 0:
+		return uuid.Nil, ErrNoTenant
+	case len(tenantIDs) > 1:
+		return uuid.Nil, ErrTenantRequired
+	}
+	return tenantIDs[0], nil
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipService_TenantIDs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewMembershipService(pool)
+	email := fmt.Sprintf("member-%d@example.com", time.Now().UnixNano())
+	profiled := testutils.SetupTestTenant(t, ctx, pool)
+	member := testutils.SetupTestTenant(t, ctx, pool)
+	inactive := testutils.SetupTestTenant(t, ctx, pool)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO resource_management.users (tenant_id, email, display_name, created_by)
+		VALUES ($1, $2, 'Member', 'test')
+	`, profiled.ID, email)
+	require.NoError(t, err)
+
+	var userID uuid.UUID
+	err = pool.QueryRow(ctx, `
+		INSERT INTO user_management.users (email, provider, provider_user_id)
+		VALUES ($1, 'local', $1) RETURNING id
+	`, email).Scan(&userID)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `
+		INSERT INTO user_management.tenant_memberships (user_id, tenant_id, is_active)
+		VALUES ($1, $2, true), ($1, $3, false)
+	`, userID, member.ID, inactive.ID)
+	require.NoError(t, err)
+
+	tenantIDs, err := service.TenantIDs(ctx, email)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{profiled.ID, member.ID}, tenantIDs)
+
+	tenantIDs, err = service.TenantIDs(ctx, "nobody@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, tenantIDs)
+}
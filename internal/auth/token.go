@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// DefaultTokenTTL is how long issued tokens are valid when the issuer is given no TTL
+const DefaultTokenTTL = time.Hour
+
+// TokenTypeBearer is the type of every issued token
+const TokenTypeBearer = "Bearer"
+
+// Token is an access token issued at login
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int64     `json:"expires_in"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// RefreshToken is exchanged at POST /api/v1/auth/refresh for a new token, when
+	// refresh tokens are enabled
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// Issuer signs access tokens for signed-in users with the secret middleware.JWTAuth
+// verifies them with
+type Issuer struct {
+	secret string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewIssuer creates an issuer of tokens valid for ttl, or DefaultTokenTTL when ttl is
+// not positive
+func NewIssuer(secret string, ttl time.Duration) *Issuer {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &Issuer{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// Issue returns an access token signing user in to tenantID. The user's email is the
+// token's subject.
+func (i *Issuer) Issue(user *types.User, tenantID string) (*Token, error) {
+	if user == nil || user.Spec.Email == "" {
+		return nil, errors.New("a user with an email is required")
+	}
+	if tenantID == "" {
+		return nil, errors.New("a tenant is required")
+	}
+	return i.issue(Session{Subject: user.Spec.Email, TenantID: tenantID})
+}
+
+// issue returns an access token for session
+func (i *Issuer) issue(session Session) (*Token, error) {
+	now := i.now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(i.ttl)
+	accessToken, err := middleware.SignJWT(middleware.Claims{
+		Subject:   session.Subject,
+		TenantID:  session.TenantID,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}, i.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return &Token{
+		AccessToken: accessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int64(i.ttl / time.Second),
+		ExpiresAt:   expiresAt,
+	}, nil
+}
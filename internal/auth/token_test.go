@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+func TestIssuer_Issue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	issuer := NewIssuer(testSecret, 30*time.Minute)
+	issuer.now = func() time.Time { return now }
+
+	user := newUser(ProviderLocal, "dev@example.com", "dev", "dev@example.com", "Dev", "")
+	token, err := issuer.Issue(user, "tenant-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, TokenTypeBearer, token.TokenType)
+	assert.Equal(t, int64(1800), token.ExpiresIn)
+	assert.Equal(t, now.Add(30*time.Minute), token.ExpiresAt)
+
+	t.Run("the token is accepted by JWTAuth until it expires", func(t *testing.T) {
+		claims, err := middleware.ParseJWT(token.AccessToken, testSecret, now)
+		require.NoError(t, err)
+		assert.Equal(t, "dev@example.com", claims.Subject)
+		assert.Equal(t, "tenant-1", claims.TenantID)
+		assert.Equal(t, now.Unix(), claims.IssuedAt)
+		assert.Equal(t, token.ExpiresAt.Unix(), claims.ExpiresAt)
+
+		_, err = middleware.ParseJWT(token.AccessToken, testSecret, token.ExpiresAt)
+		assert.ErrorIs(t, err, middleware.ErrExpiredToken)
+	})
+
+	t.Run("the token is signed with the issuer's secret", func(t *testing.T) {
+		_, err := middleware.ParseJWT(token.AccessToken, "other-secret", now)
+		assert.ErrorIs(t, err, middleware.ErrInvalidToken)
+	})
+
+	t.Run("users need an email", func(t *testing.T) {
+		_, err := issuer.Issue(&types.User{}, "tenant-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("tokens need a tenant", func(t *testing.T) {
+		_, err := issuer.Issue(user, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestNewIssuer_DefaultTTL(t *testing.T) {
+	assert.Equal(t, DefaultTokenTTL, NewIssuer(testSecret, 0).ttl)
+}
+
+func TestLoginState(t *testing.T) {
+	now := time.Now()
+	state, err := signState(loginState{Provider: ProviderGitHub, RedirectURI: "https://idp.example.com/cb", ExpiresAt: now.Add(time.Minute).Unix()}, testSecret)
+	require.NoError(t, err)
+
+	t.Run("a signed state round trips", func(t *testing.T) {
+		parsed, err := parseState(state, testSecret, now)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderGitHub, parsed.Provider)
+		assert.Equal(t, "https://idp.example.com/cb", parsed.RedirectURI)
+	})
+
+	t.Run("each state is unique", func(t *testing.T) {
+		again, err := signState(loginState{Provider: ProviderGitHub, ExpiresAt: now.Add(time.Minute).Unix()}, testSecret)
+		require.NoError(t, err)
+		assert.NotEqual(t, state, again)
+	})
+
+	t.Run("tampered, foreign and expired states are rejected", func(t *testing.T) {
+		_, err := parseState(state, "other-secret", now)
+		assert.ErrorIs(t, err, ErrInvalidState)
+
+		_, err = parseState("x"+state, testSecret, now)
+		assert.ErrorIs(t, err, ErrInvalidState)
+
+		_, err = parseState(state, testSecret, now.Add(2*time.Minute))
+		assert.ErrorIs(t, err, ErrInvalidState)
+
+		_, err = parseState("", testSecret, now)
+		assert.ErrorIs(t, err, ErrInvalidState)
+	})
+}
+
+func TestLocal_Authenticate(t *testing.T) {
+	user, err := Local{}.Authenticate(context.Background(), Credentials{Email: "dev@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "dev@example.com", user.Spec.Email)
+	assert.Equal(t, "dev", user.Spec.DisplayName)
+	assert.Equal(t, ProviderLocal, user.Spec.Provider.Type)
+
+	for _, email := range []string{"", "dev", "Dev <dev@example.com>"} {
+		_, err := Local{}.Authenticate(context.Background(), Credentials{Email: email})
+		assert.ErrorIs(t, err, ErrInvalidCredentials, email)
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -18,6 +19,8 @@ type Config struct {
 	MaxIdleTime     time.Duration
 	MaxConnLifetime time.Duration
 	ConnectTimeout  time.Duration
+	// Tracer, if set, observes every query, for example a QueryTracer
+	Tracer pgx.QueryTracer
 }
 
 // DefaultConfig returns a sensible default configuration
@@ -50,6 +53,9 @@ func NewPool(ctx context.Context, config *Config) (*Pool, error) {
 	poolConfig.MinConns = config.MinConnections
 	poolConfig.MaxConnIdleTime = config.MaxIdleTime
 	poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	if config.Tracer != nil {
+		poolConfig.ConnConfig.Tracer = config.Tracer
+	}
 
 	// Connection timeout
 	connectCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
@@ -72,6 +78,85 @@ func NewPool(ctx context.Context, config *Config) (*Pool, error) {
 	}, nil
 }
 
+// maxConnectBackoff caps the wait between startup connection attempts
+const maxConnectBackoff = 30 * time.Second
+
+// ConnectOption configures NewPoolWithRetry
+type ConnectOption func(*connectRetry)
+
+// WithConnectAttemptHook is called after each failed connection attempt with the
+// attempt number, its error and the wait before the next attempt, which is zero after
+// the last one. Services use it to log the attempts.
+func WithConnectAttemptHook(fn func(attempt int, err error, wait time.Duration)) ConnectOption {
+	return func(r *connectRetry) {
+		r.onAttempt = fn
+	}
+}
+
+// connectRetry retries a connection with exponential backoff
+type connectRetry struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	onAttempt   func(attempt int, err error, wait time.Duration)
+
+	// Overridden in tests to simulate a database that comes up late
+	connect func(ctx context.Context) (*Pool, error)
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// NewPoolWithRetry creates a connection pool like NewPool, retrying the connection
+// and ping up to maxAttempts times in total while the database is not yet reachable,
+// as happens when a service starts before Postgres. The wait starts at baseDelay and
+// doubles after each attempt, up to 30s. It gives up early if ctx is done; an invalid
+// URL fails immediately.
+func NewPoolWithRetry(ctx context.Context, config *Config, maxAttempts int, baseDelay time.Duration, opts ...ConnectOption) (*Pool, error) {
+	if _, err := pgxpool.ParseConfig(config.URL); err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	r := &connectRetry{
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		connect: func(ctx context.Context) (*Pool, error) {
+			return NewPool(ctx, config)
+		},
+		sleep: sleepContext,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r.run(ctx)
+}
+
+func (r *connectRetry) run(ctx context.Context) (*Pool, error) {
+	delay := r.baseDelay
+	for attempt := 1; ; attempt++ {
+		pool, err := r.connect(ctx)
+		if err == nil {
+			return pool, nil
+		}
+
+		last := attempt >= r.maxAttempts
+		wait := delay
+		if last {
+			wait = 0
+		}
+		if r.onAttempt != nil {
+			r.onAttempt(attempt, err, wait)
+		}
+		if last {
+			return nil, fmt.Errorf("failed to connect after %d attempts: %w", attempt, err)
+		}
+
+		if sleepErr := r.sleep(ctx, wait); sleepErr != nil {
+			return nil, fmt.Errorf("failed to connect after %d attempts: %w", attempt, err)
+		}
+		if delay *= 2; delay > maxConnectBackoff {
+			delay = maxConnectBackoff
+		}
+	}
+}
+
 // HealthCheck returns database health information
 func (p *Pool) HealthCheck(ctx context.Context) error {
 	// Check if we can ping the database
@@ -192,10 +277,14 @@ func (p *Pool) WithTransaction(ctx context.Context, fn func(*Transaction) error)
 	return tx.Commit(ctx)
 }
 
-// QueryBuilder provides utilities for building dynamic queries
+// QueryBuilder provides utilities for building dynamic queries. The builder numbers
+// placeholders itself: prefer the column-based helpers such as AddOptionalEquals, which
+// cannot get the numbering wrong when conditions are skipped.
 type QueryBuilder struct {
 	query    string
 	args     []interface{}
+	hasWhere bool
+	// ArgIndex is the number of the next placeholder
 	ArgIndex int
 }
 
@@ -208,13 +297,10 @@ func NewQueryBuilder(baseQuery string) *QueryBuilder {
 	}
 }
 
-// AddCondition adds a WHERE condition to the query
+// AddCondition adds a WHERE condition to the query. The condition must refer to its
+// argument as $ArgIndex; AddWhere builds the condition instead.
 func (qb *QueryBuilder) AddCondition(condition string, arg interface{}) *QueryBuilder {
-	if qb.ArgIndex == 1 {
-		qb.query += " WHERE " + condition
-	} else {
-		qb.query += " AND " + condition
-	}
+	qb.where(condition)
 	qb.args = append(qb.args, arg)
 	qb.ArgIndex++
 	return qb
@@ -222,21 +308,133 @@ func (qb *QueryBuilder) AddCondition(condition string, arg interface{}) *QueryBu
 
 // AddOptionalCondition adds a condition only if the argument is not nil/empty
 func (qb *QueryBuilder) AddOptionalCondition(condition string, arg interface{}) *QueryBuilder {
-	if arg == nil {
+	if isEmptyArg(arg) {
+		return qb
+	}
+	return qb.AddCondition(condition, arg)
+}
+
+// AddWhere adds the condition "column operator $n", for example AddWhere("created_at",
+// ">=", since)
+func (qb *QueryBuilder) AddWhere(column, operator string, arg interface{}) *QueryBuilder {
+	return qb.AddCondition(fmt.Sprintf("%s %s $%d", column, operator, qb.ArgIndex), arg)
+}
+
+// AddOptionalWhere adds the condition "column operator $n" only if the argument is not
+// nil/empty
+func (qb *QueryBuilder) AddOptionalWhere(column, operator string, arg interface{}) *QueryBuilder {
+	if isEmptyArg(arg) {
 		return qb
 	}
+	return qb.AddWhere(column, operator, arg)
+}
+
+// AddEquals adds the condition "column = $n"
+func (qb *QueryBuilder) AddEquals(column string, arg interface{}) *QueryBuilder {
+	return qb.AddWhere(column, "=", arg)
+}
+
+// AddOptionalEquals adds the condition "column = $n" only if the argument is not
+// nil/empty
+func (qb *QueryBuilder) AddOptionalEquals(column string, arg interface{}) *QueryBuilder {
+	return qb.AddOptionalWhere(column, "=", arg)
+}
 
-	// Check for empty strings
-	if str, ok := arg.(string); ok && str == "" {
+// AddInCondition adds the condition "column IN ($n, $n+1, ...)" with a placeholder per
+// value. Nothing is added when values is empty.
+func (qb *QueryBuilder) AddInCondition(column string, values []interface{}) *QueryBuilder {
+	if len(values) == 0 {
 		return qb
 	}
 
-	// Check for empty slices
-	if slice, ok := arg.([]string); ok && len(slice) == 0 {
+	placeholders := make([]string, len(values))
+	for i := range values {
+		placeholders[i] = fmt.Sprintf("$%d", qb.ArgIndex+i)
+	}
+	qb.where(fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.args = append(qb.args, values...)
+	qb.ArgIndex += len(values)
+	return qb
+}
+
+// AddRangeCondition restricts column to the half-open range [from, to), as audit
+// queries do for since/until. Either bound may be nil (or a nil *time.Time or zero
+// time.Time) to leave that side open.
+func (qb *QueryBuilder) AddRangeCondition(column string, from, to interface{}) *QueryBuilder {
+	qb.AddOptionalWhere(column, ">=", from)
+	qb.AddOptionalWhere(column, "<", to)
+	return qb
+}
+
+// AddStaticCondition adds a WHERE condition that takes no argument, such as
+// "deleted_at IS NULL"
+func (qb *QueryBuilder) AddStaticCondition(condition string) *QueryBuilder {
+	qb.where(condition)
+	return qb
+}
+
+// AddSearch adds a SearchCondition matching term against columns. Nothing is added
+// when term is empty.
+func (qb *QueryBuilder) AddSearch(term string, columns ...string) *QueryBuilder {
+	if term == "" {
 		return qb
 	}
+	return qb.AddCondition(SearchCondition(qb.ArgIndex, columns...), ContainsPattern(term))
+}
 
-	return qb.AddCondition(condition, arg)
+// AddJSONEquals adds the condition "column ->> $n = $n+1", matching JSONB objects
+// whose top-level key has the value when compared as text
+func (qb *QueryBuilder) AddJSONEquals(column, key string, value interface{}) *QueryBuilder {
+	qb.where(fmt.Sprintf("%s ->> $%d = $%d", column, qb.ArgIndex, qb.ArgIndex+1))
+	qb.args = append(qb.args, key, value)
+	qb.ArgIndex += 2
+	return qb
+}
+
+// AddJSONHasKey adds the condition "column ? $n", matching JSONB objects with the
+// top-level key, whatever its value
+func (qb *QueryBuilder) AddJSONHasKey(column, key string) *QueryBuilder {
+	return qb.AddCondition(fmt.Sprintf("%s ? $%d", column, qb.ArgIndex), key)
+}
+
+// AddAfterCursor restricts a listing in DefaultSort order to the rows after the
+// cursor, for keyset pagination. Nothing is added when after is nil.
+func (qb *QueryBuilder) AddAfterCursor(after *Cursor) *QueryBuilder {
+	if after == nil {
+		return qb
+	}
+	qb.where(fmt.Sprintf("(created_at, id) < ($%d, $%d)", qb.ArgIndex, qb.ArgIndex+1))
+	qb.args = append(qb.args, after.CreatedAt, after.ID)
+	qb.ArgIndex += 2
+	return qb
+}
+
+// where appends condition to the WHERE clause
+func (qb *QueryBuilder) where(condition string) {
+	if qb.hasWhere {
+		qb.query += " AND " + condition
+	} else {
+		qb.query += " WHERE " + condition
+		qb.hasWhere = true
+	}
+}
+
+// isEmptyArg reports whether an optional condition's argument is unset: nil, an empty
+// string, an empty string slice, or a zero or nil time
+func isEmptyArg(arg interface{}) bool {
+	switch v := arg.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	case time.Time:
+		return v.IsZero()
+	case *time.Time:
+		return v == nil || v.IsZero()
+	}
+	return false
 }
 
 // AddOrderBy adds ORDER BY clause
@@ -245,6 +443,11 @@ func (qb *QueryBuilder) AddOrderBy(orderBy string) *QueryBuilder {
 	return qb
 }
 
+// AddSort adds an ORDER BY clause for s
+func (qb *QueryBuilder) AddSort(s Sort) *QueryBuilder {
+	return qb.AddOrderBy(s.OrderBy())
+}
+
 // AddLimit adds LIMIT clause
 func (qb *QueryBuilder) AddLimit(limit int) *QueryBuilder {
 	if limit > 0 {
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor is a keyset pagination position: the sort key of the last row of a page.
+// Lists ordered by created_at DESC, id DESC continue with the rows where
+// (created_at, id) < (CreatedAt, ID), so rows inserted between pages neither shift
+// later pages nor appear twice.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// Encode returns the cursor as an opaque, URL-safe token
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.CreatedAt.IsZero() || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	token := cursor.Encode()
+	assert.Equal(t, token, url.QueryEscape(token), "token should be URL-safe")
+
+	decoded, err := DecodeCursor(token)
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for name, token := range map[string]string{
+		"not base64":   "***",
+		"not json":     "bm90LWpzb24",
+		"missing id":   Cursor{CreatedAt: time.Now()}.Encode(),
+		"missing time": Cursor{ID: uuid.New()}.Encode(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeCursor(token)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultFailoverCheckInterval is how often the primary is probed when no interval is given
+const DefaultFailoverCheckInterval = 5 * time.Second
+
+// Failover tracks the health of the primary database and degrades to read-only mode,
+// serving reads from a replica, while the primary is unreachable. It leaves read-only
+// mode automatically once the primary responds again.
+type Failover struct {
+	primary *Pool
+	replica *Pool
+
+	readOnly     atomic.Bool
+	checkTimeout time.Duration
+	onChange     func(readOnly bool, cause error)
+
+	// Overridden in tests to simulate outages
+	pingPrimary func(ctx context.Context) error
+	pingReplica func(ctx context.Context) error
+}
+
+// FailoverOption configures a Failover
+type FailoverOption func(*Failover)
+
+// WithFailoverCheckTimeout bounds each health probe
+func WithFailoverCheckTimeout(d time.Duration) FailoverOption {
+	return func(f *Failover) {
+		f.checkTimeout = d
+	}
+}
+
+// WithReadOnlyChangeHook is called whenever the service enters or leaves read-only
+// mode. cause is the primary's error when entering read-only mode and nil otherwise.
+func WithReadOnlyChangeHook(fn func(readOnly bool, cause error)) FailoverOption {
+	return func(f *Failover) {
+		f.onChange = fn
+	}
+}
+
+// NewFailover creates a failover monitor for a primary and its read replica
+func NewFailover(primary, replica *Pool, opts ...FailoverOption) *Failover {
+	f := &Failover{
+		primary:      primary,
+		replica:      replica,
+		checkTimeout: 2 * time.Second,
+		pingPrimary:  primary.Ping,
+		pingReplica:  replica.Ping,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// ReadOnly reports whether the primary is unavailable and writes must be refused.
+// A nil Failover is never read-only.
+func (f *Failover) ReadOnly() bool {
+	return f != nil && f.readOnly.Load()
+}
+
+// Reader returns the pool reads should use: the replica in read-only mode and the
+// primary otherwise
+func (f *Failover) Reader() *Pool {
+	if f.ReadOnly() {
+		return f.replica
+	}
+	return f.primary
+}
+
+// Check probes the primary and replica once and updates the mode. Read-only mode is
+// entered only when the replica is reachable; if both are down there is nothing to
+// serve reads from and the mode is left unchanged.
+func (f *Failover) Check(ctx context.Context) error {
+	primaryErr := f.ping(ctx, f.pingPrimary)
+	if primaryErr == nil {
+		f.setReadOnly(false, nil)
+		return nil
+	}
+
+	if replicaErr := f.ping(ctx, f.pingReplica); replicaErr != nil {
+		return fmt.Errorf("primary and replica unavailable: %w (replica: %v)", primaryErr, replicaErr)
+	}
+
+	f.setReadOnly(true, primaryErr)
+	return nil
+}
+
+// Run checks the databases every interval until ctx is cancelled
+func (f *Failover) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultFailoverCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = f.Check(ctx)
+		}
+	}
+}
+
+func (f *Failover) ping(ctx context.Context, ping func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, f.checkTimeout)
+	defer cancel()
+	return ping(ctx)
+}
+
+func (f *Failover) setReadOnly(readOnly bool, cause error) {
+	if f.readOnly.Swap(readOnly) != readOnly && f.onChange != nil {
+		f.onChange(readOnly, cause)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailover_ReadOnlyMode(t *testing.T) {
+	primary, replica := &Pool{}, &Pool{}
+	var primaryErr, replicaErr error
+
+	var changes []bool
+	f := NewFailover(primary, replica, WithReadOnlyChangeHook(func(readOnly bool, cause error) {
+		changes = append(changes, readOnly)
+		if readOnly {
+			assert.Error(t, cause)
+		}
+	}))
+	f.pingPrimary = func(context.Context) error { return primaryErr }
+	f.pingReplica = func(context.Context) error { return replicaErr }
+
+	ctx := context.Background()
+
+	require.NoError(t, f.Check(ctx))
+	assert.False(t, f.ReadOnly())
+	assert.Same(t, primary, f.Reader())
+
+	// Primary goes down while the replica is healthy
+	primaryErr = errors.New("connection refused")
+	require.NoError(t, f.Check(ctx))
+	assert.True(t, f.ReadOnly())
+	assert.Same(t, replica, f.Reader(), "reads are served from the replica")
+
+	// Both down: nothing to fail over to, so the mode is left as it was
+	replicaErr = errors.New("connection refused")
+	assert.Error(t, f.Check(ctx))
+	assert.True(t, f.ReadOnly())
+
+	// Primary recovers
+	primaryErr, replicaErr = nil, nil
+	require.NoError(t, f.Check(ctx))
+	assert.False(t, f.ReadOnly())
+	assert.Same(t, primary, f.Reader())
+
+	assert.Equal(t, []bool{true, false}, changes, "hook fires only on transitions")
+}
+
+func TestFailover_Nil(t *testing.T) {
+	var f *Failover
+	assert.False(t, f.ReadOnly())
+}
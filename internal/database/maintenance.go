@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MaintenanceWindowsSetting is the tenant settings key holding maintenance windows
+const MaintenanceWindowsSetting = "maintenance_windows"
+
+// DefaultMaintenanceCacheTTL is how long a tenant's maintenance windows are reused
+// before being read again
+const DefaultMaintenanceCacheTTL = 30 * time.Second
+
+// maxRecurringWindow bounds recurring windows so that they never overlap the next
+// occurrence
+const maxRecurringWindow = 24 * time.Hour
+
+// ErrInvalidMaintenanceWindow is returned when a tenant's maintenance windows are malformed
+var ErrInvalidMaintenanceWindow = errors.New("invalid maintenance window")
+
+// weekdays maps the day names accepted in recurring windows
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// MaintenanceWindow is a scheduled period during which a tenant's writes are
+// rejected. A window is either an explicit range, set with Start and End, or
+// recurring: it opens at At ("15:04") in TimeZone on each of Days (all days when
+// empty) and lasts for Duration.
+type MaintenanceWindow struct {
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+
+	Days     []string `json:"days,omitempty"`
+	At       string   `json:"at,omitempty"`
+	Duration string   `json:"duration,omitempty"`
+	TimeZone string   `json:"time_zone,omitempty"`
+}
+
+// Validate checks that the window is either a well-formed range or recurrence
+func (w MaintenanceWindow) Validate() error {
+	if w.Start != nil || w.End != nil {
+		if w.Start == nil || w.End == nil {
+			return fmt.Errorf("%w: start and end are both required", ErrInvalidMaintenanceWindow)
+		}
+		if w.At != "" || w.Duration != "" || len(w.Days) > 0 {
+			return fmt.Errorf("%w: a window is either a time range or a recurrence", ErrInvalidMaintenanceWindow)
+		}
+		if !w.End.After(*w.Start) {
+			return fmt.Errorf("%w: end must be after start", ErrInvalidMaintenanceWindow)
+		}
+		return nil
+	}
+
+	_, _, _, err := w.recurrence()
+	return err
+}
+
+// ActiveAt reports whether the window is open at now and, if so, when it closes
+func (w MaintenanceWindow) ActiveAt(now time.Time) (time.Time, bool) {
+	if w.Start != nil && w.End != nil {
+		if !now.Before(*w.Start) && now.Before(*w.End) {
+			return *w.End, true
+		}
+		return time.Time{}, false
+	}
+
+	days, at, duration, err := w.recurrence()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// An occurrence that opened yesterday may still be open after midnight
+	local := now.In(at.Location())
+	for offset := 0; offset >= -1; offset-- {
+		day := local.AddDate(0, 0, offset)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+
+		start := time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, at.Location())
+		end := start.Add(duration)
+		if !now.Before(start) && now.Before(end) {
+			return end, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// recurrence parses the recurring form of the window; at carries the opening time
+// of day in the window's location
+func (w MaintenanceWindow) recurrence() (map[time.Weekday]bool, time.Time, time.Duration, error) {
+	if w.At == "" || w.Duration == "" {
+		return nil, time.Time{}, 0, fmt.Errorf("%w: recurring windows need at and duration", ErrInvalidMaintenanceWindow)
+	}
+
+	loc := time.UTC
+	if w.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return nil, time.Time{}, 0, fmt.Errorf("%w: unknown time zone %q", ErrInvalidMaintenanceWindow, w.TimeZone)
+		}
+	}
+
+	at, err := time.ParseInLocation("15:04", w.At, loc)
+	if err != nil {
+		return nil, time.Time{}, 0, fmt.Errorf("%w: at must be HH:MM", ErrInvalidMaintenanceWindow)
+	}
+
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil || duration <= 0 || duration > maxRecurringWindow {
+		return nil, time.Time{}, 0, fmt.Errorf("%w: duration must be between 0 and %s", ErrInvalidMaintenanceWindow, maxRecurringWindow)
+	}
+
+	days := make(map[time.Weekday]bool, len(w.Days))
+	for _, name := range w.Days {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return nil, time.Time{}, 0, fmt.Errorf("%w: unknown day %q", ErrInvalidMaintenanceWindow, name)
+		}
+		days[day] = true
+	}
+
+	return days, at, duration, nil
+}
+
+// ParseMaintenanceWindows reads and validates the maintenance windows in tenant settings
+func ParseMaintenanceWindows(settings map[string]interface{}) ([]MaintenanceWindow, error) {
+	raw, ok := settings[MaintenanceWindowsSetting]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMaintenanceWindow, err)
+	}
+	return decodeMaintenanceWindows(data)
+}
+
+// decodeMaintenanceWindows decodes and validates a JSON list of maintenance windows
+func decodeMaintenanceWindows(data []byte) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMaintenanceWindow, err)
+	}
+
+	for i, w := range windows {
+		if err := w.Validate(); err != nil {
+			return nil, fmt.Errorf("window %d: %w", i, err)
+		}
+	}
+
+	return windows, nil
+}
+
+// ActiveMaintenance reports whether any window is open at now and, if so, when the
+// last of the open windows closes
+func ActiveMaintenance(windows []MaintenanceWindow, now time.Time) (time.Time, bool) {
+	var until time.Time
+	active := false
+	for _, w := range windows {
+		if end, ok := w.ActiveAt(now); ok {
+			active = true
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+	return until, active
+}
+
+// MaintenanceSchedule answers whether a tenant is inside one of its maintenance
+// windows. Windows are read from tenant settings and cached briefly, so changes take
+// up to the cache TTL to apply.
+type MaintenanceSchedule struct {
+	pool *Pool
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]maintenanceEntry
+}
+
+type maintenanceEntry struct {
+	windows []MaintenanceWindow
+	expires time.Time
+}
+
+// NewMaintenanceSchedule creates a schedule reading tenant settings from pool
+func NewMaintenanceSchedule(pool *Pool, ttl time.Duration) *MaintenanceSchedule {
+	return &MaintenanceSchedule{
+		pool:    pool,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[uuid.UUID]maintenanceEntry),
+	}
+}
+
+// ActiveUntil reports whether the tenant is in maintenance at now and when the
+// maintenance ends. Its signature matches middleware.MaintenanceLookup.
+func (s *MaintenanceSchedule) ActiveUntil(ctx context.Context, tenantID string, now time.Time) (time.Time, bool, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		// Requests with a malformed tenant are rejected by the handlers
+		return time.Time{}, false, nil
+	}
+
+	windows, err := s.windows(ctx, id)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	until, active := ActiveMaintenance(windows, now)
+	return until, active, nil
+}
+
+// windows returns the tenant's maintenance windows, from the cache when fresh
+func (s *MaintenanceSchedule) windows(ctx context.Context, tenantID uuid.UUID) ([]MaintenanceWindow, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[tenantID]
+	s.mu.Unlock()
+	if ok && s.now().Before(entry.expires) {
+		return entry.windows, nil
+	}
+
+	query := `
+		SELECT settings->'maintenance_windows' FROM control_plane.tenants
+		WHERE id = $1
+	`
+
+	var data []byte
+	if err := s.pool.QueryRow(ctx, query, tenantID).Scan(&data); err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to get maintenance windows: %w", err)
+	}
+
+	var windows []MaintenanceWindow
+	if len(data) > 0 {
+		var err error
+		if windows, err = decodeMaintenanceWindows(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.ttl > 0 {
+		s.mu.Lock()
+		s.entries[tenantID] = maintenanceEntry{windows: windows, expires: s.now().Add(s.ttl)}
+		s.mu.Unlock()
+	}
+
+	return windows, nil
+}
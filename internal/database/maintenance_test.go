@@ -0,0 +1,102 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindow_ActiveAt(t *testing.T) {
+	// Saturday 7 June 2025
+	saturday := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("explicit range", func(t *testing.T) {
+		start, end := saturday.Add(2*time.Hour), saturday.Add(4*time.Hour)
+		w := MaintenanceWindow{Start: &start, End: &end}
+		require.NoError(t, w.Validate())
+
+		until, active := w.ActiveAt(start.Add(time.Minute))
+		assert.True(t, active)
+		assert.Equal(t, end, until)
+
+		_, active = w.ActiveAt(end)
+		assert.False(t, active)
+		_, active = w.ActiveAt(start.Add(-time.Second))
+		assert.False(t, active)
+	})
+
+	t.Run("recurring on selected days", func(t *testing.T) {
+		w := MaintenanceWindow{Days: []string{"sat", "Sun"}, At: "23:00", Duration: "3h"}
+		require.NoError(t, w.Validate())
+
+		until, active := w.ActiveAt(saturday.Add(23*time.Hour + 30*time.Minute))
+		assert.True(t, active)
+		assert.Equal(t, saturday.Add(26*time.Hour), until)
+
+		// Saturday's occurrence is still open after midnight
+		_, active = w.ActiveAt(saturday.Add(25 * time.Hour))
+		assert.True(t, active)
+
+		// Friday has no occurrence
+		_, active = w.ActiveAt(saturday.Add(-30 * time.Minute))
+		assert.False(t, active)
+	})
+
+	t.Run("recurring in a time zone", func(t *testing.T) {
+		w := MaintenanceWindow{At: "02:00", Duration: "1h", TimeZone: "America/New_York"}
+		require.NoError(t, w.Validate())
+
+		// 02:30 in New York is 06:30 UTC during daylight saving time
+		_, active := w.ActiveAt(saturday.Add(6*time.Hour + 30*time.Minute))
+		assert.True(t, active)
+		_, active = w.ActiveAt(saturday.Add(2*time.Hour + 30*time.Minute))
+		assert.False(t, active)
+	})
+}
+
+func TestParseMaintenanceWindows(t *testing.T) {
+	windows, err := ParseMaintenanceWindows(map[string]interface{}{
+		MaintenanceWindowsSetting: []interface{}{
+			map[string]interface{}{"start": "2025-06-07T02:00:00Z", "end": "2025-06-07T04:00:00Z"},
+			map[string]interface{}{"days": []interface{}{"sun"}, "at": "01:00", "duration": "30m"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, windows, 2)
+
+	windows, err = ParseMaintenanceWindows(nil)
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+
+	for name, window := range map[string]map[string]interface{}{
+		"missing end":       {"start": "2025-06-07T02:00:00Z"},
+		"end before start":  {"start": "2025-06-07T04:00:00Z", "end": "2025-06-07T02:00:00Z"},
+		"bad time of day":   {"at": "25:00", "duration": "1h"},
+		"too long":          {"at": "01:00", "duration": "25h"},
+		"unknown day":       {"days": []interface{}{"someday"}, "at": "01:00", "duration": "1h"},
+		"unknown time zone": {"at": "01:00", "duration": "1h", "time_zone": "Mars/Olympus"},
+	} {
+		_, err := ParseMaintenanceWindows(map[string]interface{}{
+			MaintenanceWindowsSetting: []interface{}{window},
+		})
+		assert.ErrorIs(t, err, ErrInvalidMaintenanceWindow, name)
+	}
+}
+
+func TestActiveMaintenance(t *testing.T) {
+	now := time.Date(2025, 6, 7, 3, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	end := now.Add(time.Hour)
+
+	until, active := ActiveMaintenance([]MaintenanceWindow{
+		{Start: &start, End: &end},
+		{At: "02:30", Duration: "2h"},
+	}, now)
+	assert.True(t, active)
+	assert.Equal(t, now.Add(90*time.Minute), until, "the latest closing window wins")
+
+	_, active = ActiveMaintenance(nil, now)
+	assert.False(t, active)
+}
@@ -16,8 +16,7 @@ import (
 // MigrationManager handles database migrations
 type MigrationManager struct {
 	pool         *Pool
-	migrateUp    *migrate.Migrate
-	migrateDown  *migrate.Migrate
+	migrate      *migrate.Migrate
 	migrationDir string
 }
 
@@ -52,30 +51,31 @@ func NewMigrationManager(pool *Pool, migrationDir string) (*MigrationManager, er
 
 	return &MigrationManager{
 		pool:         pool,
-		migrateUp:    m,
+		migrate:      m,
 		migrationDir: migrationDir,
 	}, nil
 }
 
 // Up runs all available migrations
 func (mm *MigrationManager) Up(ctx context.Context) error {
-	if err := mm.migrateUp.Up(); err != nil && err != migrate.ErrNoChange {
+	if err := mm.migrate.Up(); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run migrations up: %w", err)
 	}
 	return nil
 }
 
-// Down runs one migration down
+// Down rolls back the most recently applied migration
 func (mm *MigrationManager) Down(ctx context.Context) error {
-	if err := mm.migrateUp.Steps(-1); err != nil && err != migrate.ErrNoChange {
+	if err := mm.migrate.Steps(-1); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run migration down: %w", err)
 	}
 	return nil
 }
 
-// Steps runs a specific number of migrations (positive = up, negative = down)
+// Steps applies steps migrations when positive, or rolls back -steps when negative.
+// Steps are relative to the current version.
 func (mm *MigrationManager) Steps(ctx context.Context, steps int) error {
-	if err := mm.migrateUp.Steps(steps); err != nil && err != migrate.ErrNoChange {
+	if err := mm.migrate.Steps(steps); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run %d migration steps: %w", steps, err)
 	}
 	return nil
@@ -83,16 +83,27 @@ func (mm *MigrationManager) Steps(ctx context.Context, steps int) error {
 
 // Version returns the current migration version
 func (mm *MigrationManager) Version() (uint, bool, error) {
-	version, dirty, err := mm.migrateUp.Version()
+	version, dirty, err := mm.migrate.Version()
 	if err != nil && err != migrate.ErrNilVersion {
 		return 0, false, fmt.Errorf("failed to get migration version: %w", err)
 	}
 	return version, dirty, nil
 }
 
+// ForceVersion records version as the current version and clears the dirty flag,
+// without running any migration. Use it to recover from a migration that failed
+// part-way, after repairing the schema by hand: force the last version that is
+// fully applied, then run Up again. A version of -1 means no migrations applied.
+func (mm *MigrationManager) ForceVersion(version int) error {
+	if err := mm.migrate.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version %d: %w", version, err)
+	}
+	return nil
+}
+
 // Drop drops the entire database (use with caution!)
 func (mm *MigrationManager) Drop(ctx context.Context) error {
-	if err := mm.migrateUp.Drop(); err != nil {
+	if err := mm.migrate.Drop(); err != nil {
 		return fmt.Errorf("failed to drop database: %w", err)
 	}
 	return nil
@@ -145,7 +156,7 @@ type MigrationStatus struct {
 
 // Close closes the migration manager
 func (mm *MigrationManager) Close() error {
-	sourceErr, dbErr := mm.migrateUp.Close()
+	sourceErr, dbErr := mm.migrate.Close()
 	if sourceErr != nil {
 		return fmt.Errorf("failed to close migration source: %w", sourceErr)
 	}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubMigrationManager runs the repository's migrations against an in-memory
+// driver, which records versions without executing any SQL
+func newStubMigrationManager(t *testing.T) (*MigrationManager, *stub.Stub) {
+	t.Helper()
+
+	driver, err := stub.WithInstance(nil, &stub.Config{})
+	require.NoError(t, err)
+
+	m, err := migrate.NewWithDatabaseInstance("file://../../migrations", "stub", driver)
+	require.NoError(t, err)
+
+	mm := &MigrationManager{migrate: m, migrationDir: "../../migrations"}
+	t.Cleanup(func() { mm.Close() })
+	return mm, driver.(*stub.Stub)
+}
+
+func TestMigrationManager_Steps(t *testing.T) {
+	ctx := context.Background()
+	mm, _ := newStubMigrationManager(t)
+
+	require.NoError(t, mm.Steps(ctx, 3))
+	version, _, err := mm.Version()
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), version)
+
+	require.NoError(t, mm.Steps(ctx, -2))
+	version, _, err = mm.Version()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), version, "steps are relative to the current version")
+
+	require.NoError(t, mm.Down(ctx))
+	status, err := mm.Status()
+	require.NoError(t, err)
+	assert.Equal(t, "No migrations applied", status.Status)
+}
+
+func TestMigrationManager_ForceVersion(t *testing.T) {
+	ctx := context.Background()
+	mm, driver := newStubMigrationManager(t)
+
+	require.NoError(t, mm.Steps(ctx, 3))
+	// Simulate migration 4 failing part-way
+	require.NoError(t, driver.SetVersion(4, true))
+
+	status, err := mm.Status()
+	require.NoError(t, err)
+	assert.True(t, status.Dirty)
+	assert.Equal(t, "Version 4 (dirty)", status.Status)
+
+	err = mm.Up(ctx)
+	require.Error(t, err)
+	var errDirty migrate.ErrDirty
+	assert.ErrorAs(t, err, &errDirty, "a dirty database blocks migrations")
+
+	require.NoError(t, mm.ForceVersion(3))
+	status, err = mm.Status()
+	require.NoError(t, err)
+	assert.False(t, status.Dirty)
+	assert.Equal(t, uint(3), status.Version)
+
+	require.NoError(t, mm.Up(ctx))
+	version, dirty, err := mm.Version()
+	require.NoError(t, err)
+	assert.False(t, dirty)
+	assert.Greater(t, version, uint(3))
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrQuotaExceeded is returned when creating a resource would take a tenant past a
+// limit in its resource_limits
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// QuotaExceededError names the limit a create would exceed. It matches
+// ErrQuotaExceeded with errors.Is.
+type QuotaExceededError struct {
+	Resource string
+	Limit    int
+}
+
+// Error implements the error interface
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: the tenant may have at most %d %s", ErrQuotaExceeded, e.Limit, e.Resource)
+}
+
+// Is reports whether target is ErrQuotaExceeded
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Quota counts for CheckQuota, taking the tenant ID as $1. Terminated applications are
+// awaiting purge and do not count.
+const (
+	CountTeamsQuery        = `SELECT COUNT(*) FROM resource_management.teams WHERE tenant_id = $1 AND deleted_at IS NULL`
+	CountApplicationsQuery = `SELECT COUNT(*) FROM resource_management.applications WHERE tenant_id = $1 AND status <> 'terminated'`
+)
+
+// CheckQuota returns a *QuotaExceededError if the tenant already has as many of
+// resource, counted by countQuery, as its resource_limits allow. It locks the tenant
+// row, so it must run in the transaction that creates the resource: concurrent creates
+// for the tenant then wait for each other rather than all passing the check. Tenants
+// without a positive limit for resource, or without a row, are unlimited.
+func CheckQuota(ctx context.Context, tx Querier, tenantID uuid.UUID, resource, countQuery string) error {
+	var limits map[string]interface{}
+	err := tx.QueryRow(ctx, `
+		SELECT resource_limits FROM control_plane.tenants
+		WHERE id = $1
+		FOR UPDATE
+	`, tenantID).Scan(&limits)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tenant limits: %w", err)
+	}
+
+	limit := limitFor(limits, resource)
+	if limit <= 0 {
+		return nil
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, countQuery, tenantID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count tenant %s: %w", resource, err)
+	}
+	if count >= limit {
+		return &QuotaExceededError{Resource: resource, Limit: limit}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaExceededError(t *testing.T) {
+	err := fmt.Errorf("failed to create team: %w", &QuotaExceededError{Resource: LimitTeams, Limit: 3})
+
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.EqualError(t, err, "failed to create team: tenant quota exceeded: the tenant may have at most 3 teams")
+
+	var quotaErr *QuotaExceededError
+	assert.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, 3, quotaErr.Limit)
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DefaultReplicaCheckInterval is how often replicas are probed when no interval is given
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+// ReplicaPool routes reads to read replicas and everything else to the primary.
+// Reads round-robin over the replicas that passed their last health check and fall
+// back to the primary when none did. Replicas lag the primary, so only reads that
+// tolerate slightly stale data, such as list endpoints, should use it.
+type ReplicaPool struct {
+	primary      *Pool
+	replicas     []*replica
+	next         atomic.Uint64
+	checkTimeout time.Duration
+}
+
+type replica struct {
+	pool    *Pool
+	healthy atomic.Bool
+
+	// Overridden in tests to simulate outages
+	ping func(ctx context.Context) error
+}
+
+// NewReplicaPool creates a ReplicaPool. Replicas start out healthy; call Check or Run
+// to track their health.
+func NewReplicaPool(primary *Pool, replicas ...*Pool) *ReplicaPool {
+	r := &ReplicaPool{primary: primary, checkTimeout: 2 * time.Second}
+	for _, pool := range replicas {
+		rep := &replica{pool: pool, ping: pool.Ping}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	return r
+}
+
+// Primary returns the primary pool
+func (r *ReplicaPool) Primary() *Pool {
+	return r.primary
+}
+
+// Reader returns the pool the next read should use: the next healthy replica, or the
+// primary when no replica is healthy
+func (r *ReplicaPool) Reader() *Pool {
+	n := len(r.replicas)
+	if n == 0 {
+		return r.primary
+	}
+
+	start := r.next.Add(1) - 1
+	for i := 0; i < n; i++ {
+		rep := r.replicas[(start+uint64(i))%uint64(n)]
+		if rep.healthy.Load() {
+			return rep.pool
+		}
+	}
+	return r.primary
+}
+
+// QueryRead runs a read-only query on a replica
+func (r *ReplicaPool) QueryRead(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return r.Reader().Query(ctx, sql, args...)
+}
+
+// QueryRow runs a read-only query returning at most one row on a replica
+func (r *ReplicaPool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return r.Reader().QueryRow(ctx, sql, args...)
+}
+
+// Exec runs a statement on the primary
+func (r *ReplicaPool) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.primary.Exec(ctx, sql, args...)
+}
+
+// WithTransaction runs fn in a transaction on the primary
+func (r *ReplicaPool) WithTransaction(ctx context.Context, fn func(*Transaction) error) error {
+	return r.primary.WithTransaction(ctx, fn)
+}
+
+// Check pings every replica once and records which are healthy. It returns an error
+// describing the unhealthy replicas, if any.
+func (r *ReplicaPool) Check(ctx context.Context) error {
+	var errs []error
+	for i, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, r.checkTimeout)
+		err := rep.ping(pingCtx)
+		cancel()
+
+		rep.healthy.Store(err == nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("replica %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run checks the replicas every interval until ctx is cancelled
+func (r *ReplicaPool) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReplicaCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.Check(ctx)
+		}
+	}
+}
+
+// Close closes the replica pools. The primary is left open for its owner to close.
+func (r *ReplicaPool) Close() {
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+}
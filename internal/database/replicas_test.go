@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newStubReplicaPool builds a ReplicaPool over stub pools whose health is controlled
+// by the returned map of ping errors
+func newStubReplicaPool(n int) (*ReplicaPool, *Pool, []*Pool, map[*Pool]error) {
+	primary := &Pool{}
+	replicas := make([]*Pool, n)
+	for i := range replicas {
+		replicas[i] = &Pool{}
+	}
+
+	r := NewReplicaPool(primary, replicas...)
+	down := make(map[*Pool]error)
+	for _, rep := range r.replicas {
+		pool := rep.pool
+		rep.ping = func(ctx context.Context) error { return down[pool] }
+	}
+	return r, primary, replicas, down
+}
+
+func TestReplicaPool_RoundRobin(t *testing.T) {
+	r, _, replicas, _ := newStubReplicaPool(3)
+
+	var got []*Pool
+	for i := 0; i < 6; i++ {
+		got = append(got, r.Reader())
+	}
+	assert.Equal(t, []*Pool{replicas[0], replicas[1], replicas[2], replicas[0], replicas[1], replicas[2]}, got)
+}
+
+func TestReplicaPool_SkipsUnhealthyReplicas(t *testing.T) {
+	r, primary, replicas, down := newStubReplicaPool(3)
+	down[replicas[1]] = errors.New("connection refused")
+
+	err := r.Check(context.Background())
+	assert.ErrorContains(t, err, "replica 1")
+
+	for i := 0; i < 4; i++ {
+		reader := r.Reader()
+		assert.NotSame(t, replicas[1], reader)
+		assert.NotSame(t, primary, reader)
+	}
+
+	t.Run("falls back to the primary when every replica is down", func(t *testing.T) {
+		for _, rep := range replicas {
+			down[rep] = errors.New("connection refused")
+		}
+		assert.Error(t, r.Check(context.Background()))
+		assert.Same(t, primary, r.Reader())
+	})
+
+	t.Run("recovered replicas are used again", func(t *testing.T) {
+		delete(down, replicas[2])
+		assert.Error(t, r.Check(context.Background()))
+		assert.Same(t, replicas[2], r.Reader())
+		assert.Same(t, replicas[2], r.Reader())
+
+		clear(down)
+		assert.NoError(t, r.Check(context.Background()))
+	})
+}
+
+func TestReplicaPool_NoReplicas(t *testing.T) {
+	r, primary, _, _ := newStubReplicaPool(0)
+
+	assert.Same(t, primary, r.Reader())
+	assert.Same(t, primary, r.Primary())
+	assert.NoError(t, r.Check(context.Background()))
+}
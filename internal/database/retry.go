@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Default read retry settings
+const (
+	DefaultReadRetryAttempts = 3
+	DefaultReadRetryBackoff  = 50 * time.Millisecond
+	maxRetryBackoff          = 2 * time.Second
+)
+
+// retryableCodes are SQLSTATEs after which repeating a read is expected to succeed
+var retryableCodes = map[string]bool{
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+}
+
+// IsRetryable reports whether err is a transient failure, such as a dropped
+// connection or a server restart, after which the same query may succeed. It says
+// nothing about whether repeating the query is safe: only reads and idempotent
+// writes should be retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exceptions
+		return retryableCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var safe interface{ SafeToRetry() bool }
+	if errors.As(err, &safe) && safe.SafeToRetry() {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// RetryPolicy retries operations that fail with a retryable error, doubling the
+// backoff between attempts. The zero value runs operations once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	Backoff     time.Duration
+
+	// Overridden in tests to avoid sleeping
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryPolicy creates a policy making up to attempts attempts, waiting backoff
+// before the first retry
+func NewRetryPolicy(attempts int, backoff time.Duration) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, Backoff: backoff}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable, the
+// attempts are exhausted or ctx is done, and returns fn's last error. Use it only
+// for reads and idempotent writes.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	sleep := p.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		if sleepErr := sleep(ctx, backoff); sleepErr != nil {
+			return err
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"wrapped admin shutdown", fmt.Errorf("failed to get team: %w", &pgconn.PgError{Code: "57P01"}), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"no rows", pgx.ErrNoRows, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+// noSleep records the backoffs a policy waits for without sleeping
+func noSleep(policy RetryPolicy, waits *[]time.Duration) RetryPolicy {
+	policy.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return ctx.Err()
+	}
+	return policy
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	ctx := context.Background()
+	transient := &pgconn.PgError{Code: "57P01", Message: "terminating connection due to administrator command"}
+
+	t.Run("transient read failure succeeds on retry", func(t *testing.T) {
+		var waits []time.Duration
+		policy := noSleep(NewRetryPolicy(3, 10*time.Millisecond), &waits)
+
+		calls := 0
+		err := policy.Do(ctx, func(ctx context.Context) error {
+			calls++
+			if calls == 1 {
+				return fmt.Errorf("failed to get application: %w", transient)
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond}, waits)
+	})
+
+	t.Run("gives up after max attempts with exponential backoff", func(t *testing.T) {
+		var waits []time.Duration
+		policy := noSleep(NewRetryPolicy(3, 10*time.Millisecond), &waits)
+
+		calls := 0
+		err := policy.Do(ctx, func(ctx context.Context) error {
+			calls++
+			return transient
+		})
+
+		assert.ErrorIs(t, err, transient)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, waits)
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		err := NewRetryPolicy(3, 0).Do(ctx, func(ctx context.Context) error {
+			calls++
+			return pgx.ErrNoRows
+		})
+
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("zero policy runs once", func(t *testing.T) {
+		calls := 0
+		err := RetryPolicy{}.Do(ctx, func(ctx context.Context) error {
+			calls++
+			return transient
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		calls := 0
+		err := NewRetryPolicy(5, time.Hour).Do(cancelled, func(ctx context.Context) error {
+			calls++
+			return transient
+		})
+
+		assert.ErrorIs(t, err, transient)
+		assert.Equal(t, 1, calls)
+	})
+}
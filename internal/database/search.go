@@ -0,0 +1,25 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes the LIKE wildcards and PostgreSQL's default escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern returns a LIKE pattern matching values that contain term. Wildcards
+// in term are escaped, so a search for "50%" matches the literal text.
+func ContainsPattern(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
+// SearchCondition returns a condition that holds when any of columns contains the
+// ContainsPattern bound to placeholder $n, ignoring case
+func SearchCondition(n int, columns ...string) string {
+	matches := make([]string, len(columns))
+	for i, column := range columns {
+		matches[i] = fmt.Sprintf("%s ILIKE $%d", column, n)
+	}
+	return "(" + strings.Join(matches, " OR ") + ")"
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsPattern(t *testing.T) {
+	tests := map[string]string{
+		"orders":    "%orders%",
+		"50%":       `%50\%%`,
+		"my_app":    `%my\_app%`,
+		`back\path`: `%back\\path%`,
+		"":          "%%",
+	}
+
+	for term, want := range tests {
+		assert.Equal(t, want, ContainsPattern(term), term)
+	}
+}
+
+func TestSearchCondition(t *testing.T) {
+	assert.Equal(t, "(name ILIKE $3 OR description ILIKE $3)", SearchCondition(3, "name", "description"))
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Slug errors
+var (
+	ErrInvalidSlug = errors.New("invalid tenant slug")
+	ErrSlugTaken   = errors.New("tenant slug already in use")
+)
+
+// MaxSlugLength is the longest slug accepted, matching a DNS label
+const MaxSlugLength = 63
+
+// slugConstraint is the unique index backing slug uniqueness
+const slugConstraint = "idx_tenants_slug"
+
+var (
+	slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+	slugPattern    = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+)
+
+// GenerateSlug derives a URL-safe slug from a tenant name. It lowercases the name,
+// collapses every run of other characters into a single dash and truncates to
+// MaxSlugLength. Names without letters or digits fall back to "tenant".
+func GenerateSlug(name string) string {
+	slug := slugSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	slug = strings.Trim(slug, "-")
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+	if slug == "" {
+		return "tenant"
+	}
+	return slug
+}
+
+// ValidateSlug checks that a slug is URL-safe: lowercase letters, digits and dashes,
+// starting and ending with a letter or digit, at most MaxSlugLength characters
+func ValidateSlug(slug string) error {
+	if len(slug) > MaxSlugLength {
+		return fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidSlug, slug, MaxSlugLength)
+	}
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("%w: %q must contain only lowercase letters, digits and dashes", ErrInvalidSlug, slug)
+	}
+	return nil
+}
+
+// GetTenantBySlug retrieves a tenant by slug
+func (tm *TenantManager) GetTenantBySlug(ctx context.Context, slug string) (*Tenant, error) {
+	query := `
+		SELECT id, name, slug, display_name, description, database_name, status,
+			   settings, resource_limits, created_at, updated_at
+		FROM control_plane.tenants
+		WHERE slug = $1
+	`
+
+	var tenant Tenant
+	err := tm.pool.QueryRow(ctx, query, slug).Scan(
+		&tenant.ID, &tenant.Name, &tenant.Slug, &tenant.DisplayName, &tenant.Description,
+		&tenant.DatabaseName, &tenant.Status, &tenant.Settings, &tenant.ResourceLimits,
+		&tenant.CreatedAt, &tenant.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, slug)
+		}
+		return nil, fmt.Errorf("failed to get tenant by slug: %w", err)
+	}
+
+	return &tenant, nil
+}
+
+// slugConflict converts a unique violation on the slug index into ErrSlugTaken
+func slugConflict(err error, slug string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == slugConstraint {
+		return fmt.Errorf("%w: %s", ErrSlugTaken, slug)
+	}
+	return nil
+}
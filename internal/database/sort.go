@@ -0,0 +1,57 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidSort is returned for a sort a list does not support
+var ErrInvalidSort = errors.New("invalid sort")
+
+// Sort orders a list by one column. Rows with the same value are ordered by id in the
+// same direction, so pages do not overlap.
+type Sort struct {
+	Column     string
+	Descending bool
+}
+
+// DefaultSort lists the most recently created rows first
+var DefaultSort = Sort{Column: "created_at", Descending: true}
+
+// ParseSort parses a sort parameter such as "name" or "-created_at", where a leading
+// "-" sorts in descending order. An empty value is DefaultSort. The column must be one
+// of columns, so that a Sort is always safe to put in a query.
+func ParseSort(value string, columns []string) (Sort, error) {
+	if value == "" {
+		return DefaultSort, nil
+	}
+
+	sort := Sort{Column: value}
+	if column, ok := strings.CutPrefix(value, "-"); ok {
+		sort = Sort{Column: column, Descending: true}
+	}
+	if !slices.Contains(columns, sort.Column) {
+		return Sort{}, fmt.Errorf("%w %q: sort by one of %s, with a leading - for descending order",
+			ErrInvalidSort, value, strings.Join(columns, ", "))
+	}
+	return sort, nil
+}
+
+// String returns the sort in the form ParseSort accepts
+func (s Sort) String() string {
+	if s.Descending {
+		return "-" + s.Column
+	}
+	return s.Column
+}
+
+// OrderBy returns the ORDER BY expression for the sort
+func (s Sort) OrderBy() string {
+	direction := "ASC"
+	if s.Descending {
+		direction = "DESC"
+	}
+	return s.Column + " " + direction + ", id " + direction
+}
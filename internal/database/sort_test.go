@@ -0,0 +1,44 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	columns := []string{"name", "created_at", "updated_at"}
+
+	tests := []struct {
+		value string
+		want  Sort
+	}{
+		{"", DefaultSort},
+		{"name", Sort{Column: "name"}},
+		{"-name", Sort{Column: "name", Descending: true}},
+		{"updated_at", Sort{Column: "updated_at"}},
+		{"-created_at", Sort{Column: "created_at", Descending: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			sort, err := ParseSort(tt.value, columns)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sort)
+		})
+	}
+
+	t.Run("rejects columns that are not allowed", func(t *testing.T) {
+		for _, value := range []string{"owner", "--name", "name; DROP TABLE teams", "+name", "-"} {
+			_, err := ParseSort(value, columns)
+			assert.ErrorIs(t, err, ErrInvalidSort, value)
+		}
+	})
+}
+
+func TestSort_OrderBy(t *testing.T) {
+	assert.Equal(t, "created_at DESC, id DESC", DefaultSort.OrderBy())
+	assert.Equal(t, "name ASC, id ASC", Sort{Column: "name"}.OrderBy())
+	assert.Equal(t, "-created_at", DefaultSort.String())
+	assert.Equal(t, "name", Sort{Column: "name"}.String())
+}
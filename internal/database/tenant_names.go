@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTenantNameCacheTTL is how long a tenant name's ID is reused before being
+// looked up again
+const DefaultTenantNameCacheTTL = 30 * time.Second
+
+// TenantNameCache answers which tenant has a name, caching the answer briefly so
+// resolving the tenant of every request from its subdomain stays cheap. Unknown
+// names are not cached, so a tenant can be used as soon as it is created.
+type TenantNameCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	// Overridden in tests to avoid a database
+	fetch func(ctx context.Context, name string) (uuid.UUID, error)
+
+	mu      sync.Mutex
+	entries map[string]tenantNameEntry
+}
+
+type tenantNameEntry struct {
+	id      uuid.UUID
+	expires time.Time
+}
+
+// NewTenantNameCache creates a cache looking tenant names up in pool
+func NewTenantNameCache(pool *Pool, ttl time.Duration) *TenantNameCache {
+	tenants := NewTenantManager(pool)
+	return &TenantNameCache{
+		ttl: ttl,
+		now: time.Now,
+		fetch: func(ctx context.Context, name string) (uuid.UUID, error) {
+			tenant, err := tenants.GetTenantByName(ctx, name)
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return tenant.ID, nil
+		},
+		entries: make(map[string]tenantNameEntry),
+	}
+}
+
+// ID returns the ID of the tenant named name, or an error wrapping ErrTenantNotFound
+// when there is none. Its signature matches server.TenantNameResolver.
+func (c *TenantNameCache) ID(ctx context.Context, name string) (uuid.UUID, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.id, nil
+	}
+
+	id, err := c.fetch(ctx, name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.entries[name] = tenantNameEntry{id: id, expires: c.now().Add(c.ttl)}
+		c.mu.Unlock()
+	}
+
+	return id, nil
+}
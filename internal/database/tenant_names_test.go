@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantNameCache(t *testing.T) {
+	names := map[string]uuid.UUID{"acme": uuid.New()}
+	fetches := 0
+
+	clock := time.Date(2025, 6, 7, 12, 0, 0, 0, time.UTC)
+	cache := NewTenantNameCache(nil, time.Minute)
+	cache.now = func() time.Time { return clock }
+	cache.fetch = func(ctx context.Context, name string) (uuid.UUID, error) {
+		fetches++
+		id, ok := names[name]
+		if !ok {
+			return uuid.Nil, fmt.Errorf("%w: %s", ErrTenantNotFound, name)
+		}
+		return id, nil
+	}
+
+	ctx := context.Background()
+	acme := names["acme"]
+
+	got, err := cache.ID(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, acme, got)
+
+	names["acme"] = uuid.New()
+	got, _ = cache.ID(ctx, "acme")
+	assert.Equal(t, acme, got, "cached IDs are reused within the TTL")
+	assert.Equal(t, 1, fetches)
+
+	clock = clock.Add(2 * time.Minute)
+	got, _ = cache.ID(ctx, "acme")
+	assert.Equal(t, names["acme"], got, "names are looked up again once the TTL expires")
+	assert.Equal(t, 2, fetches)
+
+	_, err = cache.ID(ctx, "globex")
+	assert.ErrorIs(t, err, ErrTenantNotFound)
+	names["globex"] = uuid.New()
+	got, err = cache.ID(ctx, "globex")
+	require.NoError(t, err)
+	assert.Equal(t, names["globex"], got, "unknown names are not cached")
+}
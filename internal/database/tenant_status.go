@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultTenantStatusCacheTTL is how long a tenant's status is reused before being
+// read again
+const DefaultTenantStatusCacheTTL = 30 * time.Second
+
+// TenantStatusCache answers what status a tenant is in, caching the answer briefly
+// so that checking it on every request stays cheap. Status changes take up to the
+// cache TTL to apply.
+type TenantStatusCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	// Overridden in tests to avoid a database
+	fetch func(ctx context.Context, tenantID uuid.UUID) (string, error)
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]tenantStatusEntry
+}
+
+type tenantStatusEntry struct {
+	status  string
+	expires time.Time
+}
+
+// NewTenantStatusCache creates a cache reading tenant status from pool
+func NewTenantStatusCache(pool *Pool, ttl time.Duration) *TenantStatusCache {
+	return &TenantStatusCache{
+		ttl: ttl,
+		now: time.Now,
+		fetch: func(ctx context.Context, tenantID uuid.UUID) (string, error) {
+			var status string
+			err := pool.QueryRow(ctx, "SELECT status FROM control_plane.tenants WHERE id = $1", tenantID).Scan(&status)
+			if err != nil && err != pgx.ErrNoRows {
+				return "", fmt.Errorf("failed to get tenant status: %w", err)
+			}
+			return status, nil
+		},
+		entries: make(map[uuid.UUID]tenantStatusEntry),
+	}
+}
+
+// Status returns the tenant's status, or "" for unknown tenants and malformed IDs,
+// which the handlers reject themselves. Its signature matches
+// middleware.TenantStatusLookup.
+func (c *TenantStatusCache) Status(ctx context.Context, tenantID string) (string, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.status, nil
+	}
+
+	status, err := c.fetch(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.entries[id] = tenantStatusEntry{status: status, expires: c.now().Add(c.ttl)}
+		c.mu.Unlock()
+	}
+
+	return status, nil
+}
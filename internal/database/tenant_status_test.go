@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantStatusCache(t *testing.T) {
+	tenantID := uuid.New()
+	status := "active"
+	fetches := 0
+
+	clock := time.Date(2025, 6, 7, 12, 0, 0, 0, time.UTC)
+	cache := NewTenantStatusCache(nil, time.Minute)
+	cache.now = func() time.Time { return clock }
+	cache.fetch = func(ctx context.Context, id uuid.UUID) (string, error) {
+		fetches++
+		if id != tenantID {
+			return "", nil
+		}
+		return status, nil
+	}
+
+	ctx := context.Background()
+
+	got, err := cache.Status(ctx, tenantID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "active", got)
+
+	status = "suspended"
+	got, _ = cache.Status(ctx, tenantID.String())
+	assert.Equal(t, "active", got, "cached status is reused within the TTL")
+	assert.Equal(t, 1, fetches)
+
+	clock = clock.Add(2 * time.Minute)
+	got, _ = cache.Status(ctx, tenantID.String())
+	assert.Equal(t, "suspended", got, "status is read again once the TTL expires")
+
+	assert.Equal(t, 2, fetches)
+
+	got, err = cache.Status(ctx, "not-a-uuid")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.Equal(t, 2, fetches, "malformed IDs are not looked up")
+
+	cache.fetch = func(ctx context.Context, id uuid.UUID) (string, error) {
+		return "", errors.New("connection refused")
+	}
+	_, err = cache.Status(ctx, uuid.NewString())
+	assert.Error(t, err)
+}
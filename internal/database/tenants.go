@@ -2,28 +2,87 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/aykay76/ai-idp/internal/ratelimit"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
+// Tenant errors
+var (
+	ErrTenantNotFound          = errors.New("tenant not found")
+	ErrTenantCreationThrottled = errors.New("tenant creation rate limit exceeded")
+	ErrTenantLimitReached      = errors.New("maximum number of tenants reached")
+)
+
+// TenantCreationThrottledError reports which creation limit was hit and when to retry
+type TenantCreationThrottledError struct {
+	Scope      string // "global" or "caller"
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *TenantCreationThrottledError) Error() string {
+	return fmt.Sprintf("%s: %s limit, retry after %s", ErrTenantCreationThrottled, e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap returns ErrTenantCreationThrottled
+func (e *TenantCreationThrottledError) Unwrap() error {
+	return ErrTenantCreationThrottled
+}
+
 // TenantManager handles tenant database operations
 type TenantManager struct {
 	pool *Pool
+
+	// Creating a tenant creates a Postgres database, so creation is rate limited
+	// and the total number of tenant databases can be capped
+	createLimiter       *ratelimit.Limiter
+	callerCreateLimiter *ratelimit.KeyedLimiter
+	maxTenants          int
+
+	usage *usageCache
+}
+
+// TenantManagerOption configures a TenantManager
+type TenantManagerOption func(*TenantManager)
+
+// WithCreationRateLimit limits tenant creation globally and per caller. A nil limiter
+// disables that limit.
+func WithCreationRateLimit(global *ratelimit.Limiter, perCaller *ratelimit.KeyedLimiter) TenantManagerOption {
+	return func(tm *TenantManager) {
+		tm.createLimiter = global
+		tm.callerCreateLimiter = perCaller
+	}
+}
+
+// WithMaxTenants caps the number of tenants (and therefore tenant databases) that are
+// not terminated. Zero means unlimited.
+func WithMaxTenants(n int) TenantManagerOption {
+	return func(tm *TenantManager) {
+		tm.maxTenants = n
+	}
 }
 
 // NewTenantManager creates a new tenant manager
-func NewTenantManager(pool *Pool) *TenantManager {
-	return &TenantManager{pool: pool}
+func NewTenantManager(pool *Pool, opts ...TenantManagerOption) *TenantManager {
+	tm := &TenantManager{pool: pool, usage: newUsageCache(DefaultUsageCacheTTL)}
+	for _, opt := range opts {
+		opt(tm)
+	}
+	return tm
 }
 
 // Tenant represents a tenant record
 type Tenant struct {
 	ID             uuid.UUID              `json:"id" db:"id"`
 	Name           string                 `json:"name" db:"name"`
+	Slug           string                 `json:"slug" db:"slug"`
 	DisplayName    string                 `json:"display_name" db:"display_name"`
 	Description    *string                `json:"description,omitempty" db:"description"`
 	DatabaseName   string                 `json:"database_name" db:"database_name"`
@@ -36,11 +95,15 @@ type Tenant struct {
 
 // CreateTenantRequest represents a request to create a new tenant
 type CreateTenantRequest struct {
-	Name           string                 `json:"name" validate:"required,min=2,max=63,alphanum"`
+	Name string `json:"name" validate:"required,min=2,max=63,alphanum"`
+	// Slug is derived from Name when empty
+	Slug           string                 `json:"slug,omitempty" validate:"omitempty,max=63"`
 	DisplayName    string                 `json:"display_name" validate:"required,min=2,max=255"`
 	Description    *string                `json:"description,omitempty" validate:"omitempty,max=1000"`
 	Settings       map[string]interface{} `json:"settings,omitempty"`
 	ResourceLimits map[string]interface{} `json:"resource_limits,omitempty"`
+	// RequestedBy identifies the caller for per-caller creation rate limits
+	RequestedBy string `json:"-"`
 }
 
 // CreateTenant creates a new tenant and its isolated database
@@ -51,13 +114,34 @@ func (tm *TenantManager) CreateTenant(ctx context.Context, req *CreateTenantRequ
 		return nil, fmt.Errorf("invalid tenant name: %w", err)
 	}
 
+	slug := req.Slug
+	if slug == "" {
+		slug = GenerateSlug(req.Name)
+	}
+	if err := ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+	if _, err := ParseMaintenanceWindows(req.Settings); err != nil {
+		return nil, err
+	}
+
+	// Throttle before doing any database work
+	if err := tm.admitCreation(req.RequestedBy); err != nil {
+		return nil, err
+	}
+
 	// Start a transaction for atomic tenant creation
 	var tenant *Tenant
 	err = tm.pool.WithTransaction(ctx, func(tx *Transaction) error {
+		if err := tm.checkTenantCap(ctx, tx); err != nil {
+			return err
+		}
+
 		// Insert tenant record
 		tenant = &Tenant{
 			ID:             uuid.New(),
 			Name:           req.Name,
+			Slug:           slug,
 			DisplayName:    req.DisplayName,
 			Description:    req.Description,
 			DatabaseName:   dbName,
@@ -75,17 +159,20 @@ func (tm *TenantManager) CreateTenant(ctx context.Context, req *CreateTenantRequ
 
 		query := `
 			INSERT INTO control_plane.tenants (
-				id, name, display_name, description, database_name, status, settings, resource_limits
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				id, name, slug, display_name, description, database_name, status, settings, resource_limits
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 			RETURNING created_at, updated_at
 		`
 
 		err := tx.QueryRow(ctx, query,
-			tenant.ID, tenant.Name, tenant.DisplayName, tenant.Description,
+			tenant.ID, tenant.Name, tenant.Slug, tenant.DisplayName, tenant.Description,
 			tenant.DatabaseName, tenant.Status, tenant.Settings, tenant.ResourceLimits,
 		).Scan(&tenant.CreatedAt, &tenant.UpdatedAt)
 
 		if err != nil {
+			if conflict := slugConflict(err, tenant.Slug); conflict != nil {
+				return conflict
+			}
 			return fmt.Errorf("failed to insert tenant record: %w", err)
 		}
 
@@ -117,7 +204,7 @@ func (tm *TenantManager) CreateTenant(ctx context.Context, req *CreateTenantRequ
 // GetTenant retrieves a tenant by ID
 func (tm *TenantManager) GetTenant(ctx context.Context, tenantID uuid.UUID) (*Tenant, error) {
 	query := `
-		SELECT id, name, display_name, description, database_name, status, 
+		SELECT id, name, slug, display_name, description, database_name, status,
 			   settings, resource_limits, created_at, updated_at
 		FROM control_plane.tenants
 		WHERE id = $1
@@ -125,14 +212,14 @@ func (tm *TenantManager) GetTenant(ctx context.Context, tenantID uuid.UUID) (*Te
 
 	var tenant Tenant
 	err := tm.pool.QueryRow(ctx, query, tenantID).Scan(
-		&tenant.ID, &tenant.Name, &tenant.DisplayName, &tenant.Description,
+		&tenant.ID, &tenant.Name, &tenant.Slug, &tenant.DisplayName, &tenant.Description,
 		&tenant.DatabaseName, &tenant.Status, &tenant.Settings, &tenant.ResourceLimits,
 		&tenant.CreatedAt, &tenant.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("tenant not found: %s", tenantID)
+			return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
 		}
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
@@ -143,7 +230,7 @@ func (tm *TenantManager) GetTenant(ctx context.Context, tenantID uuid.UUID) (*Te
 // GetTenantByName retrieves a tenant by name
 func (tm *TenantManager) GetTenantByName(ctx context.Context, name string) (*Tenant, error) {
 	query := `
-		SELECT id, name, display_name, description, database_name, status,
+		SELECT id, name, slug, display_name, description, database_name, status,
 			   settings, resource_limits, created_at, updated_at
 		FROM control_plane.tenants
 		WHERE name = $1
@@ -151,14 +238,14 @@ func (tm *TenantManager) GetTenantByName(ctx context.Context, name string) (*Ten
 
 	var tenant Tenant
 	err := tm.pool.QueryRow(ctx, query, name).Scan(
-		&tenant.ID, &tenant.Name, &tenant.DisplayName, &tenant.Description,
+		&tenant.ID, &tenant.Name, &tenant.Slug, &tenant.DisplayName, &tenant.Description,
 		&tenant.DatabaseName, &tenant.Status, &tenant.Settings, &tenant.ResourceLimits,
 		&tenant.CreatedAt, &tenant.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("tenant not found: %s", name)
+			return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, name)
 		}
 		return nil, fmt.Errorf("failed to get tenant by name: %w", err)
 	}
@@ -169,12 +256,12 @@ func (tm *TenantManager) GetTenantByName(ctx context.Context, name string) (*Ten
 // ListTenants retrieves all tenants with optional filtering
 func (tm *TenantManager) ListTenants(ctx context.Context, status string, limit, offset int) ([]*Tenant, error) {
 	qb := NewQueryBuilder(`
-		SELECT id, name, display_name, description, database_name, status,
+		SELECT id, name, slug, display_name, description, database_name, status,
 			   settings, resource_limits, created_at, updated_at
 		FROM control_plane.tenants
 	`)
 
-	qb.AddOptionalCondition("status = $"+fmt.Sprint(qb.ArgIndex), status)
+	qb.AddOptionalEquals("status", status)
 	qb.AddOrderBy("created_at DESC")
 	qb.AddLimit(limit)
 	qb.AddOffset(offset)
@@ -189,7 +276,7 @@ func (tm *TenantManager) ListTenants(ctx context.Context, status string, limit,
 	for rows.Next() {
 		var tenant Tenant
 		err := rows.Scan(
-			&tenant.ID, &tenant.Name, &tenant.DisplayName, &tenant.Description,
+			&tenant.ID, &tenant.Name, &tenant.Slug, &tenant.DisplayName, &tenant.Description,
 			&tenant.DatabaseName, &tenant.Status, &tenant.Settings, &tenant.ResourceLimits,
 			&tenant.CreatedAt, &tenant.UpdatedAt,
 		)
@@ -211,7 +298,26 @@ func (tm *TenantManager) UpdateTenant(ctx context.Context, tenantID uuid.UUID, u
 
 	for field, value := range updates {
 		switch field {
-		case "display_name", "description", "status", "settings", "resource_limits":
+		case "slug":
+			slug, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: slug must be a string", ErrInvalidSlug)
+			}
+			if err := ValidateSlug(slug); err != nil {
+				return nil, err
+			}
+			setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argIndex))
+			args = append(args, slug)
+			argIndex++
+		case "settings":
+			settings, _ := value.(map[string]interface{})
+			if _, err := ParseMaintenanceWindows(settings); err != nil {
+				return nil, err
+			}
+			setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argIndex))
+			args = append(args, value)
+			argIndex++
+		case "display_name", "description", "status", "resource_limits":
 			setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argIndex))
 			args = append(args, value)
 			argIndex++
@@ -231,20 +337,25 @@ func (tm *TenantManager) UpdateTenant(ctx context.Context, tenantID uuid.UUID, u
 		UPDATE control_plane.tenants
 		SET %s, updated_at = NOW()
 		WHERE id = $%d
-		RETURNING id, name, display_name, description, database_name, status,
+		RETURNING id, name, slug, display_name, description, database_name, status,
 				  settings, resource_limits, created_at, updated_at
 	`, strings.Join(setParts, ", "), argIndex)
 
 	var tenant Tenant
 	err := tm.pool.QueryRow(ctx, query, args...).Scan(
-		&tenant.ID, &tenant.Name, &tenant.DisplayName, &tenant.Description,
+		&tenant.ID, &tenant.Name, &tenant.Slug, &tenant.DisplayName, &tenant.Description,
 		&tenant.DatabaseName, &tenant.Status, &tenant.Settings, &tenant.ResourceLimits,
 		&tenant.CreatedAt, &tenant.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("tenant not found: %s", tenantID)
+			return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, tenantID)
+		}
+		if slug, ok := updates["slug"].(string); ok {
+			if conflict := slugConflict(err, slug); conflict != nil {
+				return nil, conflict
+			}
 		}
 		return nil, fmt.Errorf("failed to update tenant: %w", err)
 	}
@@ -285,6 +396,48 @@ func (tm *TenantManager) DeleteTenant(ctx context.Context, tenantID uuid.UUID) e
 	return nil
 }
 
+// admitCreation applies the per-caller and global tenant creation rate limits. The
+// per-caller limit is checked first so that a single noisy caller does not use up
+// the global budget.
+func (tm *TenantManager) admitCreation(caller string) error {
+	if tm.callerCreateLimiter != nil {
+		if ok, retryAfter := tm.callerCreateLimiter.Allow(caller); !ok {
+			return &TenantCreationThrottledError{Scope: "caller", RetryAfter: retryAfter}
+		}
+	}
+	if tm.createLimiter != nil {
+		if ok, retryAfter := tm.createLimiter.Allow(); !ok {
+			return &TenantCreationThrottledError{Scope: "global", RetryAfter: retryAfter}
+		}
+	}
+	return nil
+}
+
+// checkTenantCap returns ErrTenantLimitReached if creating another tenant would exceed
+// the configured maximum. A transaction-scoped advisory lock serialises concurrent
+// creations so the count cannot be raced past the cap.
+func (tm *TenantManager) checkTenantCap(ctx context.Context, tx *Transaction) error {
+	if tm.maxTenants <= 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('control_plane.tenants'))"); err != nil {
+		return fmt.Errorf("failed to lock tenant creation: %w", err)
+	}
+
+	var count int
+	query := "SELECT COUNT(*) FROM control_plane.tenants WHERE status <> 'terminated'"
+	if err := tx.QueryRow(ctx, query).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	if count >= tm.maxTenants {
+		return ErrTenantLimitReached
+	}
+
+	return nil
+}
+
 // generateDatabaseName creates a valid PostgreSQL database name from tenant name
 func (tm *TenantManager) generateDatabaseName(tenantName string) (string, error) {
 	// PostgreSQL database naming rules:
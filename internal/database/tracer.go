@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SlowQuery is a query that took at least the tracer's threshold. SQL is the
+// statement as sent, with placeholders rather than argument values.
+type SlowQuery struct {
+	SQL      string
+	Duration time.Duration
+	Err      error
+}
+
+// QueryStats are the totals a QueryTracer has recorded
+type QueryStats struct {
+	Queries  uint64
+	Errors   uint64
+	Slow     uint64
+	Duration time.Duration
+}
+
+// QueryTracer is a pgx tracer that times every query and reports the slow ones. Set
+// it as Config.Tracer.
+type QueryTracer struct {
+	threshold time.Duration
+	onSlow    func(ctx context.Context, q SlowQuery)
+
+	queries  atomic.Uint64
+	errors   atomic.Uint64
+	slow     atomic.Uint64
+	duration atomic.Int64
+
+	// Overridden in tests to control query durations
+	now func() time.Time
+}
+
+// Compile-time check that QueryTracer implements pgx.QueryTracer
+var _ pgx.QueryTracer = (*QueryTracer)(nil)
+
+// NewQueryTracer creates a tracer that calls onSlow for queries taking at least
+// threshold. A non-positive threshold disables slow query reporting, but queries are
+// still counted.
+func NewQueryTracer(threshold time.Duration, onSlow func(ctx context.Context, q SlowQuery)) *QueryTracer {
+	return &QueryTracer{threshold: threshold, onSlow: onSlow, now: time.Now}
+}
+
+// queryStartKey stores the running query in the context pgx passes from
+// TraceQueryStart to TraceQueryEnd
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+// TraceQueryStart records when the query started
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: t.now()})
+}
+
+// TraceQueryEnd records the query's duration and reports it if it was slow
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	d := t.now().Sub(start.at)
+
+	t.queries.Add(1)
+	t.duration.Add(int64(d))
+	if data.Err != nil {
+		t.errors.Add(1)
+	}
+
+	if t.threshold > 0 && d >= t.threshold {
+		t.slow.Add(1)
+		if t.onSlow != nil {
+			t.onSlow(ctx, SlowQuery{SQL: start.sql, Duration: d, Err: data.Err})
+		}
+	}
+}
+
+// Stats returns the totals recorded so far
+func (t *QueryTracer) Stats() QueryStats {
+	return QueryStats{
+		Queries:  t.queries.Load(),
+		Errors:   t.errors.Load(),
+		Slow:     t.slow.Load(),
+		Duration: time.Duration(t.duration.Load()),
+	}
+}
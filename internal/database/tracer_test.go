@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTracer(t *testing.T) {
+	var slow []SlowQuery
+	tracer := NewQueryTracer(100*time.Millisecond, func(ctx context.Context, q SlowQuery) {
+		slow = append(slow, q)
+	})
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracer.now = func() time.Time { return clock }
+
+	run := func(sql string, d time.Duration, err error) {
+		ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql, Args: []any{"secret"}})
+		clock = clock.Add(d)
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: err})
+	}
+
+	errQuery := errors.New("canceling statement due to statement timeout")
+	run("SELECT 1", 5*time.Millisecond, nil)
+	run("SELECT * FROM resource_management.teams WHERE id = $1", 250*time.Millisecond, nil)
+	run("UPDATE resource_management.teams SET name = $1", 100*time.Millisecond, errQuery)
+	run("SELECT now()", 99*time.Millisecond, errQuery)
+
+	require.Len(t, slow, 2, "only queries at or over the threshold are reported")
+	assert.Equal(t, "SELECT * FROM resource_management.teams WHERE id = $1", slow[0].SQL, "SQL is reported without its arguments")
+	assert.Equal(t, 250*time.Millisecond, slow[0].Duration)
+	assert.NoError(t, slow[0].Err)
+	assert.ErrorIs(t, slow[1].Err, errQuery)
+
+	assert.Equal(t, QueryStats{
+		Queries:  4,
+		Errors:   2,
+		Slow:     2,
+		Duration: 454 * time.Millisecond,
+	}, tracer.Stats())
+}
+
+func TestQueryTracer_NoThreshold(t *testing.T) {
+	called := false
+	tracer := NewQueryTracer(0, func(ctx context.Context, q SlowQuery) { called = true })
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT pg_sleep(1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	assert.False(t, called)
+	assert.Equal(t, uint64(1), tracer.Stats().Queries)
+	assert.Zero(t, tracer.Stats().Slow)
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultUsageCacheTTL is how long a computed usage report is reused
+const DefaultUsageCacheTTL = 30 * time.Second
+
+// Usage limit keys read from a tenant's resource_limits
+const (
+	LimitTeams        = "teams"
+	LimitApplications = "applications"
+	LimitMembers      = "members"
+	LimitUsers        = "users"
+	LimitResources    = "resources"
+)
+
+// UsageMetric is a single usage count and the tenant's limit for it, if any
+type UsageMetric struct {
+	Used     int  `json:"used"`
+	Limit    *int `json:"limit,omitempty"`
+	Exceeded bool `json:"exceeded"`
+}
+
+// TenantUsage reports live counts across a tenant's data compared against its limits
+type TenantUsage struct {
+	TenantID        uuid.UUID      `json:"tenant_id"`
+	Teams           UsageMetric    `json:"teams"`
+	Applications    UsageMetric    `json:"applications"`
+	Members         UsageMetric    `json:"members"`
+	Users           UsageMetric    `json:"users"`
+	Resources       UsageMetric    `json:"resources"`
+	ResourcesByType map[string]int `json:"resources_by_type"`
+	ComputedAt      time.Time      `json:"computed_at"`
+}
+
+// WithUsageCacheTTL sets how long usage reports are cached. Zero disables caching.
+func WithUsageCacheTTL(ttl time.Duration) TenantManagerOption {
+	return func(tm *TenantManager) {
+		tm.usage = newUsageCache(ttl)
+	}
+}
+
+// GetTenantUsage returns the tenant's usage report. Reports are aggregate-heavy, so
+// they are cached briefly and may lag writes by up to the cache TTL.
+func (tm *TenantManager) GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*TenantUsage, error) {
+	if usage, ok := tm.usage.get(tenantID); ok {
+		return usage, nil
+	}
+
+	tenant, err := tm.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := tm.computeUsage(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	tm.usage.put(tenantID, usage)
+	return usage, nil
+}
+
+// computeUsage counts the tenant's teams, applications, distinct team members,
+// active users and managed resources
+func (tm *TenantManager) computeUsage(ctx context.Context, tenant *Tenant) (*TenantUsage, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM resource_management.teams WHERE tenant_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM resource_management.applications WHERE tenant_id = $1 AND status <> 'terminated'),
+			(SELECT COUNT(DISTINCT lower(m->>'email'))
+			   FROM resource_management.teams t, jsonb_array_elements(t.members) m
+			  WHERE t.tenant_id = $1 AND t.deleted_at IS NULL AND COALESCE(m->>'email', '') <> ''),
+			(SELECT COUNT(*) FROM user_management.tenant_memberships WHERE tenant_id = $1 AND is_active)
+	`
+
+	var teams, apps, members, users int
+	if err := tm.pool.QueryRow(ctx, query, tenant.ID).Scan(&teams, &apps, &members, &users); err != nil {
+		return nil, fmt.Errorf("failed to count tenant usage: %w", err)
+	}
+
+	rows, err := tm.pool.Query(ctx, `
+		SELECT resource_type, COUNT(*) FROM resource_management.resources
+		WHERE tenant_id = $1
+		GROUP BY resource_type
+	`, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tenant resources: %w", err)
+	}
+	defer rows.Close()
+
+	byType := make(map[string]int)
+	resources := 0
+	for rows.Next() {
+		var resourceType string
+		var count int
+		if err := rows.Scan(&resourceType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan resource count: %w", err)
+		}
+		byType[resourceType] = count
+		resources += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count tenant resources: %w", err)
+	}
+
+	limits := tenant.ResourceLimits
+	return &TenantUsage{
+		TenantID:        tenant.ID,
+		Teams:           newUsageMetric(teams, limits, LimitTeams),
+		Applications:    newUsageMetric(apps, limits, LimitApplications),
+		Members:         newUsageMetric(members, limits, LimitMembers),
+		Users:           newUsageMetric(users, limits, LimitUsers),
+		Resources:       newUsageMetric(resources, limits, LimitResources),
+		ResourcesByType: byType,
+		ComputedAt:      time.Now().UTC(),
+	}, nil
+}
+
+// newUsageMetric pairs a count with its limit from resource_limits. Missing or
+// non-positive limits mean unlimited.
+func newUsageMetric(used int, limits map[string]interface{}, key string) UsageMetric {
+	metric := UsageMetric{Used: used}
+
+	if limit := limitFor(limits, key); limit > 0 {
+		metric.Limit = &limit
+		metric.Exceeded = used > limit
+	}
+	return metric
+}
+
+// limitFor returns the limit for key in resource_limits, or 0 when it is missing or
+// not a number
+func limitFor(limits map[string]interface{}, key string) int {
+	switch v := limits[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
+
+// usageCache holds recently computed usage reports. A nil cache stores nothing.
+type usageCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]cachedUsage
+}
+
+type cachedUsage struct {
+	usage   *TenantUsage
+	expires time.Time
+}
+
+func newUsageCache(ttl time.Duration) *usageCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &usageCache{ttl: ttl, now: time.Now, entries: make(map[uuid.UUID]cachedUsage)}
+}
+
+func (c *usageCache) get(tenantID uuid.UUID) (*TenantUsage, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[tenantID]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, tenantID)
+		return nil, false
+	}
+	return entry.usage, true
+}
+
+func (c *usageCache) put(tenantID uuid.UUID, usage *TenantUsage) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[tenantID] = cachedUsage{usage: usage, expires: c.now().Add(c.ttl)}
+}
@@ -0,0 +1,34 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/middleware"
+)
+
+// RegisterConfig mounts GET /debug/config, serving the effective configuration with
+// its secrets redacted, when Server.EnableDebugConfig is set. Like the profiling
+// endpoints it requires the admin token, and nothing is registered when disabled.
+func RegisterConfig(mux *http.ServeMux, cfg *config.Config) bool {
+	if !cfg.Server.EnableDebugConfig {
+		return false
+	}
+
+	admin := middleware.RequireAdmin(cfg.Security.AdminToken)
+
+	// The configuration does not change while the service runs
+	body, err := json.Marshal(cfg.Redacted())
+	mux.Handle("GET /debug/config", admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(body)
+	})))
+
+	return true
+}
@@ -0,0 +1,31 @@
+// Package debug provides operational endpoints that are disabled by default and only
+// served to administrators when explicitly enabled.
+package debug
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/middleware"
+)
+
+// RegisterProfiling mounts the net/http/pprof handlers under /debug/pprof/ when
+// Server.EnableProfiling is set. The handlers require the admin token; when profiling
+// is disabled nothing is registered and the paths return 404.
+func RegisterProfiling(mux *http.ServeMux, cfg *config.Config) bool {
+	if !cfg.Server.EnableProfiling {
+		return false
+	}
+
+	admin := middleware.RequireAdmin(cfg.Security.AdminToken)
+
+	mux.Handle("GET /debug/pprof/", admin(http.HandlerFunc(pprof.Index)))
+	mux.Handle("GET /debug/pprof/cmdline", admin(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("GET /debug/pprof/profile", admin(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("GET /debug/pprof/symbol", admin(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("POST /debug/pprof/symbol", admin(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("GET /debug/pprof/trace", admin(http.HandlerFunc(pprof.Trace)))
+
+	return true
+}
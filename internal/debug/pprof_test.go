@@ -0,0 +1,74 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterProfiling(t *testing.T) {
+	paths := []string{"/debug/pprof/", "/debug/pprof/goroutine", "/debug/pprof/cmdline"}
+
+	t.Run("disabled returns 404", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Security.AdminToken = "admin-token"
+
+		mux := http.NewServeMux()
+		assert.False(t, RegisterProfiling(mux, cfg))
+
+		for _, path := range paths {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer admin-token")
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code, path)
+		}
+	})
+
+	t.Run("enabled requires admin auth", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Server.EnableProfiling = true
+		cfg.Security.AdminToken = "admin-token"
+
+		mux := http.NewServeMux()
+		assert.True(t, RegisterProfiling(mux, cfg))
+
+		for _, path := range paths {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, path)
+
+			req = httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer wrong-token")
+			rr = httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, path)
+
+			req = httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer admin-token")
+			rr = httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code, path)
+		}
+	})
+
+	t.Run("enabled without an admin token denies everyone", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Server.EnableProfiling = true
+
+		mux := http.NewServeMux()
+		RegisterProfiling(mux, cfg)
+
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
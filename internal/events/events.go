@@ -0,0 +1,58 @@
+// Package events publishes domain events when platform resources change, so that
+// downstream consumers such as notifications and search indexing can react without
+// polling.
+package events
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Event announces a successful change to a resource
+type Event struct {
+	// Type is the lower-case resource kind and past-tense action, such as
+	// team.created or application.deleted
+	Type      string                  `json:"type"`
+	TenantID  string                  `json:"tenant_id"`
+	Resource  types.ResourceReference `json:"resource_ref"`
+	Timestamp time.Time               `json:"timestamp"`
+	Actor     types.Actor             `json:"actor"`
+}
+
+// Publisher delivers events. Publishing is best effort: it never blocks on the
+// transport and never fails the change being announced.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// pastTense maps audit actions to the verbs used in event types
+var pastTense = map[string]string{
+	"create":  "created",
+	"update":  "updated",
+	"delete":  "deleted",
+	"restore": "restored",
+}
+
+// Type returns the event type for action on a resource of kind, for example
+// Type("Team", "create") is "team.created"
+func Type(kind, action string) string {
+	verb, ok := pastTense[action]
+	if !ok {
+		verb = action
+	}
+	return strings.ToLower(kind) + "." + verb
+}
+
+// FromAudit builds the event announcing the change an audit event records
+func FromAudit(e types.AuditEvent) Event {
+	return Event{
+		Type:      Type(e.Spec.Resource.Kind, e.Spec.Action),
+		TenantID:  e.Spec.Resource.Namespace,
+		Resource:  e.Spec.Resource,
+		Timestamp: e.Spec.Timestamp,
+		Actor:     e.Spec.Actor,
+	}
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/audit"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestType(t *testing.T) {
+	assert.Equal(t, "team.created", Type(audit.KindTeam, audit.ActionCreate))
+	assert.Equal(t, "application.deleted", Type(audit.KindApplication, audit.ActionDelete))
+	assert.Equal(t, "team.restored", Type(audit.KindTeam, audit.ActionRestore))
+	assert.Equal(t, "team.archive", Type(audit.KindTeam, "archive"), "unknown actions are used as they are")
+}
+
+func TestFromAudit(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.UserIDKey, "alice@company.com")
+	auditEvent := audit.NewEvent(ctx, audit.ActionUpdate, types.ResourceReference{Kind: audit.KindApplication, Name: "orders-api", Namespace: "tenant-1", UID: "42"}, nil)
+
+	event := FromAudit(auditEvent)
+
+	assert.Equal(t, "application.updated", event.Type)
+	assert.Equal(t, "tenant-1", event.TenantID)
+	assert.Equal(t, auditEvent.Spec.Resource, event.Resource)
+	assert.Equal(t, auditEvent.Spec.Timestamp, event.Timestamp)
+	assert.Equal(t, types.Actor{Type: types.ActorTypeUser, ID: "alice@company.com"}, event.Actor)
+
+	// Failures are not announced, but the conversion does not depend on the result
+	failed := audit.NewEvent(ctx, audit.ActionDelete, types.ResourceReference{Kind: audit.KindTeam}, errors.New("not found"))
+	assert.Equal(t, "team.deleted", FromAudit(failed).Type)
+}
@@ -0,0 +1,283 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for RedisPublisher
+const (
+	DefaultChannel   = "ai-idp.events"
+	DefaultQueueSize = 1024
+	redisTimeout     = 2 * time.Second
+)
+
+// ErrQueueFull is reported when an event is dropped because the publisher is
+// still delivering earlier ones
+var ErrQueueFull = errors.New("event queue is full")
+
+// ErrPublisherClosed is reported for events published after Close
+var ErrPublisherClosed = errors.New("event publisher is closed")
+
+// RedisOptions configures a RedisPublisher
+type RedisOptions struct {
+	// URL locates the server as redis://[:password@]host[:port][/db]
+	URL string
+	// Password and DB override the URL's when set
+	Password string
+	DB       int
+	// Channel is the pub/sub channel events are published to; defaults to
+	// DefaultChannel
+	Channel string
+	// QueueSize bounds the events waiting for delivery; defaults to DefaultQueueSize
+	QueueSize int
+	// OnError is told about events that could not be delivered; they are dropped
+	OnError func(event Event, err error)
+}
+
+type queuedEvent struct {
+	event   Event
+	payload []byte
+}
+
+// RedisPublisher publishes events as JSON to a Redis pub/sub channel. Publish only
+// queues the event; a single goroutine delivers the queue in order, reconnecting
+// after failures, so a Redis outage delays or drops events but never blocks callers.
+// It speaks the Redis protocol directly, as the metrics package does Prometheus', so
+// services need no client library.
+type RedisPublisher struct {
+	addr     string
+	password string
+	db       int
+	channel  string
+	onError  func(event Event, err error)
+	dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	queue chan queuedEvent
+	done  chan struct{}
+	// mu guards closing the queue against concurrent Publish calls
+	mu     sync.RWMutex
+	closed bool
+
+	// Owned by the delivery goroutine
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Compile-time check that RedisPublisher implements Publisher
+var _ Publisher = (*RedisPublisher)(nil)
+
+// NewRedisPublisher starts a publisher for the server in opts. It does not connect
+// until the first event, so it can be created while Redis is down.
+func NewRedisPublisher(opts RedisOptions) (*RedisPublisher, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid redis URL: unsupported scheme %q", u.Scheme)
+	}
+
+	p := &RedisPublisher{
+		addr:    u.Host,
+		channel: opts.Channel,
+		onError: opts.OnError,
+		dial:    (&net.Dialer{Timeout: redisTimeout}).DialContext,
+		done:    make(chan struct{}),
+	}
+	if u.Port() == "" {
+		p.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		p.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if p.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis URL: database %q is not a number", db)
+		}
+	}
+	if opts.Password != "" {
+		p.password = opts.Password
+	}
+	if opts.DB != 0 {
+		p.db = opts.DB
+	}
+	if p.channel == "" {
+		p.channel = DefaultChannel
+	}
+	if p.onError == nil {
+		p.onError = func(Event, error) {}
+	}
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	p.queue = make(chan queuedEvent, queueSize)
+
+	go p.run()
+	return p, nil
+}
+
+// Publish queues event for delivery. When the queue is full the event is dropped
+// and reported to OnError.
+func (p *RedisPublisher) Publish(ctx context.Context, event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		p.onError(event, fmt.Errorf("failed to marshal event: %w", err))
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.onError(event, ErrPublisherClosed)
+		return
+	}
+
+	select {
+	case p.queue <- queuedEvent{event: event, payload: payload}:
+	default:
+		p.onError(event, ErrQueueFull)
+	}
+}
+
+// Close delivers the events already queued and disconnects. Events published after
+// Close are reported to OnError with ErrPublisherClosed.
+func (p *RedisPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	return nil
+}
+
+func (p *RedisPublisher) run() {
+	defer close(p.done)
+	defer p.disconnect()
+
+	for queued := range p.queue {
+		reused := p.conn != nil
+		err := p.publish(queued.payload)
+		if err != nil && reused {
+			// The connection may have gone stale while idle; retry once on a new one
+			p.disconnect()
+			err = p.publish(queued.payload)
+		}
+		if err != nil {
+			p.disconnect()
+			p.onError(queued.event, err)
+		}
+	}
+}
+
+// publish sends PUBLISH channel payload, connecting first when needed
+func (p *RedisPublisher) publish(payload []byte) error {
+	if p.conn == nil {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+	if _, err := p.do("PUBLISH", p.channel, string(payload)); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+func (p *RedisPublisher) connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	conn, err := p.dial(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if p.password != "" {
+		if _, err := p.do("AUTH", p.password); err != nil {
+			p.disconnect()
+			return fmt.Errorf("failed to authenticate to redis: %w", err)
+		}
+	}
+	if p.db != 0 {
+		if _, err := p.do("SELECT", strconv.Itoa(p.db)); err != nil {
+			p.disconnect()
+			return fmt.Errorf("failed to select redis database %d: %w", p.db, err)
+		}
+	}
+	return nil
+}
+
+func (p *RedisPublisher) disconnect() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+		p.reader = nil
+	}
+}
+
+// do sends a command and reads its reply
+func (p *RedisPublisher) do(args ...string) (string, error) {
+	if err := p.conn.SetDeadline(time.Now().Add(redisTimeout)); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(p.conn, b.String()); err != nil {
+		return "", err
+	}
+
+	return readReply(p.reader)
+}
+
+// readReply reads a simple string, error, integer or bulk string reply
+func readReply(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid redis bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return "", nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return "", err
+		}
+		return string(data[:n]), nil
+	}
+	return "", fmt.Errorf("unsupported redis reply %q", line)
+}
@@ -0,0 +1,238 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRedis is a TCP server that passes the commands it receives to the test and
+// answers each with +OK, or the reply set for the command name
+type stubRedis struct {
+	listener net.Listener
+	replies  map[string]string
+	received chan []string
+}
+
+func newStubRedis(t *testing.T, replies map[string]string) *stubRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &stubRedis{listener: listener, replies: replies, received: make(chan []string, 100)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *stubRedis) url() string {
+	return "redis://" + s.listener.Addr().String()
+}
+
+func (s *stubRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for {
+		command, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.received <- command
+
+		reply, ok := s.replies[command[0]]
+		if !ok {
+			reply = "+OK"
+		}
+		fmt.Fprintf(conn, "%s\r\n", reply)
+	}
+}
+
+// next waits for the next command
+func (s *stubRedis) next(t *testing.T) []string {
+	t.Helper()
+	select {
+	case command := <-s.received:
+		return command
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a redis command")
+		return nil
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedisPublisher_Publish(t *testing.T) {
+	stub := newStubRedis(t, map[string]string{"PUBLISH": ":1"})
+
+	publisher, err := NewRedisPublisher(RedisOptions{URL: stub.url(), Password: "secret", DB: 2, Channel: "test.events"})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	event := Event{
+		Type:      "team.created",
+		TenantID:  "tenant-1",
+		Resource:  types.ResourceReference{APIVersion: "v1", Kind: "Team", Name: "platform", Namespace: "tenant-1", UID: "42"},
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Actor:     types.Actor{Type: types.ActorTypeUser, ID: "alice@company.com"},
+	}
+	publisher.Publish(context.Background(), event)
+
+	assert.Equal(t, []string{"AUTH", "secret"}, stub.next(t))
+	assert.Equal(t, []string{"SELECT", "2"}, stub.next(t))
+
+	command := stub.next(t)
+	require.Len(t, command, 3)
+	assert.Equal(t, "PUBLISH", command[0])
+	assert.Equal(t, "test.events", command[1])
+	assert.JSONEq(t, `{
+		"type": "team.created",
+		"tenant_id": "tenant-1",
+		"resource_ref": {"api_version": "v1", "kind": "Team", "name": "platform", "namespace": "tenant-1", "uid": "42"},
+		"timestamp": "2024-01-15T10:30:00Z",
+		"actor": {"type": "user", "id": "alice@company.com"}
+	}`, command[2])
+
+	// Later events reuse the connection
+	publisher.Publish(context.Background(), Event{Type: "team.deleted"})
+	command = stub.next(t)
+	assert.Equal(t, "PUBLISH", command[0])
+	assert.Equal(t, "team.deleted", decodeEvent(t, command[2]).Type)
+}
+
+func TestRedisPublisher_Failures(t *testing.T) {
+	t.Run("an unreachable server drops the event without blocking", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		listener.Close()
+
+		failed := make(chan error, 1)
+		publisher, err := NewRedisPublisher(RedisOptions{
+			URL:     "redis://" + addr,
+			OnError: func(event Event, err error) { failed <- err },
+		})
+		require.NoError(t, err)
+		defer publisher.Close()
+
+		publisher.Publish(context.Background(), Event{Type: "team.created"})
+
+		select {
+		case err := <-failed:
+			assert.Contains(t, err.Error(), "failed to connect")
+		case <-time.After(5 * time.Second):
+			t.Fatal("the failure was not reported")
+		}
+	})
+
+	t.Run("an error reply is reported", func(t *testing.T) {
+		stub := newStubRedis(t, map[string]string{"PUBLISH": "-ERR wrong type"})
+
+		failed := make(chan error, 1)
+		publisher, err := NewRedisPublisher(RedisOptions{
+			URL:     stub.url(),
+			OnError: func(event Event, err error) { failed <- err },
+		})
+		require.NoError(t, err)
+		defer publisher.Close()
+
+		publisher.Publish(context.Background(), Event{Type: "team.created"})
+
+		select {
+		case err := <-failed:
+			assert.Contains(t, err.Error(), "wrong type")
+		case <-time.After(5 * time.Second):
+			t.Fatal("the failure was not reported")
+		}
+	})
+
+	t.Run("a full queue drops the event", func(t *testing.T) {
+		var dropped []error
+		p := &RedisPublisher{
+			queue:   make(chan queuedEvent, 1),
+			onError: func(event Event, err error) { dropped = append(dropped, err) },
+		}
+
+		p.Publish(context.Background(), Event{Type: "team.created"})
+		p.Publish(context.Background(), Event{Type: "team.updated"})
+
+		assert.Equal(t, []error{ErrQueueFull}, dropped)
+	})
+
+	t.Run("publishing after close is reported", func(t *testing.T) {
+		stub := newStubRedis(t, nil)
+
+		var dropped []error
+		publisher, err := NewRedisPublisher(RedisOptions{
+			URL:     stub.url(),
+			OnError: func(event Event, err error) { dropped = append(dropped, err) },
+		})
+		require.NoError(t, err)
+		require.NoError(t, publisher.Close())
+
+		publisher.Publish(context.Background(), Event{Type: "team.created"})
+		assert.Equal(t, []error{ErrPublisherClosed}, dropped)
+	})
+}
+
+func TestNewRedisPublisher_InvalidURL(t *testing.T) {
+	for _, url := range []string{"http://localhost:6379", "redis://localhost:6379/zero", "://"} {
+		_, err := NewRedisPublisher(RedisOptions{URL: url})
+		assert.Error(t, err, url)
+	}
+}
+
+func decodeEvent(t *testing.T, payload string) Event {
+	t.Helper()
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(payload), &event))
+	return event
+}
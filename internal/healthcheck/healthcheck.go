@@ -0,0 +1,182 @@
+// Package healthcheck serves the health and Kubernetes probe endpoints of the
+// services. Health and liveness only report that the process is serving requests;
+// readiness checks the database, so traffic is not routed to a pod that cannot reach
+// it.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+)
+
+// DefaultTimeout bounds the database ping of a readiness check, well inside the
+// probe timeouts Kubernetes uses
+const DefaultTimeout = 2 * time.Second
+
+// Response is the body of the probe endpoints
+type Response struct {
+	Status    string          `json:"status"`
+	Timestamp time.Time       `json:"timestamp"`
+	Service   string          `json:"service"`
+	Version   string          `json:"version,omitempty"`
+	Mode      string          `json:"mode,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	Database  *DatabaseStatus `json:"database,omitempty"`
+}
+
+// DatabaseStatus reports the database a readiness check pinged
+type DatabaseStatus struct {
+	Status string                   `json:"status"`
+	Stats  database.ConnectionStats `json:"stats"`
+}
+
+// Database is the part of database.Pool that readiness checks use
+type Database interface {
+	Ping(ctx context.Context) error
+	Stats() database.ConnectionStats
+}
+
+// RegisterHandlers registers the probe endpoints of service on mux: GET /health,
+// GET /liveness and GET /readiness, which checks db as configured by opts. A nil db
+// is not checked.
+func RegisterHandlers(mux *http.ServeMux, service string, db Database, opts ...Option) {
+	RegisterWithReadiness(mux, service, NewReadiness(service, db, opts...))
+}
+
+// RegisterWithReadiness is RegisterHandlers for services with their own readiness
+// check, such as the gateway's check of its backends
+func RegisterWithReadiness(mux *http.ServeMux, service string, readiness http.Handler) {
+	mux.Handle("GET /health", Health(service))
+	mux.Handle("GET /liveness", Liveness(service))
+	mux.Handle("GET /readiness", readiness)
+}
+
+// Readiness answers readiness probes: 200 while the database answers a ping and
+// every extra check passes, 503 otherwise
+type Readiness struct {
+	service  string
+	db       Database
+	timeout  time.Duration
+	failover *database.Failover
+	checks   []func() error
+}
+
+// Option configures a Readiness
+type Option func(*Readiness)
+
+// WithTimeout bounds the database ping; it defaults to DefaultTimeout
+func WithTimeout(d time.Duration) Option {
+	return func(r *Readiness) {
+		r.timeout = d
+	}
+}
+
+// WithFailover keeps the service ready in read-only mode, checking the replica that
+// reads are served from instead of the unreachable primary. A nil failover is ignored.
+func WithFailover(f *database.Failover) Option {
+	return func(r *Readiness) {
+		r.failover = f
+	}
+}
+
+// WithCheck adds a condition the service must meet to be ready, such as its
+// background workers running; the error is reported as the reason it is not
+func WithCheck(check func() error) Option {
+	return func(r *Readiness) {
+		r.checks = append(r.checks, check)
+	}
+}
+
+// NewReadiness creates the readiness handler for service, checking db
+func NewReadiness(service string, db Database, opts ...Option) *Readiness {
+	r := &Readiness{
+		service: service,
+		db:      db,
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ServeHTTP implements http.Handler
+func (rd *Readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response := Response{
+		Status:    "ready",
+		Timestamp: time.Now().UTC(),
+		Service:   rd.service,
+	}
+
+	var db Database = rd.db
+	// Still ready: reads are served from the replica while writes are refused
+	if rd.failover.ReadOnly() {
+		db = rd.failover.Reader()
+		response.Mode = "read-only"
+	}
+
+	if db != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), rd.timeout)
+		defer cancel()
+
+		response.Database = &DatabaseStatus{Status: "healthy"}
+		if err := db.Ping(ctx); err != nil {
+			response.Database.Status = "unhealthy"
+			response.Status = "not ready"
+			response.Reason = "database unreachable: " + err.Error()
+		}
+		response.Database.Stats = db.Stats()
+	}
+
+	if response.Reason == "" {
+		for _, check := range rd.checks {
+			if err := check(); err != nil {
+				response.Status = "not ready"
+				response.Reason = err.Error()
+				break
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if response.Reason != "" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+// Health answers health checks with the service name and the VERSION it was
+// deployed as
+func Health(service string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Response{
+			Status:    "healthy",
+			Timestamp: time.Now().UTC(),
+			Service:   service,
+			Version:   os.Getenv("VERSION"),
+		})
+	}
+}
+
+// Liveness answers liveness probes without touching dependencies, so a database
+// outage never gets the pod restarted
+func Liveness(service string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Response{
+			Status:    "alive",
+			Timestamp: time.Now().UTC(),
+			Service:   service,
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
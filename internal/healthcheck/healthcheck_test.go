@@ -0,0 +1,143 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDatabase pings with ping and reports a fixed pool
+type fakeDatabase struct {
+	ping func(ctx context.Context) error
+}
+
+func (f fakeDatabase) Ping(ctx context.Context) error {
+	return f.ping(ctx)
+}
+
+func (f fakeDatabase) Stats() database.ConnectionStats {
+	return database.ConnectionStats{TotalConnections: 5, IdleConnections: 3, UsedConnections: 2}
+}
+
+func probe(t *testing.T, handler http.Handler, path string) (int, Response) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	var response Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return rr.Code, response
+}
+
+func TestReadiness(t *testing.T) {
+	up := fakeDatabase{ping: func(context.Context) error { return nil }}
+	down := fakeDatabase{ping: func(context.Context) error { return errors.New("connection refused") }}
+
+	t.Run("ready while the database answers", func(t *testing.T) {
+		code, response := probe(t, NewReadiness("ai-idp-team-service", up), "/readiness")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", response.Status)
+		assert.Equal(t, "ai-idp-team-service", response.Service)
+		require.NotNil(t, response.Database)
+		assert.Equal(t, "healthy", response.Database.Status)
+		assert.Equal(t, int32(5), response.Database.Stats.TotalConnections)
+		assert.Equal(t, int32(2), response.Database.Stats.UsedConnections)
+	})
+
+	t.Run("not ready while the database is down", func(t *testing.T) {
+		code, response := probe(t, NewReadiness("ai-idp-team-service", down), "/readiness")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not ready", response.Status)
+		assert.Contains(t, response.Reason, "connection refused")
+		require.NotNil(t, response.Database)
+		assert.Equal(t, "unhealthy", response.Database.Status)
+	})
+
+	t.Run("a hanging database times out", func(t *testing.T) {
+		hanging := fakeDatabase{ping: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		start := time.Now()
+		code, _ := probe(t, NewReadiness("ai-idp-team-service", hanging, WithTimeout(10*time.Millisecond)), "/readiness")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Less(t, time.Since(start), DefaultTimeout)
+	})
+
+	t.Run("failed checks make the service not ready", func(t *testing.T) {
+		readiness := NewReadiness("ai-idp-application-service", up, WithCheck(func() error {
+			return errors.New("a critical background worker is not running")
+		}))
+
+		code, response := probe(t, readiness, "/readiness")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "a critical background worker is not running", response.Reason)
+	})
+
+	t.Run("a nil failover is ignored", func(t *testing.T) {
+		code, response := probe(t, NewReadiness("ai-idp-team-service", up, WithFailover(nil)), "/readiness")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response.Mode)
+	})
+}
+
+func TestLiveness(t *testing.T) {
+	code, response := probe(t, Liveness("ai-idp-team-service"), "/liveness")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "alive", response.Status)
+	assert.Equal(t, "ai-idp-team-service", response.Service)
+	assert.Nil(t, response.Database, "liveness does not touch the database")
+}
+
+func TestRegisterHandlers(t *testing.T) {
+	t.Setenv("VERSION", "1.4.2")
+
+	t.Run("services get health, liveness and database readiness", func(t *testing.T) {
+		mux := http.NewServeMux()
+		RegisterHandlers(mux, "ai-idp-team-service", fakeDatabase{ping: func(context.Context) error { return nil }})
+
+		for path, status := range map[string]string{"/health": "healthy", "/liveness": "alive", "/readiness": "ready"} {
+			code, response := probe(t, mux, path)
+			assert.Equal(t, http.StatusOK, code, path)
+			assert.Equal(t, status, response.Status, path)
+			assert.Equal(t, "ai-idp-team-service", response.Service, path)
+		}
+
+		_, health := probe(t, mux, "/health")
+		assert.Equal(t, "1.4.2", health.Version)
+		_, readiness := probe(t, mux, "/readiness")
+		assert.NotNil(t, readiness.Database)
+	})
+
+	t.Run("services without a database are not checked for one", func(t *testing.T) {
+		mux := http.NewServeMux()
+		RegisterHandlers(mux, "ai-idp-worker", nil)
+
+		code, response := probe(t, mux, "/readiness")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Nil(t, response.Database)
+	})
+
+	t.Run("a service's own readiness check is served", func(t *testing.T) {
+		mux := http.NewServeMux()
+		RegisterWithReadiness(mux, "ai-idp-api-gateway", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusServiceUnavailable, Response{Status: "not ready", Service: "ai-idp-api-gateway"})
+		}))
+
+		code, _ := probe(t, mux, "/readiness")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		code, response := probe(t, mux, "/health")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ai-idp-api-gateway", response.Service)
+	})
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAsyncBufferSize is the number of log records buffered by an AsyncWriter
+const DefaultAsyncBufferSize = 1024
+
+// AsyncWriter decouples log calls from a potentially slow output. Records are queued
+// in a bounded buffer and written by a background goroutine; when the buffer is full
+// records are dropped and counted instead of blocking the caller.
+type AsyncWriter struct {
+	out     io.Writer
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncWriter creates an AsyncWriter that buffers up to bufferSize records for out
+func NewAsyncWriter(out io.Writer, bufferSize int) *AsyncWriter {
+	if bufferSize <= 0 {
+		bufferSize = DefaultAsyncBufferSize
+	}
+
+	w := &AsyncWriter{
+		out:   out,
+		queue: make(chan []byte, bufferSize),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+
+	return w
+}
+
+// Write enqueues a copy of p. It never blocks on the underlying writer; once the
+// writer has been shut down records are written synchronously.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return w.out.Write(p)
+	}
+
+	record := make([]byte, len(p))
+	copy(record, p)
+
+	select {
+	case w.queue <- record:
+	default:
+		w.dropped.Add(1)
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns the number of records discarded because the buffer was full
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Shutdown stops accepting buffered records and waits for the queue to be flushed
+// or for ctx to be done
+func (w *AsyncWriter) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run writes queued records to the underlying writer until the queue is closed
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for record := range w.queue {
+		_, _ = w.out.Write(record)
+	}
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks every write until released
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncLogger_SlowWriterDoesNotBlock(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	logger := NewWithOptions("info", "json", Options{Output: out, Async: true, BufferSize: 4})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			logger.Info("test message", "i", i)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected log calls not to block on a slow writer")
+	}
+
+	if logger.DroppedRecords() == 0 {
+		t.Error("Expected overflowing records to be counted as dropped")
+	}
+
+	close(out.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected shutdown to flush, got %v", err)
+	}
+
+	if !strings.Contains(out.String(), "test message") {
+		t.Error("Expected buffered records to be flushed on shutdown")
+	}
+}
+
+func TestAsyncWriter_DropCounter(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	w := NewAsyncWriter(out, 2)
+
+	// The first record may be picked up by the background goroutine, so write enough
+	// to overflow the buffer regardless
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write([]byte("record\n")); err != nil {
+			t.Fatalf("Unexpected write error: %v", err)
+		}
+	}
+
+	if dropped := w.Dropped(); dropped < 7 {
+		t.Errorf("Expected at least 7 dropped records, got %d", dropped)
+	}
+
+	close(out.release)
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+
+	// Writes after shutdown go straight to the output
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Unexpected write error: %v", err)
+	}
+	if !strings.Contains(out.String(), "after") {
+		t.Error("Expected write after shutdown to reach the output")
+	}
+}
+
+func TestSyncLogger_Shutdown(t *testing.T) {
+	logger := New("info", "json")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no error for synchronous logger, got %v", err)
+	}
+	if logger.DroppedRecords() != 0 {
+		t.Error("Expected synchronous logger to never drop records")
+	}
+}
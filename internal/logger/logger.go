@@ -2,15 +2,39 @@ package logger
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
 )
 
 // Logger wraps slog.Logger with additional functionality
 type Logger struct {
 	*slog.Logger
 	level slog.Level
+	async *AsyncWriter
+	file  *RotatingFile
+}
+
+// Options configures a logger created with NewWithOptions
+type Options struct {
+	// Output is where log records are written; defaults to os.Stdout
+	Output io.Writer
+	// Async buffers log records and writes them from a background goroutine so that
+	// a slow consumer cannot block callers; records are dropped when the buffer is full
+	Async bool
+	// BufferSize is the number of records buffered in async mode
+	BufferSize int
+	// Destination is DestinationStdout (the default), DestinationFile or
+	// DestinationBoth. File destinations write to a RotatingFile at FilePath that is
+	// rotated at MaxSizeMB and keeps MaxBackups backups; Output is not used for
+	// DestinationFile.
+	Destination string
+	FilePath    string
+	MaxSizeMB   int
+	MaxBackups  int
 }
 
 // LogFields represents a map of structured log fields
@@ -32,8 +56,39 @@ const (
 
 // New creates a new logger instance with the specified level and format
 func New(level string, format string) *Logger {
+	return NewWithOptions(level, format, Options{})
+}
+
+// NewWithOptions creates a new logger instance with the specified level, format and options
+func NewWithOptions(level string, format string, options Options) *Logger {
 	logLevel := parseLevel(level)
 
+	out := options.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	// A log file that cannot be opened must not stop the service; records then go to
+	// Output, starting with the reason
+	var file *RotatingFile
+	var fileErr error
+	if options.Destination == DestinationFile || options.Destination == DestinationBoth {
+		file, fileErr = NewRotatingFile(options.FilePath, options.MaxSizeMB, options.MaxBackups)
+		switch {
+		case fileErr != nil:
+		case options.Destination == DestinationBoth:
+			out = io.MultiWriter(out, file)
+		default:
+			out = file
+		}
+	}
+
+	var async *AsyncWriter
+	if options.Async {
+		async = NewAsyncWriter(out, options.BufferSize)
+		out = async
+	}
+
 	opts := &slog.HandlerOptions{
 		Level:     logLevel,
 		AddSource: logLevel <= slog.LevelDebug, // Add source info for debug level
@@ -41,15 +96,43 @@ func New(level string, format string) *Logger {
 
 	var handler slog.Handler
 	if format == "json" {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(out, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(out, opts)
 	}
 
-	return &Logger{
+	logger := &Logger{
 		Logger: slog.New(handler),
 		level:  logLevel,
+		async:  async,
+		file:   file,
+	}
+	if fileErr != nil {
+		logger.Error("Failed to open log file", FieldError, fileErr.Error())
+	}
+	return logger
+}
+
+// Shutdown flushes buffered log records when the logger is asynchronous and closes
+// its log file
+func (l *Logger) Shutdown(ctx context.Context) error {
+	if l.async != nil {
+		if err := l.async.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if l.file != nil {
+		return l.file.Close()
+	}
+	return nil
+}
+
+// DroppedRecords returns the number of log records dropped because the async buffer was full
+func (l *Logger) DroppedRecords() uint64 {
+	if l.async == nil {
+		return 0
 	}
+	return l.async.Dropped()
 }
 
 // parseLevel converts string level to slog.Level
@@ -73,24 +156,29 @@ func (l *Logger) IsLevelEnabled(level slog.Level) bool {
 	return l.level <= level
 }
 
-// WithContext returns a logger with context values extracted
+// WithContext returns a logger with the request, user and tenant IDs on ctx as
+// fields. They are read from the types context keys that the RequestID and JWTAuth
+// middleware set, so handlers should log through h.logger.WithContext(r.Context())
+// to have their records correlated with the request.
 func (l *Logger) WithContext(ctx context.Context) *Logger {
 	logger := l.Logger
 
 	// Extract common context values and add them as fields
-	if requestID := ctx.Value(FieldRequestID); requestID != nil {
+	if requestID := types.RequestIDFromContext(ctx); requestID != "" {
 		logger = logger.With(FieldRequestID, requestID)
 	}
-	if userID := ctx.Value(FieldUserID); userID != nil {
+	if userID := ctx.Value(types.UserIDKey); userID != nil {
 		logger = logger.With(FieldUserID, userID)
 	}
-	if tenantID := ctx.Value(FieldTenantID); tenantID != nil {
+	if tenantID := ctx.Value(types.TenantIDKey); tenantID != nil {
 		logger = logger.With(FieldTenantID, tenantID)
 	}
 
 	return &Logger{
 		Logger: logger,
 		level:  l.level,
+		async:  l.async,
+		file:   l.file,
 	}
 }
 
@@ -108,6 +196,8 @@ func (l *Logger) WithFields(fields LogFields) *Logger {
 	return &Logger{
 		Logger: l.Logger.With(args...),
 		level:  l.level,
+		async:  l.async,
+		file:   l.file,
 	}
 }
 
@@ -116,6 +206,8 @@ func (l *Logger) WithField(key string, value interface{}) *Logger {
 	return &Logger{
 		Logger: l.Logger.With(key, value),
 		level:  l.level,
+		async:  l.async,
+		file:   l.file,
 	}
 }
 
@@ -168,6 +260,8 @@ func (l *Logger) With(args ...interface{}) *Logger {
 	return &Logger{
 		Logger: l.Logger.With(args...),
 		level:  l.level,
+		async:  l.async,
+		file:   l.file,
 	}
 }
 
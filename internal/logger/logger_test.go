@@ -1,10 +1,14 @@
 package logger
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
 )
 
 func TestNew(t *testing.T) {
@@ -84,18 +88,25 @@ func TestLoggerWithFields(t *testing.T) {
 }
 
 func TestLoggerWithContext(t *testing.T) {
-	logger := New("debug", "json")
+	var buf bytes.Buffer
+	logger := NewWithOptions("debug", "json", Options{Output: &buf})
 
 	// Test with context containing values
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, FieldRequestID, "req-123")
-	ctx = context.WithValue(ctx, FieldUserID, "user-456")
-	ctx = context.WithValue(ctx, FieldTenantID, "tenant-789")
+	ctx = context.WithValue(ctx, types.RequestIDKey, "req-123")
+	ctx = context.WithValue(ctx, types.UserIDKey, "user-456")
+	ctx = context.WithValue(ctx, types.TenantIDKey, "tenant-789")
 
 	ctxLogger := logger.WithContext(ctx)
 	if ctxLogger == nil {
 		t.Fatal("Expected logger to be returned")
 	}
+	ctxLogger.Info("Processing request")
+	for _, field := range []string{`"request_id":"req-123"`, `"user_id":"user-456"`, `"tenant_id":"tenant-789"`} {
+		if !strings.Contains(buf.String(), field) {
+			t.Errorf("Expected %s in %s", field, buf.String())
+		}
+	}
 
 	// Test with empty context
 	emptyCtx := context.Background()
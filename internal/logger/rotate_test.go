@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_RotatesAndPrunesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	f, err := newRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer f.Close()
+
+	line := []byte(strings.Repeat("x", 39) + "\n")
+	// Two lines fit in each file, so ten lines make five files: the current file,
+	// two backups, and two backups that are pruned
+	for i := 0; i < 10; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() != 80 {
+			t.Errorf("expected %s to hold two lines, got %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected backups beyond the limit to be removed, got %v", err)
+	}
+}
+
+func TestRotatingFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "service.log")
+	f, err := newRotatingFile(path, 100, 1)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	f.Write([]byte(strings.Repeat("x", 60)))
+	f.Close()
+
+	// The size carried over from the existing file triggers the rotation
+	f, err = newRotatingFile(path, 100, 1)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer f.Close()
+	f.Write([]byte(strings.Repeat("y", 60)))
+
+	backup, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if string(backup) != strings.Repeat("x", 60) || string(current) != strings.Repeat("y", 60) {
+		t.Errorf("expected the existing file to be rotated, got backup %q and current %q", backup, current)
+	}
+}
+
+func TestNewWithOptions_FileDestination(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	logger := NewWithOptions("info", "json", Options{Destination: DestinationFile, FilePath: path})
+
+	logger.Info("written to file", "key", "value")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(data), `"msg":"written to file"`) || !strings.Contains(string(data), `"key":"value"`) {
+		t.Errorf("expected the JSON record in the log file, got %q", data)
+	}
+}
+
+func TestNewWithOptions_BothDestinations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	var out strings.Builder
+	logger := NewWithOptions("info", "text", Options{Output: &out, Destination: DestinationBoth, FilePath: path})
+
+	logger.Info("written twice")
+	logger.Shutdown(context.Background())
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(out.String(), "written twice") || !strings.Contains(string(data), "written twice") {
+		t.Errorf("expected the record in both outputs, got %q and %q", out.String(), data)
+	}
+}
+
+func TestNewWithOptions_UnopenableFileFallsBackToOutput(t *testing.T) {
+	dir := t.TempDir()
+	// A directory cannot be opened as the log file
+	var out strings.Builder
+	logger := NewWithOptions("info", "json", Options{Output: &out, Destination: DestinationFile, FilePath: dir})
+
+	logger.Info("still logged")
+
+	if !strings.Contains(out.String(), "Failed to open log file") || !strings.Contains(out.String(), "still logged") {
+		t.Errorf("expected records on the fallback output, got %q", out.String())
+	}
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Sampled returns a logger that emits one in n debug and info records, so that
+// high-volume logs such as per-request logs can be thinned. Warnings and errors are
+// always emitted. Loggers derived from the result share its sampling counter; n of
+// one or less returns l unchanged.
+func (l *Logger) Sampled(n int) *Logger {
+	if n <= 1 {
+		return l
+	}
+
+	handler := &samplingHandler{
+		Handler: l.Logger.Handler(),
+		n:       uint64(n),
+		counter: new(atomic.Uint64),
+	}
+	return &Logger{
+		Logger: slog.New(handler),
+		level:  l.level,
+		async:  l.async,
+		file:   l.file,
+	}
+}
+
+// samplingHandler drops all but one in n records below warning level
+type samplingHandler struct {
+	slog.Handler
+	n       uint64
+	counter *atomic.Uint64
+}
+
+// Handle passes on the first of every n sampled records
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && (h.counter.Add(1)-1)%h.n != 0 {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler with attrs that shares h's counter
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), n: h.n, counter: h.counter}
+}
+
+// WithGroup returns a handler for group name that shares h's counter
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), n: h.n, counter: h.counter}
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) count(msg string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Count(b.buf.String(), msg)
+}
+
+func TestSampled_EmitsOneInN(t *testing.T) {
+	out := &syncBuffer{}
+	logger := NewWithOptions("debug", "json", Options{Output: out}).Sampled(10)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.WithField("i", i).Info("sampled info")
+				logger.Debug("sampled debug")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 2000 records share one counter, so exactly one in ten is emitted; which of the
+	// two messages they are depends on scheduling
+	info, debug := out.count(`"msg":"sampled info"`), out.count(`"msg":"sampled debug"`)
+	if info+debug != 200 {
+		t.Errorf("Expected 200 sampled records, got %d info and %d debug", info, debug)
+	}
+}
+
+func TestSampled_NeverDropsWarningsOrErrors(t *testing.T) {
+	out := &syncBuffer{}
+	logger := NewWithOptions("info", "json", Options{Output: out}).Sampled(100)
+
+	for i := 0; i < 50; i++ {
+		logger.Info("info")
+		logger.Warn("warning")
+		logger.WithField("i", i).Error("failure")
+	}
+
+	if got := out.count(`"level":"WARN"`); got != 50 {
+		t.Errorf("Expected 50 warnings, got %d", got)
+	}
+	if got := out.count(`"level":"ERROR"`); got != 50 {
+		t.Errorf("Expected 50 errors, got %d", got)
+	}
+	if got := out.count(`"level":"INFO"`); got != 1 {
+		t.Errorf("Expected 1 info record, got %d", got)
+	}
+}
+
+func TestSampled_RateOfOneKeepsEverything(t *testing.T) {
+	logger := New("info", "json")
+	if logger.Sampled(1) != logger || logger.Sampled(0) != logger {
+		t.Error("Expected sample rates of one or less to return the logger unchanged")
+	}
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import "github.com/aykay76/ai-idp/internal/database"
+
+// RegisterPool registers gauges for a connection pool, read from stats at scrape
+// time. Pass database.Pool.Stats.
+func RegisterPool(r *Registry, stats func() database.ConnectionStats) {
+	r.NewGaugeFunc("db_pool_total_connections", "Open database connections.", func() float64 {
+		return float64(stats().TotalConnections)
+	})
+	r.NewGaugeFunc("db_pool_idle_connections", "Idle database connections.", func() float64 {
+		return float64(stats().IdleConnections)
+	})
+	r.NewGaugeFunc("db_pool_acquired_connections", "Database connections in use.", func() float64 {
+		return float64(stats().UsedConnections)
+	})
+	r.NewCounterFunc("db_pool_acquires_total", "Connections acquired from the pool.", func() float64 {
+		return float64(stats().AcquireCount)
+	})
+	r.NewCounterFunc("db_pool_empty_acquires_total", "Acquires that waited because the pool was empty.", func() float64 {
+		return float64(stats().EmptyAcquireCount)
+	})
+	r.NewCounterFunc("db_pool_acquire_duration_seconds_total", "Total time spent acquiring connections.", func() float64 {
+		return stats().AcquireDuration.Seconds()
+	})
+}
+
+// RegisterQueries registers counters for the queries a database.QueryTracer has seen,
+// read from stats at scrape time. Pass database.QueryTracer.Stats.
+func RegisterQueries(r *Registry, stats func() database.QueryStats) {
+	r.NewCounterFunc("db_queries_total", "Database queries executed.", func() float64 {
+		return float64(stats().Queries)
+	})
+	r.NewCounterFunc("db_query_errors_total", "Database queries that failed.", func() float64 {
+		return float64(stats().Errors)
+	})
+	r.NewCounterFunc("db_slow_queries_total", "Database queries slower than the slow query threshold.", func() float64 {
+		return float64(stats().Slow)
+	})
+	r.NewCounterFunc("db_query_duration_seconds_total", "Total time spent executing database queries.", func() float64 {
+		return stats().Duration.Seconds()
+	})
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// HTTP holds the request metrics recorded by middleware.Metrics. Requests are
+// labelled by route template, such as /api/v1/teams/{id}, rather than raw path so
+// IDs do not create a series each.
+type HTTP struct {
+	requests *Counter
+	inFlight *Gauge
+	duration *Histogram
+}
+
+// NewHTTP registers the HTTP request metrics with r
+func NewHTTP(r *Registry) *HTTP {
+	return &HTTP{
+		requests: r.NewCounter("http_requests_total",
+			"Total HTTP requests by method, route and status.", "method", "route", "status"),
+		inFlight: r.NewGauge("http_requests_in_flight",
+			"HTTP requests currently being served."),
+		duration: r.NewHistogram("http_request_duration_seconds",
+			"HTTP request duration in seconds by method, route and status.", DefaultBuckets, "method", "route", "status"),
+	}
+}
+
+// Started records a request entering the handler
+func (m *HTTP) Started() {
+	m.inFlight.Inc()
+}
+
+// Finished records a completed request
+func (m *HTTP) Finished(method, route string, status int, duration time.Duration) {
+	m.inFlight.Dec()
+
+	code := strconv.Itoa(status)
+	m.requests.Inc(method, route, code)
+	m.duration.Observe(duration.Seconds(), method, route, code)
+}
@@ -0,0 +1,315 @@
+// Package metrics collects service metrics and exposes them in the Prometheus text
+// exposition format, so every service can be scraped without a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the histogram upper bounds, in seconds, used for request
+// durations
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metric is a family of series that can write itself in the exposition format
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry holds a service's metrics
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes every metric in the Prometheus text format, in registration order
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry at a scrape endpoint such as GET /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.Write(w)
+	})
+}
+
+// family holds the series of one metric, keyed by their label values
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// Histograms only: per-bucket counts (not cumulative) and the sum of observations
+	buckets []uint64
+	sum     float64
+}
+
+func newFamily(name, help, kind string, labels []string) *family {
+	return &family{name: name, help: help, kind: kind, labels: labels, series: make(map[string]*series)}
+}
+
+// get returns the series for labelValues, creating it with nBuckets buckets. The
+// caller must hold f.mu.
+func (f *family) get(labelValues []string, nBuckets int) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...), buckets: make([]uint64, nBuckets)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values, so output is stable. The
+// caller must hold f.mu.
+func (f *family) sorted() []*series {
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]*series, len(keys))
+	for i, k := range keys {
+		out[i] = f.series[k]
+	}
+	return out
+}
+
+func (f *family) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+func (f *family) write(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.writeHeader(w)
+	for _, s := range f.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labels, s.labelValues), formatFloat(s.value))
+	}
+}
+
+// Counter is a value that only increases, such as a request count
+type Counter struct {
+	family *family
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, "counter", labels)}
+	r.register(c.family)
+	return c
+}
+
+// Inc adds one to the series for labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series for labelValues
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.family.mu.Lock()
+	c.family.get(labelValues, 0).value += v
+	c.family.mu.Unlock()
+}
+
+// Gauge is a value that can go up and down, such as requests in flight
+type Gauge struct {
+	family *family
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{family: newFamily(name, help, "gauge", labels)}
+	r.register(g.family)
+	return g
+}
+
+// Set sets the series for labelValues to v
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.family.mu.Lock()
+	g.family.get(labelValues, 0).value = v
+	g.family.mu.Unlock()
+}
+
+// Add adds v, which may be negative, to the series for labelValues
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.family.mu.Lock()
+	g.family.get(labelValues, 0).value += v
+	g.family.mu.Unlock()
+}
+
+// Inc adds one to the series for labelValues
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec subtracts one from the series for labelValues
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// funcMetric is an unlabelled metric whose value is read when it is scraped
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	fn   func() float64
+}
+
+func (m *funcMetric) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+	fmt.Fprintf(w, "%s %s\n", m.name, formatFloat(m.fn()))
+}
+
+// NewGaugeFunc registers a gauge whose value is fn's result at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc registers a counter whose value is fn's result at scrape time, for
+// totals that another component already keeps
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+// Histogram counts observations, such as request durations, into buckets
+type Histogram struct {
+	family  *family
+	buckets []float64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds, which must
+// be sorted, and label names. The +Inf bucket is implicit.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		family:  newFamily(name, help, "histogram", labels),
+		buckets: append([]float64(nil), buckets...),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records v in the series for labelValues
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+
+	s := h.family.get(labelValues, len(h.buckets)+1)
+	i := sort.SearchFloat64s(h.buckets, v)
+	s.buckets[i]++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) {
+	f := h.family
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.writeHeader(w)
+	labels := append(append([]string(nil), f.labels...), "le")
+	for _, s := range f.sorted() {
+		values := append(append([]string(nil), s.labelValues...), "")
+
+		var cumulative uint64
+		for i, count := range s.buckets {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			values[len(values)-1] = formatFloat(le)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(labels, values), cumulative)
+		}
+		seriesLabels := formatLabels(f.labels, s.labelValues)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, seriesLabels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, seriesLabels, cumulative)
+	}
+}
+
+// formatLabels renders {name="value",...}, or nothing for an unlabelled series
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exposition is a parsed scrape: sample values keyed by name and labels as written,
+// and each metric's declared type
+type exposition struct {
+	samples map[string]float64
+	types   map[string]string
+}
+
+// parse reads the Prometheus text format, failing the test on malformed lines
+func parse(t *testing.T, body string) exposition {
+	t.Helper()
+	e := exposition{samples: map[string]float64{}, types: map[string]string{}}
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			require.Len(t, fields, 4, line)
+			e.types[fields[2]] = fields[3]
+		case strings.HasPrefix(line, "# HELP "), line == "":
+		default:
+			i := strings.LastIndexByte(line, ' ')
+			require.Positive(t, i, line)
+			value, err := strconv.ParseFloat(line[i+1:], 64)
+			require.NoError(t, err, line)
+			_, dup := e.samples[line[:i]]
+			require.False(t, dup, "duplicate sample %s", line[:i])
+			e.samples[line[:i]] = value
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return e
+}
+
+func scrape(t *testing.T, r *Registry) exposition {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+	return parse(t, rr.Body.String())
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests.", "method")
+	inFlight := r.NewGauge("in_flight", "In flight.")
+
+	requests.Inc("GET")
+	requests.Inc("GET")
+	requests.Add(3, "POST")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+
+	e := scrape(t, r)
+	assert.Equal(t, "counter", e.types["requests_total"])
+	assert.Equal(t, "gauge", e.types["in_flight"])
+	assert.Equal(t, 2.0, e.samples[`requests_total{method="GET"}`])
+	assert.Equal(t, 3.0, e.samples[`requests_total{method="POST"}`])
+	assert.Equal(t, 1.0, e.samples["in_flight"])
+
+	assert.Panics(t, func() { requests.Add(-1, "GET") }, "counters cannot decrease")
+	assert.Panics(t, func() { requests.Inc() }, "label values must match label names")
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+
+	h.Observe(0.05, "/a")
+	h.Observe(0.1, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(3, "/a")
+
+	e := scrape(t, r)
+	assert.Equal(t, "histogram", e.types["duration_seconds"])
+	assert.Equal(t, 2.0, e.samples[`duration_seconds_bucket{route="/a",le="0.1"}`], "bucket bounds are inclusive")
+	assert.Equal(t, 3.0, e.samples[`duration_seconds_bucket{route="/a",le="1"}`], "buckets are cumulative")
+	assert.Equal(t, 4.0, e.samples[`duration_seconds_bucket{route="/a",le="+Inf"}`])
+	assert.Equal(t, 4.0, e.samples[`duration_seconds_count{route="/a"}`])
+	assert.InDelta(t, 3.65, e.samples[`duration_seconds_sum{route="/a"}`], 1e-9)
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("escaped_total", "Escaped.", "value").Inc("a\"b\\c\nd")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+	assert.Contains(t, buf.String(), `escaped_total{value="a\"b\\c\nd"} 1`)
+}
+
+func TestHTTP(t *testing.T) {
+	r := NewRegistry()
+	m := NewHTTP(r)
+
+	m.Started()
+	m.Started()
+	m.Finished(http.MethodGet, "/api/v1/teams/{id}", http.StatusOK, 20*time.Millisecond)
+
+	e := scrape(t, r)
+	assert.Equal(t, 1.0, e.samples["http_requests_in_flight"])
+	assert.Equal(t, 1.0, e.samples[`http_requests_total{method="GET",route="/api/v1/teams/{id}",status="200"}`])
+	assert.Equal(t, 1.0, e.samples[`http_request_duration_seconds_bucket{method="GET",route="/api/v1/teams/{id}",status="200",le="0.025"}`])
+	assert.Equal(t, 0.0, e.samples[`http_request_duration_seconds_bucket{method="GET",route="/api/v1/teams/{id}",status="200",le="0.01"}`])
+}
+
+func TestRegisterPool(t *testing.T) {
+	r := NewRegistry()
+	RegisterPool(r, func() database.ConnectionStats {
+		return database.ConnectionStats{
+			TotalConnections:  10,
+			IdleConnections:   7,
+			UsedConnections:   3,
+			AcquireCount:      42,
+			AcquireDuration:   1500 * time.Millisecond,
+			EmptyAcquireCount: 2,
+		}
+	})
+
+	e := scrape(t, r)
+	assert.Equal(t, 10.0, e.samples["db_pool_total_connections"])
+	assert.Equal(t, 7.0, e.samples["db_pool_idle_connections"])
+	assert.Equal(t, 3.0, e.samples["db_pool_acquired_connections"])
+	assert.Equal(t, 42.0, e.samples["db_pool_acquires_total"])
+	assert.Equal(t, 2.0, e.samples["db_pool_empty_acquires_total"])
+	assert.Equal(t, 1.5, e.samples["db_pool_acquire_duration_seconds_total"])
+	assert.Equal(t, "counter", e.types["db_pool_acquires_total"])
+}
+
+func TestRegisterQueries(t *testing.T) {
+	r := NewRegistry()
+	RegisterQueries(r, func() database.QueryStats {
+		return database.QueryStats{Queries: 120, Errors: 3, Slow: 4, Duration: 2500 * time.Millisecond}
+	})
+
+	e := scrape(t, r)
+	assert.Equal(t, 120.0, e.samples["db_queries_total"])
+	assert.Equal(t, 3.0, e.samples["db_query_errors_total"])
+	assert.Equal(t, 4.0, e.samples["db_slow_queries_total"])
+	assert.Equal(t, 2.5, e.samples["db_query_duration_seconds_total"])
+	assert.Equal(t, "counter", e.types["db_slow_queries_total"])
+}
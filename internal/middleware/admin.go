@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// RequireAdmin middleware restricts access to callers presenting the admin token as a
+// bearer credential. An empty token denies every request, so admin endpoints are
+// never open by accident.
+func RequireAdmin(adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !IsAdminRequest(r, adminToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				writeAPIError(w, http.StatusUnauthorized, &types.APIError{
+					Code:    "UNAUTHORIZED",
+					Message: "Admin credentials are required",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsAdminRequest reports whether the request carries the admin bearer token. An empty
+// token never matches.
+func IsAdminRequest(r *http.Request, adminToken string) bool {
+	if adminToken == "" {
+		return false
+	}
+
+	auth := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// CodeBodyTooLarge is the APIError code for request bodies over the size limit
+const CodeBodyTooLarge = "REQUEST_ENTITY_TOO_LARGE"
+
+// MaxBodyBytes limits request bodies to n bytes. Requests declaring a larger
+// Content-Length are answered with 413 straight away; other bodies fail to read once
+// they pass the limit, which handlers detect with BodyTooLarge. n <= 0 disables the
+// limit.
+func MaxBodyBytes(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				writeAPIError(w, http.StatusRequestEntityTooLarge, BodyTooLargeError(n))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyTooLarge reports whether err came from reading a body past the MaxBodyBytes
+// limit and, if so, returns the limit
+func BodyTooLarge(err error) (int64, bool) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return maxBytesErr.Limit, true
+	}
+	return 0, false
+}
+
+// BodyTooLargeError is the APIError for a request body over the limit of n bytes
+func BodyTooLargeError(n int64) *types.APIError {
+	return &types.APIError{
+		Code:    CodeBodyTooLarge,
+		Message: fmt.Sprintf("Request body must not exceed %d bytes", n),
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	// echo reports the body it read, or the limit it was cut off at
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if limit, ok := BodyTooLarge(err); ok {
+			writeAPIError(w, http.StatusRequestEntityTooLarge, BodyTooLargeError(limit))
+			return
+		}
+		require.NoError(t, err)
+		w.Write(body)
+	})
+	handler := MaxBodyBytes(10)(echo)
+
+	t.Run("bodies within the limit are served", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader("0123456789")))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "0123456789", rr.Body.String())
+	})
+
+	t.Run("a declared length over the limit is rejected before the handler", func(t *testing.T) {
+		called := false
+		handler := MaxBodyBytes(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader("01234567890")))
+
+		assert.False(t, called)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, CodeBodyTooLarge, apiErr.Code)
+		assert.Equal(t, "Request body must not exceed 10 bytes", apiErr.Message)
+	})
+
+	t.Run("streamed bodies are cut off at the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(strings.Repeat("x", 100)))
+		req.ContentLength = -1
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, CodeBodyTooLarge, apiErr.Code)
+	})
+
+	t.Run("zero disables the limit", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MaxBodyBytes(0)(echo).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(strings.Repeat("x", 100))))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Len(t, rr.Body.String(), 100)
+	})
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	_, ok := BodyTooLarge(io.ErrUnexpectedEOF)
+	assert.False(t, ok)
+
+	limit, ok := BodyTooLarge(&http.MaxBytesError{Limit: 42})
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), limit)
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps a handler with behaviour that runs around it
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middlewares into one, outermost first: Chain(a, b, c)(h) serves a
+// request through a, then b, then c, and finally h. Nil entries are skipped, so a
+// stack can leave out middleware that is not configured.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] != nil {
+				next = middlewares[i](next)
+			}
+		}
+		return next
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/config"
+)
+
+// CORS applies the Cross-Origin Resource Sharing policy in cfg. Requests from an
+// allowed origin get the Access-Control headers; requests from other origins get none,
+// so browsers refuse the response. Preflight requests are answered here, with only the
+// configured methods and headers. A "*" origin allows any origin and is answered with
+// a wildcard, never with credentials. No allowed origins disables CORS.
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.AllowedOrigins) == 0 {
+			return next
+		}
+
+		wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+		methods := strings.Join(cfg.AllowedMethods, ", ")
+		headers := strings.Join(cfg.AllowedHeaders, ", ")
+		maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && origin != "" &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !wildcard {
+				w.Header().Add("Vary", "Origin")
+			}
+
+			allowed := origin != "" && (wildcard || slices.Contains(cfg.AllowedOrigins, origin))
+			if allowed {
+				if wildcard {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					if cfg.AllowCredentials {
+						w.Header().Set("Access-Control-Allow-Credentials", "true")
+					}
+				}
+			}
+
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				if cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
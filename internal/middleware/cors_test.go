@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(handler http.Handler, method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/teams", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+			req.Header.Set("Access-Control-Request-Headers", "If-Match, X-Debug")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://portal.example.com"},
+		AllowedMethods:   []string{"GET", "PATCH"},
+		AllowedHeaders:   []string{"Authorization", "If-Match"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	t.Run("an allowed origin gets CORS headers", func(t *testing.T) {
+		rr := serve(CORS(cfg)(next), http.MethodGet, "https://portal.example.com")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "https://portal.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+	})
+
+	t.Run("preflights echo only the configured methods and headers", func(t *testing.T) {
+		rr := serve(CORS(cfg)(next), http.MethodOptions, "https://portal.example.com")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "https://portal.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, PATCH", rr.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, If-Match", rr.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("a disallowed origin gets no CORS headers", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodOptions} {
+			rr := serve(CORS(cfg)(next), method, "https://evil.example.com")
+			for _, header := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Allow-Methods", "Access-Control-Allow-Headers"} {
+				assert.Empty(t, rr.Header().Get(header), "%s %s", method, header)
+			}
+		}
+	})
+
+	t.Run("a wildcard allows any origin without credentials", func(t *testing.T) {
+		wildcard := cfg
+		wildcard.AllowedOrigins = []string{"*"}
+		wildcard.AllowCredentials = true
+
+		rr := serve(CORS(wildcard)(next), http.MethodGet, "https://anywhere.example.com")
+		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Empty(t, rr.Header().Get("Vary"))
+	})
+
+	t.Run("requests without an origin pass through", func(t *testing.T) {
+		rr := serve(CORS(cfg)(next), http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("no allowed origins disables CORS", func(t *testing.T) {
+		rr := serve(CORS(config.CORSConfig{})(next), http.MethodOptions, "https://portal.example.com")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
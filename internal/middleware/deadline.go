@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// DeadlineHeader carries the remaining request budget between hops, in milliseconds.
+// A relative budget is used rather than an absolute time so hops do not depend on
+// synchronised clocks.
+const DeadlineHeader = "X-Request-Deadline"
+
+// Deadline middleware bounds each request by a single end-to-end budget. The budget is
+// the smaller of the local maximum and the remaining time advertised by the caller in
+// DeadlineHeader; a zero maximum only honors the caller's budget.
+func Deadline(maxBudget time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			budget := maxBudget
+			if value := r.Header.Get(DeadlineHeader); value != "" {
+				remaining, ok := ParseDeadlineHeader(value)
+				if ok && remaining <= 0 {
+					writeAPIError(w, http.StatusGatewayTimeout, &types.APIError{
+						Code:    "DEADLINE_EXCEEDED",
+						Message: "Request deadline exceeded before processing started",
+					})
+					return
+				}
+				if ok && (budget <= 0 || remaining < budget) {
+					budget = remaining
+				}
+			}
+
+			if budget <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ParseDeadlineHeader parses a DeadlineHeader value into the remaining budget
+func ParseDeadlineHeader(value string) (time.Duration, bool) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// PropagateDeadline advertises the time left on ctx to the next hop. It returns false
+// when the deadline has already passed and the request should not be sent.
+func PropagateDeadline(ctx context.Context, header http.Header) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return false
+	}
+
+	header.Set(DeadlineHeader, strconv.FormatInt(remaining.Milliseconds(), 10))
+	return true
+}
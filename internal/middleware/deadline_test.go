@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadline(t *testing.T) {
+	remainingIn := func(r *http.Request) time.Duration {
+		deadline, ok := r.Context().Deadline()
+		require.True(t, ok, "request context should have a deadline")
+		return time.Until(deadline)
+	}
+
+	t.Run("applies the local budget", func(t *testing.T) {
+		var remaining time.Duration
+		handler := Deadline(5 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining = remainingIn(r)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.InDelta(t, 5*time.Second, remaining, float64(100*time.Millisecond))
+	})
+
+	t.Run("caller budget shortens the local budget", func(t *testing.T) {
+		var remaining time.Duration
+		handler := Deadline(5 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining = remainingIn(r)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DeadlineHeader, "1500")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.InDelta(t, 1500*time.Millisecond, remaining, float64(100*time.Millisecond))
+	})
+
+	t.Run("caller cannot extend the local budget", func(t *testing.T) {
+		var remaining time.Duration
+		handler := Deadline(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining = remainingIn(r)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DeadlineHeader, "60000")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.LessOrEqual(t, remaining, time.Second)
+	})
+
+	t.Run("zero budget honors only the caller", func(t *testing.T) {
+		var hasDeadline bool
+		handler := Deadline(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.False(t, hasDeadline)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DeadlineHeader, "250")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.True(t, hasDeadline)
+	})
+
+	t.Run("expired caller budget is rejected", func(t *testing.T) {
+		called := false
+		handler := Deadline(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DeadlineHeader, "0")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+		assert.False(t, called)
+	})
+}
+
+func TestPropagateDeadline(t *testing.T) {
+	header := http.Header{}
+	assert.True(t, PropagateDeadline(context.Background(), header))
+	assert.Empty(t, header.Get(DeadlineHeader))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.True(t, PropagateDeadline(ctx, header))
+
+	remaining, ok := ParseDeadlineHeader(header.Get(DeadlineHeader))
+	require.True(t, ok)
+	assert.Greater(t, remaining, time.Duration(0))
+	assert.LessOrEqual(t, remaining, 2*time.Second)
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	assert.False(t, PropagateDeadline(expired, http.Header{}))
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// InFlight counts the requests being served so shutdown can wait for them before
+// closing what they use, such as the database pool. Install it inside Timeout: handlers
+// that time out keep running after their response is sent and are still counted.
+type InFlight struct {
+	mu      sync.Mutex
+	count   int
+	drained []chan struct{}
+}
+
+// NewInFlight creates an in-flight request counter
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+// Middleware counts requests while next serves them
+func (f *InFlight) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.count++
+		f.mu.Unlock()
+		defer f.done()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Count returns the number of requests being served
+func (f *InFlight) Count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.count
+}
+
+// Wait blocks until no requests are being served, or returns ctx.Err() if ctx ends
+// first
+func (f *InFlight) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	if f.count == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	drained := make(chan struct{})
+	f.drained = append(f.drained, drained)
+	f.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *InFlight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.count--; f.count == 0 {
+		for _, drained := range f.drained {
+			close(drained)
+		}
+		f.drained = nil
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlight(t *testing.T) {
+	inFlight := NewInFlight()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := inFlight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	assert.NoError(t, inFlight.Wait(context.Background()), "nothing to wait for when idle")
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+	<-started
+	assert.Equal(t, 1, inFlight.Count())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, inFlight.Wait(ctx), context.DeadlineExceeded, "the wait is bounded")
+
+	close(release)
+	assert.NoError(t, inFlight.Wait(context.Background()))
+	assert.Equal(t, 0, inFlight.Count())
+}
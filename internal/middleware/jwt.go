@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// DefaultPublicPaths are served without a token: probes, metrics scrapes and logins
+// must work before a caller has credentials
+var DefaultPublicPaths = []string{"/health", "/health/", "/readiness", "/liveness", "/metrics", "/api/v1/auth/"}
+
+// JWT validation errors
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Claims are the JWT claims JWTAuth reads. Subject identifies the user.
+type Claims struct {
+	Subject        string `json:"sub"`
+	TenantID       string `json:"tenant_id,omitempty"`
+	OrganizationID string `json:"organization_id,omitempty"`
+	ExpiresAt      int64  `json:"exp"`
+	NotBefore      int64  `json:"nbf,omitempty"`
+	IssuedAt       int64  `json:"iat,omitempty"`
+}
+
+// jwtConfig holds the JWTAuth options
+type jwtConfig struct {
+	publicPaths []string
+	adminToken  string
+}
+
+// JWTOption configures JWTAuth
+type JWTOption func(*jwtConfig)
+
+// WithPublicPaths serves paths without a token; an entry ending in "/" matches every
+// path below it. DefaultPublicPaths is used when the option is not given.
+func WithPublicPaths(paths ...string) JWTOption {
+	return func(c *jwtConfig) {
+		c.publicPaths = paths
+	}
+}
+
+// WithAdminToken lets requests carrying the admin bearer token through without a JWT,
+// leaving them to the admin checks of the handlers they reach
+func WithAdminToken(token string) JWTOption {
+	return func(c *jwtConfig) {
+		c.adminToken = token
+	}
+}
+
+// JWTAuth authenticates requests with an HS256-signed bearer token, rejecting missing,
+// malformed, wrongly signed and expired tokens with 401. The token's subject, tenant
+// and organization are stored in the request context under types.UserIDKey,
+// types.TenantIDKey and types.OrganizationIDKey.
+func JWTAuth(secret string, opts ...JWTOption) func(http.Handler) http.Handler {
+	cfg := &jwtConfig{publicPaths: DefaultPublicPaths}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// CORS preflights never carry credentials
+			if r.Method == http.MethodOptions || isPublicPath(r.URL.Path, cfg.publicPaths) || IsAdminRequest(r, cfg.adminToken) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				writeUnauthorized(w, ErrMissingToken)
+				return
+			}
+
+			claims, err := ParseJWT(token, secret, time.Now())
+			if err != nil {
+				writeUnauthorized(w, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), types.UserIDKey, claims.Subject)
+			if claims.TenantID != "" {
+				ctx = context.WithValue(ctx, types.TenantIDKey, claims.TenantID)
+			}
+			if claims.OrganizationID != "" {
+				ctx = context.WithValue(ctx, types.OrganizationIDKey, claims.OrganizationID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ParseJWT verifies an HS256 token signed with secret and returns its claims. Tokens
+// must carry an expiry and be valid at now.
+func ParseJWT(token, secret string, now time.Time) (*Claims, error) {
+	if secret == "" {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Subject == "" || claims.ExpiresAt == 0 {
+		return nil, ErrInvalidToken
+	}
+	if !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrExpiredToken
+	}
+	if claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// SignJWT returns an HS256 token for claims signed with secret, in the form ParseJWT
+// verifies
+func SignJWT(claims Claims, secret string) (string, error) {
+	if secret == "" {
+		return "", errors.New("a signing secret is required")
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON token segment into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// isPublicPath reports whether path is in the allowlist
+func isPublicPath(path string, publicPaths []string) bool {
+	for _, p := range publicPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeUnauthorized writes a 401 for a failed token check
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	challenge := `Bearer realm="api"`
+	if !errors.Is(err, ErrMissingToken) {
+		challenge += `, error="invalid_token"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	message := "Authentication is required"
+	switch {
+	case errors.Is(err, ErrExpiredToken):
+		message = "Token has expired"
+	case errors.Is(err, ErrInvalidToken):
+		message = "Token is invalid"
+	}
+
+	writeAPIError(w, http.StatusUnauthorized, &types.APIError{
+		Code:    "UNAUTHORIZED",
+		Message: message,
+	})
+}
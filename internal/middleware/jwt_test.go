@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "test-secret"
+
+// signTestJWT builds an HS256 token for claims signed with secret
+func signTestJWT(t *testing.T, secret string, claims Claims) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTAuth(t *testing.T) {
+	var seen map[types.ContextKey]interface{}
+	handler := JWTAuth(testJWTSecret, WithAdminToken("admin-secret"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = map[types.ContextKey]interface{}{
+			types.UserIDKey:         r.Context().Value(types.UserIDKey),
+			types.TenantIDKey:       r.Context().Value(types.TenantIDKey),
+			types.OrganizationIDKey: r.Context().Value(types.OrganizationIDKey),
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path, authorization string) *httptest.ResponseRecorder {
+		seen = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assertUnauthorized := func(t *testing.T, rr *httptest.ResponseRecorder, message string) {
+		t.Helper()
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, "UNAUTHORIZED", apiErr.Code)
+		assert.Equal(t, message, apiErr.Message)
+		assert.Nil(t, seen, "handler must not be called")
+	}
+
+	valid := Claims{
+		Subject:        "dev@company.com",
+		TenantID:       "11111111-1111-1111-1111-111111111111",
+		OrganizationID: "acme",
+		ExpiresAt:      time.Now().Add(time.Hour).Unix(),
+	}
+
+	t.Run("valid token populates the context", func(t *testing.T) {
+		rr := serve("/api/v1/applications", "Bearer "+signTestJWT(t, testJWTSecret, valid))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "dev@company.com", seen[types.UserIDKey])
+		assert.Equal(t, valid.TenantID, seen[types.TenantIDKey])
+		assert.Equal(t, "acme", seen[types.OrganizationIDKey])
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := valid
+		expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+
+		rr := serve("/api/v1/applications", "Bearer "+signTestJWT(t, testJWTSecret, expired))
+		assertUnauthorized(t, rr, "Token has expired")
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		rr := serve("/api/v1/applications", "Bearer "+signTestJWT(t, "other-secret", valid))
+		assertUnauthorized(t, rr, "Token is invalid")
+	})
+
+	t.Run("malformed token and missing expiry", func(t *testing.T) {
+		assertUnauthorized(t, serve("/api/v1/applications", "Bearer not.a.jwt"), "Token is invalid")
+
+		noExpiry := valid
+		noExpiry.ExpiresAt = 0
+		assertUnauthorized(t, serve("/api/v1/applications", "Bearer "+signTestJWT(t, testJWTSecret, noExpiry)), "Token is invalid")
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		rr := serve("/api/v1/applications", "")
+		assertUnauthorized(t, rr, "Authentication is required")
+		assert.NotContains(t, rr.Header().Get("WWW-Authenticate"), "invalid_token")
+
+		assertUnauthorized(t, serve("/api/v1/applications", "Basic dXNlcjpwYXNz"), "Authentication is required")
+	})
+
+	t.Run("public paths and admin token skip authentication", func(t *testing.T) {
+		for _, path := range []string{"/health", "/health/services", "/readiness", "/liveness"} {
+			assert.Equal(t, http.StatusOK, serve(path, "").Code, path)
+		}
+		assert.Equal(t, http.StatusOK, serve("/api/v1/tenants", "Bearer admin-secret").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve("/healthz", "").Code)
+	})
+}
+
+func TestParseJWT_NotBefore(t *testing.T) {
+	now := time.Now()
+	token := signTestJWT(t, testJWTSecret, Claims{
+		Subject:   "dev@company.com",
+		NotBefore: now.Add(time.Hour).Unix(),
+		ExpiresAt: now.Add(2 * time.Hour).Unix(),
+	})
+
+	_, err := ParseJWT(token, testJWTSecret, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	claims, err := ParseJWT(token, testJWTSecret, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "dev@company.com", claims.Subject)
+}
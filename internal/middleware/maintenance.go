@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// MaintenanceLookup reports whether a tenant is inside a maintenance window at now
+// and when that window closes
+type MaintenanceLookup func(ctx context.Context, tenantID string, now time.Time) (until time.Time, active bool, err error)
+
+// TenantMaintenance rejects writes with 503 for tenants inside one of their scheduled
+// maintenance windows, asking clients to retry once the window closes. Reads and
+// requests without a tenant are always served. The tenant is the one resolved by
+// server.TenantResolver.Middleware, which must run first. If the schedule cannot be read
+// the request is served rather than failing every write.
+func TenantMaintenance(lookup MaintenanceLookup, now func() time.Time) func(http.Handler) http.Handler {
+	if now == nil {
+		now = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := types.ResolvedTenantIDFromContext(r.Context())
+			if tenantID == uuid.Nil || isReadMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			at := now()
+			until, active, err := lookup(r.Context(), tenantID.String(), at)
+			if err == nil && active {
+				WriteRetryableError(w, http.StatusServiceUnavailable, &types.APIError{
+					Code:    "TENANT_MAINTENANCE",
+					Message: "Tenant is in a scheduled maintenance window; retry the write after it ends",
+					Details: "maintenance ends at " + until.UTC().Format(time.RFC3339),
+				}, until.Sub(at))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantMaintenance(t *testing.T) {
+	const tenantID = "11111111-1111-1111-1111-111111111111"
+	windowStart := time.Date(2025, 6, 7, 2, 0, 0, 0, time.UTC)
+	windowEnd := windowStart.Add(2 * time.Hour)
+
+	lookup := func(ctx context.Context, id string, now time.Time) (time.Time, bool, error) {
+		if id == tenantID && !now.Before(windowStart) && now.Before(windowEnd) {
+			return windowEnd, true, nil
+		}
+		return time.Time{}, false, nil
+	}
+
+	clock := windowStart.Add(30 * time.Minute)
+	handler := TenantMaintenance(lookup, func() time.Time { return clock })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/applications", nil)
+		if tenant != "" {
+			req = req.WithContext(context.WithValue(req.Context(), types.ResolvedTenantIDKey, uuid.MustParse(tenant)))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("write during an active window is rejected", func(t *testing.T) {
+		rr := serve(http.MethodPost, tenantID)
+
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "5400", rr.Header().Get("Retry-After"))
+
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, "TENANT_MAINTENANCE", apiErr.Code)
+		require.NotNil(t, apiErr.Retryable)
+		assert.True(t, *apiErr.Retryable)
+		assert.Equal(t, (90 * time.Minute).Milliseconds(), apiErr.RetryAfterMs)
+	})
+
+	t.Run("reads and other tenants are served during the window", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, tenantID).Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost, "22222222-2222-2222-2222-222222222222").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost, "").Code)
+	})
+
+	t.Run("write outside the window is served", func(t *testing.T) {
+		clock = windowEnd
+		defer func() { clock = windowStart.Add(30 * time.Minute) }()
+
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost, tenantID).Code)
+	})
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/metrics"
+)
+
+// UnmatchedRoute labels requests that no route matched, so unknown paths share one
+// series
+const UnmatchedRoute = "unmatched"
+
+// Router resolves the route pattern a request matches; *http.ServeMux implements it
+type Router interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// Metrics middleware records the count, duration and status of each request, and
+// the number in flight. Requests are labelled with the route pattern router matches
+// rather than the raw path.
+func Metrics(m *metrics.HTTP, router Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := Route(router, r)
+			start := time.Now()
+			m.Started()
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			defer func() {
+				m.Finished(r.Method, route, wrapped.statusCode, time.Since(start))
+			}()
+
+			next.ServeHTTP(wrapped, r)
+		})
+	}
+}
+
+// Route returns the path template of the route router matches for r, such as
+// /api/v1/teams/{id}, or UnmatchedRoute
+func Route(router Router, r *http.Request) string {
+	_, pattern := router.Handler(r)
+	if pattern == "" {
+		return UnmatchedRoute
+	}
+	// Patterns may start with a method, as in "GET /api/v1/teams/{id}"
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i+1:], " ")
+	}
+	return pattern
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/teams/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /api/v1/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	registry := metrics.NewRegistry()
+	handler := Metrics(metrics.NewHTTP(registry), mux)(mux)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/teams/6f1c7c1e-1111-4c3b-9a55-000000000001", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/teams/6f1c7c1e-1111-4c3b-9a55-000000000002", nil),
+		httptest.NewRequest(http.MethodPost, "/api/v1/teams", nil),
+		httptest.NewRequest(http.MethodGet, "/nowhere", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.Write(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, `http_requests_total{method="GET",route="/api/v1/teams/{id}",status="404"} 2`, "IDs share the route's series")
+	assert.Contains(t, out, `http_requests_total{method="POST",route="/api/v1/teams",status="201"} 1`)
+	assert.Contains(t, out, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, out, `http_request_duration_seconds_count{method="POST",route="/api/v1/teams",status="201"} 1`)
+	assert.Contains(t, out, "http_requests_in_flight 0")
+	assert.NotContains(t, out, "000000000001")
+}
@@ -10,16 +10,24 @@ import (
 	"github.com/google/uuid"
 )
 
-// RequestID middleware adds a unique request ID to each request
+// RequestIDHeader carries the request ID between hops
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the inbound request IDs that are adopted
+const maxRequestIDLength = 128
+
+// RequestID middleware adds a request ID to each request. An ID sent by the caller,
+// such as the gateway, is kept so a request can be followed across services; one is
+// generated if it is missing or unusable.
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
 			requestID = uuid.New().String()
 		}
 
 		// Add request ID to response headers
-		w.Header().Set("X-Request-ID", requestID)
+		w.Header().Set(RequestIDHeader, requestID)
 
 		// Add request ID to context
 		ctx := context.WithValue(r.Context(), types.RequestIDKey, requestID)
@@ -27,10 +35,72 @@ func RequestID(next http.Handler) http.Handler {
 	})
 }
 
-// Logging middleware logs HTTP requests
-func Logging(log *logger.Logger) func(http.Handler) http.Handler {
+// PropagateRequestID passes the request ID on ctx to the next hop, so its logs can be
+// correlated with this request
+func PropagateRequestID(ctx context.Context, header http.Header) {
+	if requestID := types.RequestIDFromContext(ctx); requestID != "" {
+		header.Set(RequestIDHeader, requestID)
+	}
+}
+
+// validRequestID reports whether an inbound request ID can be adopted. It is written
+// to logs and response headers, so only short, printable IDs are accepted.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ProbePaths are the health probe endpoints, which are polled often enough that
+// logging every request to them is mostly noise
+var ProbePaths = []string{"/health", "/health/", "/readiness", "/liveness"}
+
+// loggingConfig holds the Logging options
+type loggingConfig struct {
+	sampleRate int
+	skipPaths  []string
+}
+
+// LoggingOption configures Logging
+type LoggingOption func(*loggingConfig)
+
+// WithSampleRate logs one in n successful requests; server errors are always logged
+func WithSampleRate(n int) LoggingOption {
+	return func(c *loggingConfig) {
+		c.sampleRate = n
+	}
+}
+
+// WithoutPaths does not log requests to paths; an entry ending in "/" matches every
+// path below it
+func WithoutPaths(paths ...string) LoggingOption {
+	return func(c *loggingConfig) {
+		c.skipPaths = paths
+	}
+}
+
+// Logging middleware logs HTTP requests with the request, user and tenant IDs on the
+// request's context. Server errors are logged at error level.
+func Logging(log *logger.Logger, opts ...LoggingOption) func(http.Handler) http.Handler {
+	cfg := &loggingConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	log = log.Sampled(cfg.sampleRate)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPublicPath(r.URL.Path, cfg.skipPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			start := time.Now()
 
 			// Wrap response writer to capture status code
@@ -40,76 +110,20 @@ func Logging(log *logger.Logger) func(http.Handler) http.Handler {
 			next.ServeHTTP(wrapped, r)
 
 			duration := time.Since(start)
-			requestID := r.Context().Value(types.RequestIDKey)
 
-			log.WithFields(map[string]interface{}{
+			requestLog := log.WithContext(r.Context()).WithFields(map[string]interface{}{
 				"method":     r.Method,
 				"path":       r.URL.Path,
 				"status":     wrapped.statusCode,
 				"duration":   duration.Milliseconds(),
-				"request_id": requestID,
 				"user_agent": r.UserAgent(),
 				"remote_ip":  getClientIP(r),
-			}).Info("HTTP request processed")
-		})
-	}
-}
-
-// CORS middleware handles Cross-Origin Resource Sharing
-func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-
-			// Check if origin is allowed
-			allowed := false
-			for _, allowedOrigin := range allowedOrigins {
-				if allowedOrigin == "*" || allowedOrigin == origin {
-					allowed = true
-					break
-				}
-			}
-
-			if allowed {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-			}
-
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Max-Age", "86400")
-
-			// Handle preflight requests
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
+			})
+			if wrapped.statusCode >= http.StatusInternalServerError {
+				requestLog.Error("HTTP request processed")
 				return
 			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
-}
-
-// Recovery middleware recovers from panics
-func Recovery(log *logger.Logger) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					requestID := r.Context().Value(types.RequestIDKey)
-
-					log.WithFields(map[string]interface{}{
-						"error":      err,
-						"method":     r.Method,
-						"path":       r.URL.Path,
-						"request_id": requestID,
-					}).Error("Panic recovered")
-
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				}
-			}()
-
-			next.ServeHTTP(w, r)
+			requestLog.Info("HTTP request processed")
 		})
 	}
 }
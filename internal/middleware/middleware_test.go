@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = r.Context().Value(types.RequestIDKey).(string)
+	}))
+	serve := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("an inbound ID is adopted", func(t *testing.T) {
+		rr := serve("gateway-id-1")
+		assert.Equal(t, "gateway-id-1", seen)
+		assert.Equal(t, "gateway-id-1", rr.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("an ID is generated when none is sent", func(t *testing.T) {
+		rr := serve("")
+		assert.NotEmpty(t, seen)
+		assert.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("unusable inbound IDs are replaced", func(t *testing.T) {
+		for _, id := range []string{"id with spaces", "id\x00", strings.Repeat("x", maxRequestIDLength+1)} {
+			serve(id)
+			assert.NotEqual(t, id, seen)
+			assert.NotEmpty(t, seen)
+		}
+	})
+}
+
+func TestRequestID_ReachesLogger(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.NewWithOptions("info", "json", logger.Options{Output: &out})
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "req-123", types.RequestIDFromContext(r.Context()))
+		log.WithContext(r.Context()).Info("Handling request")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, out.String(), `"request_id":"req-123"`)
+	assert.Empty(t, types.RequestIDFromContext(context.Background()))
+}
+
+func TestPropagateRequestID(t *testing.T) {
+	header := http.Header{}
+	PropagateRequestID(context.Background(), header)
+	assert.Empty(t, header.Get(RequestIDHeader), "nothing is sent without a request ID")
+
+	PropagateRequestID(context.WithValue(context.Background(), types.RequestIDKey, "abc-123"), header)
+	assert.Equal(t, "abc-123", header.Get(RequestIDHeader))
+}
+
+func TestLogging(t *testing.T) {
+	serve := func(handler http.Handler, path string) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	status := http.StatusOK
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	t.Run("logs every request by default", func(t *testing.T) {
+		var out bytes.Buffer
+		handler := Logging(logger.NewWithOptions("info", "json", logger.Options{Output: &out}))(next)
+
+		for i := 0; i < 5; i++ {
+			serve(handler, "/api/v1/teams")
+		}
+		assert.Equal(t, 5, strings.Count(out.String(), "HTTP request processed"))
+		assert.Contains(t, out.String(), `"path":"/api/v1/teams"`)
+	})
+
+	t.Run("samples requests but never server errors", func(t *testing.T) {
+		var out bytes.Buffer
+		handler := Logging(logger.NewWithOptions("info", "json", logger.Options{Output: &out}), WithSampleRate(10))(next)
+
+		status = http.StatusOK
+		for i := 0; i < 100; i++ {
+			serve(handler, "/api/v1/teams")
+		}
+		status = http.StatusServiceUnavailable
+		for i := 0; i < 3; i++ {
+			serve(handler, "/api/v1/teams")
+		}
+		status = http.StatusOK
+
+		assert.Equal(t, 10, strings.Count(out.String(), `"level":"INFO"`))
+		assert.Equal(t, 3, strings.Count(out.String(), `"level":"ERROR"`))
+	})
+
+	t.Run("skips excluded paths", func(t *testing.T) {
+		var out bytes.Buffer
+		handler := Logging(logger.NewWithOptions("info", "json", logger.Options{Output: &out}), WithoutPaths(ProbePaths...))(next)
+
+		serve(handler, "/health")
+		serve(handler, "/health/database")
+		serve(handler, "/readiness")
+		serve(handler, "/liveness")
+		assert.Empty(t, out.String())
+
+		serve(handler, "/api/v1/teams")
+		assert.Equal(t, 1, strings.Count(out.String(), "HTTP request processed"))
+	})
+
+	t.Run("logs the IDs on the request context", func(t *testing.T) {
+		var out bytes.Buffer
+		handler := Logging(logger.NewWithOptions("info", "json", logger.Options{Output: &out}))(next)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		ctx := context.WithValue(req.Context(), types.RequestIDKey, "req-123")
+		ctx = context.WithValue(ctx, types.TenantIDKey, "tenant-789")
+		handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+		assert.Contains(t, out.String(), `"request_id":"req-123"`)
+		assert.Contains(t, out.String(), `"tenant_id":"tenant-789"`)
+	})
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Priority represents the QoS class of a request
+type Priority int
+
+// Request priorities, from lowest to highest
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// String returns the header representation of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// ParsePriority parses an X-Priority header value
+func ParsePriority(value string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "low", "background", "bulk":
+		return PriorityLow, true
+	case "normal":
+		return PriorityNormal, true
+	case "high", "interactive":
+		return PriorityHigh, true
+	default:
+		return PriorityNormal, false
+	}
+}
+
+// ClassifyRequest determines a request's priority. An explicit X-Priority header wins;
+// otherwise reads are treated as interactive, bulk operations as background and
+// everything else as normal.
+func ClassifyRequest(r *http.Request) Priority {
+	if p, ok := ParsePriority(r.Header.Get("X-Priority")); ok {
+		return p
+	}
+
+	if strings.HasSuffix(r.URL.Path, ":bulk") || strings.Contains(r.URL.Path, "/bulk") {
+		return PriorityLow
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+// overloadRetryAfter is how long clients are asked to wait after being shed
+const overloadRetryAfter = time.Second
+
+// ConcurrencyLimitConfig configures the priority-aware concurrency limiter
+type ConcurrencyLimitConfig struct {
+	// MaxConcurrent is the total number of requests allowed in flight
+	MaxConcurrent int
+	// NormalPriorityLimit is the percentage of MaxConcurrent that normal priority
+	// requests may occupy before they are shed
+	NormalPriorityLimit int
+	// LowPriorityLimit is the percentage of MaxConcurrent that low priority
+	// requests may occupy before they are shed
+	LowPriorityLimit int
+	// Classify assigns a priority to a request; defaults to ClassifyRequest
+	Classify func(*http.Request) Priority
+}
+
+// ConcurrencyLimiter bounds the number of in-flight requests, shedding lower
+// priority requests first as the limit is approached
+type ConcurrencyLimiter struct {
+	mu         sync.Mutex
+	inFlight   int
+	thresholds [3]int
+	classify   func(*http.Request) Priority
+}
+
+// NewConcurrencyLimiter creates a new priority-aware concurrency limiter
+func NewConcurrencyLimiter(cfg ConcurrencyLimitConfig) *ConcurrencyLimiter {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 100
+	}
+	if cfg.Classify == nil {
+		cfg.Classify = ClassifyRequest
+	}
+
+	l := &ConcurrencyLimiter{classify: cfg.Classify}
+	l.thresholds[PriorityHigh] = cfg.MaxConcurrent
+	l.thresholds[PriorityNormal] = shareOf(cfg.MaxConcurrent, cfg.NormalPriorityLimit)
+	l.thresholds[PriorityLow] = shareOf(cfg.MaxConcurrent, cfg.LowPriorityLimit)
+
+	return l
+}
+
+// shareOf returns percent of total, keeping at least one slot available
+func shareOf(total, percent int) int {
+	if percent <= 0 || percent > 100 {
+		percent = 100
+	}
+	share := total * percent / 100
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+// acquire admits a request of the given priority if capacity allows
+func (l *ConcurrencyLimiter) acquire(p Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= l.thresholds[p] {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// release frees a slot taken by acquire
+func (l *ConcurrencyLimiter) release() {
+	l.mu.Lock()
+	l.inFlight--
+	l.mu.Unlock()
+}
+
+// InFlight returns the number of requests currently admitted
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Middleware returns the limiter as HTTP middleware
+func (l *ConcurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority := l.classify(r)
+		if !l.acquire(priority) {
+			WriteRetryableError(w, http.StatusServiceUnavailable, &types.APIError{
+				Code:    "SERVER_OVERLOADED",
+				Message: "Server is overloaded, " + priority.String() + " priority request shed",
+			}, overloadRetryAfter)
+			return
+		}
+		defer l.release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ConcurrencyLimit middleware limits in-flight requests with priority-based shedding
+func ConcurrencyLimit(cfg ConcurrencyLimitConfig) func(http.Handler) http.Handler {
+	return NewConcurrencyLimiter(cfg).Middleware
+}
+
+// writeAPIError writes an APIError as a JSON response
+func writeAPIError(w http.ResponseWriter, statusCode int, apiErr *types.APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(apiErr)
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   string
+		expected Priority
+	}{
+		{"interactive read", http.MethodGet, "/api/v1/teams", "", PriorityHigh},
+		{"write", http.MethodPost, "/api/v1/teams", "", PriorityNormal},
+		{"bulk import", http.MethodPost, "/api/v1/teams/123/members:bulk", "", PriorityLow},
+		{"explicit low", http.MethodGet, "/api/v1/teams", "low", PriorityLow},
+		{"explicit high", http.MethodPost, "/api/v1/teams", "high", PriorityHigh},
+		{"unknown header falls back", http.MethodGet, "/api/v1/teams", "urgent", PriorityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Priority", tt.header)
+			}
+			assert.Equal(t, tt.expected, ClassifyRequest(req))
+		})
+	}
+}
+
+func TestConcurrencyLimit_ShedsLowPriorityUnderSaturation(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		MaxConcurrent:       4,
+		NormalPriorityLimit: 75,
+		LowPriorityLimit:    50,
+	})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			started <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Saturate the low and normal priority share with blocked requests
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/block", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			done <- struct{}{}
+		}()
+		<-started
+	}
+	require.Equal(t, 3, limiter.InFlight())
+
+	// A low priority request is shed
+	lowReq := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+	lowReq.Header.Set("X-Priority", "low")
+	lowRR := httptest.NewRecorder()
+	handler.ServeHTTP(lowRR, lowReq)
+
+	assert.Equal(t, http.StatusServiceUnavailable, lowRR.Code)
+	assert.Equal(t, "1", lowRR.Header().Get("Retry-After"))
+
+	var apiErr types.APIError
+	require.NoError(t, json.Unmarshal(lowRR.Body.Bytes(), &apiErr))
+	assert.Equal(t, "SERVER_OVERLOADED", apiErr.Code)
+	require.NotNil(t, apiErr.Retryable)
+	assert.True(t, *apiErr.Retryable)
+	assert.Equal(t, int64(1000), apiErr.RetryAfterMs)
+
+	// A normal priority request is shed too, since its share is used up
+	normalRR := httptest.NewRecorder()
+	handler.ServeHTTP(normalRR, httptest.NewRequest(http.MethodPost, "/api/v1/teams", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, normalRR.Code)
+
+	// A high priority request is still admitted
+	highRR := httptest.NewRecorder()
+	handler.ServeHTTP(highRR, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+	assert.Equal(t, http.StatusOK, highRR.Code)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+	assert.Equal(t, 0, limiter.InFlight())
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/ratelimit"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// RateLimitOption configures RateLimit
+type RateLimitOption func(*rateLimitConfig)
+
+type rateLimitConfig struct {
+	trustedProxies []netip.Prefix
+}
+
+// WithTrustedProxies believes the X-Forwarded-For header of requests from proxies, each
+// an IP address or CIDR range. Malformed entries are ignored; config.Validate rejects
+// them in RATE_LIMIT_TRUSTED_PROXIES.
+func WithTrustedProxies(proxies ...string) RateLimitOption {
+	return func(c *rateLimitConfig) {
+		for _, proxy := range proxies {
+			if prefix, err := parseProxy(proxy); err == nil {
+				c.trustedProxies = append(c.trustedProxies, prefix)
+			}
+		}
+	}
+}
+
+// RateLimit middleware limits each caller to the rate of limiter, rejecting requests
+// over it with 429 and a Retry-After header. Callers are keyed as by RateLimitKey, so
+// it must run after JWTAuth for tenants to be limited as a whole.
+func RateLimit(limiter *ratelimit.KeyedLimiter, opts ...RateLimitOption) func(http.Handler) http.Handler {
+	cfg := &rateLimitConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, wait := limiter.Allow(cfg.key(r)); !ok {
+				WriteRetryableError(w, http.StatusTooManyRequests, &types.APIError{
+					Code:    "RATE_LIMITED",
+					Message: "Too many requests",
+				}, wait)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitKey returns the key a request is rate limited under when no proxies are
+// trusted: its tenant from the request context, or the host of RemoteAddr when it has
+// none
+func RateLimitKey(r *http.Request) string {
+	return (&rateLimitConfig{}).key(r)
+}
+
+// key returns the key r is rate limited under: its tenant, or else its client IP
+func (c *rateLimitConfig) key(r *http.Request) string {
+	if tenantID, ok := r.Context().Value(types.TenantIDKey).(string); ok && tenantID != "" {
+		return "tenant:" + tenantID
+	}
+	return "ip:" + c.clientIP(r)
+}
+
+// clientIP returns the originating client address. Requests are taken to come from
+// RemoteAddr unless it is a trusted proxy; then X-Forwarded-For is read from the
+// right, each proxy having appended the address it was reached from, and the first
+// address that is not itself a trusted proxy is the client. Entries left of it were
+// written by the client and are not believed.
+func (c *rateLimitConfig) clientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !c.trusted(remote) {
+		return remote
+	}
+
+	client := remote
+	entries := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(entries[i])
+		if entry == "" {
+			continue
+		}
+		client = entry
+		if !c.trusted(entry) {
+			break
+		}
+	}
+	return client
+}
+
+// trusted reports whether addr is one of the trusted proxies
+func (c *rateLimitConfig) trusted(addr string) bool {
+	if len(c.trustedProxies) == 0 {
+		return false
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range c.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProxy parses a trusted proxy, an IP address or CIDR range
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
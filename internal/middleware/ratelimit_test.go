@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/ratelimit"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	// 50 requests per second refills a token every 20ms
+	handler := RateLimit(ratelimit.NewKeyed(50, 2))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(tenantID, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		req.RemoteAddr = remoteAddr
+		if tenantID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), types.TenantIDKey, tenantID))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("rejects a tenant over its limit and recovers", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("tenant-a", "10.0.0.1:1234").Code)
+		assert.Equal(t, http.StatusOK, serve("tenant-a", "10.0.0.2:1234").Code)
+
+		rr := serve("tenant-a", "10.0.0.3:1234")
+		require.Equal(t, http.StatusTooManyRequests, rr.Code, "the tenant is limited across client addresses")
+		assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var apiErr types.APIError
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&apiErr))
+		assert.Equal(t, "RATE_LIMITED", apiErr.Code)
+
+		assert.Equal(t, http.StatusOK, serve("tenant-b", "10.0.0.3:1234").Code, "other tenants are unaffected")
+
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, http.StatusOK, serve("tenant-a", "10.0.0.1:1234").Code)
+	})
+
+	t.Run("limits requests without a tenant by client IP", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("", "192.0.2.1:1000").Code)
+		assert.Equal(t, http.StatusOK, serve("", "192.0.2.1:2000").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("", "192.0.2.1:3000").Code, "ports do not distinguish clients")
+		assert.Equal(t, http.StatusOK, serve("", "192.0.2.2:1000").Code)
+	})
+}
+
+func TestRateLimitKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "ip:192.0.2.1", RateLimitKey(req))
+
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	assert.Equal(t, "ip:192.0.2.1", RateLimitKey(req), "X-Forwarded-For is ignored unless the proxy is trusted")
+
+	req = req.WithContext(context.WithValue(req.Context(), types.TenantIDKey, "tenant-a"))
+	assert.Equal(t, "tenant:tenant-a", RateLimitKey(req))
+}
+
+func TestRateLimit_TrustedProxies(t *testing.T) {
+	cfg := &rateLimitConfig{}
+	WithTrustedProxies("10.0.0.0/8", "192.0.2.1", "not-an-ip")(cfg)
+	require.Len(t, cfg.trustedProxies, 2)
+
+	key := func(remoteAddr, xff string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		return cfg.key(req)
+	}
+
+	assert.Equal(t, "ip:203.0.113.7", key("192.0.2.1:1234", "203.0.113.7"), "a trusted proxy names the client")
+	assert.Equal(t, "ip:203.0.113.7", key("10.0.0.2:1234", "198.51.100.1, 203.0.113.7, 10.0.0.1"),
+		"entries written by the client, left of the last untrusted address, are not believed")
+	assert.Equal(t, "ip:10.0.0.1", key("10.0.0.2:1234", "10.0.0.1"), "a chain of trusted proxies ends at the first")
+	assert.Equal(t, "ip:10.0.0.2", key("10.0.0.2:1234", ""))
+	assert.Equal(t, "ip:198.51.100.9", key("198.51.100.9:1234", "203.0.113.7"), "untrusted addresses cannot name a client")
+}
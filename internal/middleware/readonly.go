@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// readOnlyRetryAfter matches the default failover check interval, the soonest the
+// primary can be seen to recover
+const readOnlyRetryAfter = 5 * time.Second
+
+// ReadOnly rejects writes with 503 while isReadOnly reports that the service has
+// degraded to read-only mode. GET, HEAD and OPTIONS requests are always served.
+func ReadOnly(isReadOnly func() bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isReadOnly() && !isReadMethod(r.Method) {
+				WriteRetryableError(w, http.StatusServiceUnavailable, &types.APIError{
+					Code:    "READ_ONLY_MODE",
+					Message: "Service is in read-only mode while the primary database is unavailable; retry the write later",
+				}, readOnlyRetryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isReadMethod reports whether a request method cannot modify state
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	var primaryDown atomic.Bool
+	handler := ReadOnly(primaryDown.Load)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/api/v1/applications", nil))
+		return rr
+	}
+
+	t.Run("primary up serves everything", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+			assert.Equal(t, http.StatusOK, serve(method).Code, method)
+		}
+	})
+
+	t.Run("primary down serves reads and rejects writes", func(t *testing.T) {
+		primaryDown.Store(true)
+		defer primaryDown.Store(false)
+
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+			assert.Equal(t, http.StatusOK, serve(method).Code, method)
+		}
+
+		for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			rr := serve(method)
+			require.Equal(t, http.StatusServiceUnavailable, rr.Code, method)
+			assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+			var apiErr types.APIError
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+			assert.Equal(t, "READ_ONLY_MODE", apiErr.Code)
+			assert.Contains(t, apiErr.Message, "read-only mode")
+		}
+	})
+
+	t.Run("writes resume when the primary recovers", func(t *testing.T) {
+		primaryDown.Store(true)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPost).Code)
+
+		primaryDown.Store(false)
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost).Code)
+	})
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Recover recovers from panics in next, logging the panic value and stack trace with
+// the request ID and path, and answers with a 500 INTERNAL_SERVER_ERROR. http.ErrAbortHandler
+// is re-panicked so the server still aborts the response. It runs inside Timeout, which
+// runs handlers on their own goroutine, so the logged stack is the handler's.
+func Recover(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				log.WithFields(logger.LogFields{
+					logger.FieldRequestID:  types.RequestIDFromContext(r.Context()),
+					logger.FieldHTTPMethod: r.Method,
+					logger.FieldHTTPPath:   r.URL.Path,
+					"panic":                fmt.Sprint(p),
+					"stack":                string(debug.Stack()),
+				}).Error("Panic recovered in HTTP handler")
+
+				writeAPIError(w, http.StatusInternalServerError, &types.APIError{
+					Code:    "INTERNAL_SERVER_ERROR",
+					Message: "Internal server error",
+				})
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecover(t *testing.T) {
+	t.Run("a panic is logged and answered with a 500", func(t *testing.T) {
+		var out bytes.Buffer
+		handler := Recover(logger.NewWithOptions("info", "json", logger.Options{Output: &out}))(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("nil team lead")
+			}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/1", nil)
+		req = req.WithContext(context.WithValue(req.Context(), types.RequestIDKey, "req-42"))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, "INTERNAL_SERVER_ERROR", apiErr.Code)
+		assert.NotContains(t, rr.Body.String(), "nil team lead", "panic values are not sent to clients")
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+		assert.Equal(t, "nil team lead", record["panic"])
+		assert.Equal(t, "req-42", record[logger.FieldRequestID])
+		assert.Equal(t, "/api/v1/teams/1", record[logger.FieldHTTPPath])
+		assert.Contains(t, record["stack"], "recover_test.go", "the stack leads to the panicking handler")
+	})
+
+	t.Run("aborted handlers are not recovered", func(t *testing.T) {
+		handler := Recover(logger.New("info", "json"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+}
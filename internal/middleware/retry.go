@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// WriteRetryableError writes apiErr for a transient failure, advertising when to retry
+// both in the Retry-After header and as retry metadata in the body
+func WriteRetryableError(w http.ResponseWriter, statusCode int, apiErr *types.APIError, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(RetryAfterSeconds(retryAfter)))
+	writeAPIError(w, statusCode, apiErr.WithRetryAfter(retryAfter))
+}
+
+// RetryAfterSeconds converts a wait duration to a Retry-After value of at least one second
+func RetryAfterSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
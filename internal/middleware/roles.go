@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// TeamRoleLookup returns the role of user in a team, or "" if the user is not a
+// member or the team does not exist
+type TeamRoleLookup func(ctx context.Context, teamID, user string) (types.TeamRole, error)
+
+// RequireRole serves a team route only to members of the team whose role includes
+// role; requiring types.TeamRoleMaintainer admits maintainers and owners. The
+// team is the route's {id} path value and the user is the one JWTAuth stored under
+// types.UserIDKey; requests without a user get 401 and other members 403. Requests
+// carrying the admin token are always served. Wrap individual routes with it:
+//
+//	mux.Handle("DELETE /api/v1/teams/{id}", middleware.RequireRole(types.TeamRoleMaintainer, lookup, adminToken)(handler))
+func RequireRole(role types.TeamRole, lookup TeamRoleLookup, adminToken string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsAdminRequest(r, adminToken) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, _ := r.Context().Value(types.UserIDKey).(string)
+			if user == "" {
+				writeUnauthorized(w, ErrMissingToken)
+				return
+			}
+
+			member, err := lookup(r.Context(), r.PathValue("id"), user)
+			if err != nil {
+				writeAPIError(w, http.StatusInternalServerError, &types.APIError{
+					Code:    "INTERNAL_SERVER_ERROR",
+					Message: "Failed to check team role",
+				})
+				return
+			}
+			if !member.Includes(role) {
+				writeAPIError(w, http.StatusForbidden, &types.APIError{
+					Code:    "FORBIDDEN",
+					Message: "This action requires the " + string(role) + " role in the team",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireRole(t *testing.T) {
+	const (
+		team       = "11111111-1111-1111-1111-111111111111"
+		brokenTeam = "22222222-2222-2222-2222-222222222222"
+		adminToken = "admin-secret"
+	)
+
+	roles := map[string]types.TeamRole{
+		"owner@example.com":      types.TeamRoleOwner,
+		"maintainer@example.com": types.TeamRoleMaintainer,
+		"developer@example.com":  types.TeamRoleDeveloper,
+		"viewer@example.com":     types.TeamRoleViewer,
+	}
+	lookup := func(ctx context.Context, teamID, user string) (types.TeamRole, error) {
+		if teamID == brokenTeam {
+			return "", errors.New("connection refused")
+		}
+		if teamID != team {
+			return "", nil
+		}
+		return roles[user], nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("DELETE /api/v1/teams/{id}", RequireRole(types.TeamRoleMaintainer, lookup, adminToken)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	serve := func(teamID, user, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/teams/"+teamID, nil)
+		if user != "" {
+			req = req.WithContext(context.WithValue(req.Context(), types.UserIDKey, user))
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assertError := func(t *testing.T, rr *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, rr.Code)
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, code, apiErr.Code)
+	}
+
+	t.Run("the required role and those above it are served", func(t *testing.T) {
+		for _, user := range []string{"owner@example.com", "maintainer@example.com"} {
+			assert.Equal(t, http.StatusNoContent, serve(team, user, "").Code, user)
+		}
+	})
+
+	t.Run("lower roles and non-members are forbidden", func(t *testing.T) {
+		for _, user := range []string{"developer@example.com", "viewer@example.com", "nobody@example.com"} {
+			assertError(t, serve(team, user, ""), http.StatusForbidden, "FORBIDDEN")
+		}
+		assertError(t, serve("33333333-3333-3333-3333-333333333333", "owner@example.com", ""), http.StatusForbidden, "FORBIDDEN")
+	})
+
+	t.Run("requests without a user are unauthorized", func(t *testing.T) {
+		assertError(t, serve(team, "", ""), http.StatusUnauthorized, "UNAUTHORIZED")
+	})
+
+	t.Run("the admin token is always served", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(team, "", adminToken).Code)
+	})
+
+	t.Run("lookup failures are not served", func(t *testing.T) {
+		assertError(t, serve(brokenTeam, "owner@example.com", ""), http.StatusInternalServerError, "INTERNAL_SERVER_ERROR")
+	})
+}
+
+func TestTeamRole_Includes(t *testing.T) {
+	assert.True(t, types.TeamRoleOwner.Includes(types.TeamRoleMaintainer))
+	assert.True(t, types.TeamRoleMaintainer.Includes(types.TeamRoleMaintainer))
+	assert.True(t, types.TeamRoleDeveloper.Includes(types.TeamRoleViewer))
+	assert.False(t, types.TeamRoleViewer.Includes(types.TeamRoleDeveloper))
+	assert.False(t, types.TeamRole("").Includes(types.TeamRoleViewer))
+	assert.False(t, types.TeamRoleOwner.Includes("superuser"))
+}
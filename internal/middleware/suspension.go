@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// TenantStatusLookup returns the status of a tenant, or "" if it is unknown
+type TenantStatusLookup func(ctx context.Context, tenantID string) (status string, err error)
+
+// blockedTenantStatuses maps the tenant statuses denied API access to their error
+var blockedTenantStatuses = map[string]*types.APIError{
+	"suspended": {
+		Code:    "TENANT_SUSPENDED",
+		Message: "Tenant is suspended; API access is disabled until it is reactivated",
+	},
+	"terminating": {
+		Code:    "TENANT_SUSPENDED",
+		Message: "Tenant is being terminated; API access is disabled",
+	},
+	"terminated": {
+		Code:    "TENANT_SUSPENDED",
+		Message: "Tenant has been terminated; API access is disabled",
+	},
+}
+
+// TenantSuspension rejects API requests for suspended or terminating tenants with 403.
+// Requests carrying the admin token are always served so administrators can still
+// manage those tenants, as are requests outside /api/ and those without a tenant. The
+// tenant is the one resolved by server.TenantResolver.Middleware, which must run
+// first. If the status cannot be read the request is served rather than
+// failing every request.
+func TenantSuspension(lookup TenantStatusLookup, adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := types.ResolvedTenantIDFromContext(r.Context())
+			if tenantID == uuid.Nil || !strings.HasPrefix(r.URL.Path, "/api/") || IsAdminRequest(r, adminToken) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			status, err := lookup(r.Context(), tenantID.String())
+			if apiErr, blocked := blockedTenantStatuses[status]; err == nil && blocked {
+				writeAPIError(w, http.StatusForbidden, apiErr)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantSuspension(t *testing.T) {
+	const (
+		activeTenant      = "11111111-1111-1111-1111-111111111111"
+		suspendedTenant   = "22222222-2222-2222-2222-222222222222"
+		terminatingTenant = "33333333-3333-3333-3333-333333333333"
+		brokenTenant      = "44444444-4444-4444-4444-444444444444"
+		adminToken        = "admin-secret"
+	)
+
+	statuses := map[string]string{
+		activeTenant:      "active",
+		suspendedTenant:   "suspended",
+		terminatingTenant: "terminating",
+	}
+	lookup := func(ctx context.Context, tenantID string) (string, error) {
+		if tenantID == brokenTenant {
+			return "", errors.New("connection refused")
+		}
+		return statuses[tenantID], nil
+	}
+
+	handler := TenantSuspension(lookup, adminToken)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, path, tenant, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if tenant != "" {
+			req = req.WithContext(context.WithValue(req.Context(), types.ResolvedTenantIDKey, uuid.MustParse(tenant)))
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("suspended tenant is rejected", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			rr := serve(method, "/api/v1/applications", suspendedTenant, "")
+
+			require.Equal(t, http.StatusForbidden, rr.Code, method)
+			var apiErr types.APIError
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+			assert.Equal(t, "TENANT_SUSPENDED", apiErr.Code)
+			assert.Contains(t, apiErr.Message, "suspended")
+		}
+	})
+
+	t.Run("terminating tenant is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/api/v1/teams", terminatingTenant, "").Code)
+	})
+
+	t.Run("active tenant passes", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/applications", activeTenant, "").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/api/v1/applications", activeTenant, "").Code)
+	})
+
+	t.Run("admin requests are served for suspended tenants", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/applications", suspendedTenant, adminToken).Code)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/api/v1/applications", suspendedTenant, "wrong").Code)
+	})
+
+	t.Run("non-API routes, missing tenants and lookup failures are served", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health", suspendedTenant, "").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/applications", "", "").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/applications", brokenTenant, "").Code)
+	})
+
+	t.Run("the tenant is the resolved one, not the header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+		req.Header.Set("X-Tenant-ID", activeTenant)
+		req = req.WithContext(context.WithValue(req.Context(), types.ResolvedTenantIDKey, uuid.MustParse(suspendedTenant)))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
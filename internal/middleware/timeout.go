@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Timeout middleware bounds how long a handler may run. The request context is
+// cancelled after d, which aborts in-flight database queries, and if the handler has
+// not finished by then the client gets a 503 REQUEST_TIMEOUT instead of waiting for
+// it. Responses are buffered until the handler returns, so streaming handlers should
+// not be wrapped. A zero duration disables the timeout.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.flush(w)
+			case <-ctx.Done():
+				tw.abandon()
+				writeAPIError(w, http.StatusServiceUnavailable, &types.APIError{
+					Code:    "REQUEST_TIMEOUT",
+					Message: "Request timed out",
+				})
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response so that it can be discarded if the
+// handler times out
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.status = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+		tw.wroteHeader = true
+	}
+	return tw.body.Write(p)
+}
+
+// flush writes the buffered response to w
+func (tw *timeoutWriter) flush(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	for key, values := range tw.header {
+		w.Header()[key] = values
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	_, _ = w.Write(tw.body.Bytes())
+}
+
+// abandon discards the response; further writes by the handler fail
+func (tw *timeoutWriter) abandon() {
+	tw.mu.Lock()
+	tw.timedOut = true
+	tw.mu.Unlock()
+}
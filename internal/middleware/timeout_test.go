@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("slow handler gets a 503 and a cancelled context", func(t *testing.T) {
+		ctxErr := make(chan error, 1)
+		handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				ctxErr <- r.Context().Err()
+			case <-time.After(time.Second):
+				ctxErr <- nil
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("too late"))
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		var apiErr types.APIError
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&apiErr))
+		assert.Equal(t, "REQUEST_TIMEOUT", apiErr.Code)
+
+		select {
+		case err := <-ctxErr:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("handler was not cancelled")
+		}
+		assert.NotContains(t, rr.Body.String(), "too late")
+	})
+
+	t.Run("fast handler response is passed through", func(t *testing.T) {
+		handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := r.Context().Deadline()
+			assert.True(t, ok)
+			w.Header().Set("X-Test", "yes")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/teams", nil))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "yes", rr.Header().Get("X-Test"))
+		assert.Equal(t, "created", rr.Body.String())
+	})
+
+	t.Run("panics reach the caller", func(t *testing.T) {
+		handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		assert.PanicsWithValue(t, "boom", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+
+	t.Run("zero disables the timeout", func(t *testing.T) {
+		handler := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := r.Context().Deadline()
+			assert.False(t, ok)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
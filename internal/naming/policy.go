@@ -0,0 +1,54 @@
+// Package naming holds the rules shared by resources whose names become identifiers,
+// such as teams and applications.
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotLowercase is returned by PolicyReject for names containing uppercase letters
+var ErrNotLowercase = errors.New("name must be lowercase")
+
+// Policy controls how mixed-case names are handled on create. Names end up in
+// DNS-1123 identifiers such as Kubernetes namespaces, which are lowercase, so
+// "Platform-Team" and "platform-team" would otherwise map to the same resource.
+type Policy string
+
+// Name policies
+const (
+	// PolicyPreserve stores names as given
+	PolicyPreserve Policy = "preserve"
+	// PolicyNormalize lowercases names before they are stored
+	PolicyNormalize Policy = "normalize"
+	// PolicyReject refuses names that are not already lowercase
+	PolicyReject Policy = "reject"
+)
+
+// ParsePolicy parses a policy name; an empty value selects PolicyPreserve
+func ParsePolicy(value string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(value))); p {
+	case "":
+		return PolicyPreserve, nil
+	case PolicyPreserve, PolicyNormalize, PolicyReject:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid name policy %q, must be one of: preserve, normalize, reject", value)
+	}
+}
+
+// Apply returns the name to store under the policy
+func (p Policy) Apply(name string) (string, error) {
+	switch p {
+	case PolicyNormalize:
+		return strings.ToLower(name), nil
+	case PolicyReject:
+		if name != strings.ToLower(name) {
+			return "", fmt.Errorf("%w: %q, use %q", ErrNotLowercase, name, strings.ToLower(name))
+		}
+		return name, nil
+	default:
+		return name, nil
+	}
+}
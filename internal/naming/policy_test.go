@@ -0,0 +1,50 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := map[string]Policy{
+		"":          PolicyPreserve,
+		"preserve":  PolicyPreserve,
+		"normalize": PolicyNormalize,
+		" Reject ":  PolicyReject,
+		"NORMALIZE": PolicyNormalize,
+	}
+	for value, want := range tests {
+		got, err := ParsePolicy(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	_, err := ParsePolicy("lowercase")
+	assert.Error(t, err)
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	t.Run("preserve", func(t *testing.T) {
+		name, err := PolicyPreserve.Apply("Platform-Team")
+		require.NoError(t, err)
+		assert.Equal(t, "Platform-Team", name)
+	})
+
+	t.Run("normalize", func(t *testing.T) {
+		name, err := PolicyNormalize.Apply("Platform-Team")
+		require.NoError(t, err)
+		assert.Equal(t, "platform-team", name)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		_, err := PolicyReject.Apply("Platform-Team")
+		assert.ErrorIs(t, err, ErrNotLowercase)
+		assert.Contains(t, err.Error(), `"platform-team"`)
+
+		name, err := PolicyReject.Apply("platform-team")
+		require.NoError(t, err)
+		assert.Equal(t, "platform-team", name)
+	})
+}
@@ -0,0 +1,66 @@
+// Package notify sends webhook notifications to tenants when their teams and
+// applications change in ways people act on, such as an application moving to
+// production or a team gaining a member.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	TypeApplicationLifecycleChanged = "application.lifecycle_changed"
+	TypeTeamMemberAdded             = "team.member_added"
+	TypeTeamMemberRemoved           = "team.member_removed"
+)
+
+// Notification is the JSON payload delivered to webhooks
+type Notification struct {
+	// ID identifies the notification; retried deliveries keep it so receivers can
+	// discard duplicates
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	TenantID  string    `json:"tenant_id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Data is an ApplicationLifecycleChange or a TeamMembershipChange, depending on Type
+	Data interface{} `json:"data"`
+}
+
+// ApplicationLifecycleChange is the data of an application.lifecycle_changed notification
+type ApplicationLifecycleChange struct {
+	ApplicationID string `json:"application_id"`
+	Name          string `json:"name"`
+	TeamName      string `json:"team_name"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+}
+
+// TeamMembershipChange is the data of team.member_added and team.member_removed
+// notifications
+type TeamMembershipChange struct {
+	TeamID   string `json:"team_id"`
+	TeamName string `json:"team_name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+// Notifier sends notifications. Sending is best effort: it never blocks on delivery
+// and never fails the change being notified.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification)
+}
+
+// New builds a notification of type typ for tenantID, stamped with a new ID and the
+// current time
+func New(typ string, tenantID uuid.UUID, data interface{}) Notification {
+	return Notification{
+		ID:        uuid.NewString(),
+		Type:      typ,
+		TenantID:  tenantID.String(),
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Tenant settings read by TenantTargets
+const (
+	// NotificationsSetting holds a types.NotificationSettings. With webhook enabled,
+	// the http and https URLs among its channels receive notifications.
+	NotificationsSetting = "notifications"
+	// WebhookSecretSetting holds the secret webhook payloads are signed with
+	WebhookSecretSetting = "webhook_secret"
+)
+
+// ErrMissingWebhookSecret is returned for tenants with webhooks enabled but no secret
+// to sign them with; nothing is sent unsigned
+var ErrMissingWebhookSecret = errors.New("webhooks are enabled but no webhook secret is set")
+
+// Target is a webhook URL and the secret its payloads are signed with
+type Target struct {
+	URL    string
+	Secret string
+}
+
+// Targets resolves the webhooks a tenant's notifications are delivered to
+type Targets interface {
+	WebhookTargets(ctx context.Context, tenantID string) ([]Target, error)
+}
+
+// TargetsFunc adapts a function to Targets
+type TargetsFunc func(ctx context.Context, tenantID string) ([]Target, error)
+
+// WebhookTargets calls f
+func (f TargetsFunc) WebhookTargets(ctx context.Context, tenantID string) ([]Target, error) {
+	return f(ctx, tenantID)
+}
+
+// ParseWebhookTargets reads the webhook targets configured in tenant settings.
+// Tenants without webhook notifications enabled have none.
+func ParseWebhookTargets(settings map[string]interface{}) ([]Target, error) {
+	raw, ok := settings[NotificationsSetting]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", NotificationsSetting, err)
+	}
+	var notifications types.NotificationSettings
+	if err := json.Unmarshal(data, &notifications); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", NotificationsSetting, err)
+	}
+	if !notifications.Webhook {
+		return nil, nil
+	}
+
+	secret, _ := settings[WebhookSecretSetting].(string)
+	if secret == "" {
+		return nil, ErrMissingWebhookSecret
+	}
+
+	var targets []Target
+	for _, channel := range notifications.Channels {
+		// Channels may also name chat channels; only URLs are webhooks
+		u, err := url.Parse(channel)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		targets = append(targets, Target{URL: channel, Secret: secret})
+	}
+	return targets, nil
+}
+
+// TenantTargets reads webhook targets from tenant settings in the platform database
+type TenantTargets struct {
+	db *database.Pool
+}
+
+// Compile-time check that TenantTargets implements Targets
+var _ Targets = (*TenantTargets)(nil)
+
+// NewTenantTargets creates a Targets backed by tenant settings
+func NewTenantTargets(db *database.Pool) *TenantTargets {
+	return &TenantTargets{db: db}
+}
+
+// WebhookTargets returns the webhook targets in the tenant's settings
+func (t *TenantTargets) WebhookTargets(ctx context.Context, tenantID string) ([]Target, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant ID %q: %w", tenantID, err)
+	}
+
+	var settings map[string]interface{}
+	query := `SELECT settings FROM control_plane.tenants WHERE id = $1`
+	if err := t.db.QueryRow(ctx, query, id).Scan(&settings); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+	return ParseWebhookTargets(settings)
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWebhookTargets(t *testing.T) {
+	t.Run("webhook URLs are signed with the tenant secret", func(t *testing.T) {
+		targets, err := ParseWebhookTargets(map[string]interface{}{
+			NotificationsSetting: map[string]interface{}{
+				"webhook":  true,
+				"slack":    true,
+				"channels": []interface{}{"https://hooks.example.com/idp", "#platform-alerts", "http://internal.example.com/hook"},
+			},
+			WebhookSecretSetting: "s3cret",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []Target{
+			{URL: "https://hooks.example.com/idp", Secret: "s3cret"},
+			{URL: "http://internal.example.com/hook", Secret: "s3cret"},
+		}, targets)
+	})
+
+	t.Run("tenants without webhooks enabled have no targets", func(t *testing.T) {
+		for _, settings := range []map[string]interface{}{
+			nil,
+			{NotificationsSetting: map[string]interface{}{"channels": []interface{}{"https://hooks.example.com/idp"}}},
+		} {
+			targets, err := ParseWebhookTargets(settings)
+			assert.NoError(t, err)
+			assert.Empty(t, targets)
+		}
+	})
+
+	t.Run("webhooks are not sent unsigned", func(t *testing.T) {
+		_, err := ParseWebhookTargets(map[string]interface{}{
+			NotificationsSetting: map[string]interface{}{"webhook": true, "channels": []interface{}{"https://hooks.example.com/idp"}},
+		})
+		assert.ErrorIs(t, err, ErrMissingWebhookSecret)
+	})
+
+	t.Run("malformed settings are an error", func(t *testing.T) {
+		_, err := ParseWebhookTargets(map[string]interface{}{NotificationsSetting: "webhook"})
+		assert.Error(t, err)
+	})
+}
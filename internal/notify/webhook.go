@@ -0,0 +1,255 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Headers set on webhook deliveries
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request body,
+	// keyed with the tenant's webhook secret
+	SignatureHeader = "X-IDP-Signature"
+	// EventHeader carries the notification type
+	EventHeader = "X-IDP-Event"
+	// DeliveryHeader carries the notification ID, which is the same on retries
+	DeliveryHeader = "X-IDP-Delivery"
+)
+
+// Defaults for WebhookDispatcher
+const (
+	DefaultTimeout     = 5 * time.Second
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = time.Second
+	DefaultQueueSize   = 256
+	DefaultWorkers     = 4
+)
+
+// ErrQueueFull is reported when a notification is dropped because the dispatcher is
+// still delivering earlier ones
+var ErrQueueFull = errors.New("notification queue is full")
+
+// ErrDispatcherClosed is reported for notifications sent after Close
+var ErrDispatcherClosed = errors.New("notification dispatcher is closed")
+
+// WebhookOptions configures a WebhookDispatcher
+type WebhookOptions struct {
+	// Client sends deliveries; defaults to a client without a timeout, as Timeout
+	// bounds each attempt
+	Client *http.Client
+	// Timeout bounds each delivery attempt; defaults to DefaultTimeout
+	Timeout time.Duration
+	// MaxAttempts bounds the attempts per webhook; defaults to DefaultMaxAttempts
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubling after each; defaults to
+	// DefaultBackoff
+	Backoff time.Duration
+	// QueueSize bounds the notifications waiting for delivery; defaults to
+	// DefaultQueueSize
+	QueueSize int
+	// Workers is how many notifications are delivered at once; defaults to
+	// DefaultWorkers
+	Workers int
+	// OnError is told about deliveries that failed for good. url is empty when the
+	// notification was dropped before its targets were known.
+	OnError func(n Notification, url string, err error)
+}
+
+// WebhookDispatcher delivers notifications to the webhooks of their tenant. Notify
+// only queues the notification; workers look up its targets and POST it to each,
+// retrying failed attempts with backoff, so slow or failing receivers never hold up
+// the change being notified.
+type WebhookDispatcher struct {
+	targets     Targets
+	client      *http.Client
+	timeout     time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	onError     func(n Notification, url string, err error)
+
+	queue chan Notification
+	wg    sync.WaitGroup
+	// mu guards closing the queue against concurrent Notify calls
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Compile-time check that WebhookDispatcher implements Notifier
+var _ Notifier = (*WebhookDispatcher)(nil)
+
+// NewWebhookDispatcher starts a dispatcher delivering to the webhooks in targets
+func NewWebhookDispatcher(targets Targets, opts WebhookOptions) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		targets:     targets,
+		client:      opts.Client,
+		timeout:     opts.Timeout,
+		maxAttempts: opts.MaxAttempts,
+		backoff:     opts.Backoff,
+		onError:     opts.OnError,
+	}
+	if d.client == nil {
+		d.client = &http.Client{}
+	}
+	if d.timeout <= 0 {
+		d.timeout = DefaultTimeout
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = DefaultMaxAttempts
+	}
+	if d.backoff <= 0 {
+		d.backoff = DefaultBackoff
+	}
+	if d.onError == nil {
+		d.onError = func(Notification, string, error) {}
+	}
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	d.queue = make(chan Notification, queueSize)
+
+	d.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.run()
+	}
+	return d
+}
+
+// Notify queues n for delivery. When the queue is full the notification is dropped
+// and reported to OnError.
+func (d *WebhookDispatcher) Notify(ctx context.Context, n Notification) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.onError(n, "", ErrDispatcherClosed)
+		return
+	}
+
+	select {
+	case d.queue <- n:
+	default:
+		d.onError(n, "", ErrQueueFull)
+	}
+}
+
+// Close waits for the notifications already queued to be delivered, including their
+// retries. Notifications sent after Close are reported to OnError with
+// ErrDispatcherClosed.
+func (d *WebhookDispatcher) Close() error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	return nil
+}
+
+func (d *WebhookDispatcher) run() {
+	defer d.wg.Done()
+	for n := range d.queue {
+		d.dispatch(n)
+	}
+}
+
+// dispatch delivers n to each of its tenant's webhooks
+func (d *WebhookDispatcher) dispatch(n Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	targets, err := d.targets.WebhookTargets(ctx, n.TenantID)
+	cancel()
+	if err != nil {
+		d.onError(n, "", fmt.Errorf("failed to get webhook targets: %w", err))
+		return
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		d.onError(n, "", fmt.Errorf("failed to marshal notification: %w", err))
+		return
+	}
+
+	for _, target := range targets {
+		if err := d.deliver(n, target, body); err != nil {
+			d.onError(n, target.URL, err)
+		}
+	}
+}
+
+// deliver POSTs body to target, retrying network errors, 429s and 5xx responses
+func (d *WebhookDispatcher) deliver(n Notification, target Target, body []byte) error {
+	signature := Sign(target.Secret, body)
+	backoff := d.backoff
+
+	var err error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var retry bool
+		if retry, err = d.attempt(n, target.URL, body, signature); err == nil || !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", d.maxAttempts, err)
+}
+
+// attempt makes a single delivery and reports whether a failure is worth retrying
+func (d *WebhookDispatcher) attempt(n Notification, url string, body []byte, signature string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ai-idp-webhooks")
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(EventHeader, n.Type)
+	req.Header.Set(DeliveryHeader, n.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("webhook rejected the notification: %s", resp.Status)
+	}
+}
+
+// Sign returns the SignatureHeader value for body signed with secret. Receivers
+// compute the same over the raw request body and compare in constant time.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
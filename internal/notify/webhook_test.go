@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// delivery is a request received by a test webhook
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a webhook that answers with status(attempt) and passes each
+// delivery to the test
+func newReceiver(t *testing.T, status func(attempt int32) int) (*httptest.Server, <-chan delivery) {
+	t.Helper()
+
+	received := make(chan delivery, 10)
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status(atomic.AddInt32(&attempts, 1)))
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func staticTargets(targets ...Target) Targets {
+	return TargetsFunc(func(ctx context.Context, tenantID string) ([]Target, error) {
+		return targets, nil
+	})
+}
+
+func next(t *testing.T, received <-chan delivery) delivery {
+	t.Helper()
+	select {
+	case d := <-received:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a webhook delivery")
+		return delivery{}
+	}
+}
+
+func TestWebhookDispatcher_Delivers(t *testing.T) {
+	server, received := newReceiver(t, func(int32) int { return http.StatusNoContent })
+
+	dispatcher := NewWebhookDispatcher(staticTargets(Target{URL: server.URL, Secret: "s3cret"}), WebhookOptions{})
+	defer dispatcher.Close()
+
+	tenantID := uuid.New()
+	n := New(TypeApplicationLifecycleChanged, tenantID, ApplicationLifecycleChange{
+		ApplicationID: "42",
+		Name:          "orders-api",
+		TeamName:      "payments",
+		From:          "staging",
+		To:            "production",
+	})
+	dispatcher.Notify(context.Background(), n)
+
+	d := next(t, received)
+	assert.Equal(t, "application/json", d.header.Get("Content-Type"))
+	assert.Equal(t, TypeApplicationLifecycleChanged, d.header.Get(EventHeader))
+	assert.Equal(t, n.ID, d.header.Get(DeliveryHeader))
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(d.body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), d.header.Get(SignatureHeader))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(d.body, &payload))
+	assert.Equal(t, n.ID, payload["id"])
+	assert.Equal(t, TypeApplicationLifecycleChanged, payload["type"])
+	assert.Equal(t, tenantID.String(), payload["tenant_id"])
+	assert.Equal(t, map[string]interface{}{
+		"application_id": "42",
+		"name":           "orders-api",
+		"team_name":      "payments",
+		"from":           "staging",
+		"to":             "production",
+	}, payload["data"])
+}
+
+func TestWebhookDispatcher_Retries(t *testing.T) {
+	t.Run("server errors are retried", func(t *testing.T) {
+		server, received := newReceiver(t, func(attempt int32) int {
+			if attempt < 3 {
+				return http.StatusBadGateway
+			}
+			return http.StatusOK
+		})
+
+		failed := make(chan error, 1)
+		dispatcher := NewWebhookDispatcher(staticTargets(Target{URL: server.URL, Secret: "s3cret"}), WebhookOptions{
+			Backoff: time.Millisecond,
+			OnError: func(n Notification, url string, err error) { failed <- err },
+		})
+
+		n := New(TypeTeamMemberAdded, uuid.New(), TeamMembershipChange{TeamName: "payments", Email: "dev@company.com", Role: "developer"})
+		dispatcher.Notify(context.Background(), n)
+
+		for i := 0; i < 3; i++ {
+			d := next(t, received)
+			assert.Equal(t, n.ID, d.header.Get(DeliveryHeader), "retries keep the notification ID")
+		}
+		require.NoError(t, dispatcher.Close())
+		assert.Empty(t, failed)
+	})
+
+	t.Run("delivery gives up after the last attempt", func(t *testing.T) {
+		server, received := newReceiver(t, func(int32) int { return http.StatusServiceUnavailable })
+
+		var failures []error
+		dispatcher := NewWebhookDispatcher(staticTargets(Target{URL: server.URL, Secret: "s3cret"}), WebhookOptions{
+			MaxAttempts: 2,
+			Backoff:     time.Millisecond,
+			OnError:     func(n Notification, url string, err error) { failures = append(failures, err) },
+		})
+		dispatcher.Notify(context.Background(), New(TypeTeamMemberRemoved, uuid.New(), TeamMembershipChange{}))
+		require.NoError(t, dispatcher.Close())
+
+		assert.Len(t, received, 2)
+		require.Len(t, failures, 1)
+		assert.Contains(t, failures[0].Error(), "giving up after 2 attempts")
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		server, received := newReceiver(t, func(int32) int { return http.StatusBadRequest })
+
+		var failures []error
+		dispatcher := NewWebhookDispatcher(staticTargets(Target{URL: server.URL, Secret: "s3cret"}), WebhookOptions{
+			Backoff: time.Millisecond,
+			OnError: func(n Notification, url string, err error) { failures = append(failures, err) },
+		})
+		dispatcher.Notify(context.Background(), New(TypeTeamMemberAdded, uuid.New(), TeamMembershipChange{}))
+		require.NoError(t, dispatcher.Close())
+
+		assert.Len(t, received, 1)
+		require.Len(t, failures, 1)
+		assert.Contains(t, failures[0].Error(), "rejected")
+	})
+
+	t.Run("slow receivers time out", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		failed := make(chan error, 1)
+		dispatcher := NewWebhookDispatcher(staticTargets(Target{URL: server.URL, Secret: "s3cret"}), WebhookOptions{
+			Timeout:     50 * time.Millisecond,
+			MaxAttempts: 1,
+			OnError:     func(n Notification, url string, err error) { failed <- err },
+		})
+		defer dispatcher.Close()
+
+		dispatcher.Notify(context.Background(), New(TypeTeamMemberAdded, uuid.New(), TeamMembershipChange{}))
+
+		select {
+		case err := <-failed:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the timeout was not reported")
+		}
+	})
+}
+
+func TestWebhookDispatcher_Dropped(t *testing.T) {
+	t.Run("a full queue drops the notification without blocking", func(t *testing.T) {
+		var dropped []error
+		d := &WebhookDispatcher{
+			queue:   make(chan Notification, 1),
+			onError: func(n Notification, url string, err error) { dropped = append(dropped, err) },
+		}
+
+		d.Notify(context.Background(), Notification{Type: TypeTeamMemberAdded})
+		d.Notify(context.Background(), Notification{Type: TypeTeamMemberRemoved})
+
+		assert.Equal(t, []error{ErrQueueFull}, dropped)
+	})
+
+	t.Run("notifying after close is reported", func(t *testing.T) {
+		var dropped []error
+		dispatcher := NewWebhookDispatcher(staticTargets(), WebhookOptions{
+			OnError: func(n Notification, url string, err error) { dropped = append(dropped, err) },
+		})
+		require.NoError(t, dispatcher.Close())
+
+		dispatcher.Notify(context.Background(), Notification{Type: TypeTeamMemberAdded})
+		assert.Equal(t, []error{ErrDispatcherClosed}, dropped)
+	})
+
+	t.Run("target lookup failures are reported", func(t *testing.T) {
+		var failures []error
+		dispatcher := NewWebhookDispatcher(TargetsFunc(func(ctx context.Context, tenantID string) ([]Target, error) {
+			return nil, ErrMissingWebhookSecret
+		}), WebhookOptions{
+			OnError: func(n Notification, url string, err error) { failures = append(failures, err) },
+		})
+		dispatcher.Notify(context.Background(), Notification{Type: TypeTeamMemberAdded})
+		require.NoError(t, dispatcher.Close())
+
+		require.Len(t, failures, 1)
+		assert.True(t, errors.Is(failures[0], ErrMissingWebhookSecret))
+	})
+}
@@ -0,0 +1,264 @@
+// Package openapi builds OpenAPI 3.0 documents describing a service's routes, with
+// request and response schemas derived from the Go types the handlers use.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.0.3"
+
+// DefaultAPIVersion is the API version of documents whose service has none, as when
+// VERSION is unset
+const DefaultAPIVersion = "0.0.0"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	schemas   *registry
+	errorType interface{}
+}
+
+// Info describes the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem holds the operations on one path, keyed by lower-case method
+type PathItem map[string]*Operation
+
+// Operation is one method on a path
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody is an operation's request body
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is a JSON schema as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Query returns an optional query parameter of the given type: "string",
+// "integer" or "boolean"
+func Query(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// Header returns a required string header parameter
+func Header(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Required: true, Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Endpoint describes a route for Add. Request and Response are values of the types
+// decoded from and written to the body, for example Team{} or []Member(nil); nil
+// means no body.
+type Endpoint struct {
+	Summary  string
+	Tags     []string
+	Query    []Parameter
+	Headers  []Parameter
+	Request  interface{}
+	Response interface{}
+	// Status is the success status code; defaults to 200, or 204 without a Response
+	Status int
+	// Errors are the error status codes, each returning the document's error schema
+	Errors []int
+}
+
+// New creates an empty document. errorResponse is a value of the type every error
+// status returns, for example ErrorResponse{}. An empty version is reported as
+// DefaultAPIVersion.
+func New(title, version string, errorResponse interface{}) *Document {
+	if version == "" {
+		version = DefaultAPIVersion
+	}
+	d := &Document{
+		OpenAPI:   Version,
+		Info:      Info{Title: title, Version: version},
+		Paths:     make(map[string]PathItem),
+		schemas:   newRegistry(),
+		errorType: errorResponse,
+	}
+	d.Components.Schemas = d.schemas.schemas
+	return d
+}
+
+// pathParam matches the wildcards of a ServeMux pattern, such as {id} or {path...}
+var pathParam = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+
+// Add documents the route registered with the ServeMux pattern "METHOD /path". Path
+// wildcards become required string parameters.
+func (d *Document) Add(pattern string, e Endpoint) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		panic(fmt.Sprintf("openapi: pattern %q has no method", pattern))
+	}
+	path = strings.TrimSpace(path)
+
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     e.Summary,
+		Tags:        e.Tags,
+		Responses:   make(map[string]Response),
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	path = pathParam.ReplaceAllString(path, "{$1}")
+	op.Parameters = append(op.Parameters, e.Query...)
+	op.Parameters = append(op.Parameters, e.Headers...)
+
+	if e.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(d.schemas.schemaOf(e.Request))}
+	}
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+		if e.Response == nil {
+			status = http.StatusNoContent
+		}
+	}
+	success := Response{Description: http.StatusText(status)}
+	if e.Response != nil {
+		success.Content = jsonContent(d.schemas.schemaOf(e.Response))
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	for _, code := range e.Errors {
+		op.Responses[strconv.Itoa(code)] = Response{
+			Description: http.StatusText(code),
+			Content:     jsonContent(d.schemas.schemaOf(d.errorType)),
+		}
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// Handler serves the document as JSON, for GET /openapi.json
+func (d *Document) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(d)
+	})
+}
+
+// Merge combines the paths and schemas of docs into one document, as the gateway
+// does for its backends. Earlier documents win when a path and method or a schema
+// name appears more than once.
+func Merge(title, version string, docs ...*Document) *Document {
+	merged := New(title, version, nil)
+
+	for _, doc := range docs {
+		for path, item := range doc.Paths {
+			target, ok := merged.Paths[path]
+			if !ok {
+				target = make(PathItem)
+				merged.Paths[path] = target
+			}
+			for method, op := range item {
+				if _, exists := target[method]; !exists {
+					target[method] = op
+				}
+			}
+		}
+		for name, schema := range doc.Components.Schemas {
+			if _, exists := merged.Components.Schemas[name]; !exists {
+				merged.Components.Schemas[name] = schema
+			}
+		}
+	}
+
+	return merged
+}
+
+// Operations returns the "METHOD /path" of every operation, sorted, which is
+// convenient for comparing documents with registered routes
+func (d *Document) Operations() []string {
+	var ops []string
+	for path, item := range d.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// operationID derives an identifier such as getApiV1TeamsId from a method and path
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testError struct {
+	Error string `json:"error"`
+}
+
+type testMeta struct {
+	Total int `json:"total"`
+}
+
+type testNode struct {
+	ID       uuid.UUID  `json:"id"`
+	Name     string     `json:"name"`
+	Note     *string    `json:"note,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	Created  time.Time  `json:"created_at"`
+	Children []testNode `json:"children"`
+	internal string
+	Skipped  string `json:"-"`
+}
+
+type testList struct {
+	testMeta
+	Nodes []testNode `json:"nodes"`
+}
+
+func TestSchemas(t *testing.T) {
+	doc := New("Test", "1.0.0", testError{})
+	doc.Add("GET /nodes", Endpoint{Response: testList{}})
+
+	list := doc.Components.Schemas["testList"]
+	require.NotNil(t, list)
+	assert.Equal(t, []string{"total", "nodes"}, list.Required, "embedded fields are flattened")
+	assert.Equal(t, "#/components/schemas/testNode", list.Properties["nodes"].Items.Ref)
+
+	node := doc.Components.Schemas["testNode"]
+	require.NotNil(t, node)
+	assert.Equal(t, []string{"id", "name", "created_at", "children"}, node.Required)
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, node.Properties["id"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, node.Properties["created_at"])
+	assert.Equal(t, &Schema{Type: "string", Nullable: true}, node.Properties["note"])
+	assert.Equal(t, "#/components/schemas/testNode", node.Properties["children"].Items.Ref, "recursive types are referenced")
+	assert.NotContains(t, node.Properties, "internal")
+	assert.NotContains(t, node.Properties, "Skipped")
+}
+
+func TestDocument_Add(t *testing.T) {
+	doc := New("Test", "", testError{})
+	doc.Add("PUT /nodes/{id}/files/{path...}", Endpoint{
+		Request:  testNode{},
+		Response: testNode{},
+		Errors:   []int{http.StatusNotFound},
+	})
+	doc.Add("DELETE /nodes/{id}", Endpoint{})
+	doc.Add("POST /nodes", Endpoint{Request: testNode{}, Response: testNode{}, Status: http.StatusCreated})
+
+	assert.Equal(t, DefaultAPIVersion, doc.Info.Version)
+	assert.Equal(t, []string{"DELETE /nodes/{id}", "POST /nodes", "PUT /nodes/{id}/files/{path}"}, doc.Operations())
+
+	put := doc.Paths["/nodes/{id}/files/{path}"]["put"]
+	require.NotNil(t, put)
+	assert.Equal(t, "putNodesIdFilesPath", put.OperationID)
+	require.Len(t, put.Parameters, 2)
+	assert.Equal(t, "id", put.Parameters[0].Name)
+	assert.Equal(t, "path", put.Parameters[1].Name)
+	assert.True(t, put.Parameters[1].Required)
+	assert.Contains(t, put.Responses, "200")
+	assert.Equal(t, "#/components/schemas/testError", put.Responses["404"].Content["application/json"].Schema.Ref)
+
+	assert.Contains(t, doc.Paths["/nodes/{id}"]["delete"].Responses, "204", "no response body means no content")
+	assert.Contains(t, doc.Paths["/nodes"]["post"].Responses, "201")
+}
+
+func TestMerge(t *testing.T) {
+	nodes := New("Nodes", "1.0.0", testError{})
+	nodes.Add("GET /nodes", Endpoint{Response: testList{}})
+	meta := New("Meta", "1.0.0", testError{})
+	meta.Add("GET /meta", Endpoint{Response: testMeta{}})
+	meta.Add("GET /nodes", Endpoint{Summary: "shadowed"})
+
+	merged := Merge("Gateway", "2.0.0", nodes, meta)
+
+	assert.Equal(t, "Gateway", merged.Info.Title)
+	assert.Equal(t, []string{"GET /meta", "GET /nodes"}, merged.Operations())
+	assert.Empty(t, merged.Paths["/nodes"]["get"].Summary, "earlier documents win")
+	assert.Contains(t, merged.Components.Schemas, "testList")
+	assert.Contains(t, merged.Components.Schemas, "testMeta")
+}
+
+func TestDocument_Handler(t *testing.T) {
+	doc := New("Test", "1.0.0", testError{})
+	doc.Add("GET /nodes/{id}", Endpoint{Response: testNode{}})
+
+	rr := httptest.NewRecorder()
+	doc.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var served Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &served))
+	assert.Equal(t, Version, served.OpenAPI)
+	assert.Equal(t, []string{"GET /nodes/{id}"}, served.Operations())
+	assert.Contains(t, served.Components.Schemas, "testNode")
+}
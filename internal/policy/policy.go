@@ -0,0 +1,319 @@
+// Package policy evaluates governance policies against resources before they are
+// created or changed.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Condition operators
+const (
+	OperatorEq    = "eq"
+	OperatorNe    = "ne"
+	OperatorGt    = "gt"
+	OperatorLt    = "lt"
+	OperatorIn    = "in"
+	OperatorNotIn = "not_in"
+	OperatorRegex = "regex"
+)
+
+// Resource kinds and actions the services evaluate policies for
+const (
+	ResourceApplication = "application"
+	ResourceTeam        = "team"
+
+	ActionCreate = "create"
+)
+
+// ParameterTeam names the team a PolicyScopeTeam policy applies to
+const ParameterTeam = "team"
+
+// ErrInvalidCondition is returned for conditions that cannot be evaluated, such as an
+// unknown operator or a malformed regular expression
+var ErrInvalidCondition = errors.New("invalid policy condition")
+
+// Resource is a resource being created or changed, as seen by policies
+type Resource struct {
+	// Kind is matched against PolicyRule.Resource, for example "application"
+	Kind string
+	// TenantID and Team place the resource for tenant and team scoped policies
+	TenantID string
+	Team     string
+	// Fields are the resource's fields by JSON name; conditions address nested
+	// fields with dotted paths
+	Fields map[string]interface{}
+}
+
+// NewResource builds a Resource from v, which is converted to fields by its JSON
+// encoding so that conditions use the field names clients send
+func NewResource(kind, tenantID, team string, v interface{}) (Resource, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Resource{}, fmt.Errorf("failed to marshal %s for policy evaluation: %w", kind, err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Resource{}, fmt.Errorf("failed to unmarshal %s for policy evaluation: %w", kind, err)
+	}
+
+	return Resource{Kind: kind, TenantID: tenantID, Team: team, Fields: fields}, nil
+}
+
+// Violation is a rule with a deny or audit effect that matched a resource
+type Violation struct {
+	Policy      string                  `json:"policy"`
+	Rule        string                  `json:"rule"`
+	Message     string                  `json:"message"`
+	Effect      types.PolicyEffect      `json:"effect"`
+	Enforcement types.PolicyEnforcement `json:"enforcement"`
+}
+
+// Blocking reports whether the violation must reject the request
+func (v Violation) Blocking() bool {
+	return v.Enforcement == types.PolicyEnforcementBlock && v.Effect == types.PolicyEffectDeny
+}
+
+// Blocking returns the violations that must reject the request
+func Blocking(violations []Violation) []Violation {
+	var blocking []Violation
+	for _, v := range violations {
+		if v.Blocking() {
+			blocking = append(blocking, v)
+		}
+	}
+	return blocking
+}
+
+// Source provides the policies to evaluate
+type Source interface {
+	Policies(ctx context.Context) ([]types.Policy, error)
+}
+
+// Static is a fixed set of policies
+type Static []types.Policy
+
+// Policies returns the policies
+func (s Static) Policies(ctx context.Context) ([]types.Policy, error) {
+	return s, nil
+}
+
+// LoadFile reads a JSON array of policies
+func LoadFile(path string) (Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policies Static
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+	return policies, nil
+}
+
+// Evaluator applies policies to resources
+type Evaluator struct {
+	source Source
+}
+
+// NewEvaluator creates an Evaluator for the policies from source
+func NewEvaluator(source Source) *Evaluator {
+	return &Evaluator{source: source}
+}
+
+// Evaluate returns the violations of the policies in scope for resource when action
+// is performed on it. A rule matches when its resource and action match, "*" matching
+// any, and all of its conditions hold. Rules with an allow effect never violate.
+func (e *Evaluator) Evaluate(ctx context.Context, resource Resource, action string) ([]Violation, error) {
+	policies, err := e.source.Policies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+
+	var violations []Violation
+	for _, p := range policies {
+		if !inScope(p, resource) {
+			continue
+		}
+
+		for _, rule := range p.Spec.Rules {
+			if rule.Effect == types.PolicyEffectAllow || !matches(rule.Resource, resource.Kind) || !matches(rule.Action, action) {
+				continue
+			}
+
+			ok, err := conditionsHold(rule.Conditions, resource.Fields)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s rule %s: %w", p.Metadata.Name, rule.Name, err)
+			}
+			if !ok {
+				continue
+			}
+
+			message := rule.Description
+			if message == "" {
+				message = fmt.Sprintf("%s %s is not allowed by rule %s", action, resource.Kind, rule.Name)
+			}
+			violations = append(violations, Violation{
+				Policy:      p.Metadata.Name,
+				Rule:        rule.Name,
+				Message:     message,
+				Effect:      rule.Effect,
+				Enforcement: p.Spec.Enforcement,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// inScope reports whether policy p applies to resource. Global policies apply to
+// every resource. Tenant policies apply to the tenant in their metadata namespace, or
+// to every tenant when it is empty; team policies additionally require the resource
+// to belong to the team in their team parameter. Other scopes never apply.
+func inScope(p types.Policy, resource Resource) bool {
+	tenantMatches := p.Metadata.Namespace == "" || p.Metadata.Namespace == resource.TenantID
+
+	switch p.Spec.Scope {
+	case types.PolicyScopeGlobal:
+		return true
+	case types.PolicyScopeTenant:
+		return tenantMatches
+	case types.PolicyScopeTeam:
+		team := p.Spec.Parameters[ParameterTeam]
+		return tenantMatches && team != "" && strings.EqualFold(team, resource.Team)
+	}
+	return false
+}
+
+// matches compares a rule's resource or action with the request's, case-insensitively
+func matches(pattern, value string) bool {
+	return pattern == "*" || strings.EqualFold(pattern, value)
+}
+
+// conditionsHold reports whether every condition holds for fields
+func conditionsHold(conditions []types.PolicyCondition, fields map[string]interface{}) (bool, error) {
+	for _, c := range conditions {
+		ok, err := evaluateCondition(c, fields)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evaluateCondition applies one condition. A missing field only satisfies ne and
+// not_in.
+func evaluateCondition(c types.PolicyCondition, fields map[string]interface{}) (bool, error) {
+	actual, found := lookup(fields, c.Field)
+
+	switch c.Operator {
+	case OperatorEq:
+		return found && equal(actual, c.Value), nil
+	case OperatorNe:
+		return !found || !equal(actual, c.Value), nil
+	case OperatorGt, OperatorLt:
+		a, aok := toFloat(actual)
+		b, bok := toFloat(c.Value)
+		if !bok {
+			return false, fmt.Errorf("%w: %s needs a numeric value for field %s", ErrInvalidCondition, c.Operator, c.Field)
+		}
+		if !found || !aok {
+			return false, nil
+		}
+		if c.Operator == OperatorGt {
+			return a > b, nil
+		}
+		return a < b, nil
+	case OperatorIn, OperatorNotIn:
+		values, ok := c.Value.([]interface{})
+		if strs, isStrings := c.Value.([]string); isStrings {
+			values, ok = make([]interface{}, len(strs)), true
+			for i, v := range strs {
+				values[i] = v
+			}
+		}
+		if !ok {
+			return false, fmt.Errorf("%w: %s needs a list value for field %s", ErrInvalidCondition, c.Operator, c.Field)
+		}
+		in := false
+		for _, v := range values {
+			if found && equal(actual, v) {
+				in = true
+				break
+			}
+		}
+		return in == (c.Operator == OperatorIn), nil
+	case OperatorRegex:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("%w: regex needs a string value for field %s", ErrInvalidCondition, c.Field)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("%w: %w", ErrInvalidCondition, err)
+		}
+		return found && actual != nil && re.MatchString(fmt.Sprint(actual)), nil
+	}
+
+	return false, fmt.Errorf("%w: unknown operator %q", ErrInvalidCondition, c.Operator)
+}
+
+// lookup returns the value at a dotted path such as config.replicas
+func lookup(fields map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = fields
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// equal compares values numerically when both are numbers, and otherwise by their
+// string form, so "3" in a policy file matches a numeric field
+func equal(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return x == y
+		}
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// toFloat converts JSON numbers, Go numbers and numeric strings to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,259 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// denyWhen returns a blocking global policy that denies creating applications when
+// all conditions hold
+func denyWhen(conditions ...types.PolicyCondition) types.Policy {
+	return types.Policy{
+		Metadata: types.ObjectMeta{Name: "test-policy"},
+		Spec: types.PolicySpec{
+			Scope:       types.PolicyScopeGlobal,
+			Enforcement: types.PolicyEnforcementBlock,
+			Rules: []types.PolicyRule{{
+				Name:       "test-rule",
+				Resource:   ResourceApplication,
+				Action:     ActionCreate,
+				Conditions: conditions,
+				Effect:     types.PolicyEffectDeny,
+			}},
+		},
+	}
+}
+
+func TestEvaluate_Operators(t *testing.T) {
+	resource := Resource{
+		Kind: ResourceApplication,
+		Fields: map[string]interface{}{
+			"lifecycle": "production",
+			"replicas":  float64(3),
+			"config":    map[string]interface{}{"region": "eu-west-1", "tier": 2},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		condition types.PolicyCondition
+		want      bool
+	}{
+		{"eq matches", types.PolicyCondition{Field: "lifecycle", Operator: OperatorEq, Value: "production"}, true},
+		{"eq differs", types.PolicyCondition{Field: "lifecycle", Operator: OperatorEq, Value: "staging"}, false},
+		{"eq compares numbers", types.PolicyCondition{Field: "replicas", Operator: OperatorEq, Value: 3}, true},
+		{"eq missing field", types.PolicyCondition{Field: "owner", Operator: OperatorEq, Value: "x"}, false},
+		{"ne differs", types.PolicyCondition{Field: "lifecycle", Operator: OperatorNe, Value: "staging"}, true},
+		{"ne matches", types.PolicyCondition{Field: "lifecycle", Operator: OperatorNe, Value: "production"}, false},
+		{"ne missing field", types.PolicyCondition{Field: "owner", Operator: OperatorNe, Value: "x"}, true},
+		{"gt above", types.PolicyCondition{Field: "replicas", Operator: OperatorGt, Value: 2}, true},
+		{"gt equal", types.PolicyCondition{Field: "replicas", Operator: OperatorGt, Value: 3}, false},
+		{"gt numeric string", types.PolicyCondition{Field: "replicas", Operator: OperatorGt, Value: "2.5"}, true},
+		{"gt non-numeric field", types.PolicyCondition{Field: "lifecycle", Operator: OperatorGt, Value: 1}, false},
+		{"lt below", types.PolicyCondition{Field: "replicas", Operator: OperatorLt, Value: 5}, true},
+		{"lt equal", types.PolicyCondition{Field: "replicas", Operator: OperatorLt, Value: 3}, false},
+		{"in listed", types.PolicyCondition{Field: "lifecycle", Operator: OperatorIn, Value: []interface{}{"staging", "production"}}, true},
+		{"in string list", types.PolicyCondition{Field: "lifecycle", Operator: OperatorIn, Value: []string{"staging", "production"}}, true},
+		{"in not listed", types.PolicyCondition{Field: "lifecycle", Operator: OperatorIn, Value: []interface{}{"development"}}, false},
+		{"not_in not listed", types.PolicyCondition{Field: "lifecycle", Operator: OperatorNotIn, Value: []interface{}{"development"}}, true},
+		{"not_in listed", types.PolicyCondition{Field: "lifecycle", Operator: OperatorNotIn, Value: []interface{}{"production"}}, false},
+		{"not_in missing field", types.PolicyCondition{Field: "owner", Operator: OperatorNotIn, Value: []interface{}{"x"}}, true},
+		{"regex matches", types.PolicyCondition{Field: "lifecycle", Operator: OperatorRegex, Value: "^prod"}, true},
+		{"regex differs", types.PolicyCondition{Field: "lifecycle", Operator: OperatorRegex, Value: "^dev"}, false},
+		{"regex missing field", types.PolicyCondition{Field: "owner", Operator: OperatorRegex, Value: ".*"}, false},
+		{"dotted path", types.PolicyCondition{Field: "config.region", Operator: OperatorRegex, Value: "^eu-"}, true},
+		{"dotted path number", types.PolicyCondition{Field: "config.tier", Operator: OperatorGt, Value: 1}, true},
+		{"dotted path through a scalar", types.PolicyCondition{Field: "lifecycle.stage", Operator: OperatorEq, Value: "x"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := NewEvaluator(Static{denyWhen(tt.condition)}).Evaluate(context.Background(), resource, ActionCreate)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, len(violations) == 1)
+		})
+	}
+}
+
+func TestEvaluate_InvalidConditions(t *testing.T) {
+	resource := Resource{Kind: ResourceApplication, Fields: map[string]interface{}{"lifecycle": "production"}}
+
+	tests := []struct {
+		name      string
+		condition types.PolicyCondition
+	}{
+		{"unknown operator", types.PolicyCondition{Field: "lifecycle", Operator: "contains", Value: "prod"}},
+		{"malformed regex", types.PolicyCondition{Field: "lifecycle", Operator: OperatorRegex, Value: "("}},
+		{"regex without a string", types.PolicyCondition{Field: "lifecycle", Operator: OperatorRegex, Value: 1}},
+		{"in without a list", types.PolicyCondition{Field: "lifecycle", Operator: OperatorIn, Value: "production"}},
+		{"gt without a number", types.PolicyCondition{Field: "lifecycle", Operator: OperatorGt, Value: "many"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEvaluator(Static{denyWhen(tt.condition)}).Evaluate(context.Background(), resource, ActionCreate)
+			assert.ErrorIs(t, err, ErrInvalidCondition)
+		})
+	}
+}
+
+func TestEvaluate_Rules(t *testing.T) {
+	resource := Resource{Kind: ResourceApplication, Fields: map[string]interface{}{"lifecycle": "production"}}
+	production := types.PolicyCondition{Field: "lifecycle", Operator: OperatorEq, Value: "production"}
+
+	t.Run("all conditions must hold", func(t *testing.T) {
+		p := denyWhen(production, types.PolicyCondition{Field: "lifecycle", Operator: OperatorEq, Value: "staging"})
+		violations, err := NewEvaluator(Static{p}).Evaluate(context.Background(), resource, ActionCreate)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("allow rules never violate", func(t *testing.T) {
+		p := denyWhen(production)
+		p.Spec.Rules[0].Effect = types.PolicyEffectAllow
+		violations, err := NewEvaluator(Static{p}).Evaluate(context.Background(), resource, ActionCreate)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("resource and action must match", func(t *testing.T) {
+		violations, err := NewEvaluator(Static{denyWhen(production)}).Evaluate(context.Background(), resource, "update")
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+
+		team := Resource{Kind: ResourceTeam, Fields: resource.Fields}
+		violations, err = NewEvaluator(Static{denyWhen(production)}).Evaluate(context.Background(), team, ActionCreate)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("wildcards match any resource and action", func(t *testing.T) {
+		p := denyWhen(production)
+		p.Spec.Rules[0].Resource = "*"
+		p.Spec.Rules[0].Action = "*"
+		violations, err := NewEvaluator(Static{p}).Evaluate(context.Background(), resource, "update")
+		require.NoError(t, err)
+		assert.Len(t, violations, 1)
+	})
+
+	t.Run("violation describes the rule", func(t *testing.T) {
+		p := denyWhen(production)
+		p.Spec.Rules[0].Description = "production apps need approval"
+		violations, err := NewEvaluator(Static{p}).Evaluate(context.Background(), resource, ActionCreate)
+		require.NoError(t, err)
+		assert.Equal(t, []Violation{{
+			Policy:      "test-policy",
+			Rule:        "test-rule",
+			Message:     "production apps need approval",
+			Effect:      types.PolicyEffectDeny,
+			Enforcement: types.PolicyEnforcementBlock,
+		}}, violations)
+		assert.True(t, violations[0].Blocking())
+	})
+}
+
+func TestEvaluate_Scope(t *testing.T) {
+	resource := Resource{
+		Kind:     ResourceApplication,
+		TenantID: "tenant-a",
+		Team:     "payments",
+		Fields:   map[string]interface{}{"lifecycle": "production"},
+	}
+
+	scoped := func(scope types.PolicyScope, namespace, team string) types.Policy {
+		p := denyWhen()
+		p.Metadata.Namespace = namespace
+		p.Spec.Scope = scope
+		if team != "" {
+			p.Spec.Parameters = map[string]string{ParameterTeam: team}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		policy types.Policy
+		want   bool
+	}{
+		{"global", scoped(types.PolicyScopeGlobal, "tenant-b", ""), true},
+		{"tenant without namespace", scoped(types.PolicyScopeTenant, "", ""), true},
+		{"same tenant", scoped(types.PolicyScopeTenant, "tenant-a", ""), true},
+		{"other tenant", scoped(types.PolicyScopeTenant, "tenant-b", ""), false},
+		{"same team", scoped(types.PolicyScopeTeam, "tenant-a", "Payments"), true},
+		{"other team", scoped(types.PolicyScopeTeam, "tenant-a", "platform"), false},
+		{"same team in other tenant", scoped(types.PolicyScopeTeam, "tenant-b", "payments"), false},
+		{"team scope without a team", scoped(types.PolicyScopeTeam, "", ""), false},
+		{"unsupported scope", scoped(types.PolicyScopeNamespace, "", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := NewEvaluator(Static{tt.policy}).Evaluate(context.Background(), resource, ActionCreate)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, len(violations) == 1)
+		})
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	violations := []Violation{
+		{Rule: "deny-block", Effect: types.PolicyEffectDeny, Enforcement: types.PolicyEnforcementBlock},
+		{Rule: "deny-warn", Effect: types.PolicyEffectDeny, Enforcement: types.PolicyEnforcementWarn},
+		{Rule: "audit-block", Effect: types.PolicyEffectAudit, Enforcement: types.PolicyEnforcementBlock},
+	}
+
+	blocking := Blocking(violations)
+	require.Len(t, blocking, 1)
+	assert.Equal(t, "deny-block", blocking[0].Rule)
+}
+
+func TestNewResource(t *testing.T) {
+	resource, err := NewResource(ResourceApplication, "tenant-a", "payments", struct {
+		Lifecycle string                 `json:"lifecycle"`
+		Config    map[string]interface{} `json:"config"`
+	}{"production", map[string]interface{}{"replicas": 3}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tenant-a", resource.TenantID)
+	assert.Equal(t, "production", resource.Fields["lifecycle"])
+	assert.Equal(t, map[string]interface{}{"replicas": float64(3)}, resource.Fields["config"])
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{
+		"metadata": {"name": "no-production"},
+		"spec": {
+			"type": "compliance",
+			"scope": "global",
+			"enforcement": "block",
+			"rules": [{
+				"name": "deny-production",
+				"resource": "application",
+				"action": "create",
+				"effect": "deny",
+				"conditions": [{"field": "lifecycle", "operator": "in", "value": ["production"]}]
+			}]
+		}
+	}]`), 0o600))
+
+	policies, err := LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+
+	violations, err := NewEvaluator(policies).Evaluate(context.Background(), Resource{
+		Kind:   ResourceApplication,
+		Fields: map[string]interface{}{"lifecycle": "production"},
+	}, ActionCreate)
+	require.NoError(t, err)
+	assert.Len(t, violations, 1)
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
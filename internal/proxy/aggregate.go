@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/openapi"
+)
+
+// aggregatePageSize is the page size used when listing through backends
+const aggregatePageSize = 100
+
+// maxAggregatePages stops a listing that never reaches a short page
+const maxAggregatePages = 100
+
+// Aggregator serves gateway endpoints that combine the responses of several backends
+// or tenants. A failing source is reported in the response instead of failing it; see
+// PartialResponse for the shape and status code policy.
+type Aggregator struct {
+	config *ProxyConfig
+	client *http.Client
+}
+
+// NewAggregator creates an aggregator for the backends in config
+func NewAggregator(config *ProxyConfig) *Aggregator {
+	return &Aggregator{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ServiceHealth is a backend's health response as seen by the gateway
+type ServiceHealth struct {
+	Service string          `json:"service"`
+	Health  json.RawMessage `json:"health"`
+}
+
+// TenantApplications holds the applications of one tenant in a fleet-wide listing
+type TenantApplications struct {
+	TenantID     string            `json:"tenant_id"`
+	TenantName   string            `json:"tenant_name,omitempty"`
+	Applications []json.RawMessage `json:"applications"`
+}
+
+// ServicesHealth handles GET /health/services
+func (a *Aggregator) ServicesHealth(w http.ResponseWriter, r *http.Request) {
+	backends := map[string]string{
+		"application-service": a.config.ApplicationServiceURL,
+		"team-service":        a.config.TeamServiceURL,
+		"tenant-service":      a.config.TenantServiceURL,
+	}
+	sources := []string{"application-service", "team-service", "tenant-service"}
+
+	response := fanOut(r.Context(), sources, func(ctx context.Context, service string) (ServiceHealth, error) {
+		var health json.RawMessage
+		if err := getJSON(ctx, a.client, backends[service]+"/health", nil, &health); err != nil {
+			return ServiceHealth{}, err
+		}
+		return ServiceHealth{Service: service, Health: health}, nil
+	})
+
+	a.logFailures(r, response.Errors)
+	a.writeResponse(w, r, response.StatusCode(), response)
+}
+
+// OpenAPI handles GET /openapi.json, merging the API descriptions of the team and
+// application services. Backends that fail are left out of the document and logged;
+// the response is 502 only when every backend failed.
+func (a *Aggregator) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	backends := map[string]string{
+		"team-service":        a.config.TeamServiceURL,
+		"application-service": a.config.ApplicationServiceURL,
+	}
+	sources := []string{"team-service", "application-service"}
+
+	response := fanOut(r.Context(), sources, func(ctx context.Context, service string) (*openapi.Document, error) {
+		var doc openapi.Document
+		if err := getJSON(ctx, a.client, backends[service]+"/openapi.json", nil, &doc); err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	})
+
+	a.logFailures(r, response.Errors)
+	if len(response.Results) == 0 {
+		a.writeResponse(w, r, response.StatusCode(), response)
+		return
+	}
+	a.writeResponse(w, r, http.StatusOK, openapi.Merge("AI-IDP Platform API", "", response.Results...))
+}
+
+// FleetApplications handles GET /api/v1/admin/applications, listing the applications
+// of every tenant. The caller's Authorization header, the admin bearer at the gateway,
+// is forwarded on every call so backends enforcing authentication serve them.
+func (a *Aggregator) FleetApplications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authorization := r.Header.Get("Authorization")
+
+	tenantHeader := http.Header{}
+	if authorization != "" {
+		tenantHeader.Set("Authorization", authorization)
+	}
+	tenants, err := a.listAll(ctx, a.config.TenantServiceURL+"/api/v1/tenants", "tenants", tenantHeader)
+	if err != nil {
+		// Without the tenant list there is nothing to fan out to
+		response := &PartialResponse[TenantApplications]{
+			Results: []TenantApplications{},
+			Partial: true,
+			Errors:  []SourceError{newSourceError("tenant-service", err)},
+		}
+		a.logFailures(r, response.Errors)
+		a.writeResponse(w, r, response.StatusCode(), response)
+		return
+	}
+
+	names := make(map[string]string, len(tenants))
+	sources := make([]string, 0, len(tenants))
+	for _, raw := range tenants {
+		var tenant struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &tenant); err != nil || tenant.ID == "" {
+			continue
+		}
+		source := "tenant/" + tenant.ID
+		names[source] = tenant.Name
+		sources = append(sources, source)
+	}
+
+	response := fanOut(ctx, sources, func(ctx context.Context, source string) (TenantApplications, error) {
+		tenantID := source[len("tenant/"):]
+		header := http.Header{"X-Tenant-Id": []string{tenantID}}
+		if authorization != "" {
+			header.Set("Authorization", authorization)
+		}
+
+		apps, err := a.listAll(ctx, a.config.ApplicationServiceURL+"/api/v1/applications", "applications", header)
+		if err != nil {
+			return TenantApplications{}, err
+		}
+		return TenantApplications{TenantID: tenantID, TenantName: names[source], Applications: apps}, nil
+	})
+
+	a.logFailures(r, response.Errors)
+	a.writeResponse(w, r, response.StatusCode(), response)
+}
+
+// listAll pages through a backend list endpoint, returning the items under key. Lists
+// wrapped in the response envelope carry the items directly.
+func (a *Aggregator) listAll(ctx context.Context, endpoint, key string, header http.Header) ([]json.RawMessage, error) {
+	items := []json.RawMessage{}
+
+	for page := 0; page < maxAggregatePages; page++ {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(aggregatePageSize))
+		query.Set("offset", strconv.Itoa(page*aggregatePageSize))
+
+		var body json.RawMessage
+		if err := getJSON(ctx, a.client, endpoint+"?"+query.Encode(), header, &body); err != nil {
+			return nil, err
+		}
+
+		batch, err := listItems(body, key)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+
+		if len(batch) < aggregatePageSize {
+			return items, nil
+		}
+	}
+
+	return items, nil
+}
+
+// listItems extracts list items from either a bare array or an object holding them
+// under key
+func listItems(body json.RawMessage, key string) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err == nil {
+		return items, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, fmt.Errorf("invalid list response: %w", err)
+	}
+	raw, ok := object[key]
+	if !ok || string(raw) == "null" {
+		return nil, nil
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("invalid %s in list response: %w", key, err)
+	}
+	return items, nil
+}
+
+// logFailures records the sources that failed during a fan-out
+func (a *Aggregator) logFailures(r *http.Request, failures []SourceError) {
+	for _, failure := range failures {
+		a.config.Logger.WithFields(logger.LogFields{
+			logger.FieldError:    failure.Error,
+			logger.FieldHTTPPath: r.URL.Path,
+			"source":             failure.Source,
+		}).Warn("Fan-out source failed, returning partial response")
+	}
+}
+
+func (a *Aggregator) writeResponse(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		a.config.Logger.WithFields(logger.LogFields{
+			logger.FieldError:    err.Error(),
+			logger.FieldHTTPPath: r.URL.Path,
+		}).Error("Failed to encode aggregated response")
+	}
+}
@@ -0,0 +1,258 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBackend(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestAggregator_ServicesHealth(t *testing.T) {
+	healthy := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/health", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"status":"healthy","service":%q}`, name)
+		}
+	}
+
+	t.Run("one backend failing returns the healthy ones", func(t *testing.T) {
+		aggregator := NewAggregator(&ProxyConfig{
+			ApplicationServiceURL: newBackend(t, healthy("ai-idp-application-service")),
+			TeamServiceURL: newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+			}),
+			TenantServiceURL: newBackend(t, healthy("ai-idp-tenant-service")),
+			Logger:           logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.ServicesHealth(rr, httptest.NewRequest(http.MethodGet, "/health/services", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response PartialResponse[ServiceHealth]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Partial)
+
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, "application-service", response.Results[0].Service)
+		assert.Equal(t, "tenant-service", response.Results[1].Service)
+		assert.JSONEq(t, `{"status":"healthy","service":"ai-idp-tenant-service"}`, string(response.Results[1].Health))
+
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "team-service", response.Errors[0].Source)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Errors[0].Status)
+		assert.Contains(t, response.Errors[0].Error, "503")
+	})
+
+	t.Run("every backend failing is a bad gateway", func(t *testing.T) {
+		down := newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		aggregator := NewAggregator(&ProxyConfig{
+			ApplicationServiceURL: down,
+			TeamServiceURL:        down,
+			TenantServiceURL:      "http://127.0.0.1:1", // nothing listening
+			Logger:                logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.ServicesHealth(rr, httptest.NewRequest(http.MethodGet, "/health/services", nil))
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+
+		var response PartialResponse[ServiceHealth]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Partial)
+		assert.Empty(t, response.Results)
+		assert.Len(t, response.Errors, 3)
+	})
+}
+
+func TestAggregator_FleetApplications(t *testing.T) {
+	tenantURL := newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/tenants", r.URL.Path)
+		fmt.Fprint(w, `{"tenants":[{"id":"tenant-a","name":"acme"},{"id":"tenant-b","name":"globex"}],"pagination":{"limit":100,"offset":0}}`)
+	})
+
+	t.Run("one tenant failing returns the others", func(t *testing.T) {
+		appURL := newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Header.Get("X-Tenant-ID") {
+			case "tenant-a":
+				fmt.Fprint(w, `{"applications":[{"name":"orders-api"},{"name":"billing-api"}]}`)
+			default:
+				http.Error(w, "boom", http.StatusInternalServerError)
+			}
+		})
+
+		aggregator := NewAggregator(&ProxyConfig{
+			ApplicationServiceURL: appURL,
+			TenantServiceURL:      tenantURL,
+			Logger:                logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.FleetApplications(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/applications", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response PartialResponse[TenantApplications]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Partial)
+
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, "tenant-a", response.Results[0].TenantID)
+		assert.Equal(t, "acme", response.Results[0].TenantName)
+		assert.Len(t, response.Results[0].Applications, 2)
+
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "tenant/tenant-b", response.Errors[0].Source)
+		assert.Equal(t, http.StatusInternalServerError, response.Errors[0].Status)
+	})
+
+	t.Run("enveloped backends are unwrapped", func(t *testing.T) {
+		appURL := newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"success":true,"data":[{"name":"orders-api"}],"meta":{"request_id":"abc"}}`)
+		})
+
+		aggregator := NewAggregator(&ProxyConfig{
+			ApplicationServiceURL: appURL,
+			TenantServiceURL:      tenantURL,
+			Logger:                logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.FleetApplications(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/applications", nil))
+
+		var response PartialResponse[TenantApplications]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.False(t, response.Partial)
+		assert.Empty(t, response.Errors)
+		require.Len(t, response.Results, 2)
+		assert.Len(t, response.Results[1].Applications, 1)
+	})
+
+	t.Run("forwards the caller's credentials to backends enforcing auth", func(t *testing.T) {
+		const adminToken = "admin-secret"
+		authenticated := func(handler http.HandlerFunc) string {
+			return newBackend(t, middleware.JWTAuth("jwt-secret", middleware.WithAdminToken(adminToken))(
+				middleware.RequireAdmin(adminToken)(handler)).ServeHTTP)
+		}
+
+		aggregator := NewAggregator(&ProxyConfig{
+			ApplicationServiceURL: authenticated(func(w http.ResponseWriter, r *http.Request) {
+				assert.NotEmpty(t, r.Header.Get("X-Tenant-ID"))
+				fmt.Fprint(w, `{"applications":[{"name":"orders-api"}]}`)
+			}),
+			TenantServiceURL: authenticated(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"tenants":[{"id":"tenant-a","name":"acme"},{"id":"tenant-b","name":"globex"}]}`)
+			}),
+			Logger: logger.New("debug", "text"),
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/applications", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+		aggregator.FleetApplications(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var response PartialResponse[TenantApplications]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.False(t, response.Partial)
+		assert.Len(t, response.Results, 2)
+	})
+
+	t.Run("tenant list failure", func(t *testing.T) {
+		aggregator := NewAggregator(&ProxyConfig{
+			TenantServiceURL: newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}),
+			Logger: logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.FleetApplications(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/applications", nil))
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+
+		var response PartialResponse[TenantApplications]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "tenant-service", response.Errors[0].Source)
+	})
+}
+
+func TestAggregator_OpenAPI(t *testing.T) {
+	spec := func(path string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/openapi.json", r.URL.Path)
+			doc := openapi.New("Service", "1.0.0", nil)
+			doc.Add("GET "+path, openapi.Endpoint{})
+			doc.Handler().ServeHTTP(w, r)
+		}
+	}
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}
+
+	t.Run("merges the backend documents", func(t *testing.T) {
+		aggregator := NewAggregator(&ProxyConfig{
+			TeamServiceURL:        newBackend(t, spec("/api/v1/teams")),
+			ApplicationServiceURL: newBackend(t, spec("/api/v1/applications")),
+			Logger:                logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.OpenAPI(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var doc openapi.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.Equal(t, []string{"GET /api/v1/applications", "GET /api/v1/teams"}, doc.Operations())
+	})
+
+	t.Run("a failing backend is left out", func(t *testing.T) {
+		aggregator := NewAggregator(&ProxyConfig{
+			TeamServiceURL:        newBackend(t, spec("/api/v1/teams")),
+			ApplicationServiceURL: newBackend(t, failing),
+			Logger:                logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.OpenAPI(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var doc openapi.Document
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		assert.Equal(t, []string{"GET /api/v1/teams"}, doc.Operations())
+	})
+
+	t.Run("every backend failing is a bad gateway", func(t *testing.T) {
+		aggregator := NewAggregator(&ProxyConfig{
+			TeamServiceURL:        newBackend(t, failing),
+			ApplicationServiceURL: newBackend(t, failing),
+			Logger:                logger.New("debug", "text"),
+		})
+
+		rr := httptest.NewRecorder()
+		aggregator.OpenAPI(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+	})
+}
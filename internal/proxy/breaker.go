@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+)
+
+// Circuit breaker defaults
+const (
+	// DefaultBreakerFailureThreshold is the number of consecutive failures that opens
+	// a service's circuit
+	DefaultBreakerFailureThreshold = 5
+	// DefaultBreakerCooldown is how long a circuit stays open before a probe request
+	// is let through
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// breakerState is the state of a service's circuit
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// String returns the state name used in logs
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// breakerOutcome is what a proxied request tells the breaker about its backend
+type breakerOutcome int
+
+const (
+	// outcomeIgnored is for requests that failed for reasons unrelated to the
+	// backend, such as the client going away
+	outcomeIgnored breakerOutcome = iota
+	outcomeSuccess
+	outcomeFailure
+)
+
+// circuit tracks the health of one backend service
+type circuit struct {
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// breakers holds a circuit per backend service. A circuit opens after threshold
+// consecutive failures and rejects requests until cooldown has passed; it then
+// half-opens and lets a single probe through, closing again if the probe succeeds.
+type breakers struct {
+	threshold int
+	cooldown  time.Duration
+	logger    *logger.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// newBreakers creates breakers, applying the defaults to unset thresholds
+func newBreakers(threshold int, cooldown time.Duration, appLogger *logger.Logger) *breakers {
+	if threshold <= 0 {
+		threshold = DefaultBreakerFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    appLogger,
+		now:       time.Now,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// allow reports whether a request to service may be sent. When it may, the caller
+// must pass the request's outcome to done; when it may not, retryAfter is the time
+// left until the circuit half-opens.
+func (b *breakers) allow(service string) (done func(breakerOutcome), retryAfter time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[service]
+	if c == nil {
+		c = &circuit{}
+		b.circuits[service] = c
+	}
+
+	probe := false
+	switch c.state {
+	case breakerOpen:
+		if elapsed := b.now().Sub(c.openedAt); elapsed < b.cooldown {
+			return nil, b.cooldown - elapsed, false
+		}
+		b.transition(service, c, breakerHalfOpen)
+		fallthrough
+	case breakerHalfOpen:
+		// Only one probe at a time; everything else waits for its result
+		if c.probing {
+			return nil, b.cooldown, false
+		}
+		c.probing = true
+		probe = true
+	}
+
+	return func(outcome breakerOutcome) {
+		b.record(service, c, probe, outcome)
+	}, 0, true
+}
+
+// record applies a request's outcome to its circuit
+func (b *breakers) record(service string, c *circuit, probe bool, outcome breakerOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		c.probing = false
+	} else if c.state != breakerClosed {
+		// Requests sent before the circuit opened say nothing about the probe
+		return
+	}
+
+	switch outcome {
+	case outcomeSuccess:
+		c.failures = 0
+		if c.state != breakerClosed {
+			b.transition(service, c, breakerClosed)
+		}
+	case outcomeFailure:
+		c.failures++
+		if probe || c.failures >= b.threshold {
+			c.openedAt = b.now()
+			b.transition(service, c, breakerOpen)
+		}
+	}
+}
+
+// transition moves a circuit to a new state and logs the change. Callers hold b.mu.
+func (b *breakers) transition(service string, c *circuit, state breakerState) {
+	previous := c.state
+	c.state = state
+
+	entry := b.logger.WithFields(logger.LogFields{
+		"service":              service,
+		"breaker_state":        state.String(),
+		"previous_state":       previous.String(),
+		"consecutive_failures": c.failures,
+	})
+	switch state {
+	case breakerOpen:
+		entry.Warn("Circuit breaker opened")
+	case breakerHalfOpen:
+		entry.Info("Circuit breaker half-open, probing backend")
+	default:
+		entry.Info("Circuit breaker closed")
+	}
+}
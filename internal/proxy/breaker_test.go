@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyHandler_CircuitBreaker(t *testing.T) {
+	var calls int32
+	var healthy atomic.Bool
+	handler, config := setupTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	config.BreakerFailureThreshold = 3
+	config.BreakerCooldown = time.Minute
+	handler = NewProxyHandler(config)
+
+	now := time.Now()
+	handler.breakers.now = func() time.Time { return now }
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	// Consecutive backend failures open the team service circuit
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusServiceUnavailable, get("/api/v1/teams").Code)
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	t.Run("open circuit short-circuits without calling the backend", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			rr := get("/api/v1/teams")
+			assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+			assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+			assert.Contains(t, rr.Body.String(), "SERVICE_UNAVAILABLE")
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("circuits are per service", func(t *testing.T) {
+		healthy.Store(true)
+		assert.Equal(t, http.StatusOK, get("/api/v1/applications").Code)
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+		healthy.Store(false)
+	})
+
+	t.Run("failed probe reopens the circuit", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		assert.Equal(t, http.StatusServiceUnavailable, get("/api/v1/teams").Code)
+		assert.Equal(t, int32(5), atomic.LoadInt32(&calls), "one probe reaches the backend")
+
+		get("/api/v1/teams")
+		assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("successful probe closes the circuit", func(t *testing.T) {
+		healthy.Store(true)
+		now = now.Add(time.Minute)
+
+		assert.Equal(t, http.StatusOK, get("/api/v1/teams").Code)
+		assert.Equal(t, http.StatusOK, get("/api/v1/teams").Code)
+		assert.Equal(t, int32(7), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestBreakers_HalfOpenAllowsSingleProbe(t *testing.T) {
+	b := newBreakers(1, time.Second, logger.New("debug", "text"))
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	done, _, ok := b.allow("team-service")
+	require.True(t, ok)
+	done(outcomeFailure)
+
+	_, _, ok = b.allow("team-service")
+	assert.False(t, ok)
+
+	now = now.Add(time.Second)
+	probe, _, ok := b.allow("team-service")
+	require.True(t, ok)
+
+	_, _, ok = b.allow("team-service")
+	assert.False(t, ok, "a second request must wait for the probe")
+
+	// A probe abandoned by its client lets the next request probe instead
+	probe(outcomeIgnored)
+	probe, _, ok = b.allow("team-service")
+	require.True(t, ok)
+	probe(outcomeSuccess)
+
+	done, _, ok = b.allow("team-service")
+	assert.True(t, ok)
+	done(outcomeSuccess)
+}
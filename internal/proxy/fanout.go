@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/aykay76/ai-idp/internal/middleware"
+)
+
+// maxFanOutConcurrency bounds the number of backend calls made at once by a fan-out
+const maxFanOutConcurrency = 8
+
+// maxFanOutBodyBytes bounds each backend response read during a fan-out
+const maxFanOutBodyBytes = 4 << 20
+
+// SourceError describes a backend or tenant that failed during a fan-out
+type SourceError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+	Status int    `json:"status,omitempty"`
+}
+
+// PartialResponse is the shape of every fan-out response. Results holds the data from
+// the sources that succeeded. When any source fails, Partial is true and Errors says
+// which sources failed and why.
+//
+// Status code policy: the response is 200 whenever at least one source succeeded (or
+// there were no sources), so clients must check Partial rather than the status code.
+// It is 502 only when every source failed.
+type PartialResponse[T any] struct {
+	Results []T           `json:"results"`
+	Partial bool          `json:"partial"`
+	Errors  []SourceError `json:"errors,omitempty"`
+}
+
+// StatusCode returns the HTTP status for the response under the policy above
+func (p *PartialResponse[T]) StatusCode() int {
+	if len(p.Results) == 0 && len(p.Errors) > 0 {
+		return http.StatusBadGateway
+	}
+	return http.StatusOK
+}
+
+// statusError reports a non-2xx backend response
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("backend returned %d %s", e.status, http.StatusText(e.status))
+}
+
+// fanOut calls fetch for every source concurrently and gathers the results in source
+// order. A failing source becomes a SourceError instead of failing the whole call.
+func fanOut[T any](ctx context.Context, sources []string, fetch func(ctx context.Context, source string) (T, error)) *PartialResponse[T] {
+	type outcome struct {
+		result T
+		err    error
+	}
+
+	outcomes := make([]outcome, len(sources))
+	slots := make(chan struct{}, maxFanOutConcurrency)
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result, err := fetch(ctx, source)
+			outcomes[i] = outcome{result: result, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+
+	response := &PartialResponse[T]{Results: make([]T, 0, len(sources))}
+	for i, o := range outcomes {
+		if o.err != nil {
+			response.Errors = append(response.Errors, newSourceError(sources[i], o.err))
+			continue
+		}
+		response.Results = append(response.Results, o.result)
+	}
+	response.Partial = len(response.Errors) > 0
+
+	return response
+}
+
+func newSourceError(source string, err error) SourceError {
+	sourceErr := SourceError{Source: source, Error: err.Error()}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		sourceErr.Status = statusErr.status
+	}
+	return sourceErr
+}
+
+// getJSON performs a GET against a backend and decodes a 2xx JSON body into v. Bodies
+// wrapped in the types.APIResponse envelope are unwrapped first.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	middleware.PropagateRequestID(ctx, req.Header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFanOutBodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{status: resp.StatusCode}
+	}
+
+	var envelope struct {
+		Success *bool           `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Success != nil && envelope.Data != nil {
+		body = envelope.Data
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid response body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by validateFraming
+var (
+	ErrConflictingFraming      = errors.New("request carries both Content-Length and Transfer-Encoding")
+	ErrInvalidContentLength    = errors.New("invalid Content-Length header")
+	ErrUnsupportedTransferCode = errors.New("unsupported Transfer-Encoding")
+)
+
+// validateFraming rejects requests whose message framing is ambiguous. A request that
+// declares both Content-Length and Transfer-Encoding, repeats Content-Length with
+// different values, or uses a transfer coding other than a single "chunked" could be
+// interpreted differently by the gateway and a backend, which is the basis of request
+// smuggling.
+func validateFraming(r *http.Request) error {
+	transferEncodings := r.TransferEncoding
+	if len(transferEncodings) == 0 {
+		transferEncodings = splitHeaderValues(r.Header.Values("Transfer-Encoding"))
+	}
+	contentLengths := splitHeaderValues(r.Header.Values("Content-Length"))
+
+	if len(transferEncodings) > 0 && len(contentLengths) > 0 {
+		return ErrConflictingFraming
+	}
+
+	if len(transferEncodings) > 0 {
+		if len(transferEncodings) != 1 || !strings.EqualFold(transferEncodings[0], "chunked") {
+			return ErrUnsupportedTransferCode
+		}
+	}
+
+	var declared int64 = -1
+	for _, value := range contentLengths {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return ErrInvalidContentLength
+		}
+		if declared >= 0 && n != declared {
+			return ErrInvalidContentLength
+		}
+		declared = n
+	}
+
+	if declared >= 0 && r.ContentLength >= 0 && declared != r.ContentLength {
+		return ErrInvalidContentLength
+	}
+
+	return nil
+}
+
+// splitHeaderValues splits comma separated header values into trimmed tokens
+func splitHeaderValues(values []string) []string {
+	var tokens []string
+	for _, value := range values {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
@@ -1,54 +1,189 @@
 package proxy
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Backend connection pool defaults
+const (
+	// DefaultProxyTimeout bounds a proxied request, including reading the response
+	DefaultProxyTimeout = 30 * time.Second
+	// DefaultMaxIdleConns is the number of idle backend connections kept across all
+	// services
+	DefaultMaxIdleConns = 100
+	// DefaultMaxIdleConnsPerHost is the number of idle connections kept per backend.
+	// The standard library keeps two, which forces new connections under any
+	// concurrency.
+	DefaultMaxIdleConnsPerHost = 32
+	// DefaultIdleConnTimeout is how long an idle backend connection is kept open
+	DefaultIdleConnTimeout = 90 * time.Second
 )
 
 // ProxyConfig holds configuration for service proxying
 type ProxyConfig struct {
 	ApplicationServiceURL string
 	TeamServiceURL        string
-	Logger                *logger.Logger
+	TenantServiceURL      string
+	UserServiceURL        string
+	// Routes map path prefixes to backends, longest prefix first; DefaultRoutes is
+	// used when empty
+	Routes []RouteRule
+	Logger *logger.Logger
+	// DisableFramingChecks turns off the Content-Length/Transfer-Encoding sanity
+	// checks that guard backends against request smuggling
+	DisableFramingChecks bool
+	// ShadowTargets mirror a share of a route's traffic to another backend
+	ShadowTargets []ShadowTarget
+	// ShadowTimeout bounds mirrored requests; defaults to DefaultShadowTimeout
+	ShadowTimeout time.Duration
+	// BreakerFailureThreshold is the number of consecutive failures after which a
+	// service's circuit opens; defaults to DefaultBreakerFailureThreshold
+	BreakerFailureThreshold int
+	// BreakerCooldown is how long an open circuit rejects requests before probing the
+	// service again; defaults to DefaultBreakerCooldown
+	BreakerCooldown time.Duration
+	// Timeout bounds each proxied request; defaults to DefaultProxyTimeout
+	Timeout time.Duration
+	// MaxIdleConns, MaxIdleConnsPerHost and IdleConnTimeout size the pool of backend
+	// connections shared by all requests; they default to DefaultMaxIdleConns,
+	// DefaultMaxIdleConnsPerHost and DefaultIdleConnTimeout
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// MaxRetries is how many times a GET, HEAD or OPTIONS request is retried when its
+	// backend is unreachable or unavailable; zero disables retries. Other methods are
+	// never retried.
+	MaxRetries int
+	// RetryBaseDelay is the wait before the first retry, doubled for each further one
+	// and jittered; defaults to DefaultRetryBaseDelay
+	RetryBaseDelay time.Duration
+	// ReadinessTimeout bounds each backend health probe made for the gateway's
+	// readiness; defaults to DefaultReadinessTimeout
+	ReadinessTimeout time.Duration
+	// ReadinessCacheTTL is how long a readiness result is reused; defaults to
+	// DefaultReadinessCacheTTL
+	ReadinessCacheTTL time.Duration
+}
+
+// routes returns the configured routes, or DefaultRoutes when there are none
+func (c *ProxyConfig) routes() []RouteRule {
+	if len(c.Routes) == 0 {
+		return DefaultRoutes(c)
+	}
+	return c.Routes
 }
 
 // ProxyHandler handles proxying requests to backend services
 type ProxyHandler struct {
-	config *ProxyConfig
+	config   *ProxyConfig
+	routes   routeTable
+	breakers *breakers
+	client   *http.Client
+
+	shadowClient *http.Client
+	shadowSlots  chan struct{}
+	shadows      shadowCounters
+	sample       func(n int) int
 }
 
 // NewProxyHandler creates a new proxy handler
 func NewProxyHandler(config *ProxyConfig) *ProxyHandler {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProxyTimeout
+	}
+
+	// Proxied and mirrored requests share one pool so backend connections are reused
+	transport := newTransport(config)
+
 	return &ProxyHandler{
-		config: config,
+		config:       config,
+		routes:       newRouteTable(config.routes()),
+		breakers:     newBreakers(config.BreakerFailureThreshold, config.BreakerCooldown, config.Logger),
+		client:       &http.Client{Transport: transport, Timeout: timeout},
+		shadowClient: &http.Client{Transport: transport},
+		shadowSlots:  make(chan struct{}, maxInFlightShadows),
+		sample:       defaultSample,
 	}
 }
 
+// newTransport creates the backend transport, applying the connection pool defaults
+func newTransport(config *ProxyConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = DefaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = DefaultIdleConnTimeout
+
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+	return transport
+}
+
 // ServeHTTP implements the http.Handler interface for proxying
 func (p *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Reject ambiguous message framing before anything is forwarded
+	if !p.config.DisableFramingChecks {
+		if err := validateFraming(r); err != nil {
+			p.config.Logger.WithFields(logger.LogFields{
+				logger.FieldError:      err.Error(),
+				logger.FieldHTTPMethod: r.Method,
+				logger.FieldHTTPPath:   r.URL.Path,
+			}).Warn("Rejected request with ambiguous framing")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Determine target service based on path
-	var targetURL string
-	var serviceName string
-
-	if strings.HasPrefix(r.URL.Path, "/api/v1/teams") {
-		targetURL = p.config.TeamServiceURL
-		serviceName = "team-service"
-	} else if strings.HasPrefix(r.URL.Path, "/api/v1/applications") {
-		targetURL = p.config.ApplicationServiceURL
-		serviceName = "application-service"
-	} else {
+	route, ok := p.routes.match(r.URL.Path)
+	if !ok {
 		p.config.Logger.WithFields(logger.LogFields{
 			logger.FieldHTTPMethod: r.Method,
 			logger.FieldHTTPPath:   r.URL.Path,
 		}).Warn("No service found for path")
-		http.Error(w, "Service not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, &types.APIError{
+			Code:    "NOT_FOUND",
+			Message: "Service not found",
+		})
+		return
+	}
+	targetURL := route.TargetURL
+	serviceName := route.ServiceName
+
+	// Fail fast while the service's circuit is open rather than waiting on a backend
+	// that is known to be down
+	done, retryAfter, ok := p.breakers.allow(serviceName)
+	if !ok {
+		p.config.Logger.WithFields(logger.LogFields{
+			logger.FieldHTTPMethod: r.Method,
+			logger.FieldHTTPPath:   r.URL.Path,
+			"service":              serviceName,
+		}).Debug("Circuit open, rejecting request")
+		middleware.WriteRetryableError(w, http.StatusServiceUnavailable, &types.APIError{
+			Code:    "SERVICE_UNAVAILABLE",
+			Message: serviceName + " is unavailable",
+		}, retryAfter)
 		return
 	}
+	outcome := outcomeIgnored
+	defer func() { done(outcome) }()
 
 	// Parse target URL
 	target, err := url.Parse(targetURL)
@@ -76,6 +211,25 @@ func (p *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"proxy_url":            proxyURL.String(),
 	}).Debug("Proxying request")
 
+	// Buffer the body of requests selected for shadowing so both backends receive it
+	shadow := p.selectShadow(r)
+	var shadowBody []byte
+	if shadow != nil {
+		var ok bool
+		shadowBody, ok, err = bufferShadowBody(r)
+		if err != nil {
+			p.config.Logger.WithFields(logger.LogFields{
+				logger.FieldError: err.Error(),
+			}).Error("Failed to read request body")
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if !ok {
+			p.shadows.skipped.Add(1)
+			shadow = nil
+		}
+	}
+
 	// Create proxy request
 	proxyReq, err := http.NewRequestWithContext(r.Context(), r.Method, proxyURL.String(), r.Body)
 	if err != nil {
@@ -86,12 +240,16 @@ func (p *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Let the client frame the body from the validated length rather than copying
+	// the framing headers verbatim
+	proxyReq.ContentLength = r.ContentLength
+
 	// Copy headers from original request
 	for name, values := range r.Header {
-		// Skip hop-by-hop headers
+		// Skip hop-by-hop and framing headers
 		if name == "Connection" || name == "Keep-Alive" || name == "Proxy-Authenticate" ||
 			name == "Proxy-Authorization" || name == "Te" || name == "Trailers" ||
-			name == "Transfer-Encoding" || name == "Upgrade" {
+			name == "Transfer-Encoding" || name == "Upgrade" || name == "Content-Length" {
 			continue
 		}
 		for _, value := range values {
@@ -104,24 +262,52 @@ func (p *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	proxyReq.Header.Set("X-Forwarded-Host", r.Host)
 	proxyReq.Header.Set("X-Forwarded-Proto", "http") // TODO: detect actual protocol
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Forward the request ID, including one the gateway generated, so backend logs
+	// can be correlated with the gateway's
+	middleware.PropagateRequestID(r.Context(), proxyReq.Header)
+
+	// Advertise the remaining request budget so the backend gives up when the client would
+	if !middleware.PropagateDeadline(r.Context(), proxyReq.Header) {
+		p.config.Logger.WithFields(logger.LogFields{
+			logger.FieldHTTPMethod: r.Method,
+			logger.FieldHTTPPath:   r.URL.Path,
+			"service":              serviceName,
+		}).Warn("Request deadline exceeded before proxying")
+		http.Error(w, "Gateway Timeout", http.StatusGatewayTimeout)
+		return
 	}
 
 	// Make the proxy request
-	resp, err := client.Do(proxyReq)
+	resp, err := p.send(proxyReq, serviceName)
 	if err != nil {
+		// A client that went away or ran out of budget says nothing about the backend
+		if r.Context().Err() == nil {
+			outcome = outcomeFailure
+		}
 		p.config.Logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 			"service":         serviceName,
 			"proxy_url":       proxyURL.String(),
 		}).Error("Proxy request failed")
+		if errors.Is(err, context.DeadlineExceeded) {
+			http.Error(w, "Gateway Timeout", http.StatusGatewayTimeout)
+			return
+		}
 		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
 		return
 	}
 	defer resp.Body.Close()
 
+	outcome = outcomeSuccess
+	if isBackendFailure(resp.StatusCode) {
+		outcome = outcomeFailure
+	}
+
+	// Mirror to the shadow backend; its response never reaches the client
+	if shadow != nil {
+		p.mirror(r.Context(), shadow, proxyReq, shadowBody, resp.StatusCode)
+	}
+
 	// Copy response headers
 	for name, values := range resp.Header {
 		// Skip hop-by-hop headers
@@ -155,3 +341,17 @@ func (p *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"service":              serviceName,
 	}).Debug("Request proxied successfully")
 }
+
+// isBackendFailure reports whether a backend response means the service is unhealthy
+// rather than that the request was rejected
+func isBackendFailure(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// writeJSONError writes an error in the types.APIError shape used by the gateway
+// middleware
+func writeJSONError(w http.ResponseWriter, status int, apiErr *types.APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiErr)
+}
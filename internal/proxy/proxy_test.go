@@ -0,0 +1,473 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestProxy(t *testing.T, backend http.Handler) (*ProxyHandler, *ProxyConfig) {
+	t.Helper()
+
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	config := &ProxyConfig{
+		ApplicationServiceURL: server.URL,
+		TeamServiceURL:        server.URL,
+		Logger:                logger.New("debug", "text"),
+	}
+	return NewProxyHandler(config), config
+}
+
+func TestProxyHandler_FramingChecks(t *testing.T) {
+	var calls int32
+	var receivedBody string
+	handler, config := setupTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	t.Run("rejects Content-Length with Transfer-Encoding", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"team"}`))
+		req.Header.Set("Content-Length", "15")
+		req.Header.Set("Transfer-Encoding", "chunked")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "request must not reach the backend")
+	})
+
+	t.Run("rejects parsed chunked request that also declares Content-Length", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"team"}`))
+		req.TransferEncoding = []string{"chunked"}
+		req.Header.Set("Content-Length", "15")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("rejects conflicting Content-Length values", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"team"}`))
+		req.Header.Add("Content-Length", "15")
+		req.Header.Add("Content-Length", "4")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("rejects unsupported transfer codings", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"team"}`))
+		req.Header.Set("Transfer-Encoding", "gzip, chunked")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("forwards well-formed requests", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"team"}`))
+		req.Header.Set("Content-Length", "15")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(t, `{"name":"team"}`, receivedBody)
+	})
+
+	t.Run("checks can be disabled", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		config.DisableFramingChecks = true
+		defer func() { config.DisableFramingChecks = false }()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"team"}`))
+		req.Header.Set("Content-Length", "15")
+		req.Header.Set("Transfer-Encoding", "chunked")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestProxyHandler_ShadowTraffic(t *testing.T) {
+	type mirrored struct {
+		method string
+		path   string
+		body   string
+		shadow string
+		auth   string
+		cookie string
+	}
+
+	shadowRequests := make(chan mirrored, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		shadowRequests <- mirrored{
+			method: r.Method,
+			path:   r.URL.RequestURI(),
+			body:   string(body),
+			shadow: r.Header.Get("X-Shadow-Request"),
+			auth:   r.Header.Get("Authorization"),
+			cookie: r.Header.Get("Cookie"),
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("shadow response"))
+	}))
+	t.Cleanup(shadow.Close)
+
+	var primaryBody string
+	handler, config := setupTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		primaryBody = string(body)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("primary response"))
+	}))
+	config.ShadowTargets = []ShadowTarget{{PathPrefix: "/api/v1/applications", URL: shadow.URL, Percent: 100}}
+
+	waitForShadow := func(t *testing.T) mirrored {
+		t.Helper()
+		select {
+		case m := <-shadowRequests:
+			return m
+		case <-time.After(2 * time.Second):
+			t.Fatal("shadow backend did not receive the mirrored request")
+			return mirrored{}
+		}
+	}
+
+	t.Run("mirrors reads while the client sees only the primary response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/applications?limit=5", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "primary response", rr.Body.String())
+
+		m := waitForShadow(t)
+		assert.Equal(t, http.MethodGet, m.method)
+		assert.Equal(t, "/api/v1/applications?limit=5", m.path)
+		assert.Equal(t, "true", m.shadow)
+
+		require.Eventually(t, func() bool { return handler.ShadowStats().Mirrored == 1 }, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, uint64(1), handler.ShadowStats().Mismatched)
+	})
+
+	t.Run("does not mirror writes unless opted in", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/applications", strings.NewReader(`{"name":"app"}`))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"name":"app"}`, primaryBody)
+
+		select {
+		case m := <-shadowRequests:
+			t.Fatalf("unexpected mirrored %s request", m.method)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("mirrors buffered write bodies when opted in", func(t *testing.T) {
+		config.ShadowTargets[0].MirrorWrites = true
+		t.Cleanup(func() { config.ShadowTargets[0].MirrorWrites = false })
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/applications", strings.NewReader(`{"name":"canary"}`))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "primary response", rr.Body.String())
+		assert.Equal(t, `{"name":"canary"}`, primaryBody)
+
+		m := waitForShadow(t)
+		assert.Equal(t, http.MethodPost, m.method)
+		assert.Equal(t, `{"name":"canary"}`, m.body)
+	})
+
+	t.Run("respects the sampling percentage", func(t *testing.T) {
+		config.ShadowTargets[0].Percent = 10
+		t.Cleanup(func() { config.ShadowTargets[0].Percent = 100 })
+
+		sample := 50
+		handler.sample = func(int) int { return sample }
+		t.Cleanup(func() { handler.sample = defaultSample })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		select {
+		case <-shadowRequests:
+			t.Fatal("request outside the sample must not be mirrored")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		sample = 5
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		waitForShadow(t)
+	})
+
+	t.Run("strips credentials unless opted in", func(t *testing.T) {
+		newRequest := func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+			req.Header.Set("Authorization", "Bearer user-token")
+			req.Header.Set("Cookie", "session=secret")
+			return req
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		m := waitForShadow(t)
+		assert.Empty(t, m.auth)
+		assert.Empty(t, m.cookie)
+
+		config.ShadowTargets[0].ForwardCredentials = true
+		t.Cleanup(func() { config.ShadowTargets[0].ForwardCredentials = false })
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		m = waitForShadow(t)
+		assert.Equal(t, "Bearer user-token", m.auth)
+		assert.Equal(t, "session=secret", m.cookie)
+	})
+
+	t.Run("ignores routes without a shadow target", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		select {
+		case <-shadowRequests:
+			t.Fatal("teams traffic must not be mirrored")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+func TestParseShadowTargets(t *testing.T) {
+	targets, err := ParseShadowTargets("/api/v1/applications|http://canary:8082|10, /api/v1/teams|http://teams-canary:8083|100|writes|credentials")
+	require.NoError(t, err)
+	assert.Equal(t, []ShadowTarget{
+		{PathPrefix: "/api/v1/applications", URL: "http://canary:8082", Percent: 10},
+		{PathPrefix: "/api/v1/teams", URL: "http://teams-canary:8083", Percent: 100, MirrorWrites: true, ForwardCredentials: true},
+	}, targets)
+
+	targets, err = ParseShadowTargets("")
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	for _, value := range []string{
+		"/api/v1/applications|http://canary:8082",
+		"/api/v1/applications|http://canary:8082|150",
+		"/api/v1/applications|http://canary:8082|10|all",
+	} {
+		_, err := ParseShadowTargets(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestProxyHandler_DeadlinePropagation(t *testing.T) {
+	t.Run("deadline shrinks across the gateway to backend hop", func(t *testing.T) {
+		var advertised string
+		var backendRemaining time.Duration
+		backend := middleware.Deadline(30 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			advertised = r.Header.Get(middleware.DeadlineHeader)
+			deadline, ok := r.Context().Deadline()
+			if ok {
+				backendRemaining = time.Until(deadline)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		handler, _ := setupTestProxy(t, backend)
+		gateway := middleware.Deadline(2 * time.Second)(handler)
+
+		rr := httptest.NewRecorder()
+		gateway.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		remaining, ok := middleware.ParseDeadlineHeader(advertised)
+		require.True(t, ok, "backend should receive the remaining budget")
+		assert.Greater(t, remaining, time.Duration(0))
+		assert.LessOrEqual(t, remaining, 2*time.Second)
+		assert.LessOrEqual(t, backendRemaining, remaining, "backend must not exceed the gateway budget")
+	})
+
+	t.Run("backend honors the propagated deadline", func(t *testing.T) {
+		backendErr := make(chan error, 1)
+		backend := middleware.Deadline(30 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				backendErr <- r.Context().Err()
+				w.WriteHeader(http.StatusGatewayTimeout)
+			case <-time.After(5 * time.Second):
+				backendErr <- nil
+				w.WriteHeader(http.StatusOK)
+			}
+		}))
+
+		handler, _ := setupTestProxy(t, backend)
+		gateway := middleware.Deadline(200 * time.Millisecond)(handler)
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		gateway.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		select {
+		case err := <-backendErr:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(2 * time.Second):
+			t.Fatal("backend did not stop at the propagated deadline")
+		}
+	})
+}
+
+func TestProxyHandler_RequestIDPropagation(t *testing.T) {
+	// Both hops run the RequestID middleware, as the gateway and services do
+	received := make(chan string, 1)
+	backend := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Context().Value(types.RequestIDKey).(string)
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler, _ := setupTestProxy(t, backend)
+	gateway := middleware.RequestID(handler)
+
+	t.Run("a generated ID survives the proxy hop", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		gateway.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		gatewayID := rr.Header().Get(middleware.RequestIDHeader)
+		require.NotEmpty(t, gatewayID)
+		assert.Equal(t, gatewayID, <-received, "the backend should use the gateway's request ID")
+	})
+
+	t.Run("an inbound ID is preserved end to end", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		req.Header.Set(middleware.RequestIDHeader, "client-trace-42")
+
+		rr := httptest.NewRecorder()
+		gateway.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		assert.Equal(t, "client-trace-42", rr.Header().Get(middleware.RequestIDHeader))
+		assert.Equal(t, "client-trace-42", <-received)
+	})
+}
+
+// newCountingBackend starts a backend that counts the connections opened to it
+func newCountingBackend(t testing.TB) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"teams":[]}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server, &conns
+}
+
+// proxyConcurrently sends n simultaneous requests through handler
+func proxyConcurrently(handler http.Handler, n int) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestProxyHandler_ReusesConnections(t *testing.T) {
+	backend, conns := newCountingBackend(t)
+	handler := NewProxyHandler(&ProxyConfig{
+		TeamServiceURL: backend.URL,
+		Logger:         logger.New("error", "text"),
+	})
+
+	t.Run("sequential requests share one connection", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+		}
+		assert.Equal(t, int32(1), conns.Load())
+	})
+
+	t.Run("concurrent bursts reuse idle connections", func(t *testing.T) {
+		const concurrency = 16
+		proxyConcurrently(handler, concurrency)
+		opened := conns.Load()
+		require.LessOrEqual(t, opened, int32(concurrency+1))
+
+		// Later bursts find the earlier connections idle in the pool rather than
+		// dialing new ones, as they would with only two idle connections per host
+		for round := 0; round < 5; round++ {
+			proxyConcurrently(handler, concurrency)
+		}
+		assert.LessOrEqual(t, conns.Load()-opened, int32(concurrency/2))
+	})
+}
+
+func BenchmarkProxyHandler_Concurrent(b *testing.B) {
+	backend, conns := newCountingBackend(b)
+	handler := NewProxyHandler(&ProxyConfig{
+		TeamServiceURL: backend.URL,
+		Logger:         logger.New("error", "text"),
+	})
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+		}
+	})
+	b.StopTimer()
+
+	b.ReportMetric(float64(conns.Load()), "conns")
+}
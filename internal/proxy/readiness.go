@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+)
+
+// Readiness probe defaults
+const (
+	// DefaultReadinessTimeout bounds each backend health probe
+	DefaultReadinessTimeout = 2 * time.Second
+	// DefaultReadinessCacheTTL is how long a readiness result is reused, so frequent
+	// probes of the gateway do not each probe every backend
+	DefaultReadinessCacheTTL = 2 * time.Second
+)
+
+// Backend health states reported by the readiness check
+const (
+	BackendHealthy   = "healthy"
+	BackendUnhealthy = "unhealthy"
+)
+
+// BackendStatus is one backend's health in a readiness report
+type BackendStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReadinessReport is the gateway's readiness: ready only when every backend is healthy
+type ReadinessReport struct {
+	Status    string                   `json:"status"`
+	Timestamp time.Time                `json:"timestamp"`
+	Services  map[string]BackendStatus `json:"services"`
+}
+
+// Ready reports whether every backend is healthy
+func (r *ReadinessReport) Ready() bool {
+	return r.Status == "ready"
+}
+
+// Readiness checks the health of every backend in the routing table, in parallel, and
+// caches the result briefly
+type Readiness struct {
+	backends map[string]string
+	services []string
+	client   *http.Client
+	timeout  time.Duration
+	ttl      time.Duration
+	logger   *logger.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	report    *ReadinessReport
+	checkedAt time.Time
+}
+
+// NewReadiness creates a readiness check for the backends routed to by config. A
+// service with several routes is probed once, at the URL of its first route.
+func NewReadiness(config *ProxyConfig) *Readiness {
+	timeout := config.ReadinessTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadinessTimeout
+	}
+	ttl := config.ReadinessCacheTTL
+	if ttl <= 0 {
+		ttl = DefaultReadinessCacheTTL
+	}
+
+	rd := &Readiness{
+		backends: make(map[string]string),
+		client:   &http.Client{Timeout: timeout},
+		timeout:  timeout,
+		ttl:      ttl,
+		logger:   config.Logger,
+		now:      time.Now,
+	}
+	for _, route := range config.routes() {
+		if _, ok := rd.backends[route.ServiceName]; ok {
+			continue
+		}
+		rd.backends[route.ServiceName] = route.TargetURL
+		rd.services = append(rd.services, route.ServiceName)
+	}
+	return rd
+}
+
+// Check returns the readiness of the backends, probing them unless a result within the
+// cache TTL is available. Concurrent callers share one probe.
+func (rd *Readiness) Check(ctx context.Context) *ReadinessReport {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	if rd.report != nil && rd.now().Sub(rd.checkedAt) < rd.ttl {
+		return rd.report
+	}
+
+	// The probe is shared, so it must not be cut short by one caller going away
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rd.timeout)
+	defer cancel()
+
+	response := fanOut(ctx, rd.services, func(ctx context.Context, service string) (string, error) {
+		var health json.RawMessage
+		if err := getJSON(ctx, rd.client, rd.backends[service]+"/health", nil, &health); err != nil {
+			return "", err
+		}
+		return service, nil
+	})
+
+	report := &ReadinessReport{
+		Status:    "ready",
+		Timestamp: rd.now().UTC(),
+		Services:  make(map[string]BackendStatus, len(rd.services)),
+	}
+	for _, service := range response.Results {
+		report.Services[service] = BackendStatus{Status: BackendHealthy}
+	}
+	for _, failure := range response.Errors {
+		report.Services[failure.Source] = BackendStatus{Status: BackendUnhealthy, Error: failure.Error}
+		report.Status = "not ready"
+	}
+	if !report.Ready() && rd.logger != nil {
+		rd.logger.WithFields(logger.LogFields{
+			logger.FieldComponent: "gateway-readiness",
+			"services":            report.Services,
+		}).Warn("Gateway not ready, backends unhealthy")
+	}
+
+	rd.report = report
+	rd.checkedAt = rd.now()
+	return report
+}
+
+// ServeHTTP handles GET /readiness: 200 when every backend is healthy, otherwise 503.
+// Both carry the status of each backend.
+func (rd *Readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := rd.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
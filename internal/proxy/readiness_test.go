@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	healthy := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"healthy"}`))
+	}
+	unhealthy := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+	}
+
+	serve := func(t *testing.T, rd *Readiness) (int, ReadinessReport) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		rd.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+
+		var report ReadinessReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		return rr.Code, report
+	}
+
+	tests := []struct {
+		name       string
+		team, app  http.HandlerFunc
+		wantStatus int
+		wantTeam   string
+		wantApp    string
+	}{
+		{"all healthy", healthy, healthy, http.StatusOK, BackendHealthy, BackendHealthy},
+		{"one unhealthy", unhealthy, healthy, http.StatusServiceUnavailable, BackendUnhealthy, BackendHealthy},
+		{"all unhealthy", unhealthy, unhealthy, http.StatusServiceUnavailable, BackendUnhealthy, BackendUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd := NewReadiness(&ProxyConfig{
+				Routes: []RouteRule{
+					{Prefix: "/api/v1/teams", TargetURL: newBackend(t, tt.team), ServiceName: "team-service"},
+					{Prefix: "/api/v1/applications", TargetURL: newBackend(t, tt.app), ServiceName: "application-service"},
+				},
+				Logger: logger.New("debug", "text"),
+			})
+
+			status, report := serve(t, rd)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantStatus == http.StatusOK, report.Ready())
+			require.Len(t, report.Services, 2)
+			assert.Equal(t, tt.wantTeam, report.Services["team-service"].Status)
+			assert.Equal(t, tt.wantApp, report.Services["application-service"].Status)
+			if tt.wantTeam == BackendUnhealthy {
+				assert.Contains(t, report.Services["team-service"].Error, "503")
+			}
+		})
+	}
+
+	t.Run("unreachable and slow backends are unhealthy", func(t *testing.T) {
+		rd := NewReadiness(&ProxyConfig{
+			Routes: []RouteRule{
+				{Prefix: "/api/v1/teams", TargetURL: "http://127.0.0.1:1", ServiceName: "team-service"}, // nothing listening
+				{Prefix: "/api/v1/applications", TargetURL: newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+					select {
+					case <-r.Context().Done():
+					case <-time.After(time.Second):
+					}
+				}), ServiceName: "application-service"},
+			},
+			ReadinessTimeout: 50 * time.Millisecond,
+		})
+
+		start := time.Now()
+		status, report := serve(t, rd)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, BackendUnhealthy, report.Services["team-service"].Status)
+		assert.Equal(t, BackendUnhealthy, report.Services["application-service"].Status)
+		assert.Less(t, time.Since(start), 500*time.Millisecond, "probes are bounded by the timeout")
+	})
+
+	t.Run("services are probed once and results are cached", func(t *testing.T) {
+		var probes atomic.Int32
+		backend := newBackend(t, func(w http.ResponseWriter, r *http.Request) {
+			probes.Add(1)
+			healthy(w, r)
+		})
+
+		now := time.Now()
+		rd := NewReadiness(&ProxyConfig{
+			Routes: []RouteRule{
+				{Prefix: "/api/v1/teams", TargetURL: backend, ServiceName: "team-service"},
+				{Prefix: "/api/v1/members", TargetURL: backend, ServiceName: "team-service"},
+			},
+			ReadinessCacheTTL: time.Second,
+		})
+		rd.now = func() time.Time { return now }
+
+		assert.True(t, rd.Check(context.Background()).Ready())
+		assert.True(t, rd.Check(context.Background()).Ready())
+		assert.Equal(t, int32(1), probes.Load(), "a service with several routes is probed once, and the result reused")
+
+		now = now.Add(time.Second)
+		rd.Check(context.Background())
+		assert.Equal(t, int32(2), probes.Load(), "the result expires after the TTL")
+	})
+
+	t.Run("default routes are probed", func(t *testing.T) {
+		rd := NewReadiness(&ProxyConfig{
+			ApplicationServiceURL: newBackend(t, healthy),
+			TeamServiceURL:        newBackend(t, healthy),
+			TenantServiceURL:      newBackend(t, healthy),
+			UserServiceURL:        newBackend(t, unhealthy),
+		})
+
+		report := rd.Check(context.Background())
+		assert.False(t, report.Ready())
+		assert.Len(t, report.Services, 4)
+		assert.Equal(t, BackendUnhealthy, report.Services["user-service"].Status)
+	})
+}
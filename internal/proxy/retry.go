@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+)
+
+// Retry defaults
+const (
+	// DefaultRetryBaseDelay is the wait before the first retry; later retries double it
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	// maxRetryDelay caps the wait between retries
+	maxRetryDelay = 2 * time.Second
+)
+
+// isIdempotent reports whether a request with method can be repeated without
+// duplicating side effects
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// send makes the backend request. Idempotent requests without a body are retried
+// up to MaxRetries times when the backend cannot be reached or answers 502, 503 or
+// 504; every other request is sent exactly once.
+func (p *ProxyHandler) send(req *http.Request, serviceName string) (*http.Response, error) {
+	retries := 0
+	if isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody) {
+		retries = p.config.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := p.client.Do(req)
+		if attempt >= retries || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil {
+			if !isBackendFailure(resp.StatusCode) {
+				return resp, nil
+			}
+			// Drain the failed response so its connection goes back to the pool
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		delay := p.retryDelay(attempt)
+		fields := logger.LogFields{
+			logger.FieldHTTPMethod: req.Method,
+			logger.FieldHTTPPath:   req.URL.Path,
+			"service":              serviceName,
+			"attempt":              attempt + 1,
+			"retry_in_ms":          delay.Milliseconds(),
+		}
+		if err != nil {
+			fields[logger.FieldError] = err.Error()
+		} else {
+			fields[logger.FieldHTTPStatus] = resp.StatusCode
+		}
+		p.config.Logger.WithFields(fields).Debug("Retrying proxied request")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryDelay returns the jittered wait before retry attempt+1: a random duration
+// between half and all of the base delay doubled for each earlier retry
+func (p *ProxyHandler) retryDelay(attempt int) time.Duration {
+	delay := p.config.RetryBaseDelay
+	if delay <= 0 {
+		delay = DefaultRetryBaseDelay
+	}
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyBackend fails its first failures requests with 503 and then succeeds
+func flakyBackend(failures int32, calls *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestProxyHandler_Retries(t *testing.T) {
+	newHandler := func(t *testing.T, backend http.Handler) *ProxyHandler {
+		_, config := setupTestProxy(t, backend)
+		config.MaxRetries = 2
+		config.RetryBaseDelay = time.Millisecond
+		return NewProxyHandler(config)
+	}
+
+	t.Run("GET succeeds on the second attempt", func(t *testing.T) {
+		var calls int32
+		handler := newHandler(t, flakyBackend(1, &calls))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("GET gives up after MaxRetries", func(t *testing.T) {
+		var calls int32
+		handler := newHandler(t, flakyBackend(10, &calls))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		t.Run(method+" is not retried", func(t *testing.T) {
+			var calls int32
+			handler := newHandler(t, flakyBackend(1, &calls))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(method, "/api/v1/teams", strings.NewReader(`{"name":"team"}`)))
+
+			assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		})
+	}
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var calls int32
+		handler := newHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestProxyHandler_RetryDelay(t *testing.T) {
+	handler := NewProxyHandler(&ProxyConfig{RetryBaseDelay: 100 * time.Millisecond})
+
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		delay := handler.retryDelay(attempt)
+		assert.GreaterOrEqual(t, delay, max/2)
+		assert.LessOrEqual(t, delay, max)
+	}
+	assert.LessOrEqual(t, handler.retryDelay(20), maxRetryDelay)
+}
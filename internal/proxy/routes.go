@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RouteRule sends requests whose path starts with Prefix to the backend at
+// TargetURL. ServiceName identifies the backend in logs and circuit breakers.
+type RouteRule struct {
+	Prefix      string
+	TargetURL   string
+	ServiceName string
+}
+
+// DefaultRoutes returns the routes to the application, team, tenant and user services
+// configured on config, logins being served by the user service; they are used when
+// config has no Routes
+func DefaultRoutes(config *ProxyConfig) []RouteRule {
+	return []RouteRule{
+		{Prefix: "/api/v1/teams", TargetURL: config.TeamServiceURL, ServiceName: "team-service"},
+		{Prefix: "/api/v1/applications", TargetURL: config.ApplicationServiceURL, ServiceName: "application-service"},
+		{Prefix: "/api/v1/templates", TargetURL: config.ApplicationServiceURL, ServiceName: "application-service"},
+		{Prefix: "/api/v1/tenants", TargetURL: config.TenantServiceURL, ServiceName: "tenant-service"},
+		{Prefix: "/api/v1/users", TargetURL: config.UserServiceURL, ServiceName: "user-service"},
+		{Prefix: "/api/v1/auth", TargetURL: config.UserServiceURL, ServiceName: "user-service"},
+	}
+}
+
+// ParseRoutes parses routes from a comma separated list of prefix|url|service
+// entries, for example "/api/v1/users|http://user-service:8084|user-service"
+func ParseRoutes(value string) ([]RouteRule, error) {
+	var routes []RouteRule
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, "|")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid route %q: expected prefix|url|service", entry)
+		}
+		if !strings.HasPrefix(fields[0], "/") {
+			return nil, fmt.Errorf("invalid route %q: prefix must start with /", entry)
+		}
+		if _, err := url.Parse(fields[1]); err != nil || fields[1] == "" {
+			return nil, fmt.Errorf("invalid route %q: invalid URL", entry)
+		}
+		if fields[2] == "" {
+			return nil, fmt.Errorf("invalid route %q: service name is required", entry)
+		}
+
+		routes = append(routes, RouteRule{Prefix: fields[0], TargetURL: fields[1], ServiceName: fields[2]})
+	}
+
+	return routes, nil
+}
+
+// routeTable matches request paths to routes, longest prefix first
+type routeTable []RouteRule
+
+// newRouteTable orders routes so that more specific prefixes are tried first
+func newRouteTable(routes []RouteRule) routeTable {
+	table := make(routeTable, len(routes))
+	copy(table, routes)
+	sort.SliceStable(table, func(i, j int) bool {
+		return len(table[i].Prefix) > len(table[j].Prefix)
+	})
+	return table
+}
+
+// match returns the route for path, if any
+func (t routeTable) match(path string) (RouteRule, bool) {
+	for _, route := range t {
+		if strings.HasPrefix(path, route.Prefix) {
+			return route, true
+		}
+	}
+	return RouteRule{}, false
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedBackend starts a backend that reports its name in a response header
+func namedBackend(t *testing.T, name string) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", name)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestProxyHandler_Routes(t *testing.T) {
+	handler := NewProxyHandler(&ProxyConfig{
+		Routes: []RouteRule{
+			{Prefix: "/api/v1/teams", TargetURL: namedBackend(t, "teams"), ServiceName: "team-service"},
+			{Prefix: "/api/v1/teams/stats", TargetURL: namedBackend(t, "stats"), ServiceName: "stats-service"},
+			{Prefix: "/api/v1/users", TargetURL: namedBackend(t, "users"), ServiceName: "user-service"},
+		},
+		Logger: logger.New("debug", "text"),
+	})
+
+	tests := []struct {
+		path    string
+		backend string
+	}{
+		{"/api/v1/teams", "teams"},
+		{"/api/v1/teams/3f2a", "teams"},
+		{"/api/v1/teams/stats", "stats"},
+		{"/api/v1/teams/stats/monthly", "stats"},
+		{"/api/v1/users/me", "users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.backend, rr.Header().Get("X-Backend"))
+		})
+	}
+
+	t.Run("unknown path is a JSON 404", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var apiErr types.APIError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiErr))
+		assert.Equal(t, "NOT_FOUND", apiErr.Code)
+	})
+}
+
+func TestProxyHandler_DefaultRoutes(t *testing.T) {
+	handler := NewProxyHandler(&ProxyConfig{
+		ApplicationServiceURL: namedBackend(t, "applications"),
+		TeamServiceURL:        namedBackend(t, "teams"),
+		TenantServiceURL:      namedBackend(t, "tenants"),
+		UserServiceURL:        namedBackend(t, "users"),
+		Logger:                logger.New("debug", "text"),
+	})
+
+	for path, backend := range map[string]string{
+		"/api/v1/applications/1":              "applications",
+		"/api/v1/templates/go-service/render": "applications",
+		"/api/v1/teams":                       "teams",
+		"/api/v1/tenants/acme":                "tenants",
+		"/api/v1/users/me":                    "users",
+		"/api/v1/auth/login":                  "users",
+	} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, backend, rr.Header().Get("X-Backend"), path)
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes("/api/v1/users|http://user-service:8084|user-service, /api/v1/templates|http://template-service:8086|template-service")
+	require.NoError(t, err)
+	assert.Equal(t, []RouteRule{
+		{Prefix: "/api/v1/users", TargetURL: "http://user-service:8084", ServiceName: "user-service"},
+		{Prefix: "/api/v1/templates", TargetURL: "http://template-service:8086", ServiceName: "template-service"},
+	}, routes)
+
+	routes, err = ParseRoutes("")
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	for _, invalid := range []string{
+		"/api/v1/users|http://user-service:8084",
+		"api/v1/users|http://user-service:8084|user-service",
+		"/api/v1/users||user-service",
+		"/api/v1/users|http://user-service:8084|",
+	} {
+		_, err := ParseRoutes(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,266 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+)
+
+// Shadow traffic defaults
+const (
+	// DefaultShadowTimeout bounds how long a mirrored request may take
+	DefaultShadowTimeout = 10 * time.Second
+	// maxShadowBodyBytes is the largest request body buffered for mirroring; larger
+	// requests are proxied normally without a shadow copy
+	maxShadowBodyBytes = 1 << 20
+	// maxInFlightShadows bounds concurrent mirrored requests; excess samples are skipped
+	maxInFlightShadows = 100
+)
+
+// ShadowTarget mirrors a percentage of the traffic for a route to another backend.
+// Mirrored responses are discarded; only their outcome is logged and counted.
+type ShadowTarget struct {
+	// PathPrefix selects the requests to mirror, e.g. /api/v1/applications
+	PathPrefix string
+	// URL is the shadow backend base URL
+	URL string
+	// Percent is the share of matching requests to mirror, from 0 to 100
+	Percent int
+	// MirrorWrites opts in to mirroring requests other than GET, HEAD and OPTIONS.
+	// Only enable this when the shadow backend does not share state with the primary.
+	MirrorWrites bool
+	// ForwardCredentials opts in to sending the client's credential headers, such as
+	// Authorization and Cookie, to the shadow backend. Only enable this when the
+	// shadow backend is trusted as much as the primary.
+	ForwardCredentials bool
+}
+
+// credentialHeaders are stripped from mirrored requests unless the target opts in
+var credentialHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// ShadowStats counts the outcome of mirrored requests
+type ShadowStats struct {
+	Mirrored   uint64 `json:"mirrored"`
+	Failed     uint64 `json:"failed"`
+	Mismatched uint64 `json:"mismatched"`
+	Skipped    uint64 `json:"skipped"`
+}
+
+// shadowCounters holds the live counters behind ShadowStats
+type shadowCounters struct {
+	mirrored   atomic.Uint64
+	failed     atomic.Uint64
+	mismatched atomic.Uint64
+	skipped    atomic.Uint64
+}
+
+// ParseShadowTargets parses shadow targets from a comma separated list of
+// prefix|url|percent[|option...] entries, for example
+// "/api/v1/applications|http://application-canary:8082|10". The options are writes,
+// setting MirrorWrites, and credentials, setting ForwardCredentials.
+func ParseShadowTargets(value string) ([]ShadowTarget, error) {
+	var targets []ShadowTarget
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, "|")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid shadow target %q: expected prefix|url|percent[|option...]", entry)
+		}
+
+		percent, err := strconv.Atoi(fields[2])
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid shadow target %q: percent must be between 0 and 100", entry)
+		}
+
+		if _, err := url.Parse(fields[1]); err != nil || fields[1] == "" {
+			return nil, fmt.Errorf("invalid shadow target %q: invalid URL", entry)
+		}
+
+		target := ShadowTarget{
+			PathPrefix: fields[0],
+			URL:        fields[1],
+			Percent:    percent,
+		}
+		for _, option := range fields[3:] {
+			switch option {
+			case "writes":
+				target.MirrorWrites = true
+			case "credentials":
+				target.ForwardCredentials = true
+			default:
+				return nil, fmt.Errorf("invalid shadow target %q: unknown option %q", entry, option)
+			}
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// ShadowStats returns a snapshot of the shadow traffic counters
+func (p *ProxyHandler) ShadowStats() ShadowStats {
+	return ShadowStats{
+		Mirrored:   p.shadows.mirrored.Load(),
+		Failed:     p.shadows.failed.Load(),
+		Mismatched: p.shadows.mismatched.Load(),
+		Skipped:    p.shadows.skipped.Load(),
+	}
+}
+
+// selectShadow returns the shadow target a request should be mirrored to, if any
+func (p *ProxyHandler) selectShadow(r *http.Request) *ShadowTarget {
+	for i := range p.config.ShadowTargets {
+		target := &p.config.ShadowTargets[i]
+		if !strings.HasPrefix(r.URL.Path, target.PathPrefix) {
+			continue
+		}
+		if !target.MirrorWrites && !isSafeMethod(r.Method) {
+			return nil
+		}
+		if target.Percent <= 0 || p.sample(100) >= target.Percent {
+			return nil
+		}
+		return target
+	}
+	return nil
+}
+
+// bufferShadowBody reads the request body so it can be sent to both backends. It
+// returns false, with r.Body still intact, when the body is too large to mirror.
+func bufferShadowBody(r *http.Request) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxShadowBodyBytes+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(body) > maxShadowBodyBytes {
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		return nil, false, nil
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true, nil
+}
+
+// mirror sends a copy of a proxied request to the shadow target in the background and
+// compares its status with the primary response
+func (p *ProxyHandler) mirror(ctx context.Context, target *ShadowTarget, primary *http.Request, body []byte, primaryStatus int) {
+	select {
+	case p.shadowSlots <- struct{}{}:
+	default:
+		p.shadows.skipped.Add(1)
+		return
+	}
+
+	base, err := url.Parse(target.URL)
+	if err != nil {
+		<-p.shadowSlots
+		p.shadows.failed.Add(1)
+		return
+	}
+
+	shadowURL := &url.URL{
+		Scheme:   base.Scheme,
+		Host:     base.Host,
+		Path:     primary.URL.Path,
+		RawQuery: primary.URL.RawQuery,
+	}
+	header := primary.Header.Clone()
+	if !target.ForwardCredentials {
+		for _, name := range credentialHeaders {
+			header.Del(name)
+		}
+	}
+	header.Set("X-Shadow-Request", "true")
+	method := primary.Method
+
+	// The shadow request must outlive the client request
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer func() { <-p.shadowSlots }()
+
+		ctx, cancel := context.WithTimeout(ctx, p.shadowTimeout())
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, method, shadowURL.String(), bytes.NewReader(body))
+		if err != nil {
+			p.shadows.failed.Add(1)
+			return
+		}
+		req.Header = header
+		req.ContentLength = int64(len(body))
+
+		start := time.Now()
+		resp, err := p.shadowClient.Do(req)
+		if err != nil {
+			p.shadows.failed.Add(1)
+			p.config.Logger.WithFields(logger.LogFields{
+				logger.FieldError:      err.Error(),
+				logger.FieldHTTPMethod: method,
+				logger.FieldHTTPPath:   shadowURL.Path,
+				"shadow_url":           target.URL,
+			}).Warn("Shadow request failed")
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		p.shadows.mirrored.Add(1)
+		if resp.StatusCode != primaryStatus {
+			p.shadows.mismatched.Add(1)
+		}
+
+		p.config.Logger.WithFields(logger.LogFields{
+			logger.FieldHTTPMethod: method,
+			logger.FieldHTTPPath:   shadowURL.Path,
+			logger.FieldDuration:   time.Since(start).String(),
+			"primary_status":       primaryStatus,
+			"shadow_status":        resp.StatusCode,
+			"shadow_url":           target.URL,
+		}).Debug("Shadow request completed")
+	}()
+}
+
+// shadowTimeout returns the configured shadow request timeout
+func (p *ProxyHandler) shadowTimeout() time.Duration {
+	if p.config.ShadowTimeout > 0 {
+		return p.config.ShadowTimeout
+	}
+	return DefaultShadowTimeout
+}
+
+// isSafeMethod reports whether a method is read-only and therefore safe to mirror
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultSample returns a random number in [0, n)
+func defaultSample(n int) int {
+	return rand.IntN(n)
+}
@@ -0,0 +1,143 @@
+// Package ratelimit provides token bucket rate limiters for protecting expensive
+// operations and API endpoints.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter. Tokens are added at a fixed rate up to
+// the burst size and each allowed event consumes one token.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// New creates a limiter that allows ratePerSecond events per second with bursts of
+// up to burst events. The bucket starts full.
+func New(ratePerSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &Limiter{
+		rate:  ratePerSecond,
+		burst: float64(burst),
+		now:   time.Now,
+	}
+	l.tokens = l.burst
+	l.last = l.now()
+
+	return l
+}
+
+// PerMinute returns a rate in events per second for n events per minute
+func PerMinute(n int) float64 {
+	return float64(n) / 60
+}
+
+// Allow reports whether an event may happen now, consuming a token if so. When the
+// event is not allowed it returns how long to wait until a token is available.
+func (l *Limiter) Allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+
+	if l.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+
+	wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// full reports whether the bucket has refilled completely
+func (l *Limiter) full() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	return l.tokens >= l.burst
+}
+
+// refill adds the tokens accrued since the last call; callers must hold l.mu
+func (l *Limiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+
+	if elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+	}
+}
+
+// sweepThreshold is the number of tracked keys above which idle limiters are evicted
+const sweepThreshold = 1024
+
+// KeyedLimiter maintains an independent Limiter per key, such as a caller or tenant
+type KeyedLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[string]*Limiter
+	now      func() time.Time
+}
+
+// NewKeyed creates a keyed limiter where each key gets its own bucket with the given
+// rate and burst
+func NewKeyed(ratePerSecond float64, burst int) *KeyedLimiter {
+	return &KeyedLimiter{
+		rate:     ratePerSecond,
+		burst:    burst,
+		limiters: make(map[string]*Limiter),
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether an event for key may happen now; see Limiter.Allow
+func (k *KeyedLimiter) Allow(key string) (bool, time.Duration) {
+	return k.limiter(key).Allow()
+}
+
+// limiter returns the limiter for key, creating it if needed
+func (k *KeyedLimiter) limiter(key string) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if l, ok := k.limiters[key]; ok {
+		return l
+	}
+
+	if len(k.limiters) >= sweepThreshold {
+		k.sweep()
+	}
+
+	l := New(k.rate, k.burst)
+	l.now = k.now
+	l.last = k.now()
+	k.limiters[key] = l
+
+	return l
+}
+
+// sweep evicts limiters whose buckets are full, since they carry no state that a new
+// limiter would not; callers must hold k.mu
+func (k *KeyedLimiter) sweep() {
+	for key, l := range k.limiters {
+		if l.full() {
+			delete(k.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced clock for deterministic tests
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestLimiter_Allow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(1, 2)
+	l.now = clock.Now
+	l.last = clock.Now()
+
+	ok, _ := l.Allow()
+	assert.True(t, ok)
+	ok, _ = l.Allow()
+	assert.True(t, ok)
+
+	ok, retryAfter := l.Allow()
+	assert.False(t, ok, "burst exhausted")
+	assert.Equal(t, time.Second, retryAfter)
+
+	clock.Advance(500 * time.Millisecond)
+	ok, retryAfter = l.Allow()
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	clock.Advance(500 * time.Millisecond)
+	ok, _ = l.Allow()
+	assert.True(t, ok, "token refilled")
+
+	clock.Advance(time.Hour)
+	assert.True(t, l.full(), "refill is capped at burst")
+}
+
+func TestKeyedLimiter_IndependentKeys(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	k := NewKeyed(PerMinute(1), 1)
+	k.now = clock.Now
+
+	ok, _ := k.Allow("alice")
+	assert.True(t, ok)
+	ok, _ = k.Allow("alice")
+	assert.False(t, ok)
+
+	ok, _ = k.Allow("bob")
+	assert.True(t, ok, "other keys are unaffected")
+
+	clock.Advance(time.Minute)
+	ok, _ = k.Allow("alice")
+	assert.True(t, ok)
+}
+
+func TestKeyedLimiter_SweepsIdleKeys(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	k := NewKeyed(1, 1)
+	k.now = clock.Now
+
+	for i := 0; i < sweepThreshold; i++ {
+		k.Allow(string(rune('a'+i%26)) + time.Duration(i).String())
+	}
+	assert.Len(t, k.limiters, sweepThreshold)
+
+	clock.Advance(time.Second)
+	k.Allow("new-key")
+	assert.Len(t, k.limiters, 1)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidActor is returned when a created_by/updated_by value is not a valid user
+var ErrInvalidActor = errors.New("invalid user identifier")
+
+// actorIDPattern matches user and service account IDs such as "system" or "ci-bot"
+var actorIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+
+// ValidateActor checks that a value identifies a user: an email address, a UUID or a
+// plain user ID. Values recorded in created_by/updated_by take one of these forms.
+func ValidateActor(value string) error {
+	if strings.Contains(value, "@") {
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return fmt.Errorf("%w: %q is not a valid email address", ErrInvalidActor, value)
+		}
+		return nil
+	}
+
+	if _, err := uuid.Parse(value); err == nil {
+		return nil
+	}
+
+	if !actorIDPattern.MatchString(value) {
+		return fmt.Errorf("%w: %q is not a valid user ID", ErrInvalidActor, value)
+	}
+	return nil
+}
+
+// MaxSearchLength is the longest search term list endpoints accept
+const MaxSearchLength = 100
+
+// Validate checks the actor filters and the search term, returning an error naming the
+// offending parameter
+func (f *FilterParams) Validate() error {
+	if len(f.Search) > MaxSearchLength {
+		return fmt.Errorf("search: must be at most %d characters", MaxSearchLength)
+	}
+	if f.CreatedBy != "" {
+		if err := ValidateActor(f.CreatedBy); err != nil {
+			return fmt.Errorf("created_by: %w", err)
+		}
+	}
+	if f.UpdatedBy != "" {
+		if err := ValidateActor(f.UpdatedBy); err != nil {
+			return fmt.Errorf("updated_by: %w", err)
+		}
+	}
+	return nil
+}
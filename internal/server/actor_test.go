@@ -0,0 +1,44 @@
+package server_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateActor(t *testing.T) {
+	for _, valid := range []string{"owner@company.com", "system", "test-user", "ci.bot_1", uuid.NewString()} {
+		assert.NoError(t, server.ValidateActor(valid), valid)
+	}
+
+	for _, invalid := range []string{"", "not an email@", "Owner <owner@company.com>", "user' OR '1'='1", "-leading-dash"} {
+		assert.ErrorIs(t, server.ValidateActor(invalid), server.ErrInvalidActor, invalid)
+	}
+}
+
+func TestFilterParams_Validate(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/teams?created_by=owner@company.com&updated_by=system", nil)
+	filters := server.ParseFilterParams(req)
+	assert.Equal(t, "owner@company.com", filters.CreatedBy)
+	assert.Equal(t, "system", filters.UpdatedBy)
+	assert.NoError(t, filters.Validate())
+
+	req = httptest.NewRequest("GET", "/api/v1/teams?updated_by=bad%20value", nil)
+	err := server.ParseFilterParams(req).Validate()
+	assert.ErrorIs(t, err, server.ErrInvalidActor)
+	assert.Contains(t, err.Error(), "updated_by")
+
+	req = httptest.NewRequest("GET", "/api/v1/teams?search=%20orders%20", nil)
+	filters = server.ParseFilterParams(req)
+	assert.Equal(t, "orders", filters.Search)
+	assert.NoError(t, filters.Validate())
+
+	req = httptest.NewRequest("GET", "/api/v1/teams?search="+strings.Repeat("x", server.MaxSearchLength+1), nil)
+	err = server.ParseFilterParams(req).Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "search")
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CodeUnknownField is the error code for request bodies with a field the API does
+// not accept
+const CodeUnknownField = "UNKNOWN_FIELD"
+
+// UnknownFieldError describes a request body field the target type does not have,
+// usually a misspelling that would otherwise be silently ignored
+type UnknownFieldError struct {
+	Field string
+}
+
+// Error implements the error interface
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field '%s'", e.Field)
+}
+
+// DecodeJSON decodes a JSON body into v, rejecting fields v does not have with an
+// *UnknownFieldError. Other errors are returned as the decoder reports them, so
+// callers can still tell a body over the size limit from malformed JSON.
+func DecodeJSON(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		// The decoder has no typed error for unknown fields, only this message
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return &UnknownFieldError{Field: strings.Trim(field, `"`)}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type request struct {
+		Name       string `json:"name"`
+		OwnerEmail string `json:"owner_email"`
+	}
+
+	t.Run("known fields are decoded", func(t *testing.T) {
+		var req request
+		require.NoError(t, DecodeJSON(strings.NewReader(`{"name": "orders-api", "owner_email": "owner@company.com"}`), &req))
+		assert.Equal(t, request{Name: "orders-api", OwnerEmail: "owner@company.com"}, req)
+	})
+
+	t.Run("unknown fields are named", func(t *testing.T) {
+		var req request
+		err := DecodeJSON(strings.NewReader(`{"name": "orders-api", "owner_emial": "owner@company.com"}`), &req)
+
+		var unknownField *UnknownFieldError
+		require.True(t, errors.As(err, &unknownField))
+		assert.Equal(t, "owner_emial", unknownField.Field)
+		assert.Equal(t, "unknown field 'owner_emial'", err.Error())
+	})
+
+	t.Run("malformed JSON is not an unknown field", func(t *testing.T) {
+		var req request
+		err := DecodeJSON(strings.NewReader(`{"name": `), &req)
+
+		require.Error(t, err)
+		var unknownField *UnknownFieldError
+		assert.False(t, errors.As(err, &unknownField))
+	})
+}
+
+func TestParseJSONBody_UnknownField(t *testing.T) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	r := httptest.NewRequest(http.MethodPost, "/teams", strings.NewReader(`{"nme": "platform"}`))
+
+	err := ParseJSONBody(r, &req)
+
+	var unknownField *UnknownFieldError
+	require.True(t, errors.As(err, &unknownField))
+	assert.Equal(t, "nme", unknownField.Field)
+}
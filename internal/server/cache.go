@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Cache-Control directives for responses that must not be stored
+const NoStore = "no-store"
+
+// cacheVary lists the request headers that storable responses depend on: the caller's
+// credentials and the tenant they act in, so caches never mix users or tenants
+const cacheVary = "Authorization, X-Tenant-ID"
+
+// CachePolicy sets the Cache-Control header on responses. Reads (GET and HEAD) get
+// the default read directive and everything else is no-store, unless a route has
+// been given its own directive with Route.
+type CachePolicy struct {
+	read       string
+	routes     *http.ServeMux
+	directives map[string]string
+}
+
+// NewCachePolicy creates a policy that lets private caches keep reads for maxAge. A
+// zero maxAge still marks reads private but requires revalidation on every use.
+func NewCachePolicy(maxAge time.Duration) *CachePolicy {
+	read := "private, no-cache"
+	if seconds := int(maxAge.Seconds()); seconds > 0 {
+		read = fmt.Sprintf("private, max-age=%d", seconds)
+	}
+
+	return &CachePolicy{
+		read:       read,
+		routes:     http.NewServeMux(),
+		directives: make(map[string]string),
+	}
+}
+
+// Route overrides the directive for requests matching pattern, which uses the same
+// syntax as http.ServeMux, e.g. "GET /api/v1/applications/{id}/env". Like
+// ServeMux, it panics if the pattern is invalid or already registered.
+func (p *CachePolicy) Route(pattern, directive string) *CachePolicy {
+	p.routes.Handle(pattern, http.NotFoundHandler())
+	p.directives[pattern] = directive
+	return p
+}
+
+// Directive returns the Cache-Control directive for a request
+func (p *CachePolicy) Directive(r *http.Request) string {
+	if _, pattern := p.routes.Handler(r); pattern != "" {
+		if directive, ok := p.directives[pattern]; ok {
+			return directive
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return p.read
+	default:
+		return NoStore
+	}
+}
+
+// Middleware sets the Cache-Control header before calling next, with a Vary header on
+// responses that may be stored; handlers may still replace them for individual responses
+func (p *CachePolicy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		directive := p.Directive(r)
+		w.Header().Set("Cache-Control", directive)
+		if directive != NoStore {
+			w.Header().Add("Vary", cacheVary)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
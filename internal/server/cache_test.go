@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachePolicy(t *testing.T) {
+	policy := NewCachePolicy(time.Minute).
+		Route("GET /api/v1/applications/{id}/env", NoStore)
+
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, path string) string {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr.Header().Get("Cache-Control")
+	}
+
+	t.Run("reads use the configured max age", func(t *testing.T) {
+		assert.Equal(t, "private, max-age=60", serve(http.MethodGet, "/api/v1/applications"))
+		assert.Equal(t, "private, max-age=60", serve(http.MethodHead, "/api/v1/applications/123"))
+	})
+
+	t.Run("mutations are not stored", func(t *testing.T) {
+		for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			assert.Equal(t, NoStore, serve(method, "/api/v1/applications"), method)
+		}
+	})
+
+	t.Run("stored responses vary by caller and tenant", func(t *testing.T) {
+		vary := func(method, path string) string {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+			return rr.Header().Get("Vary")
+		}
+		assert.Equal(t, "Authorization, X-Tenant-ID", vary(http.MethodGet, "/api/v1/applications"))
+		assert.Empty(t, vary(http.MethodPost, "/api/v1/applications"))
+		assert.Empty(t, vary(http.MethodGet, "/api/v1/applications/123/env"))
+	})
+
+	t.Run("route overrides win", func(t *testing.T) {
+		assert.Equal(t, NoStore, serve(http.MethodGet, "/api/v1/applications/123/env"))
+	})
+
+	t.Run("zero max age requires revalidation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		assert.Equal(t, "private, no-cache", NewCachePolicy(0).Directive(req))
+	})
+
+	t.Run("handlers can replace the header", func(t *testing.T) {
+		custom := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		}))
+
+		rr := httptest.NewRecorder()
+		custom.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/version", nil))
+		assert.Equal(t, "public, max-age=3600", rr.Header().Get("Cache-Control"))
+	})
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Responder writes handler responses either as bare JSON or wrapped in the
+// types.APIResponse envelope. A service uses a single Responder so that every
+// response it returns has the same shape.
+type Responder struct {
+	envelope bool
+}
+
+// NewResponder creates a Responder; envelope selects the types.APIResponse form
+func NewResponder(envelope bool) *Responder {
+	return &Responder{envelope: envelope}
+}
+
+// Enveloped reports whether responses are wrapped in types.APIResponse
+func (rs *Responder) Enveloped() bool {
+	return rs.envelope
+}
+
+// JSON writes data with the given status code
+func (rs *Responder) JSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	if !rs.envelope {
+		return writeJSON(w, status, data)
+	}
+	return writeJSON(w, status, types.APIResponse{
+		Success: true,
+		Data:    data,
+		Meta:    responseMeta(r, nil),
+	})
+}
+
+// List writes a paginated list. In bare mode the handler's own list body is written;
+// in envelope mode the items become the data and the pagination moves to meta.
+func (rs *Responder) List(w http.ResponseWriter, r *http.Request, status int, bare, items interface{}, page *types.Pagination) error {
+	if !rs.envelope {
+		return writeJSON(w, status, bare)
+	}
+	return writeJSON(w, status, types.APIResponse{
+		Success: true,
+		Data:    items,
+		Meta:    responseMeta(r, page),
+	})
+}
+
+// ConditionalList writes a paginated list as List does, tagged with a WeakETag of the
+// bare body. A request whose If-None-Match header matches the tag gets a 304 with no
+// body instead, so clients polling a list only download it when it has changed. The
+// bare body is hashed in both modes, since the envelope's metadata differs on every
+// response.
+func (rs *Responder) ConditionalList(w http.ResponseWriter, r *http.Request, status int, bare, items interface{}, page *types.Pagination) error {
+	etag, err := WeakETag(bare)
+	if err != nil {
+		return err
+	}
+	if NotModified(w, r, etag) {
+		return nil
+	}
+	return rs.List(w, r, status, bare, items, page)
+}
+
+// Error writes an error response. In bare mode the handler's own error body is
+// written; in envelope mode apiErr is reported in the envelope's error field.
+func (rs *Responder) Error(w http.ResponseWriter, r *http.Request, status int, apiErr *types.APIError, bare interface{}) error {
+	if !rs.envelope {
+		return writeJSON(w, status, bare)
+	}
+	return writeJSON(w, status, types.APIResponse{
+		Success: false,
+		Error:   apiErr,
+		Meta:    responseMeta(r, nil),
+	})
+}
+
+// ValidationErrors writes a 400 listing the fields that failed validation. In bare
+// mode the body is the one RespondWithValidationErrors writes; in envelope mode the
+// field messages are reported in the error details.
+func (rs *Responder) ValidationErrors(w http.ResponseWriter, r *http.Request, errs ValidationErrors) error {
+	details := make([]string, 0, len(errs))
+	for _, e := range errs {
+		details = append(details, e.Field+": "+e.Message)
+	}
+	return rs.Error(w, r, http.StatusBadRequest, &types.APIError{
+		Code:    CodeValidationFailed,
+		Message: "Validation failed",
+		Details: strings.Join(details, "; "),
+	}, validationErrorResponse(errs))
+}
+
+// PolicyViolations writes a 403 listing the policy violations that rejected the
+// request. In bare mode they are reported in the body's details; in envelope mode the
+// violation messages are reported in the error details.
+func (rs *Responder) PolicyViolations(w http.ResponseWriter, r *http.Request, violations []policy.Violation) error {
+	details := make([]string, 0, len(violations))
+	for _, v := range violations {
+		details = append(details, v.Policy+": "+v.Message)
+	}
+	return rs.Error(w, r, http.StatusForbidden, &types.APIError{
+		Code:    CodePolicyViolation,
+		Message: "Request denied by policy",
+		Details: strings.Join(details, "; "),
+	}, &ErrorResponse{
+		Error:   http.StatusText(http.StatusForbidden),
+		Message: "Request denied by policy",
+		Code:    CodePolicyViolation,
+		Details: map[string]interface{}{
+			"policy_violations": violations,
+		},
+		Time: time.Now().UTC(),
+	})
+}
+
+// PathError writes the standard 400 response for an error returned by ParsePathUUID
+func (rs *Responder) PathError(w http.ResponseWriter, r *http.Request, err error) {
+	if !rs.envelope {
+		RespondWithPathError(w, err)
+		return
+	}
+	_ = rs.Error(w, r, http.StatusBadRequest, &types.APIError{
+		Code:    pathErrorCode(err),
+		Message: err.Error(),
+	}, nil)
+}
+
+// NewPagination converts limit/offset paging into envelope pagination metadata
+func NewPagination(limit, offset, total int) *types.Pagination {
+	page := &types.Pagination{
+		Page:    1,
+		PerPage: limit,
+		Total:   int64(total),
+	}
+	if limit > 0 {
+		page.Page = offset/limit + 1
+		page.TotalPages = (total + limit - 1) / limit
+	}
+	return page
+}
+
+// CodeForStatus derives an error code such as NOT_FOUND from an HTTP status
+func CodeForStatus(status int) string {
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// responseMeta builds envelope metadata, taking the request ID from the context set
+// by the RequestID middleware
+func responseMeta(r *http.Request, page *types.Pagination) *types.Meta {
+	meta := &types.Meta{
+		Timestamp:  time.Now().UTC(),
+		Pagination: page,
+	}
+	if r != nil {
+		meta.RequestID = types.RequestIDFromContext(r.Context())
+	}
+	return meta
+}
+
+// pathErrorCode returns the error code for a ParsePathUUID error
+func pathErrorCode(err error) string {
+	if errors.Is(err, ErrMissingPathParam) {
+		return CodeMissingID
+	}
+	return CodeInvalidID
+}
+
+// writeJSON writes a JSON body with the given status code
+func writeJSON(w http.ResponseWriter, status int, data interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(data)
+}
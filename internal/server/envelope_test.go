@@ -0,0 +1,116 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequestWithID(requestID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+	return req.WithContext(context.WithValue(req.Context(), types.RequestIDKey, requestID))
+}
+
+func TestResponder_Bare(t *testing.T) {
+	responder := server.NewResponder(false)
+
+	rr := httptest.NewRecorder()
+	require.NoError(t, responder.JSON(rr, newRequestWithID("req-1"), http.StatusOK, map[string]string{"name": "team"}))
+
+	assert.JSONEq(t, `{"name":"team"}`, rr.Body.String())
+}
+
+func TestResponder_Envelope(t *testing.T) {
+	responder := server.NewResponder(true)
+
+	t.Run("wraps data with the request ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		require.NoError(t, responder.JSON(rr, newRequestWithID("req-1"), http.StatusCreated, map[string]string{"name": "team"}))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var response map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.JSONEq(t, `true`, string(response["success"]))
+		assert.JSONEq(t, `{"name":"team"}`, string(response["data"]))
+		assert.NotContains(t, response, "error")
+
+		var meta types.Meta
+		require.NoError(t, json.Unmarshal(response["meta"], &meta))
+		assert.Equal(t, "req-1", meta.RequestID)
+		assert.False(t, meta.Timestamp.IsZero())
+		assert.Nil(t, meta.Pagination)
+	})
+
+	t.Run("moves pagination into meta", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		bare := map[string]interface{}{"teams": []string{"a", "b"}}
+		require.NoError(t, responder.List(rr, newRequestWithID("req-2"), http.StatusOK, bare, []string{"a", "b"}, server.NewPagination(2, 2, 5)))
+
+		var response types.APIResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, []interface{}{"a", "b"}, response.Data)
+		require.NotNil(t, response.Meta.Pagination)
+		assert.Equal(t, types.Pagination{Page: 2, PerPage: 2, Total: 5, TotalPages: 3}, *response.Meta.Pagination)
+	})
+
+	t.Run("reports errors in the envelope", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		responder.PathError(rr, newRequestWithID("req-3"), invalidPathError(t))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var response types.APIResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.False(t, response.Success)
+		assert.Nil(t, response.Data)
+		require.NotNil(t, response.Error)
+		assert.Equal(t, server.CodeInvalidID, response.Error.Code)
+		assert.Equal(t, "req-3", response.Meta.RequestID)
+	})
+}
+
+// invalidPathError returns the ParsePathUUID error for a malformed ID
+func invalidPathError(t *testing.T) error {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/not-a-uuid", nil)
+	req.SetPathValue("id", "not-a-uuid")
+	_, err := server.ParsePathUUID(req, "id")
+	require.Error(t, err)
+	return err
+}
+
+func TestResponder_ConditionalList(t *testing.T) {
+	bare := map[string]interface{}{"items": []string{"a", "b"}, "total": 2}
+
+	for _, envelope := range []bool{false, true} {
+		responder := server.NewResponder(envelope)
+
+		first := httptest.NewRecorder()
+		require.NoError(t, responder.ConditionalList(first, newRequestWithID("req-1"), http.StatusOK, bare, []string{"a", "b"}, server.NewPagination(2, 0, 2)))
+		assert.Equal(t, http.StatusOK, first.Code)
+		etag := first.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		// The envelope's request ID and timestamp differ, but the tag still matches
+		req := newRequestWithID("req-2")
+		req.Header.Set("If-None-Match", etag)
+		again := httptest.NewRecorder()
+		require.NoError(t, responder.ConditionalList(again, req, http.StatusOK, bare, []string{"a", "b"}, server.NewPagination(2, 0, 2)))
+		assert.Equal(t, http.StatusNotModified, again.Code, "envelope=%v", envelope)
+		assert.Empty(t, again.Body.String())
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	assert.Equal(t, types.Pagination{Page: 1, PerPage: 50, Total: 0, TotalPages: 0}, *server.NewPagination(50, 0, 0))
+	assert.Equal(t, types.Pagination{Page: 3, PerPage: 10, Total: 25, TotalPages: 3}, *server.NewPagination(10, 20, 25))
+}
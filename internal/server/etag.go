@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// If-Match errors
+var (
+	ErrMissingIfMatch = errors.New("If-Match header is required; send the ETag from a previous read")
+	ErrInvalidIfMatch = errors.New("If-Match header must be a single ETag from a previous read")
+)
+
+// ETag returns the entity tag for a resource version
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetETag sets the ETag header to the tag for version
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// WeakETag returns a weak entity tag from a hash of data's JSON encoding, for
+// responses such as lists that have no version of their own
+func WeakETag(data interface{}) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return `W/"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// NotModified sets the ETag header to etag and reports whether the request's
+// If-None-Match header matches it, in which case a 304 with no body has been written
+// and the handler should return. Tags are compared weakly, as RFC 9110 requires for
+// If-None-Match.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIfMatch returns the version in a request's If-Match header, for updates that
+// must not overwrite a change the client has not seen. "*" matches any version and
+// is returned as 0. A missing header is ErrMissingIfMatch and anything other than a
+// single tag from ETag is ErrInvalidIfMatch.
+func ParseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, ErrMissingIfMatch
+	}
+	if value == "*" {
+		return 0, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if !ok {
+		return 0, ErrInvalidIfMatch
+	}
+	if unquoted, ok = strings.CutSuffix(unquoted, `"`); !ok {
+		return 0, ErrInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	rr := httptest.NewRecorder()
+	SetETag(rr, 7)
+
+	assert.Equal(t, `"7"`, ETag(7))
+	assert.Equal(t, `"7"`, rr.Header().Get("ETag"))
+}
+
+func TestParseIfMatch(t *testing.T) {
+	request := func(ifMatch string) *http.Request {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/1", nil)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return req
+	}
+
+	t.Run("a tag from ETag names its version", func(t *testing.T) {
+		version, err := ParseIfMatch(request(ETag(3)))
+		require.NoError(t, err)
+		assert.Equal(t, 3, version)
+	})
+
+	t.Run("a wildcard matches any version", func(t *testing.T) {
+		version, err := ParseIfMatch(request("*"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, version)
+	})
+
+	t.Run("the header is required", func(t *testing.T) {
+		_, err := ParseIfMatch(request(""))
+		assert.ErrorIs(t, err, ErrMissingIfMatch)
+	})
+
+	t.Run("other tags are invalid", func(t *testing.T) {
+		for _, ifMatch := range []string{`3`, `W/"3"`, `"3", "4"`, `"three"`, `"0"`, `"-1"`, `"`} {
+			_, err := ParseIfMatch(request(ifMatch))
+			assert.ErrorIs(t, err, ErrInvalidIfMatch, ifMatch)
+		}
+	})
+}
+
+func TestWeakETag(t *testing.T) {
+	first, err := WeakETag(map[string]int{"total": 2})
+	require.NoError(t, err)
+	again, err := WeakETag(map[string]int{"total": 2})
+	require.NoError(t, err)
+	changed, err := WeakETag(map[string]int{"total": 3})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^W/"[A-Za-z0-9_-]+"$`, first)
+	assert.Equal(t, first, again)
+	assert.NotEqual(t, first, changed)
+}
+
+func TestNotModified(t *testing.T) {
+	const etag = `W/"abc"`
+	check := func(ifNoneMatch string) (*httptest.ResponseRecorder, bool) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		return rr, NotModified(rr, req, etag)
+	}
+
+	t.Run("matching tags are not modified", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{etag, `"abc"`, `"old", W/"abc"`, "*"} {
+			rr, notModified := check(ifNoneMatch)
+			assert.True(t, notModified, ifNoneMatch)
+			assert.Equal(t, http.StatusNotModified, rr.Code, ifNoneMatch)
+			assert.Equal(t, etag, rr.Header().Get("ETag"), ifNoneMatch)
+		}
+	})
+
+	t.Run("other tags are modified", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{"", `W/"abd"`, `"old"`} {
+			rr, notModified := check(ifNoneMatch)
+			assert.False(t, notModified, ifNoneMatch)
+			assert.Equal(t, etag, rr.Header().Get("ETag"), ifNoneMatch)
+		}
+	})
+}
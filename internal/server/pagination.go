@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ErrInvalidPagination is returned for limit and offset query parameters a list
+// endpoint does not accept
+var ErrInvalidPagination = errors.New("invalid pagination")
+
+// CodeInvalidPagination is the error code for rejected limit and offset parameters
+const CodeInvalidPagination = "INVALID_PAGINATION"
+
+// PaginationError describes a limit or offset query parameter that was rejected,
+// so the response can tell the client which value to fix
+type PaginationError struct {
+	Param string
+	Value string
+	// Accepted describes the values the parameter accepts
+	Accepted string
+}
+
+// Error implements the error interface
+func (e *PaginationError) Error() string {
+	return fmt.Sprintf("invalid %s %q: must be %s", e.Param, e.Value, e.Accepted)
+}
+
+// Is reports whether target is ErrInvalidPagination
+func (e *PaginationError) Is(target error) bool {
+	return target == ErrInvalidPagination
+}
+
+// PageLimits holds the page sizes list endpoints accept
+type PageLimits struct {
+	// DefaultLimit is the page size when the request has no limit
+	DefaultLimit int
+	// MaxLimit is the largest limit accepted; larger limits are rejected rather than
+	// clamped, so clients are not silently sent a shorter page
+	MaxLimit int
+}
+
+// DefaultPageLimits are used by handlers that are not configured with PageLimits
+var DefaultPageLimits = PageLimits{DefaultLimit: 50, MaxLimit: 100}
+
+// PaginationParams represents pagination parameters
+type PaginationParams struct {
+	Limit  int
+	Offset int
+}
+
+// ParsePagination parses the limit and offset query parameters, applying the
+// default limit when there is none. A limit outside 1 to limits.MaxLimit, a negative
+// offset or a value that is not a number is a *PaginationError, which matches
+// ErrInvalidPagination.
+func ParsePagination(r *http.Request, limits PageLimits) (PaginationParams, error) {
+	query := r.URL.Query()
+	params := PaginationParams{Limit: limits.DefaultLimit}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > limits.MaxLimit {
+			return PaginationParams{}, &PaginationError{Param: "limit", Value: limitStr, Accepted: fmt.Sprintf("an integer between 1 and %d", limits.MaxLimit)}
+		}
+		params.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return PaginationParams{}, &PaginationError{Param: "offset", Value: offsetStr, Accepted: "a non-negative integer"}
+		}
+		params.Offset = offset
+	}
+
+	return params, nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePagination(t *testing.T) {
+	limits := PageLimits{DefaultLimit: 25, MaxLimit: 200}
+	parse := func(query string) (PaginationParams, error) {
+		return ParsePagination(httptest.NewRequest(http.MethodGet, "/api/v1/teams"+query, nil), limits)
+	}
+
+	t.Run("defaults when unspecified", func(t *testing.T) {
+		params, err := parse("")
+		require.NoError(t, err)
+		assert.Equal(t, PaginationParams{Limit: 25, Offset: 0}, params)
+	})
+
+	t.Run("uses the requested limit and offset", func(t *testing.T) {
+		params, err := parse("?limit=200&offset=40")
+		require.NoError(t, err)
+		assert.Equal(t, PaginationParams{Limit: 200, Offset: 40}, params)
+	})
+
+	t.Run("rejects a limit over the max", func(t *testing.T) {
+		_, err := parse("?limit=201")
+		assert.ErrorIs(t, err, ErrInvalidPagination)
+		assert.EqualError(t, err, `invalid limit "201": must be an integer between 1 and 200`)
+	})
+
+	t.Run("rejects other invalid values", func(t *testing.T) {
+		for query, param := range map[string]string{
+			"?limit=0":      "limit",
+			"?limit=-1":     "limit",
+			"?limit=ten":    "limit",
+			"?offset=-1":    "offset",
+			"?offset=first": "offset",
+			"?limit=1.5":    "limit",
+		} {
+			_, err := parse(query)
+			var paginationErr *PaginationError
+			require.ErrorAs(t, err, &paginationErr, query)
+			assert.Equal(t, param, paginationErr.Param, query)
+			assert.ErrorIs(t, err, ErrInvalidPagination, query)
+		}
+	})
+}
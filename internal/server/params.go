@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/google/uuid"
+)
+
+// Path parameter errors
+var (
+	ErrMissingPathParam = errors.New("missing path parameter")
+	ErrInvalidPathUUID  = errors.New("path parameter is not a valid UUID")
+)
+
+// Path parameter error codes
+const (
+	CodeMissingID = "MISSING_ID"
+	CodeInvalidID = "INVALID_ID"
+)
+
+// PathParamError describes a path parameter that could not be parsed
+type PathParamError struct {
+	Param string
+	Value string
+	Err   error
+}
+
+// Error implements the error interface
+func (e *PathParamError) Error() string {
+	if errors.Is(e.Err, ErrMissingPathParam) {
+		return fmt.Sprintf("path parameter '%s' is required", e.Param)
+	}
+	return fmt.Sprintf("path parameter '%s' must be a valid UUID", e.Param)
+}
+
+// Unwrap returns the underlying sentinel error
+func (e *PathParamError) Unwrap() error {
+	return e.Err
+}
+
+// ParsePathUUID parses the named path parameter as a UUID
+func ParsePathUUID(r *http.Request, param string) (uuid.UUID, error) {
+	value := r.PathValue(param)
+	if value == "" {
+		return uuid.Nil, &PathParamError{Param: param, Err: ErrMissingPathParam}
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, &PathParamError{Param: param, Value: value, Err: ErrInvalidPathUUID}
+	}
+
+	return id, nil
+}
+
+// ParseCursorParam decodes the optional cursor query parameter. A nil cursor means the
+// client is paging by offset.
+func ParseCursorParam(r *http.Request) (*database.Cursor, error) {
+	token := r.URL.Query().Get("cursor")
+	if token == "" {
+		return nil, nil
+	}
+	return database.DecodeCursor(token)
+}
+
+// CodeInvalidSort is the error code for a sort parameter a list does not support
+const CodeInvalidSort = "INVALID_SORT"
+
+// ParseSortParam parses the optional sort query parameter against the columns a list
+// can be sorted by. Without one the list is in database.DefaultSort order.
+func ParseSortParam(r *http.Request, columns []string) (database.Sort, error) {
+	return database.ParseSort(r.URL.Query().Get("sort"), columns)
+}
+
+// RespondWithPathError writes the standard 400 response for an error returned by
+// ParsePathUUID
+func RespondWithPathError(w http.ResponseWriter, err error) {
+	code := pathErrorCode(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Message: err.Error(),
+		Code:    code,
+		Time:    time.Now().UTC(),
+	})
+}
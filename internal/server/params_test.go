@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/applications"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/teams"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathUUID(t *testing.T) {
+	id := uuid.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/"+id.String(), nil)
+	req.SetPathValue("id", id.String())
+	parsed, err := server.ParsePathUUID(req, "id")
+	require.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/teams/", nil)
+	_, err = server.ParsePathUUID(req, "id")
+	assert.ErrorIs(t, err, server.ErrMissingPathParam)
+
+	req.SetPathValue("id", "not-a-uuid")
+	_, err = server.ParsePathUUID(req, "id")
+	assert.ErrorIs(t, err, server.ErrInvalidPathUUID)
+}
+
+func TestInvalidPathUUID_ConsistentAcrossResources(t *testing.T) {
+	testLogger := logger.New("error", "text")
+	teamHandlers := teams.NewHandlers(nil, testLogger)
+	appHandlers := applications.NewHandlers(nil, testLogger)
+
+	routes := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+	}{
+		{"get team", http.MethodGet, teamHandlers.GetTeam},
+		{"update team", http.MethodPut, teamHandlers.UpdateTeam},
+		{"delete team", http.MethodDelete, teamHandlers.DeleteTeam},
+		{"bulk add members", http.MethodPost, teamHandlers.BulkAddMembers},
+		{"update member role", http.MethodPut, teamHandlers.UpdateMemberRole},
+		{"remove member", http.MethodDelete, teamHandlers.RemoveMember},
+		{"get application", http.MethodGet, appHandlers.GetApplication},
+		{"update application", http.MethodPut, appHandlers.UpdateApplication},
+		{"delete application", http.MethodDelete, appHandlers.DeleteApplication},
+		{"get resources", http.MethodGet, appHandlers.GetResources},
+		{"set resources", http.MethodPut, appHandlers.SetResources},
+		{"update resource status", http.MethodPut, appHandlers.UpdateResourceStatus},
+	}
+
+	for _, route := range routes {
+		t.Run(route.name, func(t *testing.T) {
+			req := httptest.NewRequest(route.method, "/api/v1/resource/not-a-uuid", nil)
+			req.SetPathValue("id", "not-a-uuid")
+
+			rr := httptest.NewRecorder()
+			route.handler(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.ElementsMatch(t, []string{"error", "message", "code", "timestamp"}, keys(body))
+			assert.Equal(t, server.CodeInvalidID, body["code"])
+			assert.Equal(t, "path parameter 'id' must be a valid UUID", body["message"])
+		})
+	}
+}
+
+// missingTeams is a team service with no teams
+type missingTeams struct {
+	teams.TeamService
+}
+
+func (missingTeams) GetTeam(ctx context.Context, id uuid.UUID) (teams.Team, error) {
+	return teams.Team{}, teams.ErrTeamNotFound
+}
+
+func TestNotFound_SameShapeAcrossPackages(t *testing.T) {
+	decode := func(rr *httptest.ResponseRecorder) map[string]interface{} {
+		t.Helper()
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		return body
+	}
+
+	id := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/"+id.String(), nil)
+	req.SetPathValue("id", id.String())
+	rr := httptest.NewRecorder()
+	teams.NewHandlers(missingTeams{}, logger.New("error", "text")).GetTeam(rr, req)
+	fromTeams := decode(rr)
+
+	rr = httptest.NewRecorder()
+	server.RespondWithNotFound(rr, "tenant")
+	fromServer := decode(rr)
+
+	assert.ElementsMatch(t, keys(fromTeams), keys(fromServer))
+	for key, value := range fromServer {
+		assert.IsType(t, value, fromTeams[key], key)
+	}
+	assert.ElementsMatch(t, []string{"error", "message", "code", "timestamp"}, keys(fromServer))
+	assert.Equal(t, "NOT_FOUND", fromServer["code"])
+}
+
+func keys(m map[string]interface{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,6 +15,8 @@ import (
 	"github.com/aykay76/ai-idp/internal/config"
 	"github.com/aykay76/ai-idp/internal/database"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/types"
 )
 
 // Server wraps the HTTP server with database and utilities
@@ -21,18 +24,21 @@ type Server struct {
 	config     *config.Config
 	mux        *http.ServeMux
 	database   *database.Pool
+	closeDB    func()
 	server     *http.Server
 	middleware []Middleware
+	inFlight   *middleware.InFlight
 }
 
 // Middleware represents HTTP middleware
-type Middleware func(http.Handler) http.Handler
+type Middleware = middleware.Middleware
 
 // NewServer creates a new server instance
 func NewServer(cfg *config.Config) *Server {
 	return &Server{
-		mux:    http.NewServeMux(),
-		config: cfg,
+		mux:      http.NewServeMux(),
+		config:   cfg,
+		inFlight: middleware.NewInFlight(),
 	}
 }
 
@@ -45,6 +51,7 @@ func (s *Server) SetupDatabase(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to setup database: %w", err)
 	}
+	s.closeDB = s.database.Close
 
 	// Update readiness handler to include database health
 	s.HandleFunc("GET /readiness", s.readinessWithDBHandler)
@@ -74,41 +81,54 @@ func (s *Server) Handle(pattern string, handler http.Handler) {
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	// Apply all middleware to the mux
-	var finalHandler http.Handler = s.mux
-	for i := len(s.middleware) - 1; i >= 0; i-- {
-		finalHandler = s.middleware[i](finalHandler)
+	listener, err := net.Listen("tcp", ":"+s.config.Server.Port)
+	if err != nil {
+		return err
 	}
+	return s.Serve(listener)
+}
+
+// Serve serves HTTP on listener
+func (s *Server) Serve(listener net.Listener) error {
+	// Apply all middleware to the mux, counting requests so Stop can drain them
+	finalHandler := middleware.Chain(s.inFlight.Middleware, middleware.Chain(s.middleware...))(s.mux)
 
 	s.server = &http.Server{
-		Addr:         ":" + s.config.Server.Port,
+		Addr:         listener.Addr().String(),
 		Handler:      finalHandler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	fmt.Printf("🚀 %s starting on port %s (env: %s)\n",
-		s.config.ServiceName, s.config.Server.Port, s.config.Environment)
+	fmt.Printf("🚀 %s starting on %s (env: %s)\n",
+		s.config.ServiceName, listener.Addr(), s.config.Environment)
 
-	return s.server.ListenAndServe()
+	return s.server.Serve(listener)
 }
 
-// Stop gracefully stops the server
+// Stop gracefully stops the server. It stops accepting requests and waits, until ctx
+// ends, for those in flight to finish before closing the database they may be using.
 func (s *Server) Stop(ctx context.Context) error {
-	fmt.Println("🛑 Shutting down server...")
+	fmt.Printf("🛑 Shutting down server, %d requests in flight...\n", s.inFlight.Count())
 
-	// Close database connections
-	if s.database != nil {
-		s.database.Close()
+	var err error
+	if s.server != nil {
+		err = s.server.Shutdown(ctx)
+	}
+	if drainErr := s.inFlight.Wait(ctx); drainErr != nil {
+		fmt.Printf("⚠️  %d requests still in flight at the shutdown timeout\n", s.inFlight.Count())
+		if err == nil {
+			err = drainErr
+		}
 	}
 
-	// Shutdown HTTP server
-	if s.server != nil {
-		return s.server.Shutdown(ctx)
+	// Close database connections
+	if s.closeDB != nil {
+		s.closeDB()
 	}
 
-	return nil
+	return err
 }
 
 // Run starts the server and handles graceful shutdown
@@ -212,7 +232,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		// Extract request ID if present
 		ctx := r.Context()
 		if requestID := r.Header.Get("X-Request-ID"); requestID != "" {
-			ctx = context.WithValue(ctx, logger.FieldRequestID, requestID)
+			ctx = context.WithValue(ctx, types.RequestIDKey, requestID)
 			r = r.WithContext(ctx)
 		}
 
@@ -248,46 +268,27 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// CORSMiddleware handles CORS for development
-func CORSMiddleware(allowedOrigins []string) Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-
-			allowed := false
-			for _, allowedOrigin := range allowedOrigins {
-				if origin == allowedOrigin {
-					allowed = true
-					break
-				}
-			}
-
-			if allowed {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Tenant-ID, X-User-Email")
-				w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
-			}
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusNoContent)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
+// CORSMiddleware applies the CORS policy in cfg; see middleware.CORS
+func CORSMiddleware(cfg config.CORSConfig) Middleware {
+	return middleware.CORS(cfg)
 }
 
 // Response utilities
 
-// ErrorResponse represents a standard error response
+// ErrorResponse is the error body every service writes when responses are not
+// enveloped, so clients see one error schema whichever backend answered. Code is an
+// error code such as NOT_FOUND; enveloped responses report the same code in a
+// types.APIError.
 type ErrorResponse struct {
 	Error   string                 `json:"error"`
 	Message string                 `json:"message"`
-	Code    int                    `json:"code"`
+	Code    string                 `json:"code"`
 	Details map[string]interface{} `json:"details,omitempty"`
-	Time    string                 `json:"time"`
+	Time    time.Time              `json:"timestamp"`
+
+	// Retryable and RetryAfterMs are set as in types.APIError, for transient failures
+	Retryable    *bool `json:"retryable,omitempty"`
+	RetryAfterMs int64 `json:"retry_after_ms,omitempty"`
 }
 
 // SuccessResponse represents a standard success response
@@ -314,8 +315,8 @@ func RespondWithError(w http.ResponseWriter, code int, err error, message string
 	response := &ErrorResponse{
 		Error:   err.Error(),
 		Message: message,
-		Code:    code,
-		Time:    time.Now().UTC().Format(time.RFC3339),
+		Code:    CodeForStatus(code),
+		Time:    time.Now().UTC(),
 	}
 	RespondWithJSON(w, code, response)
 }
@@ -325,8 +326,8 @@ func RespondWithValidationError(w http.ResponseWriter, err error) {
 	response := &ErrorResponse{
 		Error:   err.Error(),
 		Message: "Validation failed",
-		Code:    http.StatusBadRequest,
-		Time:    time.Now().UTC().Format(time.RFC3339),
+		Code:    CodeValidationFailed,
+		Time:    time.Now().UTC(),
 	}
 	RespondWithJSON(w, http.StatusBadRequest, response)
 }
@@ -337,8 +338,8 @@ func RespondWithNotFound(w http.ResponseWriter, resource string) {
 	response := &ErrorResponse{
 		Error:   err.Error(),
 		Message: "Resource not found",
-		Code:    http.StatusNotFound,
-		Time:    time.Now().UTC().Format(time.RFC3339),
+		Code:    CodeForStatus(http.StatusNotFound),
+		Time:    time.Now().UTC(),
 	}
 	RespondWithJSON(w, http.StatusNotFound, response)
 }
@@ -348,8 +349,8 @@ func RespondWithInternalError(w http.ResponseWriter, err error) {
 	response := &ErrorResponse{
 		Error:   err.Error(),
 		Message: "Internal server error",
-		Code:    http.StatusInternalServerError,
-		Time:    time.Now().UTC().Format(time.RFC3339),
+		Code:    CodeForStatus(http.StatusInternalServerError),
+		Time:    time.Now().UTC(),
 	}
 	RespondWithJSON(w, http.StatusInternalServerError, response)
 }
@@ -394,14 +395,15 @@ func (rw *responseWriter) WriteHeader(code int) {
 
 // Helper functions for request parsing
 
-// ParseJSONBody parses JSON request body into the provided interface
+// ParseJSONBody parses JSON request body into the provided interface, rejecting
+// unknown fields with an *UnknownFieldError
 func ParseJSONBody(r *http.Request, v interface{}) error {
 	if r.Body == nil {
 		return fmt.Errorf("empty request body")
 	}
 	defer r.Body.Close()
 
-	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+	if err := DecodeJSON(r.Body, v); err != nil {
 		return fmt.Errorf("invalid JSON: %w", err)
 	}
 
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopDrainsRequestsBeforeClosingDatabase(t *testing.T) {
+	s := NewServer(&config.Config{ServiceName: "test-service"})
+
+	started := make(chan struct{})
+	var completed, completedBeforeClose atomic.Bool
+	s.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		completed.Store(true)
+		w.WriteHeader(http.StatusOK)
+	})
+	s.closeDB = func() {
+		completedBeforeClose.Store(completed.Load())
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(listener) }()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+
+	assert.True(t, completedBeforeClose.Load(), "the database was closed under an in-flight request")
+	assert.Equal(t, http.StatusOK, <-status)
+}
+
+func TestStopIsBoundedByTimeout(t *testing.T) {
+	s := NewServer(&config.Config{ServiceName: "test-service"})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	s.HandleFunc("GET /stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	var closed atomic.Bool
+	s.closeDB = func() { closed.Store(true) }
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(listener) }()
+	go func() {
+		if resp, err := http.Get("http://" + listener.Addr().String() + "/stuck"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+	assert.True(t, closed.Load(), "the database is still closed once the timeout passes")
+}
@@ -5,18 +5,24 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/metrics"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/types"
 	"github.com/google/uuid"
 )
 
 // ServiceTemplate provides a reusable template for creating microservices
 type ServiceTemplate struct {
 	*Server
-	name    string
-	version string
+	name        string
+	version     string
+	cachePolicy *CachePolicy
+	metrics     *metrics.Registry
 }
 
 // NewServiceTemplate creates a new service template
@@ -25,18 +31,68 @@ func NewServiceTemplate(name, version string, cfg *config.Config) *ServiceTempla
 	server := NewServer(cfg)
 
 	template := &ServiceTemplate{
-		Server:  server,
-		name:    name,
-		version: version,
+		Server:      server,
+		name:        name,
+		version:     version,
+		cachePolicy: NewCachePolicy(cfg.Server.CacheMaxAge).Route("GET /metrics", NoStore),
+		metrics:     metrics.NewRegistry(),
 	}
+	template.Use(middleware.Metrics(metrics.NewHTTP(template.metrics), server.mux))
+	template.Use(template.cachePolicy.Middleware)
+
+	template.metrics.NewGaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch in seconds.", func() float64 {
+		return float64(startTime.UnixNano()) / 1e9
+	})
+	metrics.RegisterPool(template.metrics, template.poolStats)
 
 	// Add service-specific endpoints
 	template.HandleFunc("GET /version", template.versionHandler)
-	template.HandleFunc("GET /metrics", template.metricsHandler)
+	template.Handle("GET /metrics", template.metrics.Handler())
 
 	return template
 }
 
+// Metrics returns the registry served at GET /metrics, for services to register
+// their own metrics with
+func (st *ServiceTemplate) Metrics() *metrics.Registry {
+	return st.metrics
+}
+
+// CacheControl overrides the Cache-Control directive for routes matching pattern,
+// for example to mark a sensitive read as NoStore
+func (st *ServiceTemplate) CacheControl(pattern, directive string) {
+	st.cachePolicy.Route(pattern, directive)
+}
+
+// MigrationStatusSource reports the schema migration status, as
+// database.MigrationManager does
+type MigrationStatusSource interface {
+	Status() (*database.MigrationStatus, error)
+}
+
+// RegisterMigrationStatus serves the migration status from source at
+// GET /migrations/status, so operators can check the schema of a running service.
+// Like /debug/config it requires the admin token.
+func RegisterMigrationStatus(mux *http.ServeMux, source MigrationStatusSource, adminToken string) {
+	admin := middleware.RequireAdmin(adminToken)
+	mux.Handle("GET /migrations/status", admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", NoStore)
+		status, err := source.Status()
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err, "Failed to get migration status")
+			return
+		}
+		RespondWithJSON(w, http.StatusOK, status)
+	})))
+}
+
+// RegisterMigrationStatus serves the migration status from source at
+// GET /migrations/status to callers with the admin token
+func (st *ServiceTemplate) RegisterMigrationStatus(source MigrationStatusSource) {
+	st.CacheControl("GET /migrations/status", NoStore)
+	RegisterMigrationStatus(st.mux, source, st.config.Security.AdminToken)
+}
+
 // RegisterCRUDRoutes registers standard CRUD routes for a resource
 func (st *ServiceTemplate) RegisterCRUDRoutes(basePath string, handlers *CRUDHandlers) {
 	// Ensure basePath starts and ends correctly
@@ -101,51 +157,12 @@ func ExtractTenantContext(r *http.Request) (*TenantContext, error) {
 	}, nil
 }
 
-// PaginationParams represents pagination parameters
-type PaginationParams struct {
-	Limit  int
-	Offset int
-}
-
-// ParsePaginationParams parses limit and offset from query parameters
-func ParsePaginationParams(r *http.Request) (*PaginationParams, error) {
-	query := r.URL.Query()
-
-	params := &PaginationParams{
-		Limit:  50, // default limit
-		Offset: 0,  // default offset
-	}
-
-	if limitStr := query.Get("limit"); limitStr != "" {
-		limit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid limit parameter: %w", err)
-		}
-		if limit < 1 || limit > 1000 {
-			return nil, fmt.Errorf("limit must be between 1 and 1000")
-		}
-		params.Limit = limit
-	}
-
-	if offsetStr := query.Get("offset"); offsetStr != "" {
-		offset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid offset parameter: %w", err)
-		}
-		if offset < 0 {
-			return nil, fmt.Errorf("offset must be non-negative")
-		}
-		params.Offset = offset
-	}
-
-	return params, nil
-}
-
 // FilterParams represents common filtering parameters
 type FilterParams struct {
 	Search    string
 	Status    string
 	CreatedBy string
+	UpdatedBy string
 	TeamName  string
 	// Add more common filters as needed
 }
@@ -155,9 +172,10 @@ func ParseFilterParams(r *http.Request) *FilterParams {
 	query := r.URL.Query()
 
 	return &FilterParams{
-		Search:    query.Get("search"),
+		Search:    strings.TrimSpace(query.Get("search")),
 		Status:    query.Get("status"),
 		CreatedBy: query.Get("created_by"),
+		UpdatedBy: query.Get("updated_by"),
 		TeamName:  query.Get("team_name"),
 	}
 }
@@ -205,7 +223,7 @@ func WithTenantValidation(handler http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Add tenant context to request context
-		ctx := context.WithValue(r.Context(), "tenant", tenantCtx)
+		ctx := context.WithValue(r.Context(), types.TenantContextKey, tenantCtx)
 		r = r.WithContext(ctx)
 
 		handler(w, r)
@@ -214,7 +232,7 @@ func WithTenantValidation(handler http.HandlerFunc) http.HandlerFunc {
 
 // GetTenantFromContext extracts tenant context from request context
 func GetTenantFromContext(r *http.Request) (*TenantContext, error) {
-	tenantCtx, ok := r.Context().Value("tenant").(*TenantContext)
+	tenantCtx, ok := r.Context().Value(types.TenantContextKey).(*TenantContext)
 	if !ok {
 		return nil, fmt.Errorf("tenant context not found")
 	}
@@ -231,6 +249,12 @@ type ValidationError struct {
 // ValidationErrors represents multiple validation errors
 type ValidationErrors []ValidationError
 
+// CodeValidationFailed is the error code of enveloped validation error responses
+const CodeValidationFailed = "VALIDATION_FAILED"
+
+// CodePolicyViolation is the error code of requests rejected by a blocking policy
+const CodePolicyViolation = "POLICY_VIOLATION"
+
 // Error implements the error interface
 func (ve ValidationErrors) Error() string {
 	if len(ve) == 0 {
@@ -241,16 +265,20 @@ func (ve ValidationErrors) Error() string {
 
 // RespondWithValidationErrors sends validation errors response
 func RespondWithValidationErrors(w http.ResponseWriter, errors ValidationErrors) {
-	response := &ErrorResponse{
+	RespondWithJSON(w, http.StatusBadRequest, validationErrorResponse(errors))
+}
+
+// validationErrorResponse builds the body written for validation errors
+func validationErrorResponse(errors ValidationErrors) *ErrorResponse {
+	return &ErrorResponse{
 		Error:   errors.Error(),
 		Message: "Validation failed",
-		Code:    http.StatusBadRequest,
+		Code:    CodeValidationFailed,
 		Details: map[string]interface{}{
 			"validation_errors": errors,
 		},
-		Time: time.Now().UTC().Format(time.RFC3339),
+		Time: time.Now().UTC(),
 	}
-	RespondWithJSON(w, http.StatusBadRequest, response)
 }
 
 // Service template handlers
@@ -264,25 +292,12 @@ func (st *ServiceTemplate) versionHandler(w http.ResponseWriter, r *http.Request
 	})
 }
 
-// metricsHandler returns basic service metrics
-func (st *ServiceTemplate) metricsHandler(w http.ResponseWriter, r *http.Request) {
-	metrics := map[string]interface{}{
-		"service": st.name,
-		"uptime":  time.Since(startTime).String(),
-		"time":    time.Now().UTC().Format(time.RFC3339),
-	}
-
-	// Add database metrics if available
-	if st.database != nil {
-		stats := st.database.Stats()
-		metrics["database"] = map[string]interface{}{
-			"total_connections": stats.TotalConnections,
-			"idle_connections":  stats.IdleConnections,
-			"used_connections":  stats.UsedConnections,
-		}
+// poolStats returns the database pool statistics, or zeros before SetupDatabase
+func (st *ServiceTemplate) poolStats() database.ConnectionStats {
+	if st.database == nil {
+		return database.ConnectionStats{}
 	}
-
-	RespondWithJSON(w, http.StatusOK, metrics)
+	return st.database.Stats()
 }
 
 // ResourceTemplate provides a template for resource-based services
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/config"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubMigrationStatus struct {
+	status *database.MigrationStatus
+	err    error
+}
+
+func (s stubMigrationStatus) Status() (*database.MigrationStatus, error) {
+	return s.status, s.err
+}
+
+func TestRegisterMigrationStatus(t *testing.T) {
+	serveAs := func(source MigrationStatusSource, token string) *httptest.ResponseRecorder {
+		cfg := config.LoadWithDefaults("test-service", "8080")
+		cfg.Security.AdminToken = "admin-secret"
+		st := NewServiceTemplate("test-service", "1.0.0", cfg)
+		st.RegisterMigrationStatus(source)
+		req := httptest.NewRequest(http.MethodGet, "/migrations/status", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		st.mux.ServeHTTP(rr, req)
+		return rr
+	}
+	serve := func(source MigrationStatusSource) *httptest.ResponseRecorder {
+		return serveAs(source, "admin-secret")
+	}
+
+	t.Run("returns the status", func(t *testing.T) {
+		rr := serve(stubMigrationStatus{status: &database.MigrationStatus{Version: 14, Status: "Version 14 (clean)"}})
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got database.MigrationStatus
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, uint(14), got.Version)
+		assert.False(t, got.Dirty)
+		assert.Equal(t, "Version 14 (clean)", got.Status)
+		assert.Equal(t, NoStore, rr.Header().Get("Cache-Control"))
+	})
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		source := stubMigrationStatus{status: &database.MigrationStatus{Version: 14}}
+		assert.Equal(t, http.StatusUnauthorized, serveAs(source, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serveAs(source, "not-the-token").Code)
+	})
+
+	t.Run("status failure is an internal error", func(t *testing.T) {
+		rr := serve(stubMigrationStatus{err: errors.New("connection refused")})
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
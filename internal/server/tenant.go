@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// Tenant sources, as named in TENANT_RESOLUTION
+const (
+	TenantSourceHeader    = "header"
+	TenantSourceClaim     = "claim"
+	TenantSourceSubdomain = "subdomain"
+)
+
+// ErrNoTenant is returned when no source names the tenant of a request
+var ErrNoTenant = errors.New("missing tenant context: no tenant in the X-Tenant-ID header, token or host")
+
+// ErrTenantMismatch is returned when a request names a tenant other than the one in
+// its token's tenant_id claim
+var ErrTenantMismatch = errors.New("the requested tenant does not match the token's tenant")
+
+// TenantSource finds the tenant a request names in one place, returning uuid.Nil when
+// it names none there
+type TenantSource func(r *http.Request) (uuid.UUID, error)
+
+// TenantNameResolver looks up the ID of the tenant with the given name
+type TenantNameResolver func(ctx context.Context, name string) (uuid.UUID, error)
+
+// HeaderTenantSource reads the tenant ID from the X-Tenant-ID header
+func HeaderTenantSource(r *http.Request) (uuid.UUID, error) {
+	return parseTenantID(r.Header.Get("X-Tenant-ID"))
+}
+
+// ClaimTenantSource reads the tenant ID from the token's tenant_id claim, which
+// middleware.JWTAuth stores under types.TenantIDKey
+func ClaimTenantSource(r *http.Request) (uuid.UUID, error) {
+	tenantID, _ := r.Context().Value(types.TenantIDKey).(string)
+	return parseTenantID(tenantID)
+}
+
+// SubdomainTenantSource resolves the tenant named by the first label of hosts one
+// level below baseDomain, so "acme.idp.example.com" names the tenant "acme" when
+// baseDomain is "idp.example.com". Other hosts name no tenant.
+//
+// database.TenantNameCache provides a resolver via ID.
+func SubdomainTenantSource(baseDomain string, resolve TenantNameResolver) TenantSource {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(r *http.Request) (uuid.UUID, error) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		name, ok := strings.CutSuffix(strings.ToLower(host), suffix)
+		if !ok || name == "" || strings.Contains(name, ".") {
+			return uuid.Nil, nil
+		}
+		return resolve(r.Context(), name)
+	}
+}
+
+// ParseTenantSources returns the sources named in names, in order. The subdomain
+// source needs baseDomain and resolve; without a base domain it is left out.
+func ParseTenantSources(names []string, baseDomain string, resolve TenantNameResolver) ([]TenantSource, error) {
+	sources := make([]TenantSource, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case TenantSourceHeader:
+			sources = append(sources, HeaderTenantSource)
+		case TenantSourceClaim:
+			sources = append(sources, ClaimTenantSource)
+		case TenantSourceSubdomain:
+			if baseDomain != "" {
+				sources = append(sources, SubdomainTenantSource(baseDomain, resolve))
+			}
+		default:
+			return nil, fmt.Errorf("unknown tenant source %q, must be one of: %s, %s, %s", name, TenantSourceHeader, TenantSourceClaim, TenantSourceSubdomain)
+		}
+	}
+	return sources, nil
+}
+
+// TenantResolver finds the tenant of a request by asking its sources in order; the
+// first to name a tenant wins. A token bound to a tenant by its tenant_id claim can
+// only be used in that tenant, whatever the order.
+type TenantResolver struct {
+	sources []TenantSource
+}
+
+// NewTenantResolver creates a resolver asking sources in order
+func NewTenantResolver(sources ...TenantSource) *TenantResolver {
+	return &TenantResolver{sources: sources}
+}
+
+// Resolve returns the tenant of r, or ErrNoTenant when no source names one. A source
+// failing, such as a malformed header or an unknown subdomain, fails the request
+// rather than falling through to the next source. When the token has a tenant_id
+// claim and the tenant resolved is a different one, Resolve returns
+// ErrTenantMismatch, so X-Tenant-ID cannot move a token into another tenant.
+func (tr *TenantResolver) Resolve(r *http.Request) (uuid.UUID, error) {
+	for _, source := range tr.sources {
+		tenantID, err := source(r)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if tenantID == uuid.Nil {
+			continue
+		}
+
+		claimID, err := ClaimTenantSource(r)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if claimID != uuid.Nil && claimID != tenantID {
+			return uuid.Nil, fmt.Errorf("%w: token is for tenant %s", ErrTenantMismatch, claimID)
+		}
+		return tenantID, nil
+	}
+	return uuid.Nil, ErrNoTenant
+}
+
+// Middleware resolves the tenant of /api/ requests once, before the middlewares acting
+// on it such as middleware.TenantSuspension and middleware.TenantMaintenance, and
+// stores it under types.ResolvedTenantIDKey for them and WithTenantResolution.
+// Requests naming no tenant are passed on for their handler to reject if it needs one;
+// other failures are reported as WithTenantResolution reports them.
+func (tr *TenantResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantID, err := tr.Resolve(r)
+		if err != nil && !errors.Is(err, ErrNoTenant) {
+			respondWithTenantError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), types.ResolvedTenantIDKey, tenantID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve returns the tenant Middleware resolved for r, resolving it here when the
+// middleware did not run
+func (tr *TenantResolver) resolve(r *http.Request) (uuid.UUID, error) {
+	tenantID, ok := r.Context().Value(types.ResolvedTenantIDKey).(uuid.UUID)
+	if !ok {
+		return tr.Resolve(r)
+	}
+	if tenantID == uuid.Nil {
+		return uuid.Nil, ErrNoTenant
+	}
+	return tenantID, nil
+}
+
+// WithTenantResolution wraps a handler with tenant context resolved by resolver, or
+// already resolved by its Middleware. Requests naming no tenant, or a malformed one,
+// are reported as 400, those naming a tenant other than their token's as 403 and
+// unknown tenant names as 404.
+func WithTenantResolution(resolver *TenantResolver, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := resolver.resolve(r)
+		if err != nil {
+			respondWithTenantError(w, err)
+			return
+		}
+
+		userID, _ := GetHeaderValue(r, "X-User-Email", false)
+		if userID == "" {
+			userID = "system" // Default for development
+		}
+
+		tenantCtx := &TenantContext{TenantID: tenantID, UserID: userID}
+		ctx := context.WithValue(r.Context(), types.TenantContextKey, tenantCtx)
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// respondWithTenantError reports why the tenant of a request could not be resolved
+func respondWithTenantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNoTenant), errors.Is(err, errInvalidTenantID):
+		RespondWithError(w, http.StatusBadRequest, err, "Invalid tenant context")
+	case errors.Is(err, ErrTenantMismatch):
+		RespondWithError(w, http.StatusForbidden, err, "Not allowed to act in this tenant")
+	case errors.Is(err, database.ErrTenantNotFound):
+		RespondWithError(w, http.StatusNotFound, err, "Tenant not found")
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err, "Failed to resolve tenant")
+	}
+}
+
+// errInvalidTenantID is wrapped by the errors for malformed tenant IDs
+var errInvalidTenantID = errors.New("invalid tenant ID")
+
+// parseTenantID parses a tenant ID, returning uuid.Nil for an empty one
+func parseTenantID(value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	tenantID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w", errInvalidTenantID, err)
+	}
+	return tenantID, nil
+}
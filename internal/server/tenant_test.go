@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantResolution(t *testing.T) {
+	var (
+		headerID = uuid.New()
+		claimID  = uuid.New()
+		acmeID   = uuid.New()
+	)
+	lookups := 0
+	resolve := func(ctx context.Context, name string) (uuid.UUID, error) {
+		lookups++
+		switch name {
+		case "acme":
+			return acmeID, nil
+		case "broken":
+			return uuid.Nil, errors.New("connection refused")
+		default:
+			return uuid.Nil, fmt.Errorf("%w: %s", database.ErrTenantNotFound, name)
+		}
+	}
+
+	sources, err := ParseTenantSources([]string{TenantSourceHeader, TenantSourceClaim, TenantSourceSubdomain}, "idp.example.com", resolve)
+	require.NoError(t, err)
+	resolver := NewTenantResolver(sources...)
+
+	// request builds a request naming a tenant in each of the given places
+	request := func(header, claim, host string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/applications", nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set("X-Tenant-ID", header)
+		}
+		if claim != "" {
+			req = req.WithContext(context.WithValue(req.Context(), types.TenantIDKey, claim))
+		}
+		return req
+	}
+
+	t.Run("each source resolves the tenant", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			req  *http.Request
+			want uuid.UUID
+		}{
+			"header":              {request(headerID.String(), "", "api.internal"), headerID},
+			"claim":               {request("", claimID.String(), "api.internal"), claimID},
+			"subdomain":           {request("", "", "acme.idp.example.com"), acmeID},
+			"subdomain with port": {request("", "", "ACME.idp.example.com:8443"), acmeID},
+		} {
+			got, err := resolver.Resolve(tc.req)
+			require.NoError(t, err, name)
+			assert.Equal(t, tc.want, got, name)
+		}
+	})
+
+	t.Run("sources are asked in order", func(t *testing.T) {
+		got, err := resolver.Resolve(request(headerID.String(), "", "acme.idp.example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, headerID, got, "the header comes first")
+
+		lookups = 0
+		got, err = resolver.Resolve(request("", claimID.String(), "acme.idp.example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, claimID, got, "the claim comes before the subdomain")
+		assert.Zero(t, lookups, "later sources are not asked")
+
+		reversed, err := ParseTenantSources([]string{TenantSourceSubdomain, TenantSourceHeader}, "idp.example.com", resolve)
+		require.NoError(t, err)
+		got, err = NewTenantResolver(reversed...).Resolve(request(headerID.String(), "", "acme.idp.example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, acmeID, got, "the configured order wins")
+	})
+
+	t.Run("the tenant must match the token's claim", func(t *testing.T) {
+		got, err := resolver.Resolve(request(claimID.String(), claimID.String(), ""))
+		require.NoError(t, err)
+		assert.Equal(t, claimID, got, "a header naming the token's tenant is accepted")
+
+		_, err = resolver.Resolve(request(headerID.String(), claimID.String(), ""))
+		assert.ErrorIs(t, err, ErrTenantMismatch, "a header naming another tenant is not")
+
+		reversed, err := ParseTenantSources([]string{TenantSourceSubdomain, TenantSourceClaim}, "idp.example.com", resolve)
+		require.NoError(t, err)
+		_, err = NewTenantResolver(reversed...).Resolve(request("", claimID.String(), "acme.idp.example.com"))
+		assert.ErrorIs(t, err, ErrTenantMismatch, "nor is a subdomain naming another tenant")
+	})
+
+	t.Run("hosts outside the base domain name no tenant", func(t *testing.T) {
+		for _, host := range []string{"idp.example.com", "a.b.idp.example.com", "acme.example.org", "acmeidp.example.com"} {
+			_, err := resolver.Resolve(request("", "", host))
+			assert.ErrorIs(t, err, ErrNoTenant, host)
+		}
+	})
+
+	t.Run("a failing source fails the request", func(t *testing.T) {
+		_, err := resolver.Resolve(request("not-a-uuid", claimID.String(), ""))
+		assert.ErrorIs(t, err, errInvalidTenantID)
+
+		_, err = resolver.Resolve(request("", "", "globex.idp.example.com"))
+		assert.ErrorIs(t, err, database.ErrTenantNotFound)
+	})
+
+	t.Run("the subdomain source needs a base domain", func(t *testing.T) {
+		sources, err := ParseTenantSources([]string{TenantSourceSubdomain}, "", resolve)
+		require.NoError(t, err)
+		assert.Empty(t, sources)
+
+		_, err = ParseTenantSources([]string{"cookie"}, "", resolve)
+		assert.Error(t, err)
+	})
+
+	t.Run("the handler gets the resolved tenant context", func(t *testing.T) {
+		var got *TenantContext
+		handler := WithTenantResolution(resolver, func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			got, err = GetTenantFromContext(r)
+			require.NoError(t, err)
+			w.WriteHeader(http.StatusOK)
+		})
+		serve := func(req *http.Request) int {
+			got = nil
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			return rr.Code
+		}
+
+		req := request("", "", "acme.idp.example.com")
+		req.Header.Set("X-User-Email", "dev@company.com")
+		require.Equal(t, http.StatusOK, serve(req))
+		assert.Equal(t, acmeID, got.TenantID)
+		assert.Equal(t, "dev@company.com", got.UserID)
+
+		assert.Equal(t, http.StatusBadRequest, serve(request("", "", "api.internal")))
+		assert.Equal(t, http.StatusBadRequest, serve(request("", "not-a-uuid", "")))
+		assert.Equal(t, http.StatusForbidden, serve(request(headerID.String(), claimID.String(), "")))
+		assert.Equal(t, http.StatusNotFound, serve(request("", "", "globex.idp.example.com")))
+		assert.Equal(t, http.StatusInternalServerError, serve(request("", "", "broken.idp.example.com")))
+		assert.Nil(t, got)
+	})
+
+	t.Run("the middleware resolves the tenant once for later middlewares", func(t *testing.T) {
+		var resolved, got uuid.UUID
+		handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resolved = types.ResolvedTenantIDFromContext(r.Context())
+			WithTenantResolution(resolver, func(w http.ResponseWriter, r *http.Request) {
+				tenantCtx, err := GetTenantFromContext(r)
+				require.NoError(t, err)
+				got = tenantCtx.TenantID
+			})(w, r)
+		}))
+		serve := func(req *http.Request) int {
+			resolved, got = uuid.Nil, uuid.Nil
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			return rr.Code
+		}
+
+		lookups = 0
+		require.Equal(t, http.StatusOK, serve(request("", "", "acme.idp.example.com")))
+		assert.Equal(t, acmeID, resolved)
+		assert.Equal(t, acmeID, got)
+		assert.Equal(t, 1, lookups, "the handler reuses the resolved tenant")
+
+		assert.Equal(t, http.StatusBadRequest, serve(request("", "", "api.internal")), "handlers needing a tenant still reject requests naming none")
+		assert.Equal(t, http.StatusForbidden, serve(request(headerID.String(), claimID.String(), "")))
+		assert.Equal(t, uuid.Nil, resolved, "rejected requests are not passed on")
+
+		req := request("not-a-uuid", "", "")
+		req.URL.Path = "/health"
+		serve(req)
+		assert.Equal(t, uuid.Nil, resolved, "only /api/ requests are resolved")
+	})
+}
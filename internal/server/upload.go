@@ -0,0 +1,225 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+)
+
+// Default upload limits, applied when an UploadOptions field is zero
+const (
+	DefaultMaxPartBytes  = 10 << 20 // 10MB
+	DefaultMaxTotalBytes = 50 << 20 // 50MB
+	DefaultMaxParts      = 100
+)
+
+// Upload errors
+var (
+	ErrUploadTooLarge = errors.New("upload too large")
+	ErrInvalidUpload  = errors.New("invalid upload")
+)
+
+// UploadOptions configures ParseUpload
+type UploadOptions struct {
+	// MaxPartBytes limits the size of each file or field
+	MaxPartBytes int64
+	// MaxTotalBytes limits the combined size of all parts
+	MaxTotalBytes int64
+	// MaxParts limits the number of parts
+	MaxParts int
+	// TempDir is where files are written; defaults to os.TempDir
+	TempDir string
+	// Store, when set, streams each file to storage instead of a temp file. content
+	// enforces the size limits and fails with ErrUploadTooLarge when they are exceeded.
+	Store func(file *UploadedFile, content io.Reader) error
+}
+
+// UploadedFile is a file part of a multipart upload
+type UploadedFile struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Size        int64
+	// Path is the temp file holding the content; empty when UploadOptions.Store is used
+	Path string
+}
+
+// Open opens the temp file holding the uploaded content
+func (f *UploadedFile) Open() (*os.File, error) {
+	if f.Path == "" {
+		return nil, fmt.Errorf("upload %s was streamed to storage", f.FileName)
+	}
+	return os.Open(f.Path)
+}
+
+// Upload is a parsed multipart/form-data request
+type Upload struct {
+	Fields map[string]string
+	Files  []*UploadedFile
+}
+
+// Cleanup removes the temp files written for the upload
+func (u *Upload) Cleanup() error {
+	var errs []error
+	for _, f := range u.Files {
+		if f.Path == "" {
+			continue
+		}
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseUpload streams a multipart/form-data request body part by part, writing files
+// to temp files (or opts.Store) rather than buffering them in memory. Exceeding a
+// limit returns ErrUploadTooLarge and removes anything already written. Callers must
+// call Cleanup on the returned upload once they are done with the files.
+func ParseUpload(r *http.Request, opts UploadOptions) (*Upload, error) {
+	opts = opts.withDefaults()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, fmt.Errorf("%w: content type must be multipart/form-data", ErrInvalidUpload)
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+
+	upload := &Upload{Fields: make(map[string]string)}
+	total := &uploadBudget{remaining: opts.MaxTotalBytes, limit: opts.MaxTotalBytes}
+
+	fail := func(err error) (*Upload, error) {
+		_ = upload.Cleanup()
+		return nil, err
+	}
+
+	for parts := 0; ; parts++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return upload, nil
+		}
+		if err != nil {
+			return fail(fmt.Errorf("%w: %v", ErrInvalidUpload, err))
+		}
+		if parts >= opts.MaxParts {
+			part.Close()
+			return fail(fmt.Errorf("%w: more than %d parts", ErrUploadTooLarge, opts.MaxParts))
+		}
+
+		content := &limitedPart{
+			r:       part,
+			name:    part.FormName(),
+			budget:  &uploadBudget{remaining: opts.MaxPartBytes, limit: opts.MaxPartBytes},
+			overall: total,
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(content)
+			part.Close()
+			if err != nil {
+				return fail(err)
+			}
+			upload.Fields[part.FormName()] = string(value)
+			continue
+		}
+
+		file := &UploadedFile{
+			FieldName:   part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+		}
+		err = storeUploadedFile(file, content, opts)
+		part.Close()
+		if file.Path != "" || opts.Store != nil {
+			upload.Files = append(upload.Files, file)
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+}
+
+// RespondWithUploadError maps a ParseUpload error to a response: 413 for exceeded
+// limits, 400 for malformed uploads and 500 otherwise
+func RespondWithUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUploadTooLarge):
+		RespondWithError(w, http.StatusRequestEntityTooLarge, err, "Upload exceeds the size limit")
+	case errors.Is(err, ErrInvalidUpload):
+		RespondWithError(w, http.StatusBadRequest, err, "Invalid upload")
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err, "Failed to process upload")
+	}
+}
+
+func (o UploadOptions) withDefaults() UploadOptions {
+	if o.MaxPartBytes <= 0 {
+		o.MaxPartBytes = DefaultMaxPartBytes
+	}
+	if o.MaxTotalBytes <= 0 {
+		o.MaxTotalBytes = DefaultMaxTotalBytes
+	}
+	if o.MaxParts <= 0 {
+		o.MaxParts = DefaultMaxParts
+	}
+	return o
+}
+
+// storeUploadedFile streams a file part to opts.Store or a temp file, recording its size
+func storeUploadedFile(file *UploadedFile, content *limitedPart, opts UploadOptions) error {
+	if opts.Store != nil {
+		err := opts.Store(file, content)
+		file.Size = content.read
+		return err
+	}
+
+	tmp, err := os.CreateTemp(opts.TempDir, "upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	file.Path = tmp.Name()
+
+	file.Size, err = io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write upload: %w", closeErr)
+	}
+	return err
+}
+
+// uploadBudget tracks the bytes left under a limit
+type uploadBudget struct {
+	remaining int64
+	limit     int64
+}
+
+// limitedPart reads a part, failing with ErrUploadTooLarge once the part or the
+// upload as a whole exceeds its limit
+type limitedPart struct {
+	r       io.Reader
+	name    string
+	budget  *uploadBudget
+	overall *uploadBudget
+	read    int64
+}
+
+func (l *limitedPart) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	l.budget.remaining -= int64(n)
+	l.overall.remaining -= int64(n)
+
+	if l.budget.remaining < 0 {
+		return n, fmt.Errorf("%w: part %q exceeds %d bytes", ErrUploadTooLarge, l.name, l.budget.limit)
+	}
+	if l.overall.remaining < 0 {
+		return n, fmt.Errorf("%w: upload exceeds %d bytes", ErrUploadTooLarge, l.overall.limit)
+	}
+	return n, err
+}
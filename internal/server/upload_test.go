@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUploadRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile("files", name)
+		require.NoError(t, err)
+		_, err = io.WriteString(part, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/templates/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestParseUpload(t *testing.T) {
+	opts := UploadOptions{MaxPartBytes: 64, MaxTotalBytes: 128, TempDir: t.TempDir()}
+
+	t.Run("within limits", func(t *testing.T) {
+		req := newUploadRequest(t,
+			map[string]string{"template": "web-service"},
+			map[string]string{"main.tf": `resource "null_resource" "x" {}`},
+		)
+
+		upload, err := ParseUpload(req, opts)
+		require.NoError(t, err)
+		defer upload.Cleanup()
+
+		assert.Equal(t, "web-service", upload.Fields["template"])
+		require.Len(t, upload.Files, 1)
+
+		file := upload.Files[0]
+		assert.Equal(t, "files", file.FieldName)
+		assert.Equal(t, "main.tf", file.FileName)
+		assert.Equal(t, int64(len(`resource "null_resource" "x" {}`)), file.Size)
+
+		f, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(f)
+		f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, `resource "null_resource" "x" {}`, string(content))
+
+		require.NoError(t, upload.Cleanup())
+		_, err = os.Stat(file.Path)
+		assert.True(t, os.IsNotExist(err), "cleanup removes temp files")
+	})
+
+	t.Run("part over the limit is rejected with 413", func(t *testing.T) {
+		req := newUploadRequest(t, nil, map[string]string{"bundle.zip": strings.Repeat("x", 65)})
+
+		_, err := ParseUpload(req, opts)
+		require.ErrorIs(t, err, ErrUploadTooLarge)
+
+		rr := httptest.NewRecorder()
+		RespondWithUploadError(rr, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+		entries, err := os.ReadDir(opts.TempDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "partially written files are removed")
+	})
+
+	t.Run("total over the limit is rejected", func(t *testing.T) {
+		req := newUploadRequest(t, nil, map[string]string{
+			"a.tf": strings.Repeat("a", 60),
+			"b.tf": strings.Repeat("b", 60),
+			"c.tf": strings.Repeat("c", 60),
+		})
+
+		_, err := ParseUpload(req, opts)
+		assert.ErrorIs(t, err, ErrUploadTooLarge)
+	})
+
+	t.Run("files can be streamed to storage", func(t *testing.T) {
+		var stored bytes.Buffer
+		streaming := opts
+		streaming.Store = func(file *UploadedFile, content io.Reader) error {
+			_, err := io.Copy(&stored, content)
+			return err
+		}
+
+		upload, err := ParseUpload(newUploadRequest(t, nil, map[string]string{"main.tf": "hello"}), streaming)
+		require.NoError(t, err)
+
+		require.Len(t, upload.Files, 1)
+		assert.Empty(t, upload.Files[0].Path)
+		assert.Equal(t, int64(5), upload.Files[0].Size)
+		assert.Equal(t, "hello", stored.String())
+	})
+
+	t.Run("non-multipart requests are invalid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+
+		_, err := ParseUpload(req, opts)
+		require.ErrorIs(t, err, ErrInvalidUpload)
+
+		rr := httptest.NewRecorder()
+		RespondWithUploadError(rr, err)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
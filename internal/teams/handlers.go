@@ -2,26 +2,71 @@ package teams
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/database"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/aykay76/ai-idp/internal/validation"
 	"github.com/google/uuid"
 )
 
 // Handlers provides HTTP handlers for team resources using native Go HTTP
 type Handlers struct {
-	service TeamService
-	logger  *logger.Logger
+	service   TeamService
+	logger    *logger.Logger
+	responder *server.Responder
+	policies  *policy.Evaluator
+
+	pageLimits server.PageLimits
+}
+
+// HandlerOption configures Handlers
+type HandlerOption func(*Handlers)
+
+// WithResponseEnvelope wraps every response in the types.APIResponse envelope
+func WithResponseEnvelope(enabled bool) HandlerOption {
+	return func(h *Handlers) {
+		h.responder = server.NewResponder(enabled)
+	}
+}
+
+// WithPolicies evaluates policies before teams are created. Blocking violations
+// reject the request with a 403; others are logged.
+func WithPolicies(e *policy.Evaluator) HandlerOption {
+	return func(h *Handlers) {
+		h.policies = e
+	}
+}
+
+// WithPageLimits sets the default and maximum page size for listing teams
+func WithPageLimits(limits server.PageLimits) HandlerOption {
+	return func(h *Handlers) {
+		h.pageLimits = limits
+	}
 }
 
 // NewHandlers creates new team handlers
-func NewHandlers(service TeamService, appLogger *logger.Logger) *Handlers {
-	return &Handlers{
-		service: service,
-		logger:  appLogger,
+func NewHandlers(service TeamService, appLogger *logger.Logger, opts ...HandlerOption) *Handlers {
+	h := &Handlers{
+		service:   service,
+		logger:    appLogger,
+		responder: server.NewResponder(false),
+
+		pageLimits: server.DefaultPageLimits,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // ListTeamsResponse represents the response for listing teams
@@ -32,18 +77,14 @@ type ListTeamsResponse struct {
 
 // PaginationMeta contains pagination metadata
 type PaginationMeta struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
-	Total  int `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string    `json:"error"`
-	Message string    `json:"message"`
-	Code    string    `json:"code,omitempty"`
-	Time    time.Time `json:"timestamp"`
-}
+// ErrorResponse represents an error response, in the shape shared by every service
+type ErrorResponse = server.ErrorResponse
 
 // CreateTeam handles POST /api/v1/teams
 func (h *Handlers) CreateTeam(w http.ResponseWriter, r *http.Request) {
@@ -56,12 +97,18 @@ func (h *Handlers) CreateTeam(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var teamReq Team
-	if err := json.NewDecoder(r.Body).Decode(&teamReq); err != nil {
+	if err := server.DecodeJSON(r.Body, &teamReq); err != nil {
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 		}).Error("Failed to decode team request")
 
-		h.writeError(w, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		h.writeDecodeError(w, r, err)
+		return
+	}
+	if !h.validate(w, r, &teamReq) {
+		return
+	}
+	if !h.checkPolicies(w, r, &teamReq) {
 		return
 	}
 
@@ -72,7 +119,23 @@ func (h *Handlers) CreateTeam(w http.ResponseWriter, r *http.Request) {
 			logger.FieldError: err.Error(),
 		}).Error("Failed to create team")
 
-		h.writeError(w, "Failed to create team", http.StatusInternalServerError, "CREATE_FAILED")
+		if errors.Is(err, naming.ErrNotLowercase) {
+			h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_NAME")
+			return
+		}
+		if errors.Is(err, ErrInvalidTeamData) {
+			h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_TEAM_DATA")
+			return
+		}
+		if errors.Is(err, ErrTeamAlreadyExists) {
+			h.writeError(w, r, "A team with this name already exists", http.StatusConflict, "TEAM_ALREADY_EXISTS")
+			return
+		}
+		if errors.Is(err, database.ErrQuotaExceeded) {
+			h.writeError(w, r, err.Error(), http.StatusForbidden, "QUOTA_EXCEEDED")
+			return
+		}
+		h.writeError(w, r, "Failed to create team", http.StatusInternalServerError, "CREATE_FAILED")
 		return
 	}
 
@@ -82,13 +145,7 @@ func (h *Handlers) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	}).Info("Team created successfully")
 
 	// Return created team
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(team); err != nil {
-		h.logger.WithFields(logger.LogFields{
-			logger.FieldError: err.Error(),
-		}).Error("Failed to encode team response")
-	}
+	h.respondJSON(w, r, http.StatusCreated, team)
 }
 
 // GetTeam handles GET /api/v1/teams/{id}
@@ -96,21 +153,9 @@ func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Extract team ID from path
-	teamID := r.PathValue("id")
-	if teamID == "" {
-		h.writeError(w, "Team ID is required", http.StatusBadRequest, "MISSING_TEAM_ID")
-		return
-	}
-
-	// Parse team ID as UUID
-	id, err := uuid.Parse(teamID)
+	id, err := server.ParsePathUUID(r, "id")
 	if err != nil {
-		h.logger.WithFields(logger.LogFields{
-			logger.FieldError: err.Error(),
-			"team_id":         teamID,
-		}).Error("Invalid team ID format")
-
-		h.writeError(w, "Invalid team ID format", http.StatusBadRequest, "INVALID_TEAM_ID")
+		h.responder.PathError(w, r, err)
 		return
 	}
 
@@ -124,7 +169,7 @@ func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
 	team, err := h.service.GetTeam(ctx, id)
 	if err != nil {
 		if err == ErrTeamNotFound {
-			h.writeError(w, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+			h.writeError(w, r, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
 			return
 		}
 
@@ -133,18 +178,15 @@ func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
 			"team_id":         id.String(),
 		}).Error("Failed to get team")
 
-		h.writeError(w, "Failed to get team", http.StatusInternalServerError, "GET_FAILED")
+		h.writeError(w, r, "Failed to get team", http.StatusInternalServerError, "GET_FAILED")
 		return
 	}
 
-	// Return team
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(team); err != nil {
-		h.logger.WithFields(logger.LogFields{
-			logger.FieldError: err.Error(),
-		}).Error("Failed to encode team response")
+	// Return team, tagged with its version for conditional updates and reads
+	if server.NotModified(w, r, server.ETag(team.Version)) {
+		return
 	}
+	h.respondJSON(w, r, http.StatusOK, team)
 }
 
 // ListTeams handles GET /api/v1/teams
@@ -156,53 +198,103 @@ func (h *Handlers) ListTeams(w http.ResponseWriter, r *http.Request) {
 		logger.FieldHTTPPath:   r.URL.Path,
 	}).Debug("Listing teams")
 
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+	// Parse pagination parameters
+	params, err := server.ParsePagination(r, h.pageLimits)
+	if err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, server.CodeInvalidPagination)
+		return
+	}
+	limit, offset := params.Limit, params.Offset
+
+	// Parse and validate actor filters
+	filters := server.ParseFilterParams(r)
+	if err := filters.Validate(); err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_FILTER")
+		return
+	}
 
-	// Set default values
-	limit := 50
-	offset := 0
+	sort, err := server.ParseSortParam(r, SortFields)
+	if err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, server.CodeInvalidSort)
+		return
+	}
 
-	// Parse limit if provided
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
+	// Soft-deleted teams are only listed with include_deleted=true
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+	listReq := ListTeamsRequest{
+		CreatedBy:      filters.CreatedBy,
+		UpdatedBy:      filters.UpdatedBy,
+		Search:         filters.Search,
+		IncludeDeleted: includeDeleted,
+		Sort:           sort,
+		Limit:          limit,
+		Offset:         offset,
 	}
 
-	// Parse offset if provided
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	// A cursor switches to keyset pagination; one extra row shows whether more exist
+	cursor, err := server.ParseCursorParam(r)
+	if err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_CURSOR")
+		return
+	}
+	if cursor != nil {
+		if sort != database.DefaultSort {
+			h.writeError(w, r, "a cursor can only continue a listing in the default order", http.StatusBadRequest, server.CodeInvalidSort)
+			return
 		}
+		offset = 0
+		listReq.After = cursor
+		listReq.Limit = limit + 1
+		listReq.Offset = 0
 	}
 
 	// List teams using service
-	teams, total, err := h.service.ListTeams(ctx, limit, offset)
+	teams, total, err := h.service.ListTeams(ctx, listReq)
 	if err != nil {
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 		}).Error("Failed to list teams")
 
-		h.writeError(w, "Failed to list teams", http.StatusInternalServerError, "LIST_FAILED")
+		h.writeError(w, r, "Failed to list teams", http.StatusInternalServerError, "LIST_FAILED")
 		return
 	}
 
+	if teams == nil {
+		teams = []Team{}
+	}
+
+	more := offset+len(teams) < total
+	if cursor != nil {
+		more = len(teams) > limit
+		if more {
+			teams = teams[:limit]
+		}
+	}
+
+	// Cursors follow the default order, so other sorts page by offset only
+	var nextCursor string
+	if more && len(teams) > 0 && sort == database.DefaultSort {
+		last := teams[len(teams)-1]
+		nextCursor = database.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
 	// Create response
 	response := ListTeamsResponse{
 		Teams: teams,
 		Pagination: PaginationMeta{
-			Limit:  limit,
-			Offset: offset,
-			Total:  total,
+			Limit:      limit,
+			Offset:     offset,
+			Total:      total,
+			NextCursor: nextCursor,
 		},
 	}
 
+	page := server.NewPagination(limit, offset, total)
+	page.NextCursor = nextCursor
+
 	// Return teams list
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if err := h.responder.ConditionalList(w, r, http.StatusOK, response, teams, page); err != nil {
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 		}).Error("Failed to encode teams response")
@@ -214,21 +306,9 @@ func (h *Handlers) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Extract team ID from path
-	teamID := r.PathValue("id")
-	if teamID == "" {
-		h.writeError(w, "Team ID is required", http.StatusBadRequest, "MISSING_TEAM_ID")
-		return
-	}
-
-	// Parse team ID as UUID
-	id, err := uuid.Parse(teamID)
+	id, err := server.ParsePathUUID(r, "id")
 	if err != nil {
-		h.logger.WithFields(logger.LogFields{
-			logger.FieldError: err.Error(),
-			"team_id":         teamID,
-		}).Error("Invalid team ID format")
-
-		h.writeError(w, "Invalid team ID format", http.StatusBadRequest, "INVALID_TEAM_ID")
+		h.responder.PathError(w, r, err)
 		return
 	}
 
@@ -238,25 +318,52 @@ func (h *Handlers) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 		"team_id":              id.String(),
 	}).Debug("Updating team")
 
+	// Updates must name the version they were made against
+	version, err := server.ParseIfMatch(r)
+	if err != nil {
+		h.writeIfMatchError(w, r, err)
+		return
+	}
+
 	// Parse request body
 	var teamReq Team
-	if err := json.NewDecoder(r.Body).Decode(&teamReq); err != nil {
+	if err := server.DecodeJSON(r.Body, &teamReq); err != nil {
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 		}).Error("Failed to decode team update request")
 
-		h.writeError(w, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		h.writeDecodeError(w, r, err)
+		return
+	}
+	if !h.validate(w, r, &teamReq) {
 		return
 	}
 
-	// Set the ID from path
+	// Set the ID from path and the version from If-Match
 	teamReq.ID = id
+	teamReq.Version = version
 
 	// Update team using service
 	team, err := h.service.UpdateTeam(ctx, teamReq)
 	if err != nil {
-		if err == ErrTeamNotFound {
-			h.writeError(w, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+		if errors.Is(err, ErrTeamNotFound) {
+			h.writeError(w, r, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+			return
+		}
+		if errors.Is(err, ErrInvalidTeamData) {
+			h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_TEAM_DATA")
+			return
+		}
+		if errors.Is(err, ErrTeamAlreadyExists) {
+			h.writeError(w, r, "A team with this name already exists", http.StatusConflict, "TEAM_ALREADY_EXISTS")
+			return
+		}
+		if errors.Is(err, ErrVersionMismatch) {
+			h.writeError(w, r, "Team has been modified since it was read; fetch it again and retry", http.StatusPreconditionFailed, "VERSION_MISMATCH")
+			return
+		}
+		if errors.Is(err, ErrLastOwner) {
+			h.writeError(w, r, "A team must keep at least one owner", http.StatusConflict, "LAST_OWNER")
 			return
 		}
 
@@ -265,7 +372,7 @@ func (h *Handlers) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 			"team_id":         id.String(),
 		}).Error("Failed to update team")
 
-		h.writeError(w, "Failed to update team", http.StatusInternalServerError, "UPDATE_FAILED")
+		h.writeError(w, r, "Failed to update team", http.StatusInternalServerError, "UPDATE_FAILED")
 		return
 	}
 
@@ -275,35 +382,96 @@ func (h *Handlers) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 	}).Info("Team updated successfully")
 
 	// Return updated team
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(team); err != nil {
-		h.logger.WithFields(logger.LogFields{
-			logger.FieldError: err.Error(),
-		}).Error("Failed to encode team response")
-	}
+	server.SetETag(w, team.Version)
+	h.respondJSON(w, r, http.StatusOK, team)
 }
 
-// DeleteTeam handles DELETE /api/v1/teams/{id}
-func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+// PatchTeam handles PATCH /api/v1/teams/{id}, changing only the fields in the body
+func (h *Handlers) PatchTeam(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Extract team ID from path
-	teamID := r.PathValue("id")
-	if teamID == "" {
-		h.writeError(w, "Team ID is required", http.StatusBadRequest, "MISSING_TEAM_ID")
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"team_id":              id.String(),
+	}).Debug("Patching team")
+
+	// Patches must name the version they were made against, like updates
+	version, err := server.ParseIfMatch(r)
+	if err != nil {
+		h.writeIfMatchError(w, r, err)
+		return
+	}
+
+	// Parse request body
+	var req UpdateTeamRequest
+	if err := server.DecodeJSON(r.Body, &req); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to decode team patch request")
+
+		h.writeDecodeError(w, r, err)
+		return
+	}
+	if !h.validate(w, r, &req) {
 		return
 	}
+	req.IfMatch = version
 
-	// Parse team ID as UUID
-	id, err := uuid.Parse(teamID)
+	// Patch team using service
+	team, err := h.service.PatchTeam(ctx, id, req)
 	if err != nil {
+		if errors.Is(err, ErrTeamNotFound) {
+			h.writeError(w, r, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+			return
+		}
+		if errors.Is(err, ErrInvalidTeamData) {
+			h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_TEAM_DATA")
+			return
+		}
+		if errors.Is(err, ErrTeamAlreadyExists) {
+			h.writeError(w, r, "A team with this name already exists", http.StatusConflict, "TEAM_ALREADY_EXISTS")
+			return
+		}
+		if errors.Is(err, ErrVersionMismatch) {
+			h.writeError(w, r, "Team has been modified since it was read; fetch it again and retry", http.StatusPreconditionFailed, "VERSION_MISMATCH")
+			return
+		}
+
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
-			"team_id":         teamID,
-		}).Error("Invalid team ID format")
+			"team_id":         id.String(),
+		}).Error("Failed to patch team")
 
-		h.writeError(w, "Invalid team ID format", http.StatusBadRequest, "INVALID_TEAM_ID")
+		h.writeError(w, r, "Failed to update team", http.StatusInternalServerError, "UPDATE_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"team_id":   team.ID,
+		"team_name": team.Name,
+	}).Info("Team patched successfully")
+
+	// Return patched team
+	server.SetETag(w, team.Version)
+	h.respondJSON(w, r, http.StatusOK, team)
+}
+
+// DeleteTeam handles DELETE /api/v1/teams/{id}
+func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract team ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
 		return
 	}
 
@@ -317,7 +485,7 @@ func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
 	err = h.service.DeleteTeam(ctx, id)
 	if err != nil {
 		if err == ErrTeamNotFound {
-			h.writeError(w, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+			h.writeError(w, r, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
 			return
 		}
 
@@ -326,7 +494,7 @@ func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
 			"team_id":         id.String(),
 		}).Error("Failed to delete team")
 
-		h.writeError(w, "Failed to delete team", http.StatusInternalServerError, "DELETE_FAILED")
+		h.writeError(w, r, "Failed to delete team", http.StatusInternalServerError, "DELETE_FAILED")
 		return
 	}
 
@@ -338,8 +506,342 @@ func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreTeam handles POST /api/v1/teams/{id}/restore
+func (h *Handlers) RestoreTeam(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract team ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"team_id":              id.String(),
+	}).Debug("Restoring team")
+
+	// Restore team using service
+	team, err := h.service.RestoreTeam(ctx, id)
+	if err != nil {
+		if err == ErrTeamNotFound {
+			h.writeError(w, r, "Deleted team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"team_id":         id.String(),
+		}).Error("Failed to restore team")
+
+		h.writeError(w, r, "Failed to restore team", http.StatusInternalServerError, "RESTORE_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"team_id":   team.ID,
+		"team_name": team.Name,
+	}).Info("Team restored successfully")
+
+	// Return restored team
+	h.respondJSON(w, r, http.StatusOK, team)
+}
+
+// BulkAddMembers handles POST /api/v1/teams/{id}/members:bulk
+func (h *Handlers) BulkAddMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract team ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	// Parse request body
+	var bulkReq BulkMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&bulkReq); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to decode bulk members request")
+
+		h.writeDecodeError(w, r, err)
+		return
+	}
+
+	if len(bulkReq.Members) == 0 {
+		h.writeError(w, r, "At least one member is required", http.StatusBadRequest, "INVALID_MEMBERS")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"team_id":              id.String(),
+		"member_count":         len(bulkReq.Members),
+		"atomic":               bulkReq.Atomic,
+	}).Debug("Importing team members")
+
+	report, err := h.service.BulkAddMembers(ctx, id, bulkReq.Members, bulkReq.Atomic)
+	if err != nil {
+		if err == ErrTeamNotFound {
+			h.writeError(w, r, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"team_id":         id.String(),
+		}).Error("Failed to import team members")
+
+		h.writeError(w, r, "Failed to import team members", http.StatusInternalServerError, "BULK_IMPORT_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"team_id":  id.String(),
+		"added":    report.Added,
+		"rejected": report.Rejected,
+		"applied":  report.Applied,
+	}).Info("Team members imported")
+
+	// An atomic import that was rolled back is reported as unprocessable
+	statusCode := http.StatusOK
+	if report.Atomic && !report.Applied && report.Rejected > 0 {
+		statusCode = http.StatusUnprocessableEntity
+	}
+
+	h.respondJSON(w, r, statusCode, report)
+}
+
+// RemoveMember handles DELETE /api/v1/teams/{id}/members/{email}
+func (h *Handlers) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract team ID and member email from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+	email := r.PathValue("email")
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"team_id":              id.String(),
+		"member_email":         email,
+	}).Debug("Removing team member")
+
+	if err := h.service.RemoveMember(ctx, id, email); err != nil {
+		if h.writeMemberError(w, r, err) {
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"team_id":         id.String(),
+		}).Error("Failed to remove team member")
+
+		h.writeError(w, r, "Failed to remove team member", http.StatusInternalServerError, "REMOVE_MEMBER_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"team_id":      id.String(),
+		"member_email": email,
+	}).Info("Team member removed")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateMemberRole handles PUT /api/v1/teams/{id}/members/{email}
+func (h *Handlers) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract team ID and member email from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+	email := r.PathValue("email")
+
+	// Parse request body
+	var roleReq UpdateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&roleReq); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to decode member role request")
+
+		h.writeDecodeError(w, r, err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"team_id":              id.String(),
+		"member_email":         email,
+		"role":                 roleReq.Role,
+	}).Debug("Updating team member role")
+
+	member, err := h.service.UpdateMemberRole(ctx, id, email, roleReq.Role)
+	if err != nil {
+		if h.writeMemberError(w, r, err) {
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"team_id":         id.String(),
+		}).Error("Failed to update team member role")
+
+		h.writeError(w, r, "Failed to update team member role", http.StatusInternalServerError, "UPDATE_MEMBER_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"team_id":      id.String(),
+		"member_email": email,
+		"role":         member.Role,
+	}).Info("Team member role updated")
+
+	h.respondJSON(w, r, http.StatusOK, member)
+}
+
+// writeMemberError writes the response for the expected failures of a member change
+// and reports whether err was one of them
+func (h *Handlers) writeMemberError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, ErrTeamNotFound):
+		h.writeError(w, r, "Team not found", http.StatusNotFound, "TEAM_NOT_FOUND")
+	case errors.Is(err, ErrMemberNotFound):
+		h.writeError(w, r, "Team member not found", http.StatusNotFound, "MEMBER_NOT_FOUND")
+	case errors.Is(err, ErrLastOwner):
+		h.writeError(w, r, "A team must keep at least one owner", http.StatusConflict, "LAST_OWNER")
+	case errors.Is(err, ErrInvalidTeamData):
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_ROLE")
+	default:
+		return false
+	}
+	return true
+}
+
+// checkPolicies evaluates the policies for creating the requested team. It writes a
+// 403 and returns false when a blocking policy denies it.
+func (h *Handlers) checkPolicies(w http.ResponseWriter, r *http.Request, team *Team) bool {
+	if h.policies == nil {
+		return true
+	}
+
+	var tenantID string
+	if team.TenantID != uuid.Nil {
+		tenantID = team.TenantID.String()
+	}
+
+	resource, err := policy.NewResource(policy.ResourceTeam, tenantID, team.Name, team)
+	var violations []policy.Violation
+	if err == nil {
+		violations, err = h.policies.Evaluate(r.Context(), resource, policy.ActionCreate)
+	}
+	if err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"team_name":       team.Name,
+		}).Error("Failed to evaluate team policies")
+		h.writeError(w, r, "Failed to evaluate policies", http.StatusInternalServerError, "POLICY_EVALUATION_FAILED")
+		return false
+	}
+
+	for _, v := range violations {
+		if v.Blocking() {
+			continue
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldHTTPPath: r.URL.Path,
+			"policy":             v.Policy,
+			"rule":               v.Rule,
+			"enforcement":        v.Enforcement,
+			"violation":          v.Message,
+		}).Warn("Policy violation allowed")
+	}
+
+	blocking := policy.Blocking(violations)
+	if len(blocking) == 0 {
+		return true
+	}
+	if err := h.responder.PolicyViolations(w, r, blocking); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode policy violation response")
+	}
+	return false
+}
+
+// validate checks a team or team update against its validate tags, writing a 400
+// with the failing fields and returning false if it is invalid
+func (h *Handlers) validate(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	err := validation.Struct(req)
+	if err == nil {
+		return true
+	}
+
+	var errs server.ValidationErrors
+	if !errors.As(err, &errs) {
+		h.writeError(w, r, "Failed to validate request", http.StatusInternalServerError, "VALIDATION_ERROR")
+		return false
+	}
+	if err := h.responder.ValidationErrors(w, r, errs); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode validation response")
+	}
+	return false
+}
+
+// writeDecodeError reports a request body that could not be decoded: 413 when it is
+// over the MaxBodyBytes limit, otherwise 400 naming any unknown field
+func (h *Handlers) writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if limit, ok := middleware.BodyTooLarge(err); ok {
+		apiErr := middleware.BodyTooLargeError(limit)
+		h.writeError(w, r, apiErr.Message, http.StatusRequestEntityTooLarge, apiErr.Code)
+		return
+	}
+	var unknownField *server.UnknownFieldError
+	if errors.As(err, &unknownField) {
+		h.writeError(w, r, fmt.Sprintf("Unknown field '%s' in request body", unknownField.Field), http.StatusBadRequest, server.CodeUnknownField)
+		return
+	}
+	h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+}
+
+// writeIfMatchError reports an update without a usable If-Match header: 428 when it
+// is missing, 400 when it is not a version ETag
+func (h *Handlers) writeIfMatchError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, server.ErrMissingIfMatch) {
+		h.writeError(w, r, err.Error(), http.StatusPreconditionRequired, "PRECONDITION_REQUIRED")
+		return
+	}
+	h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_IF_MATCH")
+}
+
+// respondJSON writes payload with the status code. The status has been sent by the
+// time encoding fails, so the failure can only be logged.
+func (h *Handlers) respondJSON(w http.ResponseWriter, r *http.Request, status int, payload interface{}) {
+	if err := h.responder.JSON(w, r, status, payload); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError:      err.Error(),
+			logger.FieldHTTPPath:   r.URL.Path,
+			logger.FieldHTTPStatus: status,
+		}).Error("Failed to encode response")
+	}
+}
+
 // writeError writes an error response
-func (h *Handlers) writeError(w http.ResponseWriter, message string, statusCode int, code string) {
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, message string, statusCode int, code string) {
 	response := ErrorResponse{
 		Error:   http.StatusText(statusCode),
 		Message: message,
@@ -347,10 +849,8 @@ func (h *Handlers) writeError(w http.ResponseWriter, message string, statusCode
 		Time:    time.Now().UTC(),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	apiErr := &types.APIError{Code: code, Message: message}
+	if err := h.responder.Error(w, r, statusCode, apiErr, response); err != nil {
 		h.logger.WithFields(logger.LogFields{
 			logger.FieldError: err.Error(),
 		}).Error("Failed to encode error response")
@@ -4,13 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/database"
 	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/policy"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -32,8 +40,8 @@ func (m *MockTeamService) GetTeam(ctx context.Context, teamID uuid.UUID) (Team,
 	return args.Get(0).(Team), args.Error(1)
 }
 
-func (m *MockTeamService) ListTeams(ctx context.Context, limit, offset int) ([]Team, int, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockTeamService) ListTeams(ctx context.Context, req ListTeamsRequest) ([]Team, int, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).([]Team), args.Int(1), args.Error(2)
 }
 
@@ -42,11 +50,36 @@ func (m *MockTeamService) UpdateTeam(ctx context.Context, team Team) (Team, erro
 	return args.Get(0).(Team), args.Error(1)
 }
 
+func (m *MockTeamService) PatchTeam(ctx context.Context, teamID uuid.UUID, req UpdateTeamRequest) (Team, error) {
+	args := m.Called(ctx, teamID, req)
+	return args.Get(0).(Team), args.Error(1)
+}
+
 func (m *MockTeamService) DeleteTeam(ctx context.Context, teamID uuid.UUID) error {
 	args := m.Called(ctx, teamID)
 	return args.Error(0)
 }
 
+func (m *MockTeamService) RestoreTeam(ctx context.Context, teamID uuid.UUID) (Team, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).(Team), args.Error(1)
+}
+
+func (m *MockTeamService) BulkAddMembers(ctx context.Context, teamID uuid.UUID, members []Member, atomic bool) (BulkMembersReport, error) {
+	args := m.Called(ctx, teamID, members, atomic)
+	return args.Get(0).(BulkMembersReport), args.Error(1)
+}
+
+func (m *MockTeamService) RemoveMember(ctx context.Context, teamID uuid.UUID, email string) error {
+	args := m.Called(ctx, teamID, email)
+	return args.Error(0)
+}
+
+func (m *MockTeamService) UpdateMemberRole(ctx context.Context, teamID uuid.UUID, email, role string) (Member, error) {
+	args := m.Called(ctx, teamID, email, role)
+	return args.Get(0).(Member), args.Error(1)
+}
+
 func setupTestHandlers() (*Handlers, *MockTeamService) {
 	mockService := &MockTeamService{}
 	testLogger := logger.New("debug", "text")
@@ -109,14 +142,114 @@ func TestHandlers_CreateTeam(t *testing.T) {
 		assert.Equal(t, "INVALID_JSON", errorResp.Code)
 	})
 
-	t.Run("service error", func(t *testing.T) {
+	t.Run("unknown field", func(t *testing.T) {
+		body := `{"name": "test-team", "lead_email": "lead@company.com", "lead_emial": "typo@company.com"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTeam(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, server.CodeUnknownField, errorResp.Code)
+		assert.Equal(t, "Unknown field 'lead_emial' in request body", errorResp.Message)
+	})
+
+	t.Run("oversized body", func(t *testing.T) {
+		body := `{"name": "test-team", "description": "` + strings.Repeat("x", 2048) + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.ContentLength = -1
+
+		rr := httptest.NewRecorder()
+		middleware.MaxBodyBytes(1024)(http.HandlerFunc(handlers.CreateTeam)).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+		var errorResp ErrorResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, middleware.CodeBodyTooLarge, errorResp.Code)
+		assert.Equal(t, "Request body must not exceed 1024 bytes", errorResp.Message)
+	})
+
+	t.Run("invalid team data", func(t *testing.T) {
+		team := Team{
+			Name:        "test-team",
+			DisplayName: "Test Team",
+			LeadEmail:   "lead@company.com",
+		}
+
+		mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).
+			Return(Team{}, fmt.Errorf("%w: name is required", ErrInvalidTeamData)).Once()
+
+		reqBody, err := json.Marshal(team)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTeam(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		err = json.Unmarshal(rr.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, "invalid team data: name is required", errorResp.Message)
+		assert.Equal(t, "INVALID_TEAM_DATA", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).
+			Return(Team{}, &database.QuotaExceededError{Resource: database.LimitTeams, Limit: 2}).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"name":"test-team","lead_email":"lead@company.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTeam(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		var errorResp ErrorResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, "QUOTA_EXCEEDED", errorResp.Code)
+		assert.Equal(t, "tenant quota exceeded: the tenant may have at most 2 teams", errorResp.Message)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(`{"lead_email":"lead@company.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTeam(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "CreateTeam", mock.Anything, mock.Anything)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
 		team := Team{
 			Name:        "test-team",
 			DisplayName: "Test Team",
 			LeadEmail:   "lead@company.com",
 		}
 
-		mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).Return(Team{}, ErrInvalidTeamData).Once()
+		mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).
+			Return(Team{}, errors.New("failed to create team: connection refused")).Once()
 
 		reqBody, err := json.Marshal(team)
 		require.NoError(t, err)
@@ -137,6 +270,97 @@ func TestHandlers_CreateTeam(t *testing.T) {
 
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		team := Team{
+			Name:        "test-team",
+			DisplayName: "Test Team",
+			LeadEmail:   "lead@company.com",
+		}
+
+		mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).Return(Team{}, fmt.Errorf("%w: test-team", ErrTeamAlreadyExists)).Once()
+
+		reqBody, err := json.Marshal(team)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTeam(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		var errorResp ErrorResponse
+		err = json.Unmarshal(rr.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, "TEAM_ALREADY_EXISTS", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_CreateTeam_NamePolicy(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+	mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).
+		Return(Team{}, fmt.Errorf("%w: %w", ErrInvalidTeamData, naming.ErrNotLowercase)).Once()
+
+	reqBody, err := json.Marshal(Team{Name: "Platform-Team", LeadEmail: "lead@company.com"})
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handlers.CreateTeam(rr, httptest.NewRequest(http.MethodPost, "/api/v1/teams", bytes.NewReader(reqBody)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var errorResp ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+	assert.Equal(t, "INVALID_NAME", errorResp.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_CreateTeam_Policies(t *testing.T) {
+	mockService := &MockTeamService{}
+	handlers := NewHandlers(mockService, logger.New("debug", "text"), WithPolicies(policy.NewEvaluator(policy.Static{{
+		Metadata: types.ObjectMeta{Name: "payments-leads"},
+		Spec: types.PolicySpec{
+			Scope:       types.PolicyScopeTeam,
+			Enforcement: types.PolicyEnforcementBlock,
+			Parameters:  map[string]string{policy.ParameterTeam: "payments"},
+			Rules: []types.PolicyRule{{
+				Name:       "company-lead",
+				Resource:   policy.ResourceTeam,
+				Action:     policy.ActionCreate,
+				Conditions: []types.PolicyCondition{{Field: "lead_email", Operator: policy.OperatorRegex, Value: "@contractor\\.com$"}},
+				Effect:     types.PolicyEffectDeny,
+			}},
+		},
+	}})))
+
+	create := func(name string) *httptest.ResponseRecorder {
+		reqBody, err := json.Marshal(Team{Name: name, LeadEmail: "lead@contractor.com"})
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTeam(rr, httptest.NewRequest(http.MethodPost, "/api/v1/teams", bytes.NewReader(reqBody)))
+		return rr
+	}
+
+	t.Run("team policy blocks its team", func(t *testing.T) {
+		rr := create("payments")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "payments-leads")
+		mockService.AssertNotCalled(t, "CreateTeam", mock.Anything, mock.Anything)
+	})
+
+	t.Run("team policy ignores other teams", func(t *testing.T) {
+		mockService.On("CreateTeam", mock.Anything, mock.AnythingOfType("Team")).Return(Team{ID: uuid.New(), Name: "platform"}, nil).Once()
+
+		rr := create("platform")
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestHandlers_GetTeam(t *testing.T) {
@@ -150,6 +374,7 @@ func TestHandlers_GetTeam(t *testing.T) {
 			DisplayName: "Test Team",
 			LeadEmail:   "lead@company.com",
 			CreatedAt:   time.Now().UTC(),
+			Version:     3,
 		}
 
 		mockService.On("GetTeam", mock.Anything, teamID).Return(expectedTeam, nil).Once()
@@ -162,6 +387,7 @@ func TestHandlers_GetTeam(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
 
 		var responseTeam Team
 		err := json.Unmarshal(rr.Body.Bytes(), &responseTeam)
@@ -183,8 +409,8 @@ func TestHandlers_GetTeam(t *testing.T) {
 		var errorResp ErrorResponse
 		err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
 		require.NoError(t, err)
-		assert.Equal(t, "Team ID is required", errorResp.Message)
-		assert.Equal(t, "MISSING_TEAM_ID", errorResp.Code)
+		assert.Equal(t, "path parameter 'id' is required", errorResp.Message)
+		assert.Equal(t, server.CodeMissingID, errorResp.Code)
 	})
 
 	t.Run("invalid team ID format", func(t *testing.T) {
@@ -199,8 +425,8 @@ func TestHandlers_GetTeam(t *testing.T) {
 		var errorResp ErrorResponse
 		err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
 		require.NoError(t, err)
-		assert.Equal(t, "Invalid team ID format", errorResp.Message)
-		assert.Equal(t, "INVALID_TEAM_ID", errorResp.Code)
+		assert.Equal(t, "path parameter 'id' must be a valid UUID", errorResp.Message)
+		assert.Equal(t, server.CodeInvalidID, errorResp.Code)
 	})
 
 	t.Run("team not found", func(t *testing.T) {
@@ -244,7 +470,7 @@ func TestHandlers_ListTeams(t *testing.T) {
 			},
 		}
 
-		mockService.On("ListTeams", mock.Anything, 50, 0).Return(expectedTeams, 2, nil).Once()
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 50}).Return(expectedTeams, 2, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
 
@@ -275,7 +501,7 @@ func TestHandlers_ListTeams(t *testing.T) {
 			},
 		}
 
-		mockService.On("ListTeams", mock.Anything, 10, 20).Return(expectedTeams, 25, nil).Once()
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 10, Offset: 20}).Return(expectedTeams, 25, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?limit=10&offset=20", nil)
 
@@ -295,8 +521,64 @@ func TestHandlers_ListTeams(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("configured default limit", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		WithPageLimits(server.PageLimits{DefaultLimit: 10, MaxLimit: 20})(handlers)
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 10}).Return([]Team{}, 0, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("limit over the max", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?limit=101", nil)
+
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
+		require.NoError(t, err)
+		assert.Equal(t, server.CodeInvalidPagination, errorResp.Code)
+		assert.Contains(t, errorResp.Message, "between 1 and 100")
+		mockService.AssertNotCalled(t, "ListTeams", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid pagination parameters", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"limit=abc": `invalid limit "abc": must be an integer between 1 and 100`,
+			"offset=-5": `invalid offset "-5": must be a non-negative integer`,
+			"limit=0":   `invalid limit "0": must be an integer between 1 and 100`,
+		} {
+			handlers, mockService := setupTestHandlers()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?"+query, nil)
+
+			rr := httptest.NewRecorder()
+			handlers.ListTeams(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+
+			var errorResp ErrorResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
+			require.NoError(t, err)
+			assert.Equal(t, server.CodeInvalidPagination, errorResp.Code, query)
+			assert.Equal(t, message, errorResp.Message, query)
+			mockService.AssertNotCalled(t, "ListTeams", mock.Anything, mock.Anything)
+		}
+	})
+
 	t.Run("service error", func(t *testing.T) {
-		mockService.On("ListTeams", mock.Anything, 50, 0).Return([]Team{}, 0, assert.AnError).Once()
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 50}).Return([]Team{}, 0, assert.AnError).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
 
@@ -315,6 +597,154 @@ func TestHandlers_ListTeams(t *testing.T) {
 	})
 }
 
+func TestHandlers_ConditionalGet(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		teams := []Team{{ID: uuid.New(), Name: "team1", LeadEmail: "lead1@company.com"}}
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 50}).Return(teams, 1, nil).Twice()
+
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		etag := rr.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		req.Header.Set("If-None-Match", etag)
+		rr = httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("list changed since the tag", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 50}).Return([]Team{{ID: uuid.New(), Name: "team1"}}, 1, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams", nil)
+		req.Header.Set("If-None-Match", `W/"stale"`)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Body.String())
+	})
+
+	t.Run("detail", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		teamID := uuid.New()
+		mockService.On("GetTeam", mock.Anything, teamID).Return(Team{ID: teamID, Name: "team1", Version: 4}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/"+teamID.String(), nil)
+		req.SetPathValue("id", teamID.String())
+		req.Header.Set("If-None-Match", `"4"`)
+		rr := httptest.NewRecorder()
+		handlers.GetTeam(rr, req)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestHandlers_ListTeams_Sort(t *testing.T) {
+	t.Run("passes the sort to the service", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.Sort{Column: "name", Descending: true}, Limit: 1}).
+			Return([]Team{{ID: uuid.New(), Name: "zeta", CreatedAt: time.Now()}}, 2, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?sort=-name&limit=1", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListTeamsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Empty(t, response.Pagination.NextCursor, "cursors only continue the default order")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an unknown sort field", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?sort=lead_email", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, server.CodeInvalidSort, errorResp.Code)
+		mockService.AssertNotCalled(t, "ListTeams", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a cursor with another sort", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		cursor := database.Cursor{CreatedAt: time.Now(), ID: uuid.New()}.Encode()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?sort=name&cursor="+cursor, nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockService.AssertNotCalled(t, "ListTeams", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_ListTeams_ActorFilters(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("filters by creator", func(t *testing.T) {
+		expectedTeams := []Team{{ID: uuid.New(), Name: "team1", CreatedBy: "lead@company.com"}}
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{CreatedBy: "lead@company.com", Sort: database.DefaultSort, Limit: 50}).
+			Return(expectedTeams, 1, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?created_by=lead@company.com", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListTeamsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Teams, 1)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("passes the search term", func(t *testing.T) {
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Search: "pay", Sort: database.DefaultSort, Limit: 50}).
+			Return([]Team{{ID: uuid.New(), Name: "payments"}}, 1, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?search=+pay+", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid updater", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?updated_by=bad%40", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "INVALID_FILTER", errorResp.Code)
+
+		mockService.AssertNotCalled(t, "ListTeams", mock.Anything, mock.Anything)
+	})
+}
+
 func TestHandlers_UpdateTeam(t *testing.T) {
 	handlers, mockService := setupTestHandlers()
 
@@ -329,9 +759,10 @@ func TestHandlers_UpdateTeam(t *testing.T) {
 		expectedTeam := team
 		expectedTeam.ID = teamID
 		expectedTeam.UpdatedAt = time.Now().UTC()
+		expectedTeam.Version = 3
 
 		mockService.On("UpdateTeam", mock.Anything, mock.MatchedBy(func(t Team) bool {
-			return t.ID == teamID
+			return t.ID == teamID && t.Version == 2
 		})).Return(expectedTeam, nil).Once()
 
 		reqBody, err := json.Marshal(team)
@@ -339,6 +770,7 @@ func TestHandlers_UpdateTeam(t *testing.T) {
 
 		req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String(), bytes.NewReader(reqBody))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"2"`)
 		req.SetPathValue("id", teamID.String())
 
 		rr := httptest.NewRecorder()
@@ -346,6 +778,7 @@ func TestHandlers_UpdateTeam(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
 
 		var responseTeam Team
 		err = json.Unmarshal(rr.Body.Bytes(), &responseTeam)
@@ -373,6 +806,7 @@ func TestHandlers_UpdateTeam(t *testing.T) {
 
 		req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String(), bytes.NewReader(reqBody))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
 		req.SetPathValue("id", teamID.String())
 
 		rr := httptest.NewRecorder()
@@ -388,16 +822,159 @@ func TestHandlers_UpdateTeam(t *testing.T) {
 
 		mockService.AssertExpectations(t)
 	})
-}
-
-func TestHandlers_DeleteTeam(t *testing.T) {
-	handlers, mockService := setupTestHandlers()
 
-	t.Run("successful deletion", func(t *testing.T) {
+	t.Run("stale version", func(t *testing.T) {
 		teamID := uuid.New()
-		mockService.On("DeleteTeam", mock.Anything, teamID).Return(nil).Once()
 
-		req := httptest.NewRequest(http.MethodDelete, "/api/v1/teams/"+teamID.String(), nil)
+		mockService.On("UpdateTeam", mock.Anything, mock.MatchedBy(func(t Team) bool {
+			return t.ID == teamID && t.Version == 1
+		})).Return(Team{}, ErrVersionMismatch).Once()
+
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String(),
+			strings.NewReader(`{"name":"stale-team","lead_email":"lead@company.com"}`))
+		req.Header.Set("If-Match", `"1"`)
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateTeam(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "VERSION_MISMATCH", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("removing every owner", func(t *testing.T) {
+		teamID := uuid.New()
+
+		mockService.On("UpdateTeam", mock.Anything, mock.MatchedBy(func(t Team) bool {
+			return t.ID == teamID && len(t.Members) == 0
+		})).Return(Team{}, ErrLastOwner).Once()
+
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String(),
+			strings.NewReader(`{"name":"orphaned-team","lead_email":"lead@company.com","members":[]}`))
+		req.Header.Set("If-Match", `"1"`)
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateTeam(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "LAST_OWNER", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("If-Match is required", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		for _, tt := range []struct {
+			ifMatch string
+			status  int
+			code    string
+		}{
+			{"", http.StatusPreconditionRequired, "PRECONDITION_REQUIRED"},
+			{`W/"1"`, http.StatusBadRequest, "INVALID_IF_MATCH"},
+			{`"1", "2"`, http.StatusBadRequest, "INVALID_IF_MATCH"},
+		} {
+			teamID := uuid.New()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String(),
+				strings.NewReader(`{"name":"team","lead_email":"lead@company.com"}`))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req.SetPathValue("id", teamID.String())
+
+			rr := httptest.NewRecorder()
+			handlers.UpdateTeam(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code, tt.ifMatch)
+
+			var errorResp ErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+			assert.Equal(t, tt.code, errorResp.Code, tt.ifMatch)
+		}
+
+		mockService.AssertNotCalled(t, "UpdateTeam", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_PatchTeam(t *testing.T) {
+	t.Run("only the fields in the body are sent", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		teamID := uuid.New()
+		description := "Payments platform"
+		mockService.On("PatchTeam", mock.Anything, teamID, UpdateTeamRequest{Description: &description, IfMatch: 2}).
+			Return(Team{ID: teamID, Name: "payments", DisplayName: "Payments", Description: &description, Version: 3}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/teams/"+teamID.String(), strings.NewReader(`{"description":"Payments platform"}`))
+		req.Header.Set("If-Match", `"2"`)
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.PatchTeam(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+		var responseTeam Team
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseTeam))
+		assert.Equal(t, "Payments", responseTeam.DisplayName)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		teamID := uuid.New()
+		mockService.On("PatchTeam", mock.Anything, teamID, mock.Anything).Return(Team{}, ErrVersionMismatch).Once()
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/teams/"+teamID.String(), strings.NewReader(`{"description":"Payments platform"}`))
+		req.Header.Set("If-Match", `"1"`)
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.PatchTeam(rr, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid fields are rejected", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		teamID := uuid.New()
+		for _, body := range []string{`{"lead_email":"lead"}`, `{"descripton":"typo"}`} {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/teams/"+teamID.String(), strings.NewReader(body))
+			req.Header.Set("If-Match", "*")
+			req.SetPathValue("id", teamID.String())
+
+			rr := httptest.NewRecorder()
+			handlers.PatchTeam(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+
+		mockService.AssertNotCalled(t, "PatchTeam", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_DeleteTeam(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("successful deletion", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("DeleteTeam", mock.Anything, teamID).Return(nil).Once()
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/teams/"+teamID.String(), nil)
 		req.SetPathValue("id", teamID.String())
 
 		rr := httptest.NewRecorder()
@@ -431,5 +1008,504 @@ func TestHandlers_DeleteTeam(t *testing.T) {
 	})
 }
 
+func TestHandlers_DeleteTeam_RequireRole(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+	teamID := uuid.New()
+
+	roles := map[string]types.TeamRole{
+		"owner@company.com":  types.TeamRoleOwner,
+		"viewer@company.com": types.TeamRoleViewer,
+	}
+	lookup := func(ctx context.Context, id, user string) (types.TeamRole, error) {
+		if id != teamID.String() {
+			return "", nil
+		}
+		return roles[user], nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("DELETE /api/v1/teams/{id}", middleware.RequireRole(types.TeamRoleMaintainer, lookup, "")(http.HandlerFunc(handlers.DeleteTeam)))
+
+	deleteAs := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/teams/"+teamID.String(), nil)
+		req = req.WithContext(context.WithValue(req.Context(), types.UserIDKey, user))
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("an owner can delete the team", func(t *testing.T) {
+		mockService.On("DeleteTeam", mock.Anything, teamID).Return(nil).Once()
+
+		rr := deleteAs("owner@company.com")
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("a viewer is forbidden", func(t *testing.T) {
+		rr := deleteAs("viewer@company.com")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockService.AssertNumberOfCalls(t, "DeleteTeam", 1)
+	})
+}
+
+func TestHandlers_ListTeams_Cursor(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := database.Cursor{CreatedAt: created, ID: uuid.New()}
+
+	page := make([]Team, 3)
+	for i := range page {
+		page[i] = Team{ID: uuid.New(), Name: fmt.Sprintf("team-%d", i), CreatedAt: created}
+	}
+
+	t.Run("fetches one extra row and returns the next cursor", func(t *testing.T) {
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{After: &cursor, Sort: database.DefaultSort, Limit: 3}).Return(page, 40, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?limit=2&cursor="+cursor.Encode(), nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListTeamsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Teams, 2)
+		assert.Equal(t, database.Cursor{CreatedAt: created, ID: page[1].ID}.Encode(), response.Pagination.NextCursor)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("offset pages also return a cursor while rows remain", func(t *testing.T) {
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 2}).Return(page[:2], 40, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?limit=2", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListTeamsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, database.Cursor{CreatedAt: created, ID: page[1].ID}.Encode(), response.Pagination.NextCursor)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid cursor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?cursor=***", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "INVALID_CURSOR", errorResp.Code)
+	})
+}
+
+func TestHandlers_TeamValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"missing required fields", `{"display_name":"Platform"}`, []string{"name", "lead_email"}},
+		{"bad lead email", `{"name":"platform","lead_email":"lead"}`, []string{"lead_email"}},
+		{"bad manager email", `{"name":"platform","lead_email":"lead@company.com","manager_email":"manager"}`, []string{"manager_email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers, mockService := setupTestHandlers()
+
+			teamID := uuid.New()
+			update := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String(), strings.NewReader(tt.body))
+			update.Header.Set("If-Match", `"1"`)
+			update.SetPathValue("id", teamID.String())
+
+			for _, call := range []struct {
+				handler http.HandlerFunc
+				req     *http.Request
+			}{
+				{handlers.CreateTeam, httptest.NewRequest(http.MethodPost, "/api/v1/teams", strings.NewReader(tt.body))},
+				{handlers.UpdateTeam, update},
+			} {
+				rr := httptest.NewRecorder()
+				call.handler(rr, call.req)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+				var response struct {
+					Details struct {
+						ValidationErrors server.ValidationErrors `json:"validation_errors"`
+					} `json:"details"`
+				}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+				var fields []string
+				for _, e := range response.Details.ValidationErrors {
+					fields = append(fields, e.Field)
+				}
+				assert.Equal(t, tt.fields, fields)
+			}
+
+			mockService.AssertNotCalled(t, "CreateTeam", mock.Anything, mock.Anything)
+			mockService.AssertNotCalled(t, "UpdateTeam", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandlers_RestoreTeam(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("successful restore", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("RestoreTeam", mock.Anything, teamID).Return(Team{ID: teamID, Name: "restored"}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams/"+teamID.String()+"/restore", nil)
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.RestoreTeam(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var team Team
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &team))
+		assert.Equal(t, teamID, team.ID)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("team not deleted", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("RestoreTeam", mock.Anything, teamID).Return(Team{}, ErrTeamNotFound).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams/"+teamID.String()+"/restore", nil)
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.RestoreTeam(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("include_deleted is passed to list", func(t *testing.T) {
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{IncludeDeleted: true, Sort: database.DefaultSort, Limit: 50}).Return([]Team{}, 0, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/teams?include_deleted=true", nil)
+
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_BulkAddMembers(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	members := []Member{
+		{Email: "alice@company.com", Role: "developer"},
+		{Email: "not-an-email", Role: "developer"},
+		{Email: "bob@company.com", Role: "superuser"},
+	}
+
+	t.Run("non-atomic mixed batch", func(t *testing.T) {
+		teamID := uuid.New()
+		report := BulkMembersReport{
+			TeamID:      teamID,
+			Applied:     true,
+			Added:       1,
+			Rejected:    2,
+			MemberCount: 1,
+			Results: []BulkMemberResult{
+				{Index: 0, Email: "alice@company.com", Status: BulkMemberAdded},
+				{Index: 1, Email: "not-an-email", Status: BulkMemberRejected, Reason: "invalid email format"},
+				{Index: 2, Email: "bob@company.com", Status: BulkMemberRejected, Reason: "invalid role"},
+			},
+		}
+		mockService.On("BulkAddMembers", mock.Anything, teamID, members, false).Return(report, nil).Once()
+
+		reqBody, err := json.Marshal(BulkMembersRequest{Members: members})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams/"+teamID.String()+"/members:bulk", bytes.NewReader(reqBody))
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.BulkAddMembers(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response BulkMembersReport
+		err = json.Unmarshal(rr.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.True(t, response.Applied)
+		assert.Equal(t, 1, response.Added)
+		assert.Equal(t, 2, response.Rejected)
+		assert.Len(t, response.Results, 3)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("atomic mixed batch is rolled back", func(t *testing.T) {
+		teamID := uuid.New()
+		report := BulkMembersReport{
+			TeamID:   teamID,
+			Atomic:   true,
+			Applied:  false,
+			Rejected: 2,
+			Results: []BulkMemberResult{
+				{Index: 0, Email: "alice@company.com", Status: BulkMemberSkipped},
+				{Index: 1, Email: "not-an-email", Status: BulkMemberRejected, Reason: "invalid email format"},
+				{Index: 2, Email: "bob@company.com", Status: BulkMemberRejected, Reason: "invalid role"},
+			},
+		}
+		mockService.On("BulkAddMembers", mock.Anything, teamID, members, true).Return(report, nil).Once()
+
+		reqBody, err := json.Marshal(BulkMembersRequest{Members: members, Atomic: true})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams/"+teamID.String()+"/members:bulk", bytes.NewReader(reqBody))
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.BulkAddMembers(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		var response BulkMembersReport
+		err = json.Unmarshal(rr.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.False(t, response.Applied)
+		assert.Equal(t, BulkMemberSkipped, response.Results[0].Status)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		teamID := uuid.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams/"+teamID.String()+"/members:bulk", strings.NewReader(`{"members":[]}`))
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.BulkAddMembers(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("team not found", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("BulkAddMembers", mock.Anything, teamID, members, false).Return(BulkMembersReport{}, ErrTeamNotFound).Once()
+
+		reqBody, err := json.Marshal(BulkMembersRequest{Members: members})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/teams/"+teamID.String()+"/members:bulk", bytes.NewReader(reqBody))
+		req.SetPathValue("id", teamID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.BulkAddMembers(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_RemoveMember(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	serve := func(teamID uuid.UUID, email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/teams/"+teamID.String()+"/members/"+email, nil)
+		req.SetPathValue("id", teamID.String())
+		req.SetPathValue("email", email)
+
+		rr := httptest.NewRecorder()
+		handlers.RemoveMember(rr, req)
+		return rr
+	}
+
+	t.Run("removed", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("RemoveMember", mock.Anything, teamID, "alice@company.com").Return(nil).Once()
+
+		assert.Equal(t, http.StatusNoContent, serve(teamID, "alice@company.com").Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("last owner", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("RemoveMember", mock.Anything, teamID, "owner@company.com").Return(ErrLastOwner).Once()
+
+		rr := serve(teamID, "owner@company.com")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "LAST_OWNER", response.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("member not found", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("RemoveMember", mock.Anything, teamID, "nobody@company.com").Return(ErrMemberNotFound).Once()
+
+		assert.Equal(t, http.StatusNotFound, serve(teamID, "nobody@company.com").Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_UpdateMemberRole(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	serve := func(teamID uuid.UUID, email, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/teams/"+teamID.String()+"/members/"+email, strings.NewReader(body))
+		req.SetPathValue("id", teamID.String())
+		req.SetPathValue("email", email)
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateMemberRole(rr, req)
+		return rr
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		teamID := uuid.New()
+		member := Member{Email: "alice@company.com", Role: "maintainer", Status: "active"}
+		mockService.On("UpdateMemberRole", mock.Anything, teamID, "alice@company.com", "maintainer").Return(member, nil).Once()
+
+		rr := serve(teamID, "alice@company.com", `{"role":"maintainer"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response Member
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "maintainer", response.Role)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("demoting the last owner", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("UpdateMemberRole", mock.Anything, teamID, "owner@company.com", "viewer").Return(Member{}, ErrLastOwner).Once()
+
+		assert.Equal(t, http.StatusConflict, serve(teamID, "owner@company.com", `{"role":"viewer"}`).Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		teamID := uuid.New()
+		err := fmt.Errorf("%w: invalid role", ErrInvalidTeamData)
+		mockService.On("UpdateMemberRole", mock.Anything, teamID, "alice@company.com", "superuser").Return(Member{}, err).Once()
+
+		assert.Equal(t, http.StatusBadRequest, serve(teamID, "alice@company.com", `{"role":"superuser"}`).Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
 // Compile-time check that MockTeamService implements TeamService
 var _ TeamService = (*MockTeamService)(nil)
+
+func TestHandlers_ResponseEnvelope(t *testing.T) {
+	mockService := &MockTeamService{}
+	handlers := NewHandlers(mockService, logger.New("debug", "text"), WithResponseEnvelope(true))
+
+	withRequestID := func(req *http.Request) *http.Request {
+		return req.WithContext(context.WithValue(req.Context(), types.RequestIDKey, "req-123"))
+	}
+
+	t.Run("list puts pagination in meta", func(t *testing.T) {
+		expectedTeams := []Team{{ID: uuid.New(), Name: "team1"}, {ID: uuid.New(), Name: "team2"}}
+		mockService.On("ListTeams", mock.Anything, ListTeamsRequest{Sort: database.DefaultSort, Limit: 2, Offset: 2}).Return(expectedTeams, 5, nil).Once()
+
+		req := withRequestID(httptest.NewRequest(http.MethodGet, "/api/v1/teams?limit=2&offset=2", nil))
+		rr := httptest.NewRecorder()
+		handlers.ListTeams(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Success bool       `json:"success"`
+			Data    []Team     `json:"data"`
+			Meta    types.Meta `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Len(t, response.Data, 2)
+		assert.Equal(t, "req-123", response.Meta.RequestID)
+		require.NotNil(t, response.Meta.Pagination)
+		assert.Equal(t, 2, response.Meta.Pagination.Page)
+		assert.Equal(t, 2, response.Meta.Pagination.PerPage)
+		assert.Equal(t, int64(5), response.Meta.Pagination.Total)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("errors use the envelope", func(t *testing.T) {
+		teamID := uuid.New()
+		mockService.On("GetTeam", mock.Anything, teamID).Return(Team{}, ErrTeamNotFound).Once()
+
+		req := withRequestID(httptest.NewRequest(http.MethodGet, "/api/v1/teams/"+teamID.String(), nil))
+		req.SetPathValue("id", teamID.String())
+		rr := httptest.NewRecorder()
+		handlers.GetTeam(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		var response types.APIResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.False(t, response.Success)
+		require.NotNil(t, response.Error)
+		assert.Equal(t, "TEAM_NOT_FOUND", response.Error.Code)
+		assert.Equal(t, "req-123", response.Meta.RequestID)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+// failingWriter is a response writer whose body writes fail, as when the client has
+// gone away
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestHandlers_RespondJSON(t *testing.T) {
+	var logs bytes.Buffer
+	handlers := NewHandlers(&MockTeamService{}, logger.NewWithOptions("info", "json", logger.Options{Output: &logs}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/teams/1", nil)
+
+	t.Run("writes the status and body", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handlers.respondJSON(rr, req, http.StatusCreated, Team{Name: "payments"})
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var team Team
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &team))
+		assert.Equal(t, "payments", team.Name)
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("logs an encode failure", func(t *testing.T) {
+		rr := failingWriter{httptest.NewRecorder()}
+		handlers.respondJSON(rr, req, http.StatusOK, Team{Name: "payments"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, logs.String(), "Failed to encode response")
+		assert.Contains(t, logs.String(), "connection reset by peer")
+		assert.Contains(t, logs.String(), `"http_path":"/api/v1/teams/1"`)
+	})
+}
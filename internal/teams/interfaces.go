@@ -10,7 +10,12 @@ import (
 type TeamService interface {
 	CreateTeam(ctx context.Context, team Team) (Team, error)
 	GetTeam(ctx context.Context, teamID uuid.UUID) (Team, error)
-	ListTeams(ctx context.Context, limit, offset int) ([]Team, int, error)
+	ListTeams(ctx context.Context, req ListTeamsRequest) ([]Team, int, error)
 	UpdateTeam(ctx context.Context, team Team) (Team, error)
+	PatchTeam(ctx context.Context, teamID uuid.UUID, req UpdateTeamRequest) (Team, error)
 	DeleteTeam(ctx context.Context, teamID uuid.UUID) error
-}
\ No newline at end of file
+	RestoreTeam(ctx context.Context, teamID uuid.UUID) (Team, error)
+	BulkAddMembers(ctx context.Context, teamID uuid.UUID, members []Member, atomic bool) (BulkMembersReport, error)
+	RemoveMember(ctx context.Context, teamID uuid.UUID, email string) error
+	UpdateMemberRole(ctx context.Context, teamID uuid.UUID, email, role string) (Member, error)
+}
@@ -0,0 +1,441 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/audit"
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/notify"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Member roles supported by the platform
+var validMemberRoles = map[string]bool{
+	"owner":      true,
+	"maintainer": true,
+	"developer":  true,
+	"viewer":     true,
+}
+
+// Bulk import result statuses
+const (
+	BulkMemberAdded    = "added"
+	BulkMemberRejected = "rejected"
+	BulkMemberSkipped  = "skipped"
+)
+
+// BulkMembersRequest represents the request body for a bulk membership import
+type BulkMembersRequest struct {
+	Members []Member `json:"members"`
+	Atomic  bool     `json:"atomic"`
+}
+
+// UpdateMemberRoleRequest represents the request body for changing a member's role
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// BulkMemberResult reports the outcome of importing a single member
+type BulkMemberResult struct {
+	Index  int    `json:"index"`
+	Email  string `json:"email"`
+	Status string `json:"status"` // added, rejected, skipped
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkMembersReport summarizes the outcome of a bulk membership import
+type BulkMembersReport struct {
+	TeamID      uuid.UUID          `json:"team_id"`
+	Atomic      bool               `json:"atomic"`
+	Applied     bool               `json:"applied"`
+	Added       int                `json:"added"`
+	Rejected    int                `json:"rejected"`
+	MemberCount int                `json:"member_count"`
+	Results     []BulkMemberResult `json:"results"`
+}
+
+// BulkAddMembers validates and adds a batch of members to a team. Valid members are
+// applied in a single transaction; in atomic mode nothing is applied if any member
+// is rejected.
+func (s *Service) BulkAddMembers(ctx context.Context, teamID uuid.UUID, members []Member, atomic bool) (BulkMembersReport, error) {
+	report := BulkMembersReport{
+		TeamID: teamID,
+		Atomic: atomic,
+	}
+
+	if len(members) == 0 {
+		return BulkMembersReport{}, fmt.Errorf("%w: at least one member is required", ErrInvalidTeamData)
+	}
+
+	team := Team{ID: teamID}
+	var added []Member
+	err := s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		var membersJSON string
+		query := `SELECT tenant_id, name, members FROM resource_management.teams WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+		if err := tx.QueryRow(ctx, query, teamID).Scan(&team.TenantID, &team.Name, &membersJSON); err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrTeamNotFound
+			}
+			return fmt.Errorf("failed to lock team: %w", err)
+		}
+
+		var existing []Member
+		if err := json.Unmarshal([]byte(membersJSON), &existing); err != nil {
+			return fmt.Errorf("failed to unmarshal members: %w", err)
+		}
+
+		accepted, results := validateBulkMembers(existing, members, time.Now().UTC())
+		report.Results = results
+		report.Added = len(accepted)
+		report.Rejected = len(members) - len(accepted)
+		report.MemberCount = len(existing)
+
+		if len(accepted) == 0 {
+			return nil
+		}
+
+		if atomic && report.Rejected > 0 {
+			// Nothing is written; mark the valid members as skipped so the caller can
+			// tell them apart from the rejected ones.
+			for i := range report.Results {
+				if report.Results[i].Status == BulkMemberAdded {
+					report.Results[i].Status = BulkMemberSkipped
+					report.Results[i].Reason = "atomic import aborted due to rejected members"
+				}
+			}
+			report.Added = 0
+			return nil
+		}
+
+		updated := append(existing, accepted...)
+		updatedJSON, err := json.Marshal(updated)
+		if err != nil {
+			return fmt.Errorf("failed to marshal members: %w", err)
+		}
+
+		update := `
+			UPDATE resource_management.teams
+			SET members = $2, member_count = $3, updated_at = $4, version = version + 1
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, update, teamID, string(updatedJSON), len(updated), time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to update members: %w", err)
+		}
+
+		report.Applied = true
+		report.MemberCount = len(updated)
+		added = accepted
+		return nil
+	})
+	if err != nil {
+		return BulkMembersReport{}, err
+	}
+
+	for _, member := range added {
+		s.notifyMembership(ctx, notify.TypeTeamMemberAdded, team, member)
+	}
+	return report, nil
+}
+
+// RemoveMember removes the member with email from a team. ErrLastOwner is returned
+// if the member is the team's only owner.
+func (s *Service) RemoveMember(ctx context.Context, teamID uuid.UUID, email string) error {
+	var removed Member
+	team, _, err := s.updateMembers(ctx, teamID, func(members []Member) ([]Member, error) {
+		if i := findMember(members, email); i >= 0 {
+			removed = members[i]
+		}
+		return removeMember(members, email)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyMembership(ctx, notify.TypeTeamMemberRemoved, team, removed)
+	return nil
+}
+
+// UpdateMemberRole changes the role of the member with email and returns the updated
+// member. ErrLastOwner is returned if it would demote the team's only owner.
+func (s *Service) UpdateMemberRole(ctx context.Context, teamID uuid.UUID, email, role string) (Member, error) {
+	if !validMemberRoles[role] {
+		return Member{}, fmt.Errorf("%w: invalid role %q: must be one of owner, maintainer, developer, viewer", ErrInvalidTeamData, role)
+	}
+
+	_, members, err := s.updateMembers(ctx, teamID, func(members []Member) ([]Member, error) {
+		return setMemberRole(members, email, role)
+	})
+	if err != nil {
+		return Member{}, err
+	}
+
+	i := findMember(members, email)
+	return members[i], nil
+}
+
+// MemberRole returns the role of user in a team, matching the member's user ID or,
+// case-insensitively, email. It is "" when the user is not an active member, or the
+// ID does not name a team; it serves as a middleware.TeamRoleLookup.
+func (s *Service) MemberRole(ctx context.Context, teamID, user string) (types.TeamRole, error) {
+	return s.memberRole(ctx, teamID, user, `SELECT members FROM resource_management.teams WHERE id = $1 AND deleted_at IS NULL`)
+}
+
+// DeletedMemberRole is MemberRole for teams that may have been deleted, so the
+// restore route can check the role a user had in the team when it was deleted
+func (s *Service) DeletedMemberRole(ctx context.Context, teamID, user string) (types.TeamRole, error) {
+	return s.memberRole(ctx, teamID, user, `SELECT members FROM resource_management.teams WHERE id = $1`)
+}
+
+// memberRole returns the role of user in the members query selects for teamID
+func (s *Service) memberRole(ctx context.Context, teamID, user, query string) (types.TeamRole, error) {
+	id, err := uuid.Parse(teamID)
+	if err != nil {
+		return "", nil
+	}
+
+	var membersJSON string
+	err = s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.reader().QueryRow(ctx, query, id).Scan(&membersJSON)
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get team members: %w", err)
+	}
+
+	var members []Member
+	if err := json.Unmarshal([]byte(membersJSON), &members); err != nil {
+		return "", fmt.Errorf("failed to unmarshal members: %w", err)
+	}
+	for _, m := range members {
+		if (m.UserID == user || strings.EqualFold(m.Email, user)) && (m.Status == "" || m.Status == "active") {
+			return types.TeamRole(m.Role), nil
+		}
+	}
+	return "", nil
+}
+
+// updateMembers applies change to a team's members and stores the result, returning
+// the team's identity and its updated members. The team row is locked while change
+// runs, so concurrent changes cannot together remove every owner.
+func (s *Service) updateMembers(ctx context.Context, teamID uuid.UUID, change func([]Member) ([]Member, error)) (_ Team, _ []Member, err error) {
+	team := Team{ID: teamID}
+	defer func() { s.recordAudit(ctx, audit.ActionUpdate, team, err) }()
+
+	var updated []Member
+	err = s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		locked, existing, err := lockMembers(ctx, tx, teamID)
+		if err != nil {
+			return err
+		}
+		team.TenantID, team.Name = locked.TenantID, locked.Name
+
+		if updated, err = change(existing); err != nil {
+			return err
+		}
+
+		updatedJSON, err := json.Marshal(updated)
+		if err != nil {
+			return fmt.Errorf("failed to marshal members: %w", err)
+		}
+
+		update := `
+			UPDATE resource_management.teams
+			SET members = $2, member_count = $3, updated_at = $4, version = version + 1
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, update, teamID, string(updatedJSON), len(updated), time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to update members: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Team{}, nil, err
+	}
+
+	return team, updated, nil
+}
+
+// lockMembers locks a live team's row in transaction q until it ends and returns the
+// team's identity and members, or ErrTeamNotFound if there is no such team
+func lockMembers(ctx context.Context, q database.Querier, teamID uuid.UUID) (Team, []Member, error) {
+	team := Team{ID: teamID}
+	var membersJSON string
+	query := `SELECT tenant_id, name, members FROM resource_management.teams WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := q.QueryRow(ctx, query, teamID).Scan(&team.TenantID, &team.Name, &membersJSON); err != nil {
+		if err == pgx.ErrNoRows {
+			return Team{}, nil, ErrTeamNotFound
+		}
+		return Team{}, nil, fmt.Errorf("failed to lock team: %w", err)
+	}
+
+	var members []Member
+	if err := json.Unmarshal([]byte(membersJSON), &members); err != nil {
+		return Team{}, nil, fmt.Errorf("failed to unmarshal members: %w", err)
+	}
+	return team, members, nil
+}
+
+// removeMember returns members without the member with email
+func removeMember(members []Member, email string) ([]Member, error) {
+	i := findMember(members, email)
+	if i < 0 {
+		return nil, ErrMemberNotFound
+	}
+
+	updated := append(append([]Member(nil), members[:i]...), members[i+1:]...)
+	if err := checkOwners(members, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// setMemberRole returns members with the member with email given role
+func setMemberRole(members []Member, email, role string) ([]Member, error) {
+	i := findMember(members, email)
+	if i < 0 {
+		return nil, ErrMemberNotFound
+	}
+
+	updated := append([]Member(nil), members...)
+	updated[i].Role = role
+	if err := checkOwners(members, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// checkOwners returns ErrLastOwner when a change takes a team with owners to none
+func checkOwners(before, after []Member) error {
+	if ownerCount(before) > 0 && ownerCount(after) == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// ownerCount returns the number of members with the owner role
+func ownerCount(members []Member) int {
+	count := 0
+	for _, m := range members {
+		if m.Role == "owner" {
+			count++
+		}
+	}
+	return count
+}
+
+// findMember returns the index of the member with email, compared
+// case-insensitively, or -1
+func findMember(members []Member, email string) int {
+	for i, m := range members {
+		if strings.EqualFold(m.Email, email) {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateBulkMembers checks each incoming member for a valid email, a known role and
+// duplicates against both the existing members and the rest of the batch. It returns
+// the members that can be added and a per-member result in input order.
+func validateBulkMembers(existing []Member, incoming []Member, now time.Time) ([]Member, []BulkMemberResult) {
+	seen := make(map[string]bool, len(existing)+len(incoming))
+	for _, m := range existing {
+		seen[strings.ToLower(m.Email)] = true
+	}
+
+	accepted := make([]Member, 0, len(incoming))
+	results := make([]BulkMemberResult, 0, len(incoming))
+
+	for i, m := range incoming {
+		result := BulkMemberResult{Index: i, Email: m.Email}
+
+		if reason := validateMember(m); reason != "" {
+			result.Status = BulkMemberRejected
+			result.Reason = reason
+			results = append(results, result)
+			continue
+		}
+
+		key := strings.ToLower(m.Email)
+		if seen[key] {
+			result.Status = BulkMemberRejected
+			result.Reason = "duplicate member"
+			results = append(results, result)
+			continue
+		}
+		seen[key] = true
+
+		if m.UserID == "" {
+			m.UserID = m.Email
+		}
+		if m.Status == "" {
+			m.Status = "active"
+		}
+		if m.JoinedAt.IsZero() {
+			m.JoinedAt = now
+		}
+
+		accepted = append(accepted, m)
+		result.Status = BulkMemberAdded
+		results = append(results, result)
+	}
+
+	return accepted, results
+}
+
+// ensureOwners adds each of the given emails as an active owner unless they are
+// already members. Values that are not email addresses, such as the "system"
+// creator, are ignored.
+func ensureOwners(members []Member, now time.Time, emails ...string) []Member {
+	seen := make(map[string]bool, len(members)+len(emails))
+	for _, m := range members {
+		seen[strings.ToLower(m.Email)] = true
+	}
+
+	for _, email := range emails {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			continue
+		}
+
+		key := strings.ToLower(email)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		members = append(members, Member{
+			UserID:   email,
+			Email:    email,
+			Role:     "owner",
+			JoinedAt: now,
+			Status:   "active",
+		})
+	}
+
+	return members
+}
+
+// validateMember returns a rejection reason for an invalid member, or an empty string
+func validateMember(m Member) string {
+	if m.Email == "" {
+		return "email is required"
+	}
+	addr, err := mail.ParseAddress(m.Email)
+	if err != nil || addr.Address != m.Email {
+		return "invalid email format"
+	}
+	if !validMemberRoles[m.Role] {
+		return fmt.Sprintf("invalid role %q: must be one of owner, maintainer, developer, viewer", m.Role)
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package teams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBulkMembers(t *testing.T) {
+	now := time.Now().UTC()
+	existing := []Member{
+		{UserID: "existing", Email: "existing@company.com", Role: "owner", Status: "active"},
+	}
+
+	incoming := []Member{
+		{Email: "alice@company.com", Role: "developer"},
+		{Email: "", Role: "developer"},
+		{Email: "Alice <alice@company.com>", Role: "developer"},
+		{Email: "bob@company.com", Role: "admin"},
+		{Email: "Existing@company.com", Role: "viewer"},
+		{Email: "alice@company.com", Role: "viewer"},
+		{Email: "carol@company.com", Role: "maintainer", Status: "pending"},
+	}
+
+	accepted, results := validateBulkMembers(existing, incoming, now)
+
+	require.Len(t, results, len(incoming))
+	require.Len(t, accepted, 2)
+
+	assert.Equal(t, BulkMemberAdded, results[0].Status)
+	assert.Equal(t, "email is required", results[1].Reason)
+	assert.Equal(t, "invalid email format", results[2].Reason)
+	assert.Contains(t, results[3].Reason, "invalid role")
+	assert.Equal(t, "duplicate member", results[4].Reason)
+	assert.Equal(t, "duplicate member", results[5].Reason)
+	assert.Equal(t, BulkMemberAdded, results[6].Status)
+
+	for i, r := range results {
+		assert.Equal(t, i, r.Index)
+	}
+
+	// Defaults are filled in for accepted members
+	assert.Equal(t, "alice@company.com", accepted[0].UserID)
+	assert.Equal(t, "active", accepted[0].Status)
+	assert.Equal(t, now, accepted[0].JoinedAt)
+	assert.Equal(t, "pending", accepted[1].Status)
+}
+
+func TestEnsureOwners(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("adds the lead as an active owner", func(t *testing.T) {
+		members := ensureOwners([]Member{}, now, "lead@company.com", "system")
+
+		require.Len(t, members, 1)
+		assert.Equal(t, Member{
+			UserID:   "lead@company.com",
+			Email:    "lead@company.com",
+			Role:     "owner",
+			JoinedAt: now,
+			Status:   "active",
+		}, members[0])
+	})
+
+	t.Run("adds the creator alongside the lead", func(t *testing.T) {
+		members := ensureOwners(nil, now, "lead@company.com", "creator@company.com")
+
+		require.Len(t, members, 2)
+		assert.Equal(t, "creator@company.com", members[1].Email)
+		assert.Equal(t, "owner", members[1].Role)
+	})
+
+	t.Run("does not duplicate an explicitly included lead", func(t *testing.T) {
+		existing := []Member{{UserID: "lead", Email: "Lead@company.com", Role: "maintainer", Status: "active"}}
+		members := ensureOwners(existing, now, "lead@company.com", "lead@company.com")
+
+		require.Len(t, members, 1)
+		assert.Equal(t, "maintainer", members[0].Role)
+	})
+}
+
+func TestMemberChanges_KeepAnOwner(t *testing.T) {
+	members := []Member{
+		{Email: "owner@company.com", Role: "owner"},
+		{Email: "second-owner@company.com", Role: "owner"},
+		{Email: "dev@company.com", Role: "developer"},
+	}
+
+	t.Run("removing a non-last owner succeeds", func(t *testing.T) {
+		updated, err := removeMember(members, "OWNER@company.com")
+		require.NoError(t, err)
+		assert.Len(t, updated, 2)
+		assert.Equal(t, 1, ownerCount(updated))
+		assert.Len(t, members, 3, "the input is not modified")
+	})
+
+	t.Run("removing the last owner fails", func(t *testing.T) {
+		updated, err := removeMember(members, "owner@company.com")
+		require.NoError(t, err)
+
+		_, err = removeMember(updated, "second-owner@company.com")
+		assert.ErrorIs(t, err, ErrLastOwner)
+	})
+
+	t.Run("demoting the last owner fails", func(t *testing.T) {
+		updated, err := setMemberRole(members, "owner@company.com", "maintainer")
+		require.NoError(t, err)
+		assert.Equal(t, "owner", members[0].Role, "the input is not modified")
+
+		_, err = setMemberRole(updated, "second-owner@company.com", "viewer")
+		assert.ErrorIs(t, err, ErrLastOwner)
+	})
+
+	t.Run("other members can be removed and changed", func(t *testing.T) {
+		lone := []Member{{Email: "owner@company.com", Role: "owner"}, {Email: "dev@company.com", Role: "developer"}}
+
+		_, err := removeMember(lone, "dev@company.com")
+		assert.NoError(t, err)
+		updated, err := setMemberRole(lone, "dev@company.com", "owner")
+		require.NoError(t, err)
+		_, err = setMemberRole(updated, "owner@company.com", "viewer")
+		assert.NoError(t, err, "the promoted member remains an owner")
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		_, err := removeMember(members, "nobody@company.com")
+		assert.ErrorIs(t, err, ErrMemberNotFound)
+		_, err = setMemberRole(members, "nobody@company.com", "owner")
+		assert.ErrorIs(t, err, ErrMemberNotFound)
+	})
+}
@@ -0,0 +1,91 @@
+package teams
+
+import (
+	"net/http"
+
+	"github.com/aykay76/ai-idp/internal/openapi"
+)
+
+// OpenAPI describes the team API registered by the team service
+func OpenAPI(version string) *openapi.Document {
+	doc := openapi.New("Team Service", version, ErrorResponse{})
+	tags := []string{"teams"}
+
+	doc.Add("POST /api/v1/teams", openapi.Endpoint{
+		Summary:  "Create a team",
+		Tags:     tags,
+		Request:  Team{},
+		Response: Team{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusInternalServerError},
+	})
+	doc.Add("GET /api/v1/teams", openapi.Endpoint{
+		Summary: "List teams",
+		Tags:    tags,
+		Query: []openapi.Parameter{
+			openapi.Query("limit", "integer", "Page size, at most 100"),
+			openapi.Query("offset", "integer", "Number of teams to skip"),
+			openapi.Query("search", "string", "Case-insensitive match on name, display name and description"),
+			openapi.Query("include_deleted", "boolean", "Include soft-deleted teams"),
+			openapi.Query("sort", "string", "Sort by name, display_name, created_at or updated_at; a leading - sorts descending (default -created_at)"),
+		},
+		Response: ListTeamsResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	})
+	doc.Add("GET /api/v1/teams/{id}", openapi.Endpoint{
+		Summary:  "Get a team",
+		Tags:     tags,
+		Response: Team{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	})
+	doc.Add("PUT /api/v1/teams/{id}", openapi.Endpoint{
+		Summary:  "Update a team",
+		Tags:     tags,
+		Headers:  []openapi.Parameter{openapi.Header("If-Match", "ETag of the version being updated, or * for any version")},
+		Request:  Team{},
+		Response: Team{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+			http.StatusPreconditionRequired, http.StatusInternalServerError},
+	})
+	doc.Add("PATCH /api/v1/teams/{id}", openapi.Endpoint{
+		Summary:  "Change some of a team's fields",
+		Tags:     tags,
+		Headers:  []openapi.Parameter{openapi.Header("If-Match", "ETag of the version being updated, or * for any version")},
+		Request:  UpdateTeamRequest{},
+		Response: Team{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+			http.StatusPreconditionRequired, http.StatusInternalServerError},
+	})
+	doc.Add("DELETE /api/v1/teams/{id}", openapi.Endpoint{
+		Summary: "Soft-delete a team",
+		Tags:    tags,
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	})
+	doc.Add("POST /api/v1/teams/{id}/restore", openapi.Endpoint{
+		Summary:  "Restore a soft-deleted team",
+		Tags:     tags,
+		Response: Team{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	})
+	doc.Add("POST /api/v1/teams/{id}/members:bulk", openapi.Endpoint{
+		Summary:  "Add members in bulk",
+		Tags:     tags,
+		Request:  BulkMembersRequest{},
+		Response: BulkMembersReport{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	})
+	doc.Add("PUT /api/v1/teams/{id}/members/{email}", openapi.Endpoint{
+		Summary:  "Change a member's role",
+		Tags:     tags,
+		Request:  UpdateMemberRoleRequest{},
+		Response: Member{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	})
+	doc.Add("DELETE /api/v1/teams/{id}/members/{email}", openapi.Endpoint{
+		Summary: "Remove a member",
+		Tags:    tags,
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	})
+
+	return doc
+}
@@ -0,0 +1,52 @@
+package teams
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	data, err := json.Marshal(OpenAPI("1.2.3"))
+	require.NoError(t, err)
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &spec))
+
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Equal(t, "1.2.3", spec.Info.Version)
+
+	team, ok := spec.Paths["/api/v1/teams/{id}"]
+	require.True(t, ok, "the team path is described")
+	for _, method := range []string{"get", "put", "patch", "delete"} {
+		op, ok := team[method]
+		require.True(t, ok, "%s /api/v1/teams/{id} is described", method)
+		require.NotEmpty(t, op.Parameters)
+		assert.Equal(t, "id", op.Parameters[0].Name)
+		assert.Equal(t, "path", op.Parameters[0].In)
+		assert.Contains(t, op.Responses, "404")
+	}
+
+	assert.Contains(t, spec.Paths["/api/v1/teams"], "get")
+	assert.Contains(t, spec.Paths["/api/v1/teams"], "post")
+	for _, schema := range []string{"Team", "UpdateTeamRequest", "Member", "ListTeamsResponse", "PaginationMeta", "ErrorResponse"} {
+		assert.Contains(t, spec.Components.Schemas, schema)
+	}
+}
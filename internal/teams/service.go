@@ -5,11 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/audit"
 	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/events"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/notify"
+	"github.com/aykay76/ai-idp/internal/types"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // Common errors
@@ -17,34 +24,204 @@ var (
 	ErrTeamNotFound      = errors.New("team not found")
 	ErrTeamAlreadyExists = errors.New("team already exists")
 	ErrInvalidTeamData   = errors.New("invalid team data")
+	ErrMemberNotFound    = errors.New("team member not found")
+	// ErrLastOwner is returned when removing or demoting a member would leave the team
+	// without an owner
+	ErrLastOwner = errors.New("team must keep at least one owner")
+	// ErrVersionMismatch is returned when an update names a version the team has
+	// moved on from, because someone else changed it since the caller read it
+	ErrVersionMismatch = errors.New("team has been modified since it was read")
 )
 
 // Service provides team management operations
 type Service struct {
-	db *database.Pool
+	db                  *database.Pool
+	failover            *database.Failover
+	replicas            *database.ReplicaPool
+	readRetry           database.RetryPolicy
+	namePolicy          naming.Policy
+	autoOwnerMembership bool
+	audit               audit.Recorder
+	events              events.Publisher
+	notifier            notify.Notifier
 }
 
 // Compile-time check that Service implements TeamService
 var _ TeamService = (*Service)(nil)
 
+// Option configures a Service
+type Option func(*Service)
+
+// WithAutoOwnerMembership controls whether the lead and creator of a new team are
+// added as owner members when the request does not already include them
+func WithAutoOwnerMembership(enabled bool) Option {
+	return func(s *Service) {
+		s.autoOwnerMembership = enabled
+	}
+}
+
+// WithAudit records an audit event for every create, update, delete and restore.
+// Successful creates and updates are recorded in their transaction, so they fail
+// when their event cannot be stored.
+func WithAudit(r audit.Recorder) Option {
+	return func(s *Service) {
+		s.audit = r
+	}
+}
+
+// WithEvents publishes an event for every successful create, update, delete and
+// restore
+func WithEvents(p events.Publisher) Option {
+	return func(s *Service) {
+		s.events = p
+	}
+}
+
+// WithNotifier sends a notification whenever a team gains or loses a member
+func WithNotifier(n notify.Notifier) Option {
+	return func(s *Service) {
+		s.notifier = n
+	}
+}
+
+// WithNamePolicy sets how mixed-case team names are handled on create
+func WithNamePolicy(p naming.Policy) Option {
+	return func(s *Service) {
+		s.namePolicy = p
+	}
+}
+
+// WithFailover serves reads from the read replica while the primary is unavailable
+func WithFailover(f *database.Failover) Option {
+	return func(s *Service) {
+		s.failover = f
+	}
+}
+
+// WithReplicas serves list queries from read replicas. Other reads stay on the
+// primary so that they observe the service's own writes.
+func WithReplicas(r *database.ReplicaPool) Option {
+	return func(s *Service) {
+		s.replicas = r
+	}
+}
+
+// WithReadRetry retries read-only queries that fail with a transient error. Writes
+// are never retried.
+func WithReadRetry(p database.RetryPolicy) Option {
+	return func(s *Service) {
+		s.readRetry = p
+	}
+}
+
 // NewService creates a new team service
-func NewService(db *database.Pool) *Service {
-	return &Service{db: db}
+func NewService(db *database.Pool, opts ...Option) *Service {
+	s := &Service{
+		db:                  db,
+		namePolicy:          naming.PolicyPreserve,
+		autoOwnerMembership: true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// reader returns the pool queries that only read should use
+func (s *Service) reader() *database.Pool {
+	if s.failover != nil {
+		return s.failover.Reader()
+	}
+	return s.db
+}
+
+// listReader returns the pool list queries should use: a read replica when replicas
+// are configured, unless the service is in read-only mode
+func (s *Service) listReader() *database.Pool {
+	if s.replicas != nil && !s.failover.ReadOnly() {
+		return s.replicas.Reader()
+	}
+	return s.reader()
+}
+
+// auditWrite records the successful action on team through q, the transaction
+// making it, so a create or update commits only together with its audit event
+func (s *Service) auditWrite(ctx context.Context, q database.Querier, action string, team Team) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.RecordIn(ctx, q, teamEvent(ctx, action, team, nil))
+}
+
+// finishWrite completes a create or update whose success auditWrite recorded. A
+// failure rolled its transaction back, so it is recorded on its own; a success is
+// published as an event.
+func (s *Service) finishWrite(ctx context.Context, action string, team Team, err error) {
+	if err != nil {
+		if s.audit != nil {
+			_ = s.audit.Record(ctx, teamEvent(ctx, action, team, err))
+		}
+		return
+	}
+	if s.events != nil {
+		s.events.Publish(ctx, events.FromAudit(teamEvent(ctx, action, team, nil)))
+	}
+}
+
+// recordAudit records the outcome of a write to a team and, when it succeeded,
+// publishes it as an event. The recorder reports events it fails to store and
+// publishing is best effort, so neither ever fails the write itself.
+func (s *Service) recordAudit(ctx context.Context, action string, team Team, err error) {
+	if s.audit == nil && s.events == nil {
+		return
+	}
+
+	event := teamEvent(ctx, action, team, err)
+	if s.audit != nil {
+		_ = s.audit.Record(ctx, event)
+	}
+	if s.events != nil && err == nil {
+		s.events.Publish(ctx, events.FromAudit(event))
+	}
+}
+
+// teamEvent builds the audit event for action on team
+func teamEvent(ctx context.Context, action string, team Team, err error) types.AuditEvent {
+	resource := types.ResourceReference{Kind: audit.KindTeam, Name: team.Name, UID: team.ID.String()}
+	if team.TenantID != uuid.Nil {
+		resource.Namespace = team.TenantID.String()
+	}
+	return audit.NewEvent(ctx, action, resource, err)
+}
+
+// notifyMembership notifies that member joined or left team. Sending is best effort
+// and never fails the change.
+func (s *Service) notifyMembership(ctx context.Context, typ string, team Team, member Member) {
+	if s.notifier == nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, notify.New(typ, team.TenantID, notify.TeamMembershipChange{
+		TeamID:   team.ID.String(),
+		TeamName: team.Name,
+		Email:    member.Email,
+		Role:     member.Role,
+	}))
 }
 
 // Team represents a team in the platform
 type Team struct {
 	ID                 uuid.UUID              `json:"id" db:"id"`
 	TenantID           uuid.UUID              `json:"tenant_id" db:"tenant_id"`
-	Name               string                 `json:"name" db:"name"`
-	DisplayName        string                 `json:"display_name" db:"display_name"`
+	Name               string                 `json:"name" db:"name" validate:"required,max=63"`
+	DisplayName        string                 `json:"display_name" db:"display_name" validate:"max=255"`
 	Description        *string                `json:"description,omitempty" db:"description"`
-	LeadEmail          string                 `json:"lead_email" db:"lead_email"`
+	LeadEmail          string                 `json:"lead_email" db:"lead_email" validate:"required,email"`
 	Members            []Member               `json:"members" db:"members"`
 	Contacts           map[string]interface{} `json:"contacts" db:"contacts"`
 	Department         *string                `json:"department,omitempty" db:"department"`
 	Organization       *string                `json:"organization,omitempty" db:"organization"`
-	ManagerEmail       *string                `json:"manager_email,omitempty" db:"manager_email"`
+	ManagerEmail       *string                `json:"manager_email,omitempty" db:"manager_email" validate:"omitempty,email"`
 	OwnedApplications  []string               `json:"owned_applications" db:"owned_applications"`
 	OwnedDomains       []string               `json:"owned_domains" db:"owned_domains"`
 	OwnedRepositories  []string               `json:"owned_repositories" db:"owned_repositories"`
@@ -57,6 +234,11 @@ type Team struct {
 	UpdatedAt          time.Time              `json:"updated_at" db:"updated_at"`
 	CreatedBy          string                 `json:"created_by" db:"created_by"`
 	UpdatedBy          *string                `json:"updated_by,omitempty" db:"updated_by"`
+	// Version is bumped on every change. UpdateTeam only applies an update whose
+	// Version matches, or is 0 to update unconditionally.
+	Version int `json:"version" db:"version"`
+	// DeletedAt is set on soft-deleted teams, which are only listed on request
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 // Member represents a team member
@@ -69,7 +251,9 @@ type Member struct {
 }
 
 // CreateTeam creates a new team
-func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
+func (s *Service) CreateTeam(ctx context.Context, team Team) (_ Team, err error) {
+	defer func() { s.finishWrite(ctx, audit.ActionCreate, team, err) }()
+
 	// Set default values
 	if team.ID == uuid.Nil {
 		team.ID = uuid.New()
@@ -83,6 +267,7 @@ func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
 
 	team.CreatedAt = time.Now().UTC()
 	team.UpdatedAt = team.CreatedAt
+	team.Version = 1
 
 	// TODO: Extract user from auth context
 	if team.CreatedBy == "" {
@@ -93,6 +278,11 @@ func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
 	if team.Name == "" {
 		return Team{}, fmt.Errorf("%w: name is required", ErrInvalidTeamData)
 	}
+	name, err := s.namePolicy.Apply(team.Name)
+	if err != nil {
+		return Team{}, fmt.Errorf("%w: %w", ErrInvalidTeamData, err)
+	}
+	team.Name = name
 	if team.DisplayName == "" {
 		team.DisplayName = team.Name
 	}
@@ -104,6 +294,10 @@ func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
 	if team.Members == nil {
 		team.Members = []Member{}
 	}
+	if s.autoOwnerMembership {
+		team.Members = ensureOwners(team.Members, team.CreatedAt, team.LeadEmail, team.CreatedBy)
+	}
+	team.MemberCount = len(team.Members)
 	if team.Contacts == nil {
 		team.Contacts = make(map[string]interface{})
 	}
@@ -123,66 +317,29 @@ func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
 		team.BudgetConfig = make(map[string]interface{})
 	}
 
-	// Convert complex fields to JSON
-	membersJSON, err := json.Marshal(team.Members)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal members: %w", err)
-	}
-
-	contactsJSON, err := json.Marshal(team.Contacts)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal contacts: %w", err)
-	}
-
-	ownedAppsJSON, err := json.Marshal(team.OwnedApplications)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal owned applications: %w", err)
-	}
-
-	ownedDomainsJSON, err := json.Marshal(team.OwnedDomains)
+	fields, err := marshalTeamFields(team)
 	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal owned domains: %w", err)
+		return Team{}, err
 	}
 
-	ownedReposJSON, err := json.Marshal(team.OwnedRepositories)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal owned repositories: %w", err)
-	}
-
-	policiesJSON, err := json.Marshal(team.Policies)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal policies: %w", err)
-	}
-
-	budgetConfigJSON, err := json.Marshal(team.BudgetConfig)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal budget config: %w", err)
-	}
-
-	// Insert team into database
-	query := `
-		INSERT INTO resource_management.teams (
-			id, tenant_id, name, display_name, description, lead_email, members,
-			contacts, department, organization, manager_email, owned_applications,
-			owned_domains, owned_repositories, policies, budget_config,
-			member_count, active_applications, monthly_spend, created_at,
-			updated_at, created_by, updated_by
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23
-		)
-	`
-
-	_, err = s.db.Exec(ctx, query,
-		team.ID, team.TenantID, team.Name, team.DisplayName, team.Description,
-		team.LeadEmail, string(membersJSON), string(contactsJSON), team.Department,
-		team.Organization, team.ManagerEmail, string(ownedAppsJSON),
-		string(ownedDomainsJSON), string(ownedReposJSON), string(policiesJSON),
-		string(budgetConfigJSON), team.MemberCount, team.ActiveApplications,
-		team.MonthlySpend, team.CreatedAt, team.UpdatedAt, team.CreatedBy, team.UpdatedBy,
-	)
+	// The quota check locks the tenant, so concurrent creates cannot all pass it
+	err = s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		if err := database.CheckQuota(ctx, tx, team.TenantID, database.LimitTeams, database.CountTeamsQuery); err != nil {
+			return err
+		}
+		if err := insertTeam(ctx, tx, team, fields); err != nil {
+			return err
+		}
+		return s.auditWrite(ctx, tx, audit.ActionCreate, team)
+	})
 
 	if err != nil {
+		if errors.Is(err, database.ErrQuotaExceeded) {
+			return Team{}, err
+		}
+		if isUniqueViolation(err) {
+			return Team{}, fmt.Errorf("%w: %s", ErrTeamAlreadyExists, team.Name)
+		}
 		return Team{}, fmt.Errorf("failed to create team: %w", err)
 	}
 
@@ -199,19 +356,22 @@ func (s *Service) GetTeam(ctx context.Context, teamID uuid.UUID) (Team, error) {
 			   contacts, department, organization, manager_email, owned_applications,
 			   owned_domains, owned_repositories, policies, budget_config,
 			   member_count, active_applications, monthly_spend, created_at,
-			   updated_at, created_by, updated_by
+			   updated_at, created_by, updated_by, deleted_at, version
 		FROM resource_management.teams
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	err := s.db.QueryRow(ctx, query, teamID).Scan(
-		&team.ID, &team.TenantID, &team.Name, &team.DisplayName, &team.Description,
-		&team.LeadEmail, &membersJSON, &contactsJSON, &team.Department,
-		&team.Organization, &team.ManagerEmail, &ownedAppsJSON,
-		&ownedDomainsJSON, &ownedReposJSON, &policiesJSON,
-		&budgetConfigJSON, &team.MemberCount, &team.ActiveApplications,
-		&team.MonthlySpend, &team.CreatedAt, &team.UpdatedAt, &team.CreatedBy, &team.UpdatedBy,
-	)
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.reader().QueryRow(ctx, query, teamID).Scan(
+			&team.ID, &team.TenantID, &team.Name, &team.DisplayName, &team.Description,
+			&team.LeadEmail, &membersJSON, &contactsJSON, &team.Department,
+			&team.Organization, &team.ManagerEmail, &ownedAppsJSON,
+			&ownedDomainsJSON, &ownedReposJSON, &policiesJSON,
+			&budgetConfigJSON, &team.MemberCount, &team.ActiveApplications,
+			&team.MonthlySpend, &team.CreatedAt, &team.UpdatedAt, &team.CreatedBy, &team.UpdatedBy,
+			&team.DeletedAt, &team.Version,
+		)
+	})
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -252,36 +412,101 @@ func (s *Service) GetTeam(ctx context.Context, teamID uuid.UUID) (Team, error) {
 	return team, nil
 }
 
+// SortFields are the columns teams can be listed in order of
+var SortFields = []string{"name", "display_name", "created_at", "updated_at"}
+
+// ListTeamsRequest represents a request to list teams
+type ListTeamsRequest struct {
+	CreatedBy string
+	UpdatedBy string
+	// Search matches teams whose name, display name or description contains the
+	// term, ignoring case
+	Search string
+	// IncludeDeleted also lists soft-deleted teams
+	IncludeDeleted bool
+	// Sort orders the teams by one of SortFields; the zero value is
+	// database.DefaultSort, the only order a cursor can continue
+	Sort database.Sort
+	// After continues a keyset-paginated listing after the cursor; Offset is ignored
+	After  *database.Cursor
+	Limit  int
+	Offset int
+}
+
 // ListTeams retrieves a paginated list of teams
-func (s *Service) ListTeams(ctx context.Context, limit, offset int) ([]Team, int, error) {
+func (s *Service) ListTeams(ctx context.Context, req ListTeamsRequest) ([]Team, int, error) {
 	var teams []Team
 	var totalCount int
 
+	sort := req.Sort
+	if sort.Column == "" {
+		sort = database.DefaultSort
+	}
+	if !slices.Contains(SortFields, sort.Column) {
+		return nil, 0, fmt.Errorf("%w %q", database.ErrInvalidSort, sort)
+	}
+	if req.After != nil && sort != database.DefaultSort {
+		return nil, 0, fmt.Errorf("%w: a cursor can only continue a listing in the default order", database.ErrInvalidSort)
+	}
+
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM resource_management.teams`
-	err := s.db.QueryRow(ctx, countQuery).Scan(&totalCount)
+	countQuery, countArgs := req.filter(database.NewQueryBuilder(`SELECT COUNT(*) FROM resource_management.teams`)).Build()
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.listReader().QueryRow(ctx, countQuery, countArgs...).Scan(&totalCount)
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get teams count: %w", err)
 	}
 
-	// Get teams with pagination
-	query := `
+	// Get teams with pagination, by keyset after a cursor and otherwise by offset
+	qb := req.filter(database.NewQueryBuilder(`
 		SELECT id, tenant_id, name, display_name, description, lead_email, members,
 			   contacts, department, organization, manager_email, owned_applications,
 			   owned_domains, owned_repositories, policies, budget_config,
 			   member_count, active_applications, monthly_spend, created_at,
-			   updated_at, created_by, updated_by
+			   updated_at, created_by, updated_by, deleted_at, version
 		FROM resource_management.teams
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+	`))
+	qb.AddAfterCursor(req.After)
+	qb.AddSort(sort)
+	qb.AddLimit(req.Limit)
+	if req.After == nil {
+		qb.AddOffset(req.Offset)
+	}
+	query, args := qb.Build()
+
+	err = s.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		teams, err = s.queryTeams(ctx, query, args)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return teams, totalCount, nil
+}
+
+// filter adds the request's filter conditions to qb
+func (req ListTeamsRequest) filter(qb *database.QueryBuilder) *database.QueryBuilder {
+	qb.AddOptionalEquals("created_by", req.CreatedBy)
+	qb.AddOptionalEquals("updated_by", req.UpdatedBy)
+	qb.AddSearch(req.Search, "name", "display_name", "description")
+	if !req.IncludeDeleted {
+		qb.AddStaticCondition("deleted_at IS NULL")
+	}
+	return qb
+}
 
-	rows, err := s.db.Query(ctx, query, limit, offset)
+// queryTeams runs a team list query and scans its rows
+func (s *Service) queryTeams(ctx context.Context, query string, args []interface{}) ([]Team, error) {
+	rows, err := s.listReader().Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query teams: %w", err)
+		return nil, fmt.Errorf("failed to query teams: %w", err)
 	}
 	defer rows.Close()
 
+	var teams []Team
 	for rows.Next() {
 		var team Team
 		var membersJSON, contactsJSON, ownedAppsJSON, ownedDomainsJSON, ownedReposJSON, policiesJSON, budgetConfigJSON string
@@ -293,52 +518,56 @@ func (s *Service) ListTeams(ctx context.Context, limit, offset int) ([]Team, int
 			&ownedDomainsJSON, &ownedReposJSON, &policiesJSON,
 			&budgetConfigJSON, &team.MemberCount, &team.ActiveApplications,
 			&team.MonthlySpend, &team.CreatedAt, &team.UpdatedAt, &team.CreatedBy, &team.UpdatedBy,
+			&team.DeletedAt, &team.Version,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan team row: %w", err)
+			return nil, fmt.Errorf("failed to scan team row: %w", err)
 		}
 
 		// Parse JSON fields
 		if err := json.Unmarshal([]byte(membersJSON), &team.Members); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal members: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal members: %w", err)
 		}
 
 		if err := json.Unmarshal([]byte(contactsJSON), &team.Contacts); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal contacts: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal contacts: %w", err)
 		}
 
 		if err := json.Unmarshal([]byte(ownedAppsJSON), &team.OwnedApplications); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal owned applications: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal owned applications: %w", err)
 		}
 
 		if err := json.Unmarshal([]byte(ownedDomainsJSON), &team.OwnedDomains); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal owned domains: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal owned domains: %w", err)
 		}
 
 		if err := json.Unmarshal([]byte(ownedReposJSON), &team.OwnedRepositories); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal owned repositories: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal owned repositories: %w", err)
 		}
 
 		if err := json.Unmarshal([]byte(policiesJSON), &team.Policies); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal policies: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal policies: %w", err)
 		}
 
 		if err := json.Unmarshal([]byte(budgetConfigJSON), &team.BudgetConfig); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal budget config: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal budget config: %w", err)
 		}
 
 		teams = append(teams, team)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating team rows: %w", err)
+		return nil, fmt.Errorf("error iterating team rows: %w", err)
 	}
 
-	return teams, totalCount, nil
+	return teams, nil
 }
 
-// UpdateTeam updates an existing team
-func (s *Service) UpdateTeam(ctx context.Context, team Team) (Team, error) {
+// UpdateTeam updates an existing team. ErrLastOwner is returned if its members would
+// leave a team that has owners without one.
+func (s *Service) UpdateTeam(ctx context.Context, team Team) (_ Team, err error) {
+	defer func() { s.finishWrite(ctx, audit.ActionUpdate, team, err) }()
+
 	// Validate required fields
 	if team.ID == uuid.Nil {
 		return Team{}, fmt.Errorf("%w: team ID is required", ErrInvalidTeamData)
@@ -359,43 +588,114 @@ func (s *Service) UpdateTeam(ctx context.Context, team Team) (Team, error) {
 		team.UpdatedBy = &updatedBy
 	}
 
-	// Convert complex fields to JSON
-	membersJSON, err := json.Marshal(team.Members)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal members: %w", err)
-	}
+	team.MemberCount = len(team.Members)
 
-	contactsJSON, err := json.Marshal(team.Contacts)
+	fields, err := marshalTeamFields(team)
 	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal contacts: %w", err)
+		return Team{}, err
 	}
 
-	ownedAppsJSON, err := json.Marshal(team.OwnedApplications)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal owned applications: %w", err)
-	}
+	// The team is locked while its owners are checked, as for the member operations,
+	// so replacing the members cannot leave it without an owner
+	err = s.db.WithTransaction(ctx, func(tx *database.Transaction) error {
+		_, existing, err := lockMembers(ctx, tx, team.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkOwners(existing, team.Members); err != nil {
+			return err
+		}
 
-	ownedDomainsJSON, err := json.Marshal(team.OwnedDomains)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal owned domains: %w", err)
-	}
+		version, err := updateTeam(ctx, tx, team, fields)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return updateMissed(ctx, tx, team.ID)
+		}
+		if err != nil {
+			return err
+		}
+		team.Version = version
+		return s.auditWrite(ctx, tx, audit.ActionUpdate, team)
+	})
 
-	ownedReposJSON, err := json.Marshal(team.OwnedRepositories)
 	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal owned repositories: %w", err)
+		if errors.Is(err, ErrTeamNotFound) || errors.Is(err, ErrVersionMismatch) || errors.Is(err, ErrLastOwner) {
+			return Team{}, err
+		}
+		if isUniqueViolation(err) {
+			return Team{}, fmt.Errorf("%w: %s", ErrTeamAlreadyExists, team.Name)
+		}
+		return Team{}, fmt.Errorf("failed to update team: %w", err)
 	}
 
-	policiesJSON, err := json.Marshal(team.Policies)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal policies: %w", err)
-	}
+	return team, nil
+}
 
-	budgetConfigJSON, err := json.Marshal(team.BudgetConfig)
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to marshal budget config: %w", err)
+// teamFields are a team's JSONB columns, encoded for writing
+type teamFields struct {
+	members           string
+	contacts          string
+	ownedApplications string
+	ownedDomains      string
+	ownedRepositories string
+	policies          string
+	budgetConfig      string
+}
+
+// marshalTeamFields encodes the team's JSONB columns
+func marshalTeamFields(team Team) (teamFields, error) {
+	var fields teamFields
+	for _, field := range []struct {
+		name  string
+		value interface{}
+		dest  *string
+	}{
+		{"members", team.Members, &fields.members},
+		{"contacts", team.Contacts, &fields.contacts},
+		{"owned applications", team.OwnedApplications, &fields.ownedApplications},
+		{"owned domains", team.OwnedDomains, &fields.ownedDomains},
+		{"owned repositories", team.OwnedRepositories, &fields.ownedRepositories},
+		{"policies", team.Policies, &fields.policies},
+		{"budget config", team.BudgetConfig, &fields.budgetConfig},
+	} {
+		data, err := json.Marshal(field.value)
+		if err != nil {
+			return teamFields{}, fmt.Errorf("failed to marshal %s: %w", field.name, err)
+		}
+		*field.dest = string(data)
 	}
+	return fields, nil
+}
 
-	// Update team in database
+// insertTeam inserts a new team with q, which may be a pool or a transaction
+func insertTeam(ctx context.Context, q database.Querier, team Team, fields teamFields) error {
+	query := `
+		INSERT INTO resource_management.teams (
+			id, tenant_id, name, display_name, description, lead_email, members,
+			contacts, department, organization, manager_email, owned_applications,
+			owned_domains, owned_repositories, policies, budget_config,
+			member_count, active_applications, monthly_spend, created_at,
+			updated_at, created_by, updated_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			$17, $18, $19, $20, $21, $22, $23
+		)
+	`
+
+	_, err := q.Exec(ctx, query,
+		team.ID, team.TenantID, team.Name, team.DisplayName, team.Description,
+		team.LeadEmail, fields.members, fields.contacts, team.Department,
+		team.Organization, team.ManagerEmail, fields.ownedApplications,
+		fields.ownedDomains, fields.ownedRepositories, fields.policies,
+		fields.budgetConfig, team.MemberCount, team.ActiveApplications,
+		team.MonthlySpend, team.CreatedAt, team.UpdatedAt, team.CreatedBy, team.UpdatedBy,
+	)
+	return err
+}
+
+// updateTeam writes team over the stored team with q, which may be a pool or a
+// transaction, and returns its new version. pgx.ErrNoRows is returned if no live team
+// has the ID or, when team.Version is set, the team is at another version.
+func updateTeam(ctx context.Context, q database.Querier, team Team, fields teamFields) (int, error) {
 	query := `
 		UPDATE resource_management.teams SET
 			name = $2, display_name = $3, description = $4, lead_email = $5,
@@ -403,42 +703,177 @@ func (s *Service) UpdateTeam(ctx context.Context, team Team) (Team, error) {
 			manager_email = $10, owned_applications = $11, owned_domains = $12,
 			owned_repositories = $13, policies = $14, budget_config = $15,
 			member_count = $16, active_applications = $17, monthly_spend = $18,
-			updated_at = $19, updated_by = $20
-		WHERE id = $1
+			updated_at = $19, updated_by = $20, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($21 = 0 OR version = $21)
+		RETURNING version
 	`
 
-	result, err := s.db.Exec(ctx, query,
+	var version int
+	err := q.QueryRow(ctx, query,
 		team.ID, team.Name, team.DisplayName, team.Description, team.LeadEmail,
-		string(membersJSON), string(contactsJSON), team.Department, team.Organization,
-		team.ManagerEmail, string(ownedAppsJSON), string(ownedDomainsJSON),
-		string(ownedReposJSON), string(policiesJSON), string(budgetConfigJSON),
+		fields.members, fields.contacts, team.Department, team.Organization,
+		team.ManagerEmail, fields.ownedApplications, fields.ownedDomains,
+		fields.ownedRepositories, fields.policies, fields.budgetConfig,
 		team.MemberCount, team.ActiveApplications, team.MonthlySpend,
-		team.UpdatedAt, team.UpdatedBy,
-	)
+		team.UpdatedAt, team.UpdatedBy, team.Version,
+	).Scan(&version)
+	return version, err
+}
 
-	if err != nil {
-		return Team{}, fmt.Errorf("failed to update team: %w", err)
+// UpdateTeamRequest is a partial update for PatchTeam: only the fields present are
+// changed. Members are changed through the member operations instead.
+type UpdateTeamRequest struct {
+	Name              *string                 `json:"name,omitempty" validate:"omitempty,min=1,max=63"`
+	DisplayName       *string                 `json:"display_name,omitempty" validate:"omitempty,max=255"`
+	Description       *string                 `json:"description,omitempty"`
+	LeadEmail         *string                 `json:"lead_email,omitempty" validate:"omitempty,email"`
+	Contacts          *map[string]interface{} `json:"contacts,omitempty"`
+	Department        *string                 `json:"department,omitempty"`
+	Organization      *string                 `json:"organization,omitempty"`
+	ManagerEmail      *string                 `json:"manager_email,omitempty" validate:"omitempty,email"`
+	OwnedApplications *[]string               `json:"owned_applications,omitempty"`
+	OwnedDomains      *[]string               `json:"owned_domains,omitempty"`
+	OwnedRepositories *[]string               `json:"owned_repositories,omitempty"`
+	Policies          *map[string]interface{} `json:"policies,omitempty"`
+	BudgetConfig      *map[string]interface{} `json:"budget_config,omitempty"`
+	// IfMatch applies the patch only if the team is still at this version; 0 patches
+	// whatever the version. Handlers set it from the If-Match header.
+	IfMatch int `json:"-"`
+}
+
+// patchAttempts bounds how often an unconditional patch is retried when another
+// write lands between reading the team and updating it
+const patchAttempts = 3
+
+// PatchTeam changes only the fields set in req, leaving the rest of the team as it is
+func (s *Service) PatchTeam(ctx context.Context, teamID uuid.UUID, req UpdateTeamRequest) (Team, error) {
+	for attempt := 1; ; attempt++ {
+		team, err := s.GetTeam(ctx, teamID)
+		if err != nil {
+			return Team{}, err
+		}
+		if req.IfMatch != 0 && team.Version != req.IfMatch {
+			return Team{}, ErrVersionMismatch
+		}
+
+		// Update against the version just read, so a concurrent write is not lost
+		req.applyTo(&team)
+		team.UpdatedBy = nil
+
+		updated, err := s.UpdateTeam(ctx, team)
+		if errors.Is(err, ErrVersionMismatch) && req.IfMatch == 0 && attempt < patchAttempts {
+			continue
+		}
+		return updated, err
 	}
+}
 
-	if result.RowsAffected() == 0 {
-		return Team{}, ErrTeamNotFound
+// applyTo applies the requested changes to team
+func (r *UpdateTeamRequest) applyTo(team *Team) {
+	if r.Name != nil {
+		team.Name = *r.Name
+	}
+	if r.DisplayName != nil {
+		team.DisplayName = *r.DisplayName
+	}
+	if r.Description != nil {
+		team.Description = r.Description
+	}
+	if r.LeadEmail != nil {
+		team.LeadEmail = *r.LeadEmail
+	}
+	if r.Contacts != nil {
+		team.Contacts = *r.Contacts
+	}
+	if r.Department != nil {
+		team.Department = r.Department
+	}
+	if r.Organization != nil {
+		team.Organization = r.Organization
+	}
+	if r.ManagerEmail != nil {
+		team.ManagerEmail = r.ManagerEmail
 	}
+	if r.OwnedApplications != nil {
+		team.OwnedApplications = *r.OwnedApplications
+	}
+	if r.OwnedDomains != nil {
+		team.OwnedDomains = *r.OwnedDomains
+	}
+	if r.OwnedRepositories != nil {
+		team.OwnedRepositories = *r.OwnedRepositories
+	}
+	if r.Policies != nil {
+		team.Policies = *r.Policies
+	}
+	if r.BudgetConfig != nil {
+		team.BudgetConfig = *r.BudgetConfig
+	}
+}
 
-	return team, nil
+// updateMissed explains a conditional update that matched no row: the team either
+// does not exist or is at a different version
+func updateMissed(ctx context.Context, q database.Querier, teamID uuid.UUID) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM resource_management.teams WHERE id = $1 AND deleted_at IS NULL)`
+	if err := q.QueryRow(ctx, query, teamID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to update team: %w", err)
+	}
+	if exists {
+		return ErrVersionMismatch
+	}
+	return ErrTeamNotFound
 }
 
-// DeleteTeam deletes a team by ID
-func (s *Service) DeleteTeam(ctx context.Context, teamID uuid.UUID) error {
-	query := `DELETE FROM resource_management.teams WHERE id = $1`
+// DeleteTeam soft-deletes a team by ID. The team is hidden from reads and updates but
+// keeps its row, including its name, until restored with RestoreTeam.
+func (s *Service) DeleteTeam(ctx context.Context, teamID uuid.UUID) (err error) {
+	team := Team{ID: teamID}
+	defer func() { s.recordAudit(ctx, audit.ActionDelete, team, err) }()
+
+	query := `
+		UPDATE resource_management.teams SET deleted_at = NOW(), updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING tenant_id, name
+	`
 
-	result, err := s.db.Exec(ctx, query, teamID)
+	err = s.db.QueryRow(ctx, query, teamID).Scan(&team.TenantID, &team.Name)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTeamNotFound
+		}
 		return fmt.Errorf("failed to delete team: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return ErrTeamNotFound
+	return nil
+}
+
+// RestoreTeam restores a soft-deleted team. ErrTeamNotFound is returned if there is no
+// deleted team with the ID.
+func (s *Service) RestoreTeam(ctx context.Context, teamID uuid.UUID) (_ Team, err error) {
+	team := Team{ID: teamID}
+	defer func() { s.recordAudit(ctx, audit.ActionRestore, team, err) }()
+
+	query := `
+		UPDATE resource_management.teams SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING tenant_id, name
+	`
+
+	err = s.db.QueryRow(ctx, query, teamID).Scan(&team.TenantID, &team.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Team{}, ErrTeamNotFound
+		}
+		return Team{}, fmt.Errorf("failed to restore team: %w", err)
 	}
 
-	return nil
+	return s.GetTeam(ctx, teamID)
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation, which for
+// teams means another team in the tenant, possibly soft-deleted, has the name
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
 }
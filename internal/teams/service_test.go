@@ -2,11 +2,19 @@ package teams
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/aykay76/ai-idp/internal/audit"
 	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/events"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/naming"
+	"github.com/aykay76/ai-idp/internal/notify"
 	"github.com/aykay76/ai-idp/internal/testutils"
+	"github.com/aykay76/ai-idp/internal/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -60,9 +68,12 @@ func TestTeamService_CreateTeam(t *testing.T) {
 		assert.Equal(t, "test-user", result.CreatedBy)
 		assert.WithinDuration(t, time.Now(), result.CreatedAt, time.Minute)
 		assert.WithinDuration(t, time.Now(), result.UpdatedAt, time.Minute)
-		assert.Equal(t, 0, result.MemberCount)
+		assert.Equal(t, 1, result.MemberCount)
 		assert.Equal(t, 0, result.ActiveApplications)
-		assert.Empty(t, result.Members)
+		require.Len(t, result.Members, 1)
+		assert.Equal(t, "platform-lead@company.com", result.Members[0].Email)
+		assert.Equal(t, "owner", result.Members[0].Role)
+		assert.Equal(t, "active", result.Members[0].Status)
 		assert.NotNil(t, result.Contacts)
 		assert.NotNil(t, result.OwnedApplications)
 		assert.NotNil(t, result.OwnedDomains)
@@ -71,6 +82,59 @@ func TestTeamService_CreateTeam(t *testing.T) {
 		assert.NotNil(t, result.BudgetConfig)
 	})
 
+	t.Run("explicitly included lead is not duplicated", func(t *testing.T) {
+		team := Team{
+			TenantID:  tenant.ID,
+			Name:      "lead-member-team",
+			LeadEmail: "lead-member@company.com",
+			Members: []Member{
+				{UserID: "lead-member", Email: "Lead-Member@company.com", Role: "maintainer", Status: "active"},
+			},
+			CreatedBy: "creator@company.com",
+		}
+
+		result, err := service.CreateTeam(ctx, team)
+		require.NoError(t, err)
+
+		require.Len(t, result.Members, 2)
+		assert.Equal(t, "maintainer", result.Members[0].Role, "existing membership is kept as provided")
+		assert.Equal(t, "creator@company.com", result.Members[1].Email)
+		assert.Equal(t, "owner", result.Members[1].Role)
+		assert.Equal(t, 2, result.MemberCount)
+
+		stored, err := service.GetTeam(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Len(t, stored.Members, 2)
+		assert.Equal(t, 2, stored.MemberCount)
+	})
+
+	t.Run("auto owner membership disabled", func(t *testing.T) {
+		service := NewService(pool, WithAutoOwnerMembership(false))
+
+		result, err := service.CreateTeam(ctx, Team{
+			TenantID:  tenant.ID,
+			Name:      "no-owner-team",
+			LeadEmail: "no-owner@company.com",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.Members)
+		assert.Equal(t, 0, result.MemberCount)
+	})
+
+	t.Run("duplicate name in tenant", func(t *testing.T) {
+		team := Team{
+			TenantID:  tenant.ID,
+			Name:      "duplicate-team",
+			LeadEmail: "duplicate-lead@company.com",
+		}
+
+		_, err := service.CreateTeam(ctx, team)
+		require.NoError(t, err)
+
+		_, err = service.CreateTeam(ctx, team)
+		assert.ErrorIs(t, err, ErrTeamAlreadyExists)
+	})
+
 	t.Run("missing required fields", func(t *testing.T) {
 		// Test missing name
 		team := Team{
@@ -155,6 +219,162 @@ func TestTeamService_CreateTeam(t *testing.T) {
 	})
 }
 
+// failingRecorder fails every event recorded in a transaction, after running check
+// in it, and keeps the events recorded on their own
+type failingRecorder struct {
+	err      error
+	check    func(ctx context.Context, q database.Querier, event types.AuditEvent)
+	failures []types.AuditEvent
+}
+
+func (r *failingRecorder) Record(ctx context.Context, event types.AuditEvent) error {
+	r.failures = append(r.failures, event)
+	return nil
+}
+
+func (r *failingRecorder) RecordIn(ctx context.Context, q database.Querier, event types.AuditEvent) error {
+	if r.check != nil {
+		r.check(ctx, q, event)
+	}
+	return r.err
+}
+
+func TestTeamService_WriteRollsBack(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	auditErr := errors.New("audit store unavailable")
+
+	t.Run("a failed create leaves no team", func(t *testing.T) {
+		recorder := &failingRecorder{err: auditErr, check: func(ctx context.Context, q database.Querier, event types.AuditEvent) {
+			// The row is visible inside the transaction before it fails
+			var name string
+			err := q.QueryRow(ctx, `SELECT name FROM resource_management.teams WHERE id = $1`, event.Spec.Resource.UID).Scan(&name)
+			require.NoError(t, err)
+			assert.Equal(t, "rolled-back", name)
+		}}
+		service := NewService(pool, WithAudit(recorder))
+
+		team := Team{ID: uuid.New(), TenantID: tenant.ID, Name: "rolled-back", LeadEmail: "lead@company.com"}
+		_, err := service.CreateTeam(ctx, team)
+		assert.ErrorIs(t, err, auditErr)
+
+		_, err = service.GetTeam(ctx, team.ID)
+		assert.ErrorIs(t, err, ErrTeamNotFound)
+		require.Len(t, recorder.failures, 1)
+		assert.Equal(t, types.AuditResultFailure, recorder.failures[0].Spec.Result)
+	})
+
+	t.Run("a failed update leaves the team as it was", func(t *testing.T) {
+		created, err := NewService(pool).CreateTeam(ctx, Team{TenantID: tenant.ID, Name: "kept", DisplayName: "Kept", LeadEmail: "lead@company.com"})
+		require.NoError(t, err)
+
+		recorder := &failingRecorder{err: auditErr}
+		service := NewService(pool, WithAudit(recorder))
+
+		changed := created
+		changed.DisplayName = "Changed"
+		_, err = service.UpdateTeam(ctx, changed)
+		assert.ErrorIs(t, err, auditErr)
+		require.Len(t, recorder.failures, 1)
+		assert.Equal(t, audit.ActionUpdate, recorder.failures[0].Spec.Action)
+
+		stored, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Kept", stored.DisplayName)
+		assert.Equal(t, created.Version, stored.Version)
+	})
+}
+
+func TestTeamService_CreateTeam_Quota(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+
+	tenantManager := database.NewTenantManager(pool)
+	tenant, err := tenantManager.CreateTenant(ctx, &database.CreateTenantRequest{
+		Name:           "quota-tenant",
+		DisplayName:    "Quota Tenant",
+		ResourceLimits: map[string]interface{}{database.LimitTeams: 2},
+	})
+	require.NoError(t, err)
+
+	create := func(name string) (Team, error) {
+		return service.CreateTeam(ctx, Team{
+			TenantID:  tenant.ID,
+			Name:      name,
+			LeadEmail: "lead@company.com",
+			CreatedBy: "test-user",
+		})
+	}
+
+	first, err := create("quota-team-1")
+	require.NoError(t, err)
+	_, err = create("quota-team-2")
+	require.NoError(t, err)
+
+	t.Run("creating past the limit is rejected", func(t *testing.T) {
+		_, err := create("quota-team-3")
+		assert.ErrorIs(t, err, database.ErrQuotaExceeded)
+	})
+
+	t.Run("deleted teams do not count", func(t *testing.T) {
+		require.NoError(t, service.DeleteTeam(ctx, first.ID))
+
+		_, err := create("quota-team-3")
+		assert.NoError(t, err)
+	})
+}
+
+func TestTeamService_NamePolicy(t *testing.T) {
+	team := Team{
+		Name:      "Platform-Team",
+		LeadEmail: "platform-lead@company.com",
+		CreatedBy: "test-user",
+	}
+
+	t.Run("reject refuses mixed-case names", func(t *testing.T) {
+		// Rejected before any query, so no database is needed
+		service := NewService(nil, WithNamePolicy(naming.PolicyReject))
+
+		_, err := service.CreateTeam(context.Background(), team)
+		assert.ErrorIs(t, err, ErrInvalidTeamData)
+		assert.ErrorIs(t, err, naming.ErrNotLowercase)
+	})
+
+	t.Run("normalize lowercases names", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping integration test in short mode")
+		}
+
+		ctx := context.Background()
+		pool, cleanup := testutils.SetupTestDB(t, ctx)
+		defer cleanup()
+
+		tenant := testutils.SetupTestTenant(t, ctx, pool)
+		service := NewService(pool, WithNamePolicy(naming.PolicyNormalize))
+
+		normalized := team
+		normalized.TenantID = tenant.ID
+		result, err := service.CreateTeam(ctx, normalized)
+		require.NoError(t, err)
+		assert.Equal(t, "platform-team", result.Name)
+		assert.Equal(t, "platform-team", result.DisplayName, "display name defaults to the stored name")
+	})
+}
+
 func TestTeamService_GetTeam(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -228,7 +448,7 @@ func TestTeamService_ListTeams(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("empty list", func(t *testing.T) {
-		teams, total, err := service.ListTeams(ctx, 10, 0)
+		teams, total, err := service.ListTeams(ctx, ListTeamsRequest{Limit: 10})
 		require.NoError(t, err)
 		assert.Empty(t, teams)
 		assert.Equal(t, 0, total)
@@ -254,7 +474,7 @@ func TestTeamService_ListTeams(t *testing.T) {
 		}
 
 		// List all teams
-		teams, total, err := service.ListTeams(ctx, 10, 0)
+		teams, total, err := service.ListTeams(ctx, ListTeamsRequest{Limit: 10})
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, len(teams), len(teamNames))
 		assert.GreaterOrEqual(t, total, len(teamNames))
@@ -277,14 +497,14 @@ func TestTeamService_ListTeams(t *testing.T) {
 
 	t.Run("pagination", func(t *testing.T) {
 		// List with limit
-		teams, total, err := service.ListTeams(ctx, 2, 0)
+		teams, total, err := service.ListTeams(ctx, ListTeamsRequest{Limit: 2})
 		require.NoError(t, err)
 		assert.LessOrEqual(t, len(teams), 2)
 		assert.GreaterOrEqual(t, total, len(teams))
 
 		// List with offset
 		if total > 2 {
-			teams2, total2, err := service.ListTeams(ctx, 2, 2)
+			teams2, total2, err := service.ListTeams(ctx, ListTeamsRequest{Limit: 2, Offset: 2})
 			require.NoError(t, err)
 			assert.Equal(t, total, total2) // Total should be the same
 
@@ -296,6 +516,209 @@ func TestTeamService_ListTeams(t *testing.T) {
 	})
 }
 
+func TestTeamService_ListTeams_ByCreator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	for i, creator := range []string{"alice@company.com", "alice@company.com", "bob@company.com"} {
+		_, err := service.CreateTeam(ctx, Team{
+			TenantID:    tenant.ID,
+			Name:        fmt.Sprintf("creator-team-%d", i),
+			DisplayName: fmt.Sprintf("Creator Team %d", i),
+			LeadEmail:   creator,
+			CreatedBy:   creator,
+		})
+		require.NoError(t, err)
+	}
+
+	teams, total, err := service.ListTeams(ctx, ListTeamsRequest{CreatedBy: "alice@company.com", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	for _, team := range teams {
+		assert.Equal(t, "alice@company.com", team.CreatedBy)
+	}
+
+	teams, total, err = service.ListTeams(ctx, ListTeamsRequest{CreatedBy: "carol@company.com", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, teams)
+}
+
+func TestTeamService_ListTeams_Search(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	// A unique prefix keeps teams from other tests out of the results
+	prefix := fmt.Sprintf("srch%d", time.Now().UnixNano())
+	description := "Owns the 100% uptime dashboards"
+	seeds := []Team{
+		{Name: prefix + "-payments", DisplayName: "Payments"},
+		{Name: prefix + "-billing", DisplayName: "Billing and Payouts"},
+		{Name: prefix + "-sre", DisplayName: "Site Reliability", Description: &description},
+	}
+	for _, team := range seeds {
+		team.TenantID = tenant.ID
+		team.LeadEmail = "lead@company.com"
+		_, err := service.CreateTeam(ctx, team)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		search string
+		want   int
+	}{
+		{prefix, 3},
+		{prefix + "-PAY", 1},
+		{prefix + "-b", 1},
+		{prefix + "_", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			_, total, err := service.ListTeams(ctx, ListTeamsRequest{Search: tt.search, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, total)
+		})
+	}
+
+	t.Run("display name and description", func(t *testing.T) {
+		teams, _, err := service.ListTeams(ctx, ListTeamsRequest{Search: "payouts", Limit: 100})
+		require.NoError(t, err)
+		assert.Contains(t, teamNames(teams), prefix+"-billing")
+
+		teams, _, err = service.ListTeams(ctx, ListTeamsRequest{Search: "100% UPTIME", Limit: 100})
+		require.NoError(t, err)
+		assert.Contains(t, teamNames(teams), prefix+"-sre")
+	})
+}
+
+func teamNames(teams []Team) []string {
+	names := make([]string, len(teams))
+	for i, team := range teams {
+		names[i] = team.Name
+	}
+	return names
+}
+
+func TestTeamService_ListTeams_Sort(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	// Created out of name order, so name order differs from creation order
+	prefix := fmt.Sprintf("sort%d", time.Now().UnixNano())
+	for _, name := range []string{"bravo", "charlie", "alpha"} {
+		_, err := service.CreateTeam(ctx, Team{TenantID: tenant.ID, Name: prefix + "-" + name, DisplayName: name, LeadEmail: "lead@company.com"})
+		require.NoError(t, err)
+	}
+
+	list := func(sort database.Sort) []string {
+		teams, _, err := service.ListTeams(ctx, ListTeamsRequest{Search: prefix, Sort: sort, Limit: 10})
+		require.NoError(t, err)
+		return teamNames(teams)
+	}
+
+	assert.Equal(t, []string{prefix + "-alpha", prefix + "-bravo", prefix + "-charlie"}, list(database.Sort{Column: "name"}))
+	assert.Equal(t, []string{prefix + "-charlie", prefix + "-bravo", prefix + "-alpha"}, list(database.Sort{Column: "name", Descending: true}))
+	assert.Equal(t, []string{prefix + "-alpha", prefix + "-charlie", prefix + "-bravo"}, list(database.Sort{}), "newest first by default")
+
+	t.Run("rejects a field that is not sortable", func(t *testing.T) {
+		_, _, err := service.ListTeams(ctx, ListTeamsRequest{Sort: database.Sort{Column: "lead_email"}, Limit: 10})
+		assert.ErrorIs(t, err, database.ErrInvalidSort)
+	})
+}
+
+func TestTeamService_ListTeams_Cursor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	want := make(map[uuid.UUID]bool)
+	for i := 0; i < 25; i++ {
+		team, err := service.CreateTeam(ctx, Team{
+			TenantID:    tenant.ID,
+			Name:        fmt.Sprintf("cursor-team-%02d", i),
+			DisplayName: fmt.Sprintf("Cursor Team %d", i),
+			LeadEmail:   "cursor-lead@company.com",
+			CreatedBy:   "cursor-user",
+		})
+		require.NoError(t, err)
+		want[team.ID] = true
+	}
+
+	// Share timestamps between rows so that ordering relies on the id tiebreak
+	_, err := pool.Exec(ctx, `
+		UPDATE resource_management.teams
+		SET created_at = TIMESTAMP WITH TIME ZONE '2024-01-01 00:00:00+00' + (ascii(right(name, 1)) % 3) * INTERVAL '1 second'
+		WHERE created_by = 'cursor-user'
+	`)
+	require.NoError(t, err)
+
+	seen := make(map[uuid.UUID]bool)
+	var after *database.Cursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10, "pagination did not terminate")
+
+		teams, total, err := service.ListTeams(ctx, ListTeamsRequest{CreatedBy: "cursor-user", After: after, Limit: 10})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, total, 25)
+
+		for _, team := range teams {
+			assert.False(t, seen[team.ID], "team %s returned twice", team.Name)
+			seen[team.ID] = true
+		}
+		if len(teams) < 10 {
+			break
+		}
+
+		last := teams[len(teams)-1]
+		after = &database.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+
+		// Rows created between pages sort before the cursor and are not returned
+		if pages == 0 {
+			_, err := service.CreateTeam(ctx, Team{
+				TenantID:    tenant.ID,
+				Name:        "cursor-team-late",
+				DisplayName: "Cursor Team Late",
+				LeadEmail:   "cursor-lead@company.com",
+				CreatedBy:   "cursor-user",
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, want, seen)
+}
+
 func TestTeamService_UpdateTeam(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -344,6 +767,34 @@ func TestTeamService_UpdateTeam(t *testing.T) {
 		assert.True(t, updated.UpdatedAt.After(updated.CreatedAt))
 		assert.NotNil(t, updated.UpdatedBy)
 		assert.Equal(t, "system", *updated.UpdatedBy)
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		created, err := service.CreateTeam(ctx, Team{
+			TenantID:  tenant.ID,
+			Name:      "versioned-team",
+			LeadEmail: "lead@company.com",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, created.Version)
+
+		// Another writer updates the team first
+		first := created
+		first.DisplayName = "First Writer"
+		updated, err := service.UpdateTeam(ctx, first)
+		require.NoError(t, err)
+		assert.Equal(t, 2, updated.Version)
+
+		stale := created
+		stale.DisplayName = "Second Writer"
+		_, err = service.UpdateTeam(ctx, stale)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+
+		current, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "First Writer", current.DisplayName)
+		assert.Equal(t, 2, current.Version)
 	})
 
 	t.Run("non-existent team", func(t *testing.T) {
@@ -395,6 +846,57 @@ func TestTeamService_UpdateTeam(t *testing.T) {
 	})
 }
 
+func TestTeamService_PatchTeam(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	service := NewService(pool)
+
+	created, err := service.CreateTeam(ctx, Team{
+		TenantID:     tenant.ID,
+		Name:         "patched-team",
+		DisplayName:  "Patched Team",
+		LeadEmail:    "lead@company.com",
+		Department:   stringPtr("Engineering"),
+		OwnedDomains: []string{"payments"},
+	})
+	require.NoError(t, err)
+
+	t.Run("only provided fields change", func(t *testing.T) {
+		patched, err := service.PatchTeam(ctx, created.ID, UpdateTeamRequest{Description: stringPtr("Handles payments")})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Handles payments", *patched.Description)
+		assert.Equal(t, created.Version+1, patched.Version)
+
+		got, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Handles payments", *got.Description)
+		assert.Equal(t, "patched-team", got.Name)
+		assert.Equal(t, "Patched Team", got.DisplayName)
+		assert.Equal(t, "lead@company.com", got.LeadEmail)
+		assert.Equal(t, "Engineering", *got.Department)
+		assert.Equal(t, []string{"payments"}, got.OwnedDomains)
+		assert.Len(t, got.Members, len(created.Members))
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		_, err := service.PatchTeam(ctx, created.ID, UpdateTeamRequest{DisplayName: stringPtr("Stale"), IfMatch: created.Version})
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("non-existent team", func(t *testing.T) {
+		_, err := service.PatchTeam(ctx, uuid.New(), UpdateTeamRequest{Description: stringPtr("Nobody")})
+		assert.ErrorIs(t, err, ErrTeamNotFound)
+	})
+}
+
 func TestTeamService_DeleteTeam(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -446,7 +948,479 @@ func TestTeamService_DeleteTeam(t *testing.T) {
 	})
 }
 
+func TestTeamService_SoftDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	created, err := service.CreateTeam(ctx, Team{
+		TenantID:    tenant.ID,
+		Name:        "soft-delete-team",
+		DisplayName: "Soft Delete Team",
+		LeadEmail:   "soft-lead@company.com",
+		CreatedBy:   "test-user",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteTeam(ctx, created.ID))
+
+	t.Run("deleted team is hidden", func(t *testing.T) {
+		_, err := service.GetTeam(ctx, created.ID)
+		assert.Equal(t, ErrTeamNotFound, err)
+
+		update := created
+		update.DisplayName = "Renamed"
+		_, err = service.UpdateTeam(ctx, update)
+		assert.Equal(t, ErrTeamNotFound, err)
+
+		assert.Equal(t, ErrTeamNotFound, service.DeleteTeam(ctx, created.ID))
+	})
+
+	t.Run("list excludes deleted teams unless asked", func(t *testing.T) {
+		teams, total, err := service.ListTeams(ctx, ListTeamsRequest{CreatedBy: "test-user", Limit: 50})
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, teams)
+
+		teams, total, err = service.ListTeams(ctx, ListTeamsRequest{CreatedBy: "test-user", IncludeDeleted: true, Limit: 50})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, teams, 1)
+		assert.Equal(t, created.ID, teams[0].ID)
+		assert.NotNil(t, teams[0].DeletedAt)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		restored, err := service.RestoreTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, restored.ID)
+		assert.Nil(t, restored.DeletedAt)
+
+		_, err = service.GetTeam(ctx, created.ID)
+		assert.NoError(t, err)
+
+		_, err = service.RestoreTeam(ctx, created.ID)
+		assert.Equal(t, ErrTeamNotFound, err)
+	})
+}
+
+func TestTeamService_BulkAddMembers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+
+	// Create test tenant
+	tenantManager := database.NewTenantManager(pool)
+	tenant, err := tenantManager.CreateTenant(ctx, &database.CreateTenantRequest{
+		Name:        "test-tenant",
+		DisplayName: "Test Tenant",
+		Description: stringPtr("Test tenant for team tests"),
+	})
+	require.NoError(t, err)
+
+	mixedBatch := []Member{
+		{Email: "alice@company.com", Role: "developer"},
+		{Email: "not-an-email", Role: "developer"},
+		{Email: "bob@company.com", Role: "superuser"},
+		{Email: "carol@company.com", Role: "maintainer"},
+		{Email: "ALICE@company.com", Role: "viewer"},
+	}
+
+	t.Run("non-atomic applies valid members", func(t *testing.T) {
+		created, err := service.CreateTeam(ctx, Team{
+			TenantID:  tenant.ID,
+			Name:      "bulk-non-atomic-team",
+			LeadEmail: "bulk-lead@company.com",
+			CreatedBy: "test-user",
+		})
+		require.NoError(t, err)
+
+		report, err := service.BulkAddMembers(ctx, created.ID, mixedBatch, false)
+		require.NoError(t, err)
+
+		assert.True(t, report.Applied)
+		assert.Equal(t, 2, report.Added)
+		assert.Equal(t, 3, report.Rejected)
+		require.Len(t, report.Results, 5)
+		assert.Equal(t, BulkMemberAdded, report.Results[0].Status)
+		assert.Equal(t, BulkMemberRejected, report.Results[1].Status)
+		assert.Equal(t, BulkMemberRejected, report.Results[2].Status)
+		assert.Equal(t, BulkMemberAdded, report.Results[3].Status)
+		assert.Equal(t, "duplicate member", report.Results[4].Reason)
+
+		team, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Len(t, team.Members, created.MemberCount+2)
+		assert.Equal(t, len(team.Members), team.MemberCount)
+		assert.Equal(t, report.MemberCount, team.MemberCount)
+	})
+
+	t.Run("atomic rolls back on any rejection", func(t *testing.T) {
+		created, err := service.CreateTeam(ctx, Team{
+			TenantID:  tenant.ID,
+			Name:      "bulk-atomic-team",
+			LeadEmail: "bulk-lead@company.com",
+			CreatedBy: "test-user",
+		})
+		require.NoError(t, err)
+
+		report, err := service.BulkAddMembers(ctx, created.ID, mixedBatch, true)
+		require.NoError(t, err)
+
+		assert.False(t, report.Applied)
+		assert.Equal(t, 0, report.Added)
+		assert.Equal(t, 3, report.Rejected)
+		assert.Equal(t, BulkMemberSkipped, report.Results[0].Status)
+		assert.Equal(t, BulkMemberSkipped, report.Results[3].Status)
+
+		team, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Len(t, team.Members, len(created.Members))
+		assert.Equal(t, created.MemberCount, team.MemberCount)
+	})
+
+	t.Run("atomic applies a fully valid batch", func(t *testing.T) {
+		created, err := service.CreateTeam(ctx, Team{
+			TenantID:  tenant.ID,
+			Name:      "bulk-atomic-valid-team",
+			LeadEmail: "bulk-lead@company.com",
+			CreatedBy: "test-user",
+		})
+		require.NoError(t, err)
+
+		report, err := service.BulkAddMembers(ctx, created.ID, []Member{
+			{Email: "dave@company.com", Role: "developer"},
+			{Email: "erin@company.com", Role: "viewer"},
+		}, true)
+		require.NoError(t, err)
+
+		assert.True(t, report.Applied)
+		assert.Equal(t, 2, report.Added)
+		assert.Equal(t, created.MemberCount+2, report.MemberCount)
+	})
+
+	t.Run("non-existent team", func(t *testing.T) {
+		_, err := service.BulkAddMembers(ctx, uuid.New(), mixedBatch, false)
+		require.Error(t, err)
+		assert.Equal(t, ErrTeamNotFound, err)
+	})
+}
+
+func TestTeamService_MemberChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool, WithAutoOwnerMembership(false))
+
+	tenantManager := database.NewTenantManager(pool)
+	tenant, err := tenantManager.CreateTenant(ctx, &database.CreateTenantRequest{
+		Name:        "test-tenant",
+		DisplayName: "Test Tenant",
+		Description: stringPtr("Test tenant for team tests"),
+	})
+	require.NoError(t, err)
+
+	created, err := service.CreateTeam(ctx, Team{
+		TenantID:  tenant.ID,
+		Name:      "member-changes-team",
+		LeadEmail: "lead@company.com",
+		CreatedBy: "test-user",
+		Members: []Member{
+			{Email: "owner@company.com", Role: "owner"},
+			{Email: "second-owner@company.com", Role: "owner"},
+			{Email: "dev@company.com", Role: "developer"},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("removing a non-last owner succeeds", func(t *testing.T) {
+		require.NoError(t, service.RemoveMember(ctx, created.ID, "second-owner@company.com"))
+
+		team, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Len(t, team.Members, 2)
+		assert.Equal(t, 2, team.MemberCount)
+	})
+
+	t.Run("removing the last owner fails", func(t *testing.T) {
+		err := service.RemoveMember(ctx, created.ID, "owner@company.com")
+		assert.ErrorIs(t, err, ErrLastOwner)
+
+		team, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Len(t, team.Members, 2, "the members are unchanged")
+	})
+
+	t.Run("demoting the last owner fails", func(t *testing.T) {
+		_, err := service.UpdateMemberRole(ctx, created.ID, "owner@company.com", "maintainer")
+		assert.ErrorIs(t, err, ErrLastOwner)
+	})
+
+	t.Run("promoting then demoting", func(t *testing.T) {
+		member, err := service.UpdateMemberRole(ctx, created.ID, "dev@company.com", "owner")
+		require.NoError(t, err)
+		assert.Equal(t, "owner", member.Role)
+
+		_, err = service.UpdateMemberRole(ctx, created.ID, "owner@company.com", "viewer")
+		assert.NoError(t, err)
+	})
+
+	t.Run("replacing the members cannot remove every owner", func(t *testing.T) {
+		team, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+
+		team.Members = []Member{}
+		_, err = service.UpdateTeam(ctx, team)
+		assert.ErrorIs(t, err, ErrLastOwner)
+
+		stored, err := service.GetTeam(ctx, created.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, stored.Members, "the members are unchanged")
+		assert.Equal(t, team.Version, stored.Version)
+	})
+
+	t.Run("unknown member and team", func(t *testing.T) {
+		assert.ErrorIs(t, service.RemoveMember(ctx, created.ID, "nobody@company.com"), ErrMemberNotFound)
+		assert.ErrorIs(t, service.RemoveMember(ctx, uuid.New(), "owner@company.com"), ErrTeamNotFound)
+	})
+}
+
+func TestTeamService_MemberRole(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool, WithAutoOwnerMembership(false))
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	created, err := service.CreateTeam(ctx, Team{
+		TenantID:  tenant.ID,
+		Name:      "member-role-team",
+		LeadEmail: "lead@company.com",
+		CreatedBy: "test-user",
+		Members: []Member{
+			{UserID: "u-owner", Email: "owner@company.com", Role: "owner"},
+			{Email: "viewer@company.com", Role: "viewer"},
+			{Email: "pending@company.com", Role: "maintainer", Status: "pending"},
+		},
+	})
+	require.NoError(t, err)
+
+	for user, want := range map[string]types.TeamRole{
+		"u-owner":             types.TeamRoleOwner,
+		"Owner@Company.com":   types.TeamRoleOwner,
+		"viewer@company.com":  types.TeamRoleViewer,
+		"pending@company.com": "",
+		"nobody@company.com":  "",
+	} {
+		role, err := service.MemberRole(ctx, created.ID.String(), user)
+		require.NoError(t, err, user)
+		assert.Equal(t, want, role, user)
+	}
+
+	role, err := service.MemberRole(ctx, uuid.New().String(), "owner@company.com")
+	require.NoError(t, err)
+	assert.Empty(t, role, "unknown teams have no members")
+
+	role, err = service.MemberRole(ctx, "not-a-uuid", "owner@company.com")
+	require.NoError(t, err)
+	assert.Empty(t, role)
+
+	t.Run("deleted teams keep their roles for restoring", func(t *testing.T) {
+		require.NoError(t, service.DeleteTeam(ctx, created.ID))
+
+		role, err := service.MemberRole(ctx, created.ID.String(), "owner@company.com")
+		require.NoError(t, err)
+		assert.Empty(t, role, "deleted teams have no members")
+
+		role, err = service.DeletedMemberRole(ctx, created.ID.String(), "owner@company.com")
+		require.NoError(t, err)
+		assert.Equal(t, types.TeamRoleOwner, role)
+
+		role, err = service.DeletedMemberRole(ctx, created.ID.String(), "viewer@company.com")
+		require.NoError(t, err)
+		assert.Equal(t, types.TeamRoleViewer, role)
+	})
+}
+
 // Helper function to create string pointer
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestTeamService_Audit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.WithValue(context.Background(), types.UserIDKey, "auditor@company.com")
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	auditLog := audit.New(pool, logger.New("debug", "text"))
+	service := NewService(pool, WithAudit(auditLog))
+	since := time.Now().Add(-time.Second)
+
+	team, err := service.CreateTeam(ctx, Team{
+		TenantID:  tenant.ID,
+		Name:      "audited-team",
+		LeadEmail: "lead@company.com",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteTeam(ctx, team.ID))
+	assert.ErrorIs(t, service.DeleteTeam(ctx, team.ID), ErrTeamNotFound)
+
+	events, err := auditLog.ListEvents(ctx, audit.Filter{
+		ActorID:   "auditor@company.com",
+		Kind:      audit.KindTeam,
+		Namespace: tenant.ID.String(),
+		Since:     since,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	// Newest first: the failed repeat delete, the delete, then the create
+	assert.Equal(t, audit.ActionDelete, events[0].Spec.Action)
+	assert.Equal(t, types.AuditResultFailure, events[0].Spec.Result)
+	assert.Equal(t, ErrTeamNotFound.Error(), events[0].Spec.Details["error"])
+
+	deleted := events[1].Spec
+	assert.Equal(t, audit.ActionDelete, deleted.Action)
+	assert.Equal(t, types.AuditResultSuccess, deleted.Result)
+	assert.Equal(t, types.Actor{Type: types.ActorTypeUser, ID: "auditor@company.com"}, deleted.Actor)
+	assert.Equal(t, types.ResourceReference{
+		APIVersion: audit.APIVersion,
+		Kind:       audit.KindTeam,
+		Name:       "audited-team",
+		Namespace:  tenant.ID.String(),
+		UID:        team.ID.String(),
+	}, deleted.Resource)
+
+	assert.Equal(t, audit.ActionCreate, events[2].Spec.Action)
+	assert.Equal(t, types.AuditResultSuccess, events[2].Spec.Result)
+}
+
+// recordingPublisher keeps the events published to it
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) {
+	p.events = append(p.events, event)
+}
+
+func TestTeamService_Events(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.WithValue(context.Background(), types.UserIDKey, "lead@company.com")
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	publisher := &recordingPublisher{}
+	service := NewService(pool, WithEvents(publisher))
+
+	team, err := service.CreateTeam(ctx, Team{
+		TenantID:  tenant.ID,
+		Name:      "evented-team",
+		LeadEmail: "lead@company.com",
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteTeam(ctx, team.ID))
+	assert.ErrorIs(t, service.DeleteTeam(ctx, team.ID), ErrTeamNotFound)
+
+	require.Len(t, publisher.events, 2, "failed writes are not announced")
+
+	resource := types.ResourceReference{
+		APIVersion: audit.APIVersion,
+		Kind:       audit.KindTeam,
+		Name:       "evented-team",
+		Namespace:  tenant.ID.String(),
+		UID:        team.ID.String(),
+	}
+	for i, eventType := range []string{"team.created", "team.deleted"} {
+		event := publisher.events[i]
+		assert.Equal(t, eventType, event.Type)
+		assert.Equal(t, tenant.ID.String(), event.TenantID)
+		assert.Equal(t, resource, event.Resource)
+		assert.Equal(t, types.Actor{Type: types.ActorTypeUser, ID: "lead@company.com"}, event.Actor)
+	}
+}
+
+// recordingNotifier keeps the notifications sent to it
+type recordingNotifier struct {
+	notifications []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func TestTeamService_MembershipNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	notifier := &recordingNotifier{}
+	service := NewService(pool, WithNotifier(notifier))
+
+	team, err := service.CreateTeam(ctx, Team{
+		TenantID:  tenant.ID,
+		Name:      "notified-team",
+		LeadEmail: "lead@company.com",
+	})
+	require.NoError(t, err)
+
+	_, err = service.BulkAddMembers(ctx, team.ID, []Member{
+		{Email: "dev@company.com", Role: "developer"},
+		{Email: "not-an-email", Role: "developer"},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, service.RemoveMember(ctx, team.ID, "dev@company.com"))
+	assert.ErrorIs(t, service.RemoveMember(ctx, team.ID, "dev@company.com"), ErrMemberNotFound)
+
+	require.Len(t, notifier.notifications, 2, "rejected and failed changes are not notified")
+	change := notify.TeamMembershipChange{
+		TeamID:   team.ID.String(),
+		TeamName: "notified-team",
+		Email:    "dev@company.com",
+		Role:     "developer",
+	}
+	for i, typ := range []string{notify.TypeTeamMemberAdded, notify.TypeTeamMemberRemoved} {
+		n := notifier.notifications[i]
+		assert.Equal(t, typ, n.Type)
+		assert.Equal(t, tenant.ID.String(), n.TenantID)
+		assert.Equal(t, change, n.Data)
+	}
+}
@@ -0,0 +1,122 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Handlers provides HTTP handlers for templates using native Go HTTP
+type Handlers struct {
+	service   TemplateService
+	logger    *logger.Logger
+	responder *server.Responder
+}
+
+// HandlerOption configures Handlers
+type HandlerOption func(*Handlers)
+
+// WithResponseEnvelope wraps every response in the types.APIResponse envelope
+func WithResponseEnvelope(enabled bool) HandlerOption {
+	return func(h *Handlers) {
+		h.responder = server.NewResponder(enabled)
+	}
+}
+
+// NewHandlers creates new template handlers
+func NewHandlers(service TemplateService, appLogger *logger.Logger, opts ...HandlerOption) *Handlers {
+	h := &Handlers{
+		service:   service,
+		logger:    appLogger,
+		responder: server.NewResponder(false),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RenderRequest holds the parameter values to render a template with
+type RenderRequest struct {
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// ErrorResponse represents an error response, in the shape shared by every service
+type ErrorResponse = server.ErrorResponse
+
+// Render handles POST /api/v1/templates/{name}/render
+func (h *Handlers) Render(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := r.PathValue("name")
+
+	var req RenderRequest
+	if err := server.DecodeJSON(r.Body, &req); err != nil {
+		h.writeDecodeError(w, r, err)
+		return
+	}
+
+	result, err := h.service.Render(ctx, name, req.Parameters)
+	if err != nil {
+		var errs server.ValidationErrors
+		if errors.As(err, &errs) {
+			h.responder.ValidationErrors(w, r, errs)
+			return
+		}
+		if errors.Is(err, ErrTemplateNotFound) {
+			h.writeError(w, r, "Template not found", http.StatusNotFound, "TEMPLATE_NOT_FOUND")
+			return
+		}
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"template":        name,
+		}).Error("Failed to render template")
+		h.writeError(w, r, "Failed to render template", http.StatusInternalServerError, "RENDER_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"template": name,
+		"files":    len(result.Files),
+	}).Info("Template rendered successfully")
+
+	h.responder.JSON(w, r, http.StatusOK, result)
+}
+
+// writeDecodeError reports a request body that could not be decoded: 413 when it is
+// over the MaxBodyBytes limit, otherwise 400 naming any unknown field
+func (h *Handlers) writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if limit, ok := middleware.BodyTooLarge(err); ok {
+		apiErr := middleware.BodyTooLargeError(limit)
+		h.writeError(w, r, apiErr.Message, http.StatusRequestEntityTooLarge, apiErr.Code)
+		return
+	}
+	var unknownField *server.UnknownFieldError
+	if errors.As(err, &unknownField) {
+		h.writeError(w, r, fmt.Sprintf("Unknown field '%s' in request body", unknownField.Field), http.StatusBadRequest, server.CodeUnknownField)
+		return
+	}
+	h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+}
+
+// writeError writes an error response
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, message string, statusCode int, code string) {
+	response := ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    code,
+		Time:    time.Now().UTC(),
+	}
+
+	apiErr := &types.APIError{Code: code, Message: message}
+	if err := h.responder.Error(w, r, statusCode, apiErr, response); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode error response")
+	}
+}
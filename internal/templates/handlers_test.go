@@ -0,0 +1,73 @@
+package templates
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_Render(t *testing.T) {
+	handlers := NewHandlers(newTestService(t), logger.New("debug", "text"))
+
+	render := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/templates/"+name+"/render", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("name", name)
+
+		rr := httptest.NewRecorder()
+		handlers.Render(rr, req)
+		return rr
+	}
+
+	t.Run("returns the rendered files", func(t *testing.T) {
+		rr := render("go-service", `{"parameters": {"name": "orders-api", "port": 9090}}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var result RenderResult
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		require.Len(t, result.Files, 3)
+		assert.Equal(t, "cmd/orders-api/main.go", result.Files[0].Path)
+		assert.Equal(t, "package main // orders-api on :9090\n", result.Files[0].Content)
+	})
+
+	t.Run("invalid parameters are reported by field", func(t *testing.T) {
+		rr := render("go-service", `{"parameters": {"name": "Orders_API"}}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var response server.ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		errs, ok := response.Details["validation_errors"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "parameters.name", errs[0].(map[string]interface{})["field"])
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		rr := render("rust-service", `{"parameters": {}}`)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "TEMPLATE_NOT_FOUND", response.Code)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		rr := render("go-service", `{"params": {"name": "orders-api"}}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, server.CodeUnknownField, response.Code)
+	})
+}
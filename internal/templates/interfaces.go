@@ -0,0 +1,10 @@
+package templates
+
+import (
+	"context"
+)
+
+// TemplateService defines the interface for template operations
+type TemplateService interface {
+	Render(ctx context.Context, name string, values map[string]interface{}) (*RenderResult, error)
+}
@@ -0,0 +1,22 @@
+package templates
+
+import (
+	"net/http"
+
+	"github.com/aykay76/ai-idp/internal/openapi"
+)
+
+// OpenAPI describes the template API registered by the application service
+func OpenAPI(version string) *openapi.Document {
+	doc := openapi.New("Template API", version, ErrorResponse{})
+
+	doc.Add("POST /api/v1/templates/{name}/render", openapi.Endpoint{
+		Summary:  "Render a template's files from parameter values",
+		Tags:     []string{"templates"},
+		Request:  RenderRequest{},
+		Response: RenderResult{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	})
+
+	return doc
+}
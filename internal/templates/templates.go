@@ -0,0 +1,326 @@
+// Package templates renders the files of application templates from their declared
+// parameters, for scaffolding new applications.
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+)
+
+// Common errors
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrInvalidTemplate  = errors.New("invalid template")
+)
+
+// Parameter types
+const (
+	ParameterString      = "string"
+	ParameterInt         = "int"
+	ParameterBool        = "bool"
+	ParameterSelect      = "select"
+	ParameterMultiSelect = "multiselect"
+)
+
+// RenderedFile is a template file with its path and content rendered
+type RenderedFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+// RenderResult is the file set rendered from a template, with the parameter values
+// used after defaults were applied
+type RenderResult struct {
+	Template   string                 `json:"template"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Files      []RenderedFile         `json:"files"`
+}
+
+// compiled is a template with its validation patterns and file templates parsed
+type compiled struct {
+	template types.Template
+	patterns map[string]*regexp.Regexp
+	// files holds the parsed path and content of files marked as templates
+	files []*template.Template
+}
+
+// Service renders templates from a fixed catalog
+type Service struct {
+	templates map[string]*compiled
+}
+
+// Compile-time check that Service implements TemplateService
+var _ TemplateService = (*Service)(nil)
+
+// NewService creates a service for templates, checking that each has a unique name,
+// parameters of a known type with valid patterns, and file content that parses
+func NewService(templates []types.Template) (*Service, error) {
+	s := &Service{templates: make(map[string]*compiled, len(templates))}
+	for _, tmpl := range templates {
+		name := tmpl.Metadata.Name
+		if name == "" {
+			return nil, fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+		}
+		if _, exists := s.templates[name]; exists {
+			return nil, fmt.Errorf("%w: duplicate template %q", ErrInvalidTemplate, name)
+		}
+
+		c, err := compile(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidTemplate, name, err)
+		}
+		s.templates[name] = c
+	}
+	return s, nil
+}
+
+// LoadFile reads a JSON array of templates
+func LoadFile(path string) ([]types.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates file: %w", err)
+	}
+
+	var templates []types.Template
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates file %s: %w", path, err)
+	}
+	return templates, nil
+}
+
+// compile parses a template's validation patterns and file templates
+func compile(tmpl types.Template) (*compiled, error) {
+	c := &compiled{template: tmpl, patterns: make(map[string]*regexp.Regexp)}
+
+	seen := make(map[string]bool, len(tmpl.Spec.Parameters))
+	for _, p := range tmpl.Spec.Parameters {
+		if p.Name == "" {
+			return nil, errors.New("parameter name is required")
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("duplicate parameter %q", p.Name)
+		}
+		seen[p.Name] = true
+
+		switch p.Type {
+		case ParameterString, ParameterInt, ParameterBool:
+		case ParameterSelect, ParameterMultiSelect:
+			if len(p.Options) == 0 {
+				return nil, fmt.Errorf("parameter %q: %s parameters need options", p.Name, p.Type)
+			}
+		default:
+			return nil, fmt.Errorf("parameter %q: unknown type %q", p.Name, p.Type)
+		}
+
+		if p.Validation != "" {
+			pattern, err := regexp.Compile(p.Validation)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %q: invalid validation pattern: %w", p.Name, err)
+			}
+			c.patterns[p.Name] = pattern
+		}
+	}
+
+	for _, f := range tmpl.Spec.Files {
+		if f.Source != "" && f.Source != "inline" {
+			return nil, fmt.Errorf("file %q: only inline content is supported", f.Path)
+		}
+		if !f.Template {
+			c.files = append(c.files, nil)
+			continue
+		}
+
+		// The path is rendered too, as the "path" template in the content's set
+		parsed, err := template.New("content").Option("missingkey=error").Parse(f.Content)
+		if err == nil {
+			_, err = parsed.New("path").Parse(f.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("file %q: %w", f.Path, err)
+		}
+		c.files = append(c.files, parsed)
+	}
+
+	return c, nil
+}
+
+// Render validates values against the parameters the template declares and renders
+// its files with them. Parameters that fail validation are reported together as
+// server.ValidationErrors. Values for undeclared parameters are rejected. Missing
+// parameters take their default; without one, required parameters fail and optional
+// ones are the zero value of their type.
+func (s *Service) Render(ctx context.Context, name string, values map[string]interface{}) (*RenderResult, error) {
+	c, ok := s.templates[name]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+
+	params, err := c.parameters(values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RenderResult{Template: name, Parameters: params, Files: make([]RenderedFile, 0, len(c.files))}
+	for i, f := range c.template.Spec.Files {
+		rendered := RenderedFile{Path: f.Path, Content: f.Content, Mode: f.Mode}
+		if parsed := c.files[i]; parsed != nil {
+			if rendered.Path, err = execute(parsed.Lookup("path"), params); err != nil {
+				return nil, fmt.Errorf("failed to render path of %s: %w", f.Path, err)
+			}
+			if rendered.Content, err = execute(parsed, params); err != nil {
+				return nil, fmt.Errorf("failed to render %s: %w", f.Path, err)
+			}
+		}
+		result.Files = append(result.Files, rendered)
+	}
+
+	return result, nil
+}
+
+// execute renders t with params
+func execute(t *template.Template, params map[string]interface{}) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, params); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// parameters checks values against the declared parameters and returns the values to
+// render with
+func (c *compiled) parameters(values map[string]interface{}) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, len(c.template.Spec.Parameters))
+	var errs server.ValidationErrors
+
+	declared := make(map[string]bool, len(c.template.Spec.Parameters))
+	for _, p := range c.template.Spec.Parameters {
+		declared[p.Name] = true
+		field := "parameters." + p.Name
+
+		value := values[p.Name]
+		if value == nil {
+			value = p.Default
+		}
+		if value == nil {
+			if p.Required {
+				errs = append(errs, server.ValidationError{Field: field, Message: field + " is required"})
+				continue
+			}
+			params[p.Name] = zeroValue(p.Type)
+			continue
+		}
+
+		converted, message := c.convert(p, value)
+		if message != "" {
+			errs = append(errs, server.ValidationError{Field: field, Message: field + " " + message, Value: fmt.Sprint(value)})
+			continue
+		}
+		params[p.Name] = converted
+	}
+
+	// Sorted so the errors come out in the same order each time
+	var undeclared []string
+	for name := range values {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		field := "parameters." + name
+		errs = append(errs, server.ValidationError{Field: field, Message: field + " is not a parameter of the template"})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return params, nil
+}
+
+// convert checks value is of the parameter's type, and matches its options and
+// pattern, returning the value to render with or a message describing the problem
+func (c *compiled) convert(p types.TemplateParameter, value interface{}) (interface{}, string) {
+	switch p.Type {
+	case ParameterInt:
+		// JSON numbers decode as float64
+		switch v := value.(type) {
+		case int:
+			return v, ""
+		case float64:
+			if v == math.Trunc(v) {
+				return int(v), ""
+			}
+		}
+		return nil, "must be an integer"
+
+	case ParameterBool:
+		if v, ok := value.(bool); ok {
+			return v, ""
+		}
+		return nil, "must be a boolean"
+
+	case ParameterSelect:
+		v, ok := value.(string)
+		if !ok || !slices.Contains(p.Options, v) {
+			return nil, "must be one of: " + strings.Join(p.Options, ", ")
+		}
+		return v, ""
+
+	case ParameterMultiSelect:
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []string:
+			for _, item := range v {
+				items = append(items, item)
+			}
+		default:
+			return nil, "must be a list"
+		}
+		selected := make([]string, 0, len(items))
+		for _, item := range items {
+			v, ok := item.(string)
+			if !ok || !slices.Contains(p.Options, v) {
+				return nil, "must only contain: " + strings.Join(p.Options, ", ")
+			}
+			selected = append(selected, v)
+		}
+		return selected, ""
+	}
+
+	v, ok := value.(string)
+	if !ok {
+		return nil, "must be a string"
+	}
+	if pattern := c.patterns[p.Name]; pattern != nil && !pattern.MatchString(v) {
+		return nil, "must match " + p.Validation
+	}
+	return v, ""
+}
+
+// zeroValue is the value rendered for an optional parameter with no value or default
+func zeroValue(typ string) interface{} {
+	switch typ {
+	case ParameterInt:
+		return 0
+	case ParameterBool:
+		return false
+	case ParameterMultiSelect:
+		return []string{}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package templates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func goService() types.Template {
+	return types.Template{
+		Metadata: types.ObjectMeta{Name: "go-service"},
+		Spec: types.TemplateSpec{
+			Parameters: []types.TemplateParameter{
+				{Name: "name", Type: ParameterString, Required: true, Validation: `^[a-z][a-z0-9-]*$`},
+				{Name: "port", Type: ParameterInt, Default: float64(8080)},
+				{Name: "database", Type: ParameterSelect, Options: []string{"none", "postgres"}, Default: "none"},
+				{Name: "features", Type: ParameterMultiSelect, Options: []string{"metrics", "tracing"}},
+				{Name: "private", Type: ParameterBool},
+			},
+			Files: []types.TemplateFile{
+				{Path: "cmd/{{.name}}/main.go", Template: true, Content: "package main // {{.name}} on :{{.port}}\n"},
+				{Path: "config.yaml", Template: true, Mode: "0644", Content: "database: {{.database}}\n{{range .features}}{{.}}: true\n{{end}}"},
+				{Path: "README.md", Content: "# {{.name}} is not rendered\n"},
+			},
+		},
+	}
+}
+
+func newTestService(t *testing.T) *Service {
+	s, err := NewService([]types.Template{goService()})
+	require.NoError(t, err)
+	return s
+}
+
+func TestService_Render(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	t.Run("renders every file", func(t *testing.T) {
+		result, err := s.Render(ctx, "go-service", map[string]interface{}{
+			"name":     "orders-api",
+			"database": "postgres",
+			"features": []interface{}{"metrics", "tracing"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "go-service", result.Template)
+		assert.Equal(t, []RenderedFile{
+			{Path: "cmd/orders-api/main.go", Content: "package main // orders-api on :8080\n"},
+			{Path: "config.yaml", Mode: "0644", Content: "database: postgres\nmetrics: true\ntracing: true\n"},
+			{Path: "README.md", Content: "# {{.name}} is not rendered\n"},
+		}, result.Files)
+		assert.Equal(t, 8080, result.Parameters["port"], "defaults are applied")
+		assert.Equal(t, false, result.Parameters["private"], "optional parameters default to their zero value")
+	})
+
+	t.Run("required parameters must have a value", func(t *testing.T) {
+		_, err := s.Render(ctx, "go-service", map[string]interface{}{"port": 9090})
+
+		var errs server.ValidationErrors
+		require.ErrorAs(t, err, &errs)
+		assert.Equal(t, server.ValidationErrors{
+			{Field: "parameters.name", Message: "parameters.name is required"},
+		}, errs)
+	})
+
+	t.Run("values must match the validation pattern", func(t *testing.T) {
+		_, err := s.Render(ctx, "go-service", map[string]interface{}{"name": "Orders_API"})
+
+		var errs server.ValidationErrors
+		require.ErrorAs(t, err, &errs)
+		assert.Equal(t, server.ValidationErrors{
+			{Field: "parameters.name", Message: "parameters.name must match ^[a-z][a-z0-9-]*$", Value: "Orders_API"},
+		}, errs)
+	})
+
+	t.Run("every invalid parameter is reported", func(t *testing.T) {
+		_, err := s.Render(ctx, "go-service", map[string]interface{}{
+			"name":     "orders-api",
+			"port":     80.5,
+			"database": "mysql",
+			"features": []interface{}{"logging"},
+			"private":  "yes",
+			"region":   "eu-west-1",
+		})
+
+		var errs server.ValidationErrors
+		require.ErrorAs(t, err, &errs)
+		var fields []string
+		for _, e := range errs {
+			fields = append(fields, e.Field)
+		}
+		assert.Equal(t, []string{
+			"parameters.port", "parameters.database", "parameters.features", "parameters.private", "parameters.region",
+		}, fields)
+		assert.Equal(t, "parameters.database must be one of: none, postgres", errs[1].Message)
+		assert.Equal(t, "parameters.region is not a parameter of the template", errs[4].Message)
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		_, err := s.Render(ctx, "rust-service", nil)
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+	})
+}
+
+func TestNewService(t *testing.T) {
+	t.Run("an empty catalog is allowed", func(t *testing.T) {
+		_, err := NewService(nil)
+		assert.NoError(t, err)
+	})
+
+	for name, modify := range map[string]func(*types.Template){
+		"missing name":         func(tmpl *types.Template) { tmpl.Metadata.Name = "" },
+		"unknown type":         func(tmpl *types.Template) { tmpl.Spec.Parameters[0].Type = "float" },
+		"invalid pattern":      func(tmpl *types.Template) { tmpl.Spec.Parameters[0].Validation = "[a-z" },
+		"select without items": func(tmpl *types.Template) { tmpl.Spec.Parameters[2].Options = nil },
+		"unparsable content":   func(tmpl *types.Template) { tmpl.Spec.Files[0].Content = "{{.name" },
+		"unsupported source":   func(tmpl *types.Template) { tmpl.Spec.Files[2].Source = "url" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			tmpl := goService()
+			modify(&tmpl)
+			_, err := NewService([]types.Template{tmpl})
+			assert.ErrorIs(t, err, ErrInvalidTemplate)
+		})
+	}
+
+	t.Run("duplicate names", func(t *testing.T) {
+		_, err := NewService([]types.Template{goService(), goService()})
+		assert.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"metadata": {"name": "go-service"}, "spec": {"files": [{"path": "main.go"}]}}]`), 0o600))
+
+	templates, err := LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "go-service", templates[0].Metadata.Name)
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,365 @@
+package tenants
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/middleware"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+)
+
+// Handlers provides HTTP handlers for tenant resources using native Go HTTP
+type Handlers struct {
+	service    TenantService
+	logger     *logger.Logger
+	responder  *server.Responder
+	adminToken string
+	pageLimits server.PageLimits
+}
+
+// HandlerOption configures Handlers
+type HandlerOption func(*Handlers)
+
+// WithResponseEnvelope wraps every response in the types.APIResponse envelope
+func WithResponseEnvelope(enabled bool) HandlerOption {
+	return func(h *Handlers) {
+		h.responder = server.NewResponder(enabled)
+	}
+}
+
+// WithAdminToken sets the bearer token that grants admin access to tenant-scoped
+// endpoints such as usage reports
+func WithAdminToken(token string) HandlerOption {
+	return func(h *Handlers) {
+		h.adminToken = token
+	}
+}
+
+// WithPageLimits sets the default and maximum page size for listing tenants
+func WithPageLimits(limits server.PageLimits) HandlerOption {
+	return func(h *Handlers) {
+		h.pageLimits = limits
+	}
+}
+
+// NewHandlers creates new tenant handlers
+func NewHandlers(service TenantService, appLogger *logger.Logger, opts ...HandlerOption) *Handlers {
+	h := &Handlers{
+		service:   service,
+		logger:    appLogger,
+		responder: server.NewResponder(false),
+
+		pageLimits: server.DefaultPageLimits,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ListTenantsResponse represents the response for listing tenants
+type ListTenantsResponse struct {
+	Tenants    []*database.Tenant `json:"tenants"`
+	Pagination PaginationMeta     `json:"pagination"`
+}
+
+// PaginationMeta contains pagination metadata
+type PaginationMeta struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// ErrorResponse represents an error response, in the shape shared by every service
+type ErrorResponse = server.ErrorResponse
+
+// CreateTenant handles POST /api/v1/tenants
+func (h *Handlers) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse request body
+	var req database.CreateTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		return
+	}
+
+	if req.Name == "" || req.DisplayName == "" {
+		h.writeError(w, r, "Name and display name are required", http.StatusBadRequest, "INVALID_TENANT")
+		return
+	}
+
+	req.RequestedBy = callerKey(r)
+
+	tenant, err := h.service.CreateTenant(ctx, &req)
+	if err != nil {
+		var throttled *database.TenantCreationThrottledError
+		if errors.As(err, &throttled) {
+			h.logger.WithFields(logger.LogFields{
+				"caller": req.RequestedBy,
+				"scope":  throttled.Scope,
+			}).Warn("Tenant creation throttled")
+
+			h.writeRetryableError(w, r, "Too many tenant creation requests", http.StatusTooManyRequests, "TENANT_CREATION_THROTTLED", throttled.RetryAfter)
+			return
+		}
+		if errors.Is(err, database.ErrInvalidSlug) {
+			h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_SLUG")
+			return
+		}
+		if errors.Is(err, database.ErrInvalidMaintenanceWindow) {
+			h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_MAINTENANCE_WINDOW")
+			return
+		}
+		if errors.Is(err, database.ErrSlugTaken) {
+			h.writeError(w, r, "Tenant slug is already in use", http.StatusConflict, "SLUG_TAKEN")
+			return
+		}
+		if errors.Is(err, database.ErrTenantLimitReached) {
+			h.logger.WithFields(logger.LogFields{
+				"name": req.Name,
+			}).Warn("Tenant limit reached")
+
+			h.writeError(w, r, "Maximum number of tenants reached", http.StatusInsufficientStorage, "TENANT_LIMIT_REACHED")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"name":            req.Name,
+		}).Error("Failed to create tenant")
+		h.writeError(w, r, "Failed to create tenant", http.StatusInternalServerError, "CREATE_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldTenantID: tenant.ID.String(),
+		"name":               tenant.Name,
+	}).Info("Tenant created successfully")
+
+	h.writeJSON(w, r, http.StatusCreated, tenant)
+}
+
+// GetTenant handles GET /api/v1/tenants/{id}. It is available to admins and to
+// callers whose token's tenant_id claim names the tenant itself.
+func (h *Handlers) GetTenant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract tenant ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	if !h.canRead(r, id) {
+		h.writeError(w, r, "Tenants are only available to admins and members of the tenant", http.StatusForbidden, "FORBIDDEN")
+		return
+	}
+
+	tenant, err := h.service.GetTenant(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrTenantNotFound) {
+			h.writeError(w, r, "Tenant not found", http.StatusNotFound, "TENANT_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError:    err.Error(),
+			logger.FieldTenantID: id.String(),
+		}).Error("Failed to get tenant")
+		h.writeError(w, r, "Failed to get tenant", http.StatusInternalServerError, "GET_FAILED")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, tenant)
+}
+
+// GetTenantBySlug handles GET /api/v1/tenants/by-slug/{slug}, available to the same
+// callers as GetTenant
+func (h *Handlers) GetTenantBySlug(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	slug := r.PathValue("slug")
+	if err := database.ValidateSlug(slug); err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_SLUG")
+		return
+	}
+
+	tenant, err := h.service.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, database.ErrTenantNotFound) {
+			h.writeError(w, r, "Tenant not found", http.StatusNotFound, "TENANT_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"slug":            slug,
+		}).Error("Failed to get tenant by slug")
+		h.writeError(w, r, "Failed to get tenant", http.StatusInternalServerError, "GET_FAILED")
+		return
+	}
+
+	if !h.canRead(r, tenant.ID) {
+		h.writeError(w, r, "Tenants are only available to admins and members of the tenant", http.StatusForbidden, "FORBIDDEN")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, tenant)
+}
+
+// GetTenantUsage handles GET /api/v1/tenants/{id}/usage. It is available to admins
+// and to callers whose token's tenant_id claim names the tenant itself.
+func (h *Handlers) GetTenantUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	if !h.canRead(r, id) {
+		h.writeError(w, r, "Usage is only available to admins and members of the tenant", http.StatusForbidden, "FORBIDDEN")
+		return
+	}
+
+	usage, err := h.service.GetTenantUsage(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrTenantNotFound) {
+			h.writeError(w, r, "Tenant not found", http.StatusNotFound, "TENANT_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError:    err.Error(),
+			logger.FieldTenantID: id.String(),
+		}).Error("Failed to compute tenant usage")
+		h.writeError(w, r, "Failed to get tenant usage", http.StatusInternalServerError, "USAGE_FAILED")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, usage)
+}
+
+// ListTenants handles GET /api/v1/tenants
+func (h *Handlers) ListTenants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse pagination parameters
+	params, err := server.ParsePagination(r, h.pageLimits)
+	if err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, server.CodeInvalidPagination)
+		return
+	}
+	limit, offset := params.Limit, params.Offset
+
+	tenants, err := h.service.ListTenants(ctx, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to list tenants")
+		h.writeError(w, r, "Failed to list tenants", http.StatusInternalServerError, "LIST_FAILED")
+		return
+	}
+
+	if tenants == nil {
+		tenants = []*database.Tenant{}
+	}
+
+	response := ListTenantsResponse{
+		Tenants: tenants,
+		Pagination: PaginationMeta{
+			Limit:  limit,
+			Offset: offset,
+		},
+	}
+	// Tenant listing does not count the total, so only the page position is reported
+	if err := h.responder.List(w, r, http.StatusOK, response, tenants, server.NewPagination(limit, offset, 0)); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode response")
+	}
+}
+
+// canRead reports whether the caller may read the tenant with the given ID: admins
+// may read any tenant, and other callers only the one named by their token's
+// tenant_id claim. The X-Tenant-ID header does not count, as any caller can set it.
+func (h *Handlers) canRead(r *http.Request, id uuid.UUID) bool {
+	if middleware.IsAdminRequest(r, h.adminToken) {
+		return true
+	}
+	claimed, _ := r.Context().Value(types.TenantIDKey).(string)
+	return claimed == id.String()
+}
+
+// callerKey identifies the caller for per-caller rate limits: the user JWTAuth
+// authenticated, or else the client address. Request headers are never used, as a
+// caller could send a new value with every request to escape the limit.
+func callerKey(r *http.Request) string {
+	if user, _ := r.Context().Value(types.UserIDKey).(string); user != "" {
+		return user
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeJSON writes a JSON response
+func (h *Handlers) writeJSON(w http.ResponseWriter, r *http.Request, statusCode int, v interface{}) {
+	if err := h.responder.JSON(w, r, statusCode, v); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode response")
+	}
+}
+
+// writeError writes an error response
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, message string, statusCode int, code string) {
+	response := ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    code,
+		Time:    time.Now().UTC(),
+	}
+
+	apiErr := &types.APIError{Code: code, Message: message}
+	h.respondError(w, r, statusCode, apiErr, response)
+}
+
+// writeRetryableError writes an error for a transient failure along with the
+// Retry-After header and retry metadata telling clients when to try again
+func (h *Handlers) writeRetryableError(w http.ResponseWriter, r *http.Request, message string, statusCode int, code string, retryAfter time.Duration) {
+	apiErr := (&types.APIError{Code: code, Message: message}).WithRetryAfter(retryAfter)
+	response := ErrorResponse{
+		Error:        http.StatusText(statusCode),
+		Message:      message,
+		Code:         code,
+		Time:         time.Now().UTC(),
+		Retryable:    apiErr.Retryable,
+		RetryAfterMs: apiErr.RetryAfterMs,
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(middleware.RetryAfterSeconds(retryAfter)))
+	h.respondError(w, r, statusCode, apiErr, response)
+}
+
+// respondError writes an error response in either the bare or enveloped shape
+func (h *Handlers) respondError(w http.ResponseWriter, r *http.Request, statusCode int, apiErr *types.APIError, response ErrorResponse) {
+	if err := h.responder.Error(w, r, statusCode, apiErr, response); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode error response")
+	}
+}
@@ -0,0 +1,372 @@
+package tenants
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTenantService is a mock implementation of the tenant service for testing
+type MockTenantService struct {
+	mock.Mock
+}
+
+func (m *MockTenantService) CreateTenant(ctx context.Context, req *database.CreateTenantRequest) (*database.Tenant, error) {
+	args := m.Called(ctx, req)
+	if tenant := args.Get(0); tenant != nil {
+		return tenant.(*database.Tenant), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTenantService) GetTenant(ctx context.Context, tenantID uuid.UUID) (*database.Tenant, error) {
+	args := m.Called(ctx, tenantID)
+	if tenant := args.Get(0); tenant != nil {
+		return tenant.(*database.Tenant), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTenantService) GetTenantBySlug(ctx context.Context, slug string) (*database.Tenant, error) {
+	args := m.Called(ctx, slug)
+	if tenant := args.Get(0); tenant != nil {
+		return tenant.(*database.Tenant), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTenantService) ListTenants(ctx context.Context, status string, limit, offset int) ([]*database.Tenant, error) {
+	args := m.Called(ctx, status, limit, offset)
+	return args.Get(0).([]*database.Tenant), args.Error(1)
+}
+
+func (m *MockTenantService) GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*database.TenantUsage, error) {
+	args := m.Called(ctx, tenantID)
+	if usage := args.Get(0); usage != nil {
+		return usage.(*database.TenantUsage), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// Compile-time check that MockTenantService implements TenantService
+var _ TenantService = (*MockTenantService)(nil)
+
+func setupTestHandlers() (*Handlers, *MockTenantService) {
+	mockService := &MockTenantService{}
+	testLogger := logger.New("debug", "text")
+	handlers := NewHandlers(mockService, testLogger, WithAdminToken("admin-secret"))
+	return handlers, mockService
+}
+
+// asAdmin returns req carrying the admin token setupTestHandlers configures
+func asAdmin(req *http.Request) *http.Request {
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	return req
+}
+
+// asTenant returns req with the tenant_id claim of a token for tenantID
+func asTenant(req *http.Request, tenantID uuid.UUID) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), types.TenantIDKey, tenantID.String()))
+}
+
+func newCreateTenantRequest(t *testing.T) *http.Request {
+	body, err := json.Marshal(database.CreateTenantRequest{Name: "acme", DisplayName: "Acme Corp"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tenants", bytes.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), types.UserIDKey, "script@company.com"))
+}
+
+func TestHandlers_CreateTenant(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("successful creation", func(t *testing.T) {
+		tenant := &database.Tenant{ID: uuid.New(), Name: "acme", DisplayName: "Acme Corp", Status: "active"}
+		mockService.On("CreateTenant", mock.Anything, mock.MatchedBy(func(req *database.CreateTenantRequest) bool {
+			return req.Name == "acme" && req.RequestedBy == "script@company.com"
+		})).Return(tenant, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTenant(rr, newCreateTenantRequest(t))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var response database.Tenant
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, tenant.ID, response.ID)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("throttled creation returns 429", func(t *testing.T) {
+		mockService.On("CreateTenant", mock.Anything, mock.Anything).
+			Return(nil, &database.TenantCreationThrottledError{Scope: "caller", RetryAfter: 1500 * time.Millisecond}).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTenant(rr, newCreateTenantRequest(t))
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "TENANT_CREATION_THROTTLED", errorResp.Code)
+		require.NotNil(t, errorResp.Retryable)
+		assert.True(t, *errorResp.Retryable)
+		assert.Equal(t, int64(1500), errorResp.RetryAfterMs)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("tenant cap returns 507", func(t *testing.T) {
+		mockService.On("CreateTenant", mock.Anything, mock.Anything).Return(nil, database.ErrTenantLimitReached).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTenant(rr, newCreateTenantRequest(t))
+
+		assert.Equal(t, http.StatusInsufficientStorage, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "TENANT_LIMIT_REACHED", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tenants", bytes.NewBufferString(`{"name":"acme"}`))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateTenant(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestHandlers_GetTenant(t *testing.T) {
+	id := uuid.New()
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tenants/"+id.String(), nil)
+		req.SetPathValue("id", id.String())
+		return req
+	}
+
+	t.Run("tenant self", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("GetTenant", mock.Anything, id).Return(&database.Tenant{ID: id, Name: "acme"}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenant(rr, asTenant(newRequest(), id))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("GetTenant", mock.Anything, id).Return(nil, database.ErrTenantNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenant(rr, asAdmin(newRequest()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("other callers are forbidden", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenant(rr, asTenant(newRequest(), uuid.New()))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		req := newRequest()
+		req.Header.Set("X-Tenant-ID", id.String())
+		rr = httptest.NewRecorder()
+		handlers.GetTenant(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "the X-Tenant-ID header does not count")
+
+		mockService.AssertNotCalled(t, "GetTenant", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_GetTenantBySlug(t *testing.T) {
+	newRequest := func(slug string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tenants/by-slug/"+slug, nil)
+		req.SetPathValue("slug", slug)
+		return req
+	}
+
+	t.Run("found", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		tenant := &database.Tenant{ID: uuid.New(), Name: "acme", Slug: "acme", DisplayName: "Acme Corp"}
+		mockService.On("GetTenantBySlug", mock.Anything, "acme").Return(tenant, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenantBySlug(rr, asTenant(newRequest("acme"), tenant.ID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response database.Tenant
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, tenant.ID, response.ID)
+		assert.Equal(t, "acme", response.Slug)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		mockService.On("GetTenantBySlug", mock.Anything, "missing").Return(nil, database.ErrTenantNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenantBySlug(rr, asAdmin(newRequest("missing")))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("other tenants are forbidden", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+		tenant := &database.Tenant{ID: uuid.New(), Name: "acme", Slug: "acme"}
+		mockService.On("GetTenantBySlug", mock.Anything, "acme").Return(tenant, nil).Once()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenantBySlug(rr, asTenant(newRequest("acme"), uuid.New()))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NotContains(t, rr.Body.String(), tenant.ID.String())
+	})
+
+	t.Run("invalid slug is rejected without a lookup", func(t *testing.T) {
+		handlers, mockService := setupTestHandlers()
+
+		rr := httptest.NewRecorder()
+		handlers.GetTenantBySlug(rr, newRequest("Not_A_Slug"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "INVALID_SLUG")
+		mockService.AssertNotCalled(t, "GetTenantBySlug", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandlers_CreateTenant_SlugTaken(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+	mockService.On("CreateTenant", mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("%w: acme", database.ErrSlugTaken)).Once()
+
+	rr := httptest.NewRecorder()
+	handlers.CreateTenant(rr, newCreateTenantRequest(t))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SLUG_TAKEN")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandlers_GetTenantUsage(t *testing.T) {
+	id := uuid.New()
+	limit := 10
+	usage := &database.TenantUsage{
+		TenantID:     id,
+		Teams:        database.UsageMetric{Used: 3, Limit: &limit},
+		Applications: database.UsageMetric{Used: 12, Limit: &limit, Exceeded: true},
+	}
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tenants/"+id.String()+"/usage", nil)
+		req.SetPathValue("id", id.String())
+		return req
+	}
+	newHandlers := func() (*Handlers, *MockTenantService) {
+		mockService := &MockTenantService{}
+		return NewHandlers(mockService, logger.New("debug", "text"), WithAdminToken("admin-secret")), mockService
+	}
+
+	t.Run("admin", func(t *testing.T) {
+		handlers, mockService := newHandlers()
+		mockService.On("GetTenantUsage", mock.Anything, id).Return(usage, nil).Once()
+
+		req := newRequest()
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		handlers.GetTenantUsage(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response database.TenantUsage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Teams.Used)
+		assert.True(t, response.Applications.Exceeded)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("tenant self", func(t *testing.T) {
+		handlers, mockService := newHandlers()
+		mockService.On("GetTenantUsage", mock.Anything, id).Return(usage, nil).Once()
+
+		req := newRequest()
+		req = req.WithContext(context.WithValue(req.Context(), types.TenantIDKey, id.String()))
+		rr := httptest.NewRecorder()
+		handlers.GetTenantUsage(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("other tenant is forbidden", func(t *testing.T) {
+		handlers, mockService := newHandlers()
+
+		req := newRequest()
+		req = req.WithContext(context.WithValue(req.Context(), types.TenantIDKey, uuid.New().String()))
+		req.Header.Set("Authorization", "Bearer wrong")
+		rr := httptest.NewRecorder()
+		handlers.GetTenantUsage(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		req = newRequest()
+		req.Header.Set("X-Tenant-ID", id.String())
+		rr = httptest.NewRecorder()
+		handlers.GetTenantUsage(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code, "the X-Tenant-ID header does not count")
+		mockService.AssertNotCalled(t, "GetTenantUsage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		handlers, mockService := newHandlers()
+		mockService.On("GetTenantUsage", mock.Anything, id).Return(nil, database.ErrTenantNotFound).Once()
+
+		req := newRequest()
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		handlers.GetTenantUsage(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestCallerKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tenants", nil)
+	req.RemoteAddr = "203.0.113.7:54321"
+	assert.Equal(t, "203.0.113.7", callerKey(req))
+
+	req.Header.Set("X-User-Email", "admin@company.com")
+	assert.Equal(t, "203.0.113.7", callerKey(req), "headers do not name the caller")
+
+	req = req.WithContext(context.WithValue(req.Context(), types.UserIDKey, "admin@company.com"))
+	assert.Equal(t, "admin@company.com", callerKey(req))
+}
@@ -0,0 +1,20 @@
+package tenants
+
+import (
+	"context"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/google/uuid"
+)
+
+// TenantService defines the interface for tenant operations
+type TenantService interface {
+	CreateTenant(ctx context.Context, req *database.CreateTenantRequest) (*database.Tenant, error)
+	GetTenant(ctx context.Context, tenantID uuid.UUID) (*database.Tenant, error)
+	GetTenantBySlug(ctx context.Context, slug string) (*database.Tenant, error)
+	ListTenants(ctx context.Context, status string, limit, offset int) ([]*database.Tenant, error)
+	GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*database.TenantUsage, error)
+}
+
+// Compile-time check that TenantManager implements TenantService
+var _ TenantService = (*database.TenantManager)(nil)
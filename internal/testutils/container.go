@@ -0,0 +1,101 @@
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// testContainerImage is the PostgreSQL image test containers run, matching the one in
+// docker-compose.yml
+const testContainerImage = "postgres:15-alpine"
+
+// sharedContainer is the container SetupTestDB uses when TEST_DATABASE_URL is unset.
+// It is started on first use and left for the testcontainers reaper to remove when
+// the test binary exits, so a package's tests share one database.
+var sharedContainer struct {
+	once sync.Once
+	url  string
+	err  error
+}
+
+// SetupTestContainerDB starts a throwaway PostgreSQL container, runs migrations and
+// returns a pool connected to it. Cleanup closes the pool and removes the container.
+// Docker, or a compatible runtime, must be available.
+func SetupTestContainerDB(t *testing.T, ctx context.Context) (*database.Pool, func()) {
+	t.Helper()
+
+	container, dbURL, err := startTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start test database container: %v", err)
+	}
+
+	pool, closePool := connectTestDB(t, ctx, dbURL)
+	cleanup := func() {
+		closePool()
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			t.Logf("Failed to remove test database container: %v", err)
+		}
+	}
+
+	return pool, cleanup
+}
+
+// sharedContainerURL returns the connection string of the shared test container,
+// starting it if this is the first call
+func sharedContainerURL(t *testing.T, ctx context.Context) string {
+	t.Helper()
+
+	sharedContainer.once.Do(func() {
+		// The container outlives this test, so it is not tied to its context
+		_, sharedContainer.url, sharedContainer.err = startTestContainer(context.WithoutCancel(ctx))
+	})
+	if sharedContainer.err != nil {
+		t.Fatalf("Failed to start test database container (set TEST_DATABASE_URL to use a running database): %v", sharedContainer.err)
+	}
+	return sharedContainer.url
+}
+
+// startTestContainer runs a PostgreSQL container with the development credentials and
+// waits for it to accept connections
+func startTestContainer(ctx context.Context) (_ *postgres.PostgresContainer, _ string, err error) {
+	// testcontainers panics when it finds no container runtime
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+
+	container, err := postgres.Run(ctx, testContainerImage,
+		postgres.WithDatabase("platform"),
+		postgres.WithUsername("platform"),
+		postgres.WithPassword("platform_dev_password"),
+		// PostgreSQL restarts once after initialization, so the second message is
+		// the one that means it is ready
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute),
+		),
+	)
+	if err != nil {
+		if container != nil {
+			testcontainers.TerminateContainer(container)
+		}
+		return nil, "", err
+	}
+
+	dbURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		testcontainers.TerminateContainer(container)
+		return nil, "", err
+	}
+	return container, dbURL, nil
+}
@@ -0,0 +1,26 @@
+package testutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/database"
+)
+
+func TestSetupTestContainerDB(t *testing.T) {
+	SkipIfShort(t)
+
+	ctx := context.Background()
+	pool, cleanup := SetupTestContainerDB(t, ctx)
+	defer cleanup()
+
+	created := SetupTestTenant(t, ctx, pool)
+
+	tenant, err := database.NewTenantManager(pool).GetTenant(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tenant: %v", err)
+	}
+	if tenant.Name != created.Name {
+		t.Errorf("Expected tenant %s, got %s", created.Name, tenant.Name)
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,8 +21,28 @@ const (
 	TeamIDKey ContextKey = "team_id"
 	// OrganizationIDKey is the context key for organization ID
 	OrganizationIDKey ContextKey = "organization_id"
+	// TenantContextKey is the context key for the tenant context resolved by the
+	// server's tenant validation
+	TenantContextKey ContextKey = "tenant_context"
+	// ResolvedTenantIDKey is the context key for the uuid.UUID of the tenant a request
+	// was resolved to by server.TenantResolver.Middleware
+	ResolvedTenantIDKey ContextKey = "resolved_tenant_id"
 )
 
+// RequestIDFromContext returns the request ID set by the RequestID middleware, or an
+// empty string if ctx has none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// ResolvedTenantIDFromContext returns the tenant resolved for the request, or uuid.Nil
+// if ctx has none
+func ResolvedTenantIDFromContext(ctx context.Context) uuid.UUID {
+	tenantID, _ := ctx.Value(ResolvedTenantIDKey).(uuid.UUID)
+	return tenantID
+}
+
 // =============================================================================
 // KUBERNETES-STYLE RESOURCE DEFINITIONS
 // =============================================================================
@@ -262,6 +283,21 @@ const (
 	TeamRoleViewer     TeamRole = "viewer"
 )
 
+// teamRoleRanks orders the team roles, each granting everything the ones below do
+var teamRoleRanks = map[TeamRole]int{
+	TeamRoleViewer:     1,
+	TeamRoleDeveloper:  2,
+	TeamRoleMaintainer: 3,
+	TeamRoleOwner:      4,
+}
+
+// Includes reports whether a member with role r may do what role requires. Unknown
+// roles include nothing and are included by nothing.
+func (r TeamRole) Includes(role TeamRole) bool {
+	rank, required := teamRoleRanks[r], teamRoleRanks[role]
+	return rank > 0 && required > 0 && rank >= required
+}
+
 // MemberStatus represents the status of a team member
 type MemberStatus string
 
@@ -703,6 +739,21 @@ type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
+	// Retryable and RetryAfterMs tell clients a failure is transient and when to try
+	// again; they are only set for conditions such as overload or rate limiting
+	Retryable    *bool `json:"retryable,omitempty"`
+	RetryAfterMs int64 `json:"retry_after_ms,omitempty"`
+}
+
+// WithRetryAfter marks the error as retryable after the given wait
+func (e *APIError) WithRetryAfter(after time.Duration) *APIError {
+	retryable := true
+	e.Retryable = &retryable
+	e.RetryAfterMs = after.Milliseconds()
+	if e.RetryAfterMs < 1 {
+		e.RetryAfterMs = 1
+	}
+	return e
 }
 
 // Meta represents metadata in API responses
@@ -718,4 +769,6 @@ type Pagination struct {
 	PerPage    int   `json:"per_page"`
 	Total      int64 `json:"total"`
 	TotalPages int   `json:"total_pages"`
+	// NextCursor fetches the next page by keyset pagination when more rows exist
+	NextCursor string `json:"next_cursor,omitempty"`
 }
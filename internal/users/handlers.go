@@ -0,0 +1,469 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/aykay76/ai-idp/internal/validation"
+	"github.com/google/uuid"
+)
+
+// Handlers provides HTTP handlers for user resources using native Go HTTP
+type Handlers struct {
+	service   UserService
+	logger    *logger.Logger
+	responder *server.Responder
+
+	pageLimits server.PageLimits
+}
+
+// HandlerOption configures Handlers
+type HandlerOption func(*Handlers)
+
+// WithResponseEnvelope wraps every response in the types.APIResponse envelope
+func WithResponseEnvelope(enabled bool) HandlerOption {
+	return func(h *Handlers) {
+		h.responder = server.NewResponder(enabled)
+	}
+}
+
+// WithPageLimits sets the default and maximum page size for listing users
+func WithPageLimits(limits server.PageLimits) HandlerOption {
+	return func(h *Handlers) {
+		h.pageLimits = limits
+	}
+}
+
+// NewHandlers creates new user handlers
+func NewHandlers(service UserService, appLogger *logger.Logger, opts ...HandlerOption) *Handlers {
+	h := &Handlers{
+		service:   service,
+		logger:    appLogger,
+		responder: server.NewResponder(false),
+
+		pageLimits: server.DefaultPageLimits,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ListUsersResponse represents the response for listing users
+type ListUsersResponse struct {
+	Users      []User         `json:"users"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// PaginationMeta contains pagination metadata
+type PaginationMeta struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ErrorResponse represents an error response, in the shape shared by every service
+type ErrorResponse = server.ErrorResponse
+
+// CreateUser handles POST /api/v1/users
+func (h *Handlers) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+	}).Debug("Creating new user")
+
+	// Parse request body
+	var userReq User
+	if err := json.NewDecoder(r.Body).Decode(&userReq); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to decode user request")
+
+		h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		return
+	}
+	if !h.validate(w, r, &userReq) {
+		return
+	}
+
+	// Users are created in the caller's tenant, whatever the body names
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+	userReq.TenantID = tenantID
+	userReq.CreatedBy = requestActor(r)
+
+	// Create user using service
+	user, err := h.service.CreateUser(ctx, userReq)
+	if err != nil {
+		if errors.Is(err, ErrUserAlreadyExists) {
+			h.writeError(w, r, "A user with this email already exists", http.StatusConflict, "USER_ALREADY_EXISTS")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to create user")
+
+		h.writeError(w, r, "Failed to create user", http.StatusInternalServerError, "CREATE_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"user_id": user.ID,
+	}).Info("User created successfully")
+
+	// Return created user
+	if err := h.responder.JSON(w, r, http.StatusCreated, user); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode user response")
+	}
+}
+
+// GetUser handles GET /api/v1/users/{id}
+func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract user ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"user_id":              id.String(),
+	}).Debug("Getting user")
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	// Get user using service
+	user, err := h.service.GetUser(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			h.writeError(w, r, "User not found", http.StatusNotFound, "USER_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"user_id":         id.String(),
+		}).Error("Failed to get user")
+
+		h.writeError(w, r, "Failed to get user", http.StatusInternalServerError, "GET_FAILED")
+		return
+	}
+
+	// Return user
+	if err := h.responder.JSON(w, r, http.StatusOK, user); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode user response")
+	}
+}
+
+// ListUsers handles GET /api/v1/users
+func (h *Handlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+	}).Debug("Listing users")
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse pagination parameters
+	params, err := server.ParsePagination(r, h.pageLimits)
+	if err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, server.CodeInvalidPagination)
+		return
+	}
+	limit, offset := params.Limit, params.Offset
+
+	// Parse and validate actor filters
+	filters := server.ParseFilterParams(r)
+	if err := filters.Validate(); err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_FILTER")
+		return
+	}
+
+	listReq := ListUsersRequest{
+		TenantID:  tenantID,
+		Role:      r.URL.Query().Get("role"),
+		Team:      r.URL.Query().Get("team"),
+		CreatedBy: filters.CreatedBy,
+		UpdatedBy: filters.UpdatedBy,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	// A cursor switches to keyset pagination; one extra row shows whether more exist
+	cursor, err := server.ParseCursorParam(r)
+	if err != nil {
+		h.writeError(w, r, err.Error(), http.StatusBadRequest, "INVALID_CURSOR")
+		return
+	}
+	if cursor != nil {
+		offset = 0
+		listReq.After = cursor
+		listReq.Limit = limit + 1
+		listReq.Offset = 0
+	}
+
+	// List users using service
+	users, total, err := h.service.ListUsers(ctx, listReq)
+	if err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to list users")
+
+		h.writeError(w, r, "Failed to list users", http.StatusInternalServerError, "LIST_FAILED")
+		return
+	}
+
+	if users == nil {
+		users = []User{}
+	}
+
+	more := offset+len(users) < total
+	if cursor != nil {
+		more = len(users) > limit
+		if more {
+			users = users[:limit]
+		}
+	}
+
+	var nextCursor string
+	if more && len(users) > 0 {
+		last := users[len(users)-1]
+		nextCursor = database.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	// Create response
+	response := ListUsersResponse{
+		Users: users,
+		Pagination: PaginationMeta{
+			Limit:      limit,
+			Offset:     offset,
+			Total:      total,
+			NextCursor: nextCursor,
+		},
+	}
+
+	page := server.NewPagination(limit, offset, total)
+	page.NextCursor = nextCursor
+
+	// Return users list
+	if err := h.responder.List(w, r, http.StatusOK, response, users, page); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode users response")
+	}
+}
+
+// UpdateUser handles PUT /api/v1/users/{id}
+func (h *Handlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract user ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"user_id":              id.String(),
+	}).Debug("Updating user")
+
+	// Parse request body
+	var userReq User
+	if err := json.NewDecoder(r.Body).Decode(&userReq); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to decode user update request")
+
+		h.writeError(w, r, "Invalid JSON in request body", http.StatusBadRequest, "INVALID_JSON")
+		return
+	}
+	if !h.validate(w, r, &userReq) {
+		return
+	}
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	// Set the ID from path; only users of the caller's tenant can be updated
+	userReq.ID = id
+	userReq.TenantID = tenantID
+	userReq.UpdatedBy = nil
+	if actor := requestActor(r); actor != "" {
+		userReq.UpdatedBy = &actor
+	}
+
+	// Update user using service
+	user, err := h.service.UpdateUser(ctx, userReq)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			h.writeError(w, r, "User not found", http.StatusNotFound, "USER_NOT_FOUND")
+			return
+		}
+		if errors.Is(err, ErrUserAlreadyExists) {
+			h.writeError(w, r, "A user with this email already exists", http.StatusConflict, "USER_ALREADY_EXISTS")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"user_id":         id.String(),
+		}).Error("Failed to update user")
+
+		h.writeError(w, r, "Failed to update user", http.StatusInternalServerError, "UPDATE_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"user_id": user.ID,
+	}).Info("User updated successfully")
+
+	// Return updated user
+	if err := h.responder.JSON(w, r, http.StatusOK, user); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode user response")
+	}
+}
+
+// DeleteUser handles DELETE /api/v1/users/{id}
+func (h *Handlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Extract user ID from path
+	id, err := server.ParsePathUUID(r, "id")
+	if err != nil {
+		h.responder.PathError(w, r, err)
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		logger.FieldHTTPMethod: r.Method,
+		logger.FieldHTTPPath:   r.URL.Path,
+		"user_id":              id.String(),
+	}).Debug("Deleting user")
+
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	// Delete user using service
+	err = h.service.DeleteUser(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			h.writeError(w, r, "User not found", http.StatusNotFound, "USER_NOT_FOUND")
+			return
+		}
+
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+			"user_id":         id.String(),
+		}).Error("Failed to delete user")
+
+		h.writeError(w, r, "Failed to delete user", http.StatusInternalServerError, "DELETE_FAILED")
+		return
+	}
+
+	h.logger.WithFields(logger.LogFields{
+		"user_id": id.String(),
+	}).Info("User deleted successfully")
+
+	// Return success
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tenantID returns the tenant of the request, as resolved by the tenant middleware or
+// else from the tenant context or X-Tenant-ID header, writing a 400 and returning
+// false when it names none
+func (h *Handlers) tenantID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	if tenantID := types.ResolvedTenantIDFromContext(r.Context()); tenantID != uuid.Nil {
+		return tenantID, true
+	}
+
+	tenantCtx, err := server.GetTenantFromContext(r)
+	if err != nil {
+		if tenantCtx, err = server.ExtractTenantContext(r); err != nil {
+			h.writeError(w, r, "Invalid tenant context: "+err.Error(), http.StatusBadRequest, "INVALID_TENANT_CONTEXT")
+			return uuid.Nil, false
+		}
+	}
+	return tenantCtx.TenantID, true
+}
+
+// requestActor returns the authenticated user making a change, or an empty string so
+// that the service records the system
+func requestActor(r *http.Request) string {
+	userID, _ := r.Context().Value(types.UserIDKey).(string)
+	return userID
+}
+
+// validate checks a user against its validate tags, writing a 400 with the failing
+// fields and returning false if it is invalid
+func (h *Handlers) validate(w http.ResponseWriter, r *http.Request, user *User) bool {
+	err := validation.Struct(user)
+	if err == nil {
+		return true
+	}
+
+	var errs server.ValidationErrors
+	if !errors.As(err, &errs) {
+		h.writeError(w, r, "Failed to validate request", http.StatusInternalServerError, "VALIDATION_ERROR")
+		return false
+	}
+	if err := h.responder.ValidationErrors(w, r, errs); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode validation response")
+	}
+	return false
+}
+
+// writeError writes an error response
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, message string, statusCode int, code string) {
+	response := ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    code,
+		Time:    time.Now().UTC(),
+	}
+
+	apiErr := &types.APIError{Code: code, Message: message}
+	if err := h.responder.Error(w, r, statusCode, apiErr, response); err != nil {
+		h.logger.WithFields(logger.LogFields{
+			logger.FieldError: err.Error(),
+		}).Error("Failed to encode error response")
+	}
+}
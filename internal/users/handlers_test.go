@@ -0,0 +1,509 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/logger"
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserService is a mock implementation of the user service for testing
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) CreateUser(ctx context.Context, user User) (User, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockUserService) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (User, error) {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockUserService) ListUsers(ctx context.Context, req ListUsersRequest) ([]User, int, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserService) UpdateUser(ctx context.Context, user User) (User, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockUserService) DeleteUser(ctx context.Context, tenantID, userID uuid.UUID) error {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Error(0)
+}
+
+func setupTestHandlers() (*Handlers, *MockUserService) {
+	mockService := &MockUserService{}
+	testLogger := logger.New("debug", "text")
+	handlers := NewHandlers(mockService, testLogger)
+	return handlers, mockService
+}
+
+// testTenantID is the tenant newTenantRequest acts in
+var testTenantID = uuid.New()
+
+// newTenantRequest creates a request acting in testTenantID
+func newTenantRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("X-Tenant-ID", testTenantID.String())
+	return req
+}
+
+func TestHandlers_CreateUser(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	create := func(body io.Reader) *http.Request {
+		return newTenantRequest(http.MethodPost, "/api/v1/users", body)
+	}
+
+	t.Run("successful creation", func(t *testing.T) {
+		user := User{
+			Email:       "dev@company.com",
+			DisplayName: "Dev",
+			Roles:       []string{"developer"},
+			Provider:    types.AuthProvider{Type: "github", ProviderID: "1234", Username: "dev"},
+		}
+
+		expectedUser := user
+		expectedUser.ID = uuid.New()
+		expectedUser.CreatedAt = time.Now().UTC()
+		expectedUser.UpdatedAt = expectedUser.CreatedAt
+
+		mockService.On("CreateUser", mock.Anything, mock.MatchedBy(func(u User) bool {
+			return u.TenantID == testTenantID && u.CreatedBy == "admin@company.com"
+		})).Return(expectedUser, nil).Once()
+
+		reqBody, err := json.Marshal(user)
+		require.NoError(t, err)
+
+		req := create(bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), types.UserIDKey, "admin@company.com"))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateUser(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var responseUser User
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseUser))
+		assert.Equal(t, expectedUser.ID, responseUser.ID)
+		assert.Equal(t, expectedUser.Provider, responseUser.Provider)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		req := create(strings.NewReader("invalid json"))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateUser(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "INVALID_JSON", errorResp.Code)
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		mockService.On("CreateUser", mock.Anything, mock.AnythingOfType("User")).Return(User{}, ErrUserAlreadyExists).Once()
+
+		req := create(strings.NewReader(`{"email":"dev@company.com"}`))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateUser(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "USER_ALREADY_EXISTS", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService.On("CreateUser", mock.Anything, mock.AnythingOfType("User")).Return(User{}, fmt.Errorf("connection refused")).Once()
+
+		req := create(strings.NewReader(`{"email":"dev@company.com"}`))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateUser(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "Failed to create user", errorResp.Message)
+		assert.Equal(t, "CREATE_FAILED", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"email":"dev@company.com"}`))
+
+		rr := httptest.NewRecorder()
+		handlers.CreateUser(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "INVALID_TENANT_CONTEXT", errorResp.Code)
+	})
+
+	t.Run("the body cannot choose the tenant", func(t *testing.T) {
+		mockService.On("CreateUser", mock.Anything, mock.MatchedBy(func(u User) bool {
+			return u.TenantID == testTenantID && u.CreatedBy == ""
+		})).Return(User{ID: uuid.New(), Email: "dev@company.com"}, nil).Once()
+
+		body := fmt.Sprintf(`{"email":"dev@company.com","tenant_id":%q,"created_by":"someone-else"}`, uuid.New())
+		rr := httptest.NewRecorder()
+		handlers.CreateUser(rr, create(strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_GetUser(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("successful get", func(t *testing.T) {
+		userID := uuid.New()
+		expectedUser := User{ID: userID, Email: "dev@company.com", Teams: []string{"platform"}}
+
+		mockService.On("GetUser", mock.Anything, testTenantID, userID).Return(expectedUser, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/users/"+userID.String(), nil)
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetUser(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var responseUser User
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseUser))
+		assert.Equal(t, expectedUser.ID, responseUser.ID)
+		assert.Equal(t, expectedUser.Teams, responseUser.Teams)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid user ID format", func(t *testing.T) {
+		req := newTenantRequest(http.MethodGet, "/api/v1/users/invalid-uuid", nil)
+		req.SetPathValue("id", "invalid-uuid")
+
+		rr := httptest.NewRecorder()
+		handlers.GetUser(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, server.CodeInvalidID, errorResp.Code)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("GetUser", mock.Anything, testTenantID, userID).Return(User{}, ErrUserNotFound).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/users/"+userID.String(), nil)
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetUser(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "User not found", errorResp.Message)
+		assert.Equal(t, "USER_NOT_FOUND", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_ListUsers(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := make([]User, 3)
+	for i := range page {
+		page[i] = User{ID: uuid.New(), Email: fmt.Sprintf("user-%d@company.com", i), CreatedAt: created}
+	}
+
+	t.Run("passes filters to the service", func(t *testing.T) {
+		mockService.On("ListUsers", mock.Anything, ListUsersRequest{
+			TenantID:  testTenantID,
+			Role:      "admin",
+			Team:      "platform",
+			CreatedBy: "alice",
+			Limit:     10,
+			Offset:    5,
+		}).Return(page, 8, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/users?role=admin&team=platform&created_by=alice&limit=10&offset=5", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListUsers(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListUsersResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Users, 3)
+		assert.Equal(t, PaginationMeta{Limit: 10, Offset: 5, Total: 8}, response.Pagination)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		mockService.On("ListUsers", mock.Anything, ListUsersRequest{TenantID: testTenantID, Limit: 50}).Return([]User(nil), 0, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/users", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListUsers(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"users":[]`)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("fetches one extra row and returns the next cursor", func(t *testing.T) {
+		cursor := database.Cursor{CreatedAt: created, ID: uuid.New()}
+		mockService.On("ListUsers", mock.Anything, ListUsersRequest{TenantID: testTenantID, After: &cursor, Limit: 3}).Return(page, 40, nil).Once()
+
+		req := newTenantRequest(http.MethodGet, "/api/v1/users?limit=2&cursor="+cursor.Encode(), nil)
+		rr := httptest.NewRecorder()
+		handlers.ListUsers(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListUsersResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Users, 2)
+		assert.Equal(t, database.Cursor{CreatedAt: created, ID: page[1].ID}.Encode(), response.Pagination.NextCursor)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejects an invalid cursor", func(t *testing.T) {
+		req := newTenantRequest(http.MethodGet, "/api/v1/users?cursor=***", nil)
+		rr := httptest.NewRecorder()
+		handlers.ListUsers(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "INVALID_CURSOR", errorResp.Code)
+	})
+}
+
+func TestHandlers_UpdateUser(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	update := func(userID uuid.UUID, body string) *httptest.ResponseRecorder {
+		req := newTenantRequest(http.MethodPut, "/api/v1/users/"+userID.String(), strings.NewReader(body))
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.UpdateUser(rr, req)
+		return rr
+	}
+
+	t.Run("successful update", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u User) bool {
+			return u.ID == userID && u.Email == "dev@company.com"
+		})).Return(User{ID: userID, Email: "dev@company.com", Roles: []string{"admin"}}, nil).Once()
+
+		rr := update(userID, `{"email":"dev@company.com","roles":["admin"]}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var responseUser User
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseUser))
+		assert.Equal(t, []string{"admin"}, responseUser.Roles)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("UpdateUser", mock.Anything, mock.AnythingOfType("User")).Return(User{}, ErrUserNotFound).Once()
+
+		rr := update(userID, `{"email":"dev@company.com"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("email taken by another user", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("UpdateUser", mock.Anything, mock.AnythingOfType("User")).Return(User{}, ErrUserAlreadyExists).Once()
+
+		rr := update(userID, `{"email":"taken@company.com"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_DeleteUser(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	t.Run("successful deletion", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("DeleteUser", mock.Anything, testTenantID, userID).Return(nil).Once()
+
+		req := newTenantRequest(http.MethodDelete, "/api/v1/users/"+userID.String(), nil)
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.DeleteUser(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Empty(t, rr.Body.String())
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("DeleteUser", mock.Anything, testTenantID, userID).Return(ErrUserNotFound).Once()
+
+		req := newTenantRequest(http.MethodDelete, "/api/v1/users/"+userID.String(), nil)
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.DeleteUser(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		var errorResp ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errorResp))
+		assert.Equal(t, "USER_NOT_FOUND", errorResp.Code)
+
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandlers_OtherTenantsUsers(t *testing.T) {
+	handlers, mockService := setupTestHandlers()
+
+	// The user belongs to another tenant, so the service does not find it in testTenantID
+	userID := uuid.New()
+	mockService.On("GetUser", mock.Anything, testTenantID, userID).Return(User{}, ErrUserNotFound).Once()
+	mockService.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u User) bool {
+		return u.ID == userID && u.TenantID == testTenantID
+	})).Return(User{}, ErrUserNotFound).Once()
+	mockService.On("DeleteUser", mock.Anything, testTenantID, userID).Return(ErrUserNotFound).Once()
+
+	for _, call := range []struct {
+		handler http.HandlerFunc
+		method  string
+		body    string
+	}{
+		{handlers.GetUser, http.MethodGet, ""},
+		{handlers.UpdateUser, http.MethodPut, `{"email":"dev@company.com","tenant_id":"` + uuid.New().String() + `"}`},
+		{handlers.DeleteUser, http.MethodDelete, ""},
+	} {
+		req := newTenantRequest(call.method, "/api/v1/users/"+userID.String(), strings.NewReader(call.body))
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		call.handler(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code, call.method)
+	}
+	mockService.AssertExpectations(t)
+
+	t.Run("requests without a tenant are rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+userID.String(), nil)
+		req.SetPathValue("id", userID.String())
+
+		rr := httptest.NewRecorder()
+		handlers.GetUser(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = httptest.NewRecorder()
+		handlers.ListUsers(rr, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestHandlers_UserValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"missing email", `{"display_name":"Dev"}`, []string{"email"}},
+		{"bad email", `{"email":"dev"}`, []string{"email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers, mockService := setupTestHandlers()
+
+			userID := uuid.New()
+			update := httptest.NewRequest(http.MethodPut, "/api/v1/users/"+userID.String(), strings.NewReader(tt.body))
+			update.SetPathValue("id", userID.String())
+
+			for _, call := range []struct {
+				handler http.HandlerFunc
+				req     *http.Request
+			}{
+				{handlers.CreateUser, httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(tt.body))},
+				{handlers.UpdateUser, update},
+			} {
+				rr := httptest.NewRecorder()
+				call.handler(rr, call.req)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+				var response struct {
+					Details struct {
+						ValidationErrors server.ValidationErrors `json:"validation_errors"`
+					} `json:"details"`
+				}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+				var fields []string
+				for _, e := range response.Details.ValidationErrors {
+					fields = append(fields, e.Field)
+				}
+				assert.Equal(t, tt.fields, fields)
+			}
+
+			mockService.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+			mockService.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		})
+	}
+}
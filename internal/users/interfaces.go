@@ -0,0 +1,16 @@
+package users
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UserService defines the interface for user operations
+type UserService interface {
+	CreateUser(ctx context.Context, user User) (User, error)
+	GetUser(ctx context.Context, tenantID, userID uuid.UUID) (User, error)
+	ListUsers(ctx context.Context, req ListUsersRequest) ([]User, int, error)
+	UpdateUser(ctx context.Context, user User) (User, error)
+	DeleteUser(ctx context.Context, tenantID, userID uuid.UUID) error
+}
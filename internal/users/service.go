@@ -0,0 +1,435 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Common errors
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrInvalidUserData   = errors.New("invalid user data")
+)
+
+// Service provides user management operations
+type Service struct {
+	db        *database.Pool
+	failover  *database.Failover
+	readRetry database.RetryPolicy
+}
+
+// Compile-time check that Service implements UserService
+var _ UserService = (*Service)(nil)
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithFailover serves reads from the read replica while the primary is unavailable
+func WithFailover(f *database.Failover) Option {
+	return func(s *Service) {
+		s.failover = f
+	}
+}
+
+// WithReadRetry retries read-only queries that fail with a transient error. Writes
+// are never retried.
+func WithReadRetry(p database.RetryPolicy) Option {
+	return func(s *Service) {
+		s.readRetry = p
+	}
+}
+
+// NewService creates a new user service
+func NewService(db *database.Pool, opts ...Option) *Service {
+	s := &Service{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// reader returns the pool queries that only read should use
+func (s *Service) reader() *database.Pool {
+	if s.failover != nil {
+		return s.failover.Reader()
+	}
+	return s.db
+}
+
+// User represents a user of the platform within a tenant
+type User struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	TenantID    uuid.UUID          `json:"tenant_id" db:"tenant_id"`
+	Email       string             `json:"email" db:"email" validate:"required,email,max=255"`
+	DisplayName string             `json:"display_name" db:"display_name" validate:"max=255"`
+	AvatarURL   *string            `json:"avatar_url,omitempty" db:"avatar_url" validate:"omitempty,max=500"`
+	Roles       []string           `json:"roles" db:"roles"`
+	Teams       []string           `json:"teams" db:"teams"`
+	Provider    types.AuthProvider `json:"provider" db:"provider"`
+	Preferences map[string]string  `json:"preferences" db:"preferences"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" db:"updated_at"`
+	CreatedBy   string             `json:"created_by" db:"created_by"`
+	UpdatedBy   *string            `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// userColumns are the columns scanned by scanUser, in order
+const userColumns = `id, tenant_id, email, display_name, avatar_url, roles, teams,
+	provider_type, provider_id, provider_username, preferences, created_at,
+	updated_at, created_by, updated_by`
+
+// normalize trims and lowercases the email, which is unique per tenant, and fills
+// in defaults for empty fields
+func (u *User) normalize() {
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	if u.DisplayName == "" {
+		u.DisplayName = u.Email
+	}
+	if u.Roles == nil {
+		u.Roles = []string{}
+	}
+	if u.Teams == nil {
+		u.Teams = []string{}
+	}
+	if u.Preferences == nil {
+		u.Preferences = make(map[string]string)
+	}
+}
+
+// CreateUser creates a new user in user.TenantID, which is required. Users created
+// without a CreatedBy are recorded as created by the system.
+func (s *Service) CreateUser(ctx context.Context, user User) (User, error) {
+	if user.TenantID == uuid.Nil {
+		return User{}, fmt.Errorf("%w: tenant ID is required", ErrInvalidUserData)
+	}
+
+	// Set default values
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+
+	user.CreatedAt = time.Now().UTC()
+	user.UpdatedAt = user.CreatedAt
+
+	if user.CreatedBy == "" {
+		user.CreatedBy = "system"
+	}
+
+	user.normalize()
+	if user.Email == "" {
+		return User{}, fmt.Errorf("%w: email is required", ErrInvalidUserData)
+	}
+
+	rolesJSON, teamsJSON, preferencesJSON, err := marshalUserFields(user)
+	if err != nil {
+		return User{}, err
+	}
+
+	// Insert user into database
+	query := `
+		INSERT INTO resource_management.users (` + userColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	_, err = s.db.Exec(ctx, query,
+		user.ID, user.TenantID, user.Email, user.DisplayName, user.AvatarURL,
+		rolesJSON, teamsJSON, nullString(user.Provider.Type), nullString(user.Provider.ProviderID),
+		nullString(user.Provider.Username), preferencesJSON, user.CreatedAt, user.UpdatedAt,
+		user.CreatedBy, user.UpdatedBy,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return User{}, ErrUserAlreadyExists
+		}
+		return User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUser retrieves a user of a tenant by ID; users of other tenants are not found
+func (s *Service) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (User, error) {
+	query := `SELECT ` + userColumns + ` FROM resource_management.users WHERE tenant_id = $1 AND id = $2`
+
+	var user User
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = scanUser(s.reader().QueryRow(ctx, query, tenantID, userID))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrUserNotFound
+		}
+		return User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// ListUsersRequest represents a request to list users
+type ListUsersRequest struct {
+	TenantID uuid.UUID
+	// Role and Team list only users holding the role or belonging to the team
+	Role      string
+	Team      string
+	CreatedBy string
+	UpdatedBy string
+	// After continues a keyset-paginated listing after the cursor; Offset is ignored
+	After  *database.Cursor
+	Limit  int
+	Offset int
+}
+
+// ListUsers retrieves a paginated list of the users of req.TenantID
+func (s *Service) ListUsers(ctx context.Context, req ListUsersRequest) ([]User, int, error) {
+	var users []User
+	var totalCount int
+
+	// Build filter conditions
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{req.TenantID}
+	if req.Role != "" {
+		args = append(args, req.Role)
+		conditions = append(conditions, fmt.Sprintf("roles ? $%d", len(args)))
+	}
+	if req.Team != "" {
+		args = append(args, req.Team)
+		conditions = append(conditions, fmt.Sprintf("teams ? $%d", len(args)))
+	}
+	if req.CreatedBy != "" {
+		args = append(args, req.CreatedBy)
+		conditions = append(conditions, fmt.Sprintf("created_by = $%d", len(args)))
+	}
+	if req.UpdatedBy != "" {
+		args = append(args, req.UpdatedBy)
+		conditions = append(conditions, fmt.Sprintf("updated_by = $%d", len(args)))
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	// Get total count
+	countQuery := `SELECT COUNT(*) FROM resource_management.users ` + whereClause
+	err := s.readRetry.Do(ctx, func(ctx context.Context) error {
+		return s.reader().QueryRow(ctx, countQuery, args...).Scan(&totalCount)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get users count: %w", err)
+	}
+
+	// Page by keyset after a cursor, otherwise by offset
+	offset := req.Offset
+	if req.After != nil {
+		args = append(args, req.After.CreatedAt, req.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+		offset = 0
+	}
+
+	// Get users with pagination
+	query := `
+		SELECT ` + userColumns + `
+		FROM resource_management.users
+		` + whereClause + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2)
+
+	args = append(args, req.Limit, offset)
+
+	err = s.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		users, err = s.queryUsers(ctx, query, args)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalCount, nil
+}
+
+// queryUsers runs a user list query and scans its rows
+func (s *Service) queryUsers(ctx context.Context, query string, args []interface{}) ([]User, error) {
+	rows, err := s.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// UpdateUser updates an existing user of user.TenantID; users of other tenants are
+// not found
+func (s *Service) UpdateUser(ctx context.Context, user User) (User, error) {
+	// Validate required fields
+	if user.ID == uuid.Nil {
+		return User{}, fmt.Errorf("%w: user ID is required", ErrInvalidUserData)
+	}
+	if user.TenantID == uuid.Nil {
+		return User{}, fmt.Errorf("%w: tenant ID is required", ErrInvalidUserData)
+	}
+
+	user.normalize()
+	if user.Email == "" {
+		return User{}, fmt.Errorf("%w: email is required", ErrInvalidUserData)
+	}
+
+	// Set update timestamp
+	user.UpdatedAt = time.Now().UTC()
+
+	// Updates without a known author are recorded as made by the system
+	if user.UpdatedBy == nil {
+		updatedBy := "system"
+		user.UpdatedBy = &updatedBy
+	}
+
+	rolesJSON, teamsJSON, preferencesJSON, err := marshalUserFields(user)
+	if err != nil {
+		return User{}, err
+	}
+
+	// Update user in database, returning the columns the request does not carry
+	query := `
+		UPDATE resource_management.users SET
+			email = $2, display_name = $3, avatar_url = $4, roles = $5, teams = $6,
+			provider_type = $7, provider_id = $8, provider_username = $9,
+			preferences = $10, updated_at = $11, updated_by = $12
+		WHERE id = $1 AND tenant_id = $13
+		RETURNING created_at, created_by
+	`
+
+	err = s.db.QueryRow(ctx, query,
+		user.ID, user.Email, user.DisplayName, user.AvatarURL, rolesJSON, teamsJSON,
+		nullString(user.Provider.Type), nullString(user.Provider.ProviderID),
+		nullString(user.Provider.Username), preferencesJSON, user.UpdatedAt, user.UpdatedBy,
+		user.TenantID,
+	).Scan(&user.CreatedAt, &user.CreatedBy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrUserNotFound
+		}
+		if isUniqueViolation(err) {
+			return User{}, ErrUserAlreadyExists
+		}
+		return User{}, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser deletes a user of a tenant by ID; users of other tenants are not found
+func (s *Service) DeleteUser(ctx context.Context, tenantID, userID uuid.UUID) error {
+	query := `DELETE FROM resource_management.users WHERE tenant_id = $1 AND id = $2`
+
+	result, err := s.db.Exec(ctx, query, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// scanUser scans a row selected with userColumns
+func scanUser(row pgx.Row) (User, error) {
+	var user User
+	var rolesJSON, teamsJSON, preferencesJSON string
+	var providerType, providerID, providerUsername *string
+
+	err := row.Scan(
+		&user.ID, &user.TenantID, &user.Email, &user.DisplayName, &user.AvatarURL,
+		&rolesJSON, &teamsJSON, &providerType, &providerID, &providerUsername,
+		&preferencesJSON, &user.CreatedAt, &user.UpdatedAt, &user.CreatedBy, &user.UpdatedBy,
+	)
+	if err != nil {
+		return User{}, err
+	}
+
+	if providerType != nil {
+		user.Provider.Type = *providerType
+	}
+	if providerID != nil {
+		user.Provider.ProviderID = *providerID
+	}
+	if providerUsername != nil {
+		user.Provider.Username = *providerUsername
+	}
+
+	// Parse JSON fields
+	if err := json.Unmarshal([]byte(rolesJSON), &user.Roles); err != nil {
+		return User{}, fmt.Errorf("failed to unmarshal roles: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(teamsJSON), &user.Teams); err != nil {
+		return User{}, fmt.Errorf("failed to unmarshal teams: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(preferencesJSON), &user.Preferences); err != nil {
+		return User{}, fmt.Errorf("failed to unmarshal preferences: %w", err)
+	}
+
+	return user, nil
+}
+
+// marshalUserFields converts the JSONB fields of a user for storage
+func marshalUserFields(user User) (roles, teams, preferences string, err error) {
+	rolesJSON, err := json.Marshal(user.Roles)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	teamsJSON, err := json.Marshal(user.Teams)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal teams: %w", err)
+	}
+
+	preferencesJSON, err := json.Marshal(user.Preferences)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+
+	return string(rolesJSON), string(teamsJSON), string(preferencesJSON), nil
+}
+
+// nullString stores empty provider fields as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation, which for
+// users means another user in the tenant already has the email
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
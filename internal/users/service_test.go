@@ -0,0 +1,264 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aykay76/ai-idp/internal/database"
+	"github.com/aykay76/ai-idp/internal/testutils"
+	"github.com/aykay76/ai-idp/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_CreateUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	// Setup test database
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	t.Run("successful creation", func(t *testing.T) {
+		result, err := service.CreateUser(ctx, User{
+			TenantID:    tenant.ID,
+			Email:       " Dev@Company.com ",
+			Roles:       []string{"developer"},
+			Teams:       []string{"platform"},
+			Provider:    types.AuthProvider{Type: "github", ProviderID: "1234", Username: "dev"},
+			Preferences: map[string]string{"theme": "dark"},
+			CreatedBy:   "test-user",
+		})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, uuid.Nil, result.ID)
+		assert.Equal(t, "dev@company.com", result.Email)
+		assert.Equal(t, "dev@company.com", result.DisplayName, "display name defaults to the email")
+		assert.WithinDuration(t, time.Now(), result.CreatedAt, time.Minute)
+
+		stored, err := service.GetUser(ctx, tenant.ID, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tenant.ID, stored.TenantID)
+		assert.Equal(t, []string{"developer"}, stored.Roles)
+		assert.Equal(t, []string{"platform"}, stored.Teams)
+		assert.Equal(t, types.AuthProvider{Type: "github", ProviderID: "1234", Username: "dev"}, stored.Provider)
+		assert.Equal(t, map[string]string{"theme": "dark"}, stored.Preferences)
+		assert.Equal(t, "test-user", stored.CreatedBy)
+	})
+
+	t.Run("defaults empty collections", func(t *testing.T) {
+		result, err := service.CreateUser(ctx, User{TenantID: tenant.ID, Email: "empty@company.com"})
+		require.NoError(t, err)
+
+		stored, err := service.GetUser(ctx, tenant.ID, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{}, stored.Roles)
+		assert.Equal(t, []string{}, stored.Teams)
+		assert.Equal(t, map[string]string{}, stored.Preferences)
+		assert.Equal(t, types.AuthProvider{}, stored.Provider)
+	})
+
+	t.Run("email is unique per tenant", func(t *testing.T) {
+		_, err := service.CreateUser(ctx, User{TenantID: tenant.ID, Email: "DEV@company.com"})
+		assert.ErrorIs(t, err, ErrUserAlreadyExists)
+
+		other := testutils.SetupTestTenant(t, ctx, pool)
+		_, err = service.CreateUser(ctx, User{TenantID: other.ID, Email: "dev@company.com"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		_, err := service.CreateUser(ctx, User{TenantID: tenant.ID})
+		assert.ErrorIs(t, err, ErrInvalidUserData)
+	})
+
+	t.Run("missing tenant", func(t *testing.T) {
+		_, err := service.CreateUser(ctx, User{Email: "no-tenant@company.com"})
+		assert.ErrorIs(t, err, ErrInvalidUserData)
+	})
+}
+
+func TestUserService_GetUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	other := testutils.SetupTestTenant(t, ctx, pool)
+
+	created, err := service.CreateUser(ctx, User{TenantID: tenant.ID, Email: "get@company.com"})
+	require.NoError(t, err)
+
+	t.Run("found in its tenant", func(t *testing.T) {
+		got, err := service.GetUser(ctx, tenant.ID, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, got.ID)
+	})
+
+	t.Run("not found in another tenant", func(t *testing.T) {
+		_, err := service.GetUser(ctx, other.ID, created.ID)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := service.GetUser(ctx, tenant.ID, uuid.New())
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+	other := testutils.SetupTestTenant(t, ctx, pool)
+
+	_, err := service.CreateUser(ctx, User{TenantID: other.ID, Email: "other-tenant@company.com", Roles: []string{"admin"}, CreatedBy: "list-test"})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		user := User{
+			TenantID:  tenant.ID,
+			Email:     fmt.Sprintf("list-user-%d@company.com", i),
+			Roles:     []string{"developer"},
+			Teams:     []string{"platform"},
+			CreatedBy: "list-test",
+		}
+		if i%2 == 0 {
+			user.Roles = append(user.Roles, "admin")
+			user.Teams = []string{"payments"}
+		}
+		_, err := service.CreateUser(ctx, user)
+		require.NoError(t, err)
+	}
+
+	t.Run("filters by role and team", func(t *testing.T) {
+		admins, total, err := service.ListUsers(ctx, ListUsersRequest{TenantID: tenant.ID, Role: "admin", CreatedBy: "list-test", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Len(t, admins, 3)
+
+		platform, total, err := service.ListUsers(ctx, ListUsersRequest{TenantID: tenant.ID, Team: "platform", CreatedBy: "list-test", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Len(t, platform, 2)
+	})
+
+	t.Run("cursor pages through every user once", func(t *testing.T) {
+		seen := map[uuid.UUID]bool{}
+		req := ListUsersRequest{TenantID: tenant.ID, CreatedBy: "list-test", Limit: 2}
+		for {
+			page, total, err := service.ListUsers(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, 5, total)
+			if len(page) == 0 {
+				break
+			}
+			for _, user := range page {
+				assert.False(t, seen[user.ID], "user returned twice")
+				seen[user.ID] = true
+			}
+			last := page[len(page)-1]
+			req.After = &database.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		assert.Len(t, seen, 5)
+	})
+}
+
+func TestUserService_UpdateUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	created, err := service.CreateUser(ctx, User{TenantID: tenant.ID, Email: "update@company.com", CreatedBy: "creator"})
+	require.NoError(t, err)
+	_, err = service.CreateUser(ctx, User{TenantID: tenant.ID, Email: "taken@company.com"})
+	require.NoError(t, err)
+
+	t.Run("successful update", func(t *testing.T) {
+		updated, err := service.UpdateUser(ctx, User{
+			ID:          created.ID,
+			TenantID:    tenant.ID,
+			Email:       "update@company.com",
+			DisplayName: "Updated",
+			Roles:       []string{"admin"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, tenant.ID, updated.TenantID)
+		assert.Equal(t, "creator", updated.CreatedBy)
+		require.NotNil(t, updated.UpdatedBy)
+
+		stored, err := service.GetUser(ctx, tenant.ID, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated", stored.DisplayName)
+		assert.Equal(t, []string{"admin"}, stored.Roles)
+	})
+
+	t.Run("email taken by another user", func(t *testing.T) {
+		_, err := service.UpdateUser(ctx, User{ID: created.ID, TenantID: tenant.ID, Email: "taken@company.com"})
+		assert.ErrorIs(t, err, ErrUserAlreadyExists)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		_, err := service.UpdateUser(ctx, User{ID: uuid.New(), TenantID: tenant.ID, Email: "missing@company.com"})
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("users of another tenant are not found", func(t *testing.T) {
+		other := testutils.SetupTestTenant(t, ctx, pool)
+		_, err := service.UpdateUser(ctx, User{ID: created.ID, TenantID: other.ID, Email: "update@company.com"})
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+func TestUserService_DeleteUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pool, cleanup := testutils.SetupTestDB(t, ctx)
+	defer cleanup()
+
+	service := NewService(pool)
+	tenant := testutils.SetupTestTenant(t, ctx, pool)
+
+	created, err := service.CreateUser(ctx, User{TenantID: tenant.ID, Email: "delete@company.com"})
+	require.NoError(t, err)
+
+	other := testutils.SetupTestTenant(t, ctx, pool)
+	assert.ErrorIs(t, service.DeleteUser(ctx, other.ID, created.ID), ErrUserNotFound, "users of another tenant are not deleted")
+
+	require.NoError(t, service.DeleteUser(ctx, tenant.ID, created.ID))
+
+	_, err = service.GetUser(ctx, tenant.ID, created.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	assert.ErrorIs(t, service.DeleteUser(ctx, tenant.ID, created.ID), ErrUserNotFound)
+}
@@ -0,0 +1,93 @@
+// Package validation checks request structs against their validate struct tags and
+// reports failures as server.ValidationErrors, naming each field as it appears in JSON.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is safe for concurrent use and caches struct metadata, so it is shared
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Struct validates s against its validate tags. It returns nil or server.ValidationErrors
+// with one entry per failing field.
+func Struct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	errs := make(server.ValidationErrors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		errs = append(errs, fieldError(fe))
+	}
+	return errs
+}
+
+// fieldError describes a single failed check
+func fieldError(fe validator.FieldError) server.ValidationError {
+	// The namespace starts with the struct's type name, e.g. "CreateApplicationRequest.name"
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+	if field == "" {
+		field = fe.Field()
+	}
+
+	ve := server.ValidationError{Field: field, Message: message(field, fe)}
+	if fe.Tag() != "required" {
+		ve.Value = fmt.Sprint(fe.Value())
+	}
+	return ve
+}
+
+// message returns a human-readable message for a failed check
+func message(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(strings.Fields(fe.Param()), ", "))
+	case "alphanum":
+		return fmt.Sprintf("%s must contain only letters and digits", field)
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("%s must be %s %s characters", field, bound, fe.Param())
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("%s must have %s %s items", field, bound, fe.Param())
+		}
+		return fmt.Sprintf("%s must be %s %s", field, bound, fe.Param())
+	}
+	return fmt.Sprintf("%s failed the %s check", field, fe.Tag())
+}
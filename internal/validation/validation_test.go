@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/aykay76/ai-idp/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOwner struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type testRequest struct {
+	Name      string     `json:"name" validate:"required,min=1,max=10"`
+	Email     string     `json:"owner_email" validate:"required,email"`
+	Lifecycle string     `json:"lifecycle" validate:"required,oneof=development staging production deprecated"`
+	Team      *string    `json:"team,omitempty" validate:"omitempty,min=2"`
+	Owner     *testOwner `json:"owner,omitempty"`
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestStruct(t *testing.T) {
+	valid := testRequest{Name: "orders", Email: "owner@company.com", Lifecycle: "production"}
+
+	tests := []struct {
+		name   string
+		modify func(r *testRequest)
+		want   server.ValidationErrors
+	}{
+		{
+			name:   "valid",
+			modify: func(r *testRequest) {},
+		},
+		{
+			name:   "missing required fields",
+			modify: func(r *testRequest) { r.Name, r.Email = "", "" },
+			want: server.ValidationErrors{
+				{Field: "name", Message: "name is required"},
+				{Field: "owner_email", Message: "owner_email is required"},
+			},
+		},
+		{
+			name:   "bad email",
+			modify: func(r *testRequest) { r.Email = "not-an-email" },
+			want: server.ValidationErrors{
+				{Field: "owner_email", Message: "owner_email must be a valid email address", Value: "not-an-email"},
+			},
+		},
+		{
+			name:   "invalid enum value",
+			modify: func(r *testRequest) { r.Lifecycle = "prod" },
+			want: server.ValidationErrors{
+				{Field: "lifecycle", Message: "lifecycle must be one of: development, staging, production, deprecated", Value: "prod"},
+			},
+		},
+		{
+			name:   "too long",
+			modify: func(r *testRequest) { r.Name = "much-too-long-name" },
+			want: server.ValidationErrors{
+				{Field: "name", Message: "name must be at most 10 characters", Value: "much-too-long-name"},
+			},
+		},
+		{
+			name:   "optional pointer is checked when set",
+			modify: func(r *testRequest) { r.Team = stringPtr("a") },
+			want: server.ValidationErrors{
+				{Field: "team", Message: "team must be at least 2 characters", Value: "a"},
+			},
+		},
+		{
+			name:   "nested fields use their JSON path",
+			modify: func(r *testRequest) { r.Owner = &testOwner{Email: "nope"} },
+			want: server.ValidationErrors{
+				{Field: "owner.email", Message: "owner.email must be a valid email address", Value: "nope"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			err := Struct(req)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var errs server.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			assert.Equal(t, tt.want, errs)
+		})
+	}
+}
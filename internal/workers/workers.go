@@ -0,0 +1,166 @@
+// Package workers tracks the liveness of background workers through heartbeats, so
+// a worker that has died or stalled shows up in health reporting instead of leaving
+// the service looking healthy while work piles up.
+package workers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// staleFactor is how many expected intervals may pass without a heartbeat before a
+// worker is reported unhealthy, allowing for a slow run
+const staleFactor = 2
+
+// WorkerStatus reports a worker's last heartbeat and whether it is overdue
+type WorkerStatus struct {
+	Name     string     `json:"name"`
+	Interval string     `json:"interval"`
+	LastRun  *time.Time `json:"last_run,omitempty"`
+	Healthy  bool       `json:"healthy"`
+	Critical bool       `json:"critical"`
+}
+
+// Option configures a registered worker
+type Option func(*worker)
+
+// Critical marks a worker whose stall makes the service not ready
+func Critical() Option {
+	return func(w *worker) {
+		w.critical = true
+	}
+}
+
+// WithStaleAfter overrides how long a worker may go without a heartbeat before it is
+// reported unhealthy; the default is twice its interval
+func WithStaleAfter(d time.Duration) Option {
+	return func(w *worker) {
+		w.staleAfter = d
+	}
+}
+
+type worker struct {
+	interval   time.Duration
+	staleAfter time.Duration
+	critical   bool
+	registered time.Time
+	lastRun    time.Time
+}
+
+// Registry holds the heartbeats of a service's background workers
+type Registry struct {
+	mu      sync.Mutex
+	workers map[string]*worker
+	now     func() time.Time
+}
+
+// NewRegistry creates an empty worker registry
+func NewRegistry() *Registry {
+	return &Registry{
+		workers: make(map[string]*worker),
+		now:     time.Now,
+	}
+}
+
+// Heartbeat records runs of a single registered worker
+type Heartbeat struct {
+	registry *Registry
+	name     string
+}
+
+// Register starts tracking a worker expected to run every interval. Until its first
+// heartbeat the worker is measured from the time it was registered.
+func (r *Registry) Register(name string, interval time.Duration, opts ...Option) *Heartbeat {
+	w := &worker{interval: interval, staleAfter: staleFactor * interval}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	r.mu.Lock()
+	w.registered = r.now()
+	r.workers[name] = w
+	r.mu.Unlock()
+
+	return &Heartbeat{registry: r, name: name}
+}
+
+// Beat records that the worker has just run. It is safe to call on a nil Heartbeat.
+func (h *Heartbeat) Beat() {
+	if h == nil {
+		return
+	}
+
+	r := h.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w, ok := r.workers[h.name]; ok {
+		w.lastRun = r.now()
+	}
+}
+
+// Status reports every registered worker, ordered by name
+func (r *Registry) Status() []WorkerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	statuses := make([]WorkerStatus, 0, len(r.workers))
+	for name, w := range r.workers {
+		status := WorkerStatus{
+			Name:     name,
+			Interval: w.interval.String(),
+			Critical: w.critical,
+		}
+
+		since := w.registered
+		if !w.lastRun.IsZero() {
+			lastRun := w.lastRun
+			status.LastRun = &lastRun
+			since = lastRun
+		}
+		status.Healthy = now.Sub(since) <= w.staleAfter
+
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Ready reports whether every critical worker is healthy. A nil Registry is ready.
+func (r *Registry) Ready() bool {
+	if r == nil {
+		return true
+	}
+
+	for _, status := range r.Status() {
+		if status.Critical && !status.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler serves the worker statuses as JSON, with 503 when a critical worker is
+// unhealthy
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		statuses := r.Status()
+
+		code := http.StatusOK
+		for _, status := range statuses {
+			if status.Critical && !status.Healthy {
+				code = http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"workers": statuses})
+	}
+}
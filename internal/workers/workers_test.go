@@ -0,0 +1,84 @@
+package workers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry() (*Registry, *time.Time) {
+	clock := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRegistry()
+	r.now = func() time.Time { return clock }
+	return r, &clock
+}
+
+func TestRegistry_StaleWorker(t *testing.T) {
+	registry, clock := newTestRegistry()
+	gc := registry.Register("application-gc", time.Minute, Critical())
+
+	t.Run("new worker is healthy", func(t *testing.T) {
+		statuses := registry.Status()
+		require.Len(t, statuses, 1)
+		assert.True(t, statuses[0].Healthy)
+		assert.Nil(t, statuses[0].LastRun)
+		assert.True(t, registry.Ready())
+	})
+
+	t.Run("heartbeating worker stays healthy", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			*clock = clock.Add(time.Minute)
+			gc.Beat()
+		}
+
+		status := registry.Status()[0]
+		assert.True(t, status.Healthy)
+		require.NotNil(t, status.LastRun)
+		assert.Equal(t, *clock, *status.LastRun)
+	})
+
+	t.Run("worker that stops heartbeating is unhealthy after the threshold", func(t *testing.T) {
+		*clock = clock.Add(2 * time.Minute)
+		assert.True(t, registry.Status()[0].Healthy, "within twice the interval")
+
+		*clock = clock.Add(time.Second)
+		assert.False(t, registry.Status()[0].Healthy)
+		assert.False(t, registry.Ready())
+
+		rr := httptest.NewRecorder()
+		registry.Handler()(rr, httptest.NewRequest(http.MethodGet, "/admin/workers", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+		var body struct {
+			Workers []WorkerStatus `json:"workers"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		require.Len(t, body.Workers, 1)
+		assert.Equal(t, "application-gc", body.Workers[0].Name)
+		assert.False(t, body.Workers[0].Healthy)
+	})
+
+	t.Run("worker recovers on its next heartbeat", func(t *testing.T) {
+		gc.Beat()
+		assert.True(t, registry.Ready())
+	})
+}
+
+func TestRegistry_NonCriticalWorker(t *testing.T) {
+	registry, clock := newTestRegistry()
+	registry.Register("outbox", time.Second, WithStaleAfter(10*time.Second))
+
+	*clock = clock.Add(11 * time.Second)
+
+	assert.False(t, registry.Status()[0].Healthy)
+	assert.True(t, registry.Ready(), "only critical workers affect readiness")
+
+	rr := httptest.NewRecorder()
+	registry.Handler()(rr, httptest.NewRequest(http.MethodGet, "/admin/workers", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,6 @@
+-- Remove retirement date from applications
+
+DROP INDEX IF EXISTS resource_management.idx_applications_retirement_date;
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN IF EXISTS retirement_date;
@@ -0,0 +1,9 @@
+-- Add retirement date to applications
+-- Deprecated applications keep serving reads until the retirement grace period
+-- has passed, after which the garbage collector purges them
+
+ALTER TABLE resource_management.applications
+    ADD COLUMN retirement_date TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_applications_retirement_date ON resource_management.applications(retirement_date)
+    WHERE retirement_date IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Remove application resource tracking
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN IF EXISTS resources;
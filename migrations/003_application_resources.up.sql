@@ -0,0 +1,5 @@
+-- Track resources declared by applications together with their provisioning status
+-- Stored as an array of {spec, status} objects
+
+ALTER TABLE resource_management.applications
+    ADD COLUMN resources JSONB NOT NULL DEFAULT '[]';
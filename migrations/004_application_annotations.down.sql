@@ -0,0 +1,4 @@
+-- Remove application annotations
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN IF EXISTS annotations;
@@ -0,0 +1,5 @@
+-- Add annotations to applications
+-- Keys in the reserved aiidp.io/ namespace toggle platform behavior
+
+ALTER TABLE resource_management.applications
+    ADD COLUMN annotations JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+-- Remove application environment variable management
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN IF EXISTS environment;
@@ -0,0 +1,5 @@
+-- Track environment variables managed separately from the deployment spec
+-- Stored as an array of {name, value} or {name, secret_ref} objects
+
+ALTER TABLE resource_management.applications
+    ADD COLUMN environment JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,6 @@
+-- Remove actor indexes
+
+DROP INDEX IF EXISTS resource_management.idx_teams_updated_by;
+DROP INDEX IF EXISTS resource_management.idx_teams_created_by;
+DROP INDEX IF EXISTS resource_management.idx_applications_updated_by;
+DROP INDEX IF EXISTS resource_management.idx_applications_created_by;
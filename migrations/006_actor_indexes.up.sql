@@ -0,0 +1,6 @@
+-- Support audit queries such as "what did user X create or change"
+
+CREATE INDEX idx_applications_created_by ON resource_management.applications(tenant_id, created_by);
+CREATE INDEX idx_applications_updated_by ON resource_management.applications(tenant_id, updated_by);
+CREATE INDEX idx_teams_created_by ON resource_management.teams(created_by);
+CREATE INDEX idx_teams_updated_by ON resource_management.teams(updated_by);
@@ -0,0 +1,6 @@
+-- Remove derived application name uniqueness
+
+DROP INDEX IF EXISTS resource_management.idx_applications_derived_name;
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN IF EXISTS derived_name;
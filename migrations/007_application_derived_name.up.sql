@@ -0,0 +1,15 @@
+-- Enforce uniqueness on the identifier derived from an application's name. Names that
+-- differ only in characters removed by sanitization map to the same database
+-- identifier and Kubernetes namespace, so they must not coexist within a tenant.
+
+ALTER TABLE resource_management.applications
+    ADD COLUMN derived_name VARCHAR(63);
+
+-- Backfill using the same rules as applications.DeriveName, which trims dashes again
+-- after truncating so a derived name never ends in one
+UPDATE resource_management.applications
+SET derived_name = rtrim(left(trim(both '-' from regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')), 63), '-');
+
+-- Fails if existing applications already collide; rename one of them and re-run
+CREATE UNIQUE INDEX idx_applications_derived_name
+    ON resource_management.applications(tenant_id, derived_name);
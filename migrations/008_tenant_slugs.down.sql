@@ -0,0 +1,7 @@
+-- Remove tenant slugs
+
+DROP INDEX IF EXISTS control_plane.idx_tenants_slug;
+
+ALTER TABLE control_plane.tenants
+    DROP CONSTRAINT IF EXISTS valid_slug,
+    DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,27 @@
+-- URL-safe tenant slugs for friendly paths such as /t/acme/...
+-- Slugs default to a value derived from the tenant name but can be edited later.
+
+ALTER TABLE control_plane.tenants
+    ADD COLUMN slug VARCHAR(63);
+
+-- Backfill using the same rules as database.GenerateSlug, truncated to leave room for
+-- a numeric suffix that disambiguates names deriving the same slug, in creation order.
+WITH derived AS (
+    SELECT id, created_at,
+           COALESCE(NULLIF(trim(both '-' from left(trim(both '-' from
+               regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')), 56)), ''), 'tenant') AS base
+    FROM control_plane.tenants
+), numbered AS (
+    SELECT id, base, row_number() OVER (PARTITION BY base ORDER BY created_at, id) AS n
+    FROM derived
+)
+UPDATE control_plane.tenants t
+SET slug = CASE WHEN numbered.n = 1 THEN numbered.base ELSE numbered.base || '-' || numbered.n END
+FROM numbered
+WHERE t.id = numbered.id;
+
+ALTER TABLE control_plane.tenants
+    ALTER COLUMN slug SET NOT NULL,
+    ADD CONSTRAINT valid_slug CHECK (slug ~ '^[a-z0-9]([a-z0-9-]*[a-z0-9])?$');
+
+CREATE UNIQUE INDEX idx_tenants_slug ON control_plane.tenants(slug);
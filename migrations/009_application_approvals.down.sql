@@ -0,0 +1,14 @@
+-- Remove the application approval workflow
+
+DROP INDEX IF EXISTS resource_management.idx_applications_pending_approval;
+
+-- Applications still awaiting a decision fall back to the states the old constraint allows
+UPDATE resource_management.applications SET status = 'pending' WHERE status = 'pending-approval';
+UPDATE resource_management.applications SET status = 'failed' WHERE status = 'rejected';
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN IF EXISTS review_comment,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP CONSTRAINT valid_status,
+    ADD CONSTRAINT valid_status CHECK (status IN ('pending', 'running', 'failed', 'terminating'));
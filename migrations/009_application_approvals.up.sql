@@ -0,0 +1,13 @@
+-- Approval workflow for applications
+-- Production applications of teams without auto-approval start out pending approval
+-- until a team owner or maintainer approves or rejects them
+
+ALTER TABLE resource_management.applications
+    DROP CONSTRAINT valid_status,
+    ADD CONSTRAINT valid_status CHECK (status IN ('pending-approval', 'rejected', 'pending', 'running', 'failed', 'terminating')),
+    ADD COLUMN reviewed_by VARCHAR(255),
+    ADD COLUMN reviewed_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN review_comment TEXT;
+
+CREATE INDEX idx_applications_pending_approval ON resource_management.applications(tenant_id, team_name)
+    WHERE status = 'pending-approval';
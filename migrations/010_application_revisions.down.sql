@@ -0,0 +1,3 @@
+-- Remove application change history
+
+DROP TABLE IF EXISTS resource_management.application_revisions;
@@ -0,0 +1,18 @@
+-- Change history for applications
+-- Every create and update stores a snapshot of the application as a numbered
+-- revision, so reviewers can see what changed between any two revisions
+
+CREATE TABLE resource_management.application_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES control_plane.tenants(id) ON DELETE CASCADE,
+    application_id UUID NOT NULL REFERENCES resource_management.applications(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    changed_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT unique_application_revision UNIQUE (application_id, revision),
+    CONSTRAINT positive_revision CHECK (revision > 0)
+);
+
+CREATE INDEX idx_application_revisions_tenant ON resource_management.application_revisions(tenant_id, application_id);
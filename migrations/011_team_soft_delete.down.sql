@@ -0,0 +1,8 @@
+-- Remove team soft delete; soft-deleted teams are purged
+
+DROP INDEX IF EXISTS resource_management.idx_teams_active;
+
+DELETE FROM resource_management.teams WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE resource_management.teams
+    DROP COLUMN IF EXISTS deleted_at;
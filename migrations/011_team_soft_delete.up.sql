@@ -0,0 +1,9 @@
+-- Soft delete for teams
+-- Deleted teams keep their row, and so their name, audit history and the references
+-- applications hold to them, until they are restored
+
+ALTER TABLE resource_management.teams
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_teams_active ON resource_management.teams(tenant_id, name)
+    WHERE deleted_at IS NULL;
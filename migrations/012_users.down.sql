@@ -0,0 +1,5 @@
+-- Remove tenant-scoped platform users
+
+DROP TRIGGER IF EXISTS update_resource_users_updated_at ON resource_management.users;
+
+DROP TABLE IF EXISTS resource_management.users;
@@ -0,0 +1,36 @@
+-- Platform users (tenant-scoped)
+-- Unlike user_management.users, which holds login accounts across tenants, these rows
+-- describe a user's profile, roles and team memberships within one tenant
+
+CREATE TABLE resource_management.users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES control_plane.tenants(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    display_name VARCHAR(255) NOT NULL,
+    avatar_url VARCHAR(500),
+
+    -- Access and membership
+    roles JSONB NOT NULL DEFAULT '[]',
+    teams JSONB NOT NULL DEFAULT '[]',
+
+    -- Authentication provider info
+    provider_type VARCHAR(50),
+    provider_id VARCHAR(255),
+    provider_username VARCHAR(255),
+
+    -- User preferences and settings
+    preferences JSONB NOT NULL DEFAULT '{}',
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_by VARCHAR(255) NOT NULL,
+    updated_by VARCHAR(255),
+
+    UNIQUE(tenant_id, email)
+);
+
+CREATE INDEX idx_users_tenant ON resource_management.users(tenant_id);
+CREATE INDEX idx_users_teams ON resource_management.users USING GIN(teams);
+
+CREATE TRIGGER update_resource_users_updated_at BEFORE UPDATE ON resource_management.users
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
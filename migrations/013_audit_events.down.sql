@@ -0,0 +1,3 @@
+-- Remove structured audit events
+
+DROP TABLE IF EXISTS audit_system.audit_events;
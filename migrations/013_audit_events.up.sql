@@ -0,0 +1,33 @@
+-- Structured audit events for write operations
+-- Rows mirror types.AuditEvent; they are kept when the tenant named in
+-- resource_namespace is deleted so the trail outlives the resources it describes
+
+CREATE TABLE audit_system.audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    -- Who performed the action
+    actor_type VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL,
+    actor_name VARCHAR(255),
+    actor_email VARCHAR(255),
+
+    -- What was done, and to which resource
+    action VARCHAR(100) NOT NULL,
+    resource_api_version VARCHAR(50) NOT NULL,
+    resource_kind VARCHAR(100) NOT NULL,
+    resource_name VARCHAR(255) NOT NULL,
+    resource_namespace VARCHAR(255),
+    resource_uid VARCHAR(255),
+
+    -- Outcome and request context
+    result VARCHAR(20) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(100)
+);
+
+CREATE INDEX idx_audit_events_occurred ON audit_system.audit_events(occurred_at DESC);
+CREATE INDEX idx_audit_events_actor ON audit_system.audit_events(actor_id, occurred_at DESC);
+CREATE INDEX idx_audit_events_resource ON audit_system.audit_events(resource_kind, resource_uid);
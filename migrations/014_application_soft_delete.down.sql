@@ -0,0 +1,15 @@
+-- Remove application soft delete; terminated applications are purged
+
+DELETE FROM resource_management.applications WHERE status = 'terminated';
+
+DROP INDEX resource_management.idx_applications_derived_name;
+CREATE UNIQUE INDEX idx_applications_derived_name
+    ON resource_management.applications(tenant_id, derived_name);
+
+DROP INDEX resource_management.idx_applications_name;
+ALTER TABLE resource_management.applications
+    ADD CONSTRAINT applications_tenant_id_name_key UNIQUE (tenant_id, name);
+
+ALTER TABLE resource_management.applications
+    DROP CONSTRAINT valid_status,
+    ADD CONSTRAINT valid_status CHECK (status IN ('pending-approval', 'rejected', 'pending', 'running', 'failed', 'terminating'));
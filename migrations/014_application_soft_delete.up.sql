@@ -0,0 +1,19 @@
+-- Soft delete for applications
+-- Deleted applications are marked terminated and keep their row, so that their audit
+-- history and revisions remain readable. Names are only unique among live
+-- applications, so a deleted application's name can be used again.
+
+ALTER TABLE resource_management.applications
+    DROP CONSTRAINT valid_status,
+    ADD CONSTRAINT valid_status CHECK (status IN ('pending-approval', 'rejected', 'pending', 'running', 'failed', 'terminating', 'terminated'));
+
+ALTER TABLE resource_management.applications
+    DROP CONSTRAINT applications_tenant_id_name_key;
+CREATE UNIQUE INDEX idx_applications_name
+    ON resource_management.applications(tenant_id, name)
+    WHERE status <> 'terminated';
+
+DROP INDEX resource_management.idx_applications_derived_name;
+CREATE UNIQUE INDEX idx_applications_derived_name
+    ON resource_management.applications(tenant_id, derived_name)
+    WHERE status <> 'terminated';
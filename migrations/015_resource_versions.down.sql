@@ -0,0 +1,7 @@
+-- Remove resource versions
+
+ALTER TABLE resource_management.applications
+    DROP COLUMN version;
+
+ALTER TABLE resource_management.teams
+    DROP COLUMN version;
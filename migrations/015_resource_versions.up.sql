@@ -0,0 +1,10 @@
+-- Resource versions for optimistic concurrency
+-- Every update bumps the version; clients read it as an ETag and send it back in
+-- If-Match, so an update based on a stale read is refused instead of overwriting
+-- a concurrent change
+
+ALTER TABLE resource_management.teams
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE resource_management.applications
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- Remove refresh tokens
+
+DROP TABLE IF EXISTS user_management.refresh_tokens;
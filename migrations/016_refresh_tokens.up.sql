@@ -0,0 +1,22 @@
+-- Refresh tokens issued at login
+-- Only a SHA-256 hash of each token is stored. Refreshing rotates the token: the old
+-- row is marked rotated and a new one joins the same family, so presenting a rotated
+-- token again reveals a stolen copy and revokes the whole family
+
+CREATE TABLE user_management.refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    family_id UUID NOT NULL,
+
+    -- Who the token signs in; subject is the access token's sub claim
+    subject VARCHAR(255) NOT NULL,
+    tenant_id UUID REFERENCES control_plane.tenants(id) ON DELETE CASCADE,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    rotated_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_refresh_tokens_family ON user_management.refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_subject ON user_management.refresh_tokens(subject);